@@ -0,0 +1,265 @@
+// Package artifacts stores small files attached to the commits and releases
+// of repositories, such as checksums, SBOMs, and signed provenance.
+//
+// Artifacts are stored content-addressed by their SHA-256 digest, so the same
+// file attached many times is only stored once. Each repository has an index
+// of its attachments in a JSON file.
+package artifacts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxSize is the maximum size of an artifact.
+const MaxSize = 10 << 20
+
+var (
+	// ErrNotFound is returned when an artifact doesn't exist.
+	ErrNotFound = errors.New("artifact not found")
+	// ErrTooLarge is returned when an artifact is larger than MaxSize.
+	ErrTooLarge = errors.New("artifact too large")
+	// ErrInvalidName is returned when an artifact name is invalid.
+	ErrInvalidName = errors.New("invalid artifact name")
+)
+
+// Artifact is a file attached to a commit or release of a repository.
+type Artifact struct {
+	// Name is the file name of the artifact, unique per commit or release.
+	Name string `json:"name"`
+	// Commit is the hash of the commit the artifact is attached to.
+	Commit string `json:"commit"`
+	// Tag is the name of the release tag the artifact is attached to, if
+	// any.
+	Tag string `json:"tag,omitempty"`
+	// Digest is the hex-encoded SHA-256 digest of the content.
+	Digest   string    `json:"digest"`
+	Size     int64     `json:"size"`
+	Uploader string    `json:"uploader,omitempty"`
+	Created  time.Time `json:"created"`
+}
+
+// Store stores artifacts in a directory. It's safe for concurrent use.
+type Store struct {
+	mtx sync.Mutex
+	dir string
+}
+
+// NewStore creates a store of the artifacts in dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// ValidName returns whether name is a valid artifact name, a file name
+// without path separators.
+func ValidName(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		!strings.ContainsAny(name, "/\\\x00") && len(name) <= 255
+}
+
+// Put stores the content read from r as an artifact, replacing the artifact
+// with the same name attached to the same commit and tag.
+func (s *Store) Put(repo string, a Artifact, r io.Reader) (Artifact, error) {
+	if !ValidName(a.Name) {
+		return Artifact{}, ErrInvalidName
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, "objects"), 0o700); err != nil {
+		return Artifact{}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Join(s.dir, "objects"), ".upload-")
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, MaxSize+1))
+	if err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return Artifact{}, err
+	}
+	if n > MaxSize {
+		return Artifact{}, ErrTooLarge
+	}
+	a.Digest = hex.EncodeToString(h.Sum(nil))
+	a.Size = n
+	if a.Created.IsZero() {
+		a.Created = time.Now().UTC()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	obj := s.objectPath(a.Digest)
+	if _, err := os.Stat(obj); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(obj), 0o700); err != nil {
+			return Artifact{}, err
+		}
+		if err := os.Rename(tmp.Name(), obj); err != nil {
+			return Artifact{}, err
+		}
+	}
+	idx, err := s.index(repo)
+	if err != nil {
+		return Artifact{}, err
+	}
+	replaced := ""
+	for i, e := range idx {
+		if e.Name == a.Name && e.Commit == a.Commit && e.Tag == a.Tag {
+			replaced = e.Digest
+			idx = append(idx[:i], idx[i+1:]...)
+			break
+		}
+	}
+	idx = append(idx, a)
+	if err := s.saveIndex(repo, idx); err != nil {
+		return Artifact{}, err
+	}
+	if replaced != "" && replaced != a.Digest {
+		if err := s.prune(replaced); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// List returns the artifacts of a repo, newest first.
+func (s *Store) List(repo string) ([]Artifact, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	idx, err := s.index(repo)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return idx[i].Created.After(idx[j].Created)
+	})
+	return idx, nil
+}
+
+// Open opens the content of an artifact of a repo with the given digest.
+// Only the artifacts of the repo can be opened, even if other repos have
+// artifacts with the same content.
+func (s *Store) Open(repo, digest string) (*os.File, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	idx, err := s.index(repo)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range idx {
+		if a.Digest == digest {
+			f, err := os.Open(s.objectPath(digest))
+			if os.IsNotExist(err) {
+				return nil, ErrNotFound
+			}
+			return f, err
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Delete removes the artifact with the given name attached to a commit and
+// tag of a repo. Its content is removed once no artifact refers to it.
+func (s *Store) Delete(repo, commit, tag, name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	idx, err := s.index(repo)
+	if err != nil {
+		return err
+	}
+	for i, a := range idx {
+		if a.Name == name && a.Commit == commit && a.Tag == tag {
+			if err := s.saveIndex(repo, append(idx[:i], idx[i+1:]...)); err != nil {
+				return err
+			}
+			return s.prune(a.Digest)
+		}
+	}
+	return ErrNotFound
+}
+
+// prune removes the content with the given digest if no artifact refers to
+// it anymore. The caller must hold the lock.
+func (s *Store) prune(digest string) error {
+	des, err := os.ReadDir(filepath.Join(s.dir, "index"))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, de := range des {
+		repo := strings.TrimSuffix(de.Name(), ".json")
+		idx, err := s.index(repo)
+		if err != nil {
+			return err
+		}
+		for _, a := range idx {
+			if a.Digest == digest {
+				return nil
+			}
+		}
+	}
+	if err := os.Remove(s.objectPath(digest)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *Store) objectPath(digest string) string {
+	return filepath.Join(s.dir, "objects", digest[:2], digest[2:])
+}
+
+func (s *Store) indexPath(repo string) string {
+	return filepath.Join(s.dir, "index", repo+".json")
+}
+
+// index reads the index of a repo. The caller must hold the lock.
+func (s *Store) index(repo string) ([]Artifact, error) {
+	bts, err := os.ReadFile(s.indexPath(repo))
+	if os.IsNotExist(err) {
+		return []Artifact{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	idx := make([]Artifact, 0)
+	if err := json.Unmarshal(bts, &idx); err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// saveIndex writes the index of a repo. The caller must hold the lock.
+func (s *Store) saveIndex(repo string, idx []Artifact) error {
+	p := s.indexPath(repo)
+	if len(idx) == 0 {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	bts, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so a failed write doesn't lose the
+	// existing index.
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
@@ -0,0 +1,81 @@
+package artifacts
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStore(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	s := NewStore(dir)
+	commit := strings.Repeat("a", 40)
+
+	a, err := s.Put("repo", Artifact{Name: "sums.txt", Commit: commit}, strings.NewReader("sums"))
+	is.NoErr(err)
+	is.Equal(a.Size, int64(4))
+	is.Equal(len(a.Digest), 64)
+	is.True(!a.Created.IsZero())
+
+	// The same content is stored once.
+	b, err := s.Put("other", Artifact{Name: "copy.txt", Commit: commit, Tag: "v1"}, strings.NewReader("sums"))
+	is.NoErr(err)
+	is.Equal(b.Digest, a.Digest)
+	objs, err := filepath.Glob(filepath.Join(dir, "objects", "*", "*"))
+	is.NoErr(err)
+	is.Equal(len(objs), 1)
+
+	f, err := s.Open("repo", a.Digest)
+	is.NoErr(err)
+	bts, err := io.ReadAll(f)
+	is.NoErr(err)
+	is.NoErr(f.Close())
+	is.Equal(string(bts), "sums")
+
+	// Repos can only open their own artifacts.
+	_, err = s.Open("third", a.Digest)
+	is.Equal(err, ErrNotFound)
+
+	// Uploading the same name replaces the artifact.
+	_, err = s.Put("repo", Artifact{Name: "sums.txt", Commit: commit, Created: time.Now().Add(time.Hour)}, strings.NewReader("new sums"))
+	is.NoErr(err)
+	as, err := s.List("repo")
+	is.NoErr(err)
+	is.Equal(len(as), 1)
+	is.Equal(as[0].Size, int64(8))
+
+	// Content is removed once unreferenced.
+	is.NoErr(s.Delete("other", commit, "v1", "copy.txt"))
+	objs, err = filepath.Glob(filepath.Join(dir, "objects", "*", "*"))
+	is.NoErr(err)
+	is.Equal(len(objs), 1)
+	is.Equal(s.Delete("other", commit, "v1", "copy.txt"), ErrNotFound)
+}
+
+func TestStoreLimits(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	s := NewStore(dir)
+	_, err := s.Put("repo", Artifact{Name: "../x"}, strings.NewReader(""))
+	is.Equal(err, ErrInvalidName)
+	_, err = s.Put("repo", Artifact{Name: "big"}, io.LimitReader(zeros{}, MaxSize+1))
+	is.Equal(err, ErrTooLarge)
+	des, err := os.ReadDir(filepath.Join(dir, "objects"))
+	is.NoErr(err)
+	is.Equal(len(des), 0) // uploads are cleaned up
+}
+
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
@@ -0,0 +1,68 @@
+// Package audit records authentication attempts, repository lifecycle and
+// access changes, and admin commands to an append-only audit log, for
+// compliance on shared instances.
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/retention"
+)
+
+// Types are the types of the events recorded to the audit log.
+var Types = []events.Type{
+	events.AuthSuccess,
+	events.AuthFailure,
+	events.RepoCreated,
+	events.RepoDeleted,
+	events.RepoVisibility,
+	events.ConfigUpdated,
+	events.AdminCommand,
+	events.CollabExpired,
+	events.AccessRequested,
+	events.AccessApproved,
+	events.AccessDenied,
+}
+
+// Audited returns whether events of type t are recorded to the audit log.
+func Audited(t events.Type) bool {
+	for _, at := range Types {
+		if t == at {
+			return true
+		}
+	}
+	return false
+}
+
+// Log is the audit log of a server. Entries are appended to a file of JSON
+// lines per day in the audit-logs directory of the data path, where they're
+// kept as long as the audit-logs retention policy allows.
+type Log struct {
+	store *events.Store
+}
+
+// NewLog returns the audit log of the server with the given data path.
+func NewLog(dataPath string) *Log {
+	return &Log{store: events.NewStore(retention.Dir(dataPath, retention.AuditLogs))}
+}
+
+// Run records the audited events published on bus until ctx is done. Events
+// that can't be recorded are logged and dropped.
+func (l *Log) Run(ctx context.Context, bus *events.Bus) {
+	for e := range bus.SubscribeDurable(ctx) {
+		if !Audited(e.Type) {
+			continue
+		}
+		if err := l.store.Append(e); err != nil {
+			log.Error("error recording audit log entry", "type", e.Type, "err", err)
+		}
+	}
+}
+
+// Entries returns the entries recorded since the given time, oldest first.
+func (l *Log) Entries(since time.Time) ([]events.Event, error) {
+	return l.store.Events("", since)
+}
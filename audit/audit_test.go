@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/matryer/is"
+)
+
+func TestLogRun(t *testing.T) {
+	is := is.New(t)
+	l := NewLog(t.TempDir())
+	b := events.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		l.Run(ctx, b)
+		close(done)
+	}()
+	// Events published before Run subscribes aren't recorded, so publish
+	// until the first one is.
+	for {
+		b.Publish(events.Event{Type: events.AuthFailure, User: "foo", Method: "publickey"})
+		time.Sleep(10 * time.Millisecond)
+		es, err := l.Entries(time.Time{})
+		is.NoErr(err)
+		if len(es) > 0 {
+			break
+		}
+	}
+	b.Publish(events.Event{Type: events.Push, Repo: "foo"})
+	b.Publish(events.Event{Type: events.AdminCommand, User: "admin", Key: "SHA256:abc", Command: "reload"})
+	cancel()
+	<-done
+	es, err := l.Entries(time.Time{})
+	is.NoErr(err)
+	last := es[len(es)-1]
+	is.Equal(last.Type, events.AdminCommand) // push events aren't audited
+	is.Equal(last.Key, "SHA256:abc")
+	for _, e := range es {
+		is.True(Audited(e.Type))
+	}
+}
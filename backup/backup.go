@@ -0,0 +1,190 @@
+// Package backup periodically exports encrypted bundles of repositories to a
+// backup target, and verifies that backups can be restored. It also writes
+// and restores snapshots of whole servers.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// bundleExt is the extension of backup files.
+const bundleExt = ".bundle.enc"
+
+// ErrNoSecrets is returned when there is no secrets store to encrypt backups
+// with.
+var ErrNoSecrets = errors.New("backups need a secrets store to be encrypted")
+
+// Scheduler backs up repositories to a target.
+type Scheduler struct {
+	cfg    *config.Config
+	target Target
+	// Interval is the time between backups.
+	Interval time.Duration
+	// VerifyInterval is the time between restore verifications.
+	VerifyInterval time.Duration
+
+	mtx sync.Mutex
+	// last is the time each repo was last backed up.
+	last map[string]time.Time
+	// stored holds the repos with a backup file on the target. Empty repos
+	// don't have one.
+	stored map[string]struct{}
+}
+
+// NewScheduler creates a new backup scheduler.
+func NewScheduler(cfg *config.Config, target Target) (*Scheduler, error) {
+	if cfg.Secrets == nil {
+		return nil, ErrNoSecrets
+	}
+	return &Scheduler{
+		cfg:            cfg,
+		target:         target,
+		Interval:       24 * time.Hour,
+		VerifyInterval: 7 * 24 * time.Hour,
+		last:           make(map[string]time.Time),
+		stored:         make(map[string]struct{}),
+	}, nil
+}
+
+// Run backs up repositories and verifies backups at the scheduled intervals
+// until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	if err := s.BackupAll(ctx); err != nil {
+		log.Error("error backing up repositories", "err", err)
+	}
+	backup := time.NewTicker(s.Interval)
+	defer backup.Stop()
+	verify := time.NewTicker(s.VerifyInterval)
+	defer verify.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-backup.C:
+			if err := s.BackupAll(ctx); err != nil {
+				log.Error("error backing up repositories", "err", err)
+			}
+		case <-verify.C:
+			repos := s.backedUp()
+			if len(repos) == 0 {
+				continue
+			}
+			rn := repos[rand.Intn(len(repos))]
+			if err := s.Verify(ctx, rn); err != nil {
+				log.Error("backup verification failed", "repo", rn, "err", err)
+			} else {
+				log.Info("backup verified", "repo", rn)
+			}
+		}
+	}
+}
+
+// BackupAll backs up the repositories that changed since their last backup.
+func (s *Scheduler) BackupAll(ctx context.Context) error {
+	var errs []error
+	for _, r := range s.cfg.Source.AllRepos() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.mtx.Lock()
+		last, ok := s.last[r.Repo()]
+		s.mtx.Unlock()
+		if ok && !r.UpdatedAt().After(last) {
+			continue
+		}
+		if err := s.Backup(ctx, r); err != nil {
+			log.Error("error backing up repository", "repo", r.Repo(), "err", err)
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Backup backs up a repository.
+func (s *Scheduler) Backup(ctx context.Context, r *config.Repo) error {
+	start := time.Now()
+	// Git refuses to create empty bundles.
+	if !r.IsEmpty() {
+		dir, err := os.MkdirTemp("", "soft-serve-backup")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		bp := filepath.Join(dir, r.Repo()+".bundle")
+		if err := r.Bundle(bp); err != nil {
+			return err
+		}
+		bts, err := os.ReadFile(bp)
+		if err != nil {
+			return err
+		}
+		box, err := s.cfg.Secrets.Seal(bts)
+		if err != nil {
+			return err
+		}
+		if err := s.target.Put(ctx, r.Repo()+bundleExt, bytes.NewReader(box)); err != nil {
+			return err
+		}
+		log.Info("backed up repository", "repo", r.Repo(), "size", len(box))
+		s.mtx.Lock()
+		s.stored[r.Repo()] = struct{}{}
+		s.mtx.Unlock()
+	}
+	s.mtx.Lock()
+	s.last[r.Repo()] = start
+	s.mtx.Unlock()
+	return nil
+}
+
+// Verify checks that the backup of a repository can be restored.
+func (s *Scheduler) Verify(ctx context.Context, repo string) error {
+	rc, err := s.target.Get(ctx, repo+bundleExt)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	box, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
+	bts, err := s.cfg.Secrets.Unseal(box)
+	if err != nil {
+		return err
+	}
+	dir, err := os.MkdirTemp("", "soft-serve-verify")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	bp := filepath.Join(dir, repo+".bundle")
+	if err := os.WriteFile(bp, bts, 0o600); err != nil {
+		return err
+	}
+	return git.VerifyBundle(bp)
+}
+
+// backedUp returns the names of the repositories that have a backup.
+func (s *Scheduler) backedUp() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	repos := make([]string, 0, len(s.stored))
+	for rn := range s.stored {
+		repos = append(repos, rn)
+	}
+	return repos
+}
@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/secrets"
+	"github.com/matryer/is"
+)
+
+func TestBackup(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	rs := config.NewRepoSource(t.TempDir())
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	_, err = rs.InitRepo("empty", true)
+	is.NoErr(err)
+	work := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", work},
+		{"-C", work, "-c", "user.name=a", "-c", "user.email=a@b", "commit", "-q", "--allow-empty", "-m", "first"},
+		{"-C", work, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/master"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	is.NoErr(rs.LoadRepo("repo"))
+
+	dir := t.TempDir()
+	store, err := secrets.Open(filepath.Join(dir, "secrets"), filepath.Join(dir, "key"))
+	is.NoErr(err)
+	cfg := &config.Config{Source: rs, Secrets: store}
+	target := &DirTarget{Path: filepath.Join(dir, "backups")}
+	s, err := NewScheduler(cfg, target)
+	is.NoErr(err)
+
+	is.NoErr(s.BackupAll(ctx))
+	is.Equal(s.backedUp(), []string{"repo"})
+	is.NoErr(s.Verify(ctx, "repo"))
+
+	// Backups are encrypted.
+	bts, err := os.ReadFile(filepath.Join(target.Path, "repo"+bundleExt))
+	is.NoErr(err)
+	is.True(len(bts) > 0)
+	is.True(!bytes.HasPrefix(bts, []byte("# v2 git bundle")))
+
+	// Unchanged repos aren't backed up again.
+	is.NoErr(os.Remove(filepath.Join(target.Path, "repo"+bundleExt)))
+	is.NoErr(s.BackupAll(ctx))
+	_, err = os.Stat(filepath.Join(target.Path, "repo"+bundleExt))
+	is.True(os.IsNotExist(err))
+}
+
+func TestNewScheduler(t *testing.T) {
+	is := is.New(t)
+	_, err := NewScheduler(&config.Config{}, &DirTarget{})
+	is.Equal(err, ErrNoSecrets)
+}
+
+func TestNewTarget(t *testing.T) {
+	is := is.New(t)
+	tg, err := NewTarget("/srv/backups")
+	is.NoErr(err)
+	is.Equal(tg, &DirTarget{Path: "/srv/backups"})
+	tg, err = NewTarget("file:///srv/backups")
+	is.NoErr(err)
+	is.Equal(tg, &DirTarget{Path: "/srv/backups"})
+	tg, err = NewTarget("rsync:host:/srv/backups")
+	is.NoErr(err)
+	is.Equal(tg, &RsyncTarget{Dest: "host:/srv/backups"})
+	_, err = NewTarget("s3://bucket")
+	is.True(err != nil)
+}
@@ -0,0 +1,283 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+)
+
+const (
+	// manifestName is the name of the manifest of snapshots, their first
+	// entry.
+	manifestName = "soft-serve-snapshot.json"
+	// snapshotVersion is the version of the snapshot format.
+	snapshotVersion = 1
+)
+
+// ErrNotEmpty is returned when restoring a snapshot onto an instance that
+// already has repos, data, or keys.
+var ErrNotEmpty = errors.New("restoring needs a fresh instance")
+
+// Manifest describes a snapshot.
+type Manifest struct {
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	// Repos are the repos in the snapshot.
+	Repos []string `json:"repos"`
+}
+
+// snapshotKeys maps the names of the keys in snapshots to their paths in
+// the settings of the server.
+func snapshotKeys(cfg *sconfig.Config) map[string]string {
+	return map[string]string{
+		"keys/host":         cfg.KeyPath,
+		"keys/host.pub":     cfg.KeyPath + ".pub",
+		"keys/secrets.json": cfg.SecretsPath,
+		"keys/secrets_key":  cfg.SecretsKeyPath,
+	}
+}
+
+// WriteSnapshot writes a snapshot of the server to w, as a gzipped tarball:
+// its repos, the data path, and its SSH host key and secrets store. Each repo
+// is copied under its maintenance lock, so that pushes wait for it to be
+// copied rather than leave it half-written.
+func WriteSnapshot(ctx context.Context, ac *config.Config, w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	repos := ac.Source.AllRepos()
+	m := Manifest{
+		Version: snapshotVersion,
+		Created: time.Now().UTC(),
+		Repos:   make([]string, 0, len(repos)),
+	}
+	for _, r := range repos {
+		m.Repos = append(m.Repos, r.Repo())
+	}
+	bts, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    manifestName,
+		Mode:    0o600,
+		Size:    int64(len(bts)),
+		ModTime: m.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(bts); err != nil {
+		return err
+	}
+
+	for _, r := range repos {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(ac.Source.Dir(), r.Path())
+		if err != nil {
+			return err
+		}
+		unlock := ac.Source.LockMaintenance(r.Repo())
+		err = addTree(tw, r.Path(), path.Join("repos", filepath.ToSlash(rel)), nil)
+		unlock()
+		if err != nil {
+			return fmt.Errorf("repo %s: %w", r.Repo(), err)
+		}
+	}
+	keys := snapshotKeys(ac.Cfg)
+	names := make([]string, 0, len(keys))
+	skip := make(map[string]bool)
+	for name, fp := range keys {
+		names = append(names, name)
+		skip[filepath.Clean(fp)] = true
+	}
+	sort.Strings(names)
+	if ac.Cfg.DataPath != "" {
+		// Keys kept in the data path are only added as keys.
+		if err := addTree(tw, ac.Cfg.DataPath, "data", skip); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if err := addFile(tw, keys[name], name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// addTree adds the directories and regular files of the directory at root
+// to a snapshot, under name, but for the files in skip. Temporary files,
+// which are being written, are skipped too. Directories are added even when
+// they're empty, since git needs the empty directories of repos.
+func addTree(tw *tar.Writer, root, name string, skip map[string]bool) error {
+	return filepath.WalkDir(root, func(fp string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, fp)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     path.Join(name, filepath.ToSlash(rel)) + "/",
+				Mode:     0o700,
+			})
+		}
+		if !d.Type().IsRegular() || strings.HasSuffix(fp, ".tmp") || skip[filepath.Clean(fp)] {
+			return nil
+		}
+		err = addFile(tw, fp, path.Join(name, filepath.ToSlash(rel)))
+		if errors.Is(err, fs.ErrNotExist) {
+			// Removed since it was listed.
+			return nil
+		}
+		return err
+	})
+}
+
+// addFile adds the file at fp to a snapshot. Files growing as they're added,
+// such as logs, are cut at the size they had when they were opened.
+func addFile(tw *tar.Writer, fp, name string) error {
+	f, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr, err := tar.FileInfoHeader(fi, "")
+	if err != nil {
+		return err
+	}
+	hdr.Name = name
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, io.LimitReader(f, hdr.Size))
+	return err
+}
+
+// RestoreSnapshot restores a snapshot written by WriteSnapshot onto a fresh
+// instance with the given settings: its repo and data paths must be empty or
+// missing, and its SSH host key missing. Keys are restored to the paths of
+// the settings, wherever they were on the server the snapshot was taken of.
+// It returns the manifest of the snapshot.
+func RestoreSnapshot(r io.Reader, cfg *sconfig.Config) (Manifest, error) {
+	var m Manifest
+	for _, dir := range []string{cfg.RepoPath, cfg.DataPath} {
+		des, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return m, err
+		}
+		if len(des) > 0 {
+			return m, fmt.Errorf("%w: %s isn't empty", ErrNotEmpty, dir)
+		}
+	}
+	if _, err := os.Stat(cfg.KeyPath); err == nil {
+		return m, fmt.Errorf("%w: %s exists", ErrNotEmpty, cfg.KeyPath)
+	}
+
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return m, err
+	}
+	tr := tar.NewReader(zr)
+	keys := snapshotKeys(cfg)
+	for i := 0; ; i++ {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+		if i == 0 {
+			if hdr.Name != manifestName {
+				return m, errors.New("not a Soft Serve snapshot")
+			}
+			if err := json.NewDecoder(tr).Decode(&m); err != nil {
+				return m, err
+			}
+			if m.Version > snapshotVersion {
+				return m, fmt.Errorf("unsupported snapshot version %d", m.Version)
+			}
+			continue
+		}
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir {
+			continue
+		}
+		name := path.Clean(hdr.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return m, fmt.Errorf("invalid path %q in snapshot", hdr.Name)
+		}
+		var fp string
+		parts := strings.SplitN(name, "/", 2)
+		switch {
+		case len(parts) < 2:
+		case parts[0] == "repos":
+			fp = filepath.Join(cfg.RepoPath, filepath.FromSlash(parts[1]))
+		case parts[0] == "data":
+			fp = filepath.Join(cfg.DataPath, filepath.FromSlash(parts[1]))
+		case parts[0] == "keys":
+			fp = keys[name]
+		}
+		if fp == "" {
+			continue
+		}
+		if hdr.Typeflag == tar.TypeDir {
+			if err := os.MkdirAll(fp, 0o700); err != nil {
+				return m, err
+			}
+			continue
+		}
+		if err := restoreFile(tr, fp, hdr); err != nil {
+			return m, err
+		}
+	}
+	return m, nil
+}
+
+// restoreFile writes a file of a snapshot to fp, with its mode and
+// modification time.
+func restoreFile(r io.Reader, fp string, hdr *tar.Header) error {
+	if err := os.MkdirAll(filepath.Dir(fp), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(fp, os.O_CREATE|os.O_EXCL|os.O_WRONLY, fs.FileMode(hdr.Mode).Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(fp, hdr.ModTime, hdr.ModTime)
+}
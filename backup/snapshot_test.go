@@ -0,0 +1,80 @@
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestSnapshot(t *testing.T) {
+	is := is.New(t)
+	settings := func() *sconfig.Config {
+		dir := t.TempDir()
+		return &sconfig.Config{
+			RepoPath:       filepath.Join(dir, "repos"),
+			DataPath:       filepath.Join(dir, "data"),
+			KeyPath:        filepath.Join(dir, "ssh", "host_ed25519"),
+			SecretsPath:    filepath.Join(dir, "ssh", "secrets.json"),
+			SecretsKeyPath: filepath.Join(dir, "ssh", "secrets_key"),
+		}
+	}
+	from := settings()
+	rs := config.NewRepoSource(from.RepoPath)
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	work := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", work},
+		{"-C", work, "-c", "user.name=a", "-c", "user.email=a@b", "commit", "-q", "--allow-empty", "-m", "first"},
+		{"-C", work, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/master"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+	}
+	is.NoErr(rs.LoadRepo("repo"))
+	is.NoErr(os.MkdirAll(filepath.Join(from.DataPath, "audit"), 0o700))
+	is.NoErr(os.WriteFile(filepath.Join(from.DataPath, "audit", "audit.log"), []byte("entry\n"), 0o600))
+	is.NoErr(os.WriteFile(filepath.Join(from.DataPath, "inbox-read.json.tmp"), []byte("{"), 0o600))
+	is.NoErr(os.MkdirAll(filepath.Dir(from.KeyPath), 0o700))
+	is.NoErr(os.WriteFile(from.KeyPath, []byte("private"), 0o600))
+	is.NoErr(os.WriteFile(from.SecretsKeyPath, []byte("sealed"), 0o600))
+
+	var buf bytes.Buffer
+	ac := &config.Config{Source: rs, Cfg: from}
+	is.NoErr(WriteSnapshot(context.Background(), ac, &buf))
+
+	to := settings()
+	m, err := RestoreSnapshot(bytes.NewReader(buf.Bytes()), to)
+	is.NoErr(err)
+	is.Equal(m.Repos, []string{"repo"})
+	out, err := exec.Command("git", "-C", filepath.Join(to.RepoPath, "repo"), "log", "--format=%s").CombinedOutput()
+	is.NoErr(err)
+	is.Equal(strings.TrimSpace(string(out)), "first")
+	bts, err := os.ReadFile(filepath.Join(to.DataPath, "audit", "audit.log"))
+	is.NoErr(err)
+	is.Equal(string(bts), "entry\n")
+	_, err = os.Stat(filepath.Join(to.DataPath, "inbox-read.json.tmp"))
+	is.True(os.IsNotExist(err))
+	// Keys are restored to the paths of the instance.
+	bts, err = os.ReadFile(to.KeyPath)
+	is.NoErr(err)
+	is.Equal(string(bts), "private")
+	fi, err := os.Stat(to.SecretsKeyPath)
+	is.NoErr(err)
+	is.Equal(fi.Mode().Perm(), os.FileMode(0o600))
+
+	// Snapshots are only restored onto fresh instances.
+	_, err = RestoreSnapshot(bytes.NewReader(buf.Bytes()), to)
+	is.True(errors.Is(err, ErrNotEmpty))
+}
@@ -0,0 +1,130 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedTarget is returned for unsupported backup target URLs.
+var ErrUnsupportedTarget = errors.New("unsupported backup target")
+
+// Target is a place backups are stored.
+type Target interface {
+	// Put stores a backup file.
+	Put(ctx context.Context, name string, r io.Reader) error
+	// Get retrieves a backup file.
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+}
+
+// NewTarget returns the target for a URL. Supported targets are local or
+// mounted directories, given as a path or a file:// URL, and rsync
+// destinations given as rsync:DEST, e.g. rsync:backup@host:/srv/backups.
+func NewTarget(u string) (Target, error) {
+	switch {
+	case strings.HasPrefix(u, "file://"):
+		return &DirTarget{Path: strings.TrimPrefix(u, "file://")}, nil
+	case strings.HasPrefix(u, "rsync:"):
+		return &RsyncTarget{Dest: strings.TrimPrefix(u, "rsync:")}, nil
+	case strings.Contains(u, "://"):
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedTarget, u)
+	default:
+		return &DirTarget{Path: u}, nil
+	}
+}
+
+// DirTarget stores backups in a directory.
+type DirTarget struct {
+	Path string
+}
+
+// Put implements Target.
+func (t *DirTarget) Put(_ context.Context, name string, r io.Reader) error {
+	if err := os.MkdirAll(t.Path, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(t.Path, name)
+	tmp := fp + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
+
+// Get implements Target.
+func (t *DirTarget) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(t.Path, name))
+}
+
+// RsyncTarget stores backups with rsync, which must be installed on the
+// server.
+type RsyncTarget struct {
+	// Dest is the rsync destination directory, e.g. host:/srv/backups.
+	Dest string
+}
+
+// Put implements Target.
+func (t *RsyncTarget) Put(ctx context.Context, name string, r io.Reader) error {
+	dir, err := os.MkdirTemp("", "soft-serve-backup")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	fp := filepath.Join(dir, name)
+	if err := (&DirTarget{Path: dir}).Put(ctx, name, r); err != nil {
+		return err
+	}
+	return rsync(ctx, fp, strings.TrimSuffix(t.Dest, "/")+"/"+name)
+}
+
+// Get implements Target.
+func (t *RsyncTarget) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	dir, err := os.MkdirTemp("", "soft-serve-backup")
+	if err != nil {
+		return nil, err
+	}
+	fp := filepath.Join(dir, name)
+	if err := rsync(ctx, strings.TrimSuffix(t.Dest, "/")+"/"+name, fp); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	f, err := os.Open(fp)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	return &tempFile{File: f, dir: dir}, nil
+}
+
+func rsync(ctx context.Context, src, dst string) error {
+	out, err := exec.CommandContext(ctx, "rsync", "--quiet", "--times", src, dst).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("rsync: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// tempFile is a file in a temporary directory removed on close.
+type tempFile struct {
+	*os.File
+	dir string
+}
+
+// Close implements io.Closer.
+func (f *tempFile) Close() error {
+	defer os.RemoveAll(f.dir)
+	return f.File.Close()
+}
@@ -0,0 +1,121 @@
+// Package chaos injects latency and errors into storage and git operations,
+// so that error handling, such as the error page of the TUI, can be
+// exercised deterministically.
+//
+// Tests can wrap sources and repos with an Injector directly. Servers only
+// inject faults when built with the chaos build tag and SOFT_SERVE_CHAOS is
+// set to an injector spec, see Parse.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error failing operations return by default.
+var ErrInjected = errors.New("injected failure")
+
+// Injector decides which operations are slowed down and which fail.
+// Operations are named after the methods they wrap, e.g. "GetRepo" or
+// "Tree".
+type Injector struct {
+	// Latency is added to every matching operation.
+	Latency time.Duration
+	// Every makes every Nth call of each matching operation fail, starting
+	// with the Nth. Operations never fail when it's zero.
+	Every int
+	// Ops are the operations to inject faults into. All operations match
+	// when it's empty.
+	Ops []string
+	// Err is the error failing operations return, ErrInjected when nil.
+	Err error
+
+	mtx   sync.Mutex
+	calls map[string]int
+}
+
+// Parse parses an injector spec, a comma-separated list of settings:
+//
+//	latency=DURATION  add latency to matching operations
+//	every=N           fail every Nth call of matching operations
+//	op=NAME           only match the operation NAME, may be repeated
+//
+// For instance, "latency=200ms,every=3,op=Tree,op=Diff".
+func Parse(spec string) (*Injector, error) {
+	i := &Injector{}
+	for _, s := range strings.Split(spec, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		kv := strings.SplitN(s, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid chaos setting %q", s)
+		}
+		k, v := kv[0], kv[1]
+		switch k {
+		case "latency":
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid chaos latency %q: %w", v, err)
+			}
+			i.Latency = d
+		case "every":
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid chaos failure interval %q", v)
+			}
+			i.Every = n
+		case "op":
+			i.Ops = append(i.Ops, v)
+		default:
+			return nil, fmt.Errorf("unknown chaos setting %q", k)
+		}
+	}
+	return i, nil
+}
+
+// Inject applies the injector to a call of op: it sleeps for the latency
+// and returns the error op must fail with, if any.
+func (i *Injector) Inject(op string) error {
+	if !i.matches(op) {
+		return nil
+	}
+	if i.Latency > 0 {
+		time.Sleep(i.Latency)
+	}
+	if i.Every <= 0 {
+		return nil
+	}
+	i.mtx.Lock()
+	if i.calls == nil {
+		i.calls = make(map[string]int)
+	}
+	i.calls[op]++
+	n := i.calls[op]
+	i.mtx.Unlock()
+	if n%i.Every != 0 {
+		return nil
+	}
+	err := i.Err
+	if err == nil {
+		err = ErrInjected
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func (i *Injector) matches(op string) bool {
+	if len(i.Ops) == 0 {
+		return true
+	}
+	for _, o := range i.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package chaos
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/matryer/is"
+)
+
+func TestParse(t *testing.T) {
+	is := is.New(t)
+	i, err := Parse("latency=200ms, every=3,op=Tree,op=Diff")
+	is.NoErr(err)
+	is.Equal(i.Latency, 200*time.Millisecond)
+	is.Equal(i.Every, 3)
+	is.Equal(i.Ops, []string{"Tree", "Diff"})
+	for _, spec := range []string{"latency", "latency=soon", "every=-1", "rate=0.5"} {
+		_, err := Parse(spec)
+		is.True(err != nil) // invalid spec
+	}
+}
+
+func TestInject(t *testing.T) {
+	is := is.New(t)
+	i := &Injector{Every: 2, Ops: []string{"Tree"}}
+	is.NoErr(i.Inject("Tree"))
+	is.True(errors.Is(i.Inject("Tree"), ErrInjected))
+	is.NoErr(i.Inject("Tree"))
+	is.True(errors.Is(i.Inject("Tree"), ErrInjected))
+	for n := 0; n < 4; n++ {
+		is.NoErr(i.Inject("Diff")) // should only fail matching operations
+	}
+
+	errDown := errors.New("down")
+	i = &Injector{Every: 1, Err: errDown}
+	is.True(errors.Is(i.Inject("GetRepo"), errDown))
+
+	i = &Injector{Latency: 50 * time.Millisecond}
+	start := time.Now()
+	is.NoErr(i.Inject("GetRepo"))
+	is.True(time.Since(start) >= i.Latency)
+}
+
+func TestSource(t *testing.T) {
+	is := is.New(t)
+	rs := config.NewRepoSource(t.TempDir())
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	s := Source(rs, &Injector{Every: 2, Ops: []string{"GetRepo", "HEAD"}})
+	r, err := s.GetRepo("repo")
+	is.NoErr(err)
+	_, err = s.GetRepo("repo")
+	is.True(errors.Is(err, ErrInjected))
+	is.Equal(len(s.AllRepos()), 1)
+
+	gr := s.(*source).WrapRepo(r)
+	is.Equal(gr.Repo(), "repo")
+	_, err = gr.HEAD()
+	is.True(!errors.Is(err, ErrInjected)) // first call
+	_, err = gr.HEAD()
+	is.True(errors.Is(err, ErrInjected))
+}
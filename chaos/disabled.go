@@ -0,0 +1,8 @@
+//go:build !chaos
+// +build !chaos
+
+package chaos
+
+// Enabled reports whether the server injects the faults SOFT_SERVE_CHAOS
+// describes. Build with the chaos tag to enable it.
+const Enabled = false
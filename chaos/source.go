@@ -0,0 +1,160 @@
+package chaos
+
+import (
+	"github.com/charmbracelet/soft-serve/config"
+	ggit "github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/git"
+)
+
+// Source returns a config.Source that injects faults into the operations of
+// s. Operations that can't fail, such as AllRepos, are only slowed down.
+// The repos the TUI browses through it inject faults into their git
+// operations too.
+func Source(s config.Source, i *Injector) config.Source {
+	return &source{s, i}
+}
+
+type source struct {
+	config.Source
+	i *Injector
+}
+
+// AllRepos implements config.Source.
+func (s *source) AllRepos() []*config.Repo {
+	_ = s.i.Inject("AllRepos")
+	return s.Source.AllRepos()
+}
+
+// GetRepo implements config.Source.
+func (s *source) GetRepo(name string) (*config.Repo, error) {
+	if err := s.i.Inject("GetRepo"); err != nil {
+		return nil, err
+	}
+	return s.Source.GetRepo(name)
+}
+
+// InitRepo implements config.Source.
+func (s *source) InitRepo(name string, bare bool) (*config.Repo, error) {
+	if err := s.i.Inject("InitRepo"); err != nil {
+		return nil, err
+	}
+	return s.Source.InitRepo(name, bare)
+}
+
+// LoadRepo implements config.Source.
+func (s *source) LoadRepo(name string) error {
+	if err := s.i.Inject("LoadRepo"); err != nil {
+		return err
+	}
+	return s.Source.LoadRepo(name)
+}
+
+// LoadRepos implements config.Source.
+func (s *source) LoadRepos() error {
+	if err := s.i.Inject("LoadRepos"); err != nil {
+		return err
+	}
+	return s.Source.LoadRepos()
+}
+
+// RemoveRepo implements config.Source.
+func (s *source) RemoveRepo(name string) error {
+	if err := s.i.Inject("RemoveRepo"); err != nil {
+		return err
+	}
+	return s.Source.RemoveRepo(name)
+}
+
+// Orphans implements config.Source.
+func (s *source) Orphans() ([]string, []string, error) {
+	if err := s.i.Inject("Orphans"); err != nil {
+		return nil, nil, err
+	}
+	return s.Source.Orphans()
+}
+
+// GC implements config.Source.
+func (s *source) GC(name string) error {
+	if err := s.i.Inject("GC"); err != nil {
+		return err
+	}
+	return s.Source.GC(name)
+}
+
+// WrapRepo implements git.RepoWrapper.
+func (s *source) WrapRepo(r git.GitRepo) git.GitRepo {
+	return Repo(r, s.i)
+}
+
+// Repo returns a git.GitRepo that injects faults into the git operations of
+// r.
+func Repo(r git.GitRepo, i *Injector) git.GitRepo {
+	return &repo{r, i}
+}
+
+type repo struct {
+	git.GitRepo
+	i *Injector
+}
+
+// Readme implements git.GitRepo.
+func (r *repo) Readme() (string, string) {
+	_ = r.i.Inject("Readme")
+	return r.GitRepo.Readme()
+}
+
+// HEAD implements git.GitRepo.
+func (r *repo) HEAD() (*ggit.Reference, error) {
+	if err := r.i.Inject("HEAD"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.HEAD()
+}
+
+// Commit implements git.GitRepo.
+func (r *repo) Commit(hash string) (*ggit.Commit, error) {
+	if err := r.i.Inject("Commit"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.Commit(hash)
+}
+
+// CommitsByPage implements git.GitRepo.
+func (r *repo) CommitsByPage(ref *ggit.Reference, path string, page, size int) (ggit.Commits, error) {
+	if err := r.i.Inject("CommitsByPage"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.CommitsByPage(ref, path, page, size)
+}
+
+// CountCommits implements git.GitRepo.
+func (r *repo) CountCommits(ref *ggit.Reference, path string) (int64, error) {
+	if err := r.i.Inject("CountCommits"); err != nil {
+		return 0, err
+	}
+	return r.GitRepo.CountCommits(ref, path)
+}
+
+// Diff implements git.GitRepo.
+func (r *repo) Diff(c *ggit.Commit) (*ggit.Diff, error) {
+	if err := r.i.Inject("Diff"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.Diff(c)
+}
+
+// References implements git.GitRepo.
+func (r *repo) References() ([]*ggit.Reference, error) {
+	if err := r.i.Inject("References"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.References()
+}
+
+// Tree implements git.GitRepo.
+func (r *repo) Tree(ref *ggit.Reference, path string) (*ggit.Tree, error) {
+	if err := r.i.Inject("Tree"); err != nil {
+		return nil, err
+	}
+	return r.GitRepo.Tree(ref, path)
+}
@@ -0,0 +1,206 @@
+// Package ci triggers builds on external CI systems when repositories are
+// pushed to.
+package ci
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+const (
+	// Woodpecker is the Woodpecker CI provider.
+	Woodpecker = "woodpecker"
+	// Drone is the Drone CI provider.
+	Drone = "drone"
+	// Buildkite is the Buildkite provider.
+	Buildkite = "buildkite"
+
+	buildkiteURL = "https://api.buildkite.com"
+)
+
+var (
+	// ErrUnknownProvider is returned for unsupported CI providers.
+	ErrUnknownProvider = errors.New("unknown CI provider")
+	// ErrMissingURL is returned when a self-hosted provider has no URL.
+	ErrMissingURL = errors.New("missing CI server URL")
+)
+
+// Build is a build to trigger.
+type Build struct {
+	Repo   string
+	Branch string
+	Commit string
+}
+
+// Client triggers builds on CI systems.
+type Client struct {
+	HTTP *http.Client
+}
+
+// NewClient returns a new CI client.
+func NewClient() *Client {
+	return &Client{
+		HTTP: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Matches returns whether a push to branch triggers the pipeline.
+func Matches(c config.CI, branch string) bool {
+	return len(c.Branches) == 0 || config.MatchBranch(c.Branches, branch)
+}
+
+// request returns the API request triggering build on the pipeline.
+func request(ctx context.Context, c config.CI, token string, b Build) (*http.Request, error) {
+	var method, u string
+	var body interface{}
+	base := strings.TrimSuffix(c.URL, "/")
+	switch c.Provider {
+	case Woodpecker:
+		if base == "" {
+			return nil, ErrMissingURL
+		}
+		method = http.MethodPost
+		u = fmt.Sprintf("%s/api/repos/%s/pipelines", base, url.PathEscape(c.Pipeline))
+		body = map[string]interface{}{
+			"branch": b.Branch,
+			"variables": map[string]string{
+				"SOFT_SERVE_REPO":   b.Repo,
+				"SOFT_SERVE_COMMIT": b.Commit,
+			},
+		}
+	case Drone:
+		if base == "" {
+			return nil, ErrMissingURL
+		}
+		q := url.Values{}
+		q.Set("branch", b.Branch)
+		q.Set("commit", b.Commit)
+		method = http.MethodPost
+		u = fmt.Sprintf("%s/api/repos/%s/builds?%s", base, c.Pipeline, q.Encode())
+	case Buildkite:
+		if base == "" {
+			base = buildkiteURL
+		}
+		parts := strings.SplitN(c.Pipeline, "/", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid buildkite pipeline %q", c.Pipeline)
+		}
+		method = http.MethodPost
+		u = fmt.Sprintf("%s/v2/organizations/%s/pipelines/%s/builds", base,
+			url.PathEscape(parts[0]), url.PathEscape(parts[1]))
+		body = map[string]string{
+			"branch":  b.Branch,
+			"commit":  b.Commit,
+			"message": fmt.Sprintf("Push to %s", b.Repo),
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, c.Provider)
+	}
+	var r io.Reader
+	if body != nil {
+		bts, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(bts)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return req, nil
+}
+
+// Trigger triggers a build of the pipeline.
+func (cl *Client) Trigger(ctx context.Context, c config.CI, token string, b Build) error {
+	req, err := request(ctx, c, token, b)
+	if err != nil {
+		return err
+	}
+	res, err := cl.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 512))
+		return fmt.Errorf("%s: unexpected status %s: %s", c.Provider, res.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Run triggers the configured pipelines on pushes until ctx is done.
+func (cl *Client) Run(ctx context.Context, cfg *config.Config) {
+	for e := range cfg.Events.SubscribeDurable(ctx) {
+		if e.Type != events.Push || e.Commit == "" || !strings.HasPrefix(e.Ref, git.RefsHeads) {
+			continue
+		}
+		b := Build{
+			Repo:   e.Repo,
+			Branch: strings.TrimPrefix(e.Ref, git.RefsHeads),
+			Commit: e.Commit,
+		}
+		files := cfg.PushedFilesFunc(e)
+		for _, c := range cfg.RepoCI(e.Repo) {
+			if cfg.DeliveryDisabled(Target(c)) || !c.Filters().Match(e, files) {
+				continue
+			}
+			go cl.trigger(ctx, cfg, c, b)
+		}
+	}
+}
+
+// Target returns the name identifying a pipeline in integration deliveries.
+func Target(c config.CI) string {
+	return fmt.Sprintf("%s %s", c.Provider, c.Pipeline)
+}
+
+// trigger triggers a build and records the delivery.
+func (cl *Client) trigger(ctx context.Context, cfg *config.Config, c config.CI, b Build) error {
+	logger := log.With("repo", b.Repo, "branch", b.Branch, "provider", c.Provider, "pipeline", c.Pipeline)
+	start := time.Now()
+	err := cl.TriggerWithSecret(ctx, cfg, c, b)
+	cfg.RecordDelivery("ci", Target(c), time.Since(start), err, func() error {
+		return cl.trigger(ctx, cfg, c, b)
+	})
+	if err != nil {
+		logger.Error("error triggering CI build", "err", err)
+		return err
+	}
+	logger.Info("triggered CI build", "commit", b.Commit)
+	return nil
+}
+
+// TriggerWithSecret triggers a build of the pipeline with the API token held
+// by its token secret.
+func (cl *Client) TriggerWithSecret(ctx context.Context, cfg *config.Config, c config.CI, b Build) error {
+	var token string
+	if c.TokenSecret != "" {
+		if cfg.Secrets == nil {
+			return errors.New("secrets are not configured")
+		}
+		t, err := cfg.Secrets.Get(c.TokenSecret)
+		if err != nil {
+			return fmt.Errorf("secret %q: %w", c.TokenSecret, err)
+		}
+		token = t
+	}
+	return cl.Trigger(ctx, c, token, b)
+}
@@ -0,0 +1,95 @@
+package ci
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/matryer/is"
+)
+
+func TestTrigger(t *testing.T) {
+	b := Build{Repo: "repo", Branch: "main", Commit: "abc"}
+	cases := []struct {
+		name string
+		ci   config.CI
+		path string
+		body map[string]interface{}
+	}{
+		{
+			name: "woodpecker",
+			ci:   config.CI{Provider: Woodpecker, Pipeline: "42"},
+			path: "/api/repos/42/pipelines",
+			body: map[string]interface{}{
+				"branch": "main",
+				"variables": map[string]interface{}{
+					"SOFT_SERVE_REPO":   "repo",
+					"SOFT_SERVE_COMMIT": "abc",
+				},
+			},
+		},
+		{
+			name: "drone",
+			ci:   config.CI{Provider: Drone, Pipeline: "octo/repo"},
+			path: "/api/repos/octo/repo/builds?branch=main&commit=abc",
+		},
+		{
+			name: "buildkite",
+			ci:   config.CI{Provider: Buildkite, Pipeline: "org/pipe"},
+			path: "/v2/organizations/org/pipelines/pipe/builds",
+			body: map[string]interface{}{
+				"branch":  "main",
+				"commit":  "abc",
+				"message": "Push to repo",
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			is := is.New(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				is.Equal(r.Method, http.MethodPost)
+				is.Equal(r.URL.RequestURI(), c.path)
+				is.Equal(r.Header.Get("Authorization"), "Bearer token")
+				bts, err := io.ReadAll(r.Body)
+				is.NoErr(err)
+				if c.body != nil {
+					var body map[string]interface{}
+					is.NoErr(json.Unmarshal(bts, &body))
+					is.Equal(body, c.body)
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer srv.Close()
+			c.ci.URL = srv.URL
+			is.NoErr(NewClient().Trigger(context.Background(), c.ci, "token", b))
+		})
+	}
+}
+
+func TestTriggerErrors(t *testing.T) {
+	is := is.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	cl := NewClient()
+	b := Build{Repo: "repo", Branch: "main", Commit: "abc"}
+	is.True(cl.Trigger(context.Background(), config.CI{Provider: Drone, URL: srv.URL, Pipeline: "a/b"}, "", b) != nil)
+	is.True(cl.Trigger(context.Background(), config.CI{Provider: "jenkins", URL: srv.URL}, "", b) != nil)
+	is.True(cl.Trigger(context.Background(), config.CI{Provider: Drone, Pipeline: "a/b"}, "", b) != nil)
+}
+
+func TestMatches(t *testing.T) {
+	is := is.New(t)
+	is.True(Matches(config.CI{}, "main"))
+	c := config.CI{Branches: []string{"main", "release/*"}}
+	is.True(Matches(c, "main"))
+	is.True(Matches(c, "release/1.0"))
+	is.True(!Matches(c, "feature"))
+	is.True(!Matches(c, "release/1.0/fix"))
+}
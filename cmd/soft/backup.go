@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/backup"
+	"github.com/spf13/cobra"
+)
+
+// restoreSettings are the settings of soft serve restore reads, where the
+// snapshot is restored to.
+var restoreSettings = map[string]bool{
+	"repo-path":        true,
+	"data-path":        true,
+	"key-path":         true,
+	"secrets-path":     true,
+	"secrets-key-path": true,
+}
+
+var (
+	backupCmd = &cobra.Command{
+		Use:   "backup [FILE]",
+		Short: "Save a snapshot of the server",
+		Long: `Save a snapshot of the server you're logged in to, as a gzipped tarball: all
+repos, the data path, and the SSH host key and secrets store of the server.
+It's written to FILE, or stdout when none is given. Only admins can take
+snapshots.
+
+Each repository is copied while pushes to it wait, so that snapshots never
+hold half-written pushes. Restore snapshots onto a fresh instance with soft
+restore.`,
+		Example: `  soft backup soft-serve.tar.gz`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := loadClientConfig()
+			if err != nil {
+				return err
+			}
+			var stderr bytes.Buffer
+			c := cc.client().Command(false, "backup")
+			c.Stderr = &stderr
+			if len(args) == 0 {
+				c.Stdout = cmd.OutOrStdout()
+				if err := c.Run(); err != nil {
+					return backupError(err, &stderr)
+				}
+				return nil
+			}
+			// The snapshot is written next to FILE first, so that a failed
+			// backup doesn't leave a truncated one behind.
+			tmp, err := os.CreateTemp(filepath.Dir(args[0]), ".soft-backup-*")
+			if err != nil {
+				return err
+			}
+			defer os.Remove(tmp.Name())
+			c.Stdout = tmp
+			err = c.Run()
+			if cerr := tmp.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return backupError(err, &stderr)
+			}
+			return os.Rename(tmp.Name(), args[0])
+		},
+	}
+
+	restoreCmd = &cobra.Command{
+		Use:   "restore FILE",
+		Short: "Restore a snapshot onto a fresh instance",
+		Long: `Restore a snapshot taken by soft backup onto a fresh instance, before
+starting it with soft serve. The repo and data paths must be empty or missing,
+and the SSH host key missing. Give - as FILE to read the snapshot from stdin.
+
+The paths are read like those of soft serve, from their flags or environment
+variables. Keys are restored to the key paths of this instance, wherever
+they were on the server the snapshot was taken of.`,
+		Example: `  SOFT_SERVE_DATA_PATH=/var/lib/soft-serve/data soft restore soft-serve.tar.gz`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if serveCfgErr != nil {
+				return serveCfgErr
+			}
+			var r io.Reader = os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+			m, err := backup.RestoreSnapshot(r, serveCfg.WithDefaults())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %d repositories from the snapshot of %s\n",
+				len(m.Repos), m.Created.Format("2006-01-02 15:04:05 MST"))
+			return nil
+		},
+	}
+)
+
+func init() {
+	backupCmd.SilenceUsage = true
+	restoreCmd.SilenceUsage = true
+	if serveCfgErr != nil {
+		return
+	}
+	for _, s := range serveCfg.Settings() {
+		if !restoreSettings[s.Flag] {
+			continue
+		}
+		usage := s.Usage
+		if usage == "" {
+			usage = s.Env
+		} else {
+			usage += " (" + s.Env + ")"
+		}
+		restoreCmd.Flags().VarPF(s, s.Flag, "", usage)
+	}
+}
+
+// backupError returns the error of a failed backup, with the error the
+// server printed, if any.
+func backupError(err error, stderr *bytes.Buffer) error {
+	msg := strings.SplitN(strings.TrimSpace(stderr.String()), "\n", 2)[0]
+	if msg = strings.TrimPrefix(msg, "Error: "); msg != "" {
+		return errors.New(msg)
+	}
+	return err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/pkg/client"
+	"gopkg.in/yaml.v3"
+)
+
+// errNotLoggedIn is returned by client commands before running login.
+var errNotLoggedIn = errors.New("not logged in, run soft login HOST first")
+
+// clientConfig is the configuration of the client commands, saved by login.
+type clientConfig struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	// User is the SSH user, which Soft Serve ignores. Defaults to the ssh
+	// default.
+	User string `yaml:"user,omitempty"`
+	// IdentityFile is the SSH key to log in with. Defaults to the keys of
+	// the ssh agent and config.
+	IdentityFile string `yaml:"identity-file,omitempty"`
+}
+
+// clientConfigPath returns the path of the client configuration.
+func clientConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "soft", "client.yaml"), nil
+}
+
+// loadClientConfig loads the client configuration saved by login.
+func loadClientConfig() (*clientConfig, error) {
+	p, err := clientConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, errNotLoggedIn
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cc clientConfig
+	if err := yaml.Unmarshal(bts, &cc); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	if cc.Host == "" {
+		return nil, errNotLoggedIn
+	}
+	return &cc, nil
+}
+
+// save saves the client configuration.
+func (cc *clientConfig) save() error {
+	p, err := clientConfigPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+		return err
+	}
+	bts, err := yaml.Marshal(cc)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, bts, 0o600)
+}
+
+// newClientConfig returns the client configuration for a client.
+func newClientConfig(c *client.Client) *clientConfig {
+	return &clientConfig{
+		Host:         c.Host,
+		Port:         c.Port,
+		User:         c.User,
+		IdentityFile: c.IdentityFile,
+	}
+}
+
+// client returns the client of the server.
+func (cc *clientConfig) client() *client.Client {
+	return &client.Client{
+		Host:         cc.Host,
+		Port:         cc.Port,
+		User:         cc.User,
+		IdentityFile: cc.IdentityFile,
+	}
+}
+
+// cachePath returns the path the output of a command is cached at.
+func (cc *clientConfig) cachePath(args ...string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	name := strings.NewReplacer("/", "%", " ", "_").Replace(strings.Join(args, " "))
+	return filepath.Join(dir, "soft", fmt.Sprintf("%s_%d", cc.Host, cc.Port), name), nil
+}
+
+// runCached runs a read-only command on the server and caches its output.
+// When the server can't be reached, the last cached output is returned, with
+// the time it was cached at.
+func (cc *clientConfig) runCached(args ...string) (out []byte, cachedAt time.Time, err error) {
+	p, perr := cc.cachePath(args...)
+	out, err = cc.client().Run(args...)
+	if perr != nil {
+		return out, time.Time{}, err
+	}
+	if err == nil {
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err == nil {
+			_ = os.WriteFile(p, out, 0o600)
+		}
+		return out, time.Time{}, nil
+	}
+	if !client.IsOffline(err) {
+		return out, time.Time{}, err
+	}
+	fi, serr := os.Stat(p)
+	if serr != nil {
+		return out, time.Time{}, err
+	}
+	cached, rerr := os.ReadFile(p)
+	if rerr != nil {
+		return out, time.Time{}, err
+	}
+	return cached, fi.ModTime(), nil
+}
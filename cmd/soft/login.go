@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/soft-serve/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+var (
+	loginIdentity string
+
+	loginCmd = &cobra.Command{
+		Use:   "login [USER@]HOST[:PORT]",
+		Short: "Log in to a Soft Serve server",
+		Long: `Log in to a Soft Serve server, so that the client commands talk to it.
+
+Commands are run over ssh, with your ssh config and agent. Use -i to log in
+with a specific key.`,
+		Example: `  soft login git.example.com
+  soft login git.example.com:2222 -i ~/.ssh/id_ed25519`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client.New(args[0])
+			if err != nil {
+				return err
+			}
+			c.IdentityFile = loginIdentity
+			repos, err := c.ListRepos()
+			if err != nil {
+				return fmt.Errorf("could not log in to %s: %w", args[0], err)
+			}
+			if err := newClientConfig(c).save(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged in to %s, %d repositories available\n", c.Addr(), len(repos))
+			return nil
+		},
+	}
+)
+
+func init() {
+	loginCmd.Flags().StringVarP(&loginIdentity, "identity", "i", "", "SSH key to log in with")
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	repoInfoJSON bool
+
+	repoCmd = &cobra.Command{
+		Use:     "repo",
+		Aliases: []string{"repos"},
+		Short:   "Manage repositories on the server",
+	}
+
+	repoListCmd = &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List repositories",
+		Long: `List the repositories you have access to.
+
+The list is cached, so it's still shown when the server can't be reached.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runCached(cmd, "ls")
+		},
+	}
+
+	repoInfoCmd = &cobra.Command{
+		Use:   "info REPO",
+		Short: "Print information about a repository",
+		Long: `Print information about a repository.
+
+The information is cached, so it's still shown when the server can't be
+reached.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ra := []string{"info", args[0]}
+			if repoInfoJSON {
+				ra = append(ra, "--json")
+			}
+			return runCached(cmd, ra...)
+		},
+	}
+
+	repoCreateCmd = &cobra.Command{
+		Use:   "create REPO",
+		Short: "Create an empty repository",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := loadClientConfig()
+			if err != nil {
+				return err
+			}
+			c := cc.client()
+			if err := c.CreateRepo(args[0]); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Created %s, clone it with: git clone %s\n", args[0], c.CloneURL(args[0]))
+			return nil
+		},
+	}
+
+	repoCloneCmd = &cobra.Command{
+		Use:   "clone REPO [DIR]",
+		Short: "Clone a repository",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := loadClientConfig()
+			if err != nil {
+				return err
+			}
+			ga := []string{"clone", cc.client().CloneURL(args[0])}
+			ga = append(ga, args[1:]...)
+			c := exec.Command("git", ga...)
+			c.Stdin = os.Stdin
+			c.Stdout = os.Stdout
+			c.Stderr = os.Stderr
+			if cc.IdentityFile != "" {
+				c.Env = append(os.Environ(), "GIT_SSH_COMMAND=ssh -i "+cc.IdentityFile)
+			}
+			return c.Run()
+		},
+	}
+
+	browseCmd = &cobra.Command{
+		Use:   "browse [REPO[/PATH]]",
+		Short: "Browse repositories in the TUI",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cc, err := loadClientConfig()
+			if err != nil {
+				return err
+			}
+			return cc.client().Interactive(args...)
+		},
+	}
+)
+
+func init() {
+	repoInfoCmd.Flags().BoolVar(&repoInfoJSON, "json", false, "Print the information as JSON")
+	repoCmd.AddCommand(
+		repoListCmd,
+		repoInfoCmd,
+		repoCreateCmd,
+		repoCloneCmd,
+	)
+	// Errors of client commands are about the server, not their usage.
+	for _, c := range append(repoCmd.Commands(), loginCmd, browseCmd) {
+		c.SilenceUsage = true
+	}
+}
+
+// runCached runs a read-only command on the server and prints its output,
+// falling back to the cached output when offline.
+func runCached(cmd *cobra.Command, args ...string) error {
+	cc, err := loadClientConfig()
+	if err != nil {
+		return err
+	}
+	out, cachedAt, err := cc.runCached(args...)
+	if err != nil {
+		return err
+	}
+	if !cachedAt.IsZero() {
+		fmt.Fprintf(cmd.ErrOrStderr(), "%s is unreachable, showing output cached %s\n",
+			cc.client().Addr(), humanize.Time(cachedAt))
+	}
+	_, err = cmd.OutOrStdout().Write(out)
+	return err
+}
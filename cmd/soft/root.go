@@ -30,6 +30,11 @@ func init() {
 	rootCmd.AddCommand(
 		serveCmd,
 		manCmd,
+		loginCmd,
+		repoCmd,
+		browseCmd,
+		backupCmd,
+		restoreCmd,
 	)
 	rootCmd.CompletionOptions.HiddenDefaultCmd = true
 
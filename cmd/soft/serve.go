@@ -16,14 +16,27 @@ import (
 )
 
 var (
+	// serveCfg holds the settings of the environment, which flags override.
+	serveCfg, serveCfgErr = config.Environ()
+
 	serveCmd = &cobra.Command{
 		Use:   "serve",
 		Short: "Start the server",
-		Long:  "Start the server",
-		Args:  cobra.NoArgs,
+		Long: `Start the server.
+
+Each setting is read from its flag, its environment variable, or its default,
+in that order. The name, anon-access, and allow-keyless settings override
+those of the config repo when set.`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			cfg := config.DefaultConfig()
-			s := server.NewServer(cfg)
+			if serveCfgErr != nil {
+				return serveCfgErr
+			}
+			cfg := serveCfg.WithDefaults()
+			s, err := server.New(server.WithConfig(cfg))
+			if err != nil {
+				return err
+			}
 
 			log.Print("Starting SSH server", "addr", fmt.Sprintf("%s:%d", cfg.BindAddr, cfg.Port))
 
@@ -50,3 +63,21 @@ var (
 		},
 	}
 )
+
+func init() {
+	if serveCfgErr != nil {
+		return
+	}
+	for _, s := range serveCfg.Settings() {
+		usage := s.Usage
+		if usage == "" {
+			usage = s.Env
+		} else {
+			usage += " (" + s.Env + ")"
+		}
+		f := serveCmd.Flags().VarPF(s, s.Flag, "", usage)
+		if s.Type() == "bool" {
+			f.NoOptDefVal = "true"
+		}
+	}
+}
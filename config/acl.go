@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// ACLVersion is the version of the format of exported access control.
+const ACLVersion = 1
+
+// ErrACLMissingRepos is returned when pruning with access control that
+// doesn't list all the repos with access settings.
+var ErrACLMissingRepos = errors.New("access control is missing repos that are private, have collaborators, or override anonymous access")
+
+// ACL is the access-control model of the server: the anonymous access level,
+// the users with their keys, roles, and OIDC identities, and the visibility,
+// collaborators, and anonymous access of repos.
+// It's exported as a single document that can be imported on another
+// server, e.g. to migrate in stages or to rehearse disaster recovery.
+// Credentials, such as HTTP passwords and tokens, aren't part of it.
+type ACL struct {
+	Version      int       `yaml:"version" json:"version"`
+	AnonAccess   string    `yaml:"anon-access" json:"anon-access"`
+	AllowKeyless bool      `yaml:"allow-keyless" json:"allow-keyless"`
+	Users        []ACLUser `yaml:"users" json:"users"`
+	// Repos are the repos that are private, have collaborators, or
+	// override the anonymous access level; other repos are public.
+	Repos []ACLRepo `yaml:"repos" json:"repos"`
+}
+
+// ACLUser is a user of exported access control.
+type ACLUser struct {
+	Name        string   `yaml:"name" json:"name"`
+	Admin       bool     `yaml:"admin" json:"admin"`
+	PublicKeys  []string `yaml:"public-keys" json:"public-keys"`
+	CollabRepos []string `yaml:"collab-repos" json:"collab-repos"`
+	// Roles are the parts of admin access granted to the user, if any.
+	Roles []string `yaml:"roles,omitempty" json:"roles,omitempty"`
+	// OIDC is the identity of the user at the OIDC provider, if any.
+	OIDC string `yaml:"oidc,omitempty" json:"oidc,omitempty"`
+}
+
+// ACLRepo is the access control of a repo.
+type ACLRepo struct {
+	Repo    string   `yaml:"repo" json:"repo"`
+	Private bool     `yaml:"private" json:"private"`
+	Collabs []string `yaml:"collabs" json:"collabs"`
+	// AnonAccess overrides the anonymous access level for the repo, if
+	// set.
+	AnonAccess string `yaml:"anon-access,omitempty" json:"anon-access,omitempty"`
+}
+
+// Validate returns an error if the access control can't be imported.
+func (a ACL) Validate() error {
+	if a.Version > ACLVersion {
+		return fmt.Errorf("access control version %d is newer than this server's %d", a.Version, ACLVersion)
+	}
+	switch a.AnonAccess {
+	case "", "no-access", "read-only", "read-write", "admin-access":
+	default:
+		return fmt.Errorf("invalid anon-access %q", a.AnonAccess)
+	}
+	names := make(map[string]bool, len(a.Users))
+	admin := false
+	for _, u := range a.Users {
+		if u.Name == "" {
+			return errors.New("users must have a name")
+		}
+		if names[u.Name] {
+			return fmt.Errorf("duplicate user %q", u.Name)
+		}
+		names[u.Name] = true
+		for _, k := range u.PublicKeys {
+			if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(k))); err != nil {
+				return fmt.Errorf("invalid public key of user %q: %w", u.Name, err)
+			}
+		}
+		for _, r := range u.Roles {
+			if err := ValidRole(r); err != nil {
+				return fmt.Errorf("user %q: %w", u.Name, err)
+			}
+		}
+		admin = admin || (u.Admin && len(u.PublicKeys) > 0)
+	}
+	if len(a.Users) > 0 && !admin {
+		return errors.New("access control must keep an admin user with a public key")
+	}
+	for _, r := range a.Repos {
+		if r.Repo == "" {
+			return errors.New("repos must have a name")
+		}
+		switch r.AnonAccess {
+		case "", "no-access", "read-only", "read-write", "admin-access":
+		default:
+			return fmt.Errorf("invalid anon-access %q of repo %q", r.AnonAccess, r.Repo)
+		}
+	}
+	return nil
+}
+
+// ExportACL returns the access control of the server.
+func (cfg *Config) ExportACL() ACL {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	a := ACL{
+		Version:      ACLVersion,
+		AnonAccess:   cfg.AnonAccess,
+		AllowKeyless: cfg.AllowKeyless,
+		Users:        make([]ACLUser, 0, len(cfg.Users)),
+		Repos:        make([]ACLRepo, 0),
+	}
+	for _, u := range cfg.Users {
+		a.Users = append(a.Users, ACLUser{
+			Name:        u.Name,
+			Admin:       u.Admin,
+			PublicKeys:  nonNil(u.PublicKeys),
+			CollabRepos: nonNil(u.CollabRepos),
+			Roles:       u.Roles,
+			OIDC:        u.OIDC,
+		})
+	}
+	for _, r := range cfg.Repos {
+		if r.Private || len(r.Collabs) > 0 || r.AnonAccess != "" {
+			a.Repos = append(a.Repos, ACLRepo{
+				Repo:       r.Repo,
+				Private:    r.Private,
+				Collabs:    nonNil(r.Collabs),
+				AnonAccess: r.AnonAccess,
+			})
+		}
+	}
+	sort.Slice(a.Repos, func(i, j int) bool {
+		return a.Repos[i].Repo < a.Repos[j].Repo
+	})
+	return a
+}
+
+// ImportACL applies access control to the server. Users are matched by
+// name, and their other settings, such as aliases and credentials, are
+// kept. With prune, users missing from the access control are removed. Repos
+// with access settings must all be listed to prune, so that an incomplete
+// document can't make private repos public: ErrACLMissingRepos is returned
+// with the missing ones otherwise. The change is committed to the config
+// repo.
+func (cfg *Config) ImportACL(a ACL, prune bool) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+	if prune {
+		if missing := cfg.aclMissingRepos(a); len(missing) > 0 {
+			return fmt.Errorf("%w: %s", ErrACLMissingRepos, strings.Join(missing, ", "))
+		}
+	}
+	return cfg.editConfig("Import access control", func(doc *yaml.Node) {
+		root := doc.Content[0]
+		if a.AnonAccess != "" {
+			setScalar(mappingValue(root, "anon-access", yaml.ScalarNode), "!!str", a.AnonAccess)
+		}
+		setScalar(mappingValue(root, "allow-keyless", yaml.ScalarNode), "!!bool", strconv.FormatBool(a.AllowKeyless))
+		names := make(map[string]bool, len(a.Users))
+		for _, u := range a.Users {
+			names[u.Name] = true
+			n := userNode(doc, u.Name)
+			setScalar(mappingValue(n, "admin", yaml.ScalarNode), "!!bool", strconv.FormatBool(u.Admin))
+			setStrings(mappingValue(n, "public-keys", yaml.SequenceNode), u.PublicKeys)
+			setStrings(mappingValue(n, "collab-repos", yaml.SequenceNode), u.CollabRepos)
+			if len(u.Roles) > 0 {
+				setStrings(mappingValue(n, "roles", yaml.SequenceNode), u.Roles)
+			} else if mappingValue(n, "roles", 0) != nil {
+				setStrings(mappingValue(n, "roles", yaml.SequenceNode), nil)
+			}
+			if u.OIDC != "" {
+				setScalar(mappingValue(n, "oidc", yaml.ScalarNode), "!!str", u.OIDC)
+			} else if v := mappingValue(n, "oidc", 0); v != nil {
+				setScalar(v, "!!str", "")
+			}
+		}
+		if prune {
+			users := mappingValue(root, "users", yaml.SequenceNode)
+			items := users.Content[:0]
+			for _, n := range users.Content {
+				if v := mappingValue(n, "name", 0); v != nil && names[v.Value] {
+					items = append(items, n)
+				}
+			}
+			users.Content = items
+		}
+		for _, r := range a.Repos {
+			rc := repoNode(doc, r.Repo, true)
+			setScalar(mappingValue(rc, "private", yaml.ScalarNode), "!!bool", strconv.FormatBool(r.Private))
+			setStrings(mappingValue(rc, "collabs", yaml.SequenceNode), r.Collabs)
+			if r.AnonAccess != "" {
+				setScalar(mappingValue(rc, "anon-access", yaml.ScalarNode), "!!str", r.AnonAccess)
+			} else {
+				deleteKey(rc, "anon-access")
+			}
+		}
+	})
+}
+
+// aclMissingRepos returns the repos of the config repo that are private,
+// have collaborators, or override the anonymous access level, but are
+// missing from access control, sorted.
+func (cfg *Config) aclMissingRepos(a ACL) []string {
+	listed := make(map[string]bool, len(a.Repos))
+	for _, r := range a.Repos {
+		listed[r.Repo] = true
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	missing := make([]string, 0)
+	for _, r := range cfg.Repos {
+		if r.repoFile || listed[r.Repo] {
+			continue
+		}
+		if r.Private || len(r.Collabs) > 0 || r.AnonAccess != "" {
+			missing = append(missing, r.Repo)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// setStrings replaces the items of a YAML sequence with scalars.
+func setStrings(seq *yaml.Node, values []string) {
+	seq.Content = make([]*yaml.Node, 0, len(values))
+	for _, v := range values {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: v})
+	}
+}
+
+// nonNil returns s, or an empty slice if s is nil, so that it's exported as
+// an empty list.
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestACLValidate(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	admin := ACLUser{Name: "admin", Admin: true, PublicKeys: []string{key}}
+	is.NoErr(ACL{}.Validate())
+	is.NoErr(ACL{AnonAccess: "read-only", Users: []ACLUser{admin}}.Validate())
+
+	for _, bad := range []ACL{
+		{Version: ACLVersion + 1},
+		{AnonAccess: "everything"},
+		{Users: []ACLUser{{Name: "Frankie", PublicKeys: []string{key}}}},
+		{Users: []ACLUser{admin, {Name: "admin"}}},
+		{Users: []ACLUser{admin, {Name: ""}}},
+		{Users: []ACLUser{admin, {Name: "Frankie", PublicKeys: []string{"not a key"}}}},
+		{Users: []ACLUser{admin, {Name: "Frankie", Roles: []string{"superuser"}}}},
+		{Repos: []ACLRepo{{Private: true}}},
+	} {
+		is.True(bad.Validate() != nil)
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/events"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// actionTimeout is the maximum time an action may take.
+const actionTimeout = time.Minute
+
+// ErrUnknownAction is returned for actions the user can't run on a repo.
+var ErrUnknownAction = errors.New("unknown action")
+
+// Action is a custom action users can run on repos from the TUI, such as
+// triggering a deploy. Actions run on the server.
+type Action struct {
+	// Name is the label of the action in the TUI.
+	Name string `yaml:"name" json:"name"`
+	// Command is a shell command template to run. {{ .Repo }} and
+	// {{ .User }} expand to the shell-quoted repo and user names, which are
+	// also set as SOFT_SERVE_REPO and SOFT_SERVE_USER.
+	Command string `yaml:"command" json:"command"`
+	// URL is a URL template to post the action event to as JSON, with
+	// {{ .Repo }} and {{ .User }} expanding to escaped path segments.
+	URL string `yaml:"url" json:"url"`
+	// Access is the access level to a repo needed to run the action,
+	// read-only, read-write (the default), or admin-access.
+	Access string `yaml:"access" json:"access"`
+	// Repos are glob patterns of the repos the action is offered for, all
+	// repos when empty.
+	Repos []string `yaml:"repos" json:"repos"`
+}
+
+// actionData is the data of action templates.
+type actionData struct {
+	Repo string
+	User string
+}
+
+// RepoActions returns the actions the user with the given public key can
+// run on a repo.
+func (cfg *Config) RepoActions(repo string, pk ssh.PublicKey) []Action {
+	cfg.mtx.Lock()
+	all := cfg.Actions
+	cfg.mtx.Unlock()
+	acc := cfg.AuthRepo(repo, pk)
+	actions := make([]Action, 0)
+	for _, a := range all {
+		if acc < accessLevel(a.Access, gm.ReadWriteAccess) || !a.matches(repo) {
+			continue
+		}
+		actions = append(actions, a)
+	}
+	return actions
+}
+
+func (a Action) matches(repo string) bool {
+	if len(a.Repos) == 0 {
+		return true
+	}
+	for _, p := range a.Repos {
+		if ok, _ := path.Match(p, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RunAction runs the named action on a repo as the user with the given
+// public key, and returns the output of its command. Runs are logged and
+// published as action-run events, which can be forwarded to a SIEM.
+func (cfg *Config) RunAction(ctx context.Context, repo string, pk ssh.PublicKey, name string) (string, error) {
+	var action *Action
+	for _, a := range cfg.RepoActions(repo, pk) {
+		if a.Name == name {
+			a := a
+			action = &a
+			break
+		}
+	}
+	if action == nil {
+		return "", ErrUnknownAction
+	}
+	user := cfg.userName(pk)
+	e := events.Event{
+		Type:   events.ActionRun,
+		Repo:   repo,
+		User:   user,
+		Action: name,
+		Time:   time.Now(),
+	}
+	out, err := action.run(ctx, e)
+	if err != nil {
+		e.Error = err.Error()
+		log.Error("action failed", "action", name, "repo", repo, "user", user, "err", err)
+	} else {
+		log.Info("action run", "action", name, "repo", repo, "user", user)
+	}
+	cfg.Events.Publish(e)
+	return out, err
+}
+
+func (a Action) run(ctx context.Context, e events.Event) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, actionTimeout)
+	defer cancel()
+	var out string
+	if a.Command != "" {
+		command, err := expand(a.Command, actionData{
+			Repo: shellQuote(e.Repo),
+			User: shellQuote(e.User),
+		})
+		if err != nil {
+			return "", err
+		}
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		cmd.Env = append(os.Environ(),
+			"SOFT_SERVE_ACTION="+e.Action,
+			"SOFT_SERVE_REPO="+e.Repo,
+			"SOFT_SERVE_USER="+e.User,
+		)
+		bts, err := cmd.CombinedOutput()
+		out = string(bytes.TrimSpace(bts))
+		if err != nil {
+			return out, fmt.Errorf("%s: %w", a.Name, err)
+		}
+	}
+	if a.URL != "" {
+		u, err := expand(a.URL, actionData{
+			Repo: url.PathEscape(e.Repo),
+			User: url.PathEscape(e.User),
+		})
+		if err != nil {
+			return out, err
+		}
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return out, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(payload))
+		if err != nil {
+			return out, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return out, err
+		}
+		defer res.Body.Close()
+		_, _ = io.Copy(io.Discard, res.Body)
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return out, fmt.Errorf("%s: unexpected status %s", a.Name, res.Status)
+		}
+	}
+	return out, nil
+}
+
+// expand executes the template text with data.
+func expand(text string, data actionData) (string, error) {
+	t, err := template.New("action").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestRunAction(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("actions are run by sh")
+	}
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{key},
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+
+	var posted events.Event
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		is.Equal(r.URL.Path, "/deploy/it's mine")
+		is.NoErr(json.NewDecoder(r.Body).Decode(&posted))
+	}))
+	defer srv.Close()
+	cfg.AnonAccess = "read-only"
+	cfg.Actions = []Action{
+		{Name: "greet", Command: `echo hello {{ .Repo }} from $SOFT_SERVE_USER`, Access: "read-only"},
+		{Name: "deploy", URL: srv.URL + "/deploy/{{ .Repo }}", Repos: []string{"it*"}},
+		{Name: "fail", Command: "echo oops; exit 1", Access: "admin-access"},
+	}
+
+	names := func(as []Action) []string {
+		ns := make([]string, 0)
+		for _, a := range as {
+			ns = append(ns, a.Name)
+		}
+		return ns
+	}
+	is.Equal(names(cfg.RepoActions("it's mine", nil)), []string{"greet"})
+	is.Equal(names(cfg.RepoActions("other", pk)), []string{"greet", "fail"})
+	is.Equal(names(cfg.RepoActions("it's mine", pk)), []string{"greet", "deploy", "fail"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := cfg.Events.Subscribe(ctx)
+	next := func() events.Event {
+		select {
+		case e := <-ch:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("no action-run event")
+		}
+		return events.Event{}
+	}
+
+	// Repo names are quoted in commands.
+	out, err := cfg.RunAction(ctx, "it's mine", pk, "greet")
+	is.NoErr(err)
+	is.Equal(out, "hello it's mine from Admin")
+	e := next()
+	is.Equal(e.Type, events.ActionRun)
+	is.Equal(e.Action, "greet")
+	is.Equal(e.User, "Admin")
+	is.Equal(e.Error, "")
+
+	_, err = cfg.RunAction(ctx, "it's mine", pk, "deploy")
+	is.NoErr(err)
+	is.Equal(posted.Action, "deploy")
+	is.Equal(next().Action, "deploy")
+
+	out, err = cfg.RunAction(ctx, "other", pk, "fail")
+	is.True(err != nil)
+	is.Equal(out, "oops")
+	is.True(next().Error != "") // failures are recorded
+
+	_, err = cfg.RunAction(ctx, "other", nil, "fail")
+	is.Equal(err, ErrUnknownAction)
+}
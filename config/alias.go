@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/gliderlabs/ssh"
+)
+
+// ExpandAlias expands the first argument of an SSH command if it matches a
+// command alias. Aliases of the user owning the public key take precedence
+// over server-wide aliases. Aliases are not expanded recursively.
+func (cfg *Config) ExpandAlias(pk ssh.PublicKey, args []string) []string {
+	if len(args) == 0 {
+		return args
+	}
+	var alias string
+	if u := cfg.findUser(pk); u != nil {
+		alias = u.Aliases[args[0]]
+	}
+	if alias == "" {
+		alias = cfg.Aliases[args[0]]
+	}
+	exp := strings.Fields(alias)
+	if len(exp) == 0 {
+		return args
+	}
+	return append(exp, args[1:]...)
+}
+
+// findUser returns the user with the given public key.
+func (cfg *Config) findUser(pk ssh.PublicKey) *User {
+	if pk == nil {
+		return nil
+	}
+	for _, user := range cfg.Users {
+		for _, k := range user.PublicKeys {
+			apk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(k)))
+			if err != nil {
+				log.Error("malformed authorized key", "key", k)
+				continue
+			}
+			if ssh.KeysEqual(pk, apk) {
+				u := user
+				return &u
+			}
+		}
+	}
+	return nil
+}
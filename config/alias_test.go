@@ -0,0 +1,76 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestExpandAlias(t *testing.T) {
+	userKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	userPk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(userKey))
+	dummyKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	dummyPk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(dummyKey))
+	cfg := Config{
+		Aliases: map[string]string{
+			"l":  "ls",
+			"rd": "range-diff",
+		},
+		Users: []User{
+			{
+				Name:       "user",
+				PublicKeys: []string{userKey},
+				Aliases: map[string]string{
+					"l": "ls  repo",
+				},
+			},
+		},
+	}
+	cases := []struct {
+		name string
+		key  ssh.PublicKey
+		args []string
+		exp  []string
+	}{
+		{
+			name: "no args",
+			args: []string{},
+			exp:  []string{},
+		},
+		{
+			name: "no alias",
+			args: []string{"cat", "repo/README.md"},
+			exp:  []string{"cat", "repo/README.md"},
+		},
+		{
+			name: "server alias, anonymous user",
+			args: []string{"l"},
+			exp:  []string{"ls"},
+		},
+		{
+			name: "server alias with args",
+			key:  dummyPk,
+			args: []string{"rd", "a..b", "c..d"},
+			exp:  []string{"range-diff", "a..b", "c..d"},
+		},
+		{
+			name: "user alias overrides server alias",
+			key:  userPk,
+			args: []string{"l", "-h"},
+			exp:  []string{"ls", "repo", "-h"},
+		},
+		{
+			name: "server alias for user",
+			key:  userPk,
+			args: []string{"rd"},
+			exp:  []string{"range-diff"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			is := is.New(t)
+			is.Equal(c.exp, cfg.ExpandAlias(c.key, c.args))
+		})
+	}
+}
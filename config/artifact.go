@@ -0,0 +1,102 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/artifacts"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// shaRe matches abbreviated and full commit hashes.
+var shaRe = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+var (
+	// ErrUnknownRevision is returned for revisions that don't resolve to a
+	// commit.
+	ErrUnknownRevision = errors.New("unknown revision")
+	// ErrArtifactsDisabled is returned when artifacts are attached without a
+	// data path to store them in.
+	ErrArtifactsDisabled = errors.New("artifacts are disabled")
+)
+
+// artifactTarget resolves a revision of a repo, a branch, tag, or commit
+// hash, to the commit and release tag artifacts are attached to.
+func (cfg *Config) artifactTarget(repo, rev string) (commit, tag string, err error) {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return "", "", err
+	}
+	if ref, err := r.Reference(rev); err == nil {
+		if ref.IsTag() {
+			tag = strings.TrimPrefix(ref.Name().String(), git.RefsTags)
+		}
+		return ref.TargetHash().String(), tag, nil
+	}
+	if !shaRe.MatchString(rev) {
+		return "", "", ErrUnknownRevision
+	}
+	c, err := r.Commit(rev)
+	if err != nil {
+		return "", "", ErrUnknownRevision
+	}
+	return c.Hash.String(), "", nil
+}
+
+// AttachArtifact stores the content read from r as an artifact named name,
+// attached to rev of a repo by the user with the given public key. Artifacts
+// attached to a tag belong to that release.
+func (cfg *Config) AttachArtifact(repo, rev, name string, r io.Reader, pk ssh.PublicKey) (artifacts.Artifact, error) {
+	if cfg.Artifacts == nil {
+		return artifacts.Artifact{}, ErrArtifactsDisabled
+	}
+	commit, tag, err := cfg.artifactTarget(repo, rev)
+	if err != nil {
+		return artifacts.Artifact{}, err
+	}
+	return cfg.Artifacts.Put(repo, artifacts.Artifact{
+		Name:     name,
+		Commit:   commit,
+		Tag:      tag,
+		Uploader: cfg.userName(pk),
+	}, r)
+}
+
+// RepoArtifacts returns the artifacts of a repo, newest first. When rev is
+// set, only the artifacts of that release, or of that commit and its
+// releases, are returned.
+func (cfg *Config) RepoArtifacts(repo, rev string) ([]artifacts.Artifact, error) {
+	if cfg.Artifacts == nil {
+		return []artifacts.Artifact{}, nil
+	}
+	all, err := cfg.Artifacts.List(repo)
+	if err != nil || rev == "" {
+		return all, err
+	}
+	commit, tag, err := cfg.artifactTarget(repo, rev)
+	if err != nil {
+		return nil, err
+	}
+	as := make([]artifacts.Artifact, 0)
+	for _, a := range all {
+		if (tag != "" && a.Tag == tag) || (tag == "" && a.Commit == commit) {
+			as = append(as, a)
+		}
+	}
+	return as, nil
+}
+
+// DeleteArtifact removes the artifact named name attached to rev of a repo.
+func (cfg *Config) DeleteArtifact(repo, rev, name string) error {
+	if cfg.Artifacts == nil {
+		return artifacts.ErrNotFound
+	}
+	commit, tag, err := cfg.artifactTarget(repo, rev)
+	if err != nil {
+		return err
+	}
+	return cfg.Artifacts.Delete(repo, commit, tag, name)
+}
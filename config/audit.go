@@ -0,0 +1,30 @@
+package config
+
+import (
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// AuditCommand publishes an admin-command event recording that the user with
+// the given public key ran a command from remoteAddr, the reason they gave
+// for it if any, and its error if it failed, for the audit log.
+func (cfg *Config) AuditCommand(pk ssh.PublicKey, remoteAddr, command, reason string, err error) {
+	e := events.Event{
+		Type:       events.AdminCommand,
+		User:       cfg.userName(pk),
+		RemoteAddr: remoteAddr,
+		Command:    command,
+		Reason:     reason,
+		Time:       time.Now(),
+	}
+	if pk != nil {
+		e.Key = gossh.FingerprintSHA256(pk)
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	cfg.Events.Publish(e)
+}
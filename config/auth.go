@@ -1,18 +1,38 @@
 package config
 
 import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/events"
 
 	gm "github.com/charmbracelet/wish/git"
 	"github.com/gliderlabs/ssh"
+	"golang.org/x/crypto/bcrypt"
 	gossh "golang.org/x/crypto/ssh"
 )
 
+// maxPushCommits is the maximum number of commits listed in push events.
+const maxPushCommits = 20
+
 // Push registers Git push functionality for the given repo and key.
 func (cfg *Config) Push(repo string, pk ssh.PublicKey) {
+	cfg.PushFrom(repo, pk, cfg.RefHashes(repo))
+}
+
+// PushFrom registers Git push functionality for the given repo and key,
+// given the hashes of the references of the repo before the push, as
+// returned by RefHashes.
+func (cfg *Config) PushFrom(repo string, pk ssh.PublicKey, before map[string]string) {
+	pushesTotal.Inc(repo)
+	cfg.pushes.Add(1)
 	go func() {
+		defer cfg.pushes.Done()
 		err := cfg.Reload()
 		if err != nil {
 			log.Error("error reloading after push", "err", err)
@@ -29,39 +49,340 @@ func (cfg *Config) Push(repo string, pk ssh.PublicKey) {
 		if err != nil {
 			log.Error("error updating server info after push", "err", err)
 		}
+		// Find the dependencies of the new HEAD while nobody waits for them.
+		if !r.IsEmpty() {
+			if _, err := cfg.Dependencies(repo, nil); err != nil {
+				log.Error("error finding dependencies after push", "repo", repo, "err", err)
+			}
+		}
+		user := cfg.userName(pk)
+		after := cfg.RefHashes(repo)
+		updated := make([]string, 0)
+		for ref, hash := range after {
+			if before[ref] != hash {
+				updated = append(updated, ref)
+			}
+		}
+		for ref := range before {
+			if _, ok := after[ref]; !ok {
+				updated = append(updated, ref)
+			}
+		}
+		if len(updated) == 0 {
+			cfg.Events.Publish(events.Event{
+				Type: events.Push,
+				Repo: repo,
+				User: user,
+			})
+			return
+		}
+		sort.Strings(updated)
+		for _, ref := range updated {
+			cfg.Events.Publish(events.Event{
+				Type:    events.Push,
+				Repo:    repo,
+				User:    user,
+				Ref:     ref,
+				Commit:  after[ref],
+				Before:  before[ref],
+				Commits: pushedCommits(r, after[ref], before),
+			})
+		}
 	}()
 }
 
+// pushedCommits returns the commits a push introduced to a reference now at
+// commit, those that no reference reached before the push.
+func pushedCommits(r *Repo, commit string, before map[string]string) []events.Commit {
+	if commit == "" {
+		return nil
+	}
+	exclude := make([]string, 0, len(before))
+	for _, hash := range before {
+		exclude = append(exclude, hash)
+	}
+	cs, err := r.CommitsBetween(commit, exclude, maxPushCommits)
+	if err != nil {
+		log.Error("error listing pushed commits", "repo", r.Repo(), "err", err)
+		return nil
+	}
+	commits := make([]events.Commit, 0, len(cs))
+	for _, c := range cs {
+		commits = append(commits, events.Commit{
+			ID:      c.Hash.String(),
+			Message: c.Message,
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Time:    c.Author.When,
+		})
+	}
+	return commits
+}
+
+// WaitPushes waits for the pushes being processed, reloading the config and
+// publishing their events, to finish.
+func (cfg *Config) WaitPushes() {
+	cfg.pushes.Wait()
+}
+
+// RefHashes returns the hashes of the references of a repo by name, read
+// from the repo rather than the cache, which lags behind pushes.
+func (cfg *Config) RefHashes(repo string) map[string]string {
+	hashes := make(map[string]string)
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return hashes
+	}
+	refs, err := r.repository.References()
+	if err != nil {
+		return hashes
+	}
+	for _, ref := range refs {
+		hashes[ref.Refspec] = ref.Hash.String()
+	}
+	return hashes
+}
+
 // Fetch registers Git fetch functionality for the given repo and key.
 func (cfg *Config) Fetch(repo string, pk ssh.PublicKey) {
+	fetchesTotal.Inc(repo)
 	if cfg.Cfg.Callbacks != nil {
 		cfg.Cfg.Callbacks.Fetch(repo)
 	}
+	cfg.Events.Publish(events.Event{
+		Type: events.Fetch,
+		Repo: repo,
+		User: cfg.userName(pk),
+	})
 }
 
-// AuthRepo grants repo authorization to the given key.
+// AuthProvider grants access to repos from outside the configuration, e.g.
+// from another identity system. Users get the highest access level granted
+// by the configuration and the providers.
+type AuthProvider interface {
+	// AccessLevel returns the access level of a key to a repo. The repo is
+	// empty when authenticating a connection.
+	AccessLevel(repo string, pk ssh.PublicKey) gm.AccessLevel
+}
+
+// AuthRepo grants repo authorization to the given key, the highest access
+// level granted by the configuration, the groups of the key in the LDAP
+// directory, and the auth providers.
 func (cfg *Config) AuthRepo(repo string, pk ssh.PublicKey) gm.AccessLevel {
-	return cfg.accessForKey(repo, pk)
+	al := cfg.accessForKey(repo, pk)
+	if ll := cfg.ldapAccess(repo, pk); ll > al {
+		al = ll
+	}
+	for _, p := range cfg.AuthProviders {
+		if pl := p.AccessLevel(repo, pk); pl > al {
+			al = pl
+		}
+	}
+	return al
+}
+
+// BasicAuth returns the first public key of the user with the given name
+// and HTTP password, so that HTTP requests get the same access as the user's
+// SSH connections. It returns false if the credentials don't match a user
+// with a public key.
+func (cfg *Config) BasicAuth(remoteAddr, name, password string) (ssh.PublicKey, bool) {
+	pk, ok := cfg.basicAuth(name, password)
+	if !ok {
+		authFailuresTotal.Inc("http")
+	}
+	cfg.publishAuth(remoteAddr, "http-password", name, pk, ok)
+	return pk, ok
+}
+
+// basicAuth implements BasicAuth.
+func (cfg *Config) basicAuth(name, password string) (ssh.PublicKey, bool) {
+	cfg.mtx.Lock()
+	var user *User
+	for i, u := range cfg.Users {
+		if u.Name == name && u.HTTPPassword != "" && len(u.PublicKeys) > 0 {
+			user = &cfg.Users[i]
+			break
+		}
+	}
+	cfg.mtx.Unlock()
+	if user == nil {
+		return nil, false
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.HTTPPassword), []byte(password)) != nil {
+		return nil, false
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(user.PublicKeys[0])))
+	if err != nil {
+		log.Error("malformed authorized key", "user", name, "err", err)
+		return nil, false
+	}
+	return pk, true
+}
+
+// TokenAuth returns the first public key of the user with the given API
+// token or personal access token, so that HTTP requests get the same access
+// as the user's SSH connections, and the highest access level the token
+// grants: admin access for API tokens, and the scope of personal access
+// tokens. It returns false if the token doesn't match a user with a public
+// key, or expired.
+func (cfg *Config) TokenAuth(remoteAddr, token string) (ssh.PublicKey, gm.AccessLevel, bool) {
+	pk, scope, ok := cfg.tokenAuth(token)
+	if !ok {
+		authFailuresTotal.Inc("http")
+	}
+	method := "api-token"
+	if strings.HasPrefix(token, TokenPrefix) {
+		method = "personal-token"
+	}
+	cfg.publishAuth(remoteAddr, method, "", pk, ok)
+	return pk, scope, ok
+}
+
+// tokenAuth implements TokenAuth.
+func (cfg *Config) tokenAuth(token string) (ssh.PublicKey, gm.AccessLevel, bool) {
+	if token == "" {
+		return nil, gm.NoAccess, false
+	}
+	sum := sha256.Sum256([]byte(token))
+	digest := []byte(hex.EncodeToString(sum[:]))
+	match := func(t string) bool {
+		return subtle.ConstantTimeCompare([]byte(strings.ToLower(strings.TrimSpace(t))), digest) == 1
+	}
+	now := time.Now()
+	cfg.mtx.Lock()
+	var user *User
+	scope := gm.NoAccess
+	for i, u := range cfg.Users {
+		if len(u.PublicKeys) == 0 {
+			continue
+		}
+		for _, t := range u.APITokens {
+			if match(t) {
+				user, scope = &cfg.Users[i], gm.AdminAccess
+			}
+		}
+		for _, t := range u.Tokens {
+			if match(t.Digest) && !t.Expired(now) {
+				user, scope = &cfg.Users[i], accessLevel(t.Scope, gm.NoAccess)
+			}
+		}
+	}
+	cfg.mtx.Unlock()
+	if user == nil || scope == gm.NoAccess {
+		return nil, gm.NoAccess, false
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(user.PublicKeys[0])))
+	if err != nil {
+		log.Error("malformed authorized key", "user", user.Name, "err", err)
+		return nil, gm.NoAccess, false
+	}
+	return pk, scope, true
 }
 
 // PasswordHandler returns whether or not password access is allowed.
 func (cfg *Config) PasswordHandler(ctx ssh.Context, password string) bool {
-	return (cfg.AnonAccess != "no-access") && cfg.AllowKeyless
+	return cfg.anonAllowed() && cfg.AllowKeyless
 }
 
 // KeyboardInteractiveHandler returns whether or not keyboard interactive is allowed.
 func (cfg *Config) KeyboardInteractiveHandler(ctx ssh.Context, _ gossh.KeyboardInteractiveChallenge) bool {
-	return (cfg.AnonAccess != "no-access") && cfg.AllowKeyless
+	ok := cfg.anonAllowed() && cfg.AllowKeyless
+	if !ok {
+		authFailuresTotal.Inc("keyboard-interactive")
+	}
+	cfg.publishAuth(remoteAddr(ctx), "keyboard-interactive", "", nil, ok)
+	return ok
 }
 
 // PublicKeyHandler returns whether or not the given public key may access the
 // repo.
 func (cfg *Config) PublicKeyHandler(ctx ssh.Context, pk ssh.PublicKey) bool {
-	return cfg.accessForKey("", pk) != gm.NoAccess
+	ok := cfg.AuthRepoCtx(ctx, "", pk) != gm.NoAccess
+	if !ok {
+		authFailuresTotal.Inc("publickey")
+	}
+	cfg.publishAuth(remoteAddr(ctx), "publickey", "", pk, ok)
+	return ok
+}
+
+// publishAuth publishes an authentication event. The user is the one of the
+// key, if any, or name, the name the user tried to authenticate as.
+func (cfg *Config) publishAuth(remoteAddr, method, name string, pk ssh.PublicKey, ok bool) {
+	e := events.Event{
+		Type:       events.AuthFailure,
+		User:       name,
+		RemoteAddr: remoteAddr,
+		Method:     method,
+	}
+	if ok {
+		e.Type = events.AuthSuccess
+	}
+	if pk != nil {
+		e.User = cfg.userName(pk)
+		e.Key = gossh.FingerprintSHA256(pk)
+	}
+	cfg.Events.Publish(e)
 }
 
-func (cfg *Config) anonAccessLevel() gm.AccessLevel {
-	switch cfg.AnonAccess {
+// remoteAddr returns the address of the client of an SSH connection, or an
+// empty string if it's unknown.
+func remoteAddr(ctx ssh.Context) string {
+	if ctx == nil || ctx.RemoteAddr() == nil {
+		return ""
+	}
+	return ctx.RemoteAddr().String()
+}
+
+// userName returns the name of the user with the given public key, or of
+// its user in the LDAP directory, the key fingerprint for unknown users, or
+// an empty string for anonymous users.
+func (cfg *Config) userName(pk ssh.PublicKey) string {
+	if pk == nil {
+		return ""
+	}
+	if u := cfg.findUser(pk); u != nil && u.Name != "" {
+		return u.Name
+	}
+	if u, ok := cfg.LDAPUser(pk); ok && u.Name != "" {
+		return u.Name
+	}
+	return gossh.FingerprintSHA256(pk)
+}
+
+// anonAccessLevel returns the access level of anonymous users to a repo:
+// the one of the repo, if it overrides the server's, or the server's. For
+// the server itself, repo "", it's the highest of them, so that anonymous
+// users can connect to the public repos letting them in.
+func (cfg *Config) anonAccessLevel(repo string) gm.AccessLevel {
+	al := accessLevel(cfg.AnonAccess, gm.NoAccess)
+	if repo == "" {
+		for _, r := range cfg.Repos {
+			if r.AnonAccess != "" && !r.Private {
+				if rl := accessLevel(r.AnonAccess, al); rl > al {
+					al = rl
+				}
+			}
+		}
+		return al
+	}
+	if r := cfg.findRepo(repo); r != nil && r.AnonAccess != "" {
+		return accessLevel(r.AnonAccess, al)
+	}
+	return al
+}
+
+// anonAllowed returns whether anonymous users can connect, because the
+// server or one of its public repos lets them in.
+func (cfg *Config) anonAllowed() bool {
+	if cfg.AnonAccess != "no-access" {
+		return true
+	}
+	return cfg.anonAccessLevel("") > gm.NoAccess
+}
+
+// accessLevel parses an access level, returning def for unknown levels.
+func accessLevel(s string, def gm.AccessLevel) gm.AccessLevel {
+	switch s {
 	case "no-access":
 		return gm.NoAccess
 	case "read-only":
@@ -71,7 +392,7 @@ func (cfg *Config) anonAccessLevel() gm.AccessLevel {
 	case "admin-access":
 		return gm.AdminAccess
 	default:
-		return gm.NoAccess
+		return def
 	}
 }
 
@@ -79,10 +400,15 @@ func (cfg *Config) anonAccessLevel() gm.AccessLevel {
 //
 // If repo doesn't exist, then access is based on user's admin privileges, or
 // config.AnonAccess.
+// Repo admins have admin access to all repos but the config repo.
 // If repo exists, and private, then admins and collabs are allowed access.
-// If repo exists, and not private, then access is based on config.AnonAccess.
+// Members of the teams of a repo, directly or through other teams, get the
+// access the repo grants the teams, if higher, and so do the users the repo
+// grants access to.
+// If repo exists, and not private, then access is based on config.AnonAccess,
+// or the anon-access of the repo overriding it.
 func (cfg *Config) accessForKey(repo string, pk ssh.PublicKey) gm.AccessLevel {
-	anon := cfg.anonAccessLevel()
+	anon := cfg.anonAccessLevel(repo)
 	private := cfg.isPrivate(repo)
 	// Find user
 	for _, user := range cfg.Users {
@@ -92,22 +418,31 @@ func (cfg *Config) accessForKey(repo string, pk ssh.PublicKey) gm.AccessLevel {
 				log.Error("malformed authorized key", "key", k)
 				return gm.NoAccess
 			}
-			if ssh.KeysEqual(pk, apk) {
+			if pk != nil && ssh.KeysEqual(pk, apk) {
 				if user.Admin {
 					return gm.AdminAccess
 				}
 				u := user
+				if repo != "config" && u.hasRole(RoleRepoAdmin) {
+					return gm.AdminAccess
+				}
+				al := gm.NoAccess
 				if cfg.isCollab(repo, &u) {
-					if anon > gm.ReadWriteAccess {
-						return anon
-					}
-					return gm.ReadWriteAccess
+					al = gm.ReadWriteAccess
+				} else if !private {
+					al = gm.ReadOnlyAccess
+				}
+				if tl := cfg.teamAccess(repo, &u); tl > al {
+					al = tl
+				}
+				if ul := cfg.userAccess(repo, &u); ul > al {
+					al = ul
 				}
-				if !private {
-					if anon > gm.ReadOnlyAccess {
+				if al != gm.NoAccess {
+					if anon > al {
 						return anon
 					}
-					return gm.ReadOnlyAccess
+					return al
 				}
 			}
 		}
@@ -120,6 +455,114 @@ func (cfg *Config) accessForKey(repo string, pk ssh.PublicKey) gm.AccessLevel {
 	return anon
 }
 
+// HooksFor returns the hooks of the server and of repo triggered by the given
+// event type. Repo hooks only call URLs: their commands are dropped, since
+// repo configs can be pushed by collaborators. Repos with webhooks turned off
+// only trigger the hooks of the server.
+func (cfg *Config) HooksFor(typ, repo string) []Hook {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all := cfg.Hooks
+	if r := cfg.findRepo(repo); r != nil && cfg.featureEnabled(repo, FeatureWebhooks) {
+		all = append(all[:len(all):len(all)], r.Hooks...)
+		for i := len(cfg.Hooks); i < len(all); i++ {
+			all[i].Command = ""
+		}
+	}
+	hooks := make([]Hook, 0)
+	for _, h := range all {
+		for _, e := range h.Events {
+			if e == typ {
+				hooks = append(hooks, h)
+				break
+			}
+		}
+	}
+	return hooks
+}
+
+// RepoCI returns the CI pipelines configured for the given repo.
+func (cfg *Config) RepoCI(repo string) []CI {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if r := cfg.findRepo(repo); r != nil {
+		return r.CI
+	}
+	return nil
+}
+
+// RepoPages returns the pages configuration of the given repo.
+func (cfg *Config) RepoPages(repo string) Pages {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if r := cfg.findRepo(repo); r != nil {
+		return r.Pages
+	}
+	return Pages{}
+}
+
+// RepoNoGoImport returns whether serving the given repo as a Go module path
+// is disabled.
+func (cfg *Config) RepoNoGoImport(repo string) bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if r := cfg.findRepo(repo); r != nil {
+		return r.NoGoImport
+	}
+	return false
+}
+
+// RepoProfiles returns the path profiles of the given repo.
+func (cfg *Config) RepoProfiles(repo string) map[string]string {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	ps := make(map[string]string)
+	if r := cfg.findRepo(repo); r != nil {
+		for n, p := range r.Profiles {
+			ps[n] = strings.Trim(p, "/")
+		}
+	}
+	return ps
+}
+
+// RepoKind returns whether the given repo is a source repo, a fork, or a
+// mirror.
+func (cfg *Config) RepoKind(repo string) RepoKind {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if r := cfg.findRepo(repo); r != nil {
+		switch {
+		case r.Mirror != "":
+			return KindMirror
+		case r.Fork != "":
+			return KindFork
+		}
+	}
+	return KindSource
+}
+
+// ListingHides returns true if repos of the given kind are hidden from the
+// repo list by default.
+func (cfg *Config) ListingHides(k RepoKind) bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	switch k {
+	case KindFork:
+		return cfg.Listing.HideForks
+	case KindMirror:
+		return cfg.Listing.HideMirrors
+	}
+	return false
+}
+
+// RetentionPolicy returns the retention policy of a class of data, if any.
+func (cfg *Config) RetentionPolicy(class string) (Retention, bool) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	r, ok := cfg.Retention[class]
+	return r, ok
+}
+
 func (cfg *Config) findRepo(repo string) *RepoConfig {
 	for _, r := range cfg.Repos {
 		if r.Repo == repo {
@@ -136,8 +579,31 @@ func (cfg *Config) isPrivate(repo string) bool {
 	return false
 }
 
+// Collabs returns the names of the collaborators of the given repo.
+func (cfg *Config) Collabs(repo string) []string {
+	names := make(map[string]struct{})
+	for _, u := range cfg.Users {
+		if cfg.isCollab(repo, &u) {
+			names[u.Name] = struct{}{}
+		}
+	}
+	collabs := make([]string, 0, len(names))
+	for n := range names {
+		collabs = append(collabs, n)
+	}
+	sort.Strings(collabs)
+	return collabs
+}
+
+// isCollab returns whether a user is a collaborator of a repo, whose access
+// hasn't expired.
 func (cfg *Config) isCollab(repo string, user *User) bool {
 	if user != nil {
+		if r := cfg.findRepo(repo); r != nil {
+			if t, ok := r.CollabExpires[user.Name]; ok && !time.Now().Before(t) {
+				return false
+			}
+		}
 		for _, r := range user.CollabRepos {
 			if r == repo {
 				return true
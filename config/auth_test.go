@@ -1,6 +1,10 @@
 package config
 
 import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/charmbracelet/wish/git"
@@ -641,6 +645,96 @@ func TestAuth(t *testing.T) {
 			},
 		},
 
+		// Repo overrides
+		{
+			name:   "anon access: no-access, repo read-only, anonymous user",
+			repo:   "foo",
+			access: git.ReadOnlyAccess,
+			cfg: Config{
+				AnonAccess: "no-access",
+				Repos: []RepoConfig{
+					{
+						Repo:       "foo",
+						AnonAccess: "read-only",
+					},
+				},
+			},
+		},
+		{
+			name:   "anon access: no-access, repo read-only, anonymous user, other repo",
+			repo:   "bar",
+			access: git.NoAccess,
+			cfg: Config{
+				AnonAccess: "no-access",
+				Repos: []RepoConfig{
+					{
+						Repo:       "foo",
+						AnonAccess: "read-only",
+					},
+					{
+						Repo: "bar",
+					},
+				},
+			},
+		},
+		{
+			name:   "anon access: no-access, repo read-only, anonymous user, server",
+			access: git.ReadOnlyAccess,
+			cfg: Config{
+				AnonAccess: "no-access",
+				Repos: []RepoConfig{
+					{
+						Repo:       "foo",
+						AnonAccess: "read-only",
+					},
+				},
+			},
+		},
+		{
+			name:   "anon access: no-access, private repo read-only, anonymous user, server",
+			access: git.NoAccess,
+			cfg: Config{
+				AnonAccess: "no-access",
+				Repos: []RepoConfig{
+					{
+						Repo:       "foo",
+						Private:    true,
+						AnonAccess: "read-only",
+					},
+				},
+				Users: []User{
+					{
+						Admin: true,
+						PublicKeys: []string{
+							adminKey,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:   "anon access: read-write, repo no-access, authd user",
+			repo:   "foo",
+			key:    dummyPk,
+			access: git.ReadOnlyAccess,
+			cfg: Config{
+				AnonAccess: "read-write",
+				Repos: []RepoConfig{
+					{
+						Repo:       "foo",
+						AnonAccess: "no-access",
+					},
+				},
+				Users: []User{
+					{
+						PublicKeys: []string{
+							dummyKey,
+						},
+					},
+				},
+			},
+		},
+
 		// No users
 		{
 			name:   "anon access: read-only, no users",
@@ -659,7 +753,8 @@ func TestAuth(t *testing.T) {
 			},
 		},
 	}
-	for _, c := range cases {
+	for i := range cases {
+		c := &cases[i]
 		t.Run(c.name, func(t *testing.T) {
 			is := is.New(t)
 			al := c.cfg.accessForKey(c.repo, c.key)
@@ -667,3 +762,64 @@ func TestAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoKind(t *testing.T) {
+	is := is.New(t)
+	cfg := &Config{
+		Repos: []RepoConfig{
+			{Repo: "upstream"},
+			{Repo: "fork", Fork: "upstream"},
+			{Repo: "mirror", Mirror: "https://example.com/upstream.git"},
+		},
+		Listing: Listing{HideForks: true},
+	}
+	is.Equal(cfg.RepoKind("upstream"), KindSource)
+	is.Equal(cfg.RepoKind("fork"), KindFork)
+	is.Equal(cfg.RepoKind("mirror"), KindMirror)
+	is.Equal(cfg.RepoKind("unknown"), KindSource)
+	is.True(cfg.ListingHides(KindFork))
+	is.True(!cfg.ListingHides(KindMirror))
+	is.True(!cfg.ListingHides(KindSource))
+}
+
+// testContext is a bare connection context.
+type testContext struct {
+	context.Context
+	sync.Mutex
+}
+
+func (c *testContext) User() string                  { return "" }
+func (c *testContext) SessionID() string             { return "" }
+func (c *testContext) ClientVersion() string         { return "" }
+func (c *testContext) ServerVersion() string         { return "" }
+func (c *testContext) RemoteAddr() net.Addr          { return nil }
+func (c *testContext) LocalAddr() net.Addr           { return nil }
+func (c *testContext) Permissions() *ssh.Permissions { return nil }
+func (c *testContext) SetValue(key, value interface{}) {
+	c.Context = context.WithValue(c.Context, key, value)
+}
+
+func TestAuthRepoCtx(t *testing.T) {
+	is := is.New(t)
+	adminKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	adminPk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(adminKey))
+	dummyKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	dummyPk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(dummyKey))
+	cfg := &Config{
+		AnonAccess: "no-access",
+		Users:      []User{{Name: "admin", Admin: true, PublicKeys: []string{adminKey}}},
+	}
+	ctx := &testContext{Context: context.Background()}
+	is.Equal(cfg.AuthRepoCtx(ctx, "repo", adminPk), git.AdminAccess)
+
+	// Levels are cached for the connection.
+	cfg.Users = nil
+	is.Equal(cfg.AuthRepoCtx(ctx, "repo", adminPk), git.AdminAccess)
+	// But not across reloads.
+	atomic.AddUint64(&cfg.gen, 1)
+	is.Equal(cfg.AuthRepoCtx(ctx, "repo", adminPk), git.NoAccess)
+	// Nor across keys.
+	cfg.Users = []User{{Name: "admin", Admin: true, PublicKeys: []string{adminKey}}}
+	is.Equal(cfg.AuthRepoCtx(ctx, "repo", dummyPk), git.NoAccess)
+	is.Equal(cfg.AuthRepoCtx(ctx, "repo", adminPk), git.AdminAccess)
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+type authCacheKey struct{}
+
+// authCache holds the access levels resolved for one connection. Clients
+// often open several channels on a connection, e.g. a fetch and the TUI, and
+// only the first one has to resolve them.
+type authCache struct {
+	mtx sync.Mutex
+	// gen is the config generation the levels were resolved with.
+	gen uint64
+	// key is the fingerprint of the key the levels were resolved for.
+	key    string
+	access map[string]gm.AccessLevel
+}
+
+// AuthRepoCtx is like AuthRepo, but caches the access level in the
+// connection context. Cached levels are dropped when the config reloads.
+func (cfg *Config) AuthRepoCtx(ctx ssh.Context, repo string, pk ssh.PublicKey) gm.AccessLevel {
+	if ctx == nil {
+		return cfg.AuthRepo(repo, pk)
+	}
+	c, ok := ctx.Value(authCacheKey{}).(*authCache)
+	if !ok {
+		c = &authCache{}
+		ctx.SetValue(authCacheKey{}, c)
+	}
+	fp := ""
+	if pk != nil {
+		fp = gossh.FingerprintSHA256(pk)
+	}
+	gen := atomic.LoadUint64(&cfg.gen)
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	// Clients may offer several keys before one is accepted.
+	if c.access == nil || c.gen != gen || c.key != fp {
+		c.access = make(map[string]gm.AccessLevel)
+		c.gen = gen
+		c.key = fp
+	}
+	if al, ok := c.access[repo]; ok {
+		return al
+	}
+	al := cfg.AuthRepo(repo, pk)
+	c.access[repo] = al
+	return al
+}
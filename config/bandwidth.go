@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/gliderlabs/ssh"
+)
+
+// bandwidthDir is the directory of the data path bandwidth usage is stored
+// in, in a file per day.
+const bandwidthDir = "bandwidth"
+
+// bandwidthDayLayout is the layout of the dates naming the files of
+// bandwidth usage.
+const bandwidthDayLayout = "2006-01-02"
+
+// Bandwidth is the bandwidth used by a user on a repository during a day.
+// Repo is empty for the sessions that don't run on a repository, such as
+// those of the SSH CLI.
+type Bandwidth struct {
+	// Day is the UTC date of the usage, as 2006-01-02.
+	Day  string `json:"day"`
+	User string `json:"user"`
+	Repo string `json:"repo,omitempty"`
+	// In and Out are the bytes received from and sent to clients.
+	In  int64 `json:"in"`
+	Out int64 `json:"out"`
+}
+
+// RecordBandwidth adds the bytes transferred by a session or git HTTP request
+// of the user with the given public key, anonymous if nil, on repo to the
+// bandwidth usage of the day, and to the metrics.
+func (cfg *Config) RecordBandwidth(pk ssh.PublicKey, repo string, in, out int64) {
+	if in == 0 && out == 0 {
+		return
+	}
+	user := "anonymous"
+	if pk != nil {
+		user = cfg.userName(pk)
+	}
+	bandwidthBytes.Add(float64(in), user, "in")
+	bandwidthBytes.Add(float64(out), user, "out")
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return
+	}
+	day := time.Now().UTC().Format(bandwidthDayLayout)
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	fp := filepath.Join(cfg.Cfg.DataPath, bandwidthDir, day+".json")
+	bs, err := readBandwidth(fp)
+	if err != nil {
+		log.Error("error reading bandwidth usage", "err", err)
+		return
+	}
+	found := false
+	for i := range bs {
+		if bs[i].User == user && bs[i].Repo == repo {
+			bs[i].In += in
+			bs[i].Out += out
+			found = true
+			break
+		}
+	}
+	if !found {
+		bs = append(bs, Bandwidth{Day: day, User: user, Repo: repo, In: in, Out: out})
+	}
+	if err := writeBandwidth(fp, bs); err != nil {
+		log.Error("error writing bandwidth usage", "err", err)
+	}
+}
+
+// BandwidthUsage returns the daily bandwidth usage since the given day,
+// oldest first, and by user and repo within a day.
+func (cfg *Config) BandwidthUsage(since time.Time) ([]Bandwidth, error) {
+	bs := make([]Bandwidth, 0)
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return bs, nil
+	}
+	from := since.UTC().Format(bandwidthDayLayout)
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	dir := filepath.Join(cfg.Cfg.DataPath, bandwidthDir)
+	des, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return bs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, de := range des {
+		day := strings.TrimSuffix(de.Name(), ".json")
+		if _, err := time.Parse(bandwidthDayLayout, day); err != nil || day < from {
+			continue
+		}
+		dbs, err := readBandwidth(filepath.Join(dir, de.Name()))
+		if err != nil {
+			return nil, err
+		}
+		bs = append(bs, dbs...)
+	}
+	sort.SliceStable(bs, func(i, j int) bool {
+		if bs[i].Day != bs[j].Day {
+			return bs[i].Day < bs[j].Day
+		}
+		if bs[i].User != bs[j].User {
+			return bs[i].User < bs[j].User
+		}
+		return bs[i].Repo < bs[j].Repo
+	})
+	return bs, nil
+}
+
+// readBandwidth reads a file of the bandwidth usage of a day.
+func readBandwidth(fp string) ([]Bandwidth, error) {
+	bs := make([]Bandwidth, 0)
+	bts, err := os.ReadFile(fp)
+	if os.IsNotExist(err) {
+		return bs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &bs); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// writeBandwidth writes a file of the bandwidth usage of a day, atomically.
+func writeBandwidth(fp string, bs []Bandwidth) error {
+	bts, err := json.Marshal(bs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(fp), 0o700); err != nil {
+		return err
+	}
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestBandwidthUsage(t *testing.T) {
+	is := is.New(t)
+	cfg := &Config{Cfg: &config.Config{DataPath: t.TempDir()}}
+	cfg.RecordBandwidth(nil, "repo", 10, 100)
+	cfg.RecordBandwidth(nil, "repo", 5, 50)
+	cfg.RecordBandwidth(nil, "", 1, 2)
+	cfg.RecordBandwidth(nil, "other", 0, 0) // nothing transferred
+
+	bs, err := cfg.BandwidthUsage(time.Now())
+	is.NoErr(err)
+	is.Equal(len(bs), 2)
+	day := time.Now().UTC().Format("2006-01-02")
+	is.Equal(bs[0], Bandwidth{Day: day, User: "anonymous", In: 1, Out: 2})
+	is.Equal(bs[1], Bandwidth{Day: day, User: "anonymous", Repo: "repo", In: 15, Out: 150})
+
+	bs, err = cfg.BandwidthUsage(time.Now().Add(48 * time.Hour))
+	is.NoErr(err)
+	is.Equal(len(bs), 0)
+}
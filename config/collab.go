@@ -0,0 +1,310 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	ggit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUnknownUser is returned for users missing from the configuration.
+	ErrUnknownUser = errors.New("unknown user")
+	// ErrConfigNotEditable is returned when the config repo has no YAML
+	// config file for the server to edit.
+	ErrConfigNotEditable = errors.New("only YAML config files can be edited")
+)
+
+// SetCollab adds or removes a user as a collaborator of a repo. The change is
+// committed to the config repo.
+func (cfg *Config) SetCollab(repo, user string, collab bool) error {
+	if collab {
+		return cfg.AddCollab(repo, user, time.Time{})
+	}
+	if !cfg.knownUser(user) {
+		return ErrUnknownUser
+	}
+	msg := fmt.Sprintf("Remove %s as a collaborator of %s", user, repo)
+	return cfg.editConfig(msg, func(doc *yaml.Node) {
+		removeCollab(doc, repo, user)
+	})
+}
+
+// AddCollab adds a user as a collaborator of a repo until expires, or for
+// good if it's zero. The change is committed to the config repo.
+func (cfg *Config) AddCollab(repo, user string, expires time.Time) error {
+	if !cfg.knownUser(user) {
+		return ErrUnknownUser
+	}
+	msg := fmt.Sprintf("Add %s as a collaborator of %s", user, repo)
+	if !expires.IsZero() {
+		msg += fmt.Sprintf(" until %s", expires.UTC().Format(time.RFC3339))
+	}
+	return cfg.editConfig(msg, func(doc *yaml.Node) {
+		rc := repoNode(doc, repo, true)
+		setListItem(mappingValue(rc, "collabs", yaml.SequenceNode), user, true)
+		if expires.IsZero() {
+			if exp := mappingValue(rc, "collab-expires", 0); exp != nil {
+				deleteKey(exp, user)
+			}
+			return
+		}
+		exp := mappingValue(rc, "collab-expires", yaml.MappingNode)
+		setScalar(mappingValue(exp, user, yaml.ScalarNode), "!!timestamp", expires.UTC().Format(time.RFC3339))
+	})
+}
+
+// removeCollab removes a user as a collaborator of a repo from the YAML
+// config file.
+func removeCollab(doc *yaml.Node, repo, user string) {
+	root := doc.Content[0]
+	if rc := repoNode(doc, repo, false); rc != nil {
+		if c := mappingValue(rc, "collabs", 0); c != nil {
+			setListItem(c, user, false)
+		}
+		if exp := mappingValue(rc, "collab-expires", 0); exp != nil {
+			deleteKey(exp, user)
+		}
+	}
+	// Collaborators can also be listed by user.
+	users := mappingValue(root, "users", yaml.SequenceNode)
+	for _, n := range users.Content {
+		if v := mappingValue(n, "name", 0); v != nil && v.Value == user {
+			if cr := mappingValue(n, "collab-repos", 0); cr != nil {
+				setListItem(cr, repo, false)
+			}
+		}
+	}
+}
+
+// CollabGrant is a collaborator of a repo, with the time their access
+// expires, if ever.
+type CollabGrant struct {
+	Repo    string    `json:"repo"`
+	User    string    `json:"user"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// CollabGrants returns the collaborators of a repo, sorted by name.
+func (cfg *Config) CollabGrants(repo string) []CollabGrant {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	grants := make([]CollabGrant, 0)
+	var expires map[string]time.Time
+	if r := cfg.findRepo(repo); r != nil {
+		expires = r.CollabExpires
+	}
+	for _, u := range cfg.Collabs(repo) {
+		grants = append(grants, CollabGrant{Repo: repo, User: u, Expires: expires[u]})
+	}
+	return grants
+}
+
+// ExpiringCollabs returns the collaborators whose access expires after now,
+// and by now+within, soonest first.
+func (cfg *Config) ExpiringCollabs(now time.Time, within time.Duration) []CollabGrant {
+	return cfg.collabsExpiring(func(t time.Time) bool {
+		return t.After(now) && !t.After(now.Add(within))
+	})
+}
+
+// ExpireCollabs removes the collaborators whose access expired at now, in one
+// commit to the config repo, and publishes a collab-expired event for each.
+// It returns the collaborators removed.
+func (cfg *Config) ExpireCollabs(now time.Time) ([]CollabGrant, error) {
+	expired := cfg.collabsExpiring(func(t time.Time) bool {
+		return !now.Before(t)
+	})
+	if len(expired) == 0 {
+		return expired, nil
+	}
+	err := cfg.editConfig("Remove expired collaborators", func(doc *yaml.Node) {
+		for _, g := range expired {
+			removeCollab(doc, g.Repo, g.User)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range expired {
+		expires := g.Expires
+		cfg.Events.Publish(events.Event{
+			Type:    events.CollabExpired,
+			Repo:    g.Repo,
+			User:    g.User,
+			Expires: &expires,
+		})
+	}
+	return expired, nil
+}
+
+// collabsExpiring returns the collaborators whose expiry matches, soonest
+// first.
+func (cfg *Config) collabsExpiring(match func(t time.Time) bool) []CollabGrant {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	grants := make([]CollabGrant, 0)
+	for _, r := range cfg.Repos {
+		for u, t := range r.CollabExpires {
+			if match(t) {
+				grants = append(grants, CollabGrant{Repo: r.Repo, User: u, Expires: t})
+			}
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		if !grants[i].Expires.Equal(grants[j].Expires) {
+			return grants[i].Expires.Before(grants[j].Expires)
+		}
+		if grants[i].Repo != grants[j].Repo {
+			return grants[i].Repo < grants[j].Repo
+		}
+		return grants[i].User < grants[j].User
+	})
+	return grants
+}
+
+// editConfig edits the YAML config file of the config repo, commits the
+// change, and reloads the configuration. Edits hold the config repo
+// exclusively: concurrent edits would commit on top of the same commit, and
+// all but one would fail to push.
+func (cfg *Config) editConfig(msg string, edit func(doc *yaml.Node)) error {
+	defer cfg.Source.LockMaintenance("config")()
+	rp := filepath.Join(cfg.Source.Dir(), "config")
+	repo, err := ggit.Clone(memory.NewStorage(), memfs.New(), &ggit.CloneOptions{
+		URL: rp,
+	})
+	if err != nil {
+		return err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+	var name string
+	for _, n := range []string{"config.yaml", "config.yml"} {
+		if _, err := wt.Filesystem.Stat(n); err == nil {
+			name = n
+			break
+		}
+	}
+	if name == "" {
+		return ErrConfigNotEditable
+	}
+	f, err := wt.Filesystem.Open(name)
+	if err != nil {
+		return err
+	}
+	bts, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(bts, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	edit(&doc)
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	f, err = wt.Filesystem.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if _, err := wt.Add(name); err != nil {
+		return err
+	}
+	opts, err := cfg.commitOptions()
+	if err != nil {
+		return err
+	}
+	if _, err := wt.Commit(msg, opts); err != nil {
+		return err
+	}
+	if err := repo.Push(&ggit.PushOptions{}); err != nil {
+		return err
+	}
+	return cfg.Reload()
+}
+
+// mappingValue returns the value of key in a YAML mapping. When kind isn't
+// zero, a missing key is added with an empty value of that kind.
+func mappingValue(m *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			v := m.Content[i+1]
+			// An empty key, e.g. "collabs:", is a null scalar.
+			if kind != 0 && v.Kind != kind && v.Tag == "!!null" {
+				v.Kind = kind
+				v.Tag = ""
+				v.Value = ""
+			}
+			return v
+		}
+	}
+	if kind == 0 {
+		return nil
+	}
+	v := &yaml.Node{Kind: kind}
+	m.Content = append(m.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		v,
+	)
+	return v
+}
+
+// deleteKey removes a key from a YAML mapping.
+func deleteKey(m *yaml.Node, key string) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// setListItem adds or removes a scalar from a YAML sequence.
+func setListItem(seq *yaml.Node, value string, present bool) {
+	items := seq.Content[:0]
+	found := false
+	for _, n := range seq.Content {
+		if n.Value == value {
+			found = true
+			if !present {
+				continue
+			}
+		}
+		items = append(items, n)
+	}
+	seq.Content = items
+	if present && !found {
+		seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: value})
+	}
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEditConfigConcurrent(t *testing.T) {
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+
+	// Concurrent edits all make it into the config repo.
+	n := 8
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- cfg.editConfig(fmt.Sprintf("Edit %d", i), func(doc *yaml.Node) {
+				mappingValue(doc.Content[0], fmt.Sprintf("edit-%d", i), yaml.ScalarNode).Value = "true"
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		is.NoErr(err)
+	}
+	cr, err := cfg.Source.GetRepo("config")
+	is.NoErr(err)
+	cy, _, err := cr.LatestFile("config.yaml")
+	is.NoErr(err)
+	for i := 0; i < n; i++ {
+		is.True(strings.Contains(cy, fmt.Sprintf("edit-%d: true\n", i)))
+	}
+}
+
+func TestCollabExpiry(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"},
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	private := true
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Private: &private}))
+	is.NoErr(cfg.CreateUser("Frankie", []string{key}))
+	c := cfg.Events.Subscribe(context.Background())
+
+	now := time.Now()
+	is.NoErr(cfg.AddCollab("repo", "Frankie", now.Add(48*time.Hour)))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.ReadWriteAccess)
+	grants := cfg.CollabGrants("repo")
+	is.Equal(len(grants), 1)
+	is.Equal(grants[0].Expires.Unix(), now.Add(48*time.Hour).Unix())
+	is.Equal(len(cfg.ExpiringCollabs(now, 24*time.Hour)), 0)
+	is.Equal(len(cfg.ExpiringCollabs(now, 72*time.Hour)), 1)
+
+	// Nothing expired yet.
+	expired, err := cfg.ExpireCollabs(now)
+	is.NoErr(err)
+	is.Equal(len(expired), 0)
+
+	// Expired collaborators lose access before they're removed.
+	is.NoErr(cfg.AddCollab("repo", "Frankie", now.Add(-time.Second)))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.NoAccess)
+	expired, err = cfg.ExpireCollabs(now)
+	is.NoErr(err)
+	is.Equal(len(expired), 1)
+	is.Equal(len(cfg.CollabGrants("repo")), 0)
+	for e := range c {
+		if e.Type == events.CollabExpired {
+			is.Equal(e.User, "Frankie")
+			is.Equal(e.Expires.Unix(), now.Add(-time.Second).Unix())
+			break
+		}
+	}
+
+	// Adding a collaborator for good clears their expiry.
+	is.NoErr(cfg.AddCollab("repo", "Frankie", now.Add(-time.Second)))
+	is.NoErr(cfg.AddCollab("repo", "Frankie", time.Time{}))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.ReadWriteAccess)
+	is.True(cfg.CollabGrants("repo")[0].Expires.IsZero())
+}
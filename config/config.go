@@ -6,8 +6,11 @@ import (
 	"errors"
 	"io/fs"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
@@ -19,11 +22,14 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/charmbracelet/soft-serve/artifacts"
+	"github.com/charmbracelet/soft-serve/events"
 	"github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/secrets"
 	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/dustin/go-humanize"
 	"github.com/go-git/go-billy/v5/memfs"
 	ggit "github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-git/go-git/v5/storage/memory"
 )
@@ -35,24 +41,110 @@ var (
 
 // Config is the Soft Serve configuration.
 type Config struct {
-	Name         string         `yaml:"name" json:"name"`
-	Host         string         `yaml:"host" json:"host"`
-	Port         int            `yaml:"port" json:"port"`
-	AnonAccess   string         `yaml:"anon-access" json:"anon-access"`
-	AllowKeyless bool           `yaml:"allow-keyless" json:"allow-keyless"`
-	Users        []User         `yaml:"users" json:"users"`
-	Repos        []RepoConfig   `yaml:"repos" json:"repos"`
-	Source       *RepoSource    `yaml:"-" json:"-"`
-	Cfg          *config.Config `yaml:"-" json:"-"`
-	mtx          sync.Mutex
+	// Version is the version of the configuration schema, see
+	// CurrentVersion.
+	Version      int               `yaml:"version" json:"version"`
+	Name         string            `yaml:"name" json:"name"`
+	Host         string            `yaml:"host" json:"host"`
+	Port         int               `yaml:"port" json:"port"`
+	AnonAccess   string            `yaml:"anon-access" json:"anon-access"`
+	AllowKeyless bool              `yaml:"allow-keyless" json:"allow-keyless"`
+	Users        []User            `yaml:"users" json:"users"`
+	Teams        []Team            `yaml:"teams" json:"teams"`
+	Repos        []RepoConfig      `yaml:"repos" json:"repos"`
+	Aliases      map[string]string `yaml:"aliases" json:"aliases"`
+	Hooks        []Hook            `yaml:"hooks" json:"hooks"`
+	Actions      []Action          `yaml:"actions" json:"actions"`
+	Listing      Listing           `yaml:"listing" json:"listing"`
+	Maintenance  []Maintenance     `yaml:"maintenance" json:"maintenance"`
+	Quota        Quota             `yaml:"quota" json:"quota"`
+	// Housekeeping configures the scheduled garbage collection and
+	// repacking of repos.
+	Housekeeping Housekeeping `yaml:"housekeeping" json:"housekeeping"`
+	// Routes send the git commands of some repos to backend git servers.
+	Routes []Route `yaml:"routes" json:"routes"`
+	// OIDC configures signing in to the web UI with an OpenID Connect
+	// provider.
+	OIDC OIDC `yaml:"oidc" json:"oidc"`
+	// LDAP configures looking up the users and groups of keys in a
+	// directory.
+	LDAP LDAP `yaml:"ldap" json:"ldap"`
+	// RequireTOTP requires all users to enable two-factor authentication
+	// to run dangerous commands, such as user delete.
+	RequireTOTP bool `yaml:"require-totp" json:"require-totp"`
+	// RequireReason requires dangerous commands to be given a reason, such
+	// as a ticket, with --reason, which is recorded to the audit log.
+	RequireReason bool `yaml:"require-reason" json:"require-reason"`
+	// Retention maps data classes, such as "audit-logs", to how long their
+	// data is kept.
+	Retention map[string]Retention `yaml:"retention" json:"retention"`
+	Source    Source               `yaml:"-" json:"-"`
+	Cfg       *config.Config       `yaml:"-" json:"-"`
+	Events    *events.Bus          `yaml:"-" json:"-"`
+	Secrets   *secrets.Store       `yaml:"-" json:"-"`
+	// Artifacts stores the files attached to commits and releases.
+	Artifacts *artifacts.Store `yaml:"-" json:"-"`
+	mtx       sync.Mutex
+	// AuthProviders grant access on top of the users of the configuration.
+	AuthProviders []AuthProvider `yaml:"-" json:"-"`
+	// downloads holds the download counts of release assets by repo.
+	downloads map[string]map[string]int64
+	// mirrors holds the sync status of mirrors by repo.
+	mirrors map[string]MirrorStatus
+	// repoState holds whether each repo was private on the last reload.
+	repoState map[string]bool
+	// configHead is the commit of the config repo on the last reload.
+	configHead string
+	// configHooksHead is the commit of the config repo the git hooks were
+	// last copied from.
+	configHooksHead string
+	// deps holds the dependencies of each repo at the last commit asked
+	// for.
+	deps map[string]repoDeps
+	// releases holds the tags of each repo by ref name, dated by the
+	// commits they point to.
+	releases map[string]map[string]Release
+	// deliveries holds the last delivery to each integration target.
+	deliveries map[string]*Delivery
+	// sessions holds the connected SSH sessions by ID.
+	sessions map[string]*liveSession
+	// ldapMtx guards ldapUsers, apart from mtx since lookups wait for the
+	// directory.
+	ldapMtx sync.Mutex
+	// ldapUsers holds the directory lookups of keys by fingerprint.
+	ldapUsers map[string]ldapLookup
+	// compat is the compatibility report of the config file.
+	compat Compatibility
+	// gen counts reloads, so that cached access levels can tell they're
+	// stale.
+	gen uint64
+	// pushes tracks the pushes still being processed.
+	pushes sync.WaitGroup
 }
 
 // User contains user-level configuration for a repository.
 type User struct {
-	Name        string   `yaml:"name" json:"name"`
-	Admin       bool     `yaml:"admin" json:"admin"`
-	PublicKeys  []string `yaml:"public-keys" json:"public-keys"`
-	CollabRepos []string `yaml:"collab-repos" json:"collab-repos"`
+	Name        string            `yaml:"name" json:"name"`
+	Admin       bool              `yaml:"admin" json:"admin"`
+	PublicKeys  []string          `yaml:"public-keys" json:"public-keys"`
+	CollabRepos []string          `yaml:"collab-repos" json:"collab-repos"`
+	Aliases     map[string]string `yaml:"aliases" json:"aliases"`
+	// Roles are the parts of admin access granted to the user, see Role.
+	Roles []string `yaml:"roles" json:"roles"`
+	// HTTPPassword is the bcrypt hash of the password the user
+	// authenticates with over HTTP.
+	HTTPPassword string `yaml:"http-password" json:"-"`
+	// APITokens are the hex-encoded SHA-256 digests of the tokens the user
+	// authenticates to the HTTP API with.
+	APITokens []string `yaml:"api-tokens" json:"-"`
+	// Tokens are the personal access tokens of the user.
+	Tokens []Token `yaml:"tokens" json:"-"`
+	// OIDC is the identity of the user at the OIDC provider, the value of
+	// the claim of its ID tokens set in the OIDC settings, e.g. an email.
+	OIDC string `yaml:"oidc" json:"oidc"`
+	// Searches are the saved searches of the user, listed as tabs of the
+	// repo list.
+	Searches []Search `yaml:"searches" json:"searches"`
 }
 
 // RepoConfig is a repository configuration.
@@ -62,11 +154,175 @@ type RepoConfig struct {
 	Note    string   `yaml:"note" json:"note"`
 	Private bool     `yaml:"private" json:"private"`
 	Readme  string   `yaml:"readme" json:"readme"`
+	Head    string   `yaml:"head" json:"head"`
 	Collabs []string `yaml:"collabs" json:"collabs"`
+	CI      []CI     `yaml:"ci" json:"ci"`
+	Pages   Pages    `yaml:"pages" json:"pages"`
+	// CollabExpires maps collaborators to the time their access expires.
+	// Expired collaborators lose access, and are removed from the config.
+	CollabExpires map[string]time.Time `yaml:"collab-expires" json:"collab-expires"`
+	// Users maps the names of users to the access level the repo grants
+	// them, e.g. when their access requests are approved.
+	Users map[string]string `yaml:"users" json:"users"`
+	// AnonAccess is the access level of anonymous users to the repo,
+	// overriding the anon-access of the server when set. Repo config files
+	// can set up to read-only.
+	AnonAccess string `yaml:"anon-access" json:"anon-access"`
+	// Requestable lists the repo to users without access, even when it's
+	// private, so that they can request access to it.
+	Requestable bool `yaml:"requestable" json:"requestable"`
+	// Teams maps the names of teams to the access level the repo grants
+	// their members, e.g. "read-write".
+	Teams map[string]string `yaml:"teams" json:"teams"`
+	// Hooks are notified of the events of the repo, in addition to the
+	// hooks of the server.
+	Hooks []Hook `yaml:"hooks" json:"hooks"`
+	// NoGoImport stops the repo from being served as a Go module path.
+	NoGoImport bool `yaml:"no-go-import" json:"no-go-import"`
+	// Profiles maps profile names to paths of the repository, so that large
+	// repos can be browsed and archived one component at a time.
+	Profiles map[string]string `yaml:"profiles" json:"profiles"`
+	// Fork is the repo this one was forked from, if any.
+	Fork string `yaml:"fork" json:"fork"`
+	// Mirror is the URL of the upstream this repo mirrors, if any. Mirrors
+	// of the config repo are synced from their upstream periodically.
+	Mirror string `yaml:"mirror" json:"mirror"`
+	// MirrorInterval is the time between the syncs of the mirror, e.g. 30m,
+	// an hour by default.
+	MirrorInterval string `yaml:"mirror-interval" json:"mirror-interval"`
+	// PushMirrors are the remotes the refs of the repo are pushed to after
+	// each push. Only the push mirrors of the config repo are pushed to.
+	PushMirrors []PushMirror `yaml:"push-mirrors" json:"push-mirrors"`
+	// Quota caps the size of the repo, instead of the quota of the server.
+	Quota Quota `yaml:"quota" json:"quota"`
+	// Protect are the rules protecting branches of the repo from pushes.
+	Protect []BranchRule `yaml:"protect" json:"protect"`
+	// Features turns features of the repo off, e.g. releases: false. They're
+	// on unless turned off.
+	Features map[string]bool `yaml:"features" json:"features"`
+	// repoFile is set on the configs read from the config files of repos,
+	// rather than the config repo.
+	repoFile bool
+}
+
+// RepoKind tells source repos apart from forks and mirrors.
+type RepoKind string
+
+const (
+	KindSource RepoKind = "source"
+	KindFork   RepoKind = "fork"
+	KindMirror RepoKind = "mirror"
+)
+
+// Listing configures the repo list of the TUI.
+type Listing struct {
+	// HideForks hides forks from the list unless they're asked for.
+	HideForks bool `yaml:"hide-forks" json:"hide-forks"`
+	// HideMirrors hides mirrors from the list unless they're asked for.
+	HideMirrors bool `yaml:"hide-mirrors" json:"hide-mirrors"`
+}
+
+// Retention is the retention policy of a class of data. Data is purged once
+// it's older than Days, or, oldest first, while the class is larger than
+// MaxSize. Zero values keep data forever.
+type Retention struct {
+	Days int `yaml:"days" json:"days"`
+	// MaxSize caps the total size of the class, e.g. "1GB".
+	MaxSize string `yaml:"max-size" json:"max-size"`
+}
+
+// MaxBytes returns the size cap of the policy in bytes, or 0 for no cap.
+func (r Retention) MaxBytes() (uint64, error) {
+	if r.MaxSize == "" {
+		return 0, nil
+	}
+	n, err := humanize.ParseBytes(r.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid retention max-size %q: %w", r.MaxSize, err)
+	}
+	return n, nil
+}
+
+// Pages configures the static site served over HTTP from a branch of a
+// repository.
+type Pages struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Branch is the branch the site is served from. Defaults to "pages".
+	Branch string `yaml:"branch" json:"branch"`
+	// MaxSize is the maximum total size of the site, e.g. "20MB". Defaults
+	// to 50MB.
+	MaxSize string `yaml:"max-size" json:"max-size"`
+}
+
+// Hook is an external command or URL notified of server events, such as repos
+// being created or deleted.
+type Hook struct {
+	// Events are the types of events that trigger the hook.
+	Events []string `yaml:"events" json:"events"`
+	// Command is a shell command to run. The event is passed as JSON on
+	// stdin and as SOFT_SERVE_* environment variables.
+	Command string `yaml:"command" json:"command"`
+	// URL is a URL the event is posted to as JSON. Failed posts are retried
+	// with backoff.
+	URL string `yaml:"url" json:"url"`
+	// SigningSecret is the name of the secret holding the key the events
+	// posted to URL are signed with, using HMAC-SHA256.
+	SigningSecret string `yaml:"signing-secret" json:"signing-secret"`
+	// Branches are glob patterns of the branches whose pushes trigger the
+	// hook. Pushes to tags don't trigger it when set.
+	Branches []string `yaml:"branches" json:"branches"`
+	// Paths are prefixes of the files whose changes trigger the hook on
+	// pushes. Prefixes starting with ! exclude files, e.g. !docs/.
+	Paths []string `yaml:"paths" json:"paths"`
+}
+
+// CI configures a CI pipeline triggered when a repository is pushed to.
+type CI struct {
+	// Provider is the CI system, one of woodpecker, drone, or buildkite.
+	Provider string `yaml:"provider" json:"provider"`
+	// URL is the URL of the CI server. It defaults to the public API for
+	// hosted providers.
+	URL string `yaml:"url" json:"url"`
+	// Pipeline identifies the pipeline to trigger: "owner/name" for Drone,
+	// the repository ID for Woodpecker, and "organization/pipeline" for
+	// Buildkite.
+	Pipeline string `yaml:"pipeline" json:"pipeline"`
+	// TokenSecret is the name of the secret holding the API token.
+	TokenSecret string `yaml:"token-secret" json:"token-secret"`
+	// Branches are glob patterns of the branches that trigger the pipeline.
+	// All branches trigger it when empty.
+	Branches []string `yaml:"branches" json:"branches"`
+	// Paths are prefixes of the files whose changes trigger the pipeline.
+	// Prefixes starting with ! exclude files, e.g. !docs/.
+	Paths []string `yaml:"paths" json:"paths"`
+}
+
+// PushMirror configures a remote the refs of a repository are pushed to
+// after each push.
+type PushMirror struct {
+	// URL is the URL of the remote repository.
+	URL string `yaml:"url" json:"url"`
+	// CredentialsSecret is the name of the secret holding the credentials
+	// of HTTP remotes, as USER:PASSWORD.
+	CredentialsSecret string `yaml:"credentials-secret" json:"credentials-secret"`
+}
+
+// String returns the URL of the push mirror, without the credentials it may
+// hold.
+func (m PushMirror) String() string {
+	return redactURL(m.URL)
 }
 
 // NewConfig creates a new internal Config struct.
 func NewConfig(cfg *config.Config) (*Config, error) {
+	rs := NewRepoSource(cfg.RepoPath)
+	rs.Mailmap = cfg.MailmapPath
+	return NewConfigWithSource(cfg, rs)
+}
+
+// NewConfigWithSource is like NewConfig, but serves the repos of rs instead
+// of those in cfg.RepoPath.
+func NewConfigWithSource(cfg *config.Config, rs Source) (*Config, error) {
 	var anonAccess string
 	var yamlUsers string
 	var displayHost string
@@ -90,9 +346,25 @@ func NewConfig(cfg *config.Config) (*Config, error) {
 		pks = append(pks, pk)
 	}
 
-	rs := NewRepoSource(cfg.RepoPath)
+	switch cfg.AnonAccess {
+	case "", "no-access", "read-only", "read-write", "admin-access":
+	default:
+		return nil, fmt.Errorf("invalid anonymous access level %q", cfg.AnonAccess)
+	}
+
 	c := &Config{
-		Cfg: cfg,
+		Cfg:    cfg,
+		Events: events.NewBus(),
+	}
+	if cfg.SecretsPath != "" && cfg.SecretsKeyPath != "" {
+		s, err := secrets.Open(cfg.SecretsPath, cfg.SecretsKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error opening secrets: %w", err)
+		}
+		c.Secrets = s
+	}
+	if cfg.DataPath != "" {
+		c.Artifacts = artifacts.NewStore(filepath.Join(cfg.DataPath, "artifacts"))
 	}
 	c.Host = cfg.Host
 	c.Port = port
@@ -109,6 +381,7 @@ func NewConfig(cfg *config.Config) (*Config, error) {
 		displayHost = host
 	}
 	yamlConfig := fmt.Sprintf(defaultConfig,
+		CurrentVersion,
 		displayHost,
 		port,
 		anonAccess,
@@ -131,19 +404,61 @@ func NewConfig(cfg *config.Config) (*Config, error) {
 	return c, nil
 }
 
+// UnknownRepos returns the repos referenced in the configuration that don't
+// exist.
+func (cfg *Config) UnknownRepos() []string {
+	unknown := make([]string, 0)
+	for _, r := range cfg.Repos {
+		if _, err := cfg.Source.GetRepo(r.Repo); err != nil {
+			unknown = append(unknown, r.Repo)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
 // readConfig reads the config file for the repo. All config files are stored in
 // the config repo.
 func (cfg *Config) readConfig(repo string, v interface{}) error {
-	cr, err := cfg.Source.GetRepo("config")
+	src, isJSON, err := cfg.configFile(repo)
 	if err != nil {
 		return err
 	}
+	if isJSON {
+		return json.Unmarshal([]byte(src), v)
+	}
+	return yaml.Unmarshal([]byte(src), v)
+}
+
+// applyOverrides applies the server settings that override the config repo.
+func (cfg *Config) applyOverrides() {
+	if cfg.Cfg == nil {
+		return
+	}
+	if n := cfg.Cfg.Name; n != "" {
+		cfg.Name = n
+	}
+	if a := cfg.Cfg.AnonAccess; a != "" {
+		cfg.AnonAccess = a
+	}
+	if k := cfg.Cfg.AllowKeyless; k != nil {
+		cfg.AllowKeyless = *k
+	}
+}
+
+// configFile returns the contents of the YAML or JSON config file of repo in
+// the config repo, YAML first.
+func (cfg *Config) configFile(repo string) (src string, isJSON bool, err error) {
+	cr, err := cfg.Source.GetRepo("config")
+	if err != nil {
+		return "", false, err
+	}
 	// Parse YAML files
 	var cy string
 	for _, ext := range []string{".yaml", ".yml"} {
 		cy, _, err = cr.LatestFile(repo + ext)
 		if err != nil && !errors.Is(err, git.ErrFileNotFound) {
-			return err
+			return "", false, err
 		} else if err == nil {
 			break
 		}
@@ -151,21 +466,53 @@ func (cfg *Config) readConfig(repo string, v interface{}) error {
 	// Parse JSON files
 	cj, _, err := cr.LatestFile(repo + ".json")
 	if err != nil && !errors.Is(err, git.ErrFileNotFound) {
+		return "", false, err
+	}
+	switch {
+	case cy != "":
+		return cy, false, nil
+	case cj != "":
+		return cj, true, nil
+	default:
+		return "", false, ErrNoConfig
+	}
+}
+
+// readServerConfig reads the server config file into cfg, migrating it to
+// CurrentVersion, and records its compatibility report.
+func (cfg *Config) readServerConfig() error {
+	// JSON is YAML too.
+	src, _, err := cfg.configFile("config")
+	if err != nil {
 		return err
 	}
-	if cy != "" {
-		err = yaml.Unmarshal([]byte(cy), v)
-		if err != nil {
-			return err
-		}
-	} else if cj != "" {
-		err = json.Unmarshal([]byte(cj), v)
-		if err != nil {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return err
+	}
+	c := Compatibility{
+		Version:       CurrentVersion,
+		ServerVersion: CurrentVersion,
+	}
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		root := doc.Content[0]
+		c.Version, c.Migrations = migrate(root)
+		c.Unknown = unknownSettings(root, reflect.TypeOf(cfg), "")
+		if err := root.Decode(cfg); err != nil {
 			return err
 		}
-	} else {
-		return ErrNoConfig
 	}
+	if c.Newer() {
+		log.Warn("config was written for a newer server, ignoring unknown settings",
+			"version", c.Version, "server-version", c.ServerVersion, "unknown", c.Unknown)
+	} else if len(c.Unknown) > 0 {
+		log.Warn("ignoring unknown config settings", "unknown", c.Unknown)
+	}
+	if len(c.Migrations) > 0 {
+		log.Info("migrated config, run the migrate command to save the migration",
+			"from", c.Version, "to", CurrentVersion)
+	}
+	cfg.compat = c
 	return nil
 }
 
@@ -173,13 +520,21 @@ func (cfg *Config) readConfig(repo string, v interface{}) error {
 func (cfg *Config) Reload() error {
 	cfg.mtx.Lock()
 	defer cfg.mtx.Unlock()
+	defer atomic.AddUint64(&cfg.gen, 1)
 	err := cfg.Source.LoadRepos()
 	if err != nil {
 		return err
 	}
-	if err := cfg.readConfig("config", cfg); err != nil {
+	if err := cfg.readServerConfig(); err != nil {
 		return fmt.Errorf("error reading config: %w", err)
 	}
+	cfg.applyOverrides()
+	if err := cfg.LDAP.Validate(); err != nil {
+		log.Error("invalid ldap settings", "err", err)
+	}
+	if err := cfg.syncConfigHooks(); err != nil {
+		log.Error("error copying the git hooks of the config repo", "err", err)
+	}
 	// sanitize repo configs
 	repos := make(map[string]RepoConfig, 0)
 	for _, r := range cfg.Repos {
@@ -197,6 +552,8 @@ func (cfg *Config) Reload() error {
 			}
 			continue
 		}
+		capGrants(repo, &rc)
+		rc.repoFile = true
 		repos[r.Repo()] = rc
 	}
 	cfg.Repos = make([]RepoConfig, 0, len(repos))
@@ -204,6 +561,17 @@ func (cfg *Config) Reload() error {
 		r.Repo = n
 		cfg.Repos = append(cfg.Repos, r)
 	}
+	if err := cfg.validateGrants(); err != nil {
+		log.Error("invalid access grants", "err", err)
+	}
+	for _, r := range cfg.Repos {
+		if _, err := r.mirrorInterval(); r.Mirror != "" && !r.repoFile && err != nil {
+			log.Error("invalid mirror interval, syncing every hour", "repo", r.Repo, "err", err)
+		}
+	}
+	if _, err := cfg.Housekeeping.interval(); err != nil {
+		log.Error("invalid housekeeping interval, collecting every week", "err", err)
+	}
 	// Populate readmes and descriptions
 	for _, r := range cfg.Source.AllRepos() {
 		repo := r.Repo()
@@ -211,6 +579,9 @@ func (cfg *Config) Reload() error {
 		if err != nil {
 			log.Error("error updating server info", "repo", repo, "err", err)
 		}
+		if err := cfg.installGitHooks(repo); err != nil {
+			log.Error("error installing git hooks", "repo", repo, "err", err)
+		}
 		pat := "README*"
 		rp := ""
 		for _, rr := range cfg.Repos {
@@ -219,6 +590,11 @@ func (cfg *Config) Reload() error {
 				r.name = rr.Name
 				r.description = rr.Note
 				r.private = rr.Private
+				if rr.Head != "" {
+					if err := r.SetHEAD(rr.Head); err != nil {
+						log.Error("error setting HEAD", "repo", repo, "head", rr.Head, "err", err)
+					}
+				}
 				break
 			}
 		}
@@ -237,9 +613,81 @@ func (cfg *Config) Reload() error {
 		}
 		r.SetReadme(rm, fp)
 	}
+	cfg.publishLifecycle()
+	cfg.publishConfigUpdated()
 	return nil
 }
 
+// publishConfigUpdated publishes a config-updated event when the config repo
+// points to a different commit than on the last reload. Nothing is published
+// on the first load. The caller must hold the lock.
+func (cfg *Config) publishConfigUpdated() {
+	r, err := cfg.Source.GetRepo("config")
+	if err != nil {
+		return
+	}
+	head, err := r.HEAD()
+	if err != nil {
+		return
+	}
+	prev := cfg.configHead
+	cfg.configHead = head.Hash.String()
+	if prev == "" || prev == cfg.configHead {
+		return
+	}
+	cfg.Events.Publish(events.Event{
+		Type:   events.ConfigUpdated,
+		Repo:   "config",
+		Commit: cfg.configHead,
+	})
+}
+
+// publishLifecycle publishes the repos created, deleted, or changing
+// visibility since the last reload. Nothing is published on the first load.
+// The caller must hold the lock.
+func (cfg *Config) publishLifecycle() {
+	state := make(map[string]bool)
+	for _, r := range cfg.Source.AllRepos() {
+		state[r.Repo()] = r.IsPrivate()
+	}
+	prev := cfg.repoState
+	cfg.repoState = state
+	if prev == nil {
+		return
+	}
+	visibility := func(private bool) string {
+		if private {
+			return "private"
+		}
+		return "public"
+	}
+	for repo, private := range state {
+		was, ok := prev[repo]
+		switch {
+		case !ok:
+			cfg.Events.Publish(events.Event{
+				Type:       events.RepoCreated,
+				Repo:       repo,
+				Visibility: visibility(private),
+			})
+		case was != private:
+			cfg.Events.Publish(events.Event{
+				Type:       events.RepoVisibility,
+				Repo:       repo,
+				Visibility: visibility(private),
+			})
+		}
+	}
+	for repo := range prev {
+		if _, ok := state[repo]; !ok {
+			cfg.Events.Publish(events.Event{
+				Type: events.RepoDeleted,
+				Repo: repo,
+			})
+		}
+	}
+}
+
 func createFile(path string, content string) error {
 	f, err := os.Create(path)
 	if err != nil {
@@ -255,7 +703,7 @@ func createFile(path string, content string) error {
 
 func (cfg *Config) createDefaultConfigRepo(yaml string) error {
 	cn := "config"
-	rp := filepath.Join(cfg.Cfg.RepoPath, cn)
+	rp := filepath.Join(cfg.Source.Dir(), cn)
 	rs := cfg.Source
 	err := rs.LoadRepo(cn)
 	if errors.Is(err, fs.ErrNotExist) {
@@ -297,16 +745,11 @@ func (cfg *Config) createDefaultConfigRepo(yaml string) error {
 		if err != nil {
 			return err
 		}
-		author := object.Signature{
-			Name:  "Soft Serve Server",
-			Email: "vt100@charm.sh",
-			When:  time.Now(),
+		opts, err := cfg.commitOptions()
+		if err != nil {
+			return err
 		}
-		_, err = wt.Commit("Default init", &ggit.CommitOptions{
-			All:       true,
-			Author:    &author,
-			Committer: &author,
-		})
+		_, err = wt.Commit("Default init", opts)
 		if err != nil {
 			return err
 		}
@@ -1,9 +1,19 @@
 package config
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/charmbracelet/soft-serve/events"
 	"github.com/charmbracelet/soft-serve/server/config"
+	ggit "github.com/go-git/go-git/v5"
 	"github.com/matryer/is"
 )
 
@@ -35,3 +45,242 @@ func TestEmptyInitialKeys(t *testing.T) {
 	is.NoErr(err)
 	is.Equal(len(cfg.Users), 0) // should not have any users
 }
+
+func TestSignedConfigCommit(t *testing.T) {
+	is := is.New(t)
+	e, err := openpgp.NewEntity("Soft Serve", "", "server@example.com", nil)
+	is.NoErr(err)
+	kp := filepath.Join(t.TempDir(), "signing.asc")
+	f, err := os.Create(kp)
+	is.NoErr(err)
+	w, err := armor.Encode(f, openpgp.PrivateKeyType, nil)
+	is.NoErr(err)
+	is.NoErr(e.SerializePrivate(w, nil))
+	is.NoErr(w.Close())
+	is.NoErr(f.Close())
+
+	rp := t.TempDir()
+	_, err = NewConfig(&config.Config{
+		RepoPath:       rp,
+		KeyPath:        t.TempDir(),
+		CommitterName:  "Forge",
+		CommitterEmail: "forge@example.com",
+		SigningKeyPath: kp,
+	})
+	is.NoErr(err)
+	r, err := ggit.PlainOpen(filepath.Join(rp, "config"))
+	is.NoErr(err)
+	head, err := r.Head()
+	is.NoErr(err)
+	c, err := r.CommitObject(head.Hash())
+	is.NoErr(err)
+	is.Equal(c.Committer.Name, "Forge")
+	is.Equal(c.Committer.Email, "forge@example.com")
+	_, err = c.Verify(armoredPublicKey(t, e))
+	is.NoErr(err)
+}
+
+func armoredPublicKey(t *testing.T, e *openpgp.Entity) string {
+	t.Helper()
+	var b strings.Builder
+	w, err := armor.Encode(&b, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestDeliveries(t *testing.T) {
+	is := is.New(t)
+	cfg := &Config{}
+	is.Equal(cfg.RetryDelivery("https://example.com"), ErrUnknownTarget)
+	is.Equal(cfg.SetDeliveryDisabled("https://example.com", true), ErrUnknownTarget)
+
+	retries := 0
+	var retry func() error
+	retry = func() error {
+		retries++
+		cfg.RecordDelivery("hook", "https://example.com", time.Millisecond, nil, retry)
+		return nil
+	}
+	cfg.RecordDelivery("hook", "https://example.com", time.Second, errors.New("timeout"), retry)
+	cfg.RecordDelivery("ci", "buildkite deploy", time.Second, nil, nil)
+
+	ds := cfg.Deliveries()
+	is.Equal(len(ds), 2)
+	is.Equal(ds[0].Target, "buildkite deploy")
+	is.True(!ds[0].Failed())
+	is.Equal(ds[1].Err, "timeout")
+	is.True(ds[1].Failed())
+
+	is.NoErr(cfg.RetryDelivery("https://example.com"))
+	is.Equal(retries, 1)
+	is.True(!cfg.Deliveries()[1].Failed())
+
+	is.True(!cfg.DeliveryDisabled("https://example.com"))
+	is.NoErr(cfg.SetDeliveryDisabled("https://example.com", true))
+	is.True(cfg.DeliveryDisabled("https://example.com"))
+	is.True(cfg.Deliveries()[1].Disabled)
+}
+
+func TestSetCollab(t *testing.T) {
+	is := is.New(t)
+	rp := t.TempDir()
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: rp,
+		KeyPath:  t.TempDir(),
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b",
+		},
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo1", true)
+	is.NoErr(err)
+	is.NoErr(cfg.Reload())
+
+	is.Equal(cfg.SetCollab("repo1", "nobody", true), ErrUnknownUser)
+	is.NoErr(cfg.SetCollab("repo1", "Admin", true))
+	is.Equal(cfg.Collabs("repo1"), []string{"Admin"})
+	// Adding twice doesn't duplicate the collaborator.
+	is.NoErr(cfg.SetCollab("repo1", "Admin", true))
+	cr, err := cfg.Source.GetRepo("config")
+	is.NoErr(err)
+	cy, _, err := cr.LatestFile("config.yaml")
+	is.NoErr(err)
+	is.Equal(strings.Count(cy, "- Admin"), 1)
+	// Comments are kept.
+	is.True(strings.Contains(cy, "# The name of the server to show in the TUI."))
+
+	is.NoErr(cfg.SetCollab("repo1", "Admin", false))
+	is.Equal(cfg.Collabs("repo1"), []string{})
+}
+
+func TestSetRepoSettings(t *testing.T) {
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b",
+		},
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo1", true)
+	is.NoErr(err)
+	is.NoErr(cfg.Reload())
+
+	// A description that looks like a bool stays a string.
+	desc, private := "true", true
+	is.NoErr(cfg.SetRepoSettings("repo1", RepoSettings{Description: &desc, Private: &private}))
+	r, err := cfg.Source.GetRepo("repo1")
+	is.NoErr(err)
+	is.Equal(r.Description(), "true")
+	is.True(r.IsPrivate())
+
+	private = false
+	is.NoErr(cfg.SetRepoSettings("repo1", RepoSettings{Private: &private}))
+	r, err = cfg.Source.GetRepo("repo1")
+	is.NoErr(err)
+	is.Equal(r.Description(), "true")
+	is.True(!r.IsPrivate())
+}
+
+func TestConfigUpdated(t *testing.T) {
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b",
+		},
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo1", true)
+	is.NoErr(err)
+	is.NoErr(cfg.Reload())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := cfg.Events.Subscribe(ctx)
+
+	// Reloading the same commit of the config repo publishes nothing.
+	is.NoErr(cfg.Reload())
+	is.NoErr(cfg.SetCollab("repo1", "Admin", true))
+	cr, err := cfg.Source.GetRepo("config")
+	is.NoErr(err)
+	head, err := cr.HEAD()
+	is.NoErr(err)
+	select {
+	case e := <-ch:
+		is.Equal(e.Type, events.ConfigUpdated)
+		is.Equal(e.Commit, head.Hash.String())
+	case <-time.After(time.Second):
+		t.Fatal("no config-updated event")
+	}
+	select {
+	case e := <-ch:
+		t.Fatalf("unexpected event %s", e.Type)
+	default:
+	}
+}
+
+// loggingSource is a Source that records the repos it was asked to load.
+type loggingSource struct {
+	*RepoSource
+	loaded []string
+}
+
+func (s *loggingSource) LoadRepo(name string) error {
+	s.loaded = append(s.loaded, name)
+	return s.RepoSource.LoadRepo(name)
+}
+
+func TestCustomSource(t *testing.T) {
+	is := is.New(t)
+	src := &loggingSource{RepoSource: NewRepoSource(t.TempDir())}
+	cfg, err := NewConfigWithSource(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	}, src)
+	is.NoErr(err)
+	is.Equal(cfg.Source, src)
+	_, err = os.Stat(filepath.Join(src.Dir(), "config"))
+	is.NoErr(err) // config repo should be created in the custom source
+	_, err = src.InitRepo("foo", true)
+	is.NoErr(err)
+	is.NoErr(cfg.Reload())
+	is.True(len(src.loaded) > 0) // should load repos through the custom source
+	_, err = cfg.Source.GetRepo("foo")
+	is.NoErr(err)
+}
+
+func TestOverrides(t *testing.T) {
+	is := is.New(t)
+	keyless := true
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:     t.TempDir(),
+		KeyPath:      t.TempDir(),
+		Name:         "Overridden",
+		AnonAccess:   "no-access",
+		AllowKeyless: &keyless,
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b",
+		},
+	})
+	is.NoErr(err)
+	is.Equal(cfg.Name, "Overridden")
+	is.Equal(cfg.AnonAccess, "no-access")
+	is.Equal(cfg.AllowKeyless, true)
+
+	_, err = NewConfig(&config.Config{
+		RepoPath:   t.TempDir(),
+		KeyPath:    t.TempDir(),
+		AnonAccess: "read-mostly",
+	})
+	is.True(err != nil) // invalid access level
+}
@@ -2,7 +2,11 @@ package config
 
 const defaultReadme = "# Soft Serve\n\n Welcome! You can configure your Soft Serve server by cloning this repo and pushing changes.\n\n```\ngit clone ssh://{{.Host}}:{{.Port}}/config\n```"
 
-const defaultConfig = `# The name of the server to show in the TUI.
+const defaultConfig = `# The version of this configuration file. The server migrates older versions
+# when reading them; run the migrate command to save the migration.
+version: %d
+
+# The name of the server to show in the TUI.
 name: Soft Serve
 
 # The host and port to display in the TUI. You may want to change this if your
@@ -26,6 +30,12 @@ repos:
     private: true
     note: "Configuration and content repo for this server"
     readme: README.md
+
+# Command aliases for the SSH command line. Users can define their own aliases
+# which take precedence over these.
+# aliases:
+#   l: ls
+#   rd: range-diff
 `
 
 const hasKeyUserConfig = `
@@ -52,6 +62,8 @@ const exampleUserConfig = `
 #   - name: Example User
 #     collab-repos:
 #       - REPO
+#     aliases:
+#       cat: cat --color --linenumber
 #     public-keys:
 #       - ssh-ed25519 AAAA... # redacted
 #       - ssh-rsa AAAAB3Nz... # redacted
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrUnknownTarget is returned for integration targets that haven't been
+// delivered to yet.
+var ErrUnknownTarget = errors.New("unknown integration target")
+
+// Delivery is the outcome of the last delivery to an integration target, such
+// as a hook or a CI pipeline.
+type Delivery struct {
+	// Kind is the kind of integration, e.g. "hook" or "ci".
+	Kind string `json:"kind"`
+	// Target identifies the endpoint delivered to.
+	Target string `json:"target"`
+	// Time is when the last delivery finished.
+	Time time.Time `json:"time"`
+	// Latency is how long the last delivery took.
+	Latency time.Duration `json:"latency"`
+	// Err is the error of the last delivery, if it failed.
+	Err string `json:"error,omitempty"`
+	// Disabled targets aren't delivered to until they're enabled again.
+	Disabled bool `json:"disabled"`
+	retry    func() error
+}
+
+// Failed returns true if the last delivery failed.
+func (d Delivery) Failed() bool {
+	return d.Err != ""
+}
+
+// RecordDelivery records the outcome of a delivery to an integration target.
+// retry delivers the same payload again.
+func (cfg *Config) RecordDelivery(kind, target string, latency time.Duration, err error, retry func() error) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if cfg.deliveries == nil {
+		cfg.deliveries = make(map[string]*Delivery)
+	}
+	d, ok := cfg.deliveries[target]
+	if !ok {
+		d = &Delivery{Kind: kind, Target: target}
+		cfg.deliveries[target] = d
+	}
+	d.Time = time.Now()
+	d.Latency = latency
+	d.Err = ""
+	if err != nil {
+		d.Err = err.Error()
+	}
+	d.retry = retry
+}
+
+// Deliveries returns the last deliveries to all integration targets since
+// the server started, sorted by target.
+func (cfg *Config) Deliveries() []Delivery {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	ds := make([]Delivery, 0, len(cfg.deliveries))
+	for _, d := range cfg.deliveries {
+		ds = append(ds, *d)
+	}
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].Target < ds[j].Target
+	})
+	return ds
+}
+
+// DeliveryDisabled returns true if delivering to target is disabled.
+func (cfg *Config) DeliveryDisabled(target string) bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	d, ok := cfg.deliveries[target]
+	return ok && d.Disabled
+}
+
+// SetDeliveryDisabled disables or enables delivering to target until the
+// server restarts.
+func (cfg *Config) SetDeliveryDisabled(target string, disabled bool) error {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	d, ok := cfg.deliveries[target]
+	if !ok {
+		return ErrUnknownTarget
+	}
+	d.Disabled = disabled
+	return nil
+}
+
+// RetryDelivery delivers the last payload to target again.
+func (cfg *Config) RetryDelivery(target string) error {
+	cfg.mtx.Lock()
+	d, ok := cfg.deliveries[target]
+	var retry func() error
+	if ok {
+		retry = d.retry
+	}
+	cfg.mtx.Unlock()
+	if retry == nil {
+		return ErrUnknownTarget
+	}
+	return retry()
+}
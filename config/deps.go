@@ -0,0 +1,87 @@
+package config
+
+import (
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/deps"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// maxManifestSize is the size of the largest manifest parsed for
+// dependencies.
+const maxManifestSize = 1 << 20
+
+// repoDeps are the dependencies of a repo at a commit.
+type repoDeps struct {
+	commit string
+	deps   []deps.Dependency
+}
+
+// Dependencies returns the dependencies declared in the manifests of a repo
+// at ref, or at HEAD when ref is nil. The dependencies of HEAD are cached,
+// and found again after pushes.
+// Manifests that can't be parsed are skipped.
+func (cfg *Config) Dependencies(repo string, ref *git.Reference) ([]deps.Dependency, error) {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	head, err := r.HEAD()
+	if err != nil {
+		return nil, err
+	}
+	if ref == nil {
+		ref = head
+	}
+	commit := ref.Hash.String()
+	cfg.mtx.Lock()
+	cached, ok := cfg.deps[repo]
+	cfg.mtx.Unlock()
+	if ok && cached.commit == commit {
+		return cached.deps, nil
+	}
+
+	files, err := r.Files(ref)
+	if err != nil {
+		return nil, err
+	}
+	t, err := r.Tree(ref, "")
+	if err != nil {
+		return nil, err
+	}
+	ds := make([]deps.Dependency, 0)
+	for _, fp := range files {
+		if !deps.IsManifest(fp) {
+			continue
+		}
+		e, err := t.TreeEntry(fp)
+		if err != nil {
+			return nil, err
+		}
+		if e.Size() > maxManifestSize {
+			log.Warn("skipping large manifest", "repo", repo, "path", fp)
+			continue
+		}
+		bts, err := e.Contents()
+		if err != nil {
+			return nil, err
+		}
+		md, err := deps.Parse(fp, bts)
+		if err != nil {
+			log.Warn("error parsing manifest", "repo", repo, "err", err)
+			continue
+		}
+		ds = append(ds, md...)
+	}
+
+	// Only HEAD is cached, so that searching other refs doesn't evict it.
+	if commit != head.Hash.String() {
+		return ds, nil
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if cfg.deps == nil {
+		cfg.deps = make(map[string]repoDeps)
+	}
+	cfg.deps[repo] = repoDeps{commit: commit, deps: ds}
+	return ds, nil
+}
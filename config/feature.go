@@ -0,0 +1,91 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Feature is a feature of repos that can be turned off per repo.
+type Feature string
+
+const (
+	// FeatureReleases serves the source archives of tags, and shows the tags
+	// and latest release of the repo in the TUI.
+	FeatureReleases Feature = "releases"
+	// FeatureWebhooks notifies the hooks of the repo of its events. The hooks
+	// of the server are notified either way.
+	FeatureWebhooks Feature = "webhooks"
+	// FeatureHousekeeping garbage collects and repacks the repo on the
+	// schedule of the housekeeping settings.
+	FeatureHousekeeping Feature = "housekeeping"
+)
+
+// Features are the features repos can turn off, all on by default.
+var Features = []Feature{FeatureReleases, FeatureWebhooks, FeatureHousekeeping}
+
+// ErrUnknownFeature is returned when turning on or off features repos don't
+// have.
+var ErrUnknownFeature = errors.New("unknown feature, must be releases, webhooks, or housekeeping")
+
+// ParseFeature returns the feature with the given name.
+func ParseFeature(name string) (Feature, error) {
+	for _, f := range Features {
+		if string(f) == name {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFeature, name)
+}
+
+// FeatureEnabled returns whether a feature is on for a repo. Features are on
+// unless the repo turns them off.
+func (cfg *Config) FeatureEnabled(repo string, f Feature) bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	return cfg.featureEnabled(repo, f)
+}
+
+// featureEnabled is FeatureEnabled for callers holding the lock.
+func (cfg *Config) featureEnabled(repo string, f Feature) bool {
+	r := cfg.findRepo(repo)
+	if r == nil {
+		return true
+	}
+	on, ok := r.Features[string(f)]
+	return !ok || on
+}
+
+// RepoFeatures returns whether each feature is on for a repo.
+func (cfg *Config) RepoFeatures(repo string) map[Feature]bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	fs := make(map[Feature]bool, len(Features))
+	for _, f := range Features {
+		fs[f] = cfg.featureEnabled(repo, f)
+	}
+	return fs
+}
+
+// setFeatures turns features of a repo on and off in the YAML mapping of the
+// repo. Features turned on are removed from the mapping, since they're on by
+// default.
+func setFeatures(rc *yaml.Node, features map[Feature]bool) {
+	fs := mappingValue(rc, "features", yaml.MappingNode)
+	for _, f := range Features {
+		on, ok := features[f]
+		if !ok {
+			continue
+		}
+		if on {
+			deleteKey(fs, string(f))
+			continue
+		}
+		setScalar(mappingValue(fs, string(f), yaml.ScalarNode), "!!bool", strconv.FormatBool(on))
+	}
+	if len(fs.Content) == 0 {
+		deleteKey(rc, "features")
+	}
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRepoFeatures(t *testing.T) {
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b",
+		},
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	is.NoErr(cfg.editConfig("Add hooks", func(doc *yaml.Node) {
+		hook := &yaml.Node{Kind: yaml.MappingNode}
+		mappingValue(hook, "url", yaml.ScalarNode).Value = "https://example.com/hook"
+		setStrings(mappingValue(hook, "events", yaml.SequenceNode), []string{"push"})
+		hooks := mappingValue(repoNode(doc, "repo", true), "hooks", yaml.SequenceNode)
+		hooks.Content = append(hooks.Content, hook)
+	}))
+
+	// Features are on by default.
+	is.Equal(cfg.RepoFeatures("repo"), map[Feature]bool{FeatureReleases: true, FeatureWebhooks: true, FeatureHousekeeping: true})
+	is.Equal(len(cfg.HooksFor("push", "repo")), 1)
+
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Features: map[Feature]bool{
+		FeatureReleases: false,
+		FeatureWebhooks: false,
+	}}))
+	is.True(!cfg.FeatureEnabled("repo", FeatureReleases))
+	is.True(!cfg.FeatureEnabled("repo", FeatureWebhooks))
+	is.Equal(len(cfg.HooksFor("push", "repo")), 0)
+	is.True(cfg.FeatureEnabled("other", FeatureReleases))
+
+	// Features turned back on are dropped from the config.
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Features: map[Feature]bool{
+		FeatureWebhooks: true,
+	}}))
+	is.Equal(cfg.RepoFeatures("repo"), map[Feature]bool{FeatureReleases: false, FeatureWebhooks: true, FeatureHousekeeping: true})
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Features: map[Feature]bool{
+		FeatureReleases: true,
+	}}))
+	is.Equal(len(cfg.findRepo("repo").Features), 0)
+
+	err = cfg.SetRepoSettings("repo", RepoSettings{Features: map[Feature]bool{"wiki": false}})
+	is.True(errors.Is(err, ErrUnknownFeature))
+}
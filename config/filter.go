@@ -0,0 +1,281 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/gobwas/glob"
+)
+
+// Filters are the rules deciding which events are delivered to a hook or CI
+// pipeline. Empty filters match all events.
+type Filters struct {
+	// Events are the types of the delivered events.
+	Events []string
+	// Branches are glob patterns of the branches of the delivered pushes.
+	// Pushes to tags don't match.
+	Branches []string
+	// Paths are prefixes of the files changed by the delivered pushes.
+	// Prefixes starting with ! exclude files instead.
+	Paths []string
+}
+
+// FilterCheck is the result of evaluating a filter for an event.
+type FilterCheck struct {
+	Filter string `json:"filter"`
+	Match  bool   `json:"match"`
+	Reason string `json:"reason"`
+}
+
+// Filters returns the filters of a hook.
+func (h Hook) Filters() Filters {
+	return Filters{Events: h.Events, Branches: h.Branches, Paths: h.Paths}
+}
+
+// Filters returns the filters of a CI pipeline, which only builds pushes to
+// branches.
+func (c CI) Filters() Filters {
+	branches := c.Branches
+	if len(branches) == 0 {
+		branches = []string{"**"}
+	}
+	return Filters{Events: []string{string(events.Push)}, Branches: branches, Paths: c.Paths}
+}
+
+// Match returns whether an event passes the filters. files returns the files
+// changed by a push, and is only called when needed.
+func (f Filters) Match(e events.Event, files func() ([]string, error)) bool {
+	return Matched(f.check(e, files, true))
+}
+
+// Check evaluates each filter for an event, e.g. to show why an event was or
+// wasn't delivered. files returns the files changed by a push.
+func (f Filters) Check(e events.Event, files func() ([]string, error)) []FilterCheck {
+	return f.check(e, files, false)
+}
+
+// Matched returns whether all the checks matched.
+func Matched(checks []FilterCheck) bool {
+	for _, c := range checks {
+		if !c.Match {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filters) check(e events.Event, files func() ([]string, error), stop bool) []FilterCheck {
+	checks := make([]FilterCheck, 0, 3)
+	for _, fn := range []func() FilterCheck{
+		func() FilterCheck { return f.checkEvent(e) },
+		func() FilterCheck { return f.checkBranch(e) },
+		func() FilterCheck { return f.checkPaths(e, files) },
+	} {
+		c := fn()
+		checks = append(checks, c)
+		if stop && !c.Match {
+			break
+		}
+	}
+	return checks
+}
+
+func (f Filters) checkEvent(e events.Event) FilterCheck {
+	c := FilterCheck{Filter: "events"}
+	if len(f.Events) == 0 {
+		c.Match, c.Reason = true, "no event filter"
+		return c
+	}
+	for _, t := range f.Events {
+		if t == string(e.Type) {
+			c.Match, c.Reason = true, fmt.Sprintf("%s event", e.Type)
+			return c
+		}
+	}
+	c.Reason = fmt.Sprintf("%s is not one of %s", e.Type, strings.Join(f.Events, ", "))
+	return c
+}
+
+func (f Filters) checkBranch(e events.Event) FilterCheck {
+	c := FilterCheck{Filter: "branches"}
+	switch {
+	case len(f.Branches) == 0:
+		c.Match, c.Reason = true, "no branch filter"
+	case e.Ref == "":
+		c.Match, c.Reason = true, "not a push"
+	case !strings.HasPrefix(e.Ref, git.RefsHeads):
+		c.Reason = fmt.Sprintf("%s is not a branch", e.Ref)
+	default:
+		branch := strings.TrimPrefix(e.Ref, git.RefsHeads)
+		c.Match = MatchBranch(f.Branches, branch)
+		if c.Match {
+			c.Reason = fmt.Sprintf("%s matches %s", branch, strings.Join(f.Branches, ", "))
+		} else {
+			c.Reason = fmt.Sprintf("%s doesn't match %s", branch, strings.Join(f.Branches, ", "))
+		}
+	}
+	return c
+}
+
+func (f Filters) checkPaths(e events.Event, files func() ([]string, error)) FilterCheck {
+	c := FilterCheck{Filter: "paths"}
+	switch {
+	case len(f.Paths) == 0:
+		c.Match, c.Reason = true, "no path filter"
+		return c
+	case e.Type != events.Push:
+		c.Match, c.Reason = true, "not a push"
+		return c
+	case e.Commit == "":
+		c.Reason = "reference deleted"
+		return c
+	}
+	fs, err := files()
+	if err != nil {
+		// Deliver rather than silently drop events when the changes can't
+		// be listed.
+		log.Error("error listing pushed files", "repo", e.Repo, "ref", e.Ref, "err", err)
+		c.Match, c.Reason = true, fmt.Sprintf("changed files unknown: %s", err)
+		return c
+	}
+	for _, fp := range fs {
+		if MatchPath(f.Paths, fp) {
+			c.Match, c.Reason = true, fmt.Sprintf("%s changed", fp)
+			return c
+		}
+	}
+	c.Reason = fmt.Sprintf("none of the %d changed files match %s", len(fs), strings.Join(f.Paths, ", "))
+	return c
+}
+
+// MatchBranch returns whether a branch matches one of the glob patterns.
+// Invalid patterns are logged and skipped.
+func MatchBranch(patterns []string, branch string) bool {
+	for _, p := range patterns {
+		g, err := glob.Compile(p, '/')
+		if err != nil {
+			log.Error("invalid branch pattern", "pattern", p, "err", err)
+			continue
+		}
+		if g.Match(branch) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPath returns whether a file path starts with one of the prefixes, and
+// with none of the prefixes starting with !. Without other prefixes, all
+// paths not excluded match.
+func MatchPath(prefixes []string, fp string) bool {
+	included, match := true, false
+	for _, p := range prefixes {
+		if strings.HasPrefix(p, "!") {
+			if strings.HasPrefix(fp, p[1:]) {
+				return false
+			}
+			continue
+		}
+		included = false
+		match = match || strings.HasPrefix(fp, p)
+	}
+	return included || match
+}
+
+// PushedFiles returns the paths of the files changed by a push. Without the
+// commit the reference pointed to before, e.g. for new branches, they are
+// the files changed by the pushed commits.
+func (cfg *Config) PushedFiles(e events.Event) ([]string, error) {
+	r, err := cfg.Source.GetRepo(e.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if e.Before != "" {
+		return r.ChangedFiles(e.Before, e.Commit)
+	}
+	commits := []string{e.Commit}
+	if len(e.Commits) > 0 {
+		commits = commits[:0]
+		for _, c := range e.Commits {
+			commits = append(commits, c.ID)
+		}
+	}
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, c := range commits {
+		fs, err := r.ChangedFiles("", c)
+		if err != nil {
+			return nil, err
+		}
+		for _, fp := range fs {
+			if !seen[fp] {
+				seen[fp] = true
+				files = append(files, fp)
+			}
+		}
+	}
+	return files, nil
+}
+
+// PushedFilesFunc returns a function listing the files changed by a push
+// once, for evaluating the filters of several endpoints.
+func (cfg *Config) PushedFilesFunc(e events.Event) func() ([]string, error) {
+	var once sync.Once
+	var files []string
+	var err error
+	return func() ([]string, error) {
+		once.Do(func() {
+			files, err = cfg.PushedFiles(e)
+		})
+		return files, err
+	}
+}
+
+// TestPush returns a sample push event of a repo, as if its last commit at
+// ref had just been pushed, for testing hooks and CI pipelines. ref defaults
+// to HEAD.
+func (cfg *Config) TestPush(repo, ref string) (events.Event, error) {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return events.Event{}, err
+	}
+	var rf *git.Reference
+	if ref == "" {
+		rf, err = r.HEAD()
+	} else {
+		rf, err = r.Reference(ref)
+	}
+	if err != nil {
+		return events.Event{}, err
+	}
+	c, err := r.Commit(rf.Hash.String())
+	if err != nil {
+		return events.Event{}, err
+	}
+	e := events.Event{
+		Type:   events.Push,
+		Repo:   r.Repo(),
+		Time:   time.Now(),
+		Ref:    rf.Name().String(),
+		Commit: c.Hash.String(),
+		Test:   true,
+		Commits: []events.Commit{{
+			ID:      c.Hash.String(),
+			Message: c.Message,
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Time:    c.Author.When,
+		}},
+	}
+	if c.ParentsCount() > 0 {
+		if id, err := c.ParentID(0); err == nil {
+			e.Before = id.String()
+		}
+	}
+	return e, nil
+}
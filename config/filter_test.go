@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/matryer/is"
+)
+
+func TestFilters(t *testing.T) {
+	push := events.Event{Type: events.Push, Repo: "repo", Ref: "refs/heads/main", Commit: "abc"}
+	tag := push
+	tag.Ref = "refs/tags/v1.0.0"
+	deleted := push
+	deleted.Commit = ""
+	created := events.Event{Type: events.RepoCreated, Repo: "repo"}
+	docs := func() ([]string, error) { return []string{"docs/index.md", "README.md"}, nil }
+	code := func() ([]string, error) { return []string{"docs/index.md", "cmd/main.go"}, nil }
+	broken := func() ([]string, error) { return nil, errors.New("broken") }
+
+	cases := []struct {
+		name   string
+		f      Filters
+		e      events.Event
+		files  func() ([]string, error)
+		checks []bool
+	}{
+		{"no filters", Filters{}, push, docs, []bool{true, true, true}},
+		{"event", Filters{Events: []string{"fetch"}}, push, docs, []bool{false, true, true}},
+		{"branch", Filters{Branches: []string{"release/*"}}, push, docs, []bool{true, false, true}},
+		{"branch glob", Filters{Branches: []string{"ma*"}}, push, docs, []bool{true, true, true}},
+		{"tag", Filters{Branches: []string{"**"}}, tag, docs, []bool{true, false, true}},
+		{"not a push", Filters{Branches: []string{"main"}, Paths: []string{"src/"}}, created, docs, []bool{true, true, true}},
+		{"docs only", Filters{Paths: []string{"!docs/", "!README.md"}}, push, docs, []bool{true, true, false}},
+		{"code", Filters{Paths: []string{"!docs/", "!README.md"}}, push, code, []bool{true, true, true}},
+		{"prefix", Filters{Paths: []string{"cmd/"}}, push, docs, []bool{true, true, false}},
+		{"deleted", Filters{Paths: []string{"cmd/"}}, deleted, code, []bool{true, true, false}},
+		{"unknown files", Filters{Paths: []string{"cmd/"}}, push, broken, []bool{true, true, true}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			is := is.New(t)
+			checks := c.f.Check(c.e, c.files)
+			matches := make([]bool, 0, len(checks))
+			for _, ch := range checks {
+				matches = append(matches, ch.Match)
+			}
+			is.Equal(matches, c.checks)
+			is.Equal(c.f.Match(c.e, c.files), Matched(checks))
+		})
+	}
+}
+
+func TestFiltersMatchListsFilesOnce(t *testing.T) {
+	is := is.New(t)
+	calls := 0
+	files := func() ([]string, error) {
+		calls++
+		return []string{"main.go"}, nil
+	}
+	e := events.Event{Type: events.Push, Ref: "refs/heads/main", Commit: "abc"}
+	is.True(!Filters{Branches: []string{"dev"}, Paths: []string{"main.go"}}.Match(e, files))
+	is.Equal(calls, 0)
+	is.True(Filters{Paths: []string{"main.go"}}.Match(e, files))
+	is.Equal(calls, 1)
+}
+
+func TestMatchPath(t *testing.T) {
+	is := is.New(t)
+	is.True(MatchPath(nil, "main.go"))
+	is.True(MatchPath([]string{"!docs/"}, "main.go"))
+	is.True(!MatchPath([]string{"!docs/"}, "docs/index.md"))
+	is.True(MatchPath([]string{"cmd/", "pkg/"}, "pkg/x.go"))
+	is.True(!MatchPath([]string{"cmd/", "pkg/"}, "main.go"))
+	is.True(!MatchPath([]string{"cmd/", "!cmd/testdata/"}, "cmd/testdata/x"))
+}
@@ -0,0 +1,131 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/deps"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// maxRefLines is the maximum number of matching lines found by code searches
+// in a repo at a reference.
+const maxRefLines = 100
+
+// RefMatch is what a search found in a repo at a reference.
+type RefMatch struct {
+	Repo   string `json:"repo"`
+	Ref    string `json:"ref"`
+	Commit string `json:"commit"`
+	// Dependencies are the matching dependencies of dependency searches.
+	Dependencies []deps.Dependency `json:"dependencies,omitempty"`
+	// Lines are the matching lines of code searches.
+	Lines []git.GrepMatch `json:"lines,omitempty"`
+	// Truncated is true when there were more matching lines than listed.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// FindDependents returns the repos depending on module at HEAD or, with
+// allRefs, at each branch and tag. Module names match ignoring case, and
+// module can be a pattern, e.g. github.com/charmbracelet/*.
+func (cfg *Config) FindDependents(module string, allRefs bool) ([]RefMatch, error) {
+	module = strings.ToLower(module)
+	if _, err := path.Match(module, ""); err != nil {
+		return nil, fmt.Errorf("invalid module pattern %q: %w", module, err)
+	}
+	return cfg.searchRefs(allRefs, func(r *Repo, ref *git.Reference) (RefMatch, error) {
+		ds, err := cfg.Dependencies(r.Repo(), ref)
+		if err != nil {
+			return RefMatch{}, err
+		}
+		m := RefMatch{}
+		for _, d := range ds {
+			if ok, _ := path.Match(module, strings.ToLower(d.Name)); ok {
+				m.Dependencies = append(m.Dependencies, d)
+			}
+		}
+		return m, nil
+	}), nil
+}
+
+// FindCode returns the lines of the files of repos containing s at HEAD or,
+// with allRefs, at each branch and tag. Binary files are skipped.
+func (cfg *Config) FindCode(s string, allRefs bool) ([]RefMatch, error) {
+	if s == "" {
+		return nil, errors.New("empty search")
+	}
+	return cfg.searchRefs(allRefs, func(r *Repo, ref *git.Reference) (RefMatch, error) {
+		lines, err := r.Grep(ref, s, maxRefLines+1)
+		if err != nil {
+			return RefMatch{}, err
+		}
+		m := RefMatch{Lines: lines}
+		if len(lines) > maxRefLines {
+			m.Lines, m.Truncated = lines[:maxRefLines], true
+		}
+		return m, nil
+	}), nil
+}
+
+// searchRefs searches the repos at HEAD or, with allRefs, at each branch and
+// tag, and returns what was found, grouped by repo and ref. References
+// pointing at the same commit are searched once. Repos that can't be
+// searched are logged and skipped, so that one broken repo doesn't hide the
+// others.
+func (cfg *Config) searchRefs(allRefs bool, search func(*Repo, *git.Reference) (RefMatch, error)) []RefMatch {
+	ms := make([]RefMatch, 0)
+	for _, r := range cfg.Source.AllRepos() {
+		if r.IsEmpty() {
+			continue
+		}
+		refs, err := searchedRefs(r, allRefs)
+		if err != nil {
+			log.Error("error listing refs", "repo", r.Repo(), "err", err)
+			continue
+		}
+		found := make(map[string]RefMatch)
+		for _, ref := range refs {
+			commit := ref.Hash.String()
+			m, ok := found[commit]
+			if !ok {
+				if m, err = search(r, ref); err != nil {
+					log.Error("error searching repo", "repo", r.Repo(), "ref", ref.Name(), "err", err)
+					continue
+				}
+				found[commit] = m
+			}
+			if len(m.Dependencies) == 0 && len(m.Lines) == 0 {
+				continue
+			}
+			m.Repo, m.Ref, m.Commit = r.Repo(), ref.Name().String(), commit
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// searchedRefs returns the HEAD of a repo or, with allRefs, its branches and
+// tags.
+func searchedRefs(r *Repo, allRefs bool) ([]*git.Reference, error) {
+	if !allRefs {
+		head, err := r.HEAD()
+		if err != nil {
+			return nil, err
+		}
+		return []*git.Reference{head}, nil
+	}
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	searched := make([]*git.Reference, 0, len(refs))
+	for _, ref := range refs {
+		if ref.IsBranch() || ref.IsTag() {
+			searched = append(searched, ref)
+		}
+	}
+	return searched, nil
+}
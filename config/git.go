@@ -1,10 +1,17 @@
 package config
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/charmbracelet/log"
 
@@ -29,6 +36,8 @@ type Repo struct {
 	refs        []*git.Reference
 	patchCache  *lru.Cache
 	private     bool
+	// mailmap is the path of the server-wide mailmap, if any.
+	mailmap string
 }
 
 // open opens a Git repository.
@@ -41,9 +50,18 @@ func (rs *RepoSource) open(path string) (*Repo, error) {
 		path:       path,
 		repository: rg,
 		patchCache: lru.New(1000),
+		mailmap:    rs.Mailmap,
 	}
 	_, err = r.HEAD()
 	if err != nil {
+		// Empty repositories have no HEAD yet. Give them the same
+		// placeholder reference as newly created ones.
+		if empty, eerr := rg.IsEmpty(); eerr == nil && empty {
+			r.refs = []*git.Reference{
+				git.NewReference(path, git.RefsHeads+"master"),
+			}
+			return r, nil
+		}
 		return nil, err
 	}
 	_, err = r.References()
@@ -58,6 +76,21 @@ func (r *Repo) IsPrivate() bool {
 	return r.private
 }
 
+// IsEmpty returns true if the repository has no commits yet.
+func (r *Repo) IsEmpty() bool {
+	refs, err := r.References()
+	if err != nil {
+		return false
+	}
+	// Newly created repos have a placeholder reference without a hash.
+	for _, ref := range refs {
+		if ref.Hash != "" {
+			return false
+		}
+	}
+	return true
+}
+
 // Path returns the path to the repository.
 func (r *Repo) Path() string {
 	return r.path
@@ -105,6 +138,17 @@ func (r *Repo) HEAD() (*git.Reference, error) {
 	return h, nil
 }
 
+// SetHEAD points the HEAD of the repository at the given branch, changing its
+// default branch.
+func (r *Repo) SetHEAD(branch string) error {
+	if err := r.repository.SetHEAD(branch); err != nil {
+		return err
+	}
+	r.head = nil
+	r.headCommit = ""
+	return nil
+}
+
 // GetReferences returns the references for a repository.
 func (r *Repo) References() ([]*git.Reference, error) {
 	if r.refs != nil {
@@ -118,11 +162,39 @@ func (r *Repo) References() ([]*git.Reference, error) {
 	return refs, nil
 }
 
+// Reference returns the reference with the given name. The name can be a full
+// reference name, e.g. "refs/heads/main", or a short branch or tag name.
+func (r *Repo) Reference(name string) (*git.Reference, error) {
+	refs, err := r.References()
+	if err != nil {
+		return nil, err
+	}
+	for _, prefix := range []string{"", git.RefsHeads, git.RefsTags} {
+		for _, ref := range refs {
+			if ref.Name().String() == prefix+name {
+				return ref, nil
+			}
+		}
+	}
+	return nil, git.ErrReferenceNotFound
+}
+
 // Tree returns the git tree for a given path.
 func (r *Repo) Tree(ref *git.Reference, path string) (*git.Tree, error) {
 	return r.repository.TreePath(ref, path)
 }
 
+// Files returns the paths of all the files at a given reference.
+func (r *Repo) Files(ref *git.Reference) ([]string, error) {
+	return r.repository.Files(ref)
+}
+
+// Grep returns the lines of the files at a given reference containing s, up
+// to max matches.
+func (r *Repo) Grep(ref *git.Reference, s string, max int) ([]git.GrepMatch, error) {
+	return r.repository.Grep(ref, s, max)
+}
+
 // Diff returns the diff for a given commit.
 func (r *Repo) Diff(commit *git.Commit) (*git.Diff, error) {
 	hash := commit.Hash.String()
@@ -138,9 +210,14 @@ func (r *Repo) Diff(commit *git.Commit) (*git.Diff, error) {
 	return diff, nil
 }
 
+// RangeDiff compares two versions of a series of commits until ctx is done.
+func (r *Repo) RangeDiff(ctx context.Context, color bool, ranges ...string) (string, error) {
+	return r.repository.RangeDiff(ctx, color, ranges...)
+}
+
 // CountCommits returns the number of commits for a repository.
-func (r *Repo) CountCommits(ref *git.Reference) (int64, error) {
-	tc, err := r.repository.CountCommits(ref)
+func (r *Repo) CountCommits(ref *git.Reference, path string) (int64, error) {
+	tc, err := r.repository.CountCommits(ref, path)
 	if err != nil {
 		return 0, err
 	}
@@ -152,20 +229,85 @@ func (r *Repo) Commit(hash string) (*git.Commit, error) {
 	if hash == "HEAD" && r.headCommit != "" {
 		hash = r.headCommit
 	}
+	isHead := hash == "HEAD"
 	c, err := r.repository.CatFileCommit(hash)
 	if err != nil {
 		return nil, err
 	}
-	r.headCommit = c.ID.String()
-	return &git.Commit{
+	if isHead {
+		r.headCommit = c.ID.String()
+	}
+	cm := &git.Commit{
 		Commit: c,
 		Hash:   git.Hash(c.ID.String()),
-	}, nil
+	}
+	r.mapIdentities(cm)
+	return cm, nil
 }
 
 // CommitsByPage returns the commits for a repository.
-func (r *Repo) CommitsByPage(ref *git.Reference, page, size int) (git.Commits, error) {
-	return r.repository.CommitsByPage(ref, page, size)
+func (r *Repo) CommitsByPage(ref *git.Reference, path string, page, size int) (git.Commits, error) {
+	cs, err := r.repository.CommitsByPage(ref, path, page, size)
+	if err != nil {
+		return nil, err
+	}
+	r.mapIdentities(cs...)
+	return cs, nil
+}
+
+// CommitsBetween returns the commits reachable from rev but not from any of
+// the excluded revisions, newest first, up to max commits.
+func (r *Repo) CommitsBetween(rev string, exclude []string, max int) (git.Commits, error) {
+	cs, err := r.repository.CommitsBetween(rev, exclude, max)
+	if err != nil {
+		return nil, err
+	}
+	r.mapIdentities(cs...)
+	return cs, nil
+}
+
+// ChangedFiles returns the paths of the files changed between two commits,
+// or by to alone when from is empty.
+func (r *Repo) ChangedFiles(from, to string) ([]string, error) {
+	return r.repository.ChangedFiles(from, to)
+}
+
+// mapIdentities replaces the authors and committers of commits with their
+// canonical identities from the mailmaps. Commits are left as they are if
+// the mailmaps can't be read.
+func (r *Repo) mapIdentities(cs ...*git.Commit) {
+	idx := make(map[string]int)
+	contacts := make([]string, 0)
+	for _, c := range cs {
+		for _, s := range []*git.Signature{c.Author, c.Committer} {
+			if s == nil || s.Email == "" {
+				continue
+			}
+			k := fmt.Sprintf("%s <%s>", s.Name, s.Email)
+			if _, ok := idx[k]; !ok {
+				idx[k] = len(contacts)
+				contacts = append(contacts, k)
+			}
+		}
+	}
+	mapped, err := r.repository.CheckMailmap(r.mailmap, contacts...)
+	if err != nil || len(mapped) != len(contacts) {
+		if err != nil {
+			log.Debug("error reading mailmap", "repo", r.Repo(), "err", err)
+		}
+		return
+	}
+	for _, c := range cs {
+		for _, s := range []*git.Signature{c.Author, c.Committer} {
+			if s == nil || s.Email == "" {
+				continue
+			}
+			m := mapped[idx[fmt.Sprintf("%s <%s>", s.Name, s.Email)]]
+			if i := strings.LastIndex(m, " <"); i >= 0 && strings.HasSuffix(m, ">") {
+				s.Name, s.Email = m[:i], m[i+2:len(m)-1]
+			}
+		}
+	}
 }
 
 // Push pushes the repository to the remote.
@@ -175,9 +317,14 @@ func (r *Repo) Push(remote, branch string) error {
 
 // RepoSource is a reference to an on-disk repositories.
 type RepoSource struct {
-	Path  string
-	mtx   sync.Mutex
-	repos map[string]*Repo
+	Path string
+	// Mailmap is the path of a mailmap applied to all repos on top of their
+	// own .mailmap.
+	Mailmap string
+	mtx     sync.Mutex
+	repos   map[string]*Repo
+	// locks coordinate pushes with maintenance, by repo name.
+	locks map[string]*sync.RWMutex
 }
 
 // NewRepoSource creates a new RepoSource.
@@ -191,7 +338,8 @@ func NewRepoSource(repoPath string) *RepoSource {
 	return rs
 }
 
-// AllRepos returns all repositories for the given RepoSource.
+// AllRepos returns all repositories for the given RepoSource, sorted by
+// name.
 func (rs *RepoSource) AllRepos() []*Repo {
 	rs.mtx.Lock()
 	defer rs.mtx.Unlock()
@@ -199,6 +347,9 @@ func (rs *RepoSource) AllRepos() []*Repo {
 	for _, r := range rs.repos {
 		repos = append(repos, r)
 	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Repo() < repos[j].Repo()
+	})
 	return repos
 }
 
@@ -298,7 +449,118 @@ func (r *Repo) LatestFile(pattern string) (string, string, error) {
 	return "", "", git.ErrFileNotFound
 }
 
+// Bundle writes a bundle of all the references of the repository to path.
+func (r *Repo) Bundle(path string) error {
+	return r.repository.Bundle(path)
+}
+
+// BundleRefs writes a bundle of the given references to path.
+func (r *Repo) BundleRefs(path string, refs ...string) error {
+	return r.repository.BundleRefs(path, refs...)
+}
+
+// Archive writes an archive of the tree at rev to w. If paths are given, only
+// those are included.
+func (r *Repo) Archive(w io.Writer, rev, format, prefix string, paths ...string) error {
+	return r.repository.Archive(w, rev, format, prefix, paths...)
+}
+
 // UpdateServerInfo updates the server info for the repository.
 func (r *Repo) UpdateServerInfo() error {
 	return r.repository.UpdateServerInfo()
 }
+
+// Size returns the on-disk size of the repository in bytes.
+func (r *Repo) Size() (int64, error) {
+	return dirSize(r.path)
+}
+
+// dirSize returns the size of the regular files in a directory in bytes.
+func dirSize(path string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+	return size, err
+}
+
+// UpdatedAt returns the last time the references of the repository were
+// updated, usually the time of the last push.
+func (r *Repo) UpdatedAt() time.Time {
+	var t time.Time
+	for _, p := range []string{"refs", "packed-refs"} {
+		_ = filepath.WalkDir(filepath.Join(r.path, p), func(_ string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info, err := d.Info(); err == nil && info.ModTime().After(t) {
+				t = info.ModTime()
+			}
+			return nil
+		})
+	}
+	return t
+}
+
+// Orphans compares the repositories on disk with the loaded repositories. It
+// returns the directories on disk that aren't loaded, such as repositories
+// copied in manually or broken ones, and the loaded repositories that no
+// longer exist on disk.
+func (rs *RepoSource) Orphans() (unloaded []string, missing []string, err error) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	rd, err := os.ReadDir(rs.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	onDisk := make(map[string]struct{}, len(rd))
+	for _, de := range rd {
+		if !de.IsDir() {
+			continue
+		}
+		onDisk[de.Name()] = struct{}{}
+		if _, ok := rs.repos[de.Name()]; !ok {
+			unloaded = append(unloaded, de.Name())
+		}
+	}
+	for name := range rs.repos {
+		if _, ok := onDisk[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(unloaded)
+	sort.Strings(missing)
+	return unloaded, missing, nil
+}
+
+// UnloadRepo removes a repository from the source without touching the disk.
+func (rs *RepoSource) UnloadRepo(name string) {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	delete(rs.repos, name)
+}
+
+// RemoveRepo unloads a repository and deletes it from disk.
+func (rs *RepoSource) RemoveRepo(name string) error {
+	rp := filepath.Join(rs.Path, name)
+	// Make sure we don't escape the repo path.
+	if filepath.Dir(rp) != filepath.Clean(rs.Path) {
+		return ErrMissingRepo
+	}
+	rs.UnloadRepo(name)
+	return os.RemoveAll(rp)
+}
@@ -0,0 +1,287 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestOrphans(t *testing.T) {
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	_, err := rs.InitRepo("served", true)
+	is.NoErr(err)
+	_, err = rs.InitRepo("deleted", true)
+	is.NoErr(err)
+	is.NoErr(os.RemoveAll(filepath.Join(rs.Path, "deleted")))
+	is.NoErr(os.Mkdir(filepath.Join(rs.Path, "copied"), 0o700))
+
+	unloaded, missing, err := rs.Orphans()
+	is.NoErr(err)
+	is.Equal(unloaded, []string{"copied"})
+	is.Equal(missing, []string{"deleted"})
+
+	is.NoErr(rs.RemoveRepo("copied"))
+	rs.UnloadRepo("deleted")
+	unloaded, missing, err = rs.Orphans()
+	is.NoErr(err)
+	is.Equal(len(unloaded), 0)
+	is.Equal(len(missing), 0)
+	_, err = os.Stat(filepath.Join(rs.Path, "copied"))
+	is.True(os.IsNotExist(err))
+}
+
+func TestRepoHead(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	wd := t.TempDir()
+	run(wd, "init", "-q")
+	run(wd, "commit", "-q", "--allow-empty", "-m", "first")
+	run(wd, "push", "-q", filepath.Join(cfg.Source.Dir(), "repo"), "HEAD:refs/heads/master", "HEAD:refs/heads/stable")
+
+	cd := t.TempDir()
+	run(cd, "clone", "-q", filepath.Join(cfg.Source.Dir(), "config"), ".")
+	setHead := func(head string) {
+		is.NoErr(os.WriteFile(filepath.Join(cd, "config.yaml"), []byte("repos:\n  - name: Repo\n    repo: repo\n    head: "+head+"\n"), 0o644))
+		run(cd, "commit", "-q", "-am", "set head")
+		run(cd, "push", "-q", "origin", "HEAD")
+		is.NoErr(cfg.Reload())
+	}
+
+	setHead("stable")
+	r, err := cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+	head, err := r.HEAD()
+	is.NoErr(err)
+	is.Equal(head.Name().Short(), "stable")
+
+	// Missing branches leave HEAD alone.
+	setHead("missing")
+	r, err = cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+	head, err = r.HEAD()
+	is.NoErr(err)
+	is.Equal(head.Name().Short(), "stable")
+}
+
+func TestLoadEmptyRepo(t *testing.T) {
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	r, err := rs.InitRepo("empty", true)
+	is.NoErr(err)
+	is.True(r.IsEmpty())
+
+	// Empty repos are loaded after a restart too.
+	rs = NewRepoSource(rs.Path)
+	is.NoErr(rs.LoadRepo("empty"))
+	r, err = rs.GetRepo("empty")
+	is.NoErr(err)
+	is.True(r.IsEmpty())
+}
+
+func TestMailmap(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	rs.Mailmap = filepath.Join(t.TempDir(), "mailmap")
+	is.NoErr(os.WriteFile(rs.Mailmap, []byte("Old Name <new@example.com> <old@example.com>\n"), 0o644))
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+
+	wd := t.TempDir()
+	is.NoErr(runGit(wd, "init", "-q"))
+	is.NoErr(os.WriteFile(filepath.Join(wd, ".mailmap"), []byte("Real Name <real@example.com> <test@example.com>\n"), 0o644))
+	is.NoErr(runGit(wd, "add", "."))
+	is.NoErr(runGit(wd, "commit", "-q", "-m", "add mailmap"))
+	is.NoErr(runGit(wd, "commit", "-q", "--allow-empty", "-m", "old", "--author", "Someone <old@example.com>"))
+	is.NoErr(runGit(wd, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/master"))
+
+	is.NoErr(rs.LoadRepo("repo"))
+	r, err := rs.GetRepo("repo")
+	is.NoErr(err)
+	head, err := r.HEAD()
+	is.NoErr(err)
+	cs, err := r.CommitsByPage(head, "", 1, 10)
+	is.NoErr(err)
+	is.Equal(len(cs), 2)
+	is.Equal(cs[0].Author.Name, "Old Name")
+	is.Equal(cs[0].Author.Email, "new@example.com")
+	is.Equal(cs[0].Committer.Name, "Real Name")
+	is.Equal(cs[1].Author.Name, "Real Name")
+	is.Equal(cs[1].Author.Email, "real@example.com")
+}
+
+func TestDependencies(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	cfg := &Config{Source: rs}
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+
+	wd := t.TempDir()
+	is.NoErr(runGit(wd, "init", "-q"))
+	files := map[string]string{
+		"go.mod":                        "module example.com/app\n\nrequire github.com/matryer/is v1.4.0\n",
+		"web/package.json":              `{"dependencies": {"react": "^18.2.0"}}`,
+		"web/node_modules/package.json": `{"dependencies": {"vendored": "1.0.0"}}`,
+		"broken/package.json":           "{",
+	}
+	for fp, content := range files {
+		is.NoErr(os.MkdirAll(filepath.Join(wd, filepath.Dir(fp)), 0o755))
+		is.NoErr(os.WriteFile(filepath.Join(wd, fp), []byte(content), 0o644))
+	}
+	is.NoErr(runGit(wd, "add", "."))
+	is.NoErr(runGit(wd, "commit", "-q", "-m", "add manifests"))
+	is.NoErr(runGit(wd, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/master"))
+	is.NoErr(rs.LoadRepo("repo"))
+
+	ds, err := cfg.Dependencies("repo", nil)
+	is.NoErr(err)
+	is.Equal(len(ds), 2)
+	is.Equal(ds[0].Name, "github.com/matryer/is")
+	is.Equal(ds[1].Name, "react")
+	is.Equal(ds[1].Manifest, "web/package.json")
+}
+
+func TestFind(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	cfg := &Config{Source: rs}
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	_, err = rs.InitRepo("empty", true)
+	is.NoErr(err)
+
+	wd := t.TempDir()
+	is.NoErr(runGit(wd, "init", "-q"))
+	commit := func(gomod string) {
+		is.NoErr(os.WriteFile(filepath.Join(wd, "go.mod"), []byte(gomod), 0o644))
+		is.NoErr(os.WriteFile(filepath.Join(wd, "main.go"), []byte("package main\n\n// TODO: fix\n"), 0o644))
+		is.NoErr(runGit(wd, "add", "."))
+		is.NoErr(runGit(wd, "commit", "-q", "-m", "update"))
+	}
+	commit("module example.com/app\n\nrequire github.com/gorilla/websocket v1.4.0\n")
+	is.NoErr(runGit(wd, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/old"))
+	commit("module example.com/app\n\nrequire github.com/matryer/is v1.4.0\n")
+	is.NoErr(runGit(wd, "tag", "v1"))
+	is.NoErr(runGit(wd, "push", "-q", filepath.Join(rs.Path, "repo"), "HEAD:refs/heads/master", "v1"))
+	is.NoErr(rs.LoadRepo("repo"))
+	is.NoErr(rs.LoadRepo("empty"))
+
+	ms, err := cfg.FindDependents("github.com/Gorilla/*", false)
+	is.NoErr(err)
+	is.Equal(len(ms), 0)
+	ms, err = cfg.FindDependents("github.com/Gorilla/*", true)
+	is.NoErr(err)
+	is.Equal(len(ms), 1)
+	is.Equal(ms[0].Repo, "repo")
+	is.Equal(ms[0].Ref, "refs/heads/old")
+	is.Equal(ms[0].Dependencies[0].Name, "github.com/gorilla/websocket")
+	_, err = cfg.FindDependents("[", false)
+	is.True(err != nil)
+
+	ms, err = cfg.FindCode("TODO:", true)
+	is.NoErr(err)
+	is.Equal(len(ms), 3)
+	refs := []string{}
+	for _, m := range ms {
+		refs = append(refs, m.Ref)
+		is.Equal(len(m.Lines), 1)
+		is.Equal(m.Lines[0].Path, "main.go")
+		is.Equal(m.Lines[0].Line, 3)
+		is.Equal(m.Lines[0].Text, "// TODO: fix")
+	}
+	is.Equal(refs, []string{"refs/heads/master", "refs/heads/old", "refs/tags/v1"})
+	ms, err = cfg.FindCode("nowhere to be found", true)
+	is.NoErr(err)
+	is.Equal(len(ms), 0)
+}
+
+func TestRangeDiff(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	_, err := rs.InitRepo("repo", false)
+	is.NoErr(err)
+	wd := filepath.Join(rs.Path, "repo")
+	for _, args := range [][]string{
+		{"commit", "-q", "--allow-empty", "-m", "base"},
+		{"checkout", "-q", "-b", "old"},
+		{"commit", "-q", "--allow-empty", "-m", "one"},
+		{"commit", "-q", "--allow-empty", "-m", "two"},
+		{"checkout", "-q", "-b", "new", "HEAD~1"},
+		{"commit", "-q", "--allow-empty", "-m", "two, reworded"},
+	} {
+		is.NoErr(runGit(wd, args...))
+	}
+	is.NoErr(rs.LoadRepo("repo"))
+	r, err := rs.GetRepo("repo")
+	is.NoErr(err)
+	ctx := context.Background()
+
+	out, err := r.RangeDiff(ctx, false, "master", "old", "new")
+	is.NoErr(err)
+	is.True(strings.Contains(out, " = 1:  ")) // "one" is unchanged
+	is.True(strings.Contains(out, " > 2:  ")) // "two" was replaced
+	same, err := r.RangeDiff(ctx, false, "old...new")
+	is.NoErr(err)
+	is.True(strings.Contains(same, "two, reworded"))
+
+	_, err = r.RangeDiff(ctx, false, "--output=x", "old")
+	is.True(errors.Is(err, git.ErrInvalidRange))
+	_, err = r.RangeDiff(ctx, false, "old..new")
+	is.True(errors.Is(err, git.ErrInvalidRange))
+	_, err = r.RangeDiff(ctx, false, "old...--output=x")
+	is.True(errors.Is(err, git.ErrInvalidRange))
+	_, err = r.RangeDiff(ctx, false, "old...")
+	is.True(errors.Is(err, git.ErrInvalidRange))
+
+	// Series are capped.
+	defer func(n int) { git.RangeDiffMaxCommits = n }(git.RangeDiffMaxCommits)
+	git.RangeDiffMaxCommits = 1
+	_, err = r.RangeDiff(ctx, false, "master", "old", "new")
+	is.True(errors.Is(err, git.ErrRangeTooLarge))
+	git.RangeDiffMaxCommits = 2
+
+	// Git is killed when the context is done.
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = r.RangeDiff(ctx, false, "master", "old", "new")
+	is.True(errors.Is(err, context.Canceled))
+}
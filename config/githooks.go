@@ -0,0 +1,226 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// gitHookNames are the server-side git hooks run on pushes.
+var gitHookNames = []string{"pre-receive", "update", "post-receive"}
+
+// gitHookMarker marks the git hooks installed by the server. Hooks without it
+// were put in the repo by hand, and are left alone.
+const gitHookMarker = "# Installed by Soft Serve"
+
+// configHooksDir is the directory of the data path the hooks of the config
+// repo are copied to.
+const configHooksDir = "config-hooks"
+
+// gitHooksDirs returns the absolute paths of the directories of git hooks:
+// the hooks path, and the copy of the hooks directory of the config repo.
+func (cfg *Config) gitHooksDirs() []string {
+	if cfg.Cfg == nil {
+		return nil
+	}
+	dirs := make([]string, 0, 2)
+	hp := cfg.Cfg.HooksPath
+	if hp == "" && cfg.Cfg.DataPath != "" {
+		hp = filepath.Join(cfg.Cfg.DataPath, "hooks")
+	}
+	if hp != "" {
+		dirs = append(dirs, hp)
+	}
+	if cfg.Cfg.DataPath != "" {
+		dirs = append(dirs, filepath.Join(cfg.Cfg.DataPath, configHooksDir))
+	}
+	for i, d := range dirs {
+		if abs, err := filepath.Abs(d); err == nil {
+			dirs[i] = abs
+		}
+	}
+	return dirs
+}
+
+// InstallGitHooks installs the git hooks of a repo, which run the hooks of
+// the hooks directories: NAME for all repos, then REPO/NAME for the repo
+// only. The pre-receive hook rejects force pushes to protected branches
+// first.
+func (cfg *Config) InstallGitHooks(repo string) error {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	return cfg.installGitHooks(repo)
+}
+
+// installGitHooks installs the git hooks of a repo. The caller must hold
+// the lock.
+func (cfg *Config) installGitHooks(repo string) error {
+	dirs := cfg.gitHooksDirs()
+	protected := cfg.forcePushProtected(repo)
+	if len(dirs) == 0 && len(protected) == 0 {
+		return cfg.removeGitHooks(repo)
+	}
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return err
+	}
+	hd := filepath.Join(r.path, "hooks")
+	if !r.repository.IsBare {
+		hd = filepath.Join(r.path, ".git", "hooks")
+	}
+	if err := os.MkdirAll(hd, 0o755); err != nil {
+		return err
+	}
+	for _, name := range gitHookNames {
+		fp := filepath.Join(hd, name)
+		script := []byte(gitHookScript(name, repo, dirs, protected))
+		cur, err := os.ReadFile(fp)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			return err
+		case bytes.Equal(cur, script):
+			continue
+		case !bytes.Contains(cur, []byte(gitHookMarker)):
+			log.Warn("not replacing git hook", "repo", repo, "hook", name)
+			continue
+		}
+		if err := os.WriteFile(fp, script, 0o755); err != nil {
+			return err
+		}
+		// WriteFile keeps the mode of existing files.
+		if err := os.Chmod(fp, 0o755); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeGitHooks removes the git hooks installed in a repo, when there's
+// nothing for them to run.
+func (cfg *Config) removeGitHooks(repo string) error {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return err
+	}
+	hd := filepath.Join(r.path, "hooks")
+	if !r.repository.IsBare {
+		hd = filepath.Join(r.path, ".git", "hooks")
+	}
+	for _, name := range gitHookNames {
+		fp := filepath.Join(hd, name)
+		cur, err := os.ReadFile(fp)
+		if err != nil || !bytes.Contains(cur, []byte(gitHookMarker)) {
+			continue
+		}
+		if err := os.Remove(fp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// gitHookScript returns the script of a git hook running the hooks of the
+// same name found in dirs, in the standard git hook environment. Hooks
+// reading their input, the refs being updated, each get a copy of it. The
+// first failing hook fails the git hook. The pre-receive hook first rejects
+// the updates of the protected refs that aren't fast-forwards.
+func gitHookScript(name, repo string, dirs []string, protected []string) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "#!/bin/sh\n%s, changes are overwritten. Add hooks to\n", gitHookMarker)
+	s.WriteString("# the hooks directory of the server or of the config repo instead.\n")
+	fmt.Fprintf(&s, "SOFT_SERVE_REPO=%s\nexport SOFT_SERVE_REPO\n", shellQuote(repo))
+	run := `"$hook" "$@"`
+	if name != "update" {
+		s.WriteString("input=$(cat)\n")
+		run = `printf '%s\n' "$input" | ` + run
+	}
+	if name == "pre-receive" && len(protected) > 0 {
+		// Ref names can't contain spaces. Refs created or deleted have
+		// a zero hash.
+		fmt.Fprintf(&s, "protected=%s\n", shellQuote(" "+strings.Join(protected, " ")+" "))
+		s.WriteString(`printf '%s\n' "$input" | while read -r old new ref; do
+	case "$protected" in *" $ref "*) ;; *) continue ;; esac
+	case "$old" in *[!0]*) ;; *) continue ;; esac
+	case "$new" in *[!0]*) ;; *) continue ;; esac
+	if ! git merge-base --is-ancestor "$old" "$new"; then
+		echo "error: $ref is a protected branch, it can't be force pushed" >&2
+		exit 1
+	fi
+done || exit
+`)
+	}
+	s.WriteString("for hook in")
+	for _, d := range dirs {
+		for _, fp := range []string{filepath.Join(d, name), filepath.Join(d, repo, name)} {
+			fmt.Fprintf(&s, " %s", shellQuote(filepath.ToSlash(fp)))
+		}
+	}
+	fmt.Fprintf(&s, "; do\n\tif [ -x \"$hook\" ]; then\n\t\t%s || exit\n\tfi\ndone\n", run)
+	return s.String()
+}
+
+// syncConfigHooks copies the hooks directory of the config repo to the data
+// path when the config repo has changed since the last copy. Hooks are made
+// executable. The caller must hold the lock.
+func (cfg *Config) syncConfigHooks() error {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return nil
+	}
+	r, err := cfg.Source.GetRepo("config")
+	if err != nil || r.IsEmpty() {
+		return nil
+	}
+	head, err := r.HEAD()
+	if err != nil {
+		return err
+	}
+	if head.Hash.String() == cfg.configHooksHead {
+		return nil
+	}
+	files, err := r.Files(head)
+	if err != nil {
+		return err
+	}
+	t, err := r.Tree(head, "")
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(cfg.Cfg.DataPath, configHooksDir)
+	tmp := dst + ".tmp"
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	for _, fp := range files {
+		if !strings.HasPrefix(fp, "hooks/") {
+			continue
+		}
+		e, err := t.TreeEntry(fp)
+		if err != nil {
+			return err
+		}
+		bts, err := e.Contents()
+		if err != nil {
+			return err
+		}
+		p := filepath.Join(tmp, filepath.FromSlash(strings.TrimPrefix(fp, "hooks/")))
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, bts, 0o700); err != nil {
+			return err
+		}
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	cfg.configHooksHead = head.Hash.String()
+	return nil
+}
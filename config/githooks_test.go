@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestInstallGitHooks(t *testing.T) {
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	hp := t.TempDir()
+	cfg := &Config{Source: rs, Cfg: &config.Config{HooksPath: hp}}
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	hd := filepath.Join(rs.Path, "repo", "hooks")
+	custom := filepath.Join(hd, "update")
+	is.NoErr(os.MkdirAll(hd, 0o755))
+	is.NoErr(os.WriteFile(custom, []byte("#!/bin/sh\nexit 0\n"), 0o755))
+
+	is.NoErr(cfg.InstallGitHooks("repo"))
+	bts, err := os.ReadFile(filepath.Join(hd, "pre-receive"))
+	is.NoErr(err)
+	is.True(strings.Contains(string(bts), gitHookMarker))
+	is.True(strings.Contains(string(bts), filepath.ToSlash(filepath.Join(hp, "repo", "pre-receive"))))
+	bts, err = os.ReadFile(custom)
+	is.NoErr(err)
+	is.Equal(string(bts), "#!/bin/sh\nexit 0\n") // hooks put in by hand are kept
+
+	// Without a hooks path or data path, nothing is installed.
+	cfg.Cfg = &config.Config{}
+	is.NoErr(os.Remove(filepath.Join(hd, "post-receive")))
+	is.NoErr(cfg.InstallGitHooks("repo"))
+	_, err = os.Stat(filepath.Join(hd, "post-receive"))
+	is.True(os.IsNotExist(err))
+}
@@ -0,0 +1,269 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// housekeepingFile is the file of the data path holding the housekeeping
+	// records of repos.
+	housekeepingFile = "housekeeping.json"
+	// DefaultHousekeepingInterval is the time between the garbage
+	// collections of repos when housekeeping doesn't set it.
+	DefaultHousekeepingInterval = 7 * 24 * time.Hour
+	// DefaultLooseObjects is the number of loose objects past which repos
+	// are repacked when housekeeping doesn't set it, that of git gc --auto.
+	DefaultLooseObjects = 6700
+)
+
+// Housekeeping configures the scheduled maintenance of repos. Repos are
+// garbage collected every interval, and their loose objects repacked in
+// between when there are more than loose-objects of them. The commit-graph
+// of repos is written after both. Repos opt out by turning their
+// housekeeping feature off.
+type Housekeeping struct {
+	// Interval is the time between the garbage collections of each repo,
+	// e.g. 72h, DefaultHousekeepingInterval if empty.
+	Interval string `yaml:"interval" json:"interval"`
+	// LooseObjects is the number of loose objects past which a repo is
+	// repacked, DefaultLooseObjects if zero.
+	LooseObjects int `yaml:"loose-objects" json:"loose-objects"`
+}
+
+// interval returns the time between the garbage collections of repos.
+func (h Housekeeping) interval() (time.Duration, error) {
+	if h.Interval == "" {
+		return DefaultHousekeepingInterval, nil
+	}
+	d, err := time.ParseDuration(h.Interval)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("housekeeping interval %q must be positive", h.Interval)
+	}
+	return d, err
+}
+
+// looseObjects returns the number of loose objects past which repos are
+// repacked.
+func (h Housekeeping) looseObjects() int {
+	if h.LooseObjects <= 0 {
+		return DefaultLooseObjects
+	}
+	return h.LooseObjects
+}
+
+// HousekeepingTask is a maintenance task run on repos.
+type HousekeepingTask string
+
+const (
+	// HousekeepingGC garbage collects a repo with git gc, which repacks all
+	// its objects and prunes the unreachable ones.
+	HousekeepingGC HousekeepingTask = "gc"
+	// HousekeepingRepack packs the loose objects of a repo.
+	HousekeepingRepack HousekeepingTask = "repack"
+)
+
+// housekeepingRecord is the housekeeping history of a repo.
+type housekeepingRecord struct {
+	// Since is when housekeeping first saw the repo. Its first garbage
+	// collection is due an interval later.
+	Since time.Time `json:"since"`
+	// LastGC is when the repo was last garbage collected.
+	LastGC time.Time `json:"last-gc,omitempty"`
+	// LastRun is when a task last ran on the repo.
+	LastRun time.Time `json:"last-run,omitempty"`
+	// Error is why the last task failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// HousekeepingStatus is the housekeeping status of a repo.
+type HousekeepingStatus struct {
+	Repo string `json:"repo"`
+	// Enabled is whether the repo is maintained on schedule.
+	Enabled bool `json:"enabled"`
+	// LooseObjects is the number of loose objects of the repo.
+	LooseObjects int `json:"loose-objects"`
+	// LastGC is when the repo was last garbage collected by housekeeping,
+	// zero if it never was.
+	LastGC time.Time `json:"last-gc"`
+	// LastRun is when a task last ran on the repo, zero if none did.
+	LastRun time.Time `json:"last-run"`
+	// Error is why the last task failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// HousekeepingStatus returns the housekeeping status of a repo.
+func (cfg *Config) HousekeepingStatus(ctx context.Context, repo string) (HousekeepingStatus, error) {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return HousekeepingStatus{}, err
+	}
+	n, err := r.repository.LooseObjects(ctx)
+	if err != nil {
+		return HousekeepingStatus{}, err
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readHousekeeping()
+	if err != nil {
+		return HousekeepingStatus{}, err
+	}
+	rec := all[repo]
+	return HousekeepingStatus{
+		Repo:         repo,
+		Enabled:      cfg.featureEnabled(repo, FeatureHousekeeping),
+		LooseObjects: n,
+		LastGC:       rec.LastGC,
+		LastRun:      rec.LastRun,
+		Error:        rec.Error,
+	}, nil
+}
+
+// HousekeepingDue returns the task due on a repo at now, or an empty task
+// if none is: a garbage collection once the interval has passed since the
+// last one, or a repack when the repo has too many loose objects. Repos
+// that turned housekeeping off are never due. Repos never seen before are
+// recorded, so that their first garbage collection is an interval later
+// rather than all at once.
+func (cfg *Config) HousekeepingDue(ctx context.Context, repo string, now time.Time) (HousekeepingTask, error) {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return "", err
+	}
+	cfg.mtx.Lock()
+	enabled := cfg.featureEnabled(repo, FeatureHousekeeping)
+	hk := cfg.Housekeeping
+	cfg.mtx.Unlock()
+	if !enabled {
+		return "", nil
+	}
+	var last time.Time
+	if err := cfg.updateHousekeeping(repo, func(rec *housekeepingRecord) {
+		if rec.Since.IsZero() {
+			rec.Since = now
+		}
+		last = rec.LastGC
+		if last.IsZero() {
+			last = rec.Since
+		}
+	}); err != nil {
+		return "", err
+	}
+	interval, err := hk.interval()
+	if err != nil {
+		interval = DefaultHousekeepingInterval
+	}
+	if !now.Before(last.Add(interval)) {
+		return HousekeepingGC, nil
+	}
+	n, err := r.repository.LooseObjects(ctx)
+	if err != nil {
+		return "", err
+	}
+	if n > hk.looseObjects() {
+		return HousekeepingRepack, nil
+	}
+	return "", nil
+}
+
+// Housekeep runs a housekeeping task on a repo, then writes its
+// commit-graph, and records the outcome in its status. Objects are repacked
+// under the maintenance lock of the repo, so pushes wait for it.
+func (cfg *Config) Housekeep(ctx context.Context, repo string, task HousekeepingTask) error {
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return err
+	}
+	switch task {
+	case HousekeepingGC:
+		err = cfg.Source.GC(repo)
+	case HousekeepingRepack:
+		unlock := cfg.Source.LockMaintenance(repo)
+		err = r.repository.Repack(ctx)
+		unlock()
+	default:
+		return fmt.Errorf("unknown housekeeping task %q", task)
+	}
+	if err == nil {
+		err = r.repository.WriteCommitGraph(ctx)
+	}
+	now := time.Now()
+	if rerr := cfg.updateHousekeeping(repo, func(rec *housekeepingRecord) {
+		if rec.Since.IsZero() {
+			rec.Since = now
+		}
+		rec.LastRun = now
+		rec.Error = ""
+		if err != nil {
+			rec.Error = err.Error()
+			return
+		}
+		if task == HousekeepingGC {
+			rec.LastGC = now
+		}
+	}); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
+
+// updateHousekeeping updates the housekeeping record of a repo with fn.
+func (cfg *Config) updateHousekeeping(repo string, fn func(*housekeepingRecord)) error {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readHousekeeping()
+	if err != nil {
+		return err
+	}
+	rec := all[repo]
+	old := rec
+	fn(&rec)
+	if rec == old {
+		return nil
+	}
+	all[repo] = rec
+	return cfg.writeHousekeeping(all)
+}
+
+// readHousekeeping reads the housekeeping records of repos. The caller must
+// hold the lock.
+func (cfg *Config) readHousekeeping() (map[string]housekeepingRecord, error) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return nil, errors.New("housekeeping needs a data path")
+	}
+	all := make(map[string]housekeepingRecord)
+	bts, err := os.ReadFile(filepath.Join(cfg.Cfg.DataPath, housekeepingFile))
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// writeHousekeeping replaces the housekeeping records of repos. The caller
+// must hold the lock.
+func (cfg *Config) writeHousekeeping(all map[string]housekeepingRecord) error {
+	bts, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Cfg.DataPath, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(cfg.Cfg.DataPath, housekeepingFile)
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestHousekeeping(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: t.TempDir(),
+	})
+	is.NoErr(err)
+	r, err := cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	// Small pushes are unpacked into loose objects.
+	work := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"commit", "-q", "--allow-empty", "-m", "first"},
+		{"push", "-q", r.Path(), "main"},
+	} {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = work
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	is.NoErr(r.SetHEAD("main"))
+	is.NoErr(cfg.Source.LoadRepo("repo"))
+	ctx := context.Background()
+	now := time.Now()
+
+	// Repos are first collected an interval after they're first seen.
+	task, err := cfg.HousekeepingDue(ctx, "repo", now)
+	is.NoErr(err)
+	is.Equal(task, HousekeepingTask(""))
+	task, err = cfg.HousekeepingDue(ctx, "repo", now.Add(DefaultHousekeepingInterval))
+	is.NoErr(err)
+	is.Equal(task, HousekeepingGC)
+
+	// Repos with too many loose objects are repacked.
+	cfg.Housekeeping.LooseObjects = 1
+	task, err = cfg.HousekeepingDue(ctx, "repo", now)
+	is.NoErr(err)
+	is.Equal(task, HousekeepingRepack)
+	is.NoErr(cfg.Housekeep(ctx, "repo", task))
+	st, err := cfg.HousekeepingStatus(ctx, "repo")
+	is.NoErr(err)
+	is.Equal(st.LooseObjects, 0)
+	is.True(st.Enabled)
+	is.True(st.LastGC.IsZero())
+	is.True(!st.LastRun.IsZero())
+	_, err = os.Stat(filepath.Join(r.Path(), "objects", "info", "commit-graph"))
+	is.NoErr(err)
+	task, err = cfg.HousekeepingDue(ctx, "repo", now)
+	is.NoErr(err)
+	is.Equal(task, HousekeepingTask(""))
+
+	cfg.Housekeeping.Interval = "1h"
+	task, err = cfg.HousekeepingDue(ctx, "repo", now.Add(time.Hour))
+	is.NoErr(err)
+	is.Equal(task, HousekeepingGC)
+	is.NoErr(cfg.Housekeep(ctx, "repo", task))
+	st, err = cfg.HousekeepingStatus(ctx, "repo")
+	is.NoErr(err)
+	is.True(!st.LastGC.IsZero())
+	task, err = cfg.HousekeepingDue(ctx, "repo", st.LastGC.Add(30*time.Minute))
+	is.NoErr(err)
+	is.Equal(task, HousekeepingTask(""))
+
+	// Repos opt out with the housekeeping feature.
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Features: map[Feature]bool{FeatureHousekeeping: false}}))
+	task, err = cfg.HousekeepingDue(ctx, "repo", now.Add(DefaultHousekeepingInterval*2))
+	is.NoErr(err)
+	is.Equal(task, HousekeepingTask(""))
+	st, err = cfg.HousekeepingStatus(ctx, "repo")
+	is.NoErr(err)
+	is.True(!st.Enabled)
+}
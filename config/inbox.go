@@ -0,0 +1,299 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/events"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+)
+
+const (
+	// inboxDir is the directory of the data path holding the events the
+	// notifications are made of, the inbox retention class.
+	inboxDir = "inbox"
+	// inboxReadFile is the file of the data path holding the notifications
+	// each user read.
+	inboxReadFile = "inbox-read.json"
+)
+
+// ErrUnknownNotification is returned when marking notifications the user
+// doesn't have as read.
+var ErrUnknownNotification = errors.New("unknown notification")
+
+// mentionRe matches the mentions of users in commit messages, e.g. @frankie.
+var mentionRe = regexp.MustCompile(`(?:^|[^\w@])@([\w.-]*\w)`)
+
+// InboxTypes are the types of the events users are notified of: pushes
+// mentioning them, access requests to the repos they admin and the decisions
+// on their own, and mirrors they admin failing to sync.
+var InboxTypes = []events.Type{
+	events.Push,
+	events.AccessRequested,
+	events.AccessApproved,
+	events.AccessDenied,
+	events.MirrorFailed,
+}
+
+// Notification is an event in the inbox of a user.
+type Notification struct {
+	ID string `json:"id"`
+	events.Event
+	Read bool `json:"read"`
+}
+
+// Summary describes the notification in a line.
+func (n Notification) Summary() string {
+	switch n.Type {
+	case events.Push:
+		return fmt.Sprintf("%s mentioned you in %s", n.User, n.Repo)
+	case events.AccessRequested:
+		return fmt.Sprintf("%s requested access to %s", n.User, n.Repo)
+	case events.AccessApproved:
+		return fmt.Sprintf("%s granted you %s access to %s", n.User, n.Access, n.Repo)
+	case events.AccessDenied:
+		return fmt.Sprintf("%s denied your access request to %s", n.User, n.Repo)
+	case events.MirrorFailed:
+		return fmt.Sprintf("Mirror %s failed to sync: %s", n.Repo, n.Error)
+	}
+	return fmt.Sprintf("%s %s", n.Type, n.Repo)
+}
+
+// RecordNotifications records the events users are notified of, published
+// on the event bus, until ctx is done. Pushes are only recorded when their
+// commits mention someone. Events that can't be recorded are logged and
+// dropped.
+func (cfg *Config) RecordNotifications(ctx context.Context) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return
+	}
+	store := cfg.inboxStore()
+	for e := range cfg.Events.SubscribeDurable(ctx) {
+		if !InboxType(e.Type) || (e.Type == events.Push && len(mentions(e)) == 0) {
+			continue
+		}
+		if err := store.Append(e); err != nil {
+			log.Error("error recording notification", "type", e.Type, "repo", e.Repo, "err", err)
+		}
+	}
+}
+
+// Notifications returns the notifications of the user of a key, newest
+// first. Anonymous users and keys of unknown users have none. Whether users
+// are notified is decided as the inbox is read, so that users who lost
+// access to a repo aren't notified of its events anymore.
+func (cfg *Config) Notifications(pk ssh.PublicKey) ([]Notification, error) {
+	ns := make([]Notification, 0)
+	user := cfg.memberName(pk)
+	if user == "" || cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return ns, nil
+	}
+	es, err := cfg.inboxStore().Events("", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	cfg.mtx.Lock()
+	read, err := cfg.readInboxRead()
+	cfg.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, id := range read[user] {
+		seen[id] = true
+	}
+	for i := len(es) - 1; i >= 0; i-- {
+		e := es[i]
+		if !cfg.notifies(e, user, pk) {
+			continue
+		}
+		id := notificationID(e)
+		ns = append(ns, Notification{ID: id, Event: e, Read: seen[id]})
+	}
+	return ns, nil
+}
+
+// UnreadNotifications returns the number of unread notifications of the
+// user of a key.
+func (cfg *Config) UnreadNotifications(pk ssh.PublicKey) int {
+	ns, err := cfg.Notifications(pk)
+	if err != nil {
+		return 0
+	}
+	unread := 0
+	for _, n := range ns {
+		if !n.Read {
+			unread++
+		}
+	}
+	return unread
+}
+
+// MarkNotificationsRead marks notifications of the user of a key as read, or
+// all of them when no IDs are given.
+func (cfg *Config) MarkNotificationsRead(pk ssh.PublicKey, ids ...string) error {
+	ns, err := cfg.Notifications(pk)
+	if err != nil {
+		return err
+	}
+	inbox := make(map[string]bool)
+	for _, n := range ns {
+		inbox[n.ID] = true
+	}
+	mark := make(map[string]bool)
+	for _, id := range ids {
+		if !inbox[id] {
+			return fmt.Errorf("%w: %q", ErrUnknownNotification, id)
+		}
+		mark[id] = true
+	}
+	// Only the notifications still in the inbox are kept track of, so that
+	// the file doesn't grow past what retention keeps.
+	user := cfg.memberName(pk)
+	read := make([]string, 0)
+	for _, n := range ns {
+		if n.Read || len(ids) == 0 || mark[n.ID] {
+			read = append(read, n.ID)
+		}
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readInboxRead()
+	if err != nil {
+		return err
+	}
+	all[user] = read
+	return cfg.writeInboxRead(all)
+}
+
+// notifies returns whether an event is a notification of a user.
+func (cfg *Config) notifies(e events.Event, user string, pk ssh.PublicKey) bool {
+	switch e.Type {
+	case events.Push:
+		if e.User == user || cfg.AuthRepo(e.Repo, pk) < gm.ReadOnlyAccess {
+			return false
+		}
+		for _, m := range mentions(e) {
+			if strings.EqualFold(m, user) {
+				return true
+			}
+		}
+	case events.AccessApproved, events.AccessDenied:
+		return e.Requester == user
+	case events.AccessRequested, events.MirrorFailed:
+		return e.User != user && cfg.AuthRepo(e.Repo, pk) >= gm.AdminAccess
+	}
+	return false
+}
+
+// HasInbox returns whether the key is the key of a user of the server, who
+// gets notifications.
+func (cfg *Config) HasInbox(pk ssh.PublicKey) bool {
+	return cfg.memberName(pk) != ""
+}
+
+// memberName returns the name of the user of a key, or an empty string if the
+// key isn't the key of a user of the server.
+func (cfg *Config) memberName(pk ssh.PublicKey) string {
+	if pk == nil {
+		return ""
+	}
+	cfg.mtx.Lock()
+	u := cfg.findUser(pk)
+	cfg.mtx.Unlock()
+	if u != nil {
+		return u.Name
+	}
+	if u, ok := cfg.LDAPUser(pk); ok {
+		return u.Name
+	}
+	return ""
+}
+
+// inboxStore returns the store of the events of notifications.
+func (cfg *Config) inboxStore() *events.Store {
+	return events.NewStore(filepath.Join(cfg.Cfg.DataPath, inboxDir))
+}
+
+// InboxType returns whether users are notified of events of type t.
+func InboxType(t events.Type) bool {
+	for _, it := range InboxTypes {
+		if t == it {
+			return true
+		}
+	}
+	return false
+}
+
+// Mentions returns the users mentioned in a commit message, e.g. frankie
+// for @frankie.
+func Mentions(message string) []string {
+	ms := make([]string, 0)
+	for _, m := range mentionRe.FindAllStringSubmatch(message, -1) {
+		ms = append(ms, m[1])
+	}
+	return ms
+}
+
+// mentions returns the users mentioned in the commits of a push.
+func mentions(e events.Event) []string {
+	ms := make([]string, 0)
+	for _, c := range e.Commits {
+		ms = append(ms, Mentions(c.Message)...)
+	}
+	return ms
+}
+
+// notificationID returns the ID of the notification of an event, the prefix
+// of the hash of the event.
+func notificationID(e events.Event) string {
+	bts, _ := json.Marshal(e)
+	sum := sha256.Sum256(bts)
+	return hex.EncodeToString(sum[:6])
+}
+
+// readInboxRead reads the IDs of the notifications read by each user. The
+// caller must hold the lock.
+func (cfg *Config) readInboxRead() (map[string][]string, error) {
+	read := make(map[string][]string)
+	bts, err := os.ReadFile(filepath.Join(cfg.Cfg.DataPath, inboxReadFile))
+	if os.IsNotExist(err) {
+		return read, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &read); err != nil {
+		return nil, err
+	}
+	return read, nil
+}
+
+// writeInboxRead replaces the IDs of the notifications read by each user.
+// The caller must hold the lock.
+func (cfg *Config) writeInboxRead(read map[string][]string) error {
+	bts, err := json.Marshal(read)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Cfg.DataPath, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(cfg.Cfg.DataPath, inboxReadFile)
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestNotifications(t *testing.T) {
+	is := is.New(t)
+	adminKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		DataPath:         t.TempDir(),
+		InitialAdminKeys: []string{adminKey},
+	})
+	is.NoErr(err)
+	admin, _, _, _, err := ssh.ParseAuthorizedKey([]byte(adminKey))
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	is.NoErr(cfg.CreateUser("Frankie", []string{key}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		cfg.RecordNotifications(ctx)
+		close(done)
+	}()
+	// Events published before the inbox subscribes aren't recorded, so
+	// publish until the first one is.
+	for {
+		cfg.Events.Publish(events.Event{Type: events.AccessDenied, Repo: "repo", Requester: "nobody"})
+		time.Sleep(10 * time.Millisecond)
+		es, err := cfg.inboxStore().Events("", time.Time{})
+		is.NoErr(err)
+		if len(es) > 0 {
+			break
+		}
+	}
+	cfg.Events.Publish(events.Event{Type: events.Push, Repo: "repo", User: "admin", Commits: []events.Commit{
+		{Message: "Fix the build"},
+		{Message: "Add docs\n\nThanks @frankie for the review, cc me@example.com"},
+	}})
+	cfg.Events.Publish(events.Event{Type: events.Push, Repo: "repo", User: "admin", Commits: []events.Commit{
+		{Message: "Mail me@example.com"},
+	}})
+	cfg.Events.Publish(events.Event{Type: events.MirrorFailed, Repo: "repo", Error: "exit status 128"})
+	cfg.Events.Publish(events.Event{Type: events.AccessRequested, Repo: "repo", User: "Frankie"})
+	cfg.Events.Publish(events.Event{Type: events.AccessApproved, Repo: "repo", User: "admin", Requester: "Frankie", Access: "read-write"})
+	cfg.Events.Publish(events.Event{Type: events.Fetch, Repo: "repo"})
+	cancel()
+	<-done
+
+	// Pushes are only recorded when they mention someone.
+	es, err := cfg.inboxStore().Events("", time.Time{})
+	is.NoErr(err)
+	pushes := 0
+	for _, e := range es {
+		is.True(e.Type != events.Fetch)
+		if e.Type == events.Push {
+			pushes++
+		}
+	}
+	is.Equal(pushes, 1)
+
+	ns, err := cfg.Notifications(pk)
+	is.NoErr(err)
+	is.Equal(len(ns), 2)
+	is.Equal(ns[0].Summary(), "admin granted you read-write access to repo")
+	is.Equal(ns[1].Summary(), "admin mentioned you in repo")
+	ns, err = cfg.Notifications(admin)
+	is.NoErr(err)
+	is.Equal(len(ns), 2)
+	is.Equal(ns[0].Summary(), "Frankie requested access to repo")
+	is.Equal(ns[1].Summary(), "Mirror repo failed to sync: exit status 128")
+	anon, err := cfg.Notifications(nil)
+	is.NoErr(err)
+	is.Equal(len(anon), 0)
+
+	// Read state is kept per user.
+	is.Equal(cfg.UnreadNotifications(admin), 2)
+	is.NoErr(cfg.MarkNotificationsRead(admin, ns[1].ID))
+	is.Equal(cfg.UnreadNotifications(admin), 1)
+	is.Equal(cfg.UnreadNotifications(pk), 2)
+	is.NoErr(cfg.MarkNotificationsRead(pk))
+	is.Equal(cfg.UnreadNotifications(pk), 0)
+	is.Equal(cfg.UnreadNotifications(admin), 1)
+	err = cfg.MarkNotificationsRead(pk, "0123456789ab")
+	is.True(errors.Is(err, ErrUnknownNotification))
+}
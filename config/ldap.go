@@ -0,0 +1,322 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/ldap"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Defaults of the LDAP settings.
+const (
+	DefaultLDAPKeyAttribute   = "sshPublicKey"
+	DefaultLDAPNameAttribute  = "uid"
+	DefaultLDAPGroupAttribute = "memberOf"
+	DefaultLDAPCache          = 5 * time.Minute
+	// ldapTimeout caps the time a lookup waits for the directory.
+	ldapTimeout = 5 * time.Second
+)
+
+// LDAP configures looking up the keys of users who aren't in the
+// configuration in an LDAP directory. A key found in the key attribute of an
+// entry is of the user named by its name attribute, and the groups of the
+// entry grant access to repos and roles.
+type LDAP struct {
+	// URL is the ldap:// or ldaps:// URL of the directory.
+	URL string `yaml:"url" json:"url"`
+	// BindDN and BindPassword are the credentials lookups bind with, or
+	// empty to bind anonymously. BindPassword is the name of the secret
+	// holding the password.
+	BindDN       string `yaml:"bind-dn" json:"bind-dn"`
+	BindPassword string `yaml:"bind-password" json:"bind-password"`
+	// BaseDN is the DN users are searched under, e.g.
+	// ou=people,dc=example,dc=com.
+	BaseDN string `yaml:"base-dn" json:"base-dn"`
+	// KeyAttribute holds the public keys of users, in authorized_keys
+	// format, DefaultLDAPKeyAttribute if empty.
+	KeyAttribute string `yaml:"key-attribute" json:"key-attribute"`
+	// NameAttribute holds the names of users, DefaultLDAPNameAttribute if
+	// empty.
+	NameAttribute string `yaml:"name-attribute" json:"name-attribute"`
+	// GroupAttribute holds the DNs of the groups of users,
+	// DefaultLDAPGroupAttribute if empty.
+	GroupAttribute string `yaml:"group-attribute" json:"group-attribute"`
+	// Cache is how long lookups are reused, e.g. "10m", DefaultLDAPCache if
+	// empty.
+	Cache string `yaml:"cache" json:"cache"`
+	// Groups map directory groups to access levels and roles.
+	Groups []LDAPGroup `yaml:"groups" json:"groups"`
+}
+
+// LDAPGroup grants the members of a directory group an access level to some
+// repos, and roles.
+type LDAPGroup struct {
+	// Group is the DN of the group, or the value of its first RDN, e.g.
+	// "cn=devs,ou=groups,dc=example,dc=com" or "devs". It's matched case
+	// insensitively.
+	Group string `yaml:"group" json:"group"`
+	// Access is the access level granted, e.g. "read-write".
+	Access string `yaml:"access" json:"access"`
+	// Repos are the patterns of the repos Access applies to, matched like
+	// path.Match, or empty for all repos. Patterns don't match the config
+	// repo, which only groups without repos get access to.
+	Repos []string `yaml:"repos" json:"repos"`
+	// Roles are granted on top of Access, see Role.
+	Roles []string `yaml:"roles" json:"roles"`
+}
+
+// LDAPUser is a user found in the directory.
+type LDAPUser struct {
+	Name string
+	DN   string
+	// Groups are the DNs of the groups of the user.
+	Groups []string
+}
+
+// ldapLookup is a cached lookup of a key.
+type ldapLookup struct {
+	// settings are the settings the lookup was made with.
+	settings string
+	expires  time.Time
+	// user is nil for keys of no user.
+	user *LDAPUser
+}
+
+// Enabled returns whether looking up keys in a directory is configured.
+func (l LDAP) Enabled() bool {
+	return l.URL != "" && l.BaseDN != ""
+}
+
+// Validate returns an error if the settings are invalid.
+func (l LDAP) Validate() error {
+	if !l.Enabled() {
+		return nil
+	}
+	if _, err := l.cache(); err != nil {
+		return err
+	}
+	for _, g := range l.Groups {
+		if g.Group == "" {
+			return fmt.Errorf("ldap group has no group")
+		}
+		if g.Access != "" && accessLevel(g.Access, -1) == -1 {
+			return fmt.Errorf("invalid access %q of ldap group %q", g.Access, g.Group)
+		}
+		for _, p := range g.Repos {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid repo pattern %q of ldap group %q", p, g.Group)
+			}
+		}
+		for _, r := range g.Roles {
+			if err := ValidRole(r); err != nil {
+				return fmt.Errorf("ldap group %q: %w", g.Group, err)
+			}
+		}
+	}
+	return nil
+}
+
+// cache returns how long lookups are reused.
+func (l LDAP) cache() (time.Duration, error) {
+	if l.Cache == "" {
+		return DefaultLDAPCache, nil
+	}
+	d, err := time.ParseDuration(l.Cache)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid ldap cache %q", l.Cache)
+	}
+	return d, nil
+}
+
+// member returns whether the group applies to a user of groups.
+func (g LDAPGroup) member(groups []string) bool {
+	for _, dn := range groups {
+		if strings.EqualFold(dn, g.Group) {
+			return true
+		}
+		rdn := strings.SplitN(dn, ",", 2)[0]
+		if i := strings.Index(rdn, "="); i >= 0 && strings.EqualFold(strings.TrimSpace(rdn[i+1:]), g.Group) {
+			return true
+		}
+	}
+	return false
+}
+
+// applies returns whether the access of the group applies to repo. Groups
+// apply to all repos when authenticating connections, with an empty repo.
+func (g LDAPGroup) applies(repo string) bool {
+	if len(g.Repos) == 0 || repo == "" {
+		return true
+	}
+	if repo == "config" {
+		return false
+	}
+	for _, p := range g.Repos {
+		if ok, _ := path.Match(p, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// LDAPSettings returns the LDAP settings of the server, with defaults
+// applied.
+func (cfg *Config) LDAPSettings() LDAP {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	l := cfg.LDAP
+	if l.KeyAttribute == "" {
+		l.KeyAttribute = DefaultLDAPKeyAttribute
+	}
+	if l.NameAttribute == "" {
+		l.NameAttribute = DefaultLDAPNameAttribute
+	}
+	if l.GroupAttribute == "" {
+		l.GroupAttribute = DefaultLDAPGroupAttribute
+	}
+	return l
+}
+
+// LDAPUser returns the directory user of a key. Lookups are cached, and
+// failed ones are logged and treated as keys of no user.
+func (cfg *Config) LDAPUser(pk ssh.PublicKey) (LDAPUser, bool) {
+	l := cfg.LDAPSettings()
+	if pk == nil || !l.Enabled() {
+		return LDAPUser{}, false
+	}
+	ttl, err := l.cache()
+	if err != nil {
+		log.Error("invalid ldap settings", "err", err)
+		return LDAPUser{}, false
+	}
+	settings := fmt.Sprint(l.URL, l.BindDN, l.BindPassword, l.BaseDN, l.KeyAttribute, l.NameAttribute, l.GroupAttribute)
+	fp := gossh.FingerprintSHA256(pk)
+	cfg.ldapMtx.Lock()
+	defer cfg.ldapMtx.Unlock()
+	if c, ok := cfg.ldapUsers[fp]; ok && c.settings == settings && time.Now().Before(c.expires) {
+		if c.user == nil {
+			return LDAPUser{}, false
+		}
+		return *c.user, true
+	}
+	u, err := cfg.lookupLDAP(l, pk)
+	if err != nil {
+		log.Error("error looking up key in ldap", "key", fp, "err", err)
+		return LDAPUser{}, false
+	}
+	if cfg.ldapUsers == nil {
+		cfg.ldapUsers = make(map[string]ldapLookup)
+	}
+	cfg.ldapUsers[fp] = ldapLookup{settings: settings, expires: time.Now().Add(ttl), user: u}
+	if u == nil {
+		return LDAPUser{}, false
+	}
+	return *u, true
+}
+
+// lookupLDAP searches the directory for the user of a key, nil if there's
+// none.
+func (cfg *Config) lookupLDAP(l LDAP, pk ssh.PublicKey) (*LDAPUser, error) {
+	var password string
+	if l.BindPassword != "" {
+		if cfg.Secrets == nil {
+			return nil, fmt.Errorf("secrets are not configured")
+		}
+		p, err := cfg.Secrets.Get(l.BindPassword)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", l.BindPassword, err)
+		}
+		password = p
+	}
+	conn, err := ldap.Dial(l.URL, ldapTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() // nolint: errcheck
+	if err := conn.Bind(l.BindDN, password); err != nil {
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(pk.Marshal())
+	entries, err := conn.Search(l.BaseDN, ldap.Contains(l.KeyAttribute, key), l.KeyAttribute, l.NameAttribute, l.GroupAttribute)
+	if err != nil {
+		return nil, err
+	}
+	// The filter matches keys as substrings, so the keys of entries are
+	// checked before they're trusted.
+	for _, e := range entries {
+		for _, k := range e.Values(l.KeyAttribute) {
+			apk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(k)))
+			if err != nil || !ssh.KeysEqual(pk, apk) {
+				continue
+			}
+			u := &LDAPUser{DN: e.DN, Groups: e.Values(l.GroupAttribute)}
+			if names := e.Values(l.NameAttribute); len(names) > 0 {
+				u.Name = names[0]
+			}
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// ldapGroups returns the groups of the LDAP settings the directory user of a
+// key is a member of.
+func (cfg *Config) ldapGroups(pk ssh.PublicKey) ([]LDAPGroup, bool) {
+	u, ok := cfg.LDAPUser(pk)
+	if !ok {
+		return nil, false
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	groups := make([]LDAPGroup, 0)
+	for _, g := range cfg.LDAP.Groups {
+		if g.member(u.Groups) {
+			groups = append(groups, g)
+		}
+	}
+	return groups, true
+}
+
+// ldapAccess returns the access level the directory grants a key to repo.
+// Like users of the configuration, directory users can read public repos.
+func (cfg *Config) ldapAccess(repo string, pk ssh.PublicKey) gm.AccessLevel {
+	groups, ok := cfg.ldapGroups(pk)
+	if !ok {
+		return gm.NoAccess
+	}
+	al := gm.NoAccess
+	cfg.mtx.Lock()
+	if !cfg.isPrivate(repo) {
+		al = gm.ReadOnlyAccess
+		if anon := cfg.anonAccessLevel(repo); anon > al {
+			al = anon
+		}
+	}
+	cfg.mtx.Unlock()
+	for _, g := range groups {
+		if gl := accessLevel(g.Access, gm.NoAccess); g.applies(repo) && gl > al {
+			al = gl
+		}
+	}
+	return al
+}
+
+// ldapHasRole returns whether the groups of the directory user of a key
+// grant one of roles.
+func (cfg *Config) ldapHasRole(pk ssh.PublicKey, roles ...Role) bool {
+	groups, _ := cfg.ldapGroups(pk)
+	for _, g := range groups {
+		u := User{Roles: g.Roles}
+		if u.hasRole(roles...) {
+			return true
+		}
+	}
+	return false
+}
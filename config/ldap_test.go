@@ -0,0 +1,95 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/ldap"
+	"github.com/charmbracelet/soft-serve/ldap/ldaptest"
+	"github.com/charmbracelet/soft-serve/secrets"
+	"github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestLDAP(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8"
+	pk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(key))
+	otherKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH"
+	otherPk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(otherKey))
+
+	d := ldaptest.New(t, "cn=soft-serve,dc=example,dc=com", "hunter2")
+	d.Add(ldap.Entry{
+		DN: "uid=frankie,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"uid":          {"frankie"},
+			"sshPublicKey": {key + " frankie@laptop"},
+			"memberOf":     {"cn=Developers,ou=groups,dc=example,dc=com", "cn=security,ou=groups,dc=example,dc=com"},
+		},
+	})
+	// The key of this entry contains the key of Frankie, without being it.
+	d.Add(ldap.Entry{
+		DN: "uid=bea,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"uid":          {"bea"},
+			"sshPublicKey": {"ssh-ed25519 x" + key[len("ssh-ed25519 "):]},
+			"memberOf":     {"cn=git-admins,ou=groups,dc=example,dc=com"},
+		},
+	})
+
+	dir := t.TempDir()
+	s, err := secrets.Open(filepath.Join(dir, "secrets"), filepath.Join(dir, "key"))
+	is.NoErr(err)
+	is.NoErr(s.Set("ldap/bind", "hunter2"))
+	cfg := &Config{
+		AnonAccess: "no-access",
+		Secrets:    s,
+		Repos: []RepoConfig{
+			{Repo: "team/app", Private: true},
+			{Repo: "team/lib", Private: true},
+			{Repo: "public"},
+			{Repo: "config", Private: true},
+		},
+		LDAP: LDAP{
+			URL:          d.URL(),
+			BindDN:       "cn=soft-serve,dc=example,dc=com",
+			BindPassword: "ldap/bind",
+			BaseDN:       "ou=people,dc=example,dc=com",
+			Groups: []LDAPGroup{
+				{Group: "developers", Access: "read-write", Repos: []string{"team/*"}},
+				{Group: "cn=security,ou=groups,dc=example,dc=com", Access: "read-only", Repos: []string{"*"}, Roles: []string{"auditor"}},
+				{Group: "git-admins", Access: "admin-access"},
+			},
+		},
+	}
+	is.NoErr(cfg.LDAP.Validate())
+
+	u, ok := cfg.LDAPUser(pk)
+	is.True(ok)
+	is.Equal(u.Name, "frankie")
+	is.Equal(u.DN, "uid=frankie,ou=people,dc=example,dc=com")
+	is.Equal(cfg.userName(pk), "frankie")
+	is.Equal(cfg.AuthRepo("", pk), git.ReadWriteAccess)
+	is.Equal(cfg.AuthRepo("team/app", pk), git.ReadWriteAccess)
+	is.Equal(cfg.AuthRepo("public", pk), git.ReadOnlyAccess)
+	is.Equal(cfg.AuthRepo("config", pk), git.NoAccess)
+	is.True(cfg.HasRole(pk, RoleAuditor))
+	is.True(!cfg.HasRole(pk, RoleRepoAdmin))
+
+	// Lookups are cached.
+	searches := d.Searches()
+	is.Equal(cfg.AuthRepo("team/lib", pk), git.ReadWriteAccess)
+	is.Equal(d.Searches(), searches)
+
+	// Keys of no entry, and bad credentials, get no access.
+	is.Equal(cfg.AuthRepo("public", otherPk), git.NoAccess)
+	is.Equal(cfg.AuthRepo("public", nil), git.NoAccess)
+	cfg.LDAP.BindDN = "cn=nobody,dc=example,dc=com"
+	_, ok = cfg.LDAPUser(pk)
+	is.True(!ok)
+
+	is.True(LDAP{URL: "ldap://x", BaseDN: "dc=x", Cache: "soon"}.Validate() != nil)
+	is.True(LDAP{URL: "ldap://x", BaseDN: "dc=x", Groups: []LDAPGroup{{Group: "g", Access: "owner"}}}.Validate() != nil)
+	is.True(LDAP{URL: "ldap://x", BaseDN: "dc=x", Groups: []LDAPGroup{{Group: "g", Roles: []string{"owner"}}}}.Validate() != nil)
+}
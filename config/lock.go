@@ -0,0 +1,48 @@
+package config
+
+import "sync"
+
+// lock returns the lock coordinating writes to the named repository. Pushes
+// share it, so they can run concurrently and rely on git's own ref locking,
+// while maintenance such as gc holds it exclusively so it never repacks or
+// prunes objects a push is still writing.
+func (rs *RepoSource) lock(name string) *sync.RWMutex {
+	rs.mtx.Lock()
+	defer rs.mtx.Unlock()
+	if rs.locks == nil {
+		rs.locks = make(map[string]*sync.RWMutex)
+	}
+	l, ok := rs.locks[name]
+	if !ok {
+		l = &sync.RWMutex{}
+		rs.locks[name] = l
+	}
+	return l
+}
+
+// LockPush locks the named repository for a push and returns the function
+// that unlocks it. It waits for running maintenance to finish.
+func (rs *RepoSource) LockPush(name string) (unlock func()) {
+	l := rs.lock(name)
+	l.RLock()
+	return l.RUnlock
+}
+
+// LockMaintenance locks the named repository exclusively for maintenance and
+// returns the function that unlocks it. It waits for running pushes to
+// finish, and holds off new ones until unlocked.
+func (rs *RepoSource) LockMaintenance(name string) (unlock func()) {
+	l := rs.lock(name)
+	l.Lock()
+	return l.Unlock
+}
+
+// GC runs git gc on the named repository while holding its maintenance lock.
+func (rs *RepoSource) GC(name string) error {
+	r, err := rs.GetRepo(name)
+	if err != nil {
+		return err
+	}
+	defer rs.LockMaintenance(name)()
+	return r.repository.GC()
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func runGit(dir string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("git %v: %w: %s", args, err, out)
+	}
+	return nil
+}
+
+func TestLockMaintenanceWaitsForPush(t *testing.T) {
+	rs := NewRepoSource(t.TempDir())
+	unlock := rs.LockPush("repo")
+	// Pushes don't block each other.
+	rs.LockPush("repo")()
+	done := make(chan struct{})
+	go func() {
+		defer rs.LockMaintenance("repo")()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("maintenance ran during a push")
+	case <-time.After(50 * time.Millisecond):
+	}
+	unlock()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("maintenance didn't run after the push")
+	}
+	// Other repos aren't affected.
+	defer rs.LockPush("repo")()
+	rs.LockMaintenance("other")()
+}
+
+func TestConcurrentPushAndGC(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	rs := NewRepoSource(t.TempDir())
+	_, err := rs.InitRepo("repo", true)
+	is.NoErr(err)
+	remote := filepath.Join(rs.Path, "repo")
+
+	const pushers = 4
+	const pushes = 5
+	errc := make(chan error, pushers+1)
+	var wg sync.WaitGroup
+	for i := 0; i < pushers; i++ {
+		wd := t.TempDir()
+		is.NoErr(runGit(wd, "init", "-q"))
+		wg.Add(1)
+		go func(i int, wd string) {
+			defer wg.Done()
+			for j := 0; j < pushes; j++ {
+				f := filepath.Join(wd, fmt.Sprintf("file-%d", j))
+				if err := os.WriteFile(f, []byte(fmt.Sprintf("%d-%d", i, j)), 0o644); err != nil {
+					errc <- err
+					return
+				}
+				if err := runGit(wd, "add", "."); err != nil {
+					errc <- err
+					return
+				}
+				if err := runGit(wd, "commit", "-q", "-m", fmt.Sprintf("commit %d", j)); err != nil {
+					errc <- err
+					return
+				}
+				// The local transport runs receive-pack like an SSH push.
+				unlock := rs.LockPush("repo")
+				err := runGit(wd, "push", "-q", remote, fmt.Sprintf("HEAD:refs/heads/branch-%d", i))
+				unlock()
+				if err != nil {
+					errc <- err
+					return
+				}
+			}
+		}(i, wd)
+	}
+	stop := make(chan struct{})
+	gcDone := make(chan struct{})
+	go func() {
+		defer close(gcDone)
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			if err := rs.GC("repo"); err != nil {
+				errc <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-gcDone
+	close(errc)
+	for err := range errc {
+		is.NoErr(err)
+	}
+
+	is.NoErr(runGit(remote, "fsck", "--strict"))
+	for i := 0; i < pushers; i++ {
+		is.NoErr(runGit(remote, "rev-parse", "--verify", fmt.Sprintf("refs/heads/branch-%d", i)))
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoMaintenance is returned when stopping maintenance that isn't
+// scheduled.
+var ErrNoMaintenance = errors.New("no maintenance scheduled")
+
+// Maintenance is a maintenance window, during which pushes to the server or
+// to a repo are rejected.
+type Maintenance struct {
+	// Repo is the repo in maintenance. The window applies to all repos but
+	// the config repo when empty, so that it can still be changed.
+	Repo string `yaml:"repo,omitempty" json:"repo,omitempty"`
+	// Start is when the window starts.
+	Start time.Time `yaml:"start" json:"start"`
+	// End is when the window ends. Windows without an end last until they're
+	// stopped.
+	End time.Time `yaml:"end,omitempty" json:"end,omitempty"`
+	// Message tells users why, e.g. "Migrating to new storage".
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+}
+
+// Active returns whether the window is open at t.
+func (m Maintenance) Active(t time.Time) bool {
+	return !t.Before(m.Start) && (m.End.IsZero() || t.Before(m.End))
+}
+
+// Over returns whether the window has ended at t.
+func (m Maintenance) Over(t time.Time) bool {
+	return !m.End.IsZero() && !t.Before(m.End)
+}
+
+// appliesTo returns whether the window applies to a repo.
+func (m Maintenance) appliesTo(repo string) bool {
+	if m.Repo == "" {
+		return repo != "config"
+	}
+	return m.Repo == repo
+}
+
+// Error returns the message pushes are rejected with during the window.
+func (m Maintenance) Error() string {
+	s := "the server is read-only for maintenance"
+	if m.Repo != "" {
+		s = fmt.Sprintf("%s is read-only for maintenance", m.Repo)
+	}
+	if !m.End.IsZero() {
+		s += " until " + m.End.UTC().Format("2006-01-02 15:04 MST")
+	}
+	if m.Message != "" {
+		s += ": " + m.Message
+	}
+	return s
+}
+
+// Banner returns the notice shown to users before and during the window.
+func (m Maintenance) Banner(t time.Time) string {
+	if m.Active(t) {
+		return "Read-only: " + m.Error()
+	}
+	s := "Maintenance scheduled"
+	if m.Repo != "" {
+		s += " for " + m.Repo
+	}
+	s += " at " + m.Start.UTC().Format("2006-01-02 15:04 MST")
+	if !m.End.IsZero() {
+		s += " for " + m.End.Sub(m.Start).String()
+	}
+	if m.Message != "" {
+		s += ": " + m.Message
+	}
+	return s
+}
+
+// MaintenanceWindows returns the maintenance windows that aren't over at t.
+func (cfg *Config) MaintenanceWindows(t time.Time) []Maintenance {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	ms := make([]Maintenance, 0, len(cfg.Maintenance))
+	for _, m := range cfg.Maintenance {
+		if !m.Over(t) {
+			ms = append(ms, m)
+		}
+	}
+	return ms
+}
+
+// ActiveMaintenance returns the maintenance window open at t for a repo,
+// nil if pushes to it are allowed.
+func (cfg *Config) ActiveMaintenance(repo string, t time.Time) *Maintenance {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	for _, m := range cfg.Maintenance {
+		if m.appliesTo(repo) && m.Active(t) {
+			m := m
+			return &m
+		}
+	}
+	return nil
+}
+
+// MaintenanceBanner returns the notice of the open or next maintenance
+// window of the server or, when repo isn't empty, of the repo at t. It's
+// empty without one.
+func (cfg *Config) MaintenanceBanner(repo string, t time.Time) string {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	var next *Maintenance
+	for i, m := range cfg.Maintenance {
+		if m.Over(t) || (m.Repo != "" && m.Repo != repo) {
+			continue
+		}
+		if m.Active(t) {
+			return m.Banner(t)
+		}
+		if next == nil || m.Start.Before(next.Start) {
+			next = &cfg.Maintenance[i]
+		}
+	}
+	if next == nil {
+		return ""
+	}
+	return next.Banner(t)
+}
+
+// StartMaintenance schedules a maintenance window. Windows that are over
+// are removed. The change is committed to the config repo.
+func (cfg *Config) StartMaintenance(m Maintenance) error {
+	if m.Repo != "" {
+		if _, err := cfg.Source.GetRepo(m.Repo); err != nil {
+			return err
+		}
+	}
+	now := time.Now()
+	target := "server"
+	if m.Repo != "" {
+		target = m.Repo
+	}
+	return cfg.editConfig(fmt.Sprintf("Schedule maintenance of %s", target), func(doc *yaml.Node) {
+		ms := mappingValue(doc.Content[0], "maintenance", yaml.SequenceNode)
+		items := ms.Content[:0]
+		for _, n := range ms.Content {
+			var o Maintenance
+			if err := n.Decode(&o); err == nil && o.Over(now) {
+				continue
+			}
+			items = append(items, n)
+		}
+		n := &yaml.Node{}
+		if err := n.Encode(m); err == nil {
+			items = append(items, n)
+		}
+		ms.Content = items
+	})
+}
+
+// StopMaintenance removes the open and scheduled maintenance windows of the
+// server or, when repo isn't empty, of a repo. The change is committed to
+// the config repo.
+func (cfg *Config) StopMaintenance(repo string) error {
+	now := time.Now()
+	found := false
+	cfg.mtx.Lock()
+	for _, m := range cfg.Maintenance {
+		if m.Repo == repo && !m.Over(now) {
+			found = true
+		}
+	}
+	cfg.mtx.Unlock()
+	if !found {
+		return ErrNoMaintenance
+	}
+	target := "server"
+	if repo != "" {
+		target = repo
+	}
+	return cfg.editConfig(fmt.Sprintf("End maintenance of %s", target), func(doc *yaml.Node) {
+		ms := mappingValue(doc.Content[0], "maintenance", yaml.SequenceNode)
+		items := ms.Content[:0]
+		for _, n := range ms.Content {
+			var o Maintenance
+			if err := n.Decode(&o); err == nil && (o.Repo == repo || o.Over(now)) {
+				continue
+			}
+			items = append(items, n)
+		}
+		ms.Content = items
+	})
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestMaintenance(t *testing.T) {
+	is := is.New(t)
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &Config{Maintenance: []Maintenance{
+		{Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour), Message: "over"},
+		{Repo: "repo", Start: now.Add(-time.Hour), End: now.Add(time.Hour), Message: "migrating"},
+		{Start: now.Add(3 * time.Hour), End: now.Add(4 * time.Hour), Message: "later"},
+		{Start: now.Add(2 * time.Hour), Message: "upgrade"},
+	}}
+
+	is.Equal(cfg.ActiveMaintenance("other", now), nil)
+	m := cfg.ActiveMaintenance("repo", now)
+	is.True(m != nil)
+	is.Equal(m.Error(), "repo is read-only for maintenance until 2023-06-01 13:00 UTC: migrating")
+	is.Equal(cfg.MaintenanceBanner("repo", now), "Read-only: "+m.Error())
+	// The next window of the server is announced.
+	is.Equal(cfg.MaintenanceBanner("", now), "Maintenance scheduled at 2023-06-01 14:00 UTC: upgrade")
+	is.Equal(len(cfg.MaintenanceWindows(now)), 3)
+
+	// Server-wide windows spare the config repo.
+	later := now.Add(2 * time.Hour)
+	is.True(cfg.ActiveMaintenance("other", later) != nil)
+	is.Equal(cfg.ActiveMaintenance("config", later), nil)
+}
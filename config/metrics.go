@@ -0,0 +1,14 @@
+package config
+
+import "github.com/charmbracelet/soft-serve/metrics"
+
+var (
+	fetchesTotal = metrics.NewCounter("soft_serve_git_fetches_total",
+		"Fetches and clones of repos, over any protocol.", "repo")
+	pushesTotal = metrics.NewCounter("soft_serve_git_pushes_total",
+		"Pushes to repos, over any protocol.", "repo")
+	authFailuresTotal = metrics.NewCounter("soft_serve_auth_failures_total",
+		"Failed authentications, by method: publickey and keyboard-interactive over SSH, and http.", "method")
+	bandwidthBytes = metrics.NewCounter("soft_serve_bandwidth_bytes_total",
+		"Bytes transferred by SSH sessions and git HTTP requests, by user and direction, in to or out of the server.", "user", "direction")
+)
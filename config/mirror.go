@@ -0,0 +1,246 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// DefaultMirrorInterval is the time between the syncs of mirrors that don't
+// set their own.
+const DefaultMirrorInterval = time.Hour
+
+// ErrNotMirror is returned when syncing a repo that isn't a synced mirror.
+var ErrNotMirror = errors.New("repo isn't a mirror")
+
+// MirrorStatus is the sync status of a mirror.
+type MirrorStatus struct {
+	Repo string `json:"repo"`
+	// URL is the upstream the mirror syncs from, without the credentials it
+	// may hold.
+	URL string `json:"url"`
+	// Interval is the time between syncs.
+	Interval time.Duration `json:"interval"`
+	// LastSync is when the mirror last synced, zero if it never did.
+	LastSync time.Time `json:"last-sync"`
+	// LastAttempt is when the mirror last tried to sync.
+	LastAttempt time.Time `json:"last-attempt"`
+	// Error is why the last sync failed, if it did.
+	Error string `json:"error,omitempty"`
+}
+
+// Due returns whether the mirror is due to sync at t.
+func (m MirrorStatus) Due(t time.Time) bool {
+	return m.LastAttempt.IsZero() || !t.Before(m.LastAttempt.Add(m.Interval))
+}
+
+// Mirrors returns the status of the mirrors synced from their upstream,
+// sorted by repo. Only the mirrors of the config repo are synced: the config
+// files of repos can be pushed by collaborators, and those of mirrors come
+// from upstream.
+func (cfg *Config) Mirrors() []MirrorStatus {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	ms := make([]MirrorStatus, 0)
+	for _, r := range cfg.Repos {
+		if m, ok := cfg.mirrorStatus(r.Repo); ok {
+			ms = append(ms, m)
+		}
+	}
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Repo < ms[j].Repo
+	})
+	return ms
+}
+
+// MirrorStatus returns the status of a mirror, and false if the repo isn't a
+// synced mirror.
+func (cfg *Config) MirrorStatus(repo string) (MirrorStatus, bool) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	return cfg.mirrorStatus(repo)
+}
+
+// mirrorStatus is MirrorStatus for callers holding the lock.
+func (cfg *Config) mirrorStatus(repo string) (MirrorStatus, bool) {
+	r := cfg.findRepo(repo)
+	if r == nil || r.Mirror == "" || r.repoFile {
+		return MirrorStatus{}, false
+	}
+	m := cfg.mirrors[repo]
+	m.Repo = repo
+	m.URL = redactURL(r.Mirror)
+	m.Interval = DefaultMirrorInterval
+	if d, err := r.mirrorInterval(); err == nil {
+		m.Interval = d
+	}
+	return m, true
+}
+
+// mirrorInterval returns the time between the syncs of a mirror.
+func (r RepoConfig) mirrorInterval() (time.Duration, error) {
+	if r.MirrorInterval == "" {
+		return DefaultMirrorInterval, nil
+	}
+	d, err := time.ParseDuration(r.MirrorInterval)
+	if err == nil && d <= 0 {
+		err = fmt.Errorf("mirror interval %q must be positive", r.MirrorInterval)
+	}
+	return d, err
+}
+
+// SyncMirror fetches the branches and tags of the upstream of a mirror,
+// overwriting its own, and records the outcome in its status. Refs updated
+// by the sync are handled like those of a push, with no pusher. Missing
+// mirrors are created, and empty ones get the HEAD of their upstream.
+func (cfg *Config) SyncMirror(ctx context.Context, repo string) error {
+	cfg.mtx.Lock()
+	_, ok := cfg.mirrorStatus(repo)
+	upstream := ""
+	if ok {
+		upstream = cfg.findRepo(repo).Mirror
+	}
+	cfg.mtx.Unlock()
+	if !ok {
+		return ErrNotMirror
+	}
+	r, err := cfg.Source.GetRepo(repo)
+	if errors.Is(err, ErrMissingRepo) {
+		if repo == "" || strings.HasPrefix(repo, "/") || strings.Contains(repo, "..") {
+			return fmt.Errorf("invalid repository name %q", repo)
+		}
+		r, err = cfg.Source.InitRepo(repo, true)
+	}
+	if err != nil {
+		return err
+	}
+	before := cfg.RefHashes(repo)
+	unlock := cfg.Source.LockPush(repo)
+	err = r.repository.Fetch(ctx, upstream)
+	if _, herr := r.repository.HEAD(); err == nil && herr != nil {
+		var head string
+		if head, err = r.repository.RemoteHEAD(ctx, upstream); err == nil {
+			err = r.repository.SetHEAD(head)
+		}
+		if errors.Is(err, git.ErrReferenceNotFound) {
+			// Empty upstreams have no HEAD to follow.
+			err = nil
+		}
+	}
+	unlock()
+	if err != nil && upstream != redactURL(upstream) {
+		err = errors.New(strings.ReplaceAll(err.Error(), upstream, redactURL(upstream)))
+	}
+
+	now := time.Now()
+	cfg.mtx.Lock()
+	if cfg.mirrors == nil {
+		cfg.mirrors = make(map[string]MirrorStatus)
+	}
+	st := cfg.mirrors[repo]
+	// Mirrors that keep failing are only reported once.
+	failed := err != nil && st.Error == ""
+	st.LastAttempt = now
+	st.Error = ""
+	if err != nil {
+		st.Error = err.Error()
+	} else {
+		st.LastSync = now
+	}
+	cfg.mirrors[repo] = st
+	cfg.mtx.Unlock()
+	if failed {
+		cfg.Events.Publish(events.Event{
+			Type:  events.MirrorFailed,
+			Repo:  repo,
+			Error: err.Error(),
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	after := cfg.RefHashes(repo)
+	changed := len(after) != len(before)
+	for ref, hash := range after {
+		if before[ref] != hash {
+			changed = true
+		}
+	}
+	if changed {
+		cfg.PushFrom(repo, nil, before)
+	}
+	return nil
+}
+
+// RepoPushMirrors returns the push mirrors of a repo. Only the push mirrors
+// of the config repo are pushed to: the config files of repos can be pushed
+// by collaborators, who could push the repo anywhere with the credentials of
+// the server.
+func (cfg *Config) RepoPushMirrors(repo string) []PushMirror {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if r := cfg.findRepo(repo); r != nil && !r.repoFile {
+		return r.PushMirrors
+	}
+	return nil
+}
+
+// PushToMirror pushes refs of a repo to one of its push mirrors with the
+// credentials held by its secret, overwriting the refs of the mirror. Refs
+// the repo doesn't have anymore are deleted from the mirror.
+func (cfg *Config) PushToMirror(ctx context.Context, repo string, m PushMirror, refs ...string) error {
+	var header string
+	if m.CredentialsSecret != "" {
+		if u, err := url.Parse(m.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("push mirror %s: credentials are only supported for HTTP remotes", m)
+		}
+		if cfg.Secrets == nil {
+			return errors.New("secrets are not configured")
+		}
+		creds, err := cfg.Secrets.Get(m.CredentialsSecret)
+		if err != nil {
+			return fmt.Errorf("secret %q: %w", m.CredentialsSecret, err)
+		}
+		if !strings.Contains(creds, ":") {
+			return fmt.Errorf("secret %q must hold USER:PASSWORD", m.CredentialsSecret)
+		}
+		header = "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(creds))
+	}
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return err
+	}
+	hashes := cfg.RefHashes(repo)
+	refspecs := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		if _, ok := hashes[ref]; ok {
+			refspecs = append(refspecs, "+"+ref+":"+ref)
+		} else {
+			refspecs = append(refspecs, ":"+ref)
+		}
+	}
+	err = r.repository.PushContext(ctx, m.URL, header, refspecs...)
+	if err != nil && m.URL != m.String() {
+		err = errors.New(strings.ReplaceAll(err.Error(), m.URL, m.String()))
+	}
+	return err
+}
+
+// redactURL returns a URL without the credentials it may hold.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
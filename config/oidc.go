@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// DefaultOIDCClaim is the claim of ID tokens matched against the oidc
+// identity of users.
+const DefaultOIDCClaim = "email"
+
+// OIDC configures signing in to the web UI with an OpenID Connect provider.
+// Signed in users get the access of the user whose oidc identity matches the
+// claim of their ID token.
+type OIDC struct {
+	// Issuer is the issuer URL of the provider, e.g.
+	// https://accounts.google.com.
+	Issuer   string `yaml:"issuer" json:"issuer"`
+	ClientID string `yaml:"client-id" json:"client-id"`
+	// ClientSecret is the name of the secret holding the client secret.
+	ClientSecret string `yaml:"client-secret" json:"client-secret"`
+	// URL is the public URL of the HTTP server. The provider sends users
+	// back to URL/-/oidc/callback.
+	URL string `yaml:"url" json:"url"`
+	// Claim is the claim matched against the oidc identity of users,
+	// DefaultOIDCClaim if empty.
+	Claim string `yaml:"claim" json:"claim"`
+	// Scopes are requested on top of "openid", e.g. "email".
+	Scopes []string `yaml:"scopes" json:"scopes"`
+}
+
+// Enabled returns whether signing in with OIDC is configured.
+func (o OIDC) Enabled() bool {
+	return o.Issuer != "" && o.ClientID != "" && o.URL != ""
+}
+
+// RedirectURL returns the URL the provider sends users back to.
+func (o OIDC) RedirectURL() string {
+	return strings.TrimSuffix(o.URL, "/") + "/-/oidc/callback"
+}
+
+// OIDCSettings returns the OIDC settings of the server, with defaults
+// applied.
+func (cfg *Config) OIDCSettings() OIDC {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	o := cfg.OIDC
+	if o.Claim == "" {
+		o.Claim = DefaultOIDCClaim
+	}
+	return o
+}
+
+// OIDCAuth returns the name and first public key of the user with the given
+// oidc identity, so that users signed in with OIDC get the same access as
+// their SSH connections. It returns false if the identity doesn't match a
+// user with a public key.
+func (cfg *Config) OIDCAuth(remoteAddr, identity string) (string, ssh.PublicKey, bool) {
+	var name string
+	cfg.mtx.Lock()
+	for _, u := range cfg.Users {
+		if identity != "" && u.OIDC == identity && len(u.PublicKeys) > 0 {
+			name = u.Name
+			break
+		}
+	}
+	cfg.mtx.Unlock()
+	pk, ok := cfg.UserKey(name)
+	if !ok {
+		authFailuresTotal.Inc("http")
+	}
+	if !ok {
+		name = identity
+	}
+	cfg.publishAuth(remoteAddr, "oidc", name, pk, ok)
+	if !ok {
+		return "", nil, false
+	}
+	return name, pk, ok
+}
+
+// UserKey returns the first public key of the named user.
+func (cfg *Config) UserKey(name string) (ssh.PublicKey, bool) {
+	if name == "" {
+		return nil, false
+	}
+	cfg.mtx.Lock()
+	var key string
+	for _, u := range cfg.Users {
+		if u.Name == name && len(u.PublicKeys) > 0 {
+			key = u.PublicKeys[0]
+			break
+		}
+	}
+	cfg.mtx.Unlock()
+	if key == "" {
+		return nil, false
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(key)))
+	if err != nil {
+		return nil, false
+	}
+	return pk, true
+}
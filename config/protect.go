@@ -0,0 +1,136 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// BranchRule protects the branches of a repo matching its patterns from
+// pushes.
+type BranchRule struct {
+	// Branches are glob patterns of the protected branches.
+	Branches []string `yaml:"branches" json:"branches"`
+	// NoForcePush rejects pushes rewriting the history of the branches.
+	NoForcePush bool `yaml:"no-force-push" json:"no-force-push"`
+	// NoDeletion rejects pushes deleting the branches.
+	NoDeletion bool `yaml:"no-deletion" json:"no-deletion"`
+	// Pushers are the users and teams allowed to push to the branches. All
+	// users with write access can when empty.
+	Pushers []string `yaml:"pushers" json:"pushers"`
+}
+
+// RefUpdate is the update of a ref by a push. Old is the zero hash for refs
+// being created, and New for refs being deleted.
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+}
+
+// Deletes returns whether the update deletes the ref.
+func (u RefUpdate) Deletes() bool {
+	return isZeroHash(u.New)
+}
+
+// PushViolation is a ref update of a push breaking a branch protection rule.
+type PushViolation struct {
+	Ref    string
+	Reason string
+}
+
+// String implements fmt.Stringer.
+func (v PushViolation) String() string {
+	return fmt.Sprintf("%s: %s", v.Ref, v.Reason)
+}
+
+// CheckPush returns the ref updates of a push by the user of a key breaking
+// the branch protection rules of a repo: deleting branches that can't be,
+// or pushing to branches the user isn't allowed to. Force pushes are
+// rejected by the pre-receive hook of the repo, once the pushed commits are
+// received.
+func (cfg *Config) CheckPush(repo string, pk ssh.PublicKey, updates []RefUpdate) []PushViolation {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	r := cfg.findRepo(repo)
+	if r == nil || len(r.Protect) == 0 {
+		return nil
+	}
+	user := ""
+	if pk != nil {
+		if u := cfg.findUser(pk); u != nil {
+			user = u.Name
+		}
+	}
+	vs := make([]PushViolation, 0)
+	for _, u := range updates {
+		if !strings.HasPrefix(u.Ref, git.RefsHeads) {
+			continue
+		}
+		branch := strings.TrimPrefix(u.Ref, git.RefsHeads)
+		for _, rule := range r.Protect {
+			if !MatchBranch(rule.Branches, branch) {
+				continue
+			}
+			if len(rule.Pushers) > 0 && !cfg.isPusher(rule, user) {
+				vs = append(vs, PushViolation{u.Ref, "protected branch, you can't push to it"})
+				break
+			}
+			if rule.NoDeletion && u.Deletes() {
+				vs = append(vs, PushViolation{u.Ref, "protected branch, it can't be deleted"})
+				break
+			}
+		}
+	}
+	return vs
+}
+
+// isPusher returns whether a user is one of the pushers of a rule, or in
+// one of its teams. The caller must hold the lock.
+func (cfg *Config) isPusher(rule BranchRule, user string) bool {
+	if user == "" {
+		return false
+	}
+	for _, p := range rule.Pushers {
+		if p == user || cfg.inTeam(user, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// forcePushProtected returns the branch refs of a repo protected from force
+// pushes, sorted. Only existing branches are listed, since creating a branch
+// doesn't rewrite its history. The caller must hold the lock.
+func (cfg *Config) forcePushProtected(repo string) []string {
+	r := cfg.findRepo(repo)
+	if r == nil {
+		return nil
+	}
+	var patterns []string
+	for _, rule := range r.Protect {
+		if rule.NoForcePush {
+			patterns = append(patterns, rule.Branches...)
+		}
+	}
+	if len(patterns) == 0 {
+		return nil
+	}
+	refs := make([]string, 0)
+	for ref := range cfg.RefHashes(repo) {
+		if strings.HasPrefix(ref, git.RefsHeads) && MatchBranch(patterns, strings.TrimPrefix(ref, git.RefsHeads)) {
+			refs = append(refs, ref)
+		}
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// isZeroHash returns whether a hash is the zero hash git uses for missing
+// refs.
+func isZeroHash(h string) bool {
+	return h != "" && strings.Trim(h, "0") == ""
+}
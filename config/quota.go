@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+)
+
+// DefaultQuotaWarn is the percentage of its quota from which pushes to a
+// repo get a warning.
+const DefaultQuotaWarn = 85
+
+// Quota caps the on-disk size of repos. Pushes to a repo at or over its
+// quota are rejected; pushes past the warning threshold are accepted with an
+// advisory message, so the repo can be cleaned up before that happens.
+type Quota struct {
+	// MaxSize is the size of the quota, e.g. "1GB".
+	MaxSize string `yaml:"max-size" json:"max-size"`
+	// Warn is the percentage of MaxSize from which pushes get a warning,
+	// DefaultQuotaWarn if zero.
+	Warn int `yaml:"warn" json:"warn"`
+}
+
+// MaxBytes returns the size of the quota in bytes, or 0 for no quota.
+func (q Quota) MaxBytes() (uint64, error) {
+	if q.MaxSize == "" {
+		return 0, nil
+	}
+	n, err := humanize.ParseBytes(q.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid quota max-size %q: %w", q.MaxSize, err)
+	}
+	return n, nil
+}
+
+// QuotaUsage is how much of its quota a repo uses.
+type QuotaUsage struct {
+	// Used is the size of the repo in bytes.
+	Used int64 `json:"used"`
+	// Max is the size of the quota in bytes.
+	Max int64 `json:"max"`
+	// Warn is the percentage of Max from which pushes get a warning.
+	Warn int `json:"warn"`
+}
+
+// Percent returns the percentage of the quota used, rounded down.
+func (u QuotaUsage) Percent() int {
+	if u.Max <= 0 {
+		return 0
+	}
+	return int(u.Used * 100 / u.Max)
+}
+
+// Exceeded returns whether the repo is at or over its quota.
+func (u QuotaUsage) Exceeded() bool {
+	return u.Used >= u.Max
+}
+
+// Warning returns whether the repo is past the warning threshold of its
+// quota.
+func (u QuotaUsage) Warning() bool {
+	return u.Percent() >= u.Warn
+}
+
+// String returns the usage as the message shown to pushers.
+func (u QuotaUsage) String() string {
+	return fmt.Sprintf("repo at %d%% of its %s quota (%s used)",
+		u.Percent(), humanize.Bytes(uint64(u.Max)), humanize.Bytes(uint64(u.Used)))
+}
+
+// RepoQuota returns the quota of a repo: its own if it has one, or the quota
+// of the server. ok is false when neither sets a max-size.
+func (cfg *Config) RepoQuota(repo string) (q Quota, ok bool) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	q = cfg.Quota
+	if r := cfg.findRepo(repo); r != nil && r.Quota.MaxSize != "" {
+		q = r.Quota
+	}
+	if q.Warn <= 0 {
+		q.Warn = DefaultQuotaWarn
+	}
+	return q, q.MaxSize != ""
+}
+
+// QuotaUsage measures how much of its quota a repo uses. ok is false when
+// the repo has no quota, or doesn't exist. Unlike DiskUsage, it walks the
+// repo, so it's meant for checking pushes rather than listing repos.
+func (cfg *Config) QuotaUsage(repo string) (u QuotaUsage, ok bool, err error) {
+	q, ok := cfg.RepoQuota(repo)
+	if !ok {
+		return u, false, nil
+	}
+	max, err := q.MaxBytes()
+	if err != nil {
+		return u, false, err
+	}
+	r, err := cfg.Source.GetRepo(repo)
+	if err != nil {
+		return u, false, nil
+	}
+	du, err := r.DiskUsage()
+	if err != nil {
+		return u, false, err
+	}
+	return QuotaUsage{Used: du.Total, Max: int64(max), Warn: q.Warn}, true, nil
+}
+
+// StoredQuotaUsage returns how much of its quota a repo used when its disk
+// usage was last measured, see MeasureDiskUsage. ok is false when the repo
+// has no quota or wasn't measured yet.
+func (cfg *Config) StoredQuotaUsage(repo string) (u QuotaUsage, ok bool, err error) {
+	q, ok := cfg.RepoQuota(repo)
+	if !ok {
+		return u, false, nil
+	}
+	max, err := q.MaxBytes()
+	if err != nil {
+		return u, false, err
+	}
+	du, _, ok, err := cfg.DiskUsage(repo)
+	if err != nil || !ok {
+		return u, false, err
+	}
+	return QuotaUsage{Used: du.Total, Max: int64(max), Warn: q.Warn}, true, nil
+}
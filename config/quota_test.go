@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestQuotaUsage(t *testing.T) {
+	is := is.New(t)
+	sc := &config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: t.TempDir(),
+	}
+	cfg, err := NewConfig(sc)
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	_, ok, err := cfg.QuotaUsage("repo")
+	is.NoErr(err)
+	is.True(!ok) // no quota
+
+	r, err := cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+	du, err := r.DiskUsage()
+	is.NoErr(err)
+	// The quota of the repo overrides the one of the server.
+	cfg.Quota = Quota{MaxSize: "1TB"}
+	cfg.Repos = append(cfg.Repos, RepoConfig{Repo: "repo", Quota: Quota{MaxSize: "1MB"}})
+	u, ok, err := cfg.QuotaUsage("repo")
+	is.NoErr(err)
+	is.True(ok)
+	is.Equal(u.Max, int64(1000*1000))
+	is.Equal(u.Used, du.Total)
+	is.Equal(u.Warn, DefaultQuotaWarn)
+	is.True(!u.Warning())
+	is.True(!u.Exceeded())
+
+	is.NoErr(os.WriteFile(filepath.Join(sc.RepoPath, "repo", "objects", "big"), make([]byte, 900*1000), 0o600))
+	u, _, err = cfg.QuotaUsage("repo")
+	is.NoErr(err)
+	is.True(u.Percent() >= 90)
+	is.True(u.Warning())
+	is.True(!u.Exceeded())
+
+	// Stored usage is the one of the last measurement.
+	_, ok, err = cfg.StoredQuotaUsage("repo")
+	is.NoErr(err)
+	is.True(!ok)
+	is.NoErr(cfg.MeasureDiskUsage())
+	su, ok, err := cfg.StoredQuotaUsage("repo")
+	is.NoErr(err)
+	is.True(ok)
+	is.Equal(su, u)
+
+	cfg.Repos[len(cfg.Repos)-1].Quota = Quota{MaxSize: "100KB", Warn: 50}
+	u, _, err = cfg.QuotaUsage("repo")
+	is.NoErr(err)
+	is.True(u.Exceeded())
+	is.Equal(u.Warn, 50)
+
+	cfg.Repos[len(cfg.Repos)-1].Quota = Quota{MaxSize: "lots"}
+	_, _, err = cfg.QuotaUsage("repo")
+	is.True(err != nil)
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// Release is a tag of a repo, dated by the commit it points to.
+type Release struct {
+	Repo   string    `json:"repo"`
+	Tag    string    `json:"tag"`
+	Commit string    `json:"commit"`
+	Date   time.Time `json:"date"`
+	// hash is the hash of the tag ref, so that moved tags are looked up
+	// again.
+	hash string
+}
+
+// Releases returns the tags of the repos the key can read that have releases
+// on, newest first. The commits of tags are cached, and only looked up again
+// when tags are created or moved.
+func (cfg *Config) Releases(pk ssh.PublicKey) []Release {
+	rs := make([]Release, 0)
+	for _, r := range cfg.Source.AllRepos() {
+		repo := r.Repo()
+		if cfg.AuthRepo(repo, pk) < gm.ReadOnlyAccess || !cfg.FeatureEnabled(repo, FeatureReleases) {
+			continue
+		}
+		rs = append(rs, cfg.repoReleases(r)...)
+	}
+	SortReleases(rs, "date")
+	return rs
+}
+
+// repoReleases returns the tags of a repo, from the cache when they haven't
+// moved.
+func (cfg *Config) repoReleases(r *Repo) []Release {
+	refs, err := r.References()
+	if err != nil {
+		return nil
+	}
+	cfg.mtx.Lock()
+	cached := cfg.releases[r.Repo()]
+	cfg.mtx.Unlock()
+	tags := make(map[string]Release)
+	rs := make([]Release, 0)
+	for _, ref := range refs {
+		if !ref.IsTag() {
+			continue
+		}
+		rel, ok := cached[ref.Refspec]
+		if !ok || rel.hash != ref.Hash.String() {
+			c, err := r.Commit(ref.TargetHash().String())
+			if err != nil {
+				// Tags of trees and blobs aren't releases.
+				continue
+			}
+			when := c.Committer.When
+			if when.IsZero() {
+				when = c.Author.When
+			}
+			rel = Release{
+				Repo:   r.Repo(),
+				Tag:    ref.Name().Short(),
+				Commit: c.ID.String(),
+				Date:   when,
+				hash:   ref.Hash.String(),
+			}
+		}
+		tags[ref.Refspec] = rel
+		rs = append(rs, rel)
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if cfg.releases == nil {
+		cfg.releases = make(map[string]map[string]Release)
+	}
+	cfg.releases[r.Repo()] = tags
+	return rs
+}
+
+// SortReleases sorts releases by date, newest first, or by name, i.e. by
+// repo and tag.
+func SortReleases(rs []Release, by string) error {
+	switch by {
+	case "date":
+		sort.SliceStable(rs, func(i, j int) bool {
+			return rs[i].Date.After(rs[j].Date)
+		})
+	case "name":
+		sort.SliceStable(rs, func(i, j int) bool {
+			if rs[i].Repo != rs[j].Repo {
+				return rs[i].Repo < rs[j].Repo
+			}
+			return rs[i].Tag < rs[j].Tag
+		})
+	default:
+		return fmt.Errorf("invalid sort %q, must be date or name", by)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestReleases(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	adminKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{adminKey},
+	})
+	is.NoErr(err)
+	admin, _, _, _, err := ssh.ParseAuthorizedKey([]byte(adminKey))
+	is.NoErr(err)
+	work := t.TempDir()
+	is.NoErr(runGit(work, "init", "-q", "-b", "main"))
+	tag := func(repo, name, date string) {
+		t.Setenv("GIT_COMMITTER_DATE", date)
+		is.NoErr(runGit(work, "commit", "-q", "--allow-empty", "-m", name))
+		is.NoErr(runGit(work, "tag", "-f", "-a", "-m", name, name))
+		r, err := cfg.Source.GetRepo(repo)
+		if err != nil {
+			r, err = cfg.Source.InitRepo(repo, true)
+		}
+		is.NoErr(err)
+		is.NoErr(runGit(work, "push", "-q", "-f", r.Path(), "main", "refs/tags/"+name))
+		is.NoErr(r.SetHEAD("main"))
+		is.NoErr(cfg.Source.LoadRepo(repo))
+	}
+	tag("a", "v1", "2020-01-01T00:00:00Z")
+	tag("b", "v1.5", "2021-01-01T00:00:00Z")
+	tag("a", "v2", "2022-01-01T00:00:00Z")
+	private := true
+	is.NoErr(cfg.SetRepoSettings("b", RepoSettings{Private: &private}))
+	names := func(rs []Release) []string {
+		ns := make([]string, len(rs))
+		for i, r := range rs {
+			ns[i] = r.Repo + " " + r.Tag
+		}
+		return ns
+	}
+
+	// Releases are listed newest first, from the repos the key can read.
+	rs := cfg.Releases(admin)
+	is.Equal(names(rs), []string{"a v2", "b v1.5", "a v1"})
+	is.Equal(rs[0].Date.Year(), 2022)
+	is.Equal(len(rs[0].Commit), 40)
+	is.Equal(names(cfg.Releases(nil)), []string{"a v2", "a v1"})
+	is.NoErr(SortReleases(rs, "name"))
+	is.Equal(names(rs), []string{"a v1", "a v2", "b v1.5"})
+	is.True(SortReleases(rs, "size") != nil)
+
+	// Moved tags are dated again.
+	tag("a", "v1", "2023-01-01T00:00:00Z")
+	rs = cfg.Releases(admin)
+	is.Equal(names(rs), []string{"a v1", "a v2", "b v1.5"})
+	is.Equal(rs[0].Date.Year(), 2023)
+
+	// Repos with releases off aren't listed.
+	is.NoErr(cfg.SetRepoSettings("a", RepoSettings{Features: map[Feature]bool{FeatureReleases: false}}))
+	is.Equal(names(cfg.Releases(admin)), []string{"b v1.5"})
+}
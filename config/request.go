@@ -0,0 +1,291 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// accessRequestsFile is the file of the data path holding the pending access
+// requests.
+const accessRequestsFile = "access-requests.json"
+
+var (
+	// ErrNotRequestable is returned when requesting access to a repo that
+	// doesn't exist, or isn't requestable.
+	ErrNotRequestable = errors.New("repo doesn't take access requests")
+	// ErrHasAccess is returned when requesting access to a repo the user can
+	// already read.
+	ErrHasAccess = errors.New("you already have access to the repo")
+	// ErrRequestPending is returned when the user already requested access
+	// to the repo.
+	ErrRequestPending = errors.New("access to the repo was already requested")
+	// ErrUnknownRequest is returned for access requests that aren't
+	// pending.
+	ErrUnknownRequest = errors.New("unknown access request")
+	// ErrInvalidAccess is returned when approving access requests with an
+	// invalid access level.
+	ErrInvalidAccess = errors.New("invalid access, must be read-only, read-write, or admin-access")
+)
+
+// AccessRequest is a pending request of a user for access to a private repo.
+type AccessRequest struct {
+	ID      string    `json:"id"`
+	Repo    string    `json:"repo"`
+	User    string    `json:"user"`
+	Message string    `json:"message,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// RequestAccess records a request of the user of a key for access to a
+// requestable repo, and publishes an access-requested event, so that the
+// admins of the repo can approve it.
+func (cfg *Config) RequestAccess(repo string, pk ssh.PublicKey, message string) (AccessRequest, error) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return AccessRequest{}, errors.New("no data path to keep access requests in")
+	}
+	cfg.mtx.Lock()
+	u := cfg.findUser(pk)
+	r := cfg.findRepo(repo)
+	cfg.mtx.Unlock()
+	if u == nil {
+		return AccessRequest{}, ErrUnknownUser
+	}
+	if r == nil || !r.Requestable {
+		return AccessRequest{}, ErrNotRequestable
+	}
+	if cfg.AuthRepo(repo, pk) >= gm.ReadOnlyAccess {
+		return AccessRequest{}, ErrHasAccess
+	}
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return AccessRequest{}, err
+	}
+	req := AccessRequest{
+		ID:      hex.EncodeToString(b),
+		Repo:    repo,
+		User:    u.Name,
+		Message: message,
+		Created: time.Now().UTC(),
+	}
+	cfg.mtx.Lock()
+	reqs, err := cfg.readAccessRequests()
+	if err == nil {
+		for _, p := range reqs {
+			if p.Repo == repo && p.User == u.Name {
+				err = ErrRequestPending
+			}
+		}
+	}
+	if err == nil {
+		err = cfg.writeAccessRequests(append(reqs, req))
+	}
+	cfg.mtx.Unlock()
+	if err != nil {
+		return AccessRequest{}, err
+	}
+	cfg.Events.Publish(events.Event{
+		Type:    events.AccessRequested,
+		Repo:    repo,
+		User:    u.Name,
+		Message: message,
+	})
+	return req, nil
+}
+
+// Requestable returns whether the user of a key can request access to a
+// repo: the repo is requestable, and the user is a user of the server who
+// can't read it.
+func (cfg *Config) Requestable(repo string, pk ssh.PublicKey) bool {
+	cfg.mtx.Lock()
+	u := cfg.findUser(pk)
+	r := cfg.findRepo(repo)
+	cfg.mtx.Unlock()
+	if u == nil || r == nil || !r.Requestable {
+		return false
+	}
+	return cfg.AuthRepo(repo, pk) < gm.ReadOnlyAccess
+}
+
+// AccessRequested reports whether the user of a key has a pending request
+// for access to the repo.
+func (cfg *Config) AccessRequested(repo string, pk ssh.PublicKey) bool {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return false
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	u := cfg.findUser(pk)
+	if u == nil {
+		return false
+	}
+	reqs, err := cfg.readAccessRequests()
+	if err != nil {
+		return false
+	}
+	for _, r := range reqs {
+		if r.Repo == repo && r.User == u.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessRequests returns the pending access requests to the repos the key
+// has admin access to, oldest first.
+func (cfg *Config) AccessRequests(pk ssh.PublicKey) ([]AccessRequest, error) {
+	reqs := make([]AccessRequest, 0)
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return reqs, nil
+	}
+	cfg.mtx.Lock()
+	all, err := cfg.readAccessRequests()
+	cfg.mtx.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range all {
+		if cfg.AuthRepo(r.Repo, pk) >= gm.AdminAccess {
+			reqs = append(reqs, r)
+		}
+	}
+	return reqs, nil
+}
+
+// AccessRequest returns the pending access request with the given ID.
+func (cfg *Config) AccessRequest(id string) (AccessRequest, error) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return AccessRequest{}, ErrUnknownRequest
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	reqs, err := cfg.readAccessRequests()
+	if err != nil {
+		return AccessRequest{}, err
+	}
+	for _, r := range reqs {
+		if r.ID == id {
+			return r, nil
+		}
+	}
+	return AccessRequest{}, ErrUnknownRequest
+}
+
+// ApproveAccess grants the user of a pending access request an access level
+// to the repo, committed to the config repo, removes the request, and
+// publishes an access-approved event. by is the key of the approver.
+func (cfg *Config) ApproveAccess(id, access string, by ssh.PublicKey) error {
+	if accessLevel(access, gm.NoAccess) == gm.NoAccess {
+		return ErrInvalidAccess
+	}
+	req, err := cfg.AccessRequest(id)
+	if err != nil {
+		return err
+	}
+	msg := fmt.Sprintf("Grant %s %s access to %s", req.User, access, req.Repo)
+	err = cfg.editConfig(msg, func(doc *yaml.Node) {
+		rc := repoNode(doc, req.Repo, true)
+		users := mappingValue(rc, "users", yaml.MappingNode)
+		mappingValue(users, req.User, yaml.ScalarNode).Value = access
+	})
+	if err != nil {
+		return err
+	}
+	return cfg.closeAccessRequest(req, events.AccessApproved, access, by)
+}
+
+// DenyAccess removes a pending access request, and publishes an
+// access-denied event. by is the key of the admin denying it.
+func (cfg *Config) DenyAccess(id string, by ssh.PublicKey) error {
+	req, err := cfg.AccessRequest(id)
+	if err != nil {
+		return err
+	}
+	return cfg.closeAccessRequest(req, events.AccessDenied, "", by)
+}
+
+// closeAccessRequest removes an access request, and publishes an event of
+// its outcome.
+func (cfg *Config) closeAccessRequest(req AccessRequest, typ events.Type, access string, by ssh.PublicKey) error {
+	cfg.mtx.Lock()
+	reqs, err := cfg.readAccessRequests()
+	if err == nil {
+		kept := reqs[:0]
+		for _, r := range reqs {
+			if r.ID != req.ID {
+				kept = append(kept, r)
+			}
+		}
+		err = cfg.writeAccessRequests(kept)
+	}
+	cfg.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+	cfg.Events.Publish(events.Event{
+		Type:      typ,
+		Repo:      req.Repo,
+		User:      cfg.userName(by),
+		Requester: req.User,
+		Access:    access,
+	})
+	return nil
+}
+
+// readAccessRequests reads the pending access requests, oldest first. The
+// caller must hold the lock.
+func (cfg *Config) readAccessRequests() ([]AccessRequest, error) {
+	reqs := make([]AccessRequest, 0)
+	bts, err := os.ReadFile(filepath.Join(cfg.Cfg.DataPath, accessRequestsFile))
+	if os.IsNotExist(err) {
+		return reqs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &reqs); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(reqs, func(i, j int) bool {
+		return reqs[i].Created.Before(reqs[j].Created)
+	})
+	return reqs, nil
+}
+
+// writeAccessRequests replaces the pending access requests. The caller must
+// hold the lock.
+func (cfg *Config) writeAccessRequests(reqs []AccessRequest) error {
+	bts, err := json.Marshal(reqs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Cfg.DataPath, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(cfg.Cfg.DataPath, accessRequestsFile)
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
+
+// userAccess returns the access level the repo grants a user by name.
+func (cfg *Config) userAccess(repo string, user *User) gm.AccessLevel {
+	r := cfg.findRepo(repo)
+	if r == nil || user == nil || user.Name == "" {
+		return gm.NoAccess
+	}
+	return accessLevel(r.Users[user.Name], gm.NoAccess)
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+func TestAccessRequests(t *testing.T) {
+	is := is.New(t)
+	adminKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		DataPath:         t.TempDir(),
+		InitialAdminKeys: []string{adminKey},
+	})
+	is.NoErr(err)
+	admin, _, _, _, err := ssh.ParseAuthorizedKey([]byte(adminKey))
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	private := true
+	is.NoErr(cfg.SetRepoSettings("repo", RepoSettings{Private: &private}))
+	is.NoErr(cfg.CreateUser("Frankie", []string{key}))
+
+	// Repos take requests only when they're requestable.
+	_, err = cfg.RequestAccess("repo", pk, "")
+	is.True(errors.Is(err, ErrNotRequestable))
+	is.NoErr(cfg.editConfig("Make repo requestable", func(doc *yaml.Node) {
+		setScalar(mappingValue(repoNode(doc, "repo", true), "requestable", yaml.ScalarNode), "!!bool", "true")
+	}))
+
+	c := cfg.Events.Subscribe(context.Background())
+	req, err := cfg.RequestAccess("repo", pk, "I'm on the team")
+	is.NoErr(err)
+	is.Equal(req.User, "Frankie")
+	is.True(cfg.AccessRequested("repo", pk))
+	_, err = cfg.RequestAccess("repo", pk, "")
+	is.True(errors.Is(err, ErrRequestPending))
+
+	// Only the admins of the repo see the request.
+	reqs, err := cfg.AccessRequests(pk)
+	is.NoErr(err)
+	is.Equal(len(reqs), 0)
+	reqs, err = cfg.AccessRequests(admin)
+	is.NoErr(err)
+	is.Equal(reqs, []AccessRequest{req})
+
+	is.True(errors.Is(cfg.ApproveAccess(req.ID, "root", admin), ErrInvalidAccess))
+	is.NoErr(cfg.ApproveAccess(req.ID, "read-only", admin))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.ReadOnlyAccess)
+	is.True(!cfg.AccessRequested("repo", pk))
+	_, err = cfg.AccessRequest(req.ID)
+	is.True(errors.Is(err, ErrUnknownRequest))
+	_, err = cfg.RequestAccess("repo", pk, "")
+	is.True(errors.Is(err, ErrHasAccess))
+
+	var seen []events.Type
+	for e := range c {
+		seen = append(seen, e.Type)
+		if e.Type == events.AccessApproved {
+			is.Equal(e.User, "Admin")
+			is.Equal(e.Requester, "Frankie")
+			is.Equal(e.Access, "read-only")
+			break
+		}
+	}
+	is.True(len(seen) > 1)
+	is.Equal(seen[0], events.AccessRequested)
+}
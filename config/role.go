@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// Role is a part of the admin access to the server, granted to users without
+// making them admins. Admins have all roles.
+type Role string
+
+const (
+	// RoleUserAdmin manages the keys of users who aren't admins and don't
+	// have roles, and the collaborators of repos.
+	RoleUserAdmin Role = "user-admin"
+	// RoleRepoAdmin has admin access to all repos but the config repo, to
+	// create, delete, and maintain them.
+	RoleRepoAdmin Role = "repo-admin"
+	// RoleSettingsAdmin manages the settings of the server kept out of the
+	// config repo: secrets, maintenance windows, retention, bandwidth, event
+	// deliveries, and connected sessions.
+	RoleSettingsAdmin Role = "settings-admin"
+	// RoleAuditor reads the audit log, events, and connected sessions.
+	RoleAuditor Role = "auditor"
+)
+
+// Roles are the roles that can be granted to users.
+var Roles = []Role{RoleUserAdmin, RoleRepoAdmin, RoleSettingsAdmin, RoleAuditor}
+
+// ValidRole returns an error if role isn't one of Roles.
+func ValidRole(role string) error {
+	for _, r := range Roles {
+		if string(r) == role {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid role %q, must be one of %v", role, Roles)
+}
+
+// hasRole returns whether the user was granted one of roles.
+func (u *User) hasRole(roles ...Role) bool {
+	for _, granted := range u.Roles {
+		for _, r := range roles {
+			if Role(granted) == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRole returns whether the key is of an admin, or of a user with one of
+// roles, granted in the configuration or by LDAP groups.
+func (cfg *Config) HasRole(pk ssh.PublicKey, roles ...Role) bool {
+	if cfg.AuthRepo("config", pk) >= gm.AdminAccess {
+		return true
+	}
+	cfg.mtx.Lock()
+	u := cfg.findUser(pk)
+	ok := u != nil && u.hasRole(roles...)
+	cfg.mtx.Unlock()
+	return ok || cfg.ldapHasRole(pk, roles...)
+}
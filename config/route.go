@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+)
+
+// Route sends the git commands of some repos to a backend git server, so
+// that Soft Serve can sit in front of legacy hosts while their repos are
+// migrated. Users authenticate to Soft Serve and get access to routed repos
+// like to local ones; the server connects to the backend with its own host
+// key, and passes the git streams through.
+type Route struct {
+	// Repos are the patterns of the repos routed, matched like path.Match,
+	// e.g. "legacy/*".
+	Repos []string `yaml:"repos" json:"repos"`
+	// Upstream is the SSH URL of the backend, e.g.
+	// ssh://git@legacy.example.com:22/srv/git. Repo paths are joined to its
+	// path.
+	Upstream string `yaml:"upstream" json:"upstream"`
+	// HostKey is the public key of the backend, in authorized_keys format,
+	// that connections to it are verified against.
+	HostKey string `yaml:"host-key" json:"host-key"`
+}
+
+// Matches returns whether the route applies to repo.
+func (r Route) Matches(repo string) bool {
+	for _, p := range r.Repos {
+		if ok, _ := path.Match(p, repo); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Target returns the address and user to connect to the backend of the
+// route with, and the path of repo on it.
+func (r Route) Target(repo string) (addr, user, repoPath string, err error) {
+	u, err := url.Parse(r.Upstream)
+	if err != nil {
+		return "", "", "", fmt.Errorf("invalid upstream %q: %w", r.Upstream, err)
+	}
+	if u.Scheme != "ssh" || u.Hostname() == "" {
+		return "", "", "", fmt.Errorf("invalid upstream %q, must be an ssh:// URL", r.Upstream)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	user = "git"
+	if u.User != nil && u.User.Username() != "" {
+		user = u.User.Username()
+	}
+	return net.JoinHostPort(u.Hostname(), port), user, path.Join(u.Path, repo), nil
+}
+
+// PublicHostKey returns the host key of the backend of the route.
+func (r Route) PublicHostKey() (ssh.PublicKey, error) {
+	if strings.TrimSpace(r.HostKey) == "" {
+		return nil, fmt.Errorf("route to %s has no host-key", r.Upstream)
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(r.HostKey)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host-key of route to %s: %w", r.Upstream, err)
+	}
+	return pk, nil
+}
+
+// RouteFor returns the first route applying to repo. ok is false when the
+// repo is served locally.
+func (cfg *Config) RouteFor(repo string) (r Route, ok bool) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	for _, r := range cfg.Routes {
+		if r.Matches(repo) {
+			return r, true
+		}
+	}
+	return Route{}, false
+}
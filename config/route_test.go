@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestRouteTarget(t *testing.T) {
+	is := is.New(t)
+	r := Route{Repos: []string{"legacy/*", "old-*"}, Upstream: "ssh://legacy.example.com/srv/git"}
+	is.True(r.Matches("legacy/app"))
+	is.True(r.Matches("old-app"))
+	is.True(!r.Matches("legacy/app/sub"))
+	is.True(!r.Matches("app"))
+
+	addr, user, path, err := r.Target("legacy/app")
+	is.NoErr(err)
+	is.Equal(addr, "legacy.example.com:22")
+	is.Equal(user, "git")
+	is.Equal(path, "/srv/git/legacy/app")
+
+	r.Upstream = "ssh://deploy@legacy.example.com:2222"
+	addr, user, path, err = r.Target("old-app")
+	is.NoErr(err)
+	is.Equal(addr, "legacy.example.com:2222")
+	is.Equal(user, "deploy")
+	is.Equal(path, "old-app")
+
+	r.Upstream = "https://legacy.example.com"
+	_, _, _, err = r.Target("old-app")
+	is.True(err != nil)
+	_, err = r.PublicHostKey()
+	is.True(err != nil) // no host key
+}
@@ -0,0 +1,208 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnknownSearch is returned for saved searches the user doesn't have.
+var ErrUnknownSearch = errors.New("unknown search")
+
+// Search is a saved search of a user.
+type Search struct {
+	Name string `yaml:"name" json:"name"`
+	// Query filters the listed repos, see ParseQuery.
+	Query string `yaml:"query" json:"query"`
+	// Sort is the order of the listed repos, name or updated for the most
+	// recently updated first. Repos keep the order of the repo list when
+	// it's empty.
+	Sort string `yaml:"sort,omitempty" json:"sort,omitempty"`
+	// Visibility is public or private to only list repos with that
+	// visibility, or empty to list both.
+	Visibility string `yaml:"visibility,omitempty" json:"visibility,omitempty"`
+}
+
+// Validate returns an error if the search can't be used.
+func (s Search) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return errors.New("search name is empty")
+	}
+	switch s.Sort {
+	case "", "name", "updated":
+	default:
+		return fmt.Errorf("invalid sort %q, must be name or updated", s.Sort)
+	}
+	switch s.Visibility {
+	case "", "public", "private":
+	default:
+		return fmt.Errorf("invalid visibility %q, must be public or private", s.Visibility)
+	}
+	_, err := ParseQuery(s.Query)
+	return err
+}
+
+// Query is a parsed search query.
+type Query struct {
+	// Terms must all appear in the name, project name, or description of
+	// a repo, ignoring case.
+	Terms []string
+	// Files must all exist at the root of the default branch of a repo.
+	Files []string
+	// Updated, when not zero, is how recently a repo must have been
+	// updated.
+	Updated time.Duration
+}
+
+// ParseQuery parses a search query. Queries are made of words matching
+// repos by name or description, and qualifiers:
+//
+//	file:go.mod   repos with a go.mod file at their root
+//	updated:30d   repos updated in the last 30 days, also in h or w
+func ParseQuery(q string) (Query, error) {
+	var query Query
+	for _, f := range strings.Fields(q) {
+		i := strings.Index(f, ":")
+		if i < 0 {
+			query.Terms = append(query.Terms, strings.ToLower(f))
+			continue
+		}
+		k, v := f[:i], f[i+1:]
+		switch k {
+		case "file":
+			if v == "" {
+				return Query{}, errors.New("file: needs a file name")
+			}
+			query.Files = append(query.Files, v)
+		case "updated":
+			d, err := parseAge(v)
+			if err != nil {
+				return Query{}, err
+			}
+			query.Updated = d
+		default:
+			return Query{}, fmt.Errorf("unknown qualifier %q, must be file or updated", k)
+		}
+	}
+	return query, nil
+}
+
+// parseAge parses a number of hours, days, or weeks, e.g. 30d.
+func parseAge(s string) (time.Duration, error) {
+	units := map[byte]time.Duration{
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if s != "" {
+		if unit, ok := units[s[len(s)-1]]; ok {
+			if n, err := strconv.Atoi(s[:len(s)-1]); err == nil && n > 0 {
+				return time.Duration(n) * unit, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid age %q, e.g. 12h, 30d, or 2w", s)
+}
+
+// Searches returns the saved searches of the user with the given public key.
+func (cfg *Config) Searches(pk ssh.PublicKey) []Search {
+	if u := cfg.findUser(pk); u != nil {
+		return u.Searches
+	}
+	return nil
+}
+
+// SaveSearch saves a search of the user with the given public key, replacing
+// their search with the same name if any. The change is committed to the
+// config repo.
+func (cfg *Config) SaveSearch(pk ssh.PublicKey, s Search) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	u := cfg.findUser(pk)
+	if u == nil {
+		return ErrUnknownUser
+	}
+	return cfg.editConfig(fmt.Sprintf("Save search %s of %s", s.Name, u.Name), func(doc *yaml.Node) {
+		searches := mappingValue(userNode(doc, u.Name), "searches", yaml.SequenceNode)
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		mappingValue(n, "name", yaml.ScalarNode).Value = s.Name
+		mappingValue(n, "query", yaml.ScalarNode).Value = s.Query
+		if s.Sort != "" {
+			mappingValue(n, "sort", yaml.ScalarNode).Value = s.Sort
+		}
+		if s.Visibility != "" {
+			mappingValue(n, "visibility", yaml.ScalarNode).Value = s.Visibility
+		}
+		for i, c := range searches.Content {
+			if v := mappingValue(c, "name", 0); v != nil && v.Value == s.Name {
+				searches.Content[i] = n
+				return
+			}
+		}
+		searches.Content = append(searches.Content, n)
+	})
+}
+
+// DeleteSearch deletes a saved search of the user with the given public key.
+// The change is committed to the config repo.
+func (cfg *Config) DeleteSearch(pk ssh.PublicKey, name string) error {
+	u := cfg.findUser(pk)
+	if u == nil {
+		return ErrUnknownUser
+	}
+	found := false
+	for _, s := range u.Searches {
+		if s.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrUnknownSearch
+	}
+	return cfg.editConfig(fmt.Sprintf("Delete search %s of %s", name, u.Name), func(doc *yaml.Node) {
+		searches := mappingValue(userNode(doc, u.Name), "searches", yaml.SequenceNode)
+		items := searches.Content[:0]
+		for _, c := range searches.Content {
+			if v := mappingValue(c, "name", 0); v != nil && v.Value == name {
+				continue
+			}
+			items = append(items, c)
+		}
+		searches.Content = items
+	})
+}
+
+// knownUser returns whether the configuration has a user with the name.
+func (cfg *Config) knownUser(name string) bool {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	for _, u := range cfg.Users {
+		if u.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// userNode returns the YAML mapping of the named user in the config file.
+// The user is added if missing, which doesn't happen for users known to the
+// loaded configuration.
+func userNode(doc *yaml.Node, name string) *yaml.Node {
+	users := mappingValue(doc.Content[0], "users", yaml.SequenceNode)
+	for _, n := range users.Content {
+		if v := mappingValue(n, "name", 0); v != nil && v.Value == name {
+			return n
+		}
+	}
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	mappingValue(n, "name", yaml.ScalarNode).Value = name
+	users.Content = append(users.Content, n)
+	return n
+}
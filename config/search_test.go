@@ -0,0 +1,54 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestParseQuery(t *testing.T) {
+	is := is.New(t)
+	q, err := ParseQuery("CLI file:go.mod updated:30d tools")
+	is.NoErr(err)
+	is.Equal(q.Terms, []string{"cli", "tools"})
+	is.Equal(q.Files, []string{"go.mod"})
+	is.Equal(q.Updated, 30*24*time.Hour)
+
+	for _, bad := range []string{"updated:soon", "updated:0d", "file:", "lang:go"} {
+		_, err := ParseQuery(bad)
+		is.True(err != nil) // invalid query
+	}
+}
+
+func TestSaveSearch(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{key},
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+
+	gorepos := Search{Name: "Go repos", Query: "file:go.mod updated:4w", Sort: "updated"}
+	is.Equal(cfg.SaveSearch(nil, gorepos), ErrUnknownUser)
+	is.True(cfg.SaveSearch(pk, Search{Name: "bad", Sort: "size"}) != nil)
+	is.NoErr(cfg.SaveSearch(pk, gorepos))
+	is.NoErr(cfg.SaveSearch(pk, Search{Name: "Public", Visibility: "public"}))
+	is.Equal(cfg.Searches(pk), []Search{gorepos, {Name: "Public", Visibility: "public"}})
+
+	// Saving a search with the same name replaces it.
+	gorepos.Sort = ""
+	is.NoErr(cfg.SaveSearch(pk, gorepos))
+	is.Equal(cfg.Searches(pk)[0], gorepos)
+	is.Equal(len(cfg.Searches(pk)), 2)
+
+	is.NoErr(cfg.DeleteSearch(pk, "Public"))
+	is.Equal(cfg.Searches(pk), []Search{gorepos})
+	is.Equal(cfg.DeleteSearch(pk, "Public"), ErrUnknownSearch)
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// ErrUnknownSession is returned for sessions that aren't connected.
+var ErrUnknownSession = errors.New("unknown session")
+
+// Session is a connected SSH session, as listed to admins.
+type Session struct {
+	// ID identifies the session, as in the server logs.
+	ID string `json:"id"`
+	// User is the name of the user, the fingerprint of their key, or
+	// "anonymous".
+	User string `json:"user"`
+	// Key is the SHA256 fingerprint of the public key of the user, if any.
+	Key        string `json:"key,omitempty"`
+	RemoteAddr string `json:"remote-addr"`
+	// Operation is what the session runs: "tui", a git command such as
+	// git-upload-pack, or a command of the SSH CLI.
+	Operation string `json:"operation"`
+	// Repo is the repository of the operation, if any.
+	Repo  string    `json:"repo,omitempty"`
+	Start time.Time `json:"start"`
+	// In and Out are the bytes received from and sent to the client.
+	In  int64 `json:"in"`
+	Out int64 `json:"out"`
+}
+
+// Duration returns how long the session has been connected at now.
+func (s Session) Duration(now time.Time) time.Duration {
+	return now.Sub(s.Start)
+}
+
+// liveSession is a tracked session, with its byte counters and the functions
+// messaging and terminating it.
+type liveSession struct {
+	Session
+	in, out   *int64
+	notify    func(msg string) error
+	terminate func() error
+}
+
+// TrackSession starts listing a connected session to admins. in and out
+// count the bytes received from and sent to the client, and are read with
+// sync/atomic. notify shows a message to the user of the session, and
+// terminate disconnects it. The returned function stops tracking the
+// session, once it ends.
+func (cfg *Config) TrackSession(s Session, pk ssh.PublicKey, in, out *int64, notify func(msg string) error, terminate func() error) func() {
+	s.User = "anonymous"
+	if pk != nil {
+		s.User = cfg.userName(pk)
+		s.Key = gossh.FingerprintSHA256(pk)
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if cfg.sessions == nil {
+		cfg.sessions = make(map[string]*liveSession)
+	}
+	cfg.sessions[s.ID] = &liveSession{
+		Session:   s,
+		in:        in,
+		out:       out,
+		notify:    notify,
+		terminate: terminate,
+	}
+	return func() {
+		cfg.mtx.Lock()
+		defer cfg.mtx.Unlock()
+		delete(cfg.sessions, s.ID)
+	}
+}
+
+// SetSessionNotifier replaces the function showing messages to the user of
+// a session, such as for sessions running the TUI, which shows them in a
+// banner.
+func (cfg *Config) SetSessionNotifier(id string, notify func(msg string) error) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if ls, ok := cfg.sessions[id]; ok {
+		ls.notify = notify
+	}
+}
+
+// Sessions returns the connected sessions, oldest first.
+func (cfg *Config) Sessions() []Session {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	ss := make([]Session, 0, len(cfg.sessions))
+	for _, ls := range cfg.sessions {
+		s := ls.Session
+		s.In = atomic.LoadInt64(ls.in)
+		s.Out = atomic.LoadInt64(ls.out)
+		ss = append(ss, s)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		if ss[i].Start.Equal(ss[j].Start) {
+			return ss[i].ID < ss[j].ID
+		}
+		return ss[i].Start.Before(ss[j].Start)
+	})
+	return ss
+}
+
+// MessageSession shows a message from the admin with the given public key
+// to the user of a session.
+func (cfg *Config) MessageSession(id string, pk ssh.PublicKey, msg string) error {
+	cfg.mtx.Lock()
+	ls, ok := cfg.sessions[id]
+	var notify func(string) error
+	if ok {
+		notify = ls.notify
+	}
+	cfg.mtx.Unlock()
+	if !ok {
+		return ErrUnknownSession
+	}
+	from := cfg.userName(pk)
+	if from == "" {
+		from = "admin"
+	}
+	return notify("Message from " + from + ": " + msg)
+}
+
+// TerminateSession disconnects a session.
+func (cfg *Config) TerminateSession(id string) error {
+	cfg.mtx.Lock()
+	ls, ok := cfg.sessions[id]
+	cfg.mtx.Unlock()
+	if !ok {
+		return ErrUnknownSession
+	}
+	return ls.terminate()
+}
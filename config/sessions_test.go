@@ -0,0 +1,55 @@
+package config
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestSessions(t *testing.T) {
+	is := is.New(t)
+	cfg := &Config{}
+	var in, out int64
+	var got string
+	terminated := false
+	now := time.Now()
+	untrack := cfg.TrackSession(Session{ID: "b", Operation: "tui", Start: now}, nil, &in, &out,
+		func(msg string) error {
+			got = msg
+			return nil
+		},
+		func() error {
+			terminated = true
+			return nil
+		})
+	cfg.TrackSession(Session{ID: "a", Operation: "git-upload-pack", Repo: "repo", Start: now.Add(-time.Minute)},
+		nil, new(int64), new(int64), nil, nil)
+	atomic.AddInt64(&in, 10)
+	atomic.AddInt64(&out, 20)
+
+	ss := cfg.Sessions()
+	is.Equal(len(ss), 2)
+	is.Equal(ss[0].ID, "a") // oldest first
+	is.Equal(ss[1].User, "anonymous")
+	is.Equal(ss[1].In, int64(10))
+	is.Equal(ss[1].Out, int64(20))
+
+	is.NoErr(cfg.MessageSession("b", nil, "hello"))
+	is.Equal(got, "Message from admin: hello")
+	cfg.SetSessionNotifier("b", func(msg string) error {
+		got = "tui " + msg
+		return nil
+	})
+	is.NoErr(cfg.MessageSession("b", nil, "hello"))
+	is.Equal(got, "tui Message from admin: hello")
+	is.NoErr(cfg.TerminateSession("b"))
+	is.True(terminated)
+
+	untrack()
+	is.Equal(len(cfg.Sessions()), 1)
+	is.True(errors.Is(cfg.MessageSession("b", nil, "hello"), ErrUnknownSession))
+	is.True(errors.Is(cfg.TerminateSession("b"), ErrUnknownSession))
+}
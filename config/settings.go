@@ -0,0 +1,70 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoSettings are settings of a repo in the config repo. Nil fields are
+// left unchanged.
+type RepoSettings struct {
+	Description *string `json:"description,omitempty"`
+	Private     *bool   `json:"private,omitempty"`
+	// Features turns features of the repo on and off.
+	Features map[Feature]bool `json:"features,omitempty"`
+}
+
+// SetRepoSettings changes the settings of a repo. The change is committed to
+// the config repo.
+func (cfg *Config) SetRepoSettings(repo string, s RepoSettings) error {
+	if s.Description == nil && s.Private == nil && len(s.Features) == 0 {
+		return nil
+	}
+	for f := range s.Features {
+		if _, err := ParseFeature(string(f)); err != nil {
+			return err
+		}
+	}
+	return cfg.editConfig(fmt.Sprintf("Update settings of %s", repo), func(doc *yaml.Node) {
+		rc := repoNode(doc, repo, true)
+		if s.Description != nil {
+			setScalar(mappingValue(rc, "note", yaml.ScalarNode), "!!str", *s.Description)
+		}
+		if s.Private != nil {
+			setScalar(mappingValue(rc, "private", yaml.ScalarNode), "!!bool", strconv.FormatBool(*s.Private))
+		}
+		if len(s.Features) > 0 {
+			setFeatures(rc, s.Features)
+		}
+	})
+}
+
+// repoNode returns the YAML mapping of a repo in the config file, or nil if
+// it's missing. With add, a missing repo is added.
+func repoNode(doc *yaml.Node, repo string, add bool) *yaml.Node {
+	repos := mappingValue(doc.Content[0], "repos", yaml.SequenceNode)
+	for _, n := range repos.Content {
+		if v := mappingValue(n, "repo", 0); v != nil && v.Value == repo {
+			return n
+		}
+	}
+	if !add {
+		return nil
+	}
+	rc := &yaml.Node{Kind: yaml.MappingNode}
+	mappingValue(rc, "name", yaml.ScalarNode).Value = repo
+	mappingValue(rc, "repo", yaml.ScalarNode).Value = repo
+	repos.Content = append(repos.Content, rc)
+	return rc
+}
+
+// setScalar sets the value and tag of a YAML scalar, so that e.g. a note of
+// "true" stays a string.
+func setScalar(n *yaml.Node, tag, value string) {
+	n.Kind = yaml.ScalarNode
+	n.Tag = tag
+	n.Value = value
+	n.Style = 0
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	ggit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var errNoSigningKey = errors.New("no private key found")
+
+// commitOptions returns the options for commits made by the server. They are
+// authored and committed by the configured server identity, and signed when
+// a signing key is configured.
+func (cfg *Config) commitOptions() (*ggit.CommitOptions, error) {
+	sig := &object.Signature{
+		Name:  cfg.Cfg.CommitterName,
+		Email: cfg.Cfg.CommitterEmail,
+		When:  time.Now(),
+	}
+	if sig.Name == "" {
+		sig.Name = "Soft Serve Server"
+	}
+	if sig.Email == "" {
+		sig.Email = "vt100@charm.sh"
+	}
+	opts := &ggit.CommitOptions{
+		All:       true,
+		Author:    sig,
+		Committer: sig,
+	}
+	if cfg.Cfg.SigningKeyPath != "" {
+		k, err := readSigningKey(cfg.Cfg.SigningKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("error reading signing key: %w", err)
+		}
+		opts.SignKey = k
+	}
+	return opts, nil
+}
+
+// readSigningKey reads the first private key of the armored key ring at path.
+func readSigningKey(path string) (*openpgp.Entity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	el, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range el {
+		if e.PrivateKey == nil {
+			continue
+		}
+		if e.PrivateKey.Encrypted {
+			return nil, fmt.Errorf("key %s is encrypted", e.PrimaryKey.KeyIdString())
+		}
+		return e, nil
+	}
+	return nil, errNoSigningKey
+}
@@ -0,0 +1,44 @@
+package config
+
+// Source stores the repositories served by the server. RepoSource, which
+// stores them in a directory, is the default. Repos are loaded from git
+// repositories on disk, so sources are filesystem sources: other sources wrap
+// a RepoSource, e.g. to hook into repos being loaded or removed, and are
+// used by passing them to NewConfigWithSource.
+type Source interface {
+	// Dir returns the directory repos are served from over git. The repo
+	// named NAME is at Dir()/NAME.
+	Dir() string
+	// AllRepos returns all loaded repos, sorted by name.
+	AllRepos() []*Repo
+	// GetRepo returns a loaded repo by name, or ErrMissingRepo.
+	GetRepo(name string) (*Repo, error)
+	// InitRepo creates and loads a new repo.
+	InitRepo(name string, bare bool) (*Repo, error)
+	// LoadRepo loads, or reloads, a repo by name.
+	LoadRepo(name string) error
+	// LoadRepos loads all repos.
+	LoadRepos() error
+	// UnloadRepo stops serving a repo without deleting it.
+	UnloadRepo(name string)
+	// RemoveRepo unloads and deletes a repo.
+	RemoveRepo(name string) error
+	// Orphans returns the repos that are stored but not loaded, and the ones
+	// that are loaded but missing from the store.
+	Orphans() (unloaded []string, missing []string, err error)
+	// LockPush locks a repo for a push and returns the function that
+	// unlocks it.
+	LockPush(name string) (unlock func())
+	// LockMaintenance locks a repo exclusively for maintenance and returns
+	// the function that unlocks it.
+	LockMaintenance(name string) (unlock func())
+	// GC garbage collects a repo.
+	GC(name string) error
+}
+
+var _ Source = (*RepoSource)(nil)
+
+// Dir implements Source.
+func (rs *RepoSource) Dir() string {
+	return rs.Path
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+var errKeep = errors.New("repos are kept")
+
+// keepSource is a RepoSource whose repos can't be removed.
+type keepSource struct {
+	*RepoSource
+	loaded []string
+}
+
+func (s *keepSource) LoadRepo(name string) error {
+	s.loaded = append(s.loaded, name)
+	return s.RepoSource.LoadRepo(name)
+}
+
+func (s *keepSource) RemoveRepo(string) error {
+	return errKeep
+}
+
+func TestWrappedSource(t *testing.T) {
+	is := is.New(t)
+	rs := &keepSource{RepoSource: NewRepoSource(t.TempDir())}
+	cfg, err := NewConfigWithSource(&config.Config{
+		KeyPath: t.TempDir(),
+	}, rs)
+	is.NoErr(err)
+	// The config is served from the wrapped source.
+	_, err = rs.RepoSource.GetRepo("config")
+	is.NoErr(err)
+	is.True(len(rs.loaded) > 0)
+
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	is.True(errors.Is(cfg.Source.RemoveRepo("repo"), errKeep))
+	_, err = cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+}
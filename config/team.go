@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/log"
+	gm "github.com/charmbracelet/wish/git"
+)
+
+// Team is a named group of users, that repos grant access to as a whole.
+type Team struct {
+	Name string `yaml:"name" json:"name"`
+	// Users are the names of the members of the team.
+	Users []string `yaml:"users" json:"users"`
+	// Teams are the names of the teams whose members are members of this
+	// team too, e.g. a "backend" team of an "engineering" team.
+	Teams []string `yaml:"teams" json:"teams"`
+}
+
+// findTeam returns the team with the given name, or nil. The caller must
+// hold the lock, or own cfg.
+func (cfg *Config) findTeam(name string) *Team {
+	for i, t := range cfg.Teams {
+		if t.Name == name {
+			return &cfg.Teams[i]
+		}
+	}
+	return nil
+}
+
+// inTeam returns whether a user is a member of a team, directly or through
+// the teams of the team. Cycles of teams are ignored.
+func (cfg *Config) inTeam(user, team string) bool {
+	seen := make(map[string]bool)
+	var in func(name string) bool
+	in = func(name string) bool {
+		t := cfg.findTeam(name)
+		if t == nil || seen[name] {
+			return false
+		}
+		seen[name] = true
+		for _, u := range t.Users {
+			if u == user {
+				return true
+			}
+		}
+		for _, sub := range t.Teams {
+			if in(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return in(team)
+}
+
+// teamAccess returns the highest access level the repo grants the teams of
+// a user.
+func (cfg *Config) teamAccess(repo string, user *User) gm.AccessLevel {
+	al := gm.NoAccess
+	r := cfg.findRepo(repo)
+	if r == nil || user == nil || user.Name == "" {
+		return al
+	}
+	for team, access := range r.Teams {
+		if tl := accessLevel(access, gm.NoAccess); tl > al && cfg.inTeam(user.Name, team) {
+			al = tl
+		}
+	}
+	return al
+}
+
+// UserTeams returns the names of the teams a user is a member of, directly
+// or not.
+func (cfg *Config) UserTeams(user string) []string {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	teams := make([]string, 0)
+	for _, t := range cfg.Teams {
+		if cfg.inTeam(user, t.Name) {
+			teams = append(teams, t.Name)
+		}
+	}
+	return teams
+}
+
+// capGrants caps the access levels the config file of a repo grants teams
+// and users to read-write, and anonymous users to read-only, since
+// collaborators can push it.
+func capGrants(repo string, rc *RepoConfig) {
+	for team, access := range rc.Teams {
+		if accessLevel(access, gm.NoAccess) > gm.ReadWriteAccess {
+			log.Warn("capping access of team granted by repo config", "repo", repo, "team", team, "access", access)
+			rc.Teams[team] = "read-write"
+		}
+	}
+	for user, access := range rc.Users {
+		if accessLevel(access, gm.NoAccess) > gm.ReadWriteAccess {
+			log.Warn("capping access of user granted by repo config", "repo", repo, "user", user, "access", access)
+			rc.Users[user] = "read-write"
+		}
+	}
+	if accessLevel(rc.AnonAccess, gm.NoAccess) > gm.ReadOnlyAccess {
+		log.Warn("capping anonymous access granted by repo config", "repo", repo, "access", rc.AnonAccess)
+		rc.AnonAccess = "read-only"
+	}
+}
+
+// validateGrants returns an error if a team is unnamed, or refers to unknown
+// teams or users, or a repo grants an invalid access level to a team, user,
+// or anonymous users.
+func (cfg *Config) validateGrants() error {
+	users := make(map[string]bool, len(cfg.Users))
+	for _, u := range cfg.Users {
+		users[u.Name] = true
+	}
+	for _, t := range cfg.Teams {
+		if t.Name == "" {
+			return fmt.Errorf("team has no name")
+		}
+		for _, u := range t.Users {
+			if !users[u] {
+				return fmt.Errorf("unknown user %q of team %q", u, t.Name)
+			}
+		}
+		for _, sub := range t.Teams {
+			if cfg.findTeam(sub) == nil {
+				return fmt.Errorf("unknown team %q of team %q", sub, t.Name)
+			}
+		}
+	}
+	for _, r := range cfg.Repos {
+		if r.AnonAccess != "" && accessLevel(r.AnonAccess, -1) == -1 {
+			return fmt.Errorf("invalid anon-access %q of repo %q", r.AnonAccess, r.Repo)
+		}
+		for team, access := range r.Teams {
+			if cfg.findTeam(team) == nil {
+				return fmt.Errorf("unknown team %q of repo %q", team, r.Repo)
+			}
+			if accessLevel(access, -1) == -1 {
+				return fmt.Errorf("invalid access %q of team %q to repo %q", access, team, r.Repo)
+			}
+		}
+		for user, access := range r.Users {
+			if !users[user] {
+				return fmt.Errorf("unknown user %q of repo %q", user, r.Repo)
+			}
+			if accessLevel(access, -1) == -1 {
+				return fmt.Errorf("invalid access %q of user %q to repo %q", access, user, r.Repo)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestTeams(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	pk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(key))
+	cfg := &Config{
+		AnonAccess: "no-access",
+		Users: []User{
+			{Name: "frankie", PublicKeys: []string{key}},
+			{Name: "bea"},
+		},
+		Teams: []Team{
+			{Name: "engineering", Teams: []string{"backend"}},
+			{Name: "backend", Users: []string{"frankie"}, Teams: []string{"ops"}},
+			{Name: "ops", Users: []string{"bea"}, Teams: []string{"engineering"}}, // a cycle
+			{Name: "design"},
+		},
+		Repos: []RepoConfig{
+			{Repo: "api", Private: true, Teams: map[string]string{"backend": "read-write"}},
+			{Repo: "infra", Private: true, Teams: map[string]string{"engineering": "admin-access", "ops": "read-only"}},
+			{Repo: "site", Private: true, Teams: map[string]string{"design": "admin-access"}},
+			{Repo: "docs", Teams: map[string]string{"backend": "no-access"}},
+		},
+	}
+	is.NoErr(cfg.validateGrants())
+	is.Equal(cfg.AuthRepo("api", pk), git.ReadWriteAccess)
+	is.Equal(cfg.AuthRepo("infra", pk), git.AdminAccess) // through engineering
+	is.Equal(cfg.AuthRepo("site", pk), git.NoAccess)
+	is.Equal(cfg.AuthRepo("docs", pk), git.ReadOnlyAccess) // teams don't lower access
+	is.Equal(cfg.UserTeams("frankie"), []string{"engineering", "backend", "ops"})
+	is.Equal(cfg.UserTeams("bea"), []string{"engineering", "backend", "ops"})
+
+	rc := RepoConfig{
+		Teams:      map[string]string{"engineering": "admin-access", "ops": "read-only"},
+		Users:      map[string]string{"bea": "admin-access"},
+		AnonAccess: "read-write",
+	}
+	capGrants("infra", &rc)
+	is.Equal(rc.Teams, map[string]string{"engineering": "read-write", "ops": "read-only"})
+	is.Equal(rc.Users, map[string]string{"bea": "read-write"})
+	is.Equal(rc.AnonAccess, "read-only")
+
+	cfg.Teams[3].Users = []string{"nobody"}
+	is.True(cfg.validateGrants() != nil)
+	cfg.Teams[3].Users = nil
+	cfg.Repos[0].Teams["backend"] = "owner"
+	is.True(cfg.validateGrants() != nil)
+	cfg.Repos[0].Teams = map[string]string{"frontend": "read-only"}
+	is.True(cfg.validateGrants() != nil)
+}
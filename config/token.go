@@ -0,0 +1,138 @@
+package config
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+// TokenPrefix starts personal access tokens, so that they can be told apart
+// from HTTP passwords and found by secret scanners.
+const TokenPrefix = "softpat_"
+
+var (
+	// ErrUnknownToken is returned for tokens the user doesn't have.
+	ErrUnknownToken = errors.New("unknown token")
+	// ErrTokenExists is returned when creating a token with the name of
+	// another token of the user.
+	ErrTokenExists = errors.New("token already exists")
+)
+
+// Token is a personal access token of a user. Tokens authenticate HTTP
+// clones and pushes, and admin API calls, with the access of the user, up to
+// the scope of the token.
+type Token struct {
+	Name string `yaml:"name" json:"name"`
+	// Digest is the hex-encoded SHA-256 digest of the token. Tokens
+	// themselves aren't stored.
+	Digest string `yaml:"digest" json:"-"`
+	// Scope is the highest access level the token grants: read-only,
+	// read-write, or admin-access.
+	Scope   string    `yaml:"scope" json:"scope"`
+	Created time.Time `yaml:"created" json:"created"`
+	// Expires is when the token stops authenticating, never if zero.
+	Expires time.Time `yaml:"expires,omitempty" json:"expires,omitempty"`
+}
+
+// Expired returns whether the token expired at now.
+func (t Token) Expired(now time.Time) bool {
+	return !t.Expires.IsZero() && !now.Before(t.Expires)
+}
+
+// ValidTokenScope returns an error if scope isn't an access level tokens can
+// be scoped to.
+func ValidTokenScope(scope string) error {
+	switch scope {
+	case "read-only", "read-write", "admin-access":
+		return nil
+	}
+	return fmt.Errorf("invalid scope %q, must be read-only, read-write, or admin-access", scope)
+}
+
+// Tokens returns the personal access tokens of the user with the given
+// public key.
+func (cfg *Config) Tokens(pk ssh.PublicKey) []Token {
+	if u := cfg.findUser(pk); u != nil {
+		return u.Tokens
+	}
+	return nil
+}
+
+// CreateToken creates a personal access token of the user with the given
+// public key, and returns it. Only its digest is committed to the config
+// repo, so the token can't be shown again.
+func (cfg *Config) CreateToken(pk ssh.PublicKey, name, scope string, expires time.Time) (string, error) {
+	if err := ValidTokenScope(scope); err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("token name can't be empty")
+	}
+	u := cfg.findUser(pk)
+	if u == nil {
+		return "", ErrUnknownUser
+	}
+	for _, t := range u.Tokens {
+		if t.Name == name {
+			return "", ErrTokenExists
+		}
+	}
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := TokenPrefix + hex.EncodeToString(b)
+	sum := sha256.Sum256([]byte(token))
+	err := cfg.editConfig(fmt.Sprintf("Create token %s of %s", name, u.Name), func(doc *yaml.Node) {
+		tokens := mappingValue(userNode(doc, u.Name), "tokens", yaml.SequenceNode)
+		n := &yaml.Node{Kind: yaml.MappingNode}
+		mappingValue(n, "name", yaml.ScalarNode).Value = name
+		mappingValue(n, "digest", yaml.ScalarNode).Value = hex.EncodeToString(sum[:])
+		mappingValue(n, "scope", yaml.ScalarNode).Value = scope
+		mappingValue(n, "created", yaml.ScalarNode).Value = time.Now().UTC().Format(time.RFC3339)
+		if !expires.IsZero() {
+			mappingValue(n, "expires", yaml.ScalarNode).Value = expires.UTC().Format(time.RFC3339)
+		}
+		tokens.Content = append(tokens.Content, n)
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken deletes a personal access token of the user with the given
+// public key. The change is committed to the config repo.
+func (cfg *Config) RevokeToken(pk ssh.PublicKey, name string) error {
+	u := cfg.findUser(pk)
+	if u == nil {
+		return ErrUnknownUser
+	}
+	found := false
+	for _, t := range u.Tokens {
+		if t.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ErrUnknownToken
+	}
+	return cfg.editConfig(fmt.Sprintf("Revoke token %s of %s", name, u.Name), func(doc *yaml.Node) {
+		tokens := mappingValue(userNode(doc, u.Name), "tokens", yaml.SequenceNode)
+		items := tokens.Content[:0]
+		for _, c := range tokens.Content {
+			if v := mappingValue(c, "name", 0); v != nil && v.Value == name {
+				continue
+			}
+			items = append(items, c)
+		}
+		tokens.Content = items
+	})
+}
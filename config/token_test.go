@@ -0,0 +1,60 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestTokens(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{key},
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+
+	_, err = cfg.CreateToken(nil, "ci", "read-only", time.Time{})
+	is.Equal(err, ErrUnknownUser)
+	_, err = cfg.CreateToken(pk, "ci", "owner", time.Time{})
+	is.True(err != nil) // invalid scope
+
+	ro, err := cfg.CreateToken(pk, "ci", "read-only", time.Time{})
+	is.NoErr(err)
+	is.True(strings.HasPrefix(ro, TokenPrefix))
+	_, err = cfg.CreateToken(pk, "ci", "read-write", time.Time{})
+	is.Equal(err, ErrTokenExists)
+	old, err := cfg.CreateToken(pk, "old", "admin-access", time.Now().Add(-time.Hour))
+	is.NoErr(err)
+
+	tokens := cfg.Tokens(pk)
+	is.Equal(len(tokens), 2)
+	is.Equal(tokens[0].Name, "ci")
+	is.Equal(tokens[0].Scope, "read-only")
+	is.True(!strings.Contains(tokens[0].Digest, ro[len(TokenPrefix):])) // only the digest is stored
+	is.True(tokens[1].Expired(time.Now()))
+
+	got, scope, ok := cfg.TokenAuth("", ro)
+	is.True(ok)
+	is.Equal(scope, gm.ReadOnlyAccess)
+	is.Equal(got.Marshal(), pk.Marshal())
+	_, _, ok = cfg.TokenAuth("", old)
+	is.True(!ok) // expired
+	_, _, ok = cfg.TokenAuth("", TokenPrefix+"bogus")
+	is.True(!ok)
+
+	is.NoErr(cfg.RevokeToken(pk, "ci"))
+	is.Equal(cfg.RevokeToken(pk, "ci"), ErrUnknownToken)
+	_, _, ok = cfg.TokenAuth("", ro)
+	is.True(!ok) // revoked
+	is.Equal(len(cfg.Tokens(pk)), 1)
+}
@@ -0,0 +1,339 @@
+package config
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // nolint: gosec
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gliderlabs/ssh"
+)
+
+const (
+	// totpFile is the file of the data path holding the TOTP enrollments of
+	// users.
+	totpFile = "totp.json"
+	// totpStep is the time step of TOTP codes.
+	totpStep = 30
+	// RecoveryCodes is the number of recovery codes generated at once.
+	RecoveryCodes = 10
+)
+
+var (
+	// ErrTOTPNotEnrolled is returned when confirming, or generating the
+	// recovery codes of, a user who didn't enroll.
+	ErrTOTPNotEnrolled = errors.New("two-factor authentication isn't enrolled")
+	// ErrTOTPEnrolled is returned when enrolling a user who already has
+	// two-factor authentication enabled.
+	ErrTOTPEnrolled = errors.New("two-factor authentication is already enabled")
+	// ErrTOTPRequired is returned when a user without two-factor
+	// authentication runs a dangerous command on a server requiring it.
+	ErrTOTPRequired = errors.New("two-factor authentication is required")
+	// ErrCodeRequired is returned when a user with two-factor authentication
+	// runs a dangerous command without a one-time code.
+	ErrCodeRequired = errors.New("one-time code required")
+	// ErrInvalidCode is returned for wrong, expired, or reused one-time
+	// codes.
+	ErrInvalidCode = errors.New("invalid one-time code")
+)
+
+// totpEnrollment is the TOTP enrollment of a user.
+type totpEnrollment struct {
+	// Secret is the base32-encoded key of the codes.
+	Secret string `json:"secret"`
+	// Enabled is set once the user confirmed a code, until then the
+	// enrollment is pending.
+	Enabled bool `json:"enabled"`
+	// Recovery are the hex-encoded SHA-256 digests of the unused recovery
+	// codes.
+	Recovery []string `json:"recovery,omitempty"`
+	// Counter is the time step of the last code used, so that codes can't
+	// be used twice.
+	Counter int64 `json:"counter,omitempty"`
+}
+
+// TOTPStatus is the two-factor authentication status of a user.
+type TOTPStatus struct {
+	// Enabled is whether dangerous commands need a one-time code.
+	Enabled bool `json:"enabled"`
+	// Pending is whether the user enrolled but didn't confirm a code yet.
+	Pending bool `json:"pending"`
+	// RecoveryCodes is the number of unused recovery codes.
+	RecoveryCodes int `json:"recovery-codes"`
+}
+
+// TOTP returns the two-factor authentication status of the user of a key.
+func (cfg *Config) TOTP(pk ssh.PublicKey) (TOTPStatus, error) {
+	user := cfg.memberName(pk)
+	if user == "" {
+		return TOTPStatus{}, ErrUnknownUser
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readTOTP()
+	if err != nil {
+		return TOTPStatus{}, err
+	}
+	e, ok := all[user]
+	return TOTPStatus{
+		Enabled:       ok && e.Enabled,
+		Pending:       ok && !e.Enabled,
+		RecoveryCodes: len(e.Recovery),
+	}, nil
+}
+
+// EnrollTOTP generates a TOTP key for the user of a key, and returns it
+// base32-encoded, along with its otpauth:// URI for authenticator apps.
+// Two-factor authentication is enabled once the user confirms a code of the
+// key. Enabled users can't enroll again until an admin resets them, so that
+// a stolen SSH key is not enough to replace their key.
+func (cfg *Config) EnrollTOTP(pk ssh.PublicKey) (string, string, error) {
+	user := cfg.memberName(pk)
+	if user == "" {
+		return "", "", ErrUnknownUser
+	}
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readTOTP()
+	if err != nil {
+		return "", "", err
+	}
+	if all[user].Enabled {
+		return "", "", ErrTOTPEnrolled
+	}
+	all[user] = totpEnrollment{Secret: secret}
+	if err := cfg.writeTOTP(all); err != nil {
+		return "", "", err
+	}
+	issuer := cfg.Name
+	if issuer == "" {
+		issuer = "Soft Serve"
+	}
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", issuer)
+	uri := fmt.Sprintf("otpauth://totp/%s:%s?%s", url.PathEscape(issuer), url.PathEscape(user), v.Encode())
+	return secret, uri, nil
+}
+
+// ConfirmTOTP enables the pending two-factor authentication of the user of a
+// key, given a code of their new key.
+func (cfg *Config) ConfirmTOTP(pk ssh.PublicKey, code string) error {
+	user := cfg.memberName(pk)
+	if user == "" {
+		return ErrUnknownUser
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readTOTP()
+	if err != nil {
+		return err
+	}
+	e, ok := all[user]
+	if !ok {
+		return ErrTOTPNotEnrolled
+	}
+	if e.Enabled {
+		return ErrTOTPEnrolled
+	}
+	counter, ok := checkTOTP(e, code, time.Now())
+	if !ok {
+		return ErrInvalidCode
+	}
+	e.Enabled = true
+	e.Counter = counter
+	all[user] = e
+	return cfg.writeTOTP(all)
+}
+
+// VerifyTOTP checks the one-time code given by the user of a key to run a
+// dangerous command: a code of their TOTP key, or one of their recovery
+// codes, which can only be used once. Users who didn't enable two-factor
+// authentication don't need a code, unless the server requires it.
+func (cfg *Config) VerifyTOTP(pk ssh.PublicKey, code string) error {
+	user := cfg.memberName(pk)
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	var all map[string]totpEnrollment
+	if user != "" && cfg.Cfg != nil && cfg.Cfg.DataPath != "" {
+		var err error
+		if all, err = cfg.readTOTP(); err != nil {
+			return err
+		}
+	}
+	e, ok := all[user]
+	switch {
+	case !ok || !e.Enabled:
+		if cfg.RequireTOTP {
+			return ErrTOTPRequired
+		}
+		return nil
+	case code == "":
+		return ErrCodeRequired
+	}
+	if counter, ok := checkTOTP(e, code, time.Now()); ok {
+		e.Counter = counter
+	} else if i := recoveryIndex(e, code); i >= 0 {
+		e.Recovery = append(e.Recovery[:i:i], e.Recovery[i+1:]...)
+	} else {
+		return ErrInvalidCode
+	}
+	all[user] = e
+	return cfg.writeTOTP(all)
+}
+
+// ResetTOTP removes the two-factor authentication of the named user, e.g.
+// when they lost their authenticator, so that they can enroll again.
+func (cfg *Config) ResetTOTP(user string) error {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readTOTP()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[user]; !ok {
+		return ErrTOTPNotEnrolled
+	}
+	delete(all, user)
+	return cfg.writeTOTP(all)
+}
+
+// GenerateRecoveryCodes replaces the recovery codes of the named user with
+// new ones, and returns them. Only their digests are kept, so the codes
+// can't be shown again.
+func (cfg *Config) GenerateRecoveryCodes(user string) ([]string, error) {
+	codes := make([]string, RecoveryCodes)
+	digests := make([]string, RecoveryCodes)
+	for i := range codes {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		c := hex.EncodeToString(b)
+		codes[i] = c[:5] + "-" + c[5:]
+		digests[i] = recoveryDigest(codes[i])
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	all, err := cfg.readTOTP()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := all[user]
+	if !ok || !e.Enabled {
+		return nil, ErrTOTPNotEnrolled
+	}
+	e.Recovery = digests
+	all[user] = e
+	if err := cfg.writeTOTP(all); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// checkTOTP returns whether code is a code of the key of an enrollment at t,
+// or the time step before or after it, to allow for clock drift, and its
+// time step. Codes of time steps up to the last one used are rejected.
+func checkTOTP(e totpEnrollment, code string, t time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(e.Secret)
+	if err != nil || len(code) != 6 {
+		return 0, false
+	}
+	now := t.Unix() / totpStep
+	for counter := now - 1; counter <= now+1; counter++ {
+		if counter <= e.Counter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+	return 0, false
+}
+
+// totpCode returns the 6-digit code of key at a time step, per RFC 6238.
+func totpCode(key []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0xf
+	v := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", v%1000000)
+}
+
+// recoveryIndex returns the index of the digest of a recovery code in an
+// enrollment, or -1.
+func recoveryIndex(e totpEnrollment, code string) int {
+	d := recoveryDigest(code)
+	for i, r := range e.Recovery {
+		if subtle.ConstantTimeCompare([]byte(r), []byte(d)) == 1 {
+			return i
+		}
+	}
+	return -1
+}
+
+// recoveryDigest returns the digest of a recovery code, ignoring case and
+// dashes.
+func recoveryDigest(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(code, "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// readTOTP reads the TOTP enrollments of users. The caller must hold the
+// lock.
+func (cfg *Config) readTOTP() (map[string]totpEnrollment, error) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return nil, errors.New("two-factor authentication needs a data path")
+	}
+	all := make(map[string]totpEnrollment)
+	bts, err := os.ReadFile(filepath.Join(cfg.Cfg.DataPath, totpFile))
+	if os.IsNotExist(err) {
+		return all, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &all); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// writeTOTP replaces the TOTP enrollments of users. The caller must hold the
+// lock.
+func (cfg *Config) writeTOTP(all map[string]totpEnrollment) error {
+	bts, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Cfg.DataPath, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(cfg.Cfg.DataPath, totpFile)
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/base32"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestTOTPCode(t *testing.T) {
+	is := is.New(t)
+	// The SHA-1 test vectors of RFC 6238, truncated to 6 digits.
+	key := []byte("12345678901234567890")
+	for ts, code := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		is.Equal(totpCode(key, ts/totpStep), code)
+	}
+}
+
+func TestTOTP(t *testing.T) {
+	is := is.New(t)
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: t.TempDir(),
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+	is.NoErr(cfg.CreateUser("Frankie", []string{key}))
+
+	// Users who didn't enroll don't need codes.
+	is.NoErr(cfg.VerifyTOTP(pk, ""))
+	secret, uri, err := cfg.EnrollTOTP(pk)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(uri, "otpauth://totp/"))
+	is.True(strings.Contains(uri, "secret="+secret))
+	st, err := cfg.TOTP(pk)
+	is.NoErr(err)
+	is.True(st.Pending)
+	is.NoErr(cfg.VerifyTOTP(pk, ""))
+
+	k, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	is.NoErr(err)
+	now := time.Now().Unix() / totpStep
+	is.True(errors.Is(cfg.ConfirmTOTP(pk, "abcdef"), ErrInvalidCode))
+	is.NoErr(cfg.ConfirmTOTP(pk, totpCode(k, now)))
+	st, err = cfg.TOTP(pk)
+	is.NoErr(err)
+	is.True(st.Enabled)
+	_, _, err = cfg.EnrollTOTP(pk)
+	is.True(errors.Is(err, ErrTOTPEnrolled))
+
+	// Codes are needed, and can only be used once.
+	is.True(errors.Is(cfg.VerifyTOTP(pk, ""), ErrCodeRequired))
+	is.True(errors.Is(cfg.VerifyTOTP(pk, totpCode(k, now)), ErrInvalidCode))
+	is.NoErr(cfg.VerifyTOTP(pk, totpCode(k, now+1)))
+	is.True(errors.Is(cfg.VerifyTOTP(pk, totpCode(k, now+1)), ErrInvalidCode))
+
+	// So are recovery codes.
+	codes, err := cfg.GenerateRecoveryCodes("Frankie")
+	is.NoErr(err)
+	is.Equal(len(codes), RecoveryCodes)
+	is.NoErr(cfg.VerifyTOTP(pk, strings.ToUpper(codes[3])))
+	is.True(errors.Is(cfg.VerifyTOTP(pk, codes[3]), ErrInvalidCode))
+	st, err = cfg.TOTP(pk)
+	is.NoErr(err)
+	is.Equal(st.RecoveryCodes, RecoveryCodes-1)
+
+	// Reset users can enroll again, unless the server requires it.
+	is.NoErr(cfg.ResetTOTP("Frankie"))
+	is.True(errors.Is(cfg.ResetTOTP("Frankie"), ErrTOTPNotEnrolled))
+	is.NoErr(cfg.VerifyTOTP(pk, ""))
+	cfg.RequireTOTP = true
+	is.True(errors.Is(cfg.VerifyTOTP(pk, ""), ErrTOTPRequired))
+	is.True(errors.Is(cfg.VerifyTOTP(nil, ""), ErrTOTPRequired))
+}
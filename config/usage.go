@@ -0,0 +1,156 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DiskUsage is the on-disk size of a repository in bytes.
+type DiskUsage struct {
+	// Objects is the size of the Git objects.
+	Objects int64 `json:"objects"`
+	// LFS is the size of the Git LFS objects.
+	LFS int64 `json:"lfs"`
+	// Total is the size of the whole repository.
+	Total int64 `json:"total"`
+	// Time is when the usage was measured.
+	Time time.Time `json:"time"`
+}
+
+// DiskUsage measures the on-disk size of the repository.
+func (r *Repo) DiskUsage() (DiskUsage, error) {
+	var du DiskUsage
+	var err error
+	du.Objects, err = dirSize(filepath.Join(r.path, "objects"))
+	if err != nil {
+		return du, err
+	}
+	du.LFS, err = dirSize(filepath.Join(r.path, "lfs"))
+	if err != nil {
+		return du, err
+	}
+	du.Total, err = dirSize(r.path)
+	if err != nil {
+		return du, err
+	}
+	du.Time = time.Now()
+	return du, nil
+}
+
+// diskUsageFile is the file of the data path disk usage measurements are
+// stored in.
+const diskUsageFile = "disk-usage.json"
+
+// diskUsageRecord is the stored disk usage of a repository.
+type diskUsageRecord struct {
+	Current  DiskUsage  `json:"current"`
+	Previous *DiskUsage `json:"previous,omitempty"`
+}
+
+// MeasureDiskUsage measures the on-disk size of all repositories and stores
+// it in the data path, along with the previous measurement of each so
+// DiskUsage can report the trend. Measuring walks every repository, so it's
+// done periodically by the server rather than on demand.
+func (cfg *Config) MeasureDiskUsage() error {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return nil
+	}
+	cur := make(map[string]DiskUsage)
+	for _, r := range cfg.Source.AllRepos() {
+		du, err := r.DiskUsage()
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.Repo(), err)
+		}
+		cur[r.Repo()] = du
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	old, err := cfg.readDiskUsage()
+	if err != nil {
+		return err
+	}
+	recs := make(map[string]diskUsageRecord, len(cur))
+	for repo, du := range cur {
+		rec := diskUsageRecord{Current: du}
+		if o, ok := old[repo]; ok {
+			rec.Previous = &o.Current
+		}
+		recs[repo] = rec
+	}
+	bts, err := json.Marshal(recs)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Cfg.DataPath, 0o700); err != nil {
+		return err
+	}
+	fp := filepath.Join(cfg.Cfg.DataPath, diskUsageFile)
+	tmp := fp + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fp)
+}
+
+// DiskUsage returns the last stored disk usage measurement of a repository,
+// and the one before it, if any. ok is false when the repository wasn't
+// measured yet.
+func (cfg *Config) DiskUsage(repo string) (cur DiskUsage, prev *DiskUsage, ok bool, err error) {
+	if cfg.Cfg == nil || cfg.Cfg.DataPath == "" {
+		return
+	}
+	cfg.mtx.Lock()
+	recs, err := cfg.readDiskUsage()
+	cfg.mtx.Unlock()
+	if err != nil {
+		return
+	}
+	rec, ok := recs[repo]
+	return rec.Current, rec.Previous, ok, nil
+}
+
+// readDiskUsage reads the stored disk usage measurements. The caller must
+// hold the lock.
+func (cfg *Config) readDiskUsage() (map[string]diskUsageRecord, error) {
+	recs := make(map[string]diskUsageRecord)
+	bts, err := os.ReadFile(filepath.Join(cfg.Cfg.DataPath, diskUsageFile))
+	if os.IsNotExist(err) {
+		return recs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bts, &recs); err != nil {
+		return nil, err
+	}
+	return recs, nil
+}
+
+// CountDownload records a download of a release asset, such as a source
+// archive, of a repository.
+func (cfg *Config) CountDownload(repo, asset string) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if cfg.downloads == nil {
+		cfg.downloads = make(map[string]map[string]int64)
+	}
+	if cfg.downloads[repo] == nil {
+		cfg.downloads[repo] = make(map[string]int64)
+	}
+	cfg.downloads[repo][asset]++
+}
+
+// Downloads returns the download counts of the release assets of a
+// repository since the server started.
+func (cfg *Config) Downloads(repo string) map[string]int64 {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	downloads := make(map[string]int64, len(cfg.downloads[repo]))
+	for a, n := range cfg.downloads[repo] {
+		downloads[a] = n
+	}
+	return downloads
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestMeasureDiskUsage(t *testing.T) {
+	is := is.New(t)
+	sc := &config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: t.TempDir(),
+	}
+	cfg, err := NewConfig(sc)
+	is.NoErr(err)
+	_, _, ok, err := cfg.DiskUsage("config")
+	is.NoErr(err)
+	is.True(!ok) // not measured yet
+
+	is.NoErr(cfg.MeasureDiskUsage())
+	first, prev, ok, err := cfg.DiskUsage("config")
+	is.NoErr(err)
+	is.True(ok)
+	is.True(prev == nil)
+	is.True(first.Total > 0)
+	is.True(first.Objects > 0)
+
+	is.NoErr(os.WriteFile(filepath.Join(sc.RepoPath, "config", "objects", "big"), make([]byte, 4096), 0o600))
+	is.NoErr(cfg.MeasureDiskUsage())
+	// Measurements are stored, and read back by other instances.
+	cfg2, err := NewConfig(sc)
+	is.NoErr(err)
+	cur, prev, ok, err := cfg2.DiskUsage("config")
+	is.NoErr(err)
+	is.True(ok)
+	is.Equal(cur.Total-prev.Total, int64(4096))
+	is.Equal(prev.Total, first.Total)
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gliderlabs/ssh"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// ErrUserExists is returned when creating a user that already exists.
+	ErrUserExists = errors.New("user already exists")
+	// ErrKeyInUse is returned when adding a public key of another user.
+	ErrKeyInUse = errors.New("public key belongs to another user")
+	// ErrInvalidUserName is returned when creating a user with an empty
+	// name or one with spaces.
+	ErrInvalidUserName = errors.New("user names can't be empty or have spaces")
+	// ErrInvalidKey is returned for public keys that don't parse.
+	ErrInvalidKey = errors.New("invalid public key")
+)
+
+// GetUser returns the named user.
+func (cfg *Config) GetUser(name string) (User, bool) {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	for _, u := range cfg.Users {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return User{}, false
+}
+
+// ListUsers returns the users of the configuration.
+func (cfg *Config) ListUsers() []User {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	return append([]User{}, cfg.Users...)
+}
+
+// CreateUser adds a user with the given public keys. The change is committed
+// to the config repo.
+func (cfg *Config) CreateUser(name string, keys []string) error {
+	if strings.TrimSpace(name) == "" || strings.ContainsAny(name, " \t\n") {
+		return ErrInvalidUserName
+	}
+	if cfg.knownUser(name) {
+		return ErrUserExists
+	}
+	for i, k := range keys {
+		k, err := cfg.checkUserKey("", k)
+		if err != nil {
+			return err
+		}
+		keys[i] = k
+	}
+	return cfg.editConfig(fmt.Sprintf("Add user %s", name), func(doc *yaml.Node) {
+		setStrings(mappingValue(userNode(doc, name), "public-keys", yaml.SequenceNode), keys)
+	})
+}
+
+// DeleteUser removes a user, and its collaborations on repos. The change is
+// committed to the config repo.
+func (cfg *Config) DeleteUser(name string) error {
+	if !cfg.knownUser(name) {
+		return ErrUnknownUser
+	}
+	return cfg.editConfig(fmt.Sprintf("Remove user %s", name), func(doc *yaml.Node) {
+		root := doc.Content[0]
+		users := mappingValue(root, "users", yaml.SequenceNode)
+		items := users.Content[:0]
+		for _, n := range users.Content {
+			if v := mappingValue(n, "name", 0); v != nil && v.Value == name {
+				continue
+			}
+			items = append(items, n)
+		}
+		users.Content = items
+		if repos := mappingValue(root, "repos", 0); repos != nil {
+			for _, rc := range repos.Content {
+				if c := mappingValue(rc, "collabs", 0); c != nil {
+					setListItem(c, name, false)
+				}
+				if u := mappingValue(rc, "users", 0); u != nil {
+					deleteKey(u, name)
+				}
+			}
+		}
+		if teams := mappingValue(root, "teams", 0); teams != nil {
+			for _, t := range teams.Content {
+				if u := mappingValue(t, "users", 0); u != nil {
+					setListItem(u, name, false)
+				}
+			}
+		}
+	})
+}
+
+// SetUserKey adds or removes a public key of a user. The change is committed
+// to the config repo.
+func (cfg *Config) SetUserKey(name, key string, present bool) error {
+	if !cfg.knownUser(name) {
+		return ErrUnknownUser
+	}
+	key, err := cfg.checkUserKey(name, key)
+	if err != nil {
+		return err
+	}
+	pk, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(key))
+	msg := fmt.Sprintf("Add a public key of %s", name)
+	if !present {
+		msg = fmt.Sprintf("Remove a public key of %s", name)
+	}
+	return cfg.editConfig(msg, func(doc *yaml.Node) {
+		keys := mappingValue(userNode(doc, name), "public-keys", yaml.SequenceNode)
+		items := keys.Content[:0]
+		for _, n := range keys.Content {
+			// Keys are compared parsed, so that their comments don't
+			// matter.
+			if k, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(n.Value))); err == nil && ssh.KeysEqual(k, pk) {
+				continue
+			}
+			items = append(items, n)
+		}
+		keys.Content = items
+		if present {
+			keys.Content = append(keys.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key})
+		}
+	})
+}
+
+// SetUserRole grants or revokes a role of a user. The change is committed to
+// the config repo.
+func (cfg *Config) SetUserRole(name string, role Role, present bool) error {
+	if err := ValidRole(string(role)); err != nil {
+		return err
+	}
+	if !cfg.knownUser(name) {
+		return ErrUnknownUser
+	}
+	msg := fmt.Sprintf("Grant %s the %s role", name, role)
+	if !present {
+		msg = fmt.Sprintf("Revoke the %s role of %s", role, name)
+	}
+	return cfg.editConfig(msg, func(doc *yaml.Node) {
+		setListItem(mappingValue(userNode(doc, name), "roles", yaml.SequenceNode), string(role), present)
+	})
+}
+
+// checkUserKey parses an authorized key, and returns it trimmed. It returns
+// ErrKeyInUse if it's the key of a user other than name, since keys identify
+// users.
+func (cfg *Config) checkUserKey(name, key string) (string, error) {
+	key = strings.TrimSpace(key)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	if u := cfg.findUser(pk); u != nil && u.Name != name {
+		return "", ErrKeyInUse
+	}
+	return key, nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+func TestUsers(t *testing.T) {
+	is := is.New(t)
+	adminKey := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAINMwLvyV3ouVrTysUYGoJdl5Vgn5BACKov+n9PlzfPwH a@b"
+	key := "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIFxIobhwtfdwN7m1TFt9wx3PsfvcAkISGPxmbmbauST8 a@b"
+	cfg, err := NewConfig(&config.Config{
+		RepoPath:         t.TempDir(),
+		KeyPath:          t.TempDir(),
+		InitialAdminKeys: []string{adminKey},
+	})
+	is.NoErr(err)
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(key))
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+
+	is.Equal(cfg.CreateUser("Frankie", []string{adminKey}), ErrKeyInUse)
+	is.True(errors.Is(cfg.CreateUser("Frankie", []string{"not a key"}), ErrInvalidKey))
+	is.Equal(cfg.CreateUser("Frankie Doe", nil), ErrInvalidUserName)
+	is.NoErr(cfg.CreateUser("Frankie", nil))
+	is.Equal(cfg.CreateUser("Frankie", nil), ErrUserExists)
+	is.NoErr(cfg.SetUserKey("Frankie", " "+key+" ", true))
+	is.NoErr(cfg.SetUserKey("Frankie", key, true)) // not added twice
+	u, ok := cfg.GetUser("Frankie")
+	is.True(ok)
+	is.Equal(u.PublicKeys, []string{key})
+	is.Equal(cfg.AuthRepo("repo", pk), gm.ReadOnlyAccess)
+	is.True(!cfg.HasRole(pk, RoleRepoAdmin, RoleAuditor))
+
+	// Repo admins have admin access to repos, but the config repo.
+	is.True(cfg.SetUserRole("Frankie", "owner", true) != nil)
+	is.NoErr(cfg.SetUserRole("Frankie", RoleRepoAdmin, true))
+	is.True(cfg.HasRole(pk, RoleRepoAdmin, RoleAuditor))
+	is.True(!cfg.HasRole(pk, RoleUserAdmin))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.AdminAccess)
+	is.True(cfg.AuthRepo("config", pk) < gm.AdminAccess)
+	is.NoErr(cfg.SetUserRole("Frankie", RoleRepoAdmin, false))
+	is.Equal(cfg.AuthRepo("repo", pk), gm.ReadOnlyAccess)
+
+	// Admins have all roles.
+	apk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(adminKey))
+	is.NoErr(err)
+	is.True(cfg.HasRole(apk, RoleAuditor))
+
+	is.NoErr(cfg.SetCollab("repo", "Frankie", true))
+	is.NoErr(cfg.DeleteUser("Frankie"))
+	is.Equal(cfg.DeleteUser("Frankie"), ErrUnknownUser)
+	is.Equal(len(cfg.Collabs("repo")), 0)
+	is.Equal(cfg.AuthRepo("repo", pk), cfg.AuthRepo("repo", nil))
+}
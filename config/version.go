@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the version of the configuration schema the server
+// reads. Configurations without a version field are version 0.
+const CurrentVersion = 1
+
+// migration migrates the configuration from one version to the next.
+type migration struct {
+	// description says what the migration changes.
+	description string
+	migrate     func(root *yaml.Node)
+}
+
+// migrations[i] migrates version i to version i+1. Add a migration and bump
+// CurrentVersion when changing the meaning of existing settings; new
+// settings with a default don't need one.
+var migrations = []migration{
+	{"add the version field", func(*yaml.Node) {}},
+}
+
+// Compatibility describes how the configuration was made readable by the
+// server.
+type Compatibility struct {
+	// Version is the version of the config file.
+	Version int `json:"version"`
+	// ServerVersion is the version the server reads.
+	ServerVersion int `json:"server-version"`
+	// Migrations describes the migrations applied when reading the config
+	// file, oldest first.
+	Migrations []string `json:"migrations"`
+	// Unknown lists the settings the server doesn't know and ignores, e.g.
+	// "repos[1].colabs".
+	Unknown []string `json:"unknown"`
+}
+
+// Newer reports whether the config file was written for a newer server.
+// Settings it doesn't know are ignored rather than refusing to start.
+func (c Compatibility) Newer() bool {
+	return c.Version > c.ServerVersion
+}
+
+// OK reports whether the config file is read as is.
+func (c Compatibility) OK() bool {
+	return !c.Newer() && len(c.Migrations) == 0 && len(c.Unknown) == 0
+}
+
+// Compatibility returns the compatibility report of the last reload.
+func (cfg *Config) Compatibility() Compatibility {
+	cfg.mtx.Lock()
+	defer cfg.mtx.Unlock()
+	return cfg.compat
+}
+
+// Migrate commits the migrations of the config file to the config repo, so
+// they don't have to be applied on every reload. It does nothing when the
+// config file is up to date.
+func (cfg *Config) Migrate() error {
+	c := cfg.Compatibility()
+	if len(c.Migrations) == 0 {
+		return nil
+	}
+	msg := fmt.Sprintf("Migrate config to version %d", CurrentVersion)
+	return cfg.editConfig(msg, func(doc *yaml.Node) {
+		migrate(doc.Content[0])
+	})
+}
+
+// migrate migrates the root mapping of a config file to CurrentVersion. It
+// returns the version of the file and the descriptions of the migrations it
+// applied.
+func migrate(root *yaml.Node) (int, []string) {
+	version := 0
+	if v := mappingValue(root, "version", 0); v != nil {
+		n, err := strconv.Atoi(v.Value)
+		if err != nil || n < 0 {
+			// Decoding reports the invalid version.
+			return 0, nil
+		}
+		version = n
+	}
+	applied := make([]string, 0)
+	for v := version; v < CurrentVersion; v++ {
+		m := migrations[v]
+		m.migrate(root)
+		mappingValue(root, "version", yaml.ScalarNode).Value = strconv.Itoa(v + 1)
+		applied = append(applied, fmt.Sprintf("%d to %d: %s", v, v+1, m.description))
+	}
+	return version, applied
+}
+
+// unknownSettings returns the paths of the settings of n that don't decode
+// into a value of type t.
+func unknownSettings(n *yaml.Node, t reflect.Type, path string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	unknown := make([]string, 0)
+	switch {
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if name == "-" || f.PkgPath != "" {
+				continue
+			}
+			if name == "" {
+				name = strings.ToLower(f.Name)
+			}
+			fields[name] = f.Type
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			k := n.Content[i].Value
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			ft, ok := fields[k]
+			if !ok {
+				unknown = append(unknown, p)
+				continue
+			}
+			unknown = append(unknown, unknownSettings(n.Content[i+1], ft, p)...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			p := path + "." + n.Content[i].Value
+			unknown = append(unknown, unknownSettings(n.Content[i+1], t.Elem(), p)...)
+		}
+	case n.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, c := range n.Content {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			unknown = append(unknown, unknownSettings(c, t.Elem(), p)...)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate(t *testing.T) {
+	is := is.New(t)
+	cfg, err := NewConfig(&config.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	c := cfg.Compatibility()
+	is.True(c.OK()) // new configs should be current
+	is.Equal(c.Version, CurrentVersion)
+
+	// An unversioned config with a typo.
+	is.NoErr(cfg.editConfig("Downgrade", func(doc *yaml.Node) {
+		root := doc.Content[0]
+		for i := 0; i+1 < len(root.Content); i += 2 {
+			if root.Content[i].Value == "version" {
+				root.Content = append(root.Content[:i], root.Content[i+2:]...)
+				break
+			}
+		}
+		repos := mappingValue(root, "repos", yaml.SequenceNode)
+		mappingValue(repos.Content[0], "colabs", yaml.SequenceNode)
+	}))
+	c = cfg.Compatibility()
+	is.Equal(c.Version, 0)
+	is.Equal(c.Migrations, []string{"0 to 1: add the version field"})
+	is.Equal(c.Unknown, []string{"repos[0].colabs"})
+	is.Equal(cfg.Version, CurrentVersion) // should be migrated in memory
+
+	is.NoErr(cfg.Migrate())
+	c = cfg.Compatibility()
+	is.Equal(c.Version, CurrentVersion)
+	is.Equal(len(c.Migrations), 0)
+	is.Equal(c.Unknown, []string{"repos[0].colabs"}) // should keep unknown settings
+	cr, err := cfg.Source.GetRepo("config")
+	is.NoErr(err)
+	cy, _, err := cr.LatestFile("config.yaml")
+	is.NoErr(err)
+	is.True(strings.Contains(cy, "version: 1\n"))
+
+	// A config from a newer server still loads.
+	is.NoErr(cfg.editConfig("Upgrade", func(doc *yaml.Node) {
+		root := doc.Content[0]
+		mappingValue(root, "version", yaml.ScalarNode).Value = "99"
+		mappingValue(root, "shiny-new-setting", yaml.ScalarNode).Value = "true"
+	}))
+	c = cfg.Compatibility()
+	is.True(c.Newer())
+	is.Equal(c.Unknown, []string{"repos[0].colabs", "shiny-new-setting"})
+	is.Equal(cfg.Name, "Soft Serve")
+}
@@ -0,0 +1,197 @@
+// Package deps finds the dependencies declared in the manifests of
+// repositories: go.mod, package.json, and requirements.txt files.
+package deps
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Ecosystems of dependencies.
+const (
+	Go   = "go"
+	NPM  = "npm"
+	PyPI = "pypi"
+)
+
+// manifests maps the file names of manifests to their ecosystem.
+var manifests = map[string]string{
+	"go.mod":           Go,
+	"package.json":     NPM,
+	"requirements.txt": PyPI,
+}
+
+// skipDirs are the directories whose manifests belong to other projects.
+var skipDirs = map[string]bool{
+	"vendor":       true,
+	"node_modules": true,
+	"testdata":     true,
+}
+
+// Dependency is a dependency declared in a manifest.
+type Dependency struct {
+	Name string `json:"name"`
+	// Version is the required version. For npm and PyPI, it's the version
+	// constraint as written, e.g. ^1.2.0 or >=2.0.
+	Version   string `json:"version"`
+	Ecosystem string `json:"ecosystem"`
+	// Manifest is the path of the manifest declaring the dependency.
+	Manifest string `json:"manifest"`
+	// Dev is true for dependencies only needed to develop the project, such
+	// as npm devDependencies.
+	Dev bool `json:"dev,omitempty"`
+	// Indirect is true for Go modules required by dependencies.
+	Indirect bool `json:"indirect,omitempty"`
+}
+
+// IsManifest returns whether the file at path fp is a manifest. Manifests of
+// vendored and test projects aren't.
+func IsManifest(fp string) bool {
+	if _, ok := manifests[path.Base(fp)]; !ok {
+		return false
+	}
+	for _, d := range strings.Split(path.Dir(fp), "/") {
+		if skipDirs[d] {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse returns the dependencies declared in the manifest at path fp.
+func Parse(fp string, data []byte) ([]Dependency, error) {
+	var ds []Dependency
+	var err error
+	switch manifests[path.Base(fp)] {
+	case Go:
+		ds, err = parseGoMod(data)
+	case NPM:
+		ds, err = parsePackageJSON(data)
+	case PyPI:
+		ds = parseRequirements(data)
+	default:
+		return nil, fmt.Errorf("%s: not a manifest", fp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fp, err)
+	}
+	for i := range ds {
+		ds[i].Manifest = fp
+	}
+	return ds, nil
+}
+
+// parseGoMod parses the require directives of a go.mod file.
+func parseGoMod(data []byte) ([]Dependency, error) {
+	ds := make([]Dependency, 0)
+	block := false
+	for i, line := range strings.Split(string(data), "\n") {
+		indirect := strings.Contains(line, "// indirect")
+		if c := strings.Index(line, "//"); c >= 0 {
+			line = line[:c]
+		}
+		fs := strings.Fields(strings.Replace(line, "(", " ( ", 1))
+		switch {
+		case len(fs) == 0:
+			continue
+		case block && fs[0] == ")":
+			block = false
+			continue
+		case block:
+		case fs[0] == "require" && len(fs) > 1 && fs[1] == "(":
+			block = true
+			continue
+		case fs[0] == "require":
+			fs = fs[1:]
+		default:
+			continue
+		}
+		if len(fs) != 2 {
+			return nil, fmt.Errorf("line %d: malformed requirement", i+1)
+		}
+		name := fs[0]
+		if n, err := strconv.Unquote(name); err == nil {
+			name = n
+		}
+		ds = append(ds, Dependency{
+			Name:      name,
+			Version:   fs[1],
+			Ecosystem: Go,
+			Indirect:  indirect,
+		})
+	}
+	return ds, nil
+}
+
+// parsePackageJSON parses the dependencies and devDependencies of a
+// package.json file.
+func parsePackageJSON(data []byte) ([]Dependency, error) {
+	var pkg struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, err
+	}
+	ds := make([]Dependency, 0, len(pkg.Dependencies)+len(pkg.DevDependencies))
+	for i, m := range []map[string]string{pkg.Dependencies, pkg.DevDependencies} {
+		names := make([]string, 0, len(m))
+		for n := range m {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			ds = append(ds, Dependency{
+				Name:      n,
+				Version:   m[n],
+				Ecosystem: NPM,
+				Dev:       i == 1,
+			})
+		}
+	}
+	return ds, nil
+}
+
+// parseRequirements parses the requirements of a pip requirements file.
+// Options, such as included files, and requirements given as URLs are
+// skipped.
+func parseRequirements(data []byte) []Dependency {
+	ds := make([]Dependency, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if c := strings.Index(line, "#"); c >= 0 {
+			line = line[:c]
+		}
+		// Drop environment markers, e.g. ; python_version < "3.8".
+		if c := strings.Index(line, ";"); c >= 0 {
+			line = line[:c]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "-") || strings.Contains(line, "://") {
+			continue
+		}
+		name, spec := line, ""
+		if i := strings.IndexAny(line, "<>=!~[ @"); i >= 0 {
+			name, spec = line[:i], line[i:]
+		}
+		// Drop extras, e.g. requests[security].
+		if strings.HasPrefix(spec, "[") {
+			if i := strings.Index(spec, "]"); i >= 0 {
+				spec = spec[i+1:]
+			}
+		}
+		spec = strings.ReplaceAll(spec, " ", "")
+		if strings.HasPrefix(spec, "==") && !strings.Contains(spec, ",") {
+			spec = strings.TrimPrefix(spec, "==")
+		}
+		ds = append(ds, Dependency{
+			Name:      name,
+			Version:   spec,
+			Ecosystem: PyPI,
+		})
+	}
+	return ds
+}
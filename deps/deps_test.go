@@ -0,0 +1,77 @@
+package deps
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestIsManifest(t *testing.T) {
+	is := is.New(t)
+	is.True(IsManifest("go.mod"))
+	is.True(IsManifest("web/package.json"))
+	is.True(IsManifest("tools/requirements.txt"))
+	is.True(!IsManifest("go.sum"))
+	is.True(!IsManifest("vendor/github.com/x/y/go.mod"))
+	is.True(!IsManifest("web/node_modules/left-pad/package.json"))
+	is.True(!IsManifest("internal/testdata/go.mod"))
+}
+
+func TestParseGoMod(t *testing.T) {
+	is := is.New(t)
+	ds, err := Parse("go.mod", []byte(`module example.com/app
+
+go 1.17
+
+require github.com/charmbracelet/log v0.2.1
+
+require (
+	github.com/matryer/is v1.4.0
+	golang.org/x/sys v0.6.0 // indirect
+)
+
+replace github.com/matryer/is => ../is
+`))
+	is.NoErr(err)
+	is.Equal(ds, []Dependency{
+		{Name: "github.com/charmbracelet/log", Version: "v0.2.1", Ecosystem: Go, Manifest: "go.mod"},
+		{Name: "github.com/matryer/is", Version: "v1.4.0", Ecosystem: Go, Manifest: "go.mod"},
+		{Name: "golang.org/x/sys", Version: "v0.6.0", Ecosystem: Go, Manifest: "go.mod", Indirect: true},
+	})
+	_, err = Parse("go.mod", []byte("require (\n\tgithub.com/matryer/is\n)\n"))
+	is.True(err != nil)
+}
+
+func TestParsePackageJSON(t *testing.T) {
+	is := is.New(t)
+	ds, err := Parse("web/package.json", []byte(`{
+  "name": "web",
+  "dependencies": {"react": "^18.2.0", "left-pad": "1.3.0"},
+  "devDependencies": {"typescript": "~5.0.0"}
+}`))
+	is.NoErr(err)
+	is.Equal(ds, []Dependency{
+		{Name: "left-pad", Version: "1.3.0", Ecosystem: NPM, Manifest: "web/package.json"},
+		{Name: "react", Version: "^18.2.0", Ecosystem: NPM, Manifest: "web/package.json"},
+		{Name: "typescript", Version: "~5.0.0", Ecosystem: NPM, Manifest: "web/package.json", Dev: true},
+	})
+	_, err = Parse("package.json", []byte("{"))
+	is.True(err != nil)
+}
+
+func TestParseRequirements(t *testing.T) {
+	is := is.New(t)
+	ds, err := Parse("requirements.txt", []byte(`# Runtime
+requests[security] == 2.31.0
+Django>=4.0,<5.0
+numpy ; python_version >= "3.8"
+-r dev-requirements.txt
+git+https://github.com/psf/black.git#egg=black
+`))
+	is.NoErr(err)
+	is.Equal(ds, []Dependency{
+		{Name: "requests", Version: "2.31.0", Ecosystem: PyPI, Manifest: "requirements.txt"},
+		{Name: "Django", Version: ">=4.0,<5.0", Ecosystem: PyPI, Manifest: "requirements.txt"},
+		{Name: "numpy", Version: "", Ecosystem: PyPI, Manifest: "requirements.txt"},
+	})
+}
@@ -0,0 +1,248 @@
+// Package events implements a simple in-process event bus used to notify
+// interested parties, such as open TUI sessions, about server activity.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is the number of events buffered per subscriber. Events
+// are dropped for subscribers that fall behind, unless they're durable.
+const subscriberBuffer = 16
+
+// Type is the type of an event.
+type Type string
+
+const (
+	// Push is published after a repository has been pushed to.
+	Push Type = "push"
+	// Fetch is published when a repository is fetched or cloned.
+	Fetch Type = "fetch"
+	// AuthSuccess is published when a user authenticates.
+	AuthSuccess Type = "auth-success"
+	// AuthFailure is published when a user fails to authenticate.
+	AuthFailure Type = "auth-failure"
+	// RepoCreated is published when a new repository is found.
+	RepoCreated Type = "repo-created"
+	// RepoDeleted is published when a repository is removed.
+	RepoDeleted Type = "repo-deleted"
+	// RepoVisibility is published when a repository is made public or
+	// private.
+	RepoVisibility Type = "repo-visibility"
+	// ConfigUpdated is published when the server reloads a new commit of
+	// the config repo.
+	ConfigUpdated Type = "config-updated"
+	// ActionRun is published when a user runs a custom action on a
+	// repository.
+	ActionRun Type = "action-run"
+	// AdminCommand is published when a user runs an admin command, over
+	// SSH or the admin API, or a command creating a repository.
+	AdminCommand Type = "admin-command"
+	// CollabExpiring is published when the access of a collaborator of a
+	// repository is about to expire.
+	CollabExpiring Type = "collab-expiring"
+	// CollabExpired is published when a collaborator of a repository is
+	// removed, once their access expired.
+	CollabExpired Type = "collab-expired"
+	// AccessRequested is published when a user requests access to a
+	// repository.
+	AccessRequested Type = "access-requested"
+	// AccessApproved is published when an admin of a repository approves an
+	// access request.
+	AccessApproved Type = "access-approved"
+	// AccessDenied is published when an admin of a repository denies an
+	// access request.
+	AccessDenied Type = "access-denied"
+	// MirrorFailed is published when a mirror fails to sync from its
+	// upstream, or a repository fails to push to a push mirror, after
+	// syncing fine.
+	MirrorFailed Type = "mirror-failed"
+)
+
+// Event is a server event.
+type Event struct {
+	Type Type      `json:"type"`
+	Repo string    `json:"repo,omitempty"`
+	Time time.Time `json:"time"`
+	// Ref is the full name of the reference updated by a push, if known.
+	Ref string `json:"ref,omitempty"`
+	// Commit is the commit the reference points to after a push. It's empty
+	// when the reference was deleted.
+	Commit string `json:"commit,omitempty"`
+	// Before is the commit the reference pointed to before a push. It's
+	// empty when the reference was created.
+	Before string `json:"before,omitempty"`
+	// Commits are the commits a push introduced, newest first, up to a
+	// limit.
+	Commits []Commit `json:"commits,omitempty"`
+	// User is the name of the user, or the fingerprint of their key if
+	// they're not a known user.
+	User string `json:"user,omitempty"`
+	// RemoteAddr is the network address of the client.
+	RemoteAddr string `json:"remote-addr,omitempty"`
+	// Key is the SHA256 fingerprint of the public key of the user, if
+	// any.
+	Key string `json:"key,omitempty"`
+	// Method is the authentication method, for authentication events:
+	// publickey, keyboard-interactive, http-password, or api-token.
+	Method string `json:"method,omitempty"`
+	// Command is the command run, for admin command events.
+	Command string `json:"command,omitempty"`
+	// Reason is the reason given for running a dangerous command, such as a
+	// ticket, for admin command events.
+	Reason string `json:"reason,omitempty"`
+	// Visibility is the visibility of the repository, public or private,
+	// for repository lifecycle events.
+	Visibility string `json:"visibility,omitempty"`
+	// Action is the name of the action run, for action events.
+	Action string `json:"action,omitempty"`
+	// Expires is when the access of a collaborator expires, for
+	// collaborator expiry events.
+	Expires *time.Time `json:"expires,omitempty"`
+	// Message is the message of an access request.
+	Message string `json:"message,omitempty"`
+	// Requester is the user who requested access, for access approval
+	// events, whose User approved or denied it.
+	Requester string `json:"requester,omitempty"`
+	// Access is the access level granted, for access approval events.
+	Access string `json:"access,omitempty"`
+	// Error is the error the action failed with, if any.
+	Error string `json:"error,omitempty"`
+	// Replayed is set on events re-emitted from the event store, rather
+	// than published as they happened.
+	Replayed bool `json:"replayed,omitempty"`
+	// Test is set on sample events sent to test hooks and CI pipelines.
+	Test bool `json:"test,omitempty"`
+}
+
+// Commit is a commit introduced by a push.
+type Commit struct {
+	ID      string    `json:"id"`
+	Message string    `json:"message"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Time    time.Time `json:"time"`
+}
+
+// Bus is a publish/subscribe event bus. The zero value is not usable, use
+// NewBus instead. A nil *Bus silently discards published events.
+type Bus struct {
+	mtx     sync.Mutex
+	subs    map[chan Event]struct{}
+	durable map[*queue]struct{}
+}
+
+// NewBus creates a new event bus.
+func NewBus() *Bus {
+	return &Bus{
+		subs:    make(map[chan Event]struct{}),
+		durable: make(map[*queue]struct{}),
+	}
+}
+
+// queue is the unbounded queue of the events of a durable subscriber.
+type queue struct {
+	mtx    sync.Mutex
+	events []Event
+	// ready is signaled when events are queued.
+	ready chan struct{}
+}
+
+// push queues an event.
+func (q *queue) push(e Event) {
+	q.mtx.Lock()
+	q.events = append(q.events, e)
+	q.mtx.Unlock()
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the next queued event, if any.
+func (q *queue) pop() (Event, bool) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+	if len(q.events) == 0 {
+		return Event{}, false
+	}
+	e := q.events[0]
+	q.events[0] = Event{}
+	q.events = q.events[1:]
+	return e, true
+}
+
+// Subscribe returns a channel that receives published events until ctx is
+// done, at which point the channel is closed.
+func (b *Bus) Subscribe(ctx context.Context) <-chan Event {
+	ch := make(chan Event, subscriberBuffer)
+	b.mtx.Lock()
+	b.subs[ch] = struct{}{}
+	b.mtx.Unlock()
+	go func() {
+		<-ctx.Done()
+		b.mtx.Lock()
+		delete(b.subs, ch)
+		close(ch)
+		b.mtx.Unlock()
+	}()
+	return ch
+}
+
+// SubscribeDurable returns a channel that receives all published events,
+// however far behind the receiver falls, for subscribers such as the event
+// store that must not miss any. Events are queued without bound. When ctx is
+// done, the events published until then are delivered and the channel is
+// closed.
+func (b *Bus) SubscribeDurable(ctx context.Context) <-chan Event {
+	q := &queue{ready: make(chan struct{}, 1)}
+	b.mtx.Lock()
+	b.durable[q] = struct{}{}
+	b.mtx.Unlock()
+	ch := make(chan Event)
+	go func() {
+		defer close(ch)
+		for {
+			e, ok := q.pop()
+			if !ok {
+				select {
+				case <-q.ready:
+					continue
+				case <-ctx.Done():
+				}
+				break
+			}
+			ch <- e
+		}
+		b.mtx.Lock()
+		delete(b.durable, q)
+		b.mtx.Unlock()
+		for e, ok := q.pop(); ok; e, ok = q.pop() {
+			ch <- e
+		}
+	}()
+	return ch
+}
+
+// Publish sends an event to all subscribers without blocking.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	for q := range b.durable {
+		q.push(e)
+	}
+}
@@ -0,0 +1,64 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestBus(t *testing.T) {
+	is := is.New(t)
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx)
+	b.Publish(Event{Type: Push, Repo: "foo"})
+	e := <-ch
+	is.Equal(e.Type, Push)
+	is.Equal(e.Repo, "foo")
+	is.True(!e.Time.IsZero())
+	cancel()
+	_, ok := <-ch
+	is.True(!ok) // channel should be closed after unsubscribing
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_ = b.Subscribe(ctx)
+	// Publishing more events than the subscriber buffer must not block.
+	for i := 0; i < subscriberBuffer*2; i++ {
+		b.Publish(Event{Type: Push})
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var b *Bus
+	b.Publish(Event{Type: Push})
+}
+
+func TestBusDurable(t *testing.T) {
+	is := is.New(t)
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.SubscribeDurable(ctx)
+	// Durable subscribers get all events, however far behind they fall.
+	n := subscriberBuffer * 4
+	for i := 0; i < n; i++ {
+		b.Publish(Event{Type: Push, Commit: strconv.Itoa(i)})
+	}
+	for i := 0; i < n/2; i++ {
+		e := <-ch
+		is.Equal(e.Commit, strconv.Itoa(i))
+	}
+	// Events published before unsubscribing are still delivered.
+	cancel()
+	var got int
+	for range ch {
+		got++
+	}
+	is.Equal(got, n/2)
+	b.Publish(Event{Type: Push})
+}
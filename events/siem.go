@@ -0,0 +1,212 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// Format is the wire format of events forwarded to a SIEM.
+type Format string
+
+const (
+	// FormatSyslog formats events as RFC 5424 syslog messages.
+	FormatSyslog Format = "syslog"
+	// FormatCEF formats events in the ArcSight Common Event Format.
+	FormatCEF Format = "cef"
+	// FormatJSON formats events as JSON objects.
+	FormatJSON Format = "json"
+)
+
+const (
+	// syslogFacility is the syslog facility of forwarded events, security
+	// and authorization messages.
+	syslogFacility = 4
+	// appName is the name of the application reported to the SIEM.
+	appName = "soft-serve"
+)
+
+// severity returns the syslog severity of an event, notice for failures and
+// informational otherwise.
+func (e Event) severity() int {
+	if e.Type == AuthFailure {
+		return 5
+	}
+	return 6
+}
+
+// Encode encodes an event in the format. Each encoded event is a single line.
+func (f Format) Encode(e Event, hostname string) ([]byte, error) {
+	switch f {
+	case FormatJSON:
+		bts, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		return append(bts, '\n'), nil
+	case FormatCEF:
+		ext := []string{
+			fmt.Sprintf("rt=%d", e.Time.UnixMilli()),
+			"dvchost=" + cefEscape(hostname),
+		}
+		if host, _, err := net.SplitHostPort(e.RemoteAddr); err == nil {
+			ext = append(ext, "src="+cefEscape(host))
+		}
+		if e.User != "" {
+			ext = append(ext, "suser="+cefEscape(e.User))
+		}
+		if e.Repo != "" {
+			ext = append(ext, "cs1Label=repo", "cs1="+cefEscape(e.Repo))
+		}
+		if e.Ref != "" {
+			ext = append(ext, "cs2Label=ref", "cs2="+cefEscape(e.Ref))
+		}
+		if e.Commit != "" {
+			ext = append(ext, "cs3Label=commit", "cs3="+cefEscape(e.Commit))
+		}
+		if e.Action != "" {
+			ext = append(ext, "cs4Label=action", "cs4="+cefEscape(e.Action))
+		}
+		if e.Key != "" {
+			ext = append(ext, "cs5Label=key", "cs5="+cefEscape(e.Key))
+		}
+		if e.Command != "" {
+			ext = append(ext, "cs6Label=command", "cs6="+cefEscape(e.Command))
+		}
+		if e.Error != "" {
+			ext = append(ext, "msg="+cefEscape(e.Error))
+		}
+		// CEF severities go from 0 to 10.
+		sev := 3
+		if e.Type == AuthFailure {
+			sev = 6
+		}
+		return []byte(fmt.Sprintf("CEF:0|Charmbracelet|Soft Serve|1.0|%[1]s|%[1]s|%d|%s\n",
+			e.Type, sev, strings.Join(ext, " "))), nil
+	case FormatSyslog, "":
+		msg := make([]string, 0)
+		if e.Repo != "" {
+			msg = append(msg, fmt.Sprintf("repo=%q", e.Repo))
+		}
+		if e.Ref != "" {
+			msg = append(msg, fmt.Sprintf("ref=%q", e.Ref))
+		}
+		if e.Commit != "" {
+			msg = append(msg, fmt.Sprintf("commit=%q", e.Commit))
+		}
+		if e.User != "" {
+			msg = append(msg, fmt.Sprintf("user=%q", e.User))
+		}
+		if e.RemoteAddr != "" {
+			msg = append(msg, fmt.Sprintf("remote-addr=%q", e.RemoteAddr))
+		}
+		if e.Action != "" {
+			msg = append(msg, fmt.Sprintf("action=%q", e.Action))
+		}
+		if e.Key != "" {
+			msg = append(msg, fmt.Sprintf("key=%q", e.Key))
+		}
+		if e.Method != "" {
+			msg = append(msg, fmt.Sprintf("method=%q", e.Method))
+		}
+		if e.Command != "" {
+			msg = append(msg, fmt.Sprintf("command=%q", e.Command))
+		}
+		if e.Error != "" {
+			msg = append(msg, fmt.Sprintf("error=%q", e.Error))
+		}
+		return []byte(fmt.Sprintf("<%d>1 %s %s %s %d %s - %s\n",
+			syslogFacility*8+e.severity(),
+			e.Time.UTC().Format(time.RFC3339Nano),
+			hostname,
+			appName,
+			os.Getpid(),
+			e.Type,
+			strings.Join(msg, " "),
+		)), nil
+	default:
+		return nil, fmt.Errorf("unknown event format %q", f)
+	}
+}
+
+// cefEscape escapes a CEF extension value.
+func cefEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`).Replace(s)
+}
+
+// Forwarder forwards events to a remote collector such as a SIEM.
+type Forwarder struct {
+	network  string
+	addr     string
+	format   Format
+	hostname string
+	conn     net.Conn
+}
+
+// NewForwarder creates a new forwarder sending events in the given format to
+// address, a URL like udp://host:514 or tcp://host:6514.
+func NewForwarder(address string, format Format) (*Forwarder, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+	default:
+		return nil, fmt.Errorf("unsupported event forwarding address %q", address)
+	}
+	if _, err := format.Encode(Event{}, ""); err != nil {
+		return nil, err
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+	return &Forwarder{
+		network:  u.Scheme,
+		addr:     u.Host,
+		format:   format,
+		hostname: hostname,
+	}, nil
+}
+
+// Run forwards events from bus until ctx is done. Events that can't be sent
+// are logged and dropped.
+func (f *Forwarder) Run(ctx context.Context, bus *Bus) {
+	ch := bus.SubscribeDurable(ctx)
+	for e := range ch {
+		if err := f.send(e); err != nil {
+			log.Error("error forwarding event", "type", e.Type, "err", err)
+		}
+	}
+	if f.conn != nil {
+		_ = f.conn.Close()
+	}
+}
+
+// send sends an event, reconnecting if needed.
+func (f *Forwarder) send(e Event) error {
+	bts, err := f.format.Encode(e, f.hostname)
+	if err != nil {
+		return err
+	}
+	if f.conn == nil {
+		f.conn, err = net.DialTimeout(f.network, f.addr, 5*time.Second)
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := f.conn.Write(bts); err != nil {
+		_ = f.conn.Close()
+		f.conn = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestEncode(t *testing.T) {
+	e := Event{
+		Type:       AuthFailure,
+		Repo:       "foo",
+		User:       "a=b",
+		RemoteAddr: "127.0.0.1:1234",
+		Time:       time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	t.Run("syslog", func(t *testing.T) {
+		is := is.New(t)
+		bts, err := FormatSyslog.Encode(e, "host")
+		is.NoErr(err)
+		s := string(bts)
+		is.True(strings.HasPrefix(s, "<37>1 2022-01-02T03:04:05Z host soft-serve "))
+		is.True(strings.HasSuffix(s, ` auth-failure - repo="foo" user="a=b" remote-addr="127.0.0.1:1234"`+"\n"))
+	})
+	t.Run("cef", func(t *testing.T) {
+		is := is.New(t)
+		bts, err := FormatCEF.Encode(e, "host")
+		is.NoErr(err)
+		is.Equal(string(bts), "CEF:0|Charmbracelet|Soft Serve|1.0|auth-failure|auth-failure|6|rt=1641092645000 dvchost=host src=127.0.0.1 suser=a\\=b cs1Label=repo cs1=foo\n")
+	})
+	t.Run("json", func(t *testing.T) {
+		is := is.New(t)
+		bts, err := FormatJSON.Encode(e, "host")
+		is.NoErr(err)
+		var d Event
+		is.NoErr(json.Unmarshal(bts, &d))
+		is.Equal(d, e)
+	})
+	t.Run("unknown", func(t *testing.T) {
+		is := is.New(t)
+		_, err := Format("xml").Encode(e, "host")
+		is.True(err != nil)
+	})
+}
+
+func TestForwarder(t *testing.T) {
+	is := is.New(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	defer l.Close()
+	f, err := NewForwarder("tcp://"+l.Addr().String(), FormatJSON)
+	is.NoErr(err)
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go f.Run(ctx, b)
+
+	lines := make(chan string)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		lines <- line
+	}()
+	// Wait for the forwarder to subscribe.
+	deadline := time.After(5 * time.Second)
+	for {
+		b.Publish(Event{Type: Push, Repo: "foo"})
+		select {
+		case line := <-lines:
+			var e Event
+			is.NoErr(json.Unmarshal([]byte(line), &e))
+			is.Equal(e.Repo, "foo")
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("timed out waiting for event")
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// storeFileLayout is the layout of the dates naming the files of a store.
+const storeFileLayout = "2006-01-02"
+
+// Store durably records push events, so that they can be replayed to
+// backfill downstream systems such as CI or a search index. Events are
+// appended to a file of JSON lines per day.
+type Store struct {
+	dir string
+	mtx sync.Mutex
+}
+
+// NewStore creates a store of the events in dir.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Run records the push events published on bus until ctx is done. Events
+// that can't be recorded are logged and dropped.
+func (s *Store) Run(ctx context.Context, bus *Bus) {
+	for e := range bus.SubscribeDurable(ctx) {
+		if e.Type != Push {
+			continue
+		}
+		if err := s.Append(e); err != nil {
+			log.Error("error storing event", "type", e.Type, "repo", e.Repo, "err", err)
+		}
+	}
+}
+
+// Append records an event.
+func (s *Store) Append(e Event) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	bts, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, e.Time.UTC().Format(storeFileLayout)+".jsonl")
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(bts, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Events returns the recorded events of repo since the given time, oldest
+// first. All repos' events are returned when repo is empty.
+func (s *Store) Events(repo string, since time.Time) ([]Event, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	des, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(des))
+	for _, de := range des {
+		day, err := time.Parse(storeFileLayout, strings.TrimSuffix(de.Name(), ".jsonl"))
+		if err != nil || de.IsDir() || !strings.HasSuffix(de.Name(), ".jsonl") {
+			continue
+		}
+		// Skip the days entirely before since.
+		if day.Add(24 * time.Hour).Before(since) {
+			continue
+		}
+		names = append(names, de.Name())
+	}
+	sort.Strings(names)
+	es := make([]Event, 0)
+	for _, name := range names {
+		day, err := readEvents(filepath.Join(s.dir, name))
+		if err != nil {
+			return es, err
+		}
+		for _, e := range day {
+			if (repo == "" || e.Repo == repo) && !e.Time.Before(since) {
+				es = append(es, e)
+			}
+		}
+	}
+	sort.SliceStable(es, func(i, j int) bool {
+		return es[i].Time.Before(es[j].Time)
+	})
+	return es, nil
+}
+
+// readEvents reads a file of events. A truncated last line, left by a crash
+// while appending, is ignored.
+func readEvents(name string) ([]Event, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	es := make([]Event, 0)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			continue
+		}
+		es = append(es, e)
+	}
+	return es, sc.Err()
+}
@@ -0,0 +1,79 @@
+package events
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+func TestStore(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	s := NewStore(dir)
+	day := time.Date(2023, 1, 2, 12, 0, 0, 0, time.UTC)
+	is.NoErr(s.Append(Event{Type: Push, Repo: "foo", Ref: "refs/heads/main", Time: day.Add(-24 * time.Hour)}))
+	is.NoErr(s.Append(Event{Type: Push, Repo: "bar", Ref: "refs/heads/main", Time: day}))
+	is.NoErr(s.Append(Event{Type: Push, Repo: "foo", Ref: "refs/tags/v1", Time: day.Add(time.Hour)}))
+
+	es, err := s.Events("", time.Time{})
+	is.NoErr(err)
+	is.Equal(len(es), 3)
+	is.Equal(es[0].Repo, "foo")
+	is.Equal(es[1].Repo, "bar")
+
+	es, err = s.Events("foo", day)
+	is.NoErr(err)
+	is.Equal(len(es), 1)
+	is.Equal(es[0].Ref, "refs/tags/v1")
+
+	// A truncated last line is ignored.
+	f, err := os.OpenFile(filepath.Join(dir, "2023-01-02.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	is.NoErr(err)
+	_, err = f.WriteString(`{"type":"pu`)
+	is.NoErr(err)
+	is.NoErr(f.Close())
+	es, err = s.Events("", day)
+	is.NoErr(err)
+	is.Equal(len(es), 2)
+}
+
+func TestStoreRun(t *testing.T) {
+	is := is.New(t)
+	s := NewStore(t.TempDir())
+	b := NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx, b)
+		close(done)
+	}()
+	// Wait for the store to subscribe.
+	for {
+		b.mtx.Lock()
+		n := len(b.durable)
+		b.mtx.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	b.Publish(Event{Type: AuthSuccess, User: "foo"})
+	b.Publish(Event{Type: Push, Repo: "foo"})
+	cancel()
+	<-done
+	es, err := s.Events("", time.Time{})
+	is.NoErr(err)
+	is.Equal(len(es), 1) // only push events are stored
+	is.Equal(es[0].Repo, "foo")
+}
+
+func TestStoreMissingDir(t *testing.T) {
+	is := is.New(t)
+	es, err := NewStore(filepath.Join(t.TempDir(), "missing")).Events("", time.Time{})
+	is.NoErr(err)
+	is.Equal(len(es), 0)
+}
@@ -46,14 +46,17 @@ func main() {
 	}
 	cfg := config.DefaultConfig()
 	cfg.Port = *port
-	s := server.NewServer(cfg)
+	s, err := server.New(server.WithConfig(cfg), server.WithSSHListener(ls))
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
 	log.Print("Starting SSH server", "addr", fmt.Sprintf("%s:%d", cfg.BindAddr, cfg.Port))
 	go func() {
-		if err := s.Serve(ls); err != nil {
+		if err := s.Start(); err != nil {
 			log.Fatal(err)
 		}
 	}()
@@ -0,0 +1,80 @@
+// Package expiry removes collaborators whose access expired, and warns about
+// the access about to expire.
+package expiry
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+)
+
+// Scheduler processes the expiry of collaborators periodically.
+type Scheduler struct {
+	cfg *config.Config
+	// Interval is the time between runs.
+	Interval time.Duration
+	// Warn is how long before their access expires collaborators are
+	// warned about.
+	Warn time.Duration
+	// warned holds the collaborators warned about, so that warnings are
+	// published once per expiry.
+	warned map[string]bool
+}
+
+// NewScheduler creates a new expiry scheduler.
+func NewScheduler(cfg *config.Config) *Scheduler {
+	return &Scheduler{
+		cfg:      cfg,
+		Interval: time.Hour,
+		Warn:     7 * 24 * time.Hour,
+		warned:   make(map[string]bool),
+	}
+}
+
+// Run processes expiries at the scheduled interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Process(time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Process removes the collaborators whose access expired at now, and
+// publishes a collab-expiring event for each collaborator whose access
+// expires within the warning period, once.
+func (s *Scheduler) Process(now time.Time) {
+	expired, err := s.cfg.ExpireCollabs(now)
+	if err != nil {
+		log.Error("error removing expired collaborators", "err", err)
+	}
+	for _, g := range expired {
+		log.Info("removed expired collaborator", "repo", g.Repo, "user", g.User, "expires", g.Expires)
+	}
+	warned := make(map[string]bool)
+	for _, g := range s.cfg.ExpiringCollabs(now, s.Warn) {
+		key := fmt.Sprint(g.Repo, "\x00", g.User, "\x00", g.Expires.Unix())
+		warned[key] = true
+		if s.warned[key] {
+			continue
+		}
+		log.Warn("collaborator access expires soon", "repo", g.Repo, "user", g.User, "expires", g.Expires)
+		expires := g.Expires
+		s.cfg.Events.Publish(events.Event{
+			Type:    events.CollabExpiring,
+			Repo:    g.Repo,
+			User:    g.User,
+			Expires: &expires,
+		})
+	}
+	s.warned = warned
+}
@@ -0,0 +1,48 @@
+package expiry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestProcess(t *testing.T) {
+	is := is.New(t)
+	cfg, err := config.NewConfig(&sconfig.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	is.NoErr(cfg.CreateUser("Frankie", nil))
+	is.NoErr(cfg.CreateUser("Bea", nil))
+	now := time.Now()
+	is.NoErr(cfg.AddCollab("repo", "Frankie", now.Add(3*24*time.Hour)))
+	is.NoErr(cfg.AddCollab("repo", "Bea", now.Add(-time.Minute)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := cfg.Events.Subscribe(ctx)
+	s := NewScheduler(cfg)
+	s.Process(now)
+	s.Process(now.Add(time.Hour)) // warnings aren't repeated
+	is.Equal(cfg.Collabs("repo"), []string{"Frankie"})
+	cancel()
+	var warned, expired []string
+	for e := range c {
+		switch e.Type {
+		case events.CollabExpiring:
+			warned = append(warned, e.User)
+		case events.CollabExpired:
+			expired = append(expired, e.User)
+		}
+	}
+	is.Equal(warned, []string{"Frankie"})
+	is.Equal(expired, []string{"Bea"})
+}
@@ -0,0 +1,119 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// BlobReader streams a blob from git cat-file, so that large files don't
+// have to be held in memory. Seeking restarts git and skips to the offset
+// the next time it's read.
+type BlobReader struct {
+	ctx  context.Context
+	repo *Repository
+	id   string
+	size int64
+	// off is where the next read starts, and pos where git is at.
+	off, pos int64
+	cmd      *exec.Cmd
+	stdout   io.ReadCloser
+}
+
+// OpenBlob returns a reader of the blob of the entry. git is killed when
+// ctx is done. The reader must be closed.
+func (r *Repository) OpenBlob(ctx context.Context, e *TreeEntry) *BlobReader {
+	return &BlobReader{
+		ctx:  ctx,
+		repo: r,
+		id:   e.ID().String(),
+		size: e.Size(),
+	}
+}
+
+// Size returns the size of the blob.
+func (b *BlobReader) Size() int64 {
+	return b.size
+}
+
+// Read implements io.Reader.
+func (b *BlobReader) Read(p []byte) (int, error) {
+	if b.off >= b.size {
+		return 0, io.EOF
+	}
+	if b.cmd != nil && b.pos != b.off {
+		b.stop()
+	}
+	if b.cmd == nil {
+		if err := b.start(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := b.stdout.Read(p)
+	b.pos += int64(n)
+	b.off = b.pos
+	if errors.Is(err, io.EOF) && b.off < b.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// start runs git, skipping the blob up to the offset.
+func (b *BlobReader) start() error {
+	cmd := exec.CommandContext(b.ctx, "git", "cat-file", "blob", b.id)
+	cmd.Dir = b.repo.Path
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	b.cmd, b.stdout, b.pos = cmd, stdout, 0
+	if b.off > 0 {
+		n, err := io.CopyN(io.Discard, stdout, b.off)
+		b.pos = n
+		if err != nil {
+			b.stop()
+			return err
+		}
+	}
+	return nil
+}
+
+// Seek implements io.Seeker.
+func (b *BlobReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.off
+	case io.SeekEnd:
+		offset += b.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	b.off = offset
+	return offset, nil
+}
+
+// Close implements io.Closer.
+func (b *BlobReader) Close() error {
+	b.stop()
+	return nil
+}
+
+// stop kills git, if it's running.
+func (b *BlobReader) stop() {
+	if b.cmd == nil {
+		return
+	}
+	b.cmd.Process.Kill() // nolint: errcheck
+	b.cmd.Wait()         // nolint: errcheck
+	b.cmd, b.stdout = nil, nil
+}
@@ -21,6 +21,9 @@ func (h Hash) SHA1() *git.SHA1 {
 	return git.MustIDFromString(h.String())
 }
 
+// Signature is the author or committer of a commit.
+type Signature = git.Signature
+
 // Commit is a wrapper around git.Commit with helper methods.
 type Commit struct {
 	*git.Commit
@@ -17,4 +17,8 @@ var (
 	ErrRevisionNotExist = git.ErrRevisionNotExist
 	// ErrNotAGitRepository is returned when the given path is not a Git repository.
 	ErrNotAGitRepository = errors.New("not a git repository")
+	// ErrInvalidRange is returned when a commit range is malformed.
+	ErrInvalidRange = errors.New("invalid commit range")
+	// ErrRangeTooLarge is returned when a commit range has too many commits.
+	ErrRangeTooLarge = errors.New("commit range too large")
 )
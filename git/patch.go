@@ -318,17 +318,28 @@ func writeFilePatchHeader(sb *strings.Builder, filePatch *DiffFile) {
 	sb.WriteByte('\n')
 }
 
+func writeFilePatch(sb *strings.Builder, filePatch *DiffFile) {
+	writeFilePatchHeader(sb, filePatch)
+	for _, s := range filePatch.Sections {
+		for _, l := range s.Lines {
+			sb.WriteString(s.diffFor(l))
+			sb.WriteString("\n")
+		}
+	}
+}
+
+// Patch returns the file diff as a patch.
+func (f *DiffFile) Patch() string {
+	var p strings.Builder
+	writeFilePatch(&p, f)
+	return p.String()
+}
+
 // Patch returns the diff as a patch.
 func (d *Diff) Patch() string {
 	var p strings.Builder
 	for _, f := range d.Files {
-		writeFilePatchHeader(&p, f)
-		for _, s := range f.Sections {
-			for _, l := range s.Lines {
-				p.WriteString(s.diffFor(l))
-				p.WriteString("\n")
-			}
-		}
+		writeFilePatch(&p, f)
 	}
 	return p.String()
 }
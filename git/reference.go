@@ -22,6 +22,19 @@ type Reference struct {
 	path string // repo path
 }
 
+// NewCommitReference creates a reference to a commit that isn't a branch or
+// tag, like a detached HEAD.
+func NewCommitReference(rp string, hash Hash) *Reference {
+	return &Reference{
+		Reference: &git.Reference{
+			ID:      hash.String(),
+			Refspec: hash.String(),
+		},
+		Hash: hash,
+		path: rp,
+	}
+}
+
 // ReferenceName is a Refspec wrapper.
 type ReferenceName string
 
@@ -64,6 +77,12 @@ func (r *Reference) IsTag() bool {
 	return strings.HasPrefix(r.Refspec, git.RefsTags)
 }
 
+// IsDetached returns true if the reference points directly at a commit
+// rather than being a branch or tag.
+func (r *Reference) IsDetached() bool {
+	return !strings.HasPrefix(r.Refspec, "refs/")
+}
+
 // TargetHash returns the hash of the reference target.
 func (r *Reference) TargetHash() Hash {
 	if r.IsTag() {
@@ -1,7 +1,16 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gogs/git-module"
 )
@@ -13,6 +22,9 @@ var (
 	DiffMaxFileLines = 1000
 	// DiffMaxLineChars is the maximum number of characters to show in a line diff.
 	DiffMaxLineChars = 1000
+	// RangeDiffMaxCommits is the maximum number of commits of each version
+	// of a series compared by RangeDiff.
+	RangeDiffMaxCommits = 250
 )
 
 // Repository is a wrapper around git.Repository with helper methods.
@@ -92,6 +104,94 @@ func (r *Repository) HEAD() (*Reference, error) {
 	}, nil
 }
 
+// SetHEAD points HEAD at the given branch. The branch can be given as a short
+// name, e.g. "main", or a full reference name, e.g. "refs/heads/main".
+func (r *Repository) SetHEAD(branch string) error {
+	if !strings.HasPrefix(branch, "refs/") {
+		branch = RefsHeads + branch
+	}
+	if cur, err := r.SymbolicRef(); err == nil && cur == branch {
+		return nil
+	}
+	if !r.HasReference(branch) {
+		return ErrReferenceNotFound
+	}
+	_, err := r.SymbolicRef(git.SymbolicRefOptions{Ref: branch})
+	return err
+}
+
+// Bundle writes a bundle of all the references of the repository to path.
+func (r *Repository) Bundle(path string) error {
+	_, err := git.NewCommand("bundle", "create", path, "--all").RunInDir(r.Path)
+	return err
+}
+
+// BundleRefs writes a bundle of the given references and their history to
+// path.
+func (r *Repository) BundleRefs(path string, refs ...string) error {
+	args := append([]string{"bundle", "create", path}, refs...)
+	_, err := git.NewCommand(args...).RunInDir(r.Path)
+	return err
+}
+
+// Archive writes an archive of the tree at rev to w. Format is any format
+// supported by git archive, e.g. "tar.gz" or "zip", and prefix is prepended
+// to every path in the archive.
+func (r *Repository) Archive(w io.Writer, rev, format, prefix string, paths ...string) error {
+	stderr := new(bytes.Buffer)
+	args := []string{"archive", "--format=" + format, "--prefix=" + prefix, rev}
+	if len(paths) > 0 {
+		args = append(args, "--")
+		args = append(args, paths...)
+	}
+	cmd := git.NewCommand(args...)
+	if err := cmd.RunInDirPipeline(w, stderr, r.Path); err != nil {
+		return fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return nil
+}
+
+// VerifyBundle checks that the bundle at path can be restored by cloning it
+// into a temporary directory.
+func VerifyBundle(path string) error {
+	dir, err := os.MkdirTemp("", "soft-serve-bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	return git.Clone(path, filepath.Join(dir, "repo"), git.CloneOptions{Bare: true})
+}
+
+// IsEmpty returns true if the repository has no references, i.e. nothing has
+// been pushed to it yet.
+func (r *Repository) IsEmpty() (bool, error) {
+	out, err := git.NewCommand("for-each-ref", "--count=1").RunInDir(r.Path)
+	if err != nil {
+		return false, err
+	}
+	return len(bytes.TrimSpace(out)) == 0, nil
+}
+
+// CheckMailmap maps contacts, formatted as "Name <email>", to their canonical
+// identities using the .mailmap of the repository's HEAD and, if path isn't
+// empty, the mailmap file at path.
+func (r *Repository) CheckMailmap(path string, contacts ...string) ([]string, error) {
+	if len(contacts) == 0 {
+		return nil, nil
+	}
+	args := make([]string, 0)
+	if path != "" {
+		args = append(args, "-c", "mailmap.file="+path)
+	}
+	args = append(args, "check-mailmap")
+	args = append(args, contacts...)
+	out, err := git.NewCommand(args...).RunInDir(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"), nil
+}
+
 // References returns the references for a repository.
 func (r *Repository) References() ([]*Reference, error) {
 	refs, err := r.ShowRef()
@@ -145,6 +245,65 @@ func (r *Repository) TreePath(ref *Reference, path string) (*Tree, error) {
 	return t.SubTree(path)
 }
 
+// Files returns the paths of all the files in the tree of the given
+// reference.
+func (r *Repository) Files(ref *Reference) ([]string, error) {
+	out, err := git.NewCommand("ls-tree", "-r", "-z", "--name-only", ref.Hash.String()).RunInDir(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]string, 0)
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// GrepMatch is a line of a file matching a search.
+type GrepMatch struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Grep returns the lines of the files at a given reference containing s, up
+// to max matches. Binary files are skipped.
+func (r *Repository) Grep(ref *Reference, s string, max int) ([]GrepMatch, error) {
+	var stdout, stderr bytes.Buffer
+	err := git.NewCommand("grep", "-I", "--fixed-strings", "--full-name", "--line-number", "--null", "-e", s, ref.Hash.String()).
+		RunInDirPipeline(&stdout, &stderr, r.Path)
+	if err != nil {
+		// git grep exits with 1 and no output when nothing matches.
+		if stderr.Len() == 0 && stdout.Len() == 0 {
+			return []GrepMatch{}, nil
+		}
+		return nil, fmt.Errorf("%w: %s", err, stderr.String())
+	}
+	matches := make([]GrepMatch, 0)
+	for _, l := range strings.Split(stdout.String(), "\n") {
+		if len(matches) == max {
+			break
+		}
+		// Lines are REV:PATH\0LINE\0TEXT.
+		fs := strings.SplitN(l, "\x00", 3)
+		if len(fs) != 3 {
+			continue
+		}
+		n, err := strconv.Atoi(fs[1])
+		if err != nil {
+			continue
+		}
+		matches = append(matches, GrepMatch{
+			Path: strings.TrimPrefix(fs[0], ref.Hash.String()+":"),
+			Line: n,
+			Text: fs[2],
+		})
+	}
+	return matches, nil
+}
+
 // Diff returns the diff for the given commit.
 func (r *Repository) Diff(commit *Commit) (*Diff, error) {
 	ddiff, err := r.Repository.Diff(commit.Hash.String(), DiffMaxFiles, DiffMaxFileLines, DiffMaxLineChars)
@@ -181,13 +340,15 @@ func (r *Repository) Patch(commit *Commit) (string, error) {
 }
 
 // CountCommits returns the number of commits in the repository.
-func (r *Repository) CountCommits(ref *Reference) (int64, error) {
-	return r.Repository.RevListCount([]string{ref.Name().String()})
+// Only commits touching path are counted unless path is empty.
+func (r *Repository) CountCommits(ref *Reference, path string) (int64, error) {
+	return r.Repository.RevListCount([]string{ref.Name().String()}, git.RevListCountOptions{Path: path})
 }
 
 // CommitsByPage returns the commits for a given page and size.
-func (r *Repository) CommitsByPage(ref *Reference, page, size int) (Commits, error) {
-	cs, err := r.Repository.CommitsByPage(ref.Name().String(), page, size)
+// Only commits touching path are returned unless path is empty.
+func (r *Repository) CommitsByPage(ref *Reference, path string, page, size int) (Commits, error) {
+	cs, err := r.Repository.CommitsByPage(ref.Name().String(), page, size, git.CommitsByPageOptions{Path: path})
 	if err != nil {
 		return nil, err
 	}
@@ -201,9 +362,216 @@ func (r *Repository) CommitsByPage(ref *Reference, page, size int) (Commits, err
 	return commits, nil
 }
 
+// CommitsBetween returns the commits reachable from rev but not from any of
+// the excluded revisions, newest first, up to max commits.
+func (r *Repository) CommitsBetween(rev string, exclude []string, max int) (Commits, error) {
+	args := []string{"rev-list", "--max-count=" + strconv.Itoa(max), rev}
+	for _, x := range exclude {
+		args = append(args, "^"+x)
+	}
+	out, err := git.NewCommand(args...).RunInDir(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	commits := make(Commits, 0)
+	for _, id := range strings.Fields(string(out)) {
+		c, err := r.CatFileCommit(id)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, &Commit{
+			Commit: c,
+			Hash:   Hash(id),
+		})
+	}
+	return commits, nil
+}
+
+// ChangedFiles returns the paths of the files changed between two commits.
+// Without from, it returns the files changed by to, compared to each of its
+// parents.
+func (r *Repository) ChangedFiles(from, to string) ([]string, error) {
+	args := []string{"diff", "--name-only", "-z", from, to}
+	if from == "" {
+		args = []string{"diff-tree", "-r", "-m", "--root", "--no-commit-id", "--name-only", "-z", to}
+	}
+	out, err := git.NewCommand(args...).RunInDir(r.Path)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	files := make([]string, 0)
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// GC runs git gc on the repository. It can take a while on large
+// repositories, so it isn't bound by the default command timeout.
+func (r *Repository) GC() error {
+	_, err := git.NewCommand("gc", "--quiet").RunInDirWithTimeout(time.Hour, r.Path)
+	return err
+}
+
+// LooseObjects returns the number of loose objects of the repository.
+func (r *Repository) LooseObjects(ctx context.Context) (int, error) {
+	out, err := r.runContext(ctx, "count-objects", "-v")
+	if err != nil {
+		return 0, err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.HasPrefix(line, "count: ") {
+			return strconv.Atoi(strings.TrimPrefix(line, "count: "))
+		}
+	}
+	return 0, fmt.Errorf("unexpected count-objects output: %q", out)
+}
+
+// Repack packs the loose objects of the repository into a new pack, and
+// removes the loose objects and packs made redundant by it. Unlike GC, it
+// neither consolidates existing packs nor prunes unreachable objects.
+func (r *Repository) Repack(ctx context.Context) error {
+	_, err := r.runContext(ctx, "repack", "-d", "-q")
+	return err
+}
+
+// WriteCommitGraph writes the commit-graph file of the repository for the
+// commits reachable from its refs, which speeds up walking history.
+func (r *Repository) WriteCommitGraph(ctx context.Context) error {
+	_, err := r.runContext(ctx, "commit-graph", "write", "--reachable")
+	return err
+}
+
 // UpdateServerInfo updates the repository server info.
 func (r *Repository) UpdateServerInfo() error {
 	cmd := git.NewCommand("update-server-info")
 	_, err := cmd.RunInDir(r.Path)
 	return err
 }
+
+// RangeDiff compares two versions of a series of commits using git
+// range-diff. Ranges can be given as "<range1> <range2>", "<rev1>...<rev2>",
+// or "<base> <rev1> <rev2>". Each version can have up to RangeDiffMaxCommits
+// commits, and git is killed when ctx is done.
+func (r *Repository) RangeDiff(ctx context.Context, color bool, ranges ...string) (string, error) {
+	if len(ranges) < 1 || len(ranges) > 3 {
+		return "", ErrInvalidRange
+	}
+	for _, rg := range ranges {
+		if strings.HasPrefix(rg, "-") {
+			return "", ErrInvalidRange
+		}
+	}
+	var versions [][]string
+	switch len(ranges) {
+	case 1:
+		revs := strings.SplitN(ranges[0], "...", 2)
+		if len(revs) != 2 {
+			return "", ErrInvalidRange
+		}
+		// The revs are passed to rev-list on their own, where they could be
+		// taken for flags.
+		for _, rev := range revs {
+			if rev == "" || strings.HasPrefix(rev, "-") {
+				return "", ErrInvalidRange
+			}
+		}
+		versions = [][]string{{revs[1], "^" + revs[0]}, {revs[0], "^" + revs[1]}}
+	case 2:
+		versions = [][]string{{ranges[0]}, {ranges[1]}}
+	case 3:
+		versions = [][]string{{ranges[1], "^" + ranges[0]}, {ranges[2], "^" + ranges[0]}}
+	}
+	for _, v := range versions {
+		out, err := r.runContext(ctx, append([]string{"rev-list", "--count"}, v...)...)
+		if err != nil {
+			return "", err
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil {
+			return "", err
+		}
+		if n > RangeDiffMaxCommits {
+			return "", fmt.Errorf("%w: more than %d commits", ErrRangeTooLarge, RangeDiffMaxCommits)
+		}
+	}
+	args := []string{"range-diff", "--no-color"}
+	if color {
+		args[1] = "--color=always"
+	}
+	out, err := r.runContext(ctx, append(args, ranges...)...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// Fetch fetches the branches and tags of the remote repository at url,
+// overwriting and pruning those of the repository so that it mirrors the
+// remote. git is killed when ctx is done.
+func (r *Repository) Fetch(ctx context.Context, url string) error {
+	_, err := r.runContext(ctx, "fetch", "--prune", "--no-tags", "--", url,
+		"+"+RefsHeads+"*:"+RefsHeads+"*", "+"+RefsTags+"*:"+RefsTags+"*")
+	return err
+}
+
+// RemoteHEAD returns the reference the HEAD of the remote repository at url
+// points to, e.g. "refs/heads/main".
+func (r *Repository) RemoteHEAD(ctx context.Context, url string) (string, error) {
+	out, err := r.runContext(ctx, "ls-remote", "--symref", "--", url, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if ref := strings.TrimPrefix(line, "ref: "); ref != line {
+			return strings.TrimSuffix(ref, "\tHEAD"), nil
+		}
+	}
+	return "", ErrReferenceNotFound
+}
+
+// PushContext pushes refs of the repository to the remote repository at url,
+// with refspecs such as "+refs/heads/main:refs/heads/main", or
+// ":refs/heads/main" to delete a ref. header, if set, is sent along the HTTP
+// requests to the remote, e.g. "Authorization: Basic ...". It's passed in the
+// environment so that it doesn't show in the process list. git is killed
+// when ctx is done.
+func (r *Repository) PushContext(ctx context.Context, url, header string, refspecs ...string) error {
+	var env []string
+	if header != "" {
+		env = []string{
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=" + header,
+		}
+	}
+	_, err := r.runEnv(ctx, env, append([]string{"push", "--", url}, refspecs...)...)
+	return err
+}
+
+// runContext runs git in the repository, killing it when ctx is done. git
+// never prompts for credentials.
+func (r *Repository) runContext(ctx context.Context, args ...string) ([]byte, error) {
+	return r.runEnv(ctx, nil, args...)
+}
+
+// runEnv is runContext with additional environment variables.
+func (r *Repository) runEnv(ctx context.Context, env []string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.Path
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), env...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
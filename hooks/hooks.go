@@ -0,0 +1,198 @@
+// Package hooks runs the external commands and calls the URLs configured to
+// be notified of server events.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+)
+
+// timeout is the maximum time a hook may take, retries included.
+const timeout = time.Minute
+
+// SignatureHeader is the header of the HMAC-SHA256 signature of the events
+// posted to URLs, as "sha256=" followed by the hex-encoded signature of the
+// body.
+const SignatureHeader = "X-Soft-Serve-Signature-256"
+
+// Runner runs hooks.
+type Runner struct {
+	HTTP *http.Client
+	// Retries is the number of times failed posts are retried.
+	Retries int
+	// Backoff is the delay before the first retry, doubled for each of the
+	// next ones.
+	Backoff time.Duration
+}
+
+// NewRunner returns a new hook runner.
+func NewRunner() *Runner {
+	return &Runner{
+		HTTP:    &http.Client{Timeout: timeout},
+		Retries: 3,
+		Backoff: time.Second,
+	}
+}
+
+// Run runs the hooks matching published events until ctx is done.
+func (r *Runner) Run(ctx context.Context, cfg *config.Config) {
+	for e := range cfg.Events.SubscribeDurable(ctx) {
+		files := cfg.PushedFilesFunc(e)
+		for _, h := range cfg.HooksFor(string(e.Type), e.Repo) {
+			if cfg.DeliveryDisabled(Target(h)) || !h.Filters().Match(e, files) {
+				continue
+			}
+			go func(h config.Hook, e events.Event) {
+				if err := r.deliver(ctx, cfg, h, e); err != nil {
+					log.Error("error running hook", "event", e.Type, "repo", e.Repo, "err", err)
+				}
+			}(h, e)
+		}
+	}
+}
+
+// Target returns the name identifying a hook in integration deliveries.
+func Target(h config.Hook) string {
+	if h.URL != "" {
+		return h.URL
+	}
+	return h.Command
+}
+
+// deliver fires a hook and records the delivery.
+func (r *Runner) deliver(ctx context.Context, cfg *config.Config, h config.Hook, e events.Event) error {
+	start := time.Now()
+	err := r.FireWithSecret(ctx, cfg, h, e)
+	cfg.RecordDelivery("hook", Target(h), time.Since(start), err, func() error {
+		return r.deliver(ctx, cfg, h, e)
+	})
+	return err
+}
+
+// FireWithSecret runs a hook for an event, signing the events posted to URLs
+// with the key held by the signing secret of the hook.
+func (r *Runner) FireWithSecret(ctx context.Context, cfg *config.Config, h config.Hook, e events.Event) error {
+	var key string
+	if h.SigningSecret != "" {
+		if cfg.Secrets == nil {
+			return errors.New("secrets are not configured")
+		}
+		k, err := cfg.Secrets.Get(h.SigningSecret)
+		if err != nil {
+			return fmt.Errorf("secret %q: %w", h.SigningSecret, err)
+		}
+		key = k
+	}
+	return r.Fire(ctx, h, key, e)
+}
+
+// Fire runs a hook for an event. Events posted to URLs are signed with key,
+// unless it's empty.
+func (r *Runner) Fire(ctx context.Context, h config.Hook, key string, e events.Event) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	if h.Command != "" {
+		if err := runCommand(ctx, h.Command, e, payload); err != nil {
+			return err
+		}
+	}
+	if h.URL != "" {
+		if err := r.post(ctx, h.URL, key, e.Type, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runCommand(ctx context.Context, command string, e events.Event, payload []byte) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"SOFT_SERVE_EVENT="+string(e.Type),
+		"SOFT_SERVE_REPO="+e.Repo,
+		"SOFT_SERVE_VISIBILITY="+e.Visibility,
+		"SOFT_SERVE_USER="+e.User,
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%q: %w: %s", command, err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// post posts an event to url, retrying with backoff on network errors,
+// server errors, and rate limiting.
+func (r *Runner) post(ctx context.Context, url, key string, typ events.Type, payload []byte) error {
+	backoff := r.Backoff
+	for i := 0; ; i++ {
+		retry, err := r.postOnce(ctx, url, key, typ, payload)
+		if err == nil || !retry || i >= r.Retries {
+			return err
+		}
+		log.Debug("retrying hook", "url", url, "err", err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// postOnce posts an event to url once, and returns whether a failed post can
+// be retried.
+func (r *Runner) postOnce(ctx context.Context, url, key string, typ events.Type, payload []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Soft-Serve-Event", string(typ))
+	if key != "" {
+		req.Header.Set(SignatureHeader, Sign(key, payload))
+	}
+	res, err := r.HTTP.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		retry := res.StatusCode >= 500 || res.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("%s: unexpected status %s", url, res.Status)
+	}
+	return false, nil
+}
+
+// Sign returns the value of the signature header of a payload signed with
+// key.
+func Sign(key string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/matryer/is"
+)
+
+func TestFireCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook command uses a POSIX shell")
+	}
+	is := is.New(t)
+	out := filepath.Join(t.TempDir(), "out")
+	h := config.Hook{
+		Command: `echo "$SOFT_SERVE_EVENT $SOFT_SERVE_REPO $SOFT_SERVE_VISIBILITY" > ` + out,
+	}
+	e := events.Event{Type: events.RepoVisibility, Repo: "foo", Visibility: "private"}
+	is.NoErr(NewRunner().Fire(context.Background(), h, "", e))
+	bts, err := os.ReadFile(out)
+	is.NoErr(err)
+	is.Equal(string(bts), "repo-visibility foo private\n")
+
+	is.True(NewRunner().Fire(context.Background(), config.Hook{Command: "exit 1"}, "", e) != nil)
+}
+
+func TestFireURL(t *testing.T) {
+	is := is.New(t)
+	got := make(chan events.Event, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e events.Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got <- e
+	}))
+	defer srv.Close()
+	e := events.Event{Type: events.RepoCreated, Repo: "foo"}
+	is.NoErr(NewRunner().Fire(context.Background(), config.Hook{URL: srv.URL}, "", e))
+	is.Equal((<-got).Repo, "foo")
+}
+
+func TestFireURLSignedWithRetries(t *testing.T) {
+	is := is.New(t)
+	var calls int32
+	var sig string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		bts, _ := io.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign("key", bts) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		sig = r.Header.Get(SignatureHeader)
+	}))
+	defer srv.Close()
+	r := NewRunner()
+	r.Backoff = time.Millisecond
+	e := events.Event{Type: events.Push, Repo: "foo"}
+	is.NoErr(r.Fire(context.Background(), config.Hook{URL: srv.URL}, "key", e))
+	is.Equal(atomic.LoadInt32(&calls), int32(3))
+	is.True(strings.HasPrefix(sig, "sha256="))
+
+	// Client errors aren't retried.
+	atomic.StoreInt32(&calls, 2)
+	is.True(r.Fire(context.Background(), config.Hook{URL: srv.URL}, "wrong", e) != nil)
+	is.Equal(atomic.LoadInt32(&calls), int32(3))
+}
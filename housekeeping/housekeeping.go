@@ -0,0 +1,72 @@
+// Package housekeeping garbage collects and repacks repos on the schedule of
+// the housekeeping settings.
+package housekeeping
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+)
+
+// Scheduler runs the housekeeping tasks due on repos periodically.
+type Scheduler struct {
+	cfg *config.Config
+	// Interval is the time between checks for repos due for housekeeping.
+	// Repos are garbage collected at the interval of the housekeeping
+	// settings.
+	Interval time.Duration
+	// Timeout is how long a task can take before it's stopped.
+	Timeout time.Duration
+}
+
+// NewScheduler creates a new housekeeping scheduler.
+func NewScheduler(cfg *config.Config) *Scheduler {
+	return &Scheduler{
+		cfg:      cfg,
+		Interval: 10 * time.Minute,
+		Timeout:  time.Hour,
+	}
+}
+
+// Run runs housekeeping tasks at the scheduled interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Process(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Process runs the tasks due on repos at now, one repo at a time. Failed
+// tasks are retried at the next interval.
+func (s *Scheduler) Process(ctx context.Context, now time.Time) {
+	for _, r := range s.cfg.Source.AllRepos() {
+		if ctx.Err() != nil {
+			return
+		}
+		repo := r.Repo()
+		task, err := s.cfg.HousekeepingDue(ctx, repo, now)
+		if err != nil {
+			log.Error("error checking housekeeping", "repo", repo, "err", err)
+			continue
+		}
+		if task == "" {
+			continue
+		}
+		hctx, cancel := context.WithTimeout(ctx, s.Timeout)
+		err = s.cfg.Housekeep(hctx, repo, task)
+		cancel()
+		if err != nil {
+			log.Error("error running housekeeping", "repo", repo, "task", task, "err", err)
+			continue
+		}
+		log.Debug("ran housekeeping", "repo", repo, "task", task)
+	}
+}
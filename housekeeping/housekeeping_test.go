@@ -0,0 +1,49 @@
+package housekeeping
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestProcess(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	cfg, err := config.NewConfig(&sconfig.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: t.TempDir(),
+	})
+	is.NoErr(err)
+	for _, rn := range []string{"on", "off"} {
+		_, err := cfg.Source.InitRepo(rn, true)
+		is.NoErr(err)
+	}
+	is.NoErr(cfg.SetRepoSettings("off", config.RepoSettings{
+		Features: map[config.Feature]bool{config.FeatureHousekeeping: false},
+	}))
+	ctx := context.Background()
+	s := NewScheduler(cfg)
+	now := time.Now()
+
+	// Repos are seen first, then collected once the interval has passed.
+	s.Process(ctx, now)
+	st, err := cfg.HousekeepingStatus(ctx, "on")
+	is.NoErr(err)
+	is.True(st.LastRun.IsZero())
+	s.Process(ctx, now.Add(config.DefaultHousekeepingInterval))
+	st, err = cfg.HousekeepingStatus(ctx, "on")
+	is.NoErr(err)
+	is.True(!st.LastGC.IsZero())
+	is.Equal(st.Error, "")
+	st, err = cfg.HousekeepingStatus(ctx, "off")
+	is.NoErr(err)
+	is.True(st.LastRun.IsZero())
+}
@@ -0,0 +1,190 @@
+// Package ber encodes and decodes the subset of ASN.1 BER used by LDAP:
+// single-byte tags and definite lengths.
+package ber
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Universal tags.
+const (
+	Boolean     byte = 0x01
+	Integer     byte = 0x02
+	OctetString byte = 0x04
+	Null        byte = 0x05
+	Enumerated  byte = 0x0a
+	Sequence    byte = 0x30
+	Set         byte = 0x31
+)
+
+// Tags of LDAP protocol operations, authentication choices, and filters.
+const (
+	BindRequest      byte = 0x60
+	BindResponse     byte = 0x61
+	UnbindRequest    byte = 0x42
+	SearchRequest    byte = 0x63
+	SearchEntry      byte = 0x64
+	SearchDone       byte = 0x65
+	SearchReference  byte = 0x73
+	SimpleAuth       byte = 0x80
+	FilterAnd        byte = 0xa0
+	FilterOr         byte = 0xa1
+	FilterEqual      byte = 0xa3
+	FilterSubstrings byte = 0xa4
+	FilterPresent    byte = 0x87
+	SubstringInitial byte = 0x80
+	SubstringAny     byte = 0x81
+	SubstringFinal   byte = 0x82
+)
+
+// MaxSize caps the size of decoded packets.
+const MaxSize = 16 << 20
+
+// ErrTruncated is returned for packets shorter than their length.
+var ErrTruncated = errors.New("ber: truncated packet")
+
+// Packet is a BER element: its tag, and its content.
+type Packet struct {
+	Tag   byte
+	Value []byte
+}
+
+// New returns a constructed packet with the given children.
+func New(tag byte, children ...Packet) Packet {
+	var v []byte
+	for _, c := range children {
+		v = append(v, c.Bytes()...)
+	}
+	return Packet{Tag: tag, Value: v}
+}
+
+// String returns a packet holding s.
+func String(tag byte, s string) Packet {
+	return Packet{Tag: tag, Value: []byte(s)}
+}
+
+// Int returns a packet holding n in two's complement.
+func Int(tag byte, n int64) Packet {
+	var v []byte
+	for {
+		v = append([]byte{byte(n)}, v...)
+		n >>= 8
+		if (n == 0 && v[0]&0x80 == 0) || (n == -1 && v[0]&0x80 != 0) {
+			break
+		}
+	}
+	return Packet{Tag: tag, Value: v}
+}
+
+// Bool returns a boolean packet.
+func Bool(b bool) Packet {
+	if b {
+		return Packet{Tag: Boolean, Value: []byte{0xff}}
+	}
+	return Packet{Tag: Boolean, Value: []byte{0}}
+}
+
+// Bytes returns the encoded packet.
+func (p Packet) Bytes() []byte {
+	n := len(p.Value)
+	b := []byte{p.Tag}
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	default:
+		var l []byte
+		for ; n > 0; n >>= 8 {
+			l = append([]byte{byte(n)}, l...)
+		}
+		b = append(b, 0x80|byte(len(l)))
+		b = append(b, l...)
+	}
+	return append(b, p.Value...)
+}
+
+// Int returns the integer held by the packet.
+func (p Packet) Int() (int64, error) {
+	if len(p.Value) == 0 || len(p.Value) > 8 {
+		return 0, fmt.Errorf("ber: invalid integer of %d bytes", len(p.Value))
+	}
+	n := int64(int8(p.Value[0]))
+	for _, b := range p.Value[1:] {
+		n = n<<8 | int64(b)
+	}
+	return n, nil
+}
+
+// Children decodes the content of a constructed packet.
+func (p Packet) Children() ([]Packet, error) {
+	var ps []Packet
+	for b := p.Value; len(b) > 0; {
+		c, rest, err := Parse(b)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, c)
+		b = rest
+	}
+	return ps, nil
+}
+
+// Parse decodes the packet at the start of b, and returns the bytes after
+// it.
+func Parse(b []byte) (Packet, []byte, error) {
+	if len(b) < 2 {
+		return Packet{}, nil, ErrTruncated
+	}
+	n, hdr := int(b[1]), 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 4 || len(b) < 2+size {
+			return Packet{}, nil, fmt.Errorf("ber: invalid length of %d bytes", size)
+		}
+		n = 0
+		for _, c := range b[2 : 2+size] {
+			n = n<<8 | int(c)
+		}
+		hdr += size
+	}
+	if n < 0 || n > MaxSize || len(b)-hdr < n {
+		return Packet{}, nil, ErrTruncated
+	}
+	return Packet{Tag: b[0], Value: b[hdr : hdr+n]}, b[hdr+n:], nil
+}
+
+// Read reads a packet from r.
+func Read(r io.Reader) (Packet, error) {
+	hdr := make([]byte, 2, 6)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return Packet{}, err
+	}
+	if size := int(hdr[1] & 0x7f); hdr[1]&0x80 != 0 {
+		if size == 0 || size > 4 {
+			return Packet{}, fmt.Errorf("ber: invalid length of %d bytes", size)
+		}
+		hdr = hdr[:2+size]
+		if _, err := io.ReadFull(r, hdr[2:]); err != nil {
+			return Packet{}, err
+		}
+	}
+	n := int(hdr[1])
+	if n&0x80 != 0 {
+		n = 0
+		for _, c := range hdr[2:] {
+			n = n<<8 | int(c)
+		}
+	}
+	if n < 0 || n > MaxSize {
+		return Packet{}, fmt.Errorf("ber: packet of %d bytes is too large", n)
+	}
+	v := make([]byte, n)
+	if _, err := io.ReadFull(r, v); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = ErrTruncated
+		}
+		return Packet{}, err
+	}
+	return Packet{Tag: hdr[0], Value: v}, nil
+}
@@ -0,0 +1,286 @@
+// Package ldap is a minimal LDAPv3 client: it binds with a DN and password,
+// and searches a directory, which is what looking up users and their groups
+// takes.
+package ldap
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/ldap/internal/ber"
+)
+
+// Result codes of LDAP operations.
+const (
+	Success            = 0
+	NoSuchObject       = 32
+	InvalidCredentials = 49
+)
+
+// ErrUnexpectedResponse is returned for responses that aren't those of the
+// request.
+var ErrUnexpectedResponse = errors.New("ldap: unexpected response")
+
+// Error is an LDAP operation that didn't succeed.
+type Error struct {
+	Code    int
+	Message string
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("ldap: result code %d", e.Code)
+	}
+	return fmt.Sprintf("ldap: result code %d: %s", e.Code, e.Message)
+}
+
+// Entry is an entry of a directory.
+type Entry struct {
+	DN string
+	// Attributes maps the names of attributes to their values.
+	Attributes map[string][]string
+}
+
+// Values returns the values of an attribute, whose name is case
+// insensitive.
+func (e Entry) Values(attr string) []string {
+	for name, vs := range e.Attributes {
+		if strings.EqualFold(name, attr) {
+			return vs
+		}
+	}
+	return nil
+}
+
+// Filter is a search filter.
+type Filter struct {
+	p ber.Packet
+}
+
+// Equal matches entries with an attribute equal to value.
+func Equal(attr, value string) Filter {
+	return Filter{ber.New(ber.FilterEqual,
+		ber.String(ber.OctetString, attr),
+		ber.String(ber.OctetString, value),
+	)}
+}
+
+// Contains matches entries with an attribute containing value.
+func Contains(attr, value string) Filter {
+	return Filter{ber.New(ber.FilterSubstrings,
+		ber.String(ber.OctetString, attr),
+		ber.New(ber.Sequence, ber.String(ber.SubstringAny, value)),
+	)}
+}
+
+// Present matches entries with an attribute.
+func Present(attr string) Filter {
+	return Filter{ber.String(ber.FilterPresent, attr)}
+}
+
+// And matches entries matching all filters.
+func And(filters ...Filter) Filter {
+	ps := make([]ber.Packet, len(filters))
+	for i, f := range filters {
+		ps[i] = f.p
+	}
+	return Filter{ber.New(ber.FilterAnd, ps...)}
+}
+
+// Conn is a connection to an LDAP server. It isn't safe for concurrent use.
+type Conn struct {
+	conn    net.Conn
+	timeout time.Duration
+	id      int64
+}
+
+// Dial connects to the server of an ldap:// or ldaps:// URL. Operations
+// time out after timeout.
+func Dial(rawURL string, timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	d := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	switch u.Scheme {
+	case "ldap":
+		conn, err = d.Dial("tcp", hostPort(u.Host, "389"))
+	case "ldaps":
+		conn, err = tls.DialWithDialer(d, "tcp", hostPort(u.Host, "636"), &tls.Config{
+			ServerName: u.Hostname(),
+			MinVersion: tls.VersionTLS12,
+		})
+	default:
+		return nil, fmt.Errorf("ldap: unsupported scheme %q, must be ldap or ldaps", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Conn{conn: conn, timeout: timeout}, nil
+}
+
+// hostPort adds the default port to hosts without one.
+func hostPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return net.JoinHostPort(strings.Trim(host, "[]"), port)
+}
+
+// Close unbinds and closes the connection.
+func (c *Conn) Close() error {
+	_ = c.send(ber.Packet{Tag: ber.UnbindRequest})
+	return c.conn.Close()
+}
+
+// Bind authenticates with a DN and password. An empty DN binds anonymously.
+func (c *Conn) Bind(dn, password string) error {
+	err := c.send(ber.New(ber.BindRequest,
+		ber.Int(ber.Integer, 3),
+		ber.String(ber.OctetString, dn),
+		ber.String(ber.SimpleAuth, password),
+	))
+	if err != nil {
+		return err
+	}
+	op, err := c.receive()
+	if err != nil {
+		return err
+	}
+	if op.Tag != ber.BindResponse {
+		return ErrUnexpectedResponse
+	}
+	return result(op)
+}
+
+// Search returns the entries under base matching filter, with the given
+// attributes, or all of them if none are given.
+func (c *Conn) Search(base string, filter Filter, attrs ...string) ([]Entry, error) {
+	as := make([]ber.Packet, len(attrs))
+	for i, a := range attrs {
+		as[i] = ber.String(ber.OctetString, a)
+	}
+	err := c.send(ber.New(ber.SearchRequest,
+		ber.String(ber.OctetString, base),
+		ber.Int(ber.Enumerated, 2), // whole subtree
+		ber.Int(ber.Enumerated, 0), // never dereference aliases
+		ber.Int(ber.Integer, 0),    // no size limit
+		ber.Int(ber.Integer, int64(c.timeout/time.Second)),
+		ber.Bool(false),
+		filter.p,
+		ber.New(ber.Sequence, as...),
+	))
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for {
+		op, err := c.receive()
+		if err != nil {
+			return nil, err
+		}
+		switch op.Tag {
+		case ber.SearchEntry:
+			e, err := parseEntry(op)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		case ber.SearchReference:
+			// Referrals to other servers aren't followed.
+		case ber.SearchDone:
+			return entries, result(op)
+		default:
+			return nil, ErrUnexpectedResponse
+		}
+	}
+}
+
+// send sends a request as the next message.
+func (c *Conn) send(op ber.Packet) error {
+	c.id++
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(ber.New(ber.Sequence, ber.Int(ber.Integer, c.id), op).Bytes())
+	return err
+}
+
+// receive returns the operation of the next response to the last request.
+func (c *Conn) receive() (ber.Packet, error) {
+	msg, err := ber.Read(c.conn)
+	if err != nil {
+		return ber.Packet{}, err
+	}
+	ps, err := msg.Children()
+	if err != nil {
+		return ber.Packet{}, err
+	}
+	if msg.Tag != ber.Sequence || len(ps) < 2 {
+		return ber.Packet{}, ErrUnexpectedResponse
+	}
+	if id, err := ps[0].Int(); err != nil || id != c.id {
+		return ber.Packet{}, ErrUnexpectedResponse
+	}
+	return ps[1], nil
+}
+
+// result returns the error of an LDAP result, if any.
+func result(op ber.Packet) error {
+	ps, err := op.Children()
+	if err != nil {
+		return err
+	}
+	if len(ps) < 3 {
+		return ErrUnexpectedResponse
+	}
+	code, err := ps[0].Int()
+	if err != nil {
+		return err
+	}
+	if code != Success {
+		return &Error{Code: int(code), Message: string(ps[2].Value)}
+	}
+	return nil
+}
+
+// parseEntry decodes a search result entry.
+func parseEntry(op ber.Packet) (Entry, error) {
+	ps, err := op.Children()
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(ps) < 2 {
+		return Entry{}, ErrUnexpectedResponse
+	}
+	e := Entry{DN: string(ps[0].Value), Attributes: map[string][]string{}}
+	attrs, err := ps[1].Children()
+	if err != nil {
+		return Entry{}, err
+	}
+	for _, a := range attrs {
+		parts, err := a.Children()
+		if err != nil {
+			return Entry{}, err
+		}
+		if len(parts) < 2 {
+			return Entry{}, ErrUnexpectedResponse
+		}
+		vals, err := parts[1].Children()
+		if err != nil {
+			return Entry{}, err
+		}
+		name := string(parts[0].Value)
+		for _, v := range vals {
+			e.Attributes[name] = append(e.Attributes[name], string(v.Value))
+		}
+	}
+	return e, nil
+}
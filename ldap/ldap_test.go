@@ -0,0 +1,59 @@
+package ldap_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/ldap"
+	"github.com/charmbracelet/soft-serve/ldap/ldaptest"
+	"github.com/matryer/is"
+)
+
+func TestSearch(t *testing.T) {
+	is := is.New(t)
+	d := ldaptest.New(t, "cn=admin,dc=example,dc=com", "secret")
+	d.Add(ldap.Entry{
+		DN: "uid=frankie,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{
+			"uid":          {"frankie"},
+			"sshPublicKey": {"ssh-ed25519 AAAAfrankie frankie@laptop"},
+			"memberOf":     {"cn=devs,ou=groups,dc=example,dc=com", "cn=ops,ou=groups,dc=example,dc=com"},
+			"mail":         {strings.Repeat("x", 300) + "@example.com"},
+		},
+	})
+	d.Add(ldap.Entry{
+		DN:         "uid=bea,ou=people,dc=example,dc=com",
+		Attributes: map[string][]string{"uid": {"bea"}},
+	})
+
+	c, err := ldap.Dial(d.URL(), time.Second)
+	is.NoErr(err)
+	defer c.Close()
+	var lerr *ldap.Error
+	is.True(errors.As(c.Bind("cn=admin,dc=example,dc=com", "wrong"), &lerr))
+	is.Equal(lerr.Code, ldap.InvalidCredentials)
+	is.NoErr(c.Bind("cn=admin,dc=example,dc=com", "secret"))
+
+	es, err := c.Search("ou=people,dc=example,dc=com",
+		ldap.And(ldap.Present("uid"), ldap.Contains("sshPublicKey", "AAAAfrankie")),
+		"uid", "memberOf", "mail")
+	is.NoErr(err)
+	is.Equal(len(es), 1)
+	is.Equal(es[0].DN, "uid=frankie,ou=people,dc=example,dc=com")
+	is.Equal(es[0].Values("UID"), []string{"frankie"})
+	is.Equal(len(es[0].Values("memberof")), 2)
+	is.Equal(len(es[0].Values("mail")[0]), 312) // long form lengths
+	is.Equal(es[0].Values("sshPublicKey"), nil) // not requested
+
+	es, err = c.Search("dc=example,dc=com", ldap.Equal("uid", "Bea"))
+	is.NoErr(err)
+	is.Equal(len(es), 1)
+	es, err = c.Search("dc=example,dc=com", ldap.Equal("uid", "nobody"))
+	is.NoErr(err)
+	is.Equal(len(es), 0)
+
+	_, err = ldap.Dial("http://localhost", time.Second)
+	is.True(err != nil)
+}
@@ -0,0 +1,224 @@
+// Package ldaptest provides an LDAP directory for tests. It serves simple
+// binds and searches of the entries added to it:
+//
+//	d := ldaptest.New(t, "cn=admin,dc=example,dc=com", "secret")
+//	d.Add(ldap.Entry{DN: "uid=frankie,dc=example,dc=com", Attributes: ...})
+//	conn, err := ldap.Dial(d.URL(), time.Second)
+package ldaptest
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/ldap"
+	"github.com/charmbracelet/soft-serve/ldap/internal/ber"
+)
+
+// Directory is a running test directory.
+type Directory struct {
+	l        net.Listener
+	bindDN   string
+	password string
+
+	mtx      sync.Mutex
+	entries  []ldap.Entry
+	searches int
+}
+
+// New starts a directory that accepts binds with the given DN and password.
+// It's stopped when the test finishes.
+func New(t testing.TB, bindDN, password string) *Directory {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Directory{l: l, bindDN: bindDN, password: password}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go d.serve(conn)
+		}
+	}()
+	return d
+}
+
+// URL returns the ldap:// URL of the directory.
+func (d *Directory) URL() string {
+	return "ldap://" + d.l.Addr().String()
+}
+
+// Add adds an entry to the directory.
+func (d *Directory) Add(e ldap.Entry) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.entries = append(d.entries, e)
+}
+
+// Remove removes the entry with the given DN.
+func (d *Directory) Remove(dn string) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	es := d.entries[:0]
+	for _, e := range d.entries {
+		if !strings.EqualFold(e.DN, dn) {
+			es = append(es, e)
+		}
+	}
+	d.entries = es
+}
+
+// Searches returns the number of searches served.
+func (d *Directory) Searches() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.searches
+}
+
+func (d *Directory) serve(conn net.Conn) {
+	defer conn.Close()
+	bound := false
+	for {
+		msg, err := ber.Read(conn)
+		if err != nil {
+			return
+		}
+		ps, err := msg.Children()
+		if err != nil || len(ps) < 2 {
+			return
+		}
+		id, _ := ps[0].Int()
+		reply := func(ops ...ber.Packet) {
+			for _, op := range ops {
+				_, _ = conn.Write(ber.New(ber.Sequence, ber.Int(ber.Integer, id), op).Bytes())
+			}
+		}
+		op := ps[1]
+		switch op.Tag {
+		case ber.BindRequest:
+			args, err := op.Children()
+			if err != nil || len(args) < 3 {
+				return
+			}
+			bound = string(args[1].Value) == d.bindDN && string(args[2].Value) == d.password
+			code := ldap.Success
+			if !bound {
+				code = ldap.InvalidCredentials
+			}
+			reply(ldapResult(ber.BindResponse, code))
+		case ber.SearchRequest:
+			args, err := op.Children()
+			if err != nil || len(args) < 8 {
+				return
+			}
+			if !bound {
+				reply(ldapResult(ber.SearchDone, ldap.InvalidCredentials))
+				continue
+			}
+			var attrs []string
+			as, _ := args[7].Children()
+			for _, a := range as {
+				attrs = append(attrs, string(a.Value))
+			}
+			reply(append(d.search(string(args[0].Value), args[6], attrs), ldapResult(ber.SearchDone, ldap.Success))...)
+		default:
+			return
+		}
+	}
+}
+
+// search returns the result entries of the entries under base matching
+// filter.
+func (d *Directory) search(base string, filter ber.Packet, attrs []string) []ber.Packet {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.searches++
+	var ops []ber.Packet
+	for _, e := range d.entries {
+		if !strings.HasSuffix(strings.ToLower(e.DN), strings.ToLower(base)) || !match(e, filter) {
+			continue
+		}
+		var as []ber.Packet
+		for name, vs := range e.Attributes {
+			if len(attrs) > 0 && !containsFold(attrs, name) {
+				continue
+			}
+			vals := make([]ber.Packet, len(vs))
+			for i, v := range vs {
+				vals[i] = ber.String(ber.OctetString, v)
+			}
+			as = append(as, ber.New(ber.Sequence, ber.String(ber.OctetString, name), ber.New(ber.Set, vals...)))
+		}
+		ops = append(ops, ber.New(ber.SearchEntry, ber.String(ber.OctetString, e.DN), ber.New(ber.Sequence, as...)))
+	}
+	return ops
+}
+
+// match returns whether an entry matches a filter.
+func match(e ldap.Entry, f ber.Packet) bool {
+	if f.Tag == ber.FilterPresent {
+		return len(e.Values(string(f.Value))) > 0
+	}
+	ps, err := f.Children()
+	if err != nil {
+		return false
+	}
+	switch f.Tag {
+	case ber.FilterAnd, ber.FilterOr:
+		for _, c := range ps {
+			if match(e, c) != (f.Tag == ber.FilterAnd) {
+				return f.Tag != ber.FilterAnd
+			}
+		}
+		return f.Tag == ber.FilterAnd
+	case ber.FilterEqual:
+		return len(ps) == 2 && containsFold(e.Values(string(ps[0].Value)), string(ps[1].Value))
+	case ber.FilterSubstrings:
+		if len(ps) != 2 {
+			return false
+		}
+		subs, _ := ps[1].Children()
+		for _, v := range e.Values(string(ps[0].Value)) {
+			ok := true
+			for _, s := range subs {
+				sub := strings.ToLower(string(s.Value))
+				v := strings.ToLower(v)
+				switch s.Tag {
+				case ber.SubstringInitial:
+					ok = ok && strings.HasPrefix(v, sub)
+				case ber.SubstringAny:
+					ok = ok && strings.Contains(v, sub)
+				case ber.SubstringFinal:
+					ok = ok && strings.HasSuffix(v, sub)
+				}
+			}
+			if ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func ldapResult(tag byte, code int) ber.Packet {
+	return ber.New(tag,
+		ber.Int(ber.Enumerated, int64(code)),
+		ber.String(ber.OctetString, ""),
+		ber.String(ber.OctetString, ""),
+	)
+}
+
+func containsFold(ss []string, s string) bool {
+	for _, v := range ss {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,255 @@
+// Package metrics exposes server metrics, such as session and git operation
+// counts, in the Prometheus text format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// kind is the Prometheus type of a metric.
+type kind string
+
+const (
+	counterKind   kind = "counter"
+	gaugeKind     kind = "gauge"
+	histogramKind kind = "histogram"
+)
+
+// DefaultBuckets are the upper bounds of the buckets of latency histograms,
+// in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+var (
+	regMtx   sync.Mutex
+	registry = make(map[string]*metric)
+)
+
+// metric is a metric with a value, or a histogram, by label values.
+type metric struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mtx    sync.Mutex
+	series map[string]*series
+}
+
+// series is the value of a metric for label values.
+type series struct {
+	labels []string
+	value  float64
+	// counts are the histogram observations by bucket, sum their sum.
+	counts []uint64
+	sum    float64
+}
+
+// register creates and registers a metric. Registering a name twice panics,
+// like defining a variable twice.
+func register(name, help string, k kind, buckets []float64, labels []string) *metric {
+	m := &metric{
+		name:    name,
+		help:    help,
+		kind:    k,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	regMtx.Lock()
+	defer regMtx.Unlock()
+	if _, ok := registry[name]; ok {
+		panic("metrics: duplicate metric " + name)
+	}
+	registry[name] = m
+	return m
+}
+
+// get returns the series of label values lvs, creating it if needed. The
+// caller must hold the lock.
+func (m *metric) get(lvs []string) *series {
+	if len(lvs) != len(m.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", m.name, len(m.labels), len(lvs)))
+	}
+	key := strings.Join(lvs, "\xff")
+	s, ok := m.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), lvs...)}
+		if m.kind == histogramKind {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// add adds v to the value of the series of lvs.
+func (m *metric) add(v float64, lvs []string) {
+	m.mtx.Lock()
+	m.get(lvs).value += v
+	m.mtx.Unlock()
+}
+
+// Counter is a value that only goes up, such as a number of requests.
+type Counter struct {
+	m *metric
+}
+
+// NewCounter registers a counter with the given label names.
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{register(name, help, counterKind, nil, labels)}
+}
+
+// Inc increments the counter of the label values lvs.
+func (c *Counter) Inc(lvs ...string) {
+	c.m.add(1, lvs)
+}
+
+// Add adds v, which must not be negative, to the counter of the label values
+// lvs.
+func (c *Counter) Add(v float64, lvs ...string) {
+	if v < 0 {
+		panic("metrics: counters can't decrease")
+	}
+	c.m.add(v, lvs)
+}
+
+// Gauge is a value that goes up and down, such as a number of open sessions.
+type Gauge struct {
+	m *metric
+}
+
+// NewGauge registers a gauge with the given label names.
+func NewGauge(name, help string, labels ...string) *Gauge {
+	return &Gauge{register(name, help, gaugeKind, nil, labels)}
+}
+
+// Inc increments the gauge of the label values lvs.
+func (g *Gauge) Inc(lvs ...string) {
+	g.m.add(1, lvs)
+}
+
+// Dec decrements the gauge of the label values lvs.
+func (g *Gauge) Dec(lvs ...string) {
+	g.m.add(-1, lvs)
+}
+
+// Histogram counts observations, such as latencies, in buckets.
+type Histogram struct {
+	m *metric
+}
+
+// NewHistogram registers a histogram with the given bucket upper bounds,
+// sorted in increasing order, and label names.
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{register(name, help, histogramKind, buckets, labels)}
+}
+
+// Observe records v in the histogram of the label values lvs.
+func (h *Histogram) Observe(v float64, lvs ...string) {
+	h.m.mtx.Lock()
+	defer h.m.mtx.Unlock()
+	s := h.m.get(lvs)
+	for i, b := range h.m.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.value++
+	s.sum += v
+}
+
+// Write writes all metrics in the Prometheus text format, sorted by name.
+func Write(w io.Writer) error {
+	regMtx.Lock()
+	ms := make([]*metric, 0, len(registry))
+	for _, m := range registry {
+		ms = append(ms, m)
+	}
+	regMtx.Unlock()
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].name < ms[j].name
+	})
+	var b strings.Builder
+	for _, m := range ms {
+		m.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// write writes the metric in the Prometheus text format.
+func (m *metric) write(b *strings.Builder) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", m.name, escapeHelp(m.help), m.name, m.kind)
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	keys := make([]string, 0, len(m.series))
+	for k := range m.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		s := m.series[k]
+		if m.kind != histogramKind {
+			fmt.Fprintf(b, "%s%s %s\n", m.name, labelPairs(m.labels, s.labels, "", ""), formatValue(s.value))
+			continue
+		}
+		for i, ub := range m.buckets {
+			fmt.Fprintf(b, "%s_bucket%s %d\n", m.name, labelPairs(m.labels, s.labels, "le", formatValue(ub)), s.counts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket%s %s\n", m.name, labelPairs(m.labels, s.labels, "le", "+Inf"), formatValue(s.value))
+		fmt.Fprintf(b, "%s_sum%s %s\n", m.name, labelPairs(m.labels, s.labels, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %s\n", m.name, labelPairs(m.labels, s.labels, "", ""), formatValue(s.value))
+	}
+}
+
+// labelPairs formats label names and values, and an extra label if extra
+// isn't empty, as {name="value",...}.
+func labelPairs(names, values []string, extra, extraValue string) string {
+	if len(names) == 0 && extra == "" {
+		return ""
+	}
+	pairs := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, n, labelEscaper.Replace(values[i])))
+	}
+	if extra != "" {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra, extraValue))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeHelp escapes backslashes and newlines of help texts.
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// formatValue formats a sample value.
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler returns a handler serving the metrics in the Prometheus text
+// format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = Write(w)
+	})
+}
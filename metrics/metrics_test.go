@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestWrite(t *testing.T) {
+	is := is.New(t)
+	c := NewCounter("test_requests_total", "Requests.\nBy path.", "path")
+	g := NewGauge("test_sessions", "Open sessions.")
+	h := NewHistogram("test_duration_seconds", "Durations.", []float64{0.1, 1}, "op")
+	c.Inc("/a")
+	c.Add(2, "/a")
+	c.Inc(`quote"back\slash`)
+	g.Inc()
+	g.Inc()
+	g.Dec()
+	h.Observe(0.05, "get")
+	h.Observe(0.5, "get")
+	h.Observe(5, "get")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	is.Equal(w.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8")
+	out := w.Body.String()
+	for _, want := range []string{
+		"# HELP test_requests_total Requests.\\nBy path.\n# TYPE test_requests_total counter\n",
+		"test_requests_total{path=\"/a\"} 3\n",
+		`test_requests_total{path="quote\"back\\slash"} 1` + "\n",
+		"# TYPE test_sessions gauge\ntest_sessions 1\n",
+		"# TYPE test_duration_seconds histogram\n",
+		"test_duration_seconds_bucket{op=\"get\",le=\"0.1\"} 1\n",
+		"test_duration_seconds_bucket{op=\"get\",le=\"1\"} 2\n",
+		"test_duration_seconds_bucket{op=\"get\",le=\"+Inf\"} 3\n",
+		"test_duration_seconds_sum{op=\"get\"} 5.55\n",
+		"test_duration_seconds_count{op=\"get\"} 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics are missing %q:\n%s", want, out)
+		}
+	}
+	// Metrics are sorted by name.
+	is.True(strings.Index(out, "test_duration_seconds") < strings.Index(out, "test_requests_total"))
+}
+
+func TestDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a metric twice should panic")
+		}
+	}()
+	NewCounter("test_dup_total", "Dup.")
+	NewCounter("test_dup_total", "Dup.")
+}
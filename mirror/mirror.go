@@ -0,0 +1,65 @@
+// Package mirror syncs mirrors from their upstream periodically, and pushes
+// repos to their push mirrors.
+package mirror
+
+import (
+	"context"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+)
+
+// Scheduler syncs the mirrors that are due periodically.
+type Scheduler struct {
+	cfg *config.Config
+	// Interval is the time between checks for mirrors due to sync. Each
+	// mirror syncs at its own interval.
+	Interval time.Duration
+	// Timeout is how long a sync can take before it's stopped.
+	Timeout time.Duration
+}
+
+// NewScheduler creates a new mirror scheduler.
+func NewScheduler(cfg *config.Config) *Scheduler {
+	return &Scheduler{
+		cfg:      cfg,
+		Interval: time.Minute,
+		Timeout:  30 * time.Minute,
+	}
+}
+
+// Run syncs mirrors at the scheduled interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.Process(ctx, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// Process syncs the mirrors due to sync at now, one at a time. Failed syncs
+// are retried at the next interval of the mirror.
+func (s *Scheduler) Process(ctx context.Context, now time.Time) {
+	for _, m := range s.cfg.Mirrors() {
+		if ctx.Err() != nil {
+			return
+		}
+		if !m.Due(now) {
+			continue
+		}
+		sctx, cancel := context.WithTimeout(ctx, s.Timeout)
+		err := s.cfg.SyncMirror(sctx, m.Repo)
+		cancel()
+		if err != nil {
+			log.Error("error syncing mirror", "repo", m.Repo, "url", m.URL, "err", err)
+			continue
+		}
+		log.Debug("synced mirror", "repo", m.Repo, "url", m.URL)
+	}
+}
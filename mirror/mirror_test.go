@@ -0,0 +1,65 @@
+package mirror
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestProcess(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	cfg, err := config.NewConfig(&sconfig.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	upstream := t.TempDir()
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git(upstream, "init", "-q")
+	git(upstream, "commit", "-q", "--allow-empty", "-m", "first")
+	// The mirror is created on its first sync.
+	cd := t.TempDir()
+	git(cd, "clone", "-q", filepath.Join(cfg.Source.Dir(), "config"), ".")
+	yaml := "repos:\n  - name: Mirror\n    repo: mirror\n    mirror: " + upstream + "\n"
+	is.NoErr(os.WriteFile(filepath.Join(cd, "config.yaml"), []byte(yaml), 0o644))
+	git(cd, "commit", "-q", "-am", "Mirror upstream")
+	git(cd, "push", "-q", "origin", "HEAD")
+	is.NoErr(cfg.Reload())
+
+	s := NewScheduler(cfg)
+	now := time.Now()
+	s.Process(context.Background(), now)
+	cfg.WaitPushes()
+	m, ok := cfg.MirrorStatus("mirror")
+	is.True(ok)
+	is.Equal(m.Error, "")
+	is.True(!m.LastSync.IsZero())
+	r, err := cfg.Source.GetRepo("mirror")
+	is.NoErr(err)
+	is.True(!r.IsEmpty())
+
+	// Mirrors sync once per interval.
+	s.Process(context.Background(), now.Add(time.Minute))
+	m2, _ := cfg.MirrorStatus("mirror")
+	is.Equal(m2.LastAttempt, m.LastAttempt)
+	s.Process(context.Background(), m.LastAttempt.Add(config.DefaultMirrorInterval))
+	m2, _ = cfg.MirrorStatus("mirror")
+	is.True(m2.LastAttempt.After(m.LastAttempt))
+}
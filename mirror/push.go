@@ -0,0 +1,100 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+)
+
+// Pusher pushes the refs updated by pushes to the push mirrors of their
+// repo.
+type Pusher struct {
+	// Timeout is how long a push can take before it's stopped.
+	Timeout time.Duration
+
+	mtx sync.Mutex
+	// locks serialize the pushes to each push mirror.
+	locks map[string]*sync.Mutex
+	// failing are the push mirrors whose last push failed.
+	failing map[string]bool
+}
+
+// NewPusher creates a new push mirror pusher.
+func NewPusher() *Pusher {
+	return &Pusher{
+		Timeout: 10 * time.Minute,
+		locks:   make(map[string]*sync.Mutex),
+		failing: make(map[string]bool),
+	}
+}
+
+// Run pushes the refs updated by pushes to the push mirrors of their repo
+// until ctx is done. Each push mirror is pushed to in the background, one
+// push at a time.
+func (p *Pusher) Run(ctx context.Context, cfg *config.Config) {
+	for e := range cfg.Events.SubscribeDurable(ctx) {
+		if e.Type != events.Push || e.Ref == "" || e.Replayed || e.Test {
+			continue
+		}
+		for _, m := range cfg.RepoPushMirrors(e.Repo) {
+			if cfg.DeliveryDisabled(PushTarget(e.Repo, m)) {
+				continue
+			}
+			go p.push(ctx, cfg, e.Repo, m, e.Ref)
+		}
+	}
+}
+
+// PushTarget returns the name identifying the push mirror of a repo in
+// integration deliveries.
+func PushTarget(repo string, m config.PushMirror) string {
+	return fmt.Sprintf("%s %s", repo, m)
+}
+
+// push pushes a ref to a push mirror and records the delivery. A
+// MirrorFailed event is published when the push mirror starts failing.
+func (p *Pusher) push(ctx context.Context, cfg *config.Config, repo string, m config.PushMirror, ref string) error {
+	target := PushTarget(repo, m)
+	p.mtx.Lock()
+	lock, ok := p.locks[target]
+	if !ok {
+		lock = &sync.Mutex{}
+		p.locks[target] = lock
+	}
+	p.mtx.Unlock()
+	lock.Lock()
+	defer lock.Unlock()
+
+	logger := log.With("repo", repo, "ref", ref, "url", m.String())
+	start := time.Now()
+	pctx, cancel := context.WithTimeout(ctx, p.Timeout)
+	err := cfg.PushToMirror(pctx, repo, m, ref)
+	cancel()
+	cfg.RecordDelivery("push-mirror", target, time.Since(start), err, func() error {
+		return p.push(ctx, cfg, repo, m, ref)
+	})
+
+	p.mtx.Lock()
+	// Push mirrors that keep failing are only reported once.
+	failed := err != nil && !p.failing[target]
+	p.failing[target] = err != nil
+	p.mtx.Unlock()
+	if failed {
+		cfg.Events.Publish(events.Event{
+			Type:  events.MirrorFailed,
+			Repo:  repo,
+			Error: fmt.Sprintf("push to %s: %s", m, err),
+		})
+	}
+	if err != nil {
+		logger.Error("error pushing to push mirror", "err", err)
+		return err
+	}
+	logger.Debug("pushed to push mirror")
+	return nil
+}
@@ -0,0 +1,99 @@
+package mirror
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func TestPush(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	cfg, err := config.NewConfig(&sconfig.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	git := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=a", "-c", "user.email=a@b"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	r, err := cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	work := t.TempDir()
+	git(work, "init", "-q", "-b", "main")
+	git(work, "commit", "-q", "--allow-empty", "-m", "first")
+	git(work, "push", "-q", r.Path(), "main", "main:feature")
+	is.NoErr(r.SetHEAD("main"))
+	is.NoErr(cfg.Source.LoadRepo("repo"))
+	target := t.TempDir()
+	git(target, "init", "-q", "--bare")
+
+	cd := t.TempDir()
+	git(cd, "clone", "-q", filepath.Join(cfg.Source.Dir(), "config"), ".")
+	yaml := `repos:
+  - repo: repo
+    push-mirrors:
+      - url: ` + target + `
+      - url: ` + filepath.Join(t.TempDir(), "missing") + `
+      - url: ssh://git@example.com/repo.git
+        credentials-secret: mirror
+`
+	is.NoErr(os.WriteFile(filepath.Join(cd, "config.yaml"), []byte(yaml), 0o644))
+	git(cd, "commit", "-q", "-am", "Push mirror")
+	git(cd, "push", "-q", "origin", "HEAD")
+	is.NoErr(cfg.Reload())
+	ms := cfg.RepoPushMirrors("repo")
+	is.Equal(len(ms), 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sub := cfg.Events.Subscribe(ctx)
+	p := NewPusher()
+	is.NoErr(p.push(ctx, cfg, "repo", ms[0], "refs/heads/main"))
+	is.NoErr(p.push(ctx, cfg, "repo", ms[0], "refs/heads/feature"))
+	is.Equal(git(target, "rev-parse", "main"), git(work, "rev-parse", "main"))
+	is.Equal(git(target, "rev-parse", "feature"), git(work, "rev-parse", "main"))
+
+	// Refs deleted from the repo are deleted from the mirror.
+	git(work, "push", "-q", r.Path(), ":feature")
+	is.NoErr(p.push(ctx, cfg, "repo", ms[0], "refs/heads/feature"))
+	is.Equal(git(target, "for-each-ref", "--format=%(refname)"), "refs/heads/main")
+
+	// Failures are recorded in the deliveries, and reported once.
+	is.True(p.push(ctx, cfg, "repo", ms[1], "refs/heads/main") != nil)
+	is.True(p.push(ctx, cfg, "repo", ms[1], "refs/heads/main") != nil)
+	err = p.push(ctx, cfg, "repo", ms[2], "refs/heads/main")
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "only supported for HTTP remotes"))
+	ds := cfg.Deliveries()
+	is.Equal(len(ds), 3)
+	for _, d := range ds {
+		is.Equal(d.Kind, "push-mirror")
+		is.Equal(d.Failed(), d.Target != PushTarget("repo", ms[0]))
+	}
+	failed := 0
+	for len(sub) > 0 {
+		if e := <-sub; e.Type == events.MirrorFailed {
+			is.Equal(e.Repo, "repo")
+			failed++
+		}
+	}
+	is.Equal(failed, 2)
+}
@@ -0,0 +1,361 @@
+// Package oidc signs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE, and verifies the ID tokens it returns.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxResponse caps the size of the responses of the provider.
+const maxResponse = 1 << 20
+
+var (
+	// ErrInvalidToken is returned for ID tokens that don't verify.
+	ErrInvalidToken = errors.New("invalid ID token")
+	// ErrUnknownKey is returned for ID tokens signed with a key the
+	// provider doesn't publish.
+	ErrUnknownKey = errors.New("ID token signed with an unknown key")
+)
+
+// Provider is an OpenID Connect provider. Its endpoints and keys are
+// discovered from its issuer URL on first use.
+type Provider struct {
+	// Issuer is the issuer URL of the provider.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the URL the provider sends users back to with a code.
+	RedirectURL string
+	// Scopes are requested on top of "openid".
+	Scopes []string
+	HTTP   *http.Client
+
+	mtx  sync.Mutex
+	meta *metadata
+	keys map[string]crypto.PublicKey
+}
+
+// metadata is the discovery document of a provider.
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Claims are the claims of a verified ID token.
+type Claims map[string]interface{}
+
+// String returns a string claim, or "".
+func (c Claims) String(name string) string {
+	s, _ := c[name].(string)
+	return s
+}
+
+// NewProvider returns a provider with the given issuer and client.
+func NewProvider(issuer, clientID, clientSecret, redirectURL string, scopes []string) *Provider {
+	return &Provider{
+		Issuer:       strings.TrimSuffix(issuer, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		Scopes:       scopes,
+		HTTP:         &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// RandomString returns a random URL-safe string, for states, nonces, and
+// PKCE verifiers.
+func RandomString() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// AuthCodeURL returns the URL to send users to to sign in. The provider
+// sends them back to the redirect URL with state, and a code to pass to
+// Exchange with the same nonce and verifier.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error) {
+	m, err := p.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(m.AuthorizationEndpoint)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", strings.Join(append([]string{"openid"}, p.Scopes...), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Exchange redeems a code for an ID token, and returns its claims once
+// verified.
+func (p *Provider) Exchange(ctx context.Context, code, nonce, verifier string) (Claims, error) {
+	m, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.ClientID), url.QueryEscape(p.ClientSecret))
+	var tok struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := p.do(req, &tok); err != nil {
+		if tok.Error != "" {
+			return nil, fmt.Errorf("token request: %s", tok.Error)
+		}
+		return nil, err
+	}
+	if tok.IDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+	return p.Verify(ctx, tok.IDToken, nonce)
+}
+
+// Verify verifies the signature, issuer, audience, expiry, and nonce of an
+// ID token, and returns its claims.
+func (p *Provider) Verify(ctx context.Context, token, nonce string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	key, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if header.Alg != "RS256" || rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], sig) != nil {
+			return nil, ErrInvalidToken
+		}
+	case *ecdsa.PublicKey:
+		if header.Alg != "ES256" || len(sig) != 64 ||
+			!ecdsa.Verify(k, digest[:], new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])) {
+			return nil, ErrInvalidToken
+		}
+	default:
+		return nil, ErrInvalidToken
+	}
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if c.String("iss") != p.Issuer {
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidToken, c.String("iss"))
+	}
+	if !c.audience(p.ClientID) {
+		return nil, fmt.Errorf("%w: issued for another client", ErrInvalidToken)
+	}
+	exp, _ := c["exp"].(float64)
+	if time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	}
+	if c.String("nonce") != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	}
+	return c, nil
+}
+
+// audience returns whether the token was issued for the client.
+func (c Claims) audience(clientID string) bool {
+	switch aud := c["aud"].(type) {
+	case string:
+		return aud == clientID
+	case []interface{}:
+		for _, a := range aud {
+			if a == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// metadata returns the discovery document of the provider.
+func (p *Provider) metadata(ctx context.Context) (*metadata, error) {
+	p.mtx.Lock()
+	m := p.meta
+	p.mtx.Unlock()
+	if m != nil {
+		return m, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	m = &metadata{}
+	if err := p.do(req, m); err != nil {
+		return nil, fmt.Errorf("discovering %s: %w", p.Issuer, err)
+	}
+	if strings.TrimSuffix(m.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("discovering %s: issuer is %q", p.Issuer, m.Issuer)
+	}
+	p.mtx.Lock()
+	p.meta = m
+	p.mtx.Unlock()
+	return m, nil
+}
+
+// key returns the signing key with the given ID. The keys are fetched again
+// when it's unknown, as providers rotate them.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mtx.Lock()
+	k, ok := p.keys[kid]
+	p.mtx.Unlock()
+	if ok {
+		return k, nil
+	}
+	m, err := p.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.do(req, &set); err != nil {
+		return nil, fmt.Errorf("fetching keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, j := range set.Keys {
+		if k, err := j.publicKey(); err == nil {
+			keys[j.Kid] = k
+		}
+	}
+	p.mtx.Lock()
+	p.keys = keys
+	p.mtx.Unlock()
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// do sends a request and decodes its JSON response into v. v is decoded
+// from error responses too, so that their error can be reported.
+func (p *Provider) do(req *http.Request, v interface{}) error {
+	res, err := p.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	bts, err := io.ReadAll(io.LimitReader(res.Body, maxResponse))
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bts, v); err != nil && res.StatusCode == http.StatusOK {
+		return err
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", req.URL.Path, res.Status)
+	}
+	return nil
+}
+
+// jwk is a public key of a JSON Web Key Set.
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey returns the RSA or P-256 key.
+func (j jwk) publicKey() (crypto.PublicKey, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		if j.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		k := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !k.Curve.IsOnCurve(k.X, k.Y) {
+			return nil, errors.New("invalid P-256 key")
+		}
+		return k, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", j.Kty)
+}
+
+// decodeSegment decodes a base64url JSON segment of a token.
+func decodeSegment(s string, v interface{}) error {
+	bts, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bts, v)
+}
@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/matryer/is"
+)
+
+// fakeProvider is an OpenID Connect provider signing ID tokens with an RSA
+// key.
+type fakeProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+}
+
+func newFakeProvider(t *testing.T) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 f.URL,
+			"authorization_endpoint": f.URL + "/authorize",
+			"token_endpoint":         f.URL + "/token",
+			"jwks_uri":               f.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// sign returns an ID token with the given claims.
+func (f *fakeProvider) sign(t *testing.T, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	seg := func(v interface{}) string {
+		bts, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(bts)
+	}
+	signed := seg(map[string]string{"alg": "RS256", "kid": kid}) + "." + seg(claims)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, f.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	is := is.New(t)
+	f := newFakeProvider(t)
+	p := NewProvider(f.URL, "soft-serve", "secret", "http://localhost/-/oidc/callback", []string{"email"})
+	ctx := context.Background()
+	claims := func(edit func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":   f.URL,
+			"aud":   "soft-serve",
+			"sub":   "1234",
+			"email": "frankie@example.com",
+			"nonce": "n0nce",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	c, err := p.Verify(ctx, f.sign(t, "k1", claims(nil)), "n0nce")
+	is.NoErr(err)
+	is.Equal(c.String("email"), "frankie@example.com")
+	_, err = p.Verify(ctx, f.sign(t, "k1", claims(func(c map[string]interface{}) {
+		c["aud"] = []string{"other", "soft-serve"}
+	})), "n0nce")
+	is.NoErr(err)
+
+	for _, bad := range []string{
+		f.sign(t, "k1", claims(func(c map[string]interface{}) { c["iss"] = "https://evil.example.com" })),
+		f.sign(t, "k1", claims(func(c map[string]interface{}) { c["aud"] = "other" })),
+		f.sign(t, "k1", claims(func(c map[string]interface{}) { c["exp"] = time.Now().Add(-time.Minute).Unix() })),
+		f.sign(t, "k1", claims(func(c map[string]interface{}) { c["nonce"] = "replayed" })),
+		f.sign(t, "k1", claims(nil))[:40] + "x" + f.sign(t, "k1", claims(nil))[41:],
+		"not.a.token",
+	} {
+		_, err := p.Verify(ctx, bad, "n0nce")
+		is.True(err != nil)
+	}
+	_, err = p.Verify(ctx, f.sign(t, "k2", claims(nil)), "n0nce")
+	is.Equal(err, ErrUnknownKey)
+
+	u, err := p.AuthCodeURL(ctx, "st4te", "n0nce", "verifier")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(u, f.URL+"/authorize?"))
+	is.True(strings.Contains(u, "code_challenge_method=S256"))
+	is.True(strings.Contains(u, "scope=openid+email"))
+}
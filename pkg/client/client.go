@@ -0,0 +1,247 @@
+// Package client is a Go client for Soft Serve servers. It runs the SSH
+// commands of the server with the system ssh, so that the ssh config, known
+// hosts, and agent of the user are used.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the default SSH port of Soft Serve servers.
+const DefaultPort = 23231
+
+// sshConnectionError is the exit status of ssh when it can't connect.
+const sshConnectionError = 255
+
+// Client runs commands on a Soft Serve server.
+type Client struct {
+	Host string
+	Port int
+	// User is the SSH user, which Soft Serve ignores. Defaults to the ssh
+	// default.
+	User string
+	// IdentityFile is the SSH key to authenticate with. Defaults to the keys
+	// of the ssh agent and config.
+	IdentityFile string
+}
+
+// Repo is a repository listed by ListRepos.
+type Repo struct {
+	Name    string
+	Private bool
+}
+
+// RepoInfo is information about a repository.
+type RepoInfo struct {
+	Repo          string    `json:"repo"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	DefaultBranch string    `json:"default-branch"`
+	Head          string    `json:"head"`
+	Size          int64     `json:"size"`
+	Private       bool      `json:"private"`
+	Collabs       []string  `json:"collabs,omitempty"`
+	UpdatedAt     time.Time `json:"updated-at"`
+	Features      []string  `json:"features"`
+	// Downloads are the download counts of release archives and bundles.
+	Downloads map[string]int64 `json:"downloads,omitempty"`
+}
+
+// Error is an error returned by the server.
+type Error struct {
+	// Code is a stable machine-readable error code, e.g. "repo_not_found".
+	Code string `json:"code"`
+	// Message is a human-readable error message.
+	Message string `json:"message"`
+	// Hint is an optional suggestion on how to fix the error.
+	Hint string `json:"hint,omitempty"`
+	// Status is the exit status of the command.
+	Status int `json:"-"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New returns a client for the server at [USER@]HOST[:PORT].
+func New(addr string) (*Client, error) {
+	c := &Client{Port: DefaultPort}
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		c.User = addr[:i]
+		addr = addr[i+1:]
+	}
+	if h, p, err := net.SplitHostPort(addr); err == nil {
+		port, err := strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in %q", addr)
+		}
+		c.Port = port
+		addr = h
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if addr == "" {
+		return nil, errors.New("missing host")
+	}
+	c.Host = addr
+	return c, nil
+}
+
+// Addr returns the HOST:PORT address of the server.
+func (c *Client) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// CloneURL returns the SSH clone URL of a repository.
+func (c *Client) CloneURL(repo string) string {
+	host := c.Addr()
+	if c.User != "" {
+		host = c.User + "@" + host
+	}
+	return fmt.Sprintf("ssh://%s/%s", host, repo)
+}
+
+// Command returns the ssh command running a command on the server. With tty,
+// a terminal is allocated, e.g. for the TUI.
+func (c *Client) Command(tty bool, args ...string) *exec.Cmd {
+	sa := []string{"-p", strconv.Itoa(c.Port)}
+	if tty {
+		sa = append(sa, "-t")
+	}
+	if c.IdentityFile != "" {
+		sa = append(sa, "-i", c.IdentityFile)
+	}
+	host := c.Host
+	if c.User != "" {
+		host = c.User + "@" + host
+	}
+	sa = append(sa, host)
+	return exec.Command("ssh", append(sa, args...)...)
+}
+
+// Interactive runs a command on the server attached to the terminal.
+func (c *Client) Interactive(args ...string) error {
+	cmd := c.Command(true, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// Run runs a command on the server and returns its output. Errors printed
+// by the server are returned as an *Error.
+func (c *Client) Run(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := c.Command(false, args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err == nil {
+		return out, nil
+	}
+	var ee *exec.ExitError
+	if !errors.As(err, &ee) || ee.ExitCode() == sshConnectionError {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return out, &connError{err: err, msg: msg}
+		}
+		return out, err
+	}
+	e := &Error{}
+	if json.Unmarshal(out, e) != nil || e.Code == "" {
+		e = &Error{Code: "internal", Message: strings.TrimSpace(stderr.String())}
+		if e.Message == "" {
+			e.Message = err.Error()
+		}
+	}
+	e.Status = ee.ExitCode()
+	return out, e
+}
+
+// runJSON runs a command on the server with JSON output.
+func (c *Client) runJSON(args ...string) ([]byte, error) {
+	return c.Run(append(args, "--json")...)
+}
+
+// connError is an error connecting to the server.
+type connError struct {
+	err error
+	msg string
+}
+
+func (e *connError) Error() string { return e.msg }
+
+func (e *connError) Unwrap() error { return e.err }
+
+// IsOffline returns true if err means that the server couldn't be reached.
+func IsOffline(err error) bool {
+	var ce *connError
+	if errors.As(err, &ce) {
+		return true
+	}
+	var ee *exec.ExitError
+	return errors.As(err, &ee) && ee.ExitCode() == sshConnectionError
+}
+
+// ListRepos lists the repositories the user can read.
+func (c *Client) ListRepos() ([]Repo, error) {
+	out, err := c.runJSON("ls", "--porcelain")
+	if err != nil {
+		return nil, err
+	}
+	return parseRepos(out), nil
+}
+
+// parseRepos parses the porcelain output of ls.
+func parseRepos(out []byte) []Repo {
+	repos := make([]Repo, 0)
+	for _, l := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if l == "" {
+			continue
+		}
+		fs := strings.Split(l, "\t")
+		r := Repo{Name: fs[0]}
+		if len(fs) > 1 {
+			r.Private = fs[1] == "private"
+		}
+		repos = append(repos, r)
+	}
+	return repos
+}
+
+// RepoInfo returns information about a repository.
+func (c *Client) RepoInfo(repo string) (*RepoInfo, error) {
+	out, err := c.runJSON("info", repo)
+	if err != nil {
+		return nil, err
+	}
+	var info RepoInfo
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// CreateRepo creates an empty repository.
+func (c *Client) CreateRepo(repo string) error {
+	_, err := c.runJSON("create", repo)
+	return err
+}
+
+// SetCollab adds or removes a user as a collaborator of a repository. It
+// needs admin access.
+func (c *Client) SetCollab(repo, user string, collab bool) error {
+	action := "add"
+	if !collab {
+		action = "remove"
+	}
+	_, err := c.runJSON("collab", action, repo, user)
+	return err
+}
@@ -0,0 +1,47 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestNew(t *testing.T) {
+	cases := []struct {
+		addr     string
+		host     string
+		port     int
+		user     string
+		cloneURL string
+	}{
+		{"git.example.com", "git.example.com", DefaultPort, "", "ssh://git.example.com:23231/repo"},
+		{"git.example.com:2222", "git.example.com", 2222, "", "ssh://git.example.com:2222/repo"},
+		{"me@git.example.com", "git.example.com", DefaultPort, "me", "ssh://me@git.example.com:23231/repo"},
+		{"::1", "::1", DefaultPort, "", "ssh://[::1]:23231/repo"},
+		{"[::1]:2222", "::1", 2222, "", "ssh://[::1]:2222/repo"},
+	}
+	for _, c := range cases {
+		t.Run(c.addr, func(t *testing.T) {
+			is := is.New(t)
+			cl, err := New(c.addr)
+			is.NoErr(err)
+			is.Equal(cl.Host, c.host)
+			is.Equal(cl.Port, c.port)
+			is.Equal(cl.User, c.user)
+			is.Equal(cl.CloneURL("repo"), c.cloneURL)
+		})
+	}
+	for _, addr := range []string{"", "host:port"} {
+		_, err := New(addr)
+		is.New(t).True(err != nil)
+	}
+}
+
+func TestParseRepos(t *testing.T) {
+	is := is.New(t)
+	is.Equal(parseRepos([]byte("config\tprivate\nsoft-serve\tpublic\n")), []Repo{
+		{Name: "config", Private: true},
+		{Name: "soft-serve"},
+	})
+	is.Equal(parseRepos(nil), []Repo{})
+}
@@ -0,0 +1,208 @@
+// Package retention purges old data, such as audit logs, session recordings,
+// trashed repositories, and stored events, according to the retention policies of the
+// configuration.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/dustin/go-humanize"
+)
+
+// Class is a class of data with a retention policy.
+type Class string
+
+const (
+	// AuditLogs are audit log files.
+	AuditLogs Class = "audit-logs"
+	// SessionRecordings are recordings of SSH sessions.
+	SessionRecordings Class = "session-recordings"
+	// Trash holds deleted repositories until they're purged.
+	Trash Class = "trash"
+	// Events are the stored push events that can be replayed.
+	Events Class = "events"
+	// Inbox holds the events the notifications of users are made of.
+	Inbox Class = "inbox"
+)
+
+// Classes are all data classes, in the order they're enforced.
+var Classes = []Class{AuditLogs, SessionRecordings, Trash, Events, Inbox}
+
+// Dir returns the directory a class of data is stored in. Each entry of the
+// directory, be it a file or a directory, is retained or purged as a whole.
+func Dir(dataPath string, c Class) string {
+	return filepath.Join(dataPath, string(c))
+}
+
+// Purge is an entry purged, or that would be purged, by a retention policy.
+type Purge struct {
+	Class   Class     `json:"class"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod-time"`
+	// Reason is why the entry is purged.
+	Reason string `json:"reason"`
+}
+
+// entry is an entry of a data class directory.
+type entry struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Enforce purges the entries of each data class that are older than its
+// policy allows, then the oldest entries until the class fits in its size
+// cap. With dryRun, nothing is deleted and the report only tells what would
+// be purged.
+func Enforce(cfg *config.Config, dataPath string, dryRun bool) ([]Purge, error) {
+	now := time.Now()
+	report := make([]Purge, 0)
+	for _, c := range Classes {
+		p, ok := cfg.RetentionPolicy(string(c))
+		if !ok {
+			continue
+		}
+		maxSize, err := p.MaxBytes()
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", c, err)
+		}
+		es, err := entries(Dir(dataPath, c))
+		if err != nil {
+			return report, fmt.Errorf("%s: %w", c, err)
+		}
+		var total int64
+		for _, e := range es {
+			total += e.size
+		}
+		// Oldest first.
+		sort.Slice(es, func(i, j int) bool {
+			return es[i].modTime.Before(es[j].modTime)
+		})
+		for _, e := range es {
+			var reason string
+			switch {
+			case p.Days > 0 && now.Sub(e.modTime) > time.Duration(p.Days)*24*time.Hour:
+				reason = fmt.Sprintf("older than %d days", p.Days)
+			case maxSize > 0 && uint64(total) > maxSize:
+				reason = fmt.Sprintf("over %s cap", humanize.Bytes(maxSize))
+			default:
+				continue
+			}
+			if !dryRun {
+				if err := os.RemoveAll(e.path); err != nil {
+					return report, err
+				}
+			}
+			total -= e.size
+			report = append(report, Purge{
+				Class:   c,
+				Path:    e.path,
+				Size:    e.size,
+				ModTime: e.modTime,
+				Reason:  reason,
+			})
+		}
+	}
+	return report, nil
+}
+
+// entries returns the entries of a directory with their total sizes. A
+// missing directory has no entries.
+func entries(dir string) ([]entry, error) {
+	des, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	es := make([]entry, 0, len(des))
+	for _, de := range des {
+		fi, err := de.Info()
+		if err != nil {
+			return nil, err
+		}
+		e := entry{
+			path:    filepath.Join(dir, de.Name()),
+			size:    fi.Size(),
+			modTime: fi.ModTime(),
+		}
+		if de.IsDir() {
+			e.size = 0
+			err := filepath.WalkDir(e.path, func(_ string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.Type().IsRegular() {
+					fi, err := d.Info()
+					if err != nil {
+						return err
+					}
+					e.size += fi.Size()
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+		es = append(es, e)
+	}
+	return es, nil
+}
+
+// Scheduler enforces retention policies periodically, and measures the disk
+// usage of repositories reported by the du command.
+type Scheduler struct {
+	cfg      *config.Config
+	dataPath string
+	// Interval is the time between enforcements.
+	Interval time.Duration
+}
+
+// NewScheduler creates a new retention scheduler for the data stored under
+// dataPath.
+func NewScheduler(cfg *config.Config, dataPath string) *Scheduler {
+	return &Scheduler{
+		cfg:      cfg,
+		dataPath: dataPath,
+		Interval: 24 * time.Hour,
+	}
+}
+
+// Run enforces retention policies at the scheduled interval until ctx is
+// done.
+func (s *Scheduler) Run(ctx context.Context) {
+	t := time.NewTicker(s.Interval)
+	defer t.Stop()
+	for {
+		s.enforce()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *Scheduler) enforce() {
+	report, err := Enforce(s.cfg, s.dataPath, false)
+	for _, p := range report {
+		log.Info("purged data", "class", p.Class, "path", p.Path, "size", p.Size, "reason", p.Reason)
+	}
+	if err != nil {
+		log.Error("error enforcing retention policies", "err", err)
+	}
+	if err := s.cfg.MeasureDiskUsage(); err != nil {
+		log.Error("error measuring disk usage", "err", err)
+	}
+}
@@ -0,0 +1,94 @@
+package retention
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/matryer/is"
+)
+
+func writeEntry(t *testing.T, path string, size int, age time.Duration) {
+	t.Helper()
+	is := is.New(t)
+	is.NoErr(os.MkdirAll(filepath.Dir(path), 0o755))
+	is.NoErr(os.WriteFile(path, make([]byte, size), 0o644))
+	mt := time.Now().Add(-age)
+	is.NoErr(os.Chtimes(path, mt, mt))
+}
+
+func TestEnforce(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	day := 24 * time.Hour
+	audit := Dir(dir, AuditLogs)
+	writeEntry(t, filepath.Join(audit, "old.log"), 10, 40*day)
+	writeEntry(t, filepath.Join(audit, "new.log"), 10, day)
+	trash := Dir(dir, Trash)
+	writeEntry(t, filepath.Join(trash, "a", "objects", "pack"), 600, 3*day)
+	writeEntry(t, filepath.Join(trash, "b", "objects", "pack"), 600, 2*day)
+	// Directory ages come from the directory itself.
+	mt := time.Now().Add(-3 * day)
+	is.NoErr(os.Chtimes(filepath.Join(trash, "a"), mt, mt))
+	// Recordings have no policy and are kept.
+	writeEntry(t, filepath.Join(Dir(dir, SessionRecordings), "s.cast"), 10, 400*day)
+
+	cfg := &config.Config{
+		Retention: map[string]config.Retention{
+			string(AuditLogs): {Days: 30},
+			string(Trash):     {MaxSize: "1KB"},
+		},
+	}
+	report, err := Enforce(cfg, dir, true)
+	is.NoErr(err)
+	is.Equal(len(report), 2)
+	is.Equal(report[0].Path, filepath.Join(audit, "old.log"))
+	is.Equal(report[0].Reason, "older than 30 days")
+	is.Equal(report[1].Path, filepath.Join(trash, "a"))
+	is.Equal(report[1].Size, int64(600))
+	is.Equal(report[1].Reason, "over 1.0 kB cap")
+	// Dry runs don't delete anything.
+	_, err = os.Stat(filepath.Join(audit, "old.log"))
+	is.NoErr(err)
+
+	report2, err := Enforce(cfg, dir, false)
+	is.NoErr(err)
+	is.Equal(report2, report)
+	for _, p := range []string{
+		filepath.Join(audit, "old.log"),
+		filepath.Join(trash, "a"),
+	} {
+		_, err := os.Stat(p)
+		is.True(os.IsNotExist(err))
+	}
+	for _, p := range []string{
+		filepath.Join(audit, "new.log"),
+		filepath.Join(trash, "b"),
+		filepath.Join(Dir(dir, SessionRecordings), "s.cast"),
+	} {
+		_, err := os.Stat(p)
+		is.NoErr(err)
+	}
+
+	cfg.Retention[string(Trash)] = config.Retention{MaxSize: "lots"}
+	_, err = Enforce(cfg, dir, true)
+	is.True(err != nil)
+}
+
+func TestSchedulerMeasuresDiskUsage(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	cfg, err := config.NewConfig(&sconfig.Config{
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		DataPath: dir,
+	})
+	is.NoErr(err)
+	NewScheduler(cfg, dir).enforce()
+	_, _, ok, err := cfg.DiskUsage("config")
+	is.NoErr(err)
+	is.True(ok)
+}
@@ -0,0 +1,275 @@
+// Package secrets implements an encrypted store for credentials used by
+// integrations, such as webhook secrets and mirror credentials.
+//
+// Secrets are sealed with NaCl secretbox using a server key and persisted to
+// a JSON file. The key is generated on first use and must be kept separately
+// from the secrets file.
+package secrets
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 24
+	// redacted replaces secret values in redacted output.
+	redacted = "[REDACTED]"
+)
+
+var (
+	// ErrSecretNotFound is returned when a secret doesn't exist.
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrInvalidName is returned when a secret name is invalid.
+	ErrInvalidName = errors.New("invalid secret name")
+	// ErrDecrypt is returned when a secret can't be decrypted, usually
+	// because the key has changed.
+	ErrDecrypt = errors.New("cannot decrypt secret")
+)
+
+// Store is an encrypted secrets store. It's safe for concurrent use.
+type Store struct {
+	mtx     sync.RWMutex
+	path    string
+	key     [keySize]byte
+	secrets map[string]string
+}
+
+// Open opens the secrets store at path, sealed with the key at keyPath. Both
+// files are created if they don't exist.
+func Open(path, keyPath string) (*Store, error) {
+	s := &Store{
+		path:    path,
+		secrets: make(map[string]string),
+	}
+	if err := s.loadKey(keyPath); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// loadKey reads the key at path, generating a new one if it doesn't exist.
+func (s *Store) loadKey(path string) error {
+	key, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		key = make([]byte, keySize)
+		if _, err := io.ReadFull(rand.Reader, key); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, key, 0o600); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+	if len(key) != keySize {
+		return fmt.Errorf("invalid secrets key %q: expected %d bytes", path, keySize)
+	}
+	copy(s.key[:], key)
+	return nil
+}
+
+// load reads and decrypts the secrets file.
+func (s *Store) load() error {
+	bts, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	sealed := make(map[string]string)
+	if err := json.Unmarshal(bts, &sealed); err != nil {
+		return err
+	}
+	for name, v := range sealed {
+		box, err := base64.StdEncoding.DecodeString(v)
+		if err != nil {
+			return fmt.Errorf("%w %q", ErrDecrypt, name)
+		}
+		value, err := s.Unseal(box)
+		if err != nil {
+			return fmt.Errorf("%w %q", ErrDecrypt, name)
+		}
+		s.secrets[name] = string(value)
+	}
+	return nil
+}
+
+// Seal encrypts data with the server key.
+func (s *Store) Seal(data []byte) ([]byte, error) {
+	var nonce [nonceSize]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nonce[:], data, &nonce, &s.key), nil
+}
+
+// Unseal decrypts data encrypted with Seal.
+func (s *Store) Unseal(box []byte) ([]byte, error) {
+	if len(box) < nonceSize {
+		return nil, ErrDecrypt
+	}
+	var nonce [nonceSize]byte
+	copy(nonce[:], box[:nonceSize])
+	data, ok := secretbox.Open(nil, box[nonceSize:], &nonce, &s.key)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	return data, nil
+}
+
+// save encrypts and writes the secrets file. The caller must hold the lock.
+func (s *Store) save() error {
+	sealed := make(map[string]string, len(s.secrets))
+	for name, value := range s.secrets {
+		box, err := s.Seal([]byte(value))
+		if err != nil {
+			return err
+		}
+		sealed[name] = base64.StdEncoding.EncodeToString(box)
+	}
+	bts, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	// Write to a temporary file first so a failed write doesn't lose
+	// existing secrets.
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, bts, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// validName returns whether name is a valid secret name.
+func validName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Set sets the value of a secret.
+func (s *Store) Set(name, value string) error {
+	if !validName(name) {
+		return ErrInvalidName
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	old, existed := s.secrets[name]
+	s.secrets[name] = value
+	if err := s.save(); err != nil {
+		if existed {
+			s.secrets[name] = old
+		} else {
+			delete(s.secrets, name)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns the value of a secret.
+func (s *Store) Get(name string) (string, error) {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	v, ok := s.secrets[name]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return v, nil
+}
+
+// Remove removes a secret.
+func (s *Store) Remove(name string) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	v, ok := s.secrets[name]
+	if !ok {
+		return ErrSecretNotFound
+	}
+	delete(s.secrets, name)
+	if err := s.save(); err != nil {
+		s.secrets[name] = v
+		return err
+	}
+	return nil
+}
+
+// Names returns the sorted names of all secrets.
+func (s *Store) Names() []string {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	names := make([]string, 0, len(s.secrets))
+	for n := range s.secrets {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Redact replaces the values of all secrets in str.
+func (s *Store) Redact(str string) string {
+	if s == nil {
+		return str
+	}
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+	for _, v := range s.secrets {
+		if v == "" {
+			continue
+		}
+		str = strings.ReplaceAll(str, v, redacted)
+	}
+	return str
+}
+
+// Redactor returns a writer that redacts secret values before writing to w.
+// Secrets split across multiple writes aren't redacted, this is meant to wrap
+// line based outputs such as loggers.
+func (s *Store) Redactor(w io.Writer) io.Writer {
+	return &redactor{s: s, w: w}
+}
+
+type redactor struct {
+	s *Store
+	w io.Writer
+}
+
+// Write implements io.Writer.
+func (r *redactor) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(r.w, r.s.Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
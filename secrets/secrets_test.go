@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestStore(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets")
+	keyPath := filepath.Join(dir, "key")
+	s, err := Open(path, keyPath)
+	is.NoErr(err)
+	is.NoErr(s.Set("webhook/foo", "hunter2"))
+	is.NoErr(s.Set("smtp", "p4ssw0rd"))
+	is.Equal(s.Set("bad name", "x"), ErrInvalidName)
+
+	bts, err := os.ReadFile(path)
+	is.NoErr(err)
+	is.True(!strings.Contains(string(bts), "hunter2")) // secrets must be encrypted at rest
+
+	s, err = Open(path, keyPath)
+	is.NoErr(err)
+	v, err := s.Get("webhook/foo")
+	is.NoErr(err)
+	is.Equal(v, "hunter2")
+	is.Equal(s.Names(), []string{"smtp", "webhook/foo"})
+
+	is.NoErr(s.Remove("smtp"))
+	_, err = s.Get("smtp")
+	is.Equal(err, ErrSecretNotFound)
+	is.Equal(s.Remove("smtp"), ErrSecretNotFound)
+}
+
+func TestWrongKey(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secrets")
+	s, err := Open(path, filepath.Join(dir, "key"))
+	is.NoErr(err)
+	is.NoErr(s.Set("foo", "bar"))
+	_, err = Open(path, filepath.Join(dir, "other-key"))
+	is.True(err != nil)
+}
+
+func TestRedact(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	s, err := Open(filepath.Join(dir, "secrets"), filepath.Join(dir, "key"))
+	is.NoErr(err)
+	is.NoErr(s.Set("foo", "hunter2"))
+	var buf bytes.Buffer
+	w := s.Redactor(&buf)
+	n, err := w.Write([]byte("password=hunter2\n"))
+	is.NoErr(err)
+	is.Equal(n, len("password=hunter2\n"))
+	is.Equal(buf.String(), "password=[REDACTED]\n")
+
+	var nilStore *Store
+	is.Equal(nilStore.Redact("hunter2"), "hunter2")
+}
@@ -0,0 +1,92 @@
+package server_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestACLExportImport(t *testing.T) {
+	is := is.New(t)
+	src := servertest.New(t)
+	dst := servertest.New(t)
+	frankie := servertest.NewKey(t)
+	is.NoErr(src.Push(src.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`anon-access: no-access
+users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+  - name: Frankie
+    public-keys:
+      - %s
+    collab-repos:
+      - tools
+    aliases:
+      mine: ls tools
+repos:
+  - name: secret
+    repo: secret
+    private: true
+    collabs:
+      - Frankie
+`, src.Admin.AuthorizedKey(), frankie.AuthorizedKey()),
+	}))
+	dst.CreateRepo("secret", map[string]string{"README.md": "# Secret\n"})
+	is.NoErr(dst.Push(dst.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: dst-admin
+    admin: true
+    public-keys:
+      - %s
+repos:
+  - name: internal
+    repo: internal
+    private: true
+`, dst.Admin.AuthorizedKey()),
+	}))
+
+	acl, err := src.Run(src.Admin, "acl export")
+	is.NoErr(err)
+	is.True(strings.Contains(acl, "anon-access: no-access"))
+	is.True(strings.Contains(acl, "name: Frankie"))
+	is.True(!strings.Contains(acl, "aliases")) // only access control is exported
+
+	_, err = src.Run(frankie, "acl export")
+	is.True(err != nil) // admins only
+
+	sess := dst.Session(dst.Admin)
+	sess.Stdin = strings.NewReader("users:\n  - name: nobody\n")
+	is.True(sess.Run("acl import") != nil) // the server would be left without admins
+
+	// Pruning doesn't make the private repos missing from the document
+	// public.
+	sess = dst.Session(dst.Admin)
+	sess.Stdin = strings.NewReader(acl)
+	var stderr strings.Builder
+	sess.Stderr = &stderr
+	is.True(sess.Run("acl import --prune") != nil)
+	is.True(strings.Contains(stderr.String(), "missing repos"))
+	is.True(strings.Contains(stderr.String(), "internal"))
+	sess = dst.Session(dst.Admin)
+	sess.Stdin = strings.NewReader(acl + "  - repo: internal\n    private: false\n    collabs: []\n")
+	is.NoErr(sess.Run("acl import --prune"))
+
+	// The imported access control is that of the source server, and only
+	// its admin has access now.
+	out, err := dst.Run(src.Admin, "acl export")
+	is.NoErr(err)
+	is.Equal(out, acl)
+	_, err = dst.Clone(dst.Admin, "secret")
+	is.True(err != nil)
+	_, err = dst.Clone(frankie, "secret")
+	is.NoErr(err)
+
+	out, err = dst.Run(src.Admin, "acl export --json")
+	is.NoErr(err)
+	is.True(strings.Contains(out, `"anon-access": "no-access"`))
+}
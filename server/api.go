@@ -0,0 +1,421 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/deps"
+	"github.com/charmbracelet/soft-serve/retention"
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	gm "github.com/charmbracelet/wish/git"
+)
+
+// apiPrefix is the path the admin API is served under.
+const apiPrefix = "api/v1"
+
+// maxAPIBody is the maximum size of API request bodies.
+const maxAPIBody = 1 << 20
+
+var (
+	errAPIUnauthorized = &cm.Error{
+		Code:    "unauthorized",
+		Message: "Unauthorized",
+		Hint:    "authenticate with an API token or personal access token of an admin user",
+	}
+	errAPIForbidden = &cm.Error{
+		Code:    "forbidden",
+		Message: "Forbidden",
+		Hint:    "only admins and users with roles can use the API",
+	}
+	errAPIRole = &cm.Error{
+		Code:    "forbidden",
+		Message: "Forbidden",
+		Hint:    "your roles don't allow this request",
+	}
+	errAPITokenScope = &cm.Error{
+		Code:    "insufficient_scope",
+		Message: "Forbidden",
+		Hint:    "the API needs a personal access token with the admin-access scope",
+	}
+	errAPINotFound = &cm.Error{
+		Code:    "not_found",
+		Message: "Not found",
+	}
+	errAPIMethodNotAllowed = &cm.Error{
+		Code:    "method_not_allowed",
+		Message: http.StatusText(http.StatusMethodNotAllowed),
+	}
+	errAPIRepoExists = &cm.Error{
+		Code:    "repo_exists",
+		Message: "Repository already exists",
+	}
+)
+
+// apiRepo is a repository as returned by the admin API.
+type apiRepo struct {
+	Repo          string          `json:"repo"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Private       bool            `json:"private"`
+	Kind          appCfg.RepoKind `json:"kind"`
+	Collaborators []string        `json:"collaborators"`
+	// Features tells whether each feature of the repo is on.
+	Features map[appCfg.Feature]bool `json:"features"`
+}
+
+// apiRoles are the roles allowed to make API requests that change
+// something, by method and number of path segments. Users with any role can
+// read.
+var apiRoles = map[string][]appCfg.Role{
+	"POST 1":   {appCfg.RoleRepoAdmin},
+	"PATCH 2":  {appCfg.RoleRepoAdmin},
+	"DELETE 2": {appCfg.RoleRepoAdmin},
+	"PUT 4":    {appCfg.RoleUserAdmin},
+	"DELETE 4": {appCfg.RoleUserAdmin},
+}
+
+// apiNewRepo is the request body creating a repository.
+type apiNewRepo struct {
+	Repo string `json:"repo"`
+	appCfg.RepoSettings
+}
+
+// serveAPI serves the admin API. Requests authenticate with the API token, or
+// a personal access token with the admin-access scope, of an admin user or a
+// user with roles as a bearer token. Repo admins can change repos, and user
+// admins collaborators. Changes are committed to the config repo,
+// like those made over SSH.
+//
+//	GET    /api/v1/repos
+//	POST   /api/v1/repos
+//	GET    /api/v1/repos/REPO
+//	PATCH  /api/v1/repos/REPO
+//	DELETE /api/v1/repos/REPO
+//	GET    /api/v1/repos/REPO/collaborators
+//	GET    /api/v1/repos/REPO/dependencies
+//	PUT    /api/v1/repos/REPO/collaborators/USER
+//	DELETE /api/v1/repos/REPO/collaborators/USER
+//	GET    /api/v1/search?dependency=MODULE|code=STRING[&refs=all]
+func (h *httpHandler) serveAPI(w http.ResponseWriter, r *http.Request, p string) {
+	token := r.Header.Get("Authorization")
+	if !strings.HasPrefix(token, "Bearer ") {
+		h.apiUnauthorized(w)
+		return
+	}
+	pk, scope, ok := h.cfg.TokenAuth(r.RemoteAddr, strings.TrimPrefix(token, "Bearer "))
+	if !ok {
+		h.apiUnauthorized(w)
+		return
+	}
+	if r.Method != http.MethodGet {
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		w = sw
+		defer func() {
+			var err error
+			if sw.status >= http.StatusBadRequest {
+				err = errors.New(http.StatusText(sw.status))
+			}
+			h.cfg.AuditCommand(pk, r.RemoteAddr, r.Method+" "+r.URL.Path, "", err)
+		}()
+	}
+	if !h.cfg.HasRole(pk, appCfg.Roles...) {
+		writeAPIError(w, http.StatusForbidden, errAPIForbidden)
+		return
+	}
+	if scope < gm.AdminAccess {
+		writeAPIError(w, http.StatusForbidden, errAPITokenScope)
+		return
+	}
+
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(p, apiPrefix), "/"), "/")
+	if len(parts) == 1 && parts[0] == "search" {
+		h.apiSearch(w, r)
+		return
+	}
+	if parts[0] != "repos" || len(parts) > 4 ||
+		(len(parts) > 2 && parts[2] != "collaborators" && (parts[2] != "dependencies" || len(parts) > 3)) {
+		writeAPIError(w, http.StatusNotFound, errAPINotFound)
+		return
+	}
+	var rr *appCfg.Repo
+	if len(parts) > 1 {
+		var err error
+		if rr, err = h.cfg.Source.GetRepo(parts[1]); err != nil {
+			writeAPIError(w, http.StatusNotFound, cm.ErrRepoNotFound)
+			return
+		}
+	}
+	route := fmt.Sprintf("%s %d", r.Method, len(parts))
+	if roles := apiRoles[route]; roles != nil && !h.cfg.HasRole(pk, roles...) {
+		writeAPIError(w, http.StatusForbidden, errAPIRole)
+		return
+	}
+	// Repo admins don't have admin access to the config repo, and user
+	// admins can't give it out: collaborators of the config repo can push
+	// a config making them admins.
+	configCollab := (route == "PUT 4" || route == "DELETE 4") && rr.Repo() == "config"
+	if (route == "PATCH 2" || route == "DELETE 2" || configCollab) && h.cfg.AuthRepo(rr.Repo(), pk) < gm.AdminAccess {
+		writeAPIError(w, http.StatusForbidden, errAPIRole)
+		return
+	}
+	switch route {
+	case "GET 1":
+		repos := make([]apiRepo, 0)
+		for _, rr := range h.cfg.Source.AllRepos() {
+			repos = append(repos, h.apiRepo(rr))
+		}
+		writeAPI(w, http.StatusOK, repos)
+	case "POST 1":
+		h.apiCreateRepo(w, r)
+	case "GET 2":
+		writeAPI(w, http.StatusOK, h.apiRepo(rr))
+	case "PATCH 2":
+		var s appCfg.RepoSettings
+		if err := decodeAPI(w, r, &s); err != nil {
+			writeAPIError(w, http.StatusBadRequest, err)
+			return
+		}
+		err := h.cfg.SetRepoSettings(rr.Repo(), s)
+		if errors.Is(err, appCfg.ErrUnknownFeature) {
+			writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(err))
+			return
+		}
+		if err != nil {
+			h.apiInternalError(w, "error updating repo", rr.Repo(), err)
+			return
+		}
+		h.apiRepoAfterReload(w, http.StatusOK, rr.Repo())
+	case "DELETE 2":
+		if rr.Repo() == "config" {
+			writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(errors.New("the config repo can't be deleted")))
+			return
+		}
+		if err := h.deleteRepo(rr.Repo()); err != nil {
+			h.apiInternalError(w, "error deleting repo", rr.Repo(), err)
+			return
+		}
+		// Reloading publishes the repo-deleted event.
+		if err := h.cfg.Reload(); err != nil {
+			h.apiInternalError(w, "error reloading config", rr.Repo(), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case "GET 3":
+		if parts[2] == "dependencies" {
+			h.apiDependencies(w, rr)
+			return
+		}
+		writeAPI(w, http.StatusOK, h.cfg.Collabs(rr.Repo()))
+	case "PUT 4", "DELETE 4":
+		err := h.cfg.SetCollab(rr.Repo(), parts[3], r.Method == http.MethodPut)
+		if errors.Is(err, appCfg.ErrUnknownUser) {
+			writeAPIError(w, http.StatusNotFound, cm.ErrUserNotFound)
+			return
+		}
+		if err != nil {
+			h.apiInternalError(w, "error setting collaborator", rr.Repo(), err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		allow := map[int]string{
+			1: "GET, POST",
+			2: "GET, PATCH, DELETE",
+			3: "GET",
+			4: "PUT, DELETE",
+		}
+		w.Header().Set("Allow", allow[len(parts)])
+		writeAPIError(w, http.StatusMethodNotAllowed, errAPIMethodNotAllowed)
+	}
+}
+
+func (h *httpHandler) apiCreateRepo(w http.ResponseWriter, r *http.Request) {
+	var req apiNewRepo
+	if err := decodeAPI(w, r, &req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	rn := strings.TrimSuffix(req.Repo, ".git")
+	if rn == "" || strings.ContainsAny(rn, "/\\") || strings.Contains(rn, "..") {
+		writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(fmt.Errorf("invalid repository name %q", req.Repo)))
+		return
+	}
+	if _, err := h.cfg.Source.GetRepo(rn); err == nil {
+		writeAPIError(w, http.StatusConflict, errAPIRepoExists)
+		return
+	}
+	// Settings go first, so that private repos are never public.
+	err := h.cfg.SetRepoSettings(rn, req.RepoSettings)
+	if errors.Is(err, appCfg.ErrUnknownFeature) {
+		writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(err))
+		return
+	}
+	if err != nil {
+		h.apiInternalError(w, "error setting up repo", rn, err)
+		return
+	}
+	if _, err := h.cfg.Source.InitRepo(rn, true); err != nil {
+		h.apiInternalError(w, "error creating repo", rn, err)
+		return
+	}
+	// Reloading publishes the repo-created event.
+	h.apiRepoAfterReload(w, http.StatusCreated, rn)
+}
+
+// apiDependencies writes the dependencies of a repo at HEAD. Empty repos
+// have none.
+func (h *httpHandler) apiDependencies(w http.ResponseWriter, rr *appCfg.Repo) {
+	if rr.IsEmpty() {
+		writeAPI(w, http.StatusOK, []deps.Dependency{})
+		return
+	}
+	ds, err := h.cfg.Dependencies(rr.Repo(), nil)
+	if err != nil {
+		h.apiInternalError(w, "error finding dependencies", rr.Repo(), err)
+		return
+	}
+	writeAPI(w, http.StatusOK, ds)
+}
+
+// apiSearch searches the repos for dependents of a module or for code, and
+// writes what was found, grouped by repo and ref.
+func (h *httpHandler) apiSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		writeAPIError(w, http.StatusMethodNotAllowed, errAPIMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	dep, code := q.Get("dependency"), q.Get("code")
+	var allRefs bool
+	switch refs := q.Get("refs"); refs {
+	case "", "head":
+	case "all":
+		allRefs = true
+	default:
+		writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(fmt.Errorf("invalid refs %q, must be head or all", refs)))
+		return
+	}
+	var ms []appCfg.RefMatch
+	var err error
+	switch {
+	case (dep == "") == (code == ""):
+		err = errors.New("either dependency or code must be given")
+	case dep != "":
+		ms, err = h.cfg.FindDependents(dep, allRefs)
+	default:
+		ms, err = h.cfg.FindCode(code, allRefs)
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, apiInvalidArgument(err))
+		return
+	}
+	writeAPI(w, http.StatusOK, ms)
+}
+
+// apiRepoAfterReload reloads the configuration and writes the repo.
+func (h *httpHandler) apiRepoAfterReload(w http.ResponseWriter, status int, repo string) {
+	if err := h.cfg.Reload(); err != nil {
+		h.apiInternalError(w, "error reloading config", repo, err)
+		return
+	}
+	rr, err := h.cfg.Source.GetRepo(repo)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, cm.ErrRepoNotFound)
+		return
+	}
+	writeAPI(w, status, h.apiRepo(rr))
+}
+
+func (h *httpHandler) apiRepo(rr *appCfg.Repo) apiRepo {
+	return apiRepo{
+		Repo:          rr.Repo(),
+		Name:          rr.Name(),
+		Description:   rr.Description(),
+		Private:       rr.IsPrivate(),
+		Kind:          h.cfg.RepoKind(rr.Repo()),
+		Collaborators: h.cfg.Collabs(rr.Repo()),
+		Features:      h.cfg.RepoFeatures(rr.Repo()),
+	}
+}
+
+// deleteRepo deletes a repo. With a data path, the repo is moved to the
+// trash, where it's kept until purged by the retention policy of the trash.
+func (h *httpHandler) deleteRepo(repo string) error {
+	if h.cfg.Cfg == nil || h.cfg.Cfg.DataPath == "" {
+		return h.cfg.Source.RemoveRepo(repo)
+	}
+	defer h.cfg.Source.LockMaintenance(repo)()
+	trash := retention.Dir(h.cfg.Cfg.DataPath, retention.Trash)
+	if err := os.MkdirAll(trash, 0o700); err != nil {
+		return err
+	}
+	now := time.Now()
+	dst := filepath.Join(trash, fmt.Sprintf("%s-%s", repo, now.UTC().Format("20060102T150405Z")))
+	if err := os.Rename(filepath.Join(h.cfg.Source.Dir(), repo), dst); err != nil {
+		return err
+	}
+	h.cfg.Source.UnloadRepo(repo)
+	// Retention goes by modification time, which renaming doesn't update.
+	return os.Chtimes(dst, now, now)
+}
+
+func (h *httpHandler) apiUnauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="Soft Serve"`)
+	writeAPIError(w, http.StatusUnauthorized, errAPIUnauthorized)
+}
+
+func (h *httpHandler) apiInternalError(w http.ResponseWriter, msg, repo string, err error) {
+	log.Error(msg, "repo", repo, "err", err)
+	writeAPIError(w, http.StatusInternalServerError, err)
+}
+
+// decodeAPI decodes the JSON request body into v. Unknown fields are
+// rejected, so that typos don't go unnoticed.
+func decodeAPI(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAPIBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return apiInvalidArgument(fmt.Errorf("invalid request body: %w", err))
+	}
+	return nil
+}
+
+func apiInvalidArgument(err error) *cm.Error {
+	return &cm.Error{
+		Code:    "invalid_argument",
+		Message: err.Error(),
+	}
+}
+
+// writeAPIError writes err as the JSON error object of the SSH commands.
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	writeAPI(w, status, cm.AsError(err))
+}
+
+func writeAPI(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// statusWriter is a response writer recording the status of the response,
+// for the audit log.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,175 @@
+package server_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/deps"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestAPI(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	hash := func(token string) string {
+		sum := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(sum[:])
+	}
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    api-tokens:
+      - %s
+  - name: Frankie
+    public-keys:
+      - %s
+    api-tokens:
+      - %s
+`, s.Admin.AuthorizedKey(), hash("admin-token"), servertest.NewKey(t).AuthorizedKey(), hash("user-token")),
+	}))
+
+	do := func(token, method, path, body string) (int, string) {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s/api/v1/%s", s.HTTPAddr, path), strings.NewReader(body))
+		is.NoErr(err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		is.NoErr(err)
+		defer res.Body.Close()
+		bts, err := io.ReadAll(res.Body)
+		is.NoErr(err)
+		return res.StatusCode, string(bts)
+	}
+	type repo struct {
+		Repo          string   `json:"repo"`
+		Description   string   `json:"description"`
+		Private       bool     `json:"private"`
+		Collaborators []string `json:"collaborators"`
+	}
+
+	code, _ := do("", http.MethodGet, "repos", "")
+	is.Equal(code, http.StatusUnauthorized)
+	code, _ = do("wrong", http.MethodGet, "repos", "")
+	is.Equal(code, http.StatusUnauthorized)
+	code, _ = do("user-token", http.MethodGet, "repos", "")
+	is.Equal(code, http.StatusForbidden)
+
+	code, body := do("admin-token", http.MethodPost, "repos", `{"repo":"new","description":"A new repo","private":true}`)
+	is.Equal(code, http.StatusCreated)
+	var r repo
+	is.NoErr(json.Unmarshal([]byte(body), &r))
+	is.Equal(r, repo{Repo: "new", Description: "A new repo", Private: true, Collaborators: []string{}})
+	code, _ = do("admin-token", http.MethodPost, "repos", `{"repo":"new"}`)
+	is.Equal(code, http.StatusConflict)
+	code, _ = do("admin-token", http.MethodPost, "repos", `{"repo":"../new"}`)
+	is.Equal(code, http.StatusBadRequest)
+	code, _ = do("admin-token", http.MethodPost, "repos", `{"repo":"new","privat":true}`)
+	is.Equal(code, http.StatusBadRequest)
+
+	code, body = do("admin-token", http.MethodPatch, "repos/new", `{"private":false}`)
+	is.Equal(code, http.StatusOK)
+	is.NoErr(json.Unmarshal([]byte(body), &r))
+	is.Equal(r.Description, "A new repo")
+	is.True(!r.Private)
+
+	code, body = do("admin-token", http.MethodPatch, "repos/new", `{"features":{"releases":false}}`)
+	is.Equal(code, http.StatusOK)
+	var fr struct {
+		Features map[string]bool `json:"features"`
+	}
+	is.NoErr(json.Unmarshal([]byte(body), &fr))
+	is.Equal(fr.Features, map[string]bool{"releases": false, "webhooks": true, "housekeeping": true})
+	code, _ = do("admin-token", http.MethodPatch, "repos/new", `{"features":{"wiki":false}}`)
+	is.Equal(code, http.StatusBadRequest)
+
+	code, _ = do("admin-token", http.MethodPut, "repos/new/collaborators/Frankie", "")
+	is.Equal(code, http.StatusNoContent)
+	code, _ = do("admin-token", http.MethodPut, "repos/new/collaborators/nobody", "")
+	is.Equal(code, http.StatusNotFound)
+	code, body = do("admin-token", http.MethodGet, "repos", "")
+	is.Equal(code, http.StatusOK)
+	var rs []repo
+	is.NoErr(json.Unmarshal([]byte(body), &rs))
+	found := false
+	for _, r := range rs {
+		if r.Repo == "new" {
+			found = true
+			is.Equal(r.Collaborators, []string{"Frankie"})
+		}
+	}
+	is.True(found)
+	code, _ = do("admin-token", http.MethodDelete, "repos/new/collaborators/Frankie", "")
+	is.Equal(code, http.StatusNoContent)
+	code, body = do("admin-token", http.MethodGet, "repos/new/collaborators", "")
+	is.Equal(code, http.StatusOK)
+	is.Equal(strings.TrimSpace(body), "[]")
+
+	s.CreateRepo("app", map[string]string{
+		"go.mod": "module example.com/app\n\nrequire github.com/matryer/is v1.4.0\n",
+	})
+	code, body = do("admin-token", http.MethodGet, "repos/app/dependencies", "")
+	is.Equal(code, http.StatusOK)
+	var ds []deps.Dependency
+	is.NoErr(json.Unmarshal([]byte(body), &ds))
+	is.Equal(ds, []deps.Dependency{{Name: "github.com/matryer/is", Version: "v1.4.0", Ecosystem: deps.Go, Manifest: "go.mod"}})
+	code, body = do("admin-token", http.MethodGet, "repos/new/dependencies", "")
+	is.Equal(code, http.StatusOK)
+	is.Equal(strings.TrimSpace(body), "[]")
+
+	type match struct {
+		Repo         string            `json:"repo"`
+		Ref          string            `json:"ref"`
+		Dependencies []deps.Dependency `json:"dependencies"`
+		Lines        []struct {
+			Path string `json:"path"`
+			Line int    `json:"line"`
+		} `json:"lines"`
+	}
+	var ms []match
+	code, body = do("admin-token", http.MethodGet, "search?dependency=github.com/matryer/*", "")
+	is.Equal(code, http.StatusOK)
+	is.NoErr(json.Unmarshal([]byte(body), &ms))
+	is.Equal(len(ms), 1)
+	is.Equal(ms[0].Repo, "app")
+	is.Equal(ms[0].Dependencies[0].Name, "github.com/matryer/is")
+	code, body = do("admin-token", http.MethodGet, "search?code=matryer&refs=all", "")
+	is.Equal(code, http.StatusOK)
+	is.NoErr(json.Unmarshal([]byte(body), &ms))
+	is.Equal(len(ms), 1)
+	is.Equal(ms[0].Lines[0].Path, "go.mod")
+	is.Equal(ms[0].Lines[0].Line, 3)
+	code, _ = do("admin-token", http.MethodGet, "search", "")
+	is.Equal(code, http.StatusBadRequest)
+	code, _ = do("admin-token", http.MethodGet, "search?code=x&refs=some", "")
+	is.Equal(code, http.StatusBadRequest)
+	code, _ = do("admin-token", http.MethodPost, "search?code=x", "")
+	is.Equal(code, http.StatusMethodNotAllowed)
+	code, _ = do("user-token", http.MethodGet, "search?code=x", "")
+	is.Equal(code, http.StatusForbidden)
+
+	code, _ = do("admin-token", http.MethodDelete, "repos/config", "")
+	is.Equal(code, http.StatusBadRequest)
+	code, _ = do("admin-token", http.MethodDelete, "repos/new", "")
+	is.Equal(code, http.StatusNoContent)
+	_, err := os.Stat(filepath.Join(s.Source.Path, "new"))
+	is.True(os.IsNotExist(err))
+	code, _ = do("admin-token", http.MethodGet, "repos/new", "")
+	is.Equal(code, http.StatusNotFound)
+	code, _ = do("admin-token", http.MethodPost, "repos/new", "")
+	is.Equal(code, http.StatusNotFound)
+	code, _ = do("admin-token", http.MethodPost, "nope", "")
+	is.Equal(code, http.StatusNotFound)
+}
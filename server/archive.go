@@ -0,0 +1,124 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// archiveFormats maps the extensions of release assets to git archive
+// formats. Bundles aren't archives and are handled separately.
+var archiveFormats = map[string]string{
+	".tar.gz": "tar.gz",
+	".zip":    "zip",
+}
+
+const bundleExt = ".bundle"
+
+// serveArchive serves the source archive or bundle of a tag, requested as
+// <tag>.tar.gz, <tag>.zip, or <tag>.bundle. Archives can be limited to the
+// path of a repo profile with ?profile=<name>. Repos with releases turned off
+// have no archives.
+func (h *httpHandler) serveArchive(w http.ResponseWriter, r *http.Request, repo, asset string) {
+	rr, err := h.cfg.Source.GetRepo(repo)
+	if err != nil || !h.cfg.FeatureEnabled(repo, appCfg.FeatureReleases) {
+		http.NotFound(w, r)
+		return
+	}
+	var tag, ext string
+	for _, e := range []string{".tar.gz", ".zip", bundleExt} {
+		if strings.HasSuffix(asset, e) {
+			tag, ext = strings.TrimSuffix(asset, e), e
+			break
+		}
+	}
+	if tag == "" {
+		http.NotFound(w, r)
+		return
+	}
+	ref, err := rr.Reference(git.RefsTags + tag)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	var paths []string
+	asset = tag
+	base := fmt.Sprintf("%s-%s", repo, strings.ReplaceAll(tag, "/", "-"))
+	if profile := r.URL.Query().Get("profile"); profile != "" {
+		p, ok := h.cfg.RepoProfiles(repo)[profile]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		// Bundles carry history, which can't be limited to a path.
+		if ext == bundleExt {
+			http.Error(w, "bundles can't be limited to a profile", http.StatusBadRequest)
+			return
+		}
+		if p != "" {
+			paths = append(paths, p)
+		}
+		asset += "-" + profile
+		base += "-" + profile
+	}
+	asset += ext
+	name := base + ext
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	// Tags rarely move, so the tagged object, profile, and format identify
+	// the asset.
+	etag := fmt.Sprintf("%q", ref.Hash.String()+strings.TrimPrefix(asset, tag))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == http.MethodGet {
+		h.cfg.CountDownload(repo, asset)
+	}
+
+	if ext == bundleExt {
+		dir, err := os.MkdirTemp("", "soft-serve-bundle")
+		if err != nil {
+			ctxLogger(r.Context()).Error("error creating bundle", "repo", repo, "tag", tag, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(dir)
+		bp := filepath.Join(dir, name)
+		if err := rr.BundleRefs(bp, ref.Name().String()); err != nil {
+			ctxLogger(r.Context()).Error("error creating bundle", "repo", repo, "tag", tag, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		f, err := os.Open(bp)
+		if err != nil {
+			ctxLogger(r.Context()).Error("error opening bundle", "repo", repo, "tag", tag, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		w.Header().Set("Content-Type", "application/octet-stream")
+		http.ServeContent(w, r, name, time.Time{}, f)
+		return
+	}
+
+	if ext == ".zip" {
+		w.Header().Set("Content-Type", "application/zip")
+	} else {
+		w.Header().Set("Content-Type", "application/gzip")
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+	prefix := base + "/"
+	if err := rr.Archive(w, ref.Name().String(), archiveFormats[ext], prefix, paths...); err != nil {
+		// Headers are already sent, so all that's left is to log.
+		ctxLogger(r.Context()).Error("error writing archive", "repo", repo, "tag", tag, "err", err)
+	}
+}
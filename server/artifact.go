@@ -0,0 +1,70 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/artifacts"
+	gm "github.com/charmbracelet/wish/git"
+)
+
+// serveArtifact serves the artifact of a repo requested as <digest>/<name>.
+// The name must be that of an artifact with the digest, so that links tell
+// what they download.
+func (h *httpHandler) serveArtifact(w http.ResponseWriter, r *http.Request, repo, asset string) {
+	i := strings.Index(asset, "/")
+	if i < 0 || h.cfg.Artifacts == nil {
+		http.NotFound(w, r)
+		return
+	}
+	digest, name := asset[:i], asset[i+1:]
+	as, err := h.cfg.Artifacts.List(repo)
+	if err != nil {
+		ctxLogger(r.Context()).Error("error listing artifacts", "repo", repo, "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	found := false
+	for _, a := range as {
+		if a.Digest == digest && a.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	f, err := h.cfg.Artifacts.Open(repo, digest)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		ctxLogger(r.Context()).Error("error opening artifact", "repo", repo, "digest", digest, "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	ct := mime.TypeByExtension(path.Ext(name))
+	if ct == "" {
+		ct = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	// Artifacts are content addressed, so they never change. Those of
+	// repos only signed in users can read mustn't be kept by shared caches.
+	w.Header().Set("ETag", fmt.Sprintf("%q", digest))
+	cache := "public"
+	if h.cfg.AuthRepo(repo, nil) < gm.ReadOnlyAccess {
+		cache = "private"
+	}
+	w.Header().Set("Cache-Control", cache+", max-age=31536000, immutable")
+	http.ServeContent(w, r, name, time.Time{}, f)
+}
@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/artifacts"
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestArtifacts(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	_, err := s.Run(s.Admin, "git repo tag v1.0.0")
+	is.NoErr(err)
+	is.NoErr(s.Reload())
+
+	upload := func(k *servertest.Key, cmd, content string) (string, error) {
+		sess := s.Session(k)
+		sess.Stdin = strings.NewReader(content)
+		out, err := sess.Output(cmd)
+		return string(out), err
+	}
+	out, err := upload(s.Admin, "artifact upload repo v1.0.0 sbom.json --json", `{"sbom":true}`)
+	is.NoErr(err)
+	var a artifacts.Artifact
+	is.NoErr(json.Unmarshal([]byte(out), &a))
+	is.Equal(a.Tag, "v1.0.0")
+	is.Equal(a.Uploader, "Admin")
+
+	// Anonymous users can read, but not upload.
+	_, err = upload(servertest.NewKey(t), "artifact upload repo v1.0.0 evil.txt", "evil")
+	var exit *cssh.ExitError
+	is.True(errors.As(err, &exit))
+	is.Equal(exit.ExitStatus(), cm.StatusUnauthorized)
+	_, err = upload(s.Admin, "artifact upload repo nope sbom.json", "")
+	is.True(errors.As(err, &exit))
+	is.Equal(exit.ExitStatus(), cm.StatusNotFound)
+
+	out, err = s.Run(servertest.NewKey(t), "artifact list repo v1.0.0 --json")
+	is.NoErr(err)
+	var as []artifacts.Artifact
+	is.NoErr(json.Unmarshal([]byte(out), &as))
+	is.Equal(len(as), 1)
+	is.Equal(as[0].Digest, a.Digest)
+	out, err = s.Run(s.Admin, "artifact get repo v1.0.0 sbom.json")
+	is.NoErr(err)
+	is.Equal(out, `{"sbom":true}`)
+
+	res, err := http.Get(fmt.Sprintf("http://%s/repo/artifacts/%s/sbom.json", s.HTTPAddr, a.Digest))
+	is.NoErr(err)
+	bts, err := io.ReadAll(res.Body)
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, http.StatusOK)
+	is.Equal(res.Header.Get("Content-Type"), "application/json")
+	is.Equal(string(bts), `{"sbom":true}`)
+	// The name must match.
+	res, err = http.Get(fmt.Sprintf("http://%s/repo/artifacts/%s/other.json", s.HTTPAddr, a.Digest))
+	is.NoErr(err)
+	is.NoErr(res.Body.Close())
+	is.Equal(res.StatusCode, http.StatusNotFound)
+
+	_, err = s.Run(s.Admin, "artifact remove repo v1.0.0 sbom.json")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "artifact get repo v1.0.0 sbom.json")
+	is.True(errors.As(err, &exit))
+	is.Equal(exit.ExitStatus(), cm.StatusNotFound)
+}
@@ -0,0 +1,58 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/audit"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestAuditLog(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	_, err := s.Run(s.Admin, "reload")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "create audited")
+	is.NoErr(err)
+	// Read-only commands aren't audited.
+	_, err = s.Run(s.Admin, "repo list")
+	is.NoErr(err)
+
+	fp := cssh.FingerprintSHA256(s.Admin.PublicKey)
+	l := audit.NewLog(s.Config.DataPath)
+	var es []events.Event
+	for i := 0; i < 100; i++ {
+		es, err = l.Entries(time.Time{})
+		is.NoErr(err)
+		cmds := 0
+		for _, e := range es {
+			if e.Type == events.AdminCommand {
+				cmds++
+			}
+		}
+		if cmds >= 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cmds := make([]string, 0)
+	auths := 0
+	for _, e := range es {
+		switch e.Type {
+		case events.AdminCommand:
+			is.Equal(e.Key, fp)
+			is.True(e.RemoteAddr != "")
+			cmds = append(cmds, e.Command)
+		case events.AuthSuccess:
+			if e.Method == "publickey" && e.Key == fp {
+				auths++
+			}
+		}
+	}
+	is.Equal(cmds, []string{"reload", "create audited"})
+	is.True(auths > 0)
+}
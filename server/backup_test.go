@@ -0,0 +1,45 @@
+package server_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"testing"
+
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestBackupCommand(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+
+	out, err := s.Run(s.Admin, "backup")
+	is.NoErr(err)
+	zr, err := gzip.NewReader(bytes.NewReader([]byte(out)))
+	is.NoErr(err)
+	tr := tar.NewReader(zr)
+	names := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		is.NoErr(err)
+		names[hdr.Name] = true
+	}
+	is.True(names["soft-serve-snapshot.json"])
+	is.True(names["repos/repo/HEAD"])
+	is.True(names["repos/config/HEAD"])
+	is.True(names["keys/host"])
+
+	var ee *cssh.ExitError
+	_, err = s.Run(servertest.NewKey(t), "backup")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusUnauthorized)
+}
@@ -0,0 +1,70 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestBandwidth(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("metered", map[string]string{"README.md": "# Metered\n"})
+	_, err := s.Clone(s.Admin, "metered")
+	is.NoErr(err)
+
+	type total struct {
+		Key string `json:"key"`
+		In  int64  `json:"in"`
+		Out int64  `json:"out"`
+	}
+	report := func(by string) map[string]total {
+		out, err := s.Run(s.Admin, "bandwidth --json --by "+by)
+		is.NoErr(err)
+		var ts []total
+		is.NoErr(json.Unmarshal([]byte(out), &ts))
+		m := make(map[string]total)
+		for _, t := range ts {
+			m[t.Key] = t
+		}
+		return m
+	}
+	// Sessions are recorded once they end, after the client is done.
+	var repos map[string]total
+	for i := 0; i < 100; i++ {
+		if repos = report("repo"); repos["metered"].Out > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	is.True(repos["metered"].In > 0)
+	is.True(repos["metered"].Out > 0)
+
+	users := report("user")
+	is.Equal(len(users), 1)
+	var user string
+	for u := range users {
+		user = u
+	}
+	is.True(user != "anonymous")
+	days := report("day")
+	is.True(days[time.Now().UTC().Format("2006-01-02")].Out > 0)
+
+	res, err := http.Get(fmt.Sprintf("http://%s/metrics", s.MetricsAddr))
+	is.NoErr(err)
+	defer res.Body.Close()
+	bts, err := io.ReadAll(res.Body)
+	is.NoErr(err)
+	is.True(strings.Contains(string(bts), fmt.Sprintf(`soft_serve_bandwidth_bytes_total{user=%q,direction="out"}`, user)))
+
+	out, err := s.Run(s.Admin, "bandwidth --by team")
+	is.True(err != nil)
+	is.True(strings.Contains(out, "invalid --by"))
+}
@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// ErrRequestNotFound is returned for access requests that aren't pending.
+var ErrRequestNotFound = &Error{
+	Code:    "request_not_found",
+	Message: "Access request not found",
+	Hint:    "run access requests to list the pending requests",
+	Status:  StatusNotFound,
+}
+
+// AccessCommand returns a command that requests access to private repos, and
+// approves and denies the requests.
+func AccessCommand() *cobra.Command {
+	accessCmd := &cobra.Command{
+		Use:   "access",
+		Short: "Request access to repositories.",
+		Long: `Request access to private repositories listed as requestable, and approve or
+deny the requests to the repositories you're an admin of.
+
+Approving a request grants the user an access level to the repository, which
+is committed to the config repo. Requests, approvals, and denials are recorded
+in the audit log.`,
+		Example: `  access request soft-serve -m "I'm on the CLI team"
+  access requests
+  access approve 1f2e3d4c read-write
+  access deny 1f2e3d4c`,
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			silenceIfJSON(cmd)
+		},
+	}
+
+	var message string
+	requestCmd := &cobra.Command{
+		Use:   "request REPO",
+		Short: "Request access to a repository.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			req, err := ac.RequestAccess(args[0], s.PublicKey(), message)
+			if err != nil {
+				return accessError(cmd, err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(req)
+			}
+			fmt.Fprintf(s, "Requested access to %s, request %s\n", req.Repo, req.ID)
+			return nil
+		},
+	}
+	requestCmd.Flags().StringVarP(&message, "message", "m", "", "Why you need access")
+
+	requestsCmd := &cobra.Command{
+		Use:   "requests",
+		Short: "List the pending requests to the repositories you're an admin of.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			reqs, err := ac.AccessRequests(s.PublicKey())
+			if err != nil {
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(reqs)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, r := range reqs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", r.ID, r.Repo, r.User,
+					r.Created.Format(time.RFC3339), strings.ReplaceAll(r.Message, "\n", " "))
+			}
+			return tw.Flush()
+		},
+	}
+
+	approveCmd := &cobra.Command{
+		Use:   "approve ID LEVEL",
+		Short: "Approve an access request, granting read-only, read-write, or admin-access.",
+		Args:  cobra.ExactArgs(2),
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := checkRequestAdmin(cmd, args[0]); err != nil {
+				return err
+			}
+			if err := ac.ApproveAccess(args[0], args[1], s.PublicKey()); err != nil {
+				return accessError(cmd, err)
+			}
+			fmt.Fprintf(s, "%s\n", args[0])
+			return nil
+		},
+	}
+
+	denyCmd := &cobra.Command{
+		Use:   "deny ID",
+		Short: "Deny an access request.",
+		Args:  cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := checkRequestAdmin(cmd, args[0]); err != nil {
+				return err
+			}
+			if err := ac.DenyAccess(args[0], s.PublicKey()); err != nil {
+				return accessError(cmd, err)
+			}
+			fmt.Fprintf(s, "%s\n", args[0])
+			return nil
+		},
+	}
+
+	accessCmd.AddCommand(requestCmd, requestsCmd, approveCmd, denyCmd)
+
+	return accessCmd
+}
+
+// checkRequestAdmin returns an error unless the user has admin access to the
+// repo of a pending access request. Requests to other repos are reported
+// missing.
+func checkRequestAdmin(cmd *cobra.Command, id string) error {
+	ac, s := fromContext(cmd)
+	req, err := ac.AccessRequest(id)
+	if err != nil {
+		return accessError(cmd, err)
+	}
+	if ac.AuthRepoCtx(s.Context(), req.Repo, s.PublicKey()) < gitwish.AdminAccess {
+		return ErrRequestNotFound
+	}
+	return nil
+}
+
+// accessError maps the errors of access requests to command errors.
+func accessError(cmd *cobra.Command, err error) error {
+	switch {
+	case errors.Is(err, config.ErrUnknownRequest):
+		return ErrRequestNotFound
+	case errors.Is(err, config.ErrNotRequestable):
+		return ErrRepoNotFound
+	case errors.Is(err, config.ErrUnknownUser):
+		return &Error{
+			Code:    "unknown_user",
+			Message: "Only users of the server can request access",
+			Hint:    "ask an admin to add your key to a user",
+			Status:  StatusUnauthorized,
+		}
+	case errors.Is(err, config.ErrHasAccess), errors.Is(err, config.ErrRequestPending),
+		errors.Is(err, config.ErrInvalidAccess):
+		return invalidArgument(cmd, err)
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// ACLCommand returns a command that exports and imports the access control
+// of the server.
+func ACLCommand() *cobra.Command {
+	aclCmd := &cobra.Command{
+		Use:   "acl",
+		Short: "Export and import access control.",
+		Long: `Export the access control of the server, its anonymous access, users with
+their keys and roles, and the visibility and collaborators of repos, as a single YAML
+or JSON document, and import it on another server, e.g. to migrate in stages
+or to rehearse disaster recovery. HTTP passwords and tokens aren't exported.
+
+Imports are committed to the config repo.`,
+		Example: `  acl export > acl.yaml
+  acl import < acl.yaml
+  acl import --prune < acl.json`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleUserAdmin, config.RoleAuditor),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleUserAdmin, config.RoleAuditor)
+		},
+	}
+
+	var format string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Print the access control of the server.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				format = "json"
+			}
+			acl := ac.ExportACL()
+			switch format {
+			case "json":
+				enc := json.NewEncoder(s)
+				enc.SetIndent("", "  ")
+				return enc.Encode(acl)
+			case "yaml":
+				enc := yaml.NewEncoder(s)
+				enc.SetIndent(2)
+				if err := enc.Encode(acl); err != nil {
+					return err
+				}
+				return enc.Close()
+			}
+			return invalidArgument(cmd, fmt.Errorf("invalid format %q, must be yaml or json", format))
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", "yaml", "Output format, yaml or json")
+
+	var prune bool
+	importCmd := dangerous(&cobra.Command{
+		Use:   "import",
+		Short: "Apply the access control read from stdin.",
+		Long: `Apply the access control read from stdin, in YAML or JSON. Users are matched
+by name and keep their other settings, such as aliases and credentials. With
+--prune, users missing from the document are removed, so that the server ends
+up with the exact access control of the document. Pruning is refused when the
+document is missing repos that are private, have collaborators, or override
+anonymous access, rather than making them public: list them, with private:
+false to make them public.
+
+Imports can grant admin access and roles, so only admins can import.`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if ac.AuthRepoCtx(s.Context(), "config", s.PublicKey()) < gitwish.AdminAccess {
+				return ErrUnauthorized
+			}
+			var acl config.ACL
+			dec := yaml.NewDecoder(s)
+			dec.KnownFields(true)
+			if err := dec.Decode(&acl); err != nil {
+				if errors.Is(err, io.EOF) {
+					err = errors.New("no access control read from stdin")
+				}
+				return invalidArgument(cmd, err)
+			}
+			if err := acl.Validate(); err != nil {
+				return invalidArgument(cmd, err)
+			}
+			err := ac.ImportACL(acl, prune)
+			if errors.Is(err, config.ErrACLMissingRepos) {
+				return invalidArgument(cmd, err)
+			}
+			return err
+		},
+	})
+	importCmd.Flags().BoolVar(&prune, "prune", false, "Remove users missing from the document")
+
+	aclCmd.AddCommand(exportCmd, importCmd)
+
+	return aclCmd
+}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/soft-serve/artifacts"
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrArtifactNotFound is returned when the artifact is not found.
+	ErrArtifactNotFound = &Error{
+		Code:    "artifact_not_found",
+		Message: "Artifact not found",
+		Hint:    "run artifact list REPO REV to list the artifacts of a commit or release",
+		Status:  StatusNotFound,
+	}
+	// ErrRevisionNotFound is returned when a revision doesn't resolve to a
+	// commit.
+	ErrRevisionNotFound = &Error{
+		Code:    "revision_not_found",
+		Message: "Revision not found",
+		Hint:    "use a branch, a tag, or a commit hash",
+		Status:  StatusNotFound,
+	}
+	// ErrArtifactTooLarge is returned when an artifact is too large.
+	ErrArtifactTooLarge = &Error{
+		Code:    "artifact_too_large",
+		Message: fmt.Sprintf("Artifacts can't be larger than %s", humanize.IBytes(artifacts.MaxSize)),
+		Hint:    "attach small files such as checksums, SBOMs, and signatures",
+		Status:  StatusInvalidArgument,
+	}
+)
+
+// ArtifactCommand returns a command that manages the artifacts attached to
+// commits and releases.
+func ArtifactCommand() *cobra.Command {
+	artifactCmd := &cobra.Command{
+		Use:     "artifact",
+		Aliases: []string{"artifacts"},
+		Short:   "Manage artifacts attached to commits and releases.",
+		Long: `Manage small files attached to commits and releases, such as checksums,
+SBOMs, and signed provenance. Artifacts attached to a tag belong to that
+release; those attached to a branch belong to the commit it points to.
+
+Artifacts are stored by their SHA-256 digest. Those of repos readable
+anonymously can be downloaded over HTTP at /REPO/artifacts/DIGEST/NAME.`,
+		Example: `  artifact upload soft-serve v1.0.0 sbom.json < sbom.json
+  artifact list soft-serve v1.0.0
+  artifact get soft-serve v1.0.0 sbom.json > sbom.json
+  artifact remove soft-serve v1.0.0 sbom.json`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+	}
+
+	uploadCmd := &cobra.Command{
+		Use:   "upload REPO REV NAME",
+		Short: "Attach an artifact read from stdin to a commit or release.",
+		Long: `Attach an artifact read from stdin to a commit or release, replacing the
+artifact with the same name.`,
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeRepo,
+		Annotations: map[string]string{
+			accessAnnotation: "read-write",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := checkArtifactAccess(cmd, args[0], gitwish.ReadWriteAccess); err != nil {
+				return err
+			}
+			a, err := ac.AttachArtifact(args[0], args[1], args[2], s, s.PublicKey())
+			if err != nil {
+				return artifactError(cmd, err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(a)
+			}
+			fmt.Fprintf(s, "%s\t%s\n", a.Digest, a.Name)
+			return nil
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:               "list REPO [REV]",
+		Aliases:           []string{"ls"},
+		Short:             "List the artifacts of a repository, commit, or release.",
+		Args:              cobra.RangeArgs(1, 2),
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := checkArtifactAccess(cmd, args[0], gitwish.ReadOnlyAccess); err != nil {
+				return err
+			}
+			rev := ""
+			if len(args) > 1 {
+				rev = args[1]
+			}
+			as, err := ac.RepoArtifacts(args[0], rev)
+			if err != nil {
+				return artifactError(cmd, err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(as)
+			}
+			w := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tCOMMIT\tTAG\tSIZE\tDIGEST\tUPLOADED")
+			for _, a := range as {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+					a.Name,
+					a.Commit[:7],
+					a.Tag,
+					humanize.IBytes(uint64(a.Size)),
+					a.Digest,
+					humanize.Time(a.Created),
+				)
+			}
+			return w.Flush()
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:               "get REPO REV NAME",
+		Short:             "Print the content of an artifact.",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := checkArtifactAccess(cmd, args[0], gitwish.ReadOnlyAccess); err != nil {
+				return err
+			}
+			as, err := ac.RepoArtifacts(args[0], args[1])
+			if err != nil {
+				return artifactError(cmd, err)
+			}
+			for _, a := range as {
+				if a.Name != args[2] {
+					continue
+				}
+				f, err := ac.Artifacts.Open(args[0], a.Digest)
+				if err != nil {
+					return artifactError(cmd, err)
+				}
+				defer f.Close()
+				_, err = io.Copy(s, f)
+				return err
+			}
+			return ErrArtifactNotFound
+		},
+	}
+
+	removeCmd := dangerous(&cobra.Command{
+		Use:               "remove REPO REV NAME",
+		Aliases:           []string{"rm"},
+		Short:             "Remove an artifact from a commit or release.",
+		Args:              cobra.ExactArgs(3),
+		ValidArgsFunction: completeRepo,
+		Annotations: map[string]string{
+			accessAnnotation: "read-write",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			if err := checkArtifactAccess(cmd, args[0], gitwish.ReadWriteAccess); err != nil {
+				return err
+			}
+			return artifactError(cmd, ac.DeleteArtifact(args[0], args[1], args[2]))
+		},
+	})
+
+	artifactCmd.AddCommand(uploadCmd, listCmd, getCmd, removeCmd)
+
+	return artifactCmd
+}
+
+// checkArtifactAccess returns an error unless the user has the given access
+// to the repo.
+func checkArtifactAccess(cmd *cobra.Command, repo string, level gitwish.AccessLevel) error {
+	ac, s := fromContext(cmd)
+	if ac.AuthRepoCtx(s.Context(), repo, s.PublicKey()) < level {
+		return ErrUnauthorized
+	}
+	if _, err := ac.Source.GetRepo(repo); err != nil {
+		return ErrRepoNotFound
+	}
+	return nil
+}
+
+// artifactError maps artifact errors to command errors.
+func artifactError(cmd *cobra.Command, err error) error {
+	switch {
+	case errors.Is(err, artifacts.ErrNotFound):
+		return ErrArtifactNotFound
+	case errors.Is(err, artifacts.ErrTooLarge):
+		return ErrArtifactTooLarge
+	case errors.Is(err, config.ErrUnknownRevision):
+		return ErrRevisionNotFound
+	case errors.Is(err, artifacts.ErrInvalidName):
+		return invalidArgument(cmd, err)
+	}
+	return err
+}
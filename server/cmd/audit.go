@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/audit"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// AuditCommand returns a command that shows the audit log.
+func AuditCommand() *cobra.Command {
+	var since string
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the audit log.",
+		Long: `Show the entries of the audit log, oldest first: authentication attempts,
+repositories created and deleted, visibility and config changes, and admin
+commands, with the user, key fingerprint, and address of the client when
+known.
+
+Entries are kept in the data path for as long as the audit-logs retention
+policy allows. --since takes a date, an RFC 3339 time, or a duration ago,
+e.g. 72h.`,
+		Example: `  audit --since 24h
+  audit --since 2023-01-01 --json`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleAuditor),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleAuditor); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			from, err := parseSince(since, time.Now())
+			if err != nil {
+				return invalidArgument(cmd, err)
+			}
+			es, err := audit.NewLog(ac.Cfg.DataPath).Entries(from)
+			if err != nil {
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(es)
+			}
+			for _, e := range es {
+				what := e.Repo
+				switch {
+				case e.Command != "":
+					what = e.Command
+				case e.Method != "":
+					what = e.Method
+				}
+				fmt.Fprintf(s, "%s\t%s\t%s\t%s\t%s\t%s", e.Time.Format(time.RFC3339), e.Type,
+					orDash(e.User), orDash(e.Key), orDash(e.RemoteAddr), orDash(what))
+				if e.Reason != "" {
+					fmt.Fprintf(s, "\treason: %s", e.Reason)
+				}
+				if e.Error != "" {
+					fmt.Fprintf(s, "\terror: %s", e.Error)
+				}
+				fmt.Fprintln(s)
+			}
+			return nil
+		},
+	}
+	auditCmd.Flags().StringVar(&since, "since", "", "Show the entries since a date, time, or duration ago")
+	return auditCmd
+}
+
+// orDash returns s, or "-" if it's empty, so that the columns of entries
+// missing a field stay aligned.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
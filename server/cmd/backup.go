@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/charmbracelet/soft-serve/backup"
+	"github.com/spf13/cobra"
+)
+
+// BackupCommand returns a command that writes a snapshot of the server.
+func BackupCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "backup",
+		Short: "Write a snapshot of the server to stdout.",
+		Long: `Write a snapshot of the server to stdout, as a gzipped tarball: all repos,
+the data path, and the SSH host key and secrets store of the server. Restore
+it onto a fresh instance with soft restore.
+
+Each repository is copied while pushes to it wait, so that snapshots never
+hold half-written pushes. The snapshot holds the keys of the server, keep it
+safe.`,
+		Example: `  backup > soft-serve.tar.gz`,
+		Args:    cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			return backup.WriteSnapshot(s.Context(), ac, s)
+		},
+	}
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// bandwidthTotal is the bandwidth used by a user, repository, or day,
+// printed by the bandwidth command.
+type bandwidthTotal struct {
+	Key string `json:"key"`
+	In  int64  `json:"in"`
+	Out int64  `json:"out"`
+}
+
+// BandwidthCommand returns a command that reports the bandwidth usage.
+func BandwidthCommand() *cobra.Command {
+	var since, by string
+	bandwidthCmd := &cobra.Command{
+		Use:   "bandwidth",
+		Short: "Report the bandwidth usage by user, repository, or day.",
+		Long: `Report the bytes transferred by SSH sessions and git HTTP requests, by user,
+repository, or day, most first, to enforce fair-use policies on shared
+servers.
+
+Usage is aggregated daily, in UTC, and attributed to the user and the
+repository of each session or request. Sessions that don't run on a
+repository, such as those of the SSH CLI, are reported under "-". --since
+takes a date, an RFC 3339 time, or a duration ago, e.g. 168h, and defaults to
+the start of the day.`,
+		Example: `  bandwidth
+  bandwidth --since 168h --by repo
+  bandwidth --since 2023-01-01 --by day --json`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			now := time.Now()
+			from := now.UTC().Truncate(24 * time.Hour)
+			if since != "" {
+				var err error
+				if from, err = parseSince(since, now); err != nil {
+					return invalidArgument(cmd, err)
+				}
+			}
+			if by != "user" && by != "repo" && by != "day" {
+				return invalidArgument(cmd, fmt.Errorf("invalid --by %q, must be user, repo, or day", by))
+			}
+			bs, err := ac.BandwidthUsage(from)
+			if err != nil {
+				return err
+			}
+			totals := make(map[string]*bandwidthTotal)
+			for _, b := range bs {
+				key := b.User
+				switch by {
+				case "repo":
+					key = b.Repo
+				case "day":
+					key = b.Day
+				}
+				if key == "" {
+					key = "-"
+				}
+				t, ok := totals[key]
+				if !ok {
+					t = &bandwidthTotal{Key: key}
+					totals[key] = t
+				}
+				t.In += b.In
+				t.Out += b.Out
+			}
+			rows := make([]bandwidthTotal, 0, len(totals))
+			for _, t := range totals {
+				rows = append(rows, *t)
+			}
+			sort.Slice(rows, func(i, j int) bool {
+				if by == "day" {
+					return rows[i].Key < rows[j].Key
+				}
+				ti, tj := rows[i].In+rows[i].Out, rows[j].In+rows[j].Out
+				if ti == tj {
+					return rows[i].Key < rows[j].Key
+				}
+				return ti > tj
+			})
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(rows)
+			}
+			w := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			fmt.Fprintf(w, "%s\tIN\tOUT\tTOTAL\n", map[string]string{"user": "USER", "repo": "REPO", "day": "DAY"}[by])
+			for _, r := range rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					r.Key,
+					humanize.Bytes(uint64(r.In)),
+					humanize.Bytes(uint64(r.Out)),
+					humanize.Bytes(uint64(r.In+r.Out)),
+				)
+			}
+			return w.Flush()
+		},
+	}
+	bandwidthCmd.Flags().StringVar(&since, "since", "", "Report the usage since a date, time, or duration ago")
+	bandwidthCmd.Flags().StringVar(&by, "by", "user", "Group the usage by user, repo, or day")
+	return bandwidthCmd
+}
@@ -30,13 +30,19 @@ func CatCommand() *cobra.Command {
 	catCmd := &cobra.Command{
 		Use:   "cat PATH",
 		Short: "Outputs the contents of the file at path.",
-		Args:  cobra.ExactArgs(1),
+		Example: `  cat soft-serve/README.md
+  cat soft-serve/cmd/soft/root.go -c -l`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRepoPath,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ac, s := fromContext(cmd)
 			ps := strings.Split(args[0], "/")
 			rn := ps[0]
 			fp := strings.Join(ps[1:], "/")
-			auth := ac.AuthRepo(rn, s.PublicKey())
+			auth := ac.AuthRepoCtx(s.Context(), rn, s.PublicKey())
 			if auth < gitwish.ReadOnlyAccess {
 				return ErrUnauthorized
 			}
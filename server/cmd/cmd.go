@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	"fmt"
+	"strings"
 
 	appCfg "github.com/charmbracelet/soft-serve/config"
 	"github.com/gliderlabs/ssh"
@@ -24,13 +24,6 @@ var (
 )
 
 var (
-	// ErrUnauthorized is returned when the user is not authorized to perform action.
-	ErrUnauthorized = fmt.Errorf("Unauthorized")
-	// ErrRepoNotFound is returned when the repo is not found.
-	ErrRepoNotFound = fmt.Errorf("Repository not found")
-	// ErrFileNotFound is returned when the file is not found.
-	ErrFileNotFound = fmt.Errorf("File not found")
-
 	usageTemplate = `Usage:{{if .Runnable}}{{if .HasParent }}
   {{.Parent.Use}} {{end}}{{.Use}}{{if .HasAvailableFlags }} [flags]{{end}}{{end}}{{if .HasAvailableSubCommands}}
   {{if .HasParent }}{{.Parent.Use}} {{end}}{{.Use}} [command]{{end}}{{if gt (len .Aliases) 0}}
@@ -39,7 +32,10 @@ Aliases:
   {{.NameAndAliases}}{{end}}{{if .HasExample}}
 
 Examples:
-{{.Example}}{{end}}{{if .HasAvailableSubCommands}}
+{{.Example}}{{end}}{{with index .Annotations "access"}}
+
+Required access:
+  {{.}}{{end}}{{if .HasAvailableSubCommands}}
 
 Available Commands:{{range .Commands}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
   {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
@@ -53,7 +49,7 @@ Global Flags:
 Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
   {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
 
-Use "{{.UseLine}} [command] --help" for more information about a command.{{end}}
+Use "{{.Root.Use}} help [command]" for more information about a command.{{end}}
 `
 )
 
@@ -66,13 +62,48 @@ func RootCommand() *cobra.Command {
 		DisableFlagsInUseLine: true,
 	}
 	rootCmd.SetUsageTemplate(usageTemplate)
+	rootCmd.SetHelpCommand(HelpCommand())
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.AddCommand(
 		ReloadCommand(),
 		CatCommand(),
+		CollabCommand(),
+		CreateCommand(),
 		ListCommand(),
 		GitCommand(),
+		RangeDiffCommand(),
+		CompletionCommand(),
+		InfoCommand(),
+		OrphansCommand(),
+		DiskUsageCommand(),
+		SecretCommand(),
+		GCCommand(),
+		HousekeepingCommand(),
+		BackupCommand(),
+		RetentionCommand(),
+		EventsCommand(),
+		MigrateCommand(),
+		RepoCommand(),
+		SearchCommand(),
+		ArtifactCommand(),
+		FindCommand(),
+		ReleasesCommand(),
+		InboxCommand(),
+		MaintenanceCommand(),
+		AuditCommand(),
+		SessionsCommand(),
+		BandwidthCommand(),
+		TokenCommand(),
+		ACLCommand(),
+		UserCommand(),
+		TOTPCommand(),
+		AccessCommand(),
 	)
+	rootCmd.PersistentFlags().Bool("json", false, "Print output and errors as JSON")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		silenceIfJSON(cmd)
+	}
+	withArgsError(rootCmd)
 
 	return rootCmd
 }
@@ -83,3 +114,23 @@ func fromContext(cmd *cobra.Command) (*appCfg.Config, ssh.Session) {
 	s := ctx.Value(SessionCtxKey).(ssh.Session)
 	return ac, s
 }
+
+// roleAccess returns the access annotation of commands requiring admin access
+// or one of roles.
+func roleAccess(roles ...appCfg.Role) string {
+	access := []string{"admin-access"}
+	for _, r := range roles {
+		access = append(access, string(r))
+	}
+	return strings.Join(access, " or ")
+}
+
+// checkRole returns ErrUnauthorized unless the user of the session is an
+// admin or has one of roles.
+func checkRole(cmd *cobra.Command, roles ...appCfg.Role) error {
+	ac, s := fromContext(cmd)
+	if !ac.HasRole(s.PublicKey(), roles...) {
+		return ErrUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// ErrUserNotFound is returned when the user is not in the configuration.
+var ErrUserNotFound = &Error{
+	Code:    "user_not_found",
+	Message: "User not found",
+	Hint:    "add the user to the config repo first",
+	Status:  StatusNotFound,
+}
+
+// CollabCommand returns a command that manages the collaborators of
+// repositories.
+func CollabCommand() *cobra.Command {
+	collabCmd := &cobra.Command{
+		Use:   "collab",
+		Short: "Manage collaborators of repositories.",
+		Long: `Manage collaborators of repositories. Collaborators have read-write access
+to a repository, even when it's private.
+
+Access can be granted until a date, e.g. to external contributors: it ends
+at the end of that day (UTC), and the collaborator is then removed. User
+admins are warned a week before.
+
+Changes are committed to the config repo.`,
+		Example: `  collab add soft-serve Frankie
+  collab add soft-serve Frankie --expires 2024-12-31
+  collab list soft-serve
+  collab remove soft-serve Frankie`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleUserAdmin),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleUserAdmin)
+		},
+	}
+
+	setCollab := func(collab bool, expires *string) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn, user := args[0], args[1]
+			if _, err := ac.Source.GetRepo(rn); err != nil {
+				return err
+			}
+			// Collaborators of the config repo can push a config making
+			// them admins, so only admins can manage them.
+			if rn == "config" && ac.AuthRepoCtx(s.Context(), rn, s.PublicKey()) < gitwish.AdminAccess {
+				return ErrUnauthorized
+			}
+			var err error
+			switch {
+			case !collab:
+				err = ac.SetCollab(rn, user, false)
+			case *expires != "":
+				var t time.Time
+				if t, err = parseExpiry(*expires, time.Now()); err != nil {
+					return invalidArgument(cmd, err)
+				}
+				err = ac.AddCollab(rn, user, t)
+			default:
+				err = ac.AddCollab(rn, user, time.Time{})
+			}
+			if errors.Is(err, config.ErrUnknownUser) {
+				return ErrUserNotFound
+			} else if err != nil {
+				return err
+			}
+			fmt.Fprintf(s, "%s\n", rn)
+			return nil
+		}
+	}
+
+	var expires string
+	addCmd := &cobra.Command{
+		Use:               "add REPO USER",
+		Short:             "Add a collaborator to a repository.",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeRepo,
+		RunE:              setCollab(true, &expires),
+	}
+	addCmd.Flags().StringVar(&expires, "expires", "", "End of the access, as a date, an RFC 3339 time, or a duration, e.g. 2024-12-31 or 720h")
+
+	removeCmd := dangerous(&cobra.Command{
+		Use:               "remove REPO USER",
+		Aliases:           []string{"rm"},
+		Short:             "Remove a collaborator from a repository.",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeRepo,
+		RunE:              setCollab(false, nil),
+	})
+
+	listCmd := &cobra.Command{
+		Use:               "list REPO",
+		Aliases:           []string{"ls"},
+		Short:             "List the collaborators of a repository.",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if _, err := ac.Source.GetRepo(args[0]); err != nil {
+				return err
+			}
+			grants := ac.CollabGrants(args[0])
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(grants)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, g := range grants {
+				exp := "-"
+				if !g.Expires.IsZero() {
+					exp = g.Expires.UTC().Format(time.RFC3339)
+				}
+				fmt.Fprintf(tw, "%s\t%s\n", g.User, exp)
+			}
+			return tw.Flush()
+		},
+	}
+
+	collabCmd.AddCommand(addCmd, removeCmd, listCmd)
+
+	return collabCmd
+}
+
+// parseExpiry parses the end of a temporary access: a date, ending at the end
+// of that day in UTC, or a time as parsed by parseWhen.
+func parseExpiry(s string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("2006-01-02", s)
+	if err == nil {
+		t = t.AddDate(0, 0, 1)
+	} else if t, err = parseWhen("expires", s, now); err != nil {
+		return time.Time{}, fmt.Errorf("invalid --expires %q, e.g. 2024-12-31, 2024-12-31T18:00:00Z, or 720h", s)
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("--expires %q is in the past", s)
+	}
+	return t, nil
+}
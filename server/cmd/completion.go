@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// CompletionCommand returns a command that generates shell completion scripts
+// for the SSH command set.
+//
+// The generated script defines a shell function wrapping the SSH invocation,
+// and registers completions for it. Completions are requested from the server
+// so repository names are always up to date.
+func CompletionCommand() *cobra.Command {
+	var name string
+
+	completionCmd := &cobra.Command{
+		Use:   "completion bash|zsh|fish",
+		Short: "Generate shell completion scripts.",
+		Long: `Generate shell completion scripts for the SSH command line.
+
+The script defines a shell function, named "soft" by default, that runs
+commands on this server, and completes commands, flags, and repository names
+for it. Load it in your current shell with:
+
+  source <(ssh HOST completion bash)`,
+		Example: `  completion bash > ~/.local/share/bash-completion/completions/soft
+  completion zsh --name git-host >> ~/.zshrc
+  completion fish | source`,
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, s := fromContext(cmd)
+			root := cmd.Root()
+			// The SSH invocation is stored in the root command usage, e.g.
+			// "ssh -p23231 localhost".
+			sshCmd := root.Use
+			root.Use = name
+			defer func() { root.Use = sshCmd }()
+			buf := &bytes.Buffer{}
+			var err error
+			switch args[0] {
+			case "bash":
+				fmt.Fprintf(buf, "%s() { %s \"$(printf '%%q ' \"$@\")\"; }\n\n", name, sshCmd)
+				err = root.GenBashCompletionV2(buf, true)
+			case "zsh":
+				fmt.Fprintf(buf, "%s() { %s \"${(j: :)${(qq)@}}\"; }\n\n", name, sshCmd)
+				err = root.GenZshCompletion(buf)
+			case "fish":
+				fmt.Fprintf(buf, "function %s\n    %s (string join ' ' -- (string escape -- $argv))\nend\n\n", name, sshCmd)
+				err = root.GenFishCompletion(buf, true)
+			}
+			if err != nil {
+				return err
+			}
+			_, err = s.Write(buf.Bytes())
+			return err
+		},
+	}
+	completionCmd.Flags().StringVarP(&name, "name", "n", "soft", "Name of the shell function to define")
+
+	return completionCmd
+}
+
+// completeRepo completes the name of a repository the user can read as the
+// first argument of a command.
+func completeRepo(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return repoNames(cmd, toComplete, ""), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRepoPath completes the repository part of a REPO/PATH argument.
+func completeRepoPath(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 || strings.Contains(toComplete, "/") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return repoNames(cmd, toComplete, "/"), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+}
+
+// repoNames returns the names of repositories the user can read that start
+// with prefix, followed by suffix.
+func repoNames(cmd *cobra.Command, prefix string, suffix string) []string {
+	ac, s := fromContext(cmd)
+	names := make([]string, 0)
+	for _, r := range ac.Source.AllRepos() {
+		rn := r.Repo()
+		if !strings.HasPrefix(rn, prefix) {
+			continue
+		}
+		if ac.AuthRepoCtx(s.Context(), rn, s.PublicKey()) >= gitwish.ReadOnlyAccess {
+			names = append(names, rn+suffix)
+		}
+	}
+	return names
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// CreateCommand returns a command that creates an empty repository.
+func CreateCommand() *cobra.Command {
+	createCmd := &cobra.Command{
+		Use:   "create REPO",
+		Short: "Create an empty repository.",
+		Long: `Create an empty repository.
+
+Anyone allowed to create a repository by pushing to it can create it
+with this command too. Set its visibility and collaborators in the config
+repo.`,
+		Example: `  create my-project`,
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: "read-write",
+			auditAnnotation:  "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn := strings.TrimSuffix(args[0], ".git")
+			if rn == "" || strings.HasPrefix(rn, "/") || strings.Contains(rn, "..") {
+				return invalidArgument(cmd, fmt.Errorf("invalid repository name %q", args[0]))
+			}
+			auth := ac.AuthRepoCtx(s.Context(), rn, s.PublicKey())
+			if auth < gitwish.ReadWriteAccess {
+				return ErrUnauthorized
+			}
+			if _, err := ac.Source.GetRepo(rn); err == nil {
+				return &Error{
+					Code:    "repo_exists",
+					Message: "Repository already exists",
+					Status:  StatusError,
+				}
+			}
+			if _, err := ac.Source.InitRepo(rn, true); err != nil {
+				return err
+			}
+			// Reloading publishes the repo-created event.
+			if err := ac.Reload(); err != nil {
+				return fmt.Errorf("created, but reloading the configuration failed: %w", err)
+			}
+			fmt.Fprintf(s, "Created %s\n", rn)
+			return nil
+		},
+	}
+	return createCmd
+}
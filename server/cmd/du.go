@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// repoUsage is the disk usage of a repository printed by the du command.
+type repoUsage struct {
+	Repo string `json:"repo"`
+	// Measured is false for repos the server didn't measure yet.
+	Measured bool `json:"measured"`
+	config.DiskUsage
+	// Delta is the change in total size since the previous measurement.
+	Delta int64 `json:"delta"`
+	// Previous is the previous measurement, if any.
+	Previous *config.DiskUsage `json:"previous,omitempty"`
+}
+
+// DiskUsageCommand returns a command that reports the disk usage of
+// repositories.
+func DiskUsageCommand() *cobra.Command {
+	duCmd := &cobra.Command{
+		Use:   "du [REPO]",
+		Short: "Report the disk usage of repositories.",
+		Long: `Report the on-disk size of repositories, largest first.
+
+Usage is measured periodically by the server, when it enforces retention
+policies. The change column shows how much each repository grew or shrank
+between the last two measurements.`,
+		Example: `  du
+  du soft-serve --json`,
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeRepo,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			repos := make([]*config.Repo, 0)
+			if len(args) > 0 {
+				if ac.AuthRepoCtx(s.Context(), args[0], s.PublicKey()) < gitwish.ReadOnlyAccess {
+					return ErrUnauthorized
+				}
+				r, err := ac.Source.GetRepo(args[0])
+				if err != nil {
+					return err
+				}
+				repos = append(repos, r)
+			} else {
+				for _, r := range ac.Source.AllRepos() {
+					if ac.AuthRepoCtx(s.Context(), r.Repo(), s.PublicKey()) >= gitwish.ReadOnlyAccess {
+						repos = append(repos, r)
+					}
+				}
+			}
+			usage := make([]repoUsage, 0, len(repos))
+			for _, r := range repos {
+				cur, prev, ok, err := ac.DiskUsage(r.Repo())
+				if err != nil {
+					return err
+				}
+				ru := repoUsage{
+					Repo:      r.Repo(),
+					Measured:  ok,
+					DiskUsage: cur,
+				}
+				if prev != nil {
+					ru.Delta = cur.Total - prev.Total
+					ru.Previous = prev
+				}
+				usage = append(usage, ru)
+			}
+			sort.SliceStable(usage, func(i, j int) bool {
+				if usage[i].Total == usage[j].Total {
+					return usage[i].Repo < usage[j].Repo
+				}
+				return usage[i].Total > usage[j].Total
+			})
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(usage)
+			}
+			w := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "REPO\tTOTAL\tOBJECTS\tLFS\tCHANGE")
+			for _, u := range usage {
+				if !u.Measured {
+					fmt.Fprintf(w, "%s\t-\t-\t-\tnot measured yet\n", u.Repo)
+					continue
+				}
+				change := "-"
+				if u.Previous != nil {
+					change = formatDelta(u.Delta) + " since " + humanize.Time(u.Previous.Time)
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					u.Repo,
+					humanize.Bytes(uint64(u.Total)),
+					humanize.Bytes(uint64(u.Objects)),
+					humanize.Bytes(uint64(u.LFS)),
+					change,
+				)
+			}
+			return w.Flush()
+		},
+	}
+	return duCmd
+}
+
+// formatDelta formats a size difference in bytes with a sign.
+func formatDelta(d int64) string {
+	if d < 0 {
+		return "-" + humanize.Bytes(uint64(-d))
+	}
+	return "+" + humanize.Bytes(uint64(d))
+}
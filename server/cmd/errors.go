@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses of SSH commands. These are stable and can be relied on by
+// scripts.
+const (
+	// StatusError is the exit status of unexpected errors.
+	StatusError = 1
+	// StatusInvalidArgument is the exit status of invalid arguments and flags.
+	StatusInvalidArgument = 2
+	// StatusUnauthorized is the exit status of permission errors.
+	StatusUnauthorized = 3
+	// StatusNotFound is the exit status of missing repositories and files.
+	StatusNotFound = 4
+)
+
+// Error is an error returned by a command. It carries a stable code and exit
+// status so automation can tell errors apart.
+type Error struct {
+	// Code is a stable machine-readable error code.
+	Code string `json:"code"`
+	// Message is a human-readable error message.
+	Message string `json:"message"`
+	// Hint is an optional suggestion on how to fix the error.
+	Hint string `json:"hint,omitempty"`
+	// Status is the exit status of the command.
+	Status int `json:"-"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	// ErrUnauthorized is returned when the user is not authorized to perform action.
+	ErrUnauthorized = &Error{
+		Code:    "unauthorized",
+		Message: "Unauthorized",
+		Hint:    "check that you are using a key with access to this repository",
+		Status:  StatusUnauthorized,
+	}
+	// ErrRepoNotFound is returned when the repo is not found.
+	ErrRepoNotFound = &Error{
+		Code:    "repo_not_found",
+		Message: "Repository not found",
+		Hint:    "run ls to list available repositories",
+		Status:  StatusNotFound,
+	}
+	// ErrFileNotFound is returned when the file is not found.
+	ErrFileNotFound = &Error{
+		Code:    "file_not_found",
+		Message: "File not found",
+		Hint:    "run ls REPO/PATH to list files",
+		Status:  StatusNotFound,
+	}
+)
+
+// silenceIfJSON silences the cobra error and usage output of cmd when errors
+// are printed as JSON, so they don't get mixed with the error envelope.
+func silenceIfJSON(cmd *cobra.Command) {
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+	}
+}
+
+// invalidArgument returns an invalid argument error for cmd.
+func invalidArgument(cmd *cobra.Command, err error) error {
+	silenceIfJSON(cmd)
+	return &Error{
+		Code:    "invalid_argument",
+		Message: err.Error(),
+		Hint:    fmt.Sprintf("run help %s for usage", cmd.Name()),
+		Status:  StatusInvalidArgument,
+	}
+}
+
+// withArgsError makes argument validation errors of cmd and its
+// subcommands invalid argument errors.
+func withArgsError(cmd *cobra.Command) {
+	if args := cmd.Args; args != nil {
+		cmd.Args = func(c *cobra.Command, a []string) error {
+			if err := args(c, a); err != nil {
+				return invalidArgument(c, err)
+			}
+			return nil
+		}
+	}
+	cmd.SetFlagErrorFunc(func(c *cobra.Command, err error) error {
+		return invalidArgument(c, err)
+	})
+	for _, c := range cmd.Commands() {
+		withArgsError(c)
+	}
+}
+
+// AsError converts err to an Error.
+func AsError(err error) *Error {
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, config.ErrMissingRepo):
+		return ErrRepoNotFound
+	case errors.Is(err, git.ErrFileNotFound), errors.Is(err, git.ErrRevisionNotExist):
+		return ErrFileNotFound
+	case errors.Is(err, git.ErrInvalidRange):
+		return &Error{
+			Code:    "invalid_argument",
+			Message: err.Error(),
+			Status:  StatusInvalidArgument,
+		}
+	default:
+		return &Error{
+			Code:    "internal",
+			Message: err.Error(),
+			Status:  StatusError,
+		}
+	}
+}
+
+// WriteError writes err to w, as a JSON object if asJSON is true, and returns
+// the exit status of the error.
+func WriteError(w io.Writer, err error, asJSON bool) int {
+	e := AsError(err)
+	if asJSON {
+		_ = json.NewEncoder(w).Encode(e)
+	} else {
+		fmt.Fprint(w, e.Message)
+	}
+	return e.Status
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/ci"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/hooks"
+	"github.com/charmbracelet/soft-serve/retention"
+	"github.com/spf13/cobra"
+)
+
+// EventsCommand returns a command that manages the stored events.
+func EventsCommand() *cobra.Command {
+	eventsCmd := &cobra.Command{
+		Use:   "events",
+		Short: "Manage stored events.",
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+	}
+	eventsCmd.AddCommand(eventsReplayCommand(), eventsTestCommand())
+	return eventsCmd
+}
+
+func eventsReplayCommand() *cobra.Command {
+	var repo, since, target, signingSecret string
+	var dryRun bool
+	replayCmd := &cobra.Command{
+		Use:   "replay",
+		Short: "Replay stored push events to a webhook.",
+		Long: `Post the stored push events to a webhook again, oldest first, to backfill a
+new CI or search index. Events are posted as JSON like those of hooks, with
+"replayed" set to true.
+
+Push events are stored in the data path for as long as the events retention
+policy allows. --since takes a date, an RFC 3339 time, or a duration ago,
+e.g. 72h. Replaying stops at the first event the webhook fails to take.
+With --signing-secret, events are signed with the key held by the secret, like
+those of hooks.`,
+		Example: `  events replay --repo my-repo --since 2023-01-01 --target https://ci.example.com/hook
+  events replay --since 72h --target https://search.example.com/hook --dry-run`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			from, err := parseSince(since, time.Now())
+			if err != nil {
+				return invalidArgument(cmd, err)
+			}
+			if u, err := url.Parse(target); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+				return invalidArgument(cmd, fmt.Errorf("invalid target %q, must be an http or https URL", target))
+			}
+			if repo != "" {
+				if _, err := ac.Source.GetRepo(repo); err != nil {
+					return ErrRepoNotFound
+				}
+			}
+			var key string
+			if signingSecret != "" {
+				if ac.Secrets == nil {
+					return errors.New("secrets are not configured")
+				}
+				if key, err = ac.Secrets.Get(signingSecret); err != nil {
+					return invalidArgument(cmd, fmt.Errorf("secret %q: %w", signingSecret, err))
+				}
+			}
+			store := events.NewStore(retention.Dir(ac.Cfg.DataPath, retention.Events))
+			es, err := store.Events(repo, from)
+			if err != nil {
+				return err
+			}
+			asJSON, _ := cmd.Flags().GetBool("json")
+			r := hooks.NewRunner()
+			replayed := make([]events.Event, 0, len(es))
+			for _, e := range es {
+				e.Replayed = true
+				if !dryRun {
+					if err := r.Fire(s.Context(), config.Hook{URL: target}, key, e); err != nil {
+						return fmt.Errorf("replayed %d of %d events: %w", len(replayed), len(es), err)
+					}
+				}
+				replayed = append(replayed, e)
+				if !asJSON {
+					fmt.Fprintf(s, "%s\t%s\t%s\t%s\t%s\n", e.Time.Format(time.RFC3339), e.Type, e.Repo, e.Ref, e.Commit)
+				}
+			}
+			if asJSON {
+				return json.NewEncoder(s).Encode(replayed)
+			}
+			verb := "Replayed"
+			if dryRun {
+				verb = "Would replay"
+			}
+			fmt.Fprintf(s, "%s %d events to %s\n", verb, len(replayed), target)
+			return nil
+		},
+	}
+	replayCmd.Flags().StringVar(&repo, "repo", "", "Only replay the events of a repository")
+	replayCmd.Flags().StringVar(&since, "since", "", "Replay the events since a date, time, or duration ago")
+	replayCmd.Flags().StringVar(&target, "target", "", "URL of the webhook to post the events to")
+	replayCmd.Flags().StringVar(&signingSecret, "signing-secret", "", "Name of the secret holding the key to sign the events with")
+	replayCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "List the events that would be replayed without posting them")
+	_ = replayCmd.MarkFlagRequired("target")
+	return replayCmd
+}
+
+// testDelivery is the result of testing the delivery of an event to a hook or
+// CI pipeline.
+type testDelivery struct {
+	Kind      string               `json:"kind"`
+	Target    string               `json:"target"`
+	Checks    []config.FilterCheck `json:"checks"`
+	Matched   bool                 `json:"matched"`
+	Delivered bool                 `json:"delivered"`
+	Error     string               `json:"error,omitempty"`
+}
+
+func eventsTestCommand() *cobra.Command {
+	var repo, ref, target string
+	var dryRun bool
+	testCmd := &cobra.Command{
+		Use:   "test",
+		Short: "Test the delivery of a push to hooks and CI pipelines.",
+		Long: `Send a sample push event of a repository to its hooks and CI pipelines, as
+if the last commit of --ref, HEAD by default, had just been pushed. The
+event has "test" set to true.
+
+The filters of each hook and pipeline are evaluated first, and the result of
+each is shown. Only the endpoints whose filters all match get the event.
+--target limits the test to one hook URL or command, or pipeline, named like
+in integration deliveries. --dry-run evaluates the filters without
+delivering anything.`,
+		Example: `  events test --repo my-repo
+  events test --repo my-repo --ref v1.0.0 --dry-run
+  events test --repo my-repo --target "drone my-org/my-repo" --json`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			if _, err := ac.Source.GetRepo(repo); err != nil {
+				return ErrRepoNotFound
+			}
+			e, err := ac.TestPush(repo, ref)
+			if err != nil {
+				return invalidArgument(cmd, fmt.Errorf("ref %q: %w", ref, err))
+			}
+			files := ac.PushedFilesFunc(e)
+			runner, client := hooks.NewRunner(), ci.NewClient()
+			ds := make([]testDelivery, 0)
+			test := func(kind, t string, f config.Filters, deliver func() error) {
+				if target != "" && t != target {
+					return
+				}
+				d := testDelivery{Kind: kind, Target: t, Checks: f.Check(e, files)}
+				d.Matched = config.Matched(d.Checks)
+				if d.Matched && !dryRun {
+					if err := deliver(); err != nil {
+						d.Error = err.Error()
+					} else {
+						d.Delivered = true
+					}
+				}
+				ds = append(ds, d)
+			}
+			for _, h := range ac.HooksFor(string(e.Type), e.Repo) {
+				h := h
+				test("hook", hooks.Target(h), h.Filters(), func() error {
+					return runner.FireWithSecret(s.Context(), ac, h, e)
+				})
+			}
+			for _, c := range ac.RepoCI(e.Repo) {
+				c := c
+				test("ci", ci.Target(c), c.Filters(), func() error {
+					return client.TriggerWithSecret(s.Context(), ac, c, ci.Build{
+						Repo:   e.Repo,
+						Branch: strings.TrimPrefix(e.Ref, git.RefsHeads),
+						Commit: e.Commit,
+					})
+				})
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(ds)
+			}
+			if len(ds) == 0 {
+				fmt.Fprintf(s, "No hooks or CI pipelines for push events of %s\n", e.Repo)
+				return nil
+			}
+			fmt.Fprintf(s, "Push of %s to %s (%s)\n", e.Repo, strings.TrimPrefix(e.Ref, "refs/"), e.Commit[:7])
+			for _, d := range ds {
+				status := "skipped"
+				switch {
+				case d.Error != "":
+					status = "failed: " + d.Error
+				case d.Delivered:
+					status = "delivered"
+				case d.Matched:
+					status = "would deliver"
+				}
+				fmt.Fprintf(s, "\n%s %s: %s\n", d.Kind, d.Target, status)
+				for _, c := range d.Checks {
+					mark := "✓"
+					if !c.Match {
+						mark = "✗"
+					}
+					fmt.Fprintf(s, "  %s %s: %s\n", mark, c.Filter, c.Reason)
+				}
+			}
+			return nil
+		},
+	}
+	testCmd.Flags().StringVar(&repo, "repo", "", "Repository of the push")
+	testCmd.Flags().StringVar(&ref, "ref", "", "Branch or tag of the push, HEAD by default")
+	testCmd.Flags().StringVar(&target, "target", "", "Only test the hook or pipeline with this target")
+	testCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Evaluate the filters without delivering the event")
+	_ = testCmd.MarkFlagRequired("repo")
+	return testCmd
+}
+
+// parseSince parses a date, an RFC 3339 time, or a duration before now. The
+// zero time is returned for an empty string.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q, e.g. 2023-01-01, 2023-01-01T15:04:05Z, or 72h", s)
+}
@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// FindCommand returns a command that searches all the repositories for
+// dependents of a module or for code, e.g. to find the repositories affected
+// by a vulnerability.
+func FindCommand() *cobra.Command {
+	var allRefs bool
+
+	findCmd := &cobra.Command{
+		Use:   "find",
+		Short: "Search all repositories for dependencies or code.",
+		Long: `Search all repositories for dependencies or code, e.g. to find the
+repositories affected by a vulnerability.
+
+Repositories are searched at their default branch, or at every branch and tag
+with --all-refs. Results are grouped by repository and ref.`,
+		Example: `  find dependents github.com/gorilla/websocket
+  find dependents 'github.com/charmbracelet/*' --all-refs
+  find code "InsecureSkipVerify: true" --json`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleRepoAdmin, config.RoleAuditor),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleRepoAdmin, config.RoleAuditor)
+		},
+	}
+	findCmd.PersistentFlags().BoolVar(&allRefs, "all-refs", false, "Search every branch and tag")
+
+	dependentsCmd := &cobra.Command{
+		Use:   "dependents MODULE",
+		Short: "Find the repositories depending on a module.",
+		Long: `Find the repositories depending on a module, declared in their go.mod,
+package.json, or requirements.txt files. Module names match ignoring case,
+and can be patterns, e.g. github.com/charmbracelet/*.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			ms, err := ac.FindDependents(args[0], allRefs)
+			if err != nil {
+				return invalidArgument(cmd, err)
+			}
+			return printMatches(cmd, ms)
+		},
+	}
+
+	codeCmd := &cobra.Command{
+		Use:   "code STRING",
+		Short: "Find the lines of code containing a string.",
+		Long: `Find the lines of code containing a string. Binary files are skipped, and
+only the first matching lines of each repository and ref are listed.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			ms, err := ac.FindCode(args[0], allRefs)
+			if err != nil {
+				return invalidArgument(cmd, err)
+			}
+			return printMatches(cmd, ms)
+		},
+	}
+
+	findCmd.AddCommand(dependentsCmd, codeCmd)
+	return findCmd
+}
+
+// printMatches prints what find found, grouped by repository and ref.
+func printMatches(cmd *cobra.Command, ms []config.RefMatch) error {
+	_, s := fromContext(cmd)
+	if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+		return json.NewEncoder(s).Encode(ms)
+	}
+	for i, m := range ms {
+		if i > 0 {
+			fmt.Fprintln(s)
+		}
+		fmt.Fprintf(s, "%s %s (%s)\n", m.Repo, strings.TrimPrefix(m.Ref, "refs/"), m.Commit[:7])
+		for _, d := range m.Dependencies {
+			fmt.Fprintf(s, "  %s: %s %s\n", d.Manifest, d.Name, d.Version)
+		}
+		for _, l := range m.Lines {
+			fmt.Fprintf(s, "  %s:%d: %s\n", l.Path, l.Line, l.Text)
+		}
+		if m.Truncated {
+			fmt.Fprintln(s, "  ...")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// GCCommand returns a command that garbage collects repositories.
+func GCCommand() *cobra.Command {
+	gcCmd := &cobra.Command{
+		Use:   "gc [REPO...]",
+		Short: "Garbage collect repositories.",
+		Long: `Garbage collect repositories, or all repositories when none are given.
+
+Pushes to a repository wait while it is being garbage collected, and
+garbage collection waits for running pushes to finish.`,
+		Example: `  gc
+  gc soft-serve`,
+		ValidArgsFunction: completeRepo,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleRepoAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleRepoAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			repos := args
+			if len(repos) == 0 {
+				for _, r := range ac.Source.AllRepos() {
+					repos = append(repos, r.Repo())
+				}
+				sort.Strings(repos)
+			}
+			for _, rn := range repos {
+				if err := ac.Source.GC(rn); err != nil {
+					return fmt.Errorf("%s: %w", rn, err)
+				}
+				fmt.Fprintf(s, "%s\n", rn)
+			}
+			return nil
+		},
+	}
+	return gcCmd
+}
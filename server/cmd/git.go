@@ -14,9 +14,18 @@ func GitCommand() *cobra.Command {
 	gitCmd := &cobra.Command{
 		Use:   "git REPO COMMAND",
 		Short: "Perform Git operations on a repository.",
+		Long: `Perform Git operations on a repository. The arguments are passed to git as
+they are, so it's limited to the admins of the config repository: options
+such as -c or --git-dir can run commands on the server and reach any
+repository.`,
+		Example: `  git soft-serve symbolic-ref HEAD refs/heads/main
+  git soft-serve log --oneline -5`,
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ac, s := fromContext(cmd)
-			auth := ac.AuthRepo("config", s.PublicKey())
+			auth := ac.AuthRepoCtx(s.Context(), "config", s.PublicKey())
 			if auth < gitwish.AdminAccess {
 				return ErrUnauthorized
 			}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// accessAnnotation is the command annotation holding the access level
+	// required to run a command. It's shown in the command help.
+	accessAnnotation = "access"
+	// auditAnnotation marks the commands recorded to the audit log besides
+	// those requiring admin access, such as those creating repositories.
+	auditAnnotation = "audit"
+	// dangerousAnnotation marks the commands users with two-factor
+	// authentication must confirm with a one-time code, see dangerous.
+	dangerousAnnotation = "dangerous"
+)
+
+// Audited returns whether running a command is recorded to the audit log:
+// whether it or a parent requires admin access or a role, or is marked as
+// audited or dangerous.
+func Audited(c *cobra.Command) bool {
+	for ; c != nil; c = c.Parent() {
+		if strings.HasPrefix(c.Annotations[accessAnnotation], "admin-access") || c.Annotations[auditAnnotation] != "" || c.Annotations[dangerousAnnotation] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditLine returns the command line of a command as recorded to the audit
+// log, with the one-time codes confirming dangerous commands masked.
+func AuditLine(args []string) string {
+	line := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case i > 0 && args[i-1] == "--otp":
+			a = "***"
+		case strings.HasPrefix(a, "--otp="):
+			a = "--otp=***"
+		}
+		line[i] = a
+	}
+	return strings.Join(line, " ")
+}
+
+// HelpCommand returns a command that prints the help of any command.
+func HelpCommand() *cobra.Command {
+	helpCmd := &cobra.Command{
+		Use:   "help [command]",
+		Short: "Help about any command",
+		Long: `Help provides the usage, examples, and required access level of any
+command.`,
+		Example: `  help
+  help cat`,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			names := make([]string, 0)
+			parent, _, err := cmd.Root().Find(args)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			for _, c := range parent.Commands() {
+				if c.IsAvailableCommand() && strings.HasPrefix(c.Name(), toComplete) {
+					names = append(names, fmt.Sprintf("%s\t%s", c.Name(), c.Short))
+				}
+			}
+			return names, cobra.ShellCompDirectiveNoFileComp
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, _, err := cmd.Root().Find(args)
+			if c == nil || err != nil {
+				return fmt.Errorf("Unknown help topic %q", strings.Join(args, " "))
+			}
+			c.InitDefaultHelpFlag()
+			return c.Help()
+		},
+	}
+	return helpCmd
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// HousekeepingCommand returns a command that shows and runs the scheduled
+// maintenance of repositories.
+func HousekeepingCommand() *cobra.Command {
+	housekeepingCmd := &cobra.Command{
+		Use:   "housekeeping",
+		Short: "Show or run the scheduled maintenance of repositories.",
+		Long: `Show or run the scheduled maintenance of repositories. Repositories are
+garbage collected every housekeeping interval, a week by default, and their
+loose objects are repacked in between when there are too many of them. Their
+commit-graph is written after both.
+
+Repositories opt out with repo disable REPO housekeeping.`,
+		Example: `  housekeeping status
+  housekeeping run soft-serve
+  housekeeping run --repack`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleRepoAdmin),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleRepoAdmin)
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:               "status [REPO...]",
+		Short:             "Show the housekeeping status of repositories.",
+		Long:              "Show the housekeeping status of repositories, or all repositories when none are given.",
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			sts := make([]config.HousekeepingStatus, 0)
+			for _, rn := range housekeepingRepos(cmd, args) {
+				st, err := ac.HousekeepingStatus(cmd.Context(), rn)
+				if err != nil {
+					return fmt.Errorf("%s: %w", rn, err)
+				}
+				sts = append(sts, st)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(sts)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, st := range sts {
+				state := "on"
+				if !st.Enabled {
+					state = "off"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%d loose\t%s\t%s", st.Repo, state, st.LooseObjects,
+					orDash(formatTime(st.LastGC)), orDash(formatTime(st.LastRun)))
+				if st.Error != "" {
+					fmt.Fprintf(tw, "\terror: %s", st.Error)
+				}
+				fmt.Fprintln(tw)
+			}
+			return tw.Flush()
+		},
+	}
+
+	var repack bool
+	runCmd := &cobra.Command{
+		Use:   "run [REPO...]",
+		Short: "Run housekeeping on repositories now.",
+		Long: `Garbage collect repositories now and write their commit-graph, or only
+repack their loose objects with --repack. Without arguments, all repositories
+that didn't opt out are maintained, one at a time.
+
+Pushes to a repository wait while its objects are being repacked.`,
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			task := config.HousekeepingGC
+			if repack {
+				task = config.HousekeepingRepack
+			}
+			for _, rn := range housekeepingRepos(cmd, args) {
+				if len(args) == 0 && !ac.FeatureEnabled(rn, config.FeatureHousekeeping) {
+					continue
+				}
+				if err := ac.Housekeep(cmd.Context(), rn, task); err != nil {
+					return fmt.Errorf("%s: %w", rn, err)
+				}
+				fmt.Fprintf(s, "%s\t%s\n", rn, task)
+			}
+			return nil
+		},
+	}
+	runCmd.Flags().BoolVar(&repack, "repack", false, "Only repack loose objects, rather than garbage collect")
+
+	housekeepingCmd.AddCommand(statusCmd, runCmd)
+	return housekeepingCmd
+}
+
+// housekeepingRepos returns the repositories given as arguments, or all
+// repositories sorted by name when none are given.
+func housekeepingRepos(cmd *cobra.Command, args []string) []string {
+	if len(args) > 0 {
+		return args
+	}
+	ac, _ := fromContext(cmd)
+	repos := make([]string, 0)
+	for _, r := range ac.Source.AllRepos() {
+		repos = append(repos, r.Repo())
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+// formatTime formats t as RFC 3339 in UTC, or returns an empty string if
+// it's zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// InboxCommand returns a command that lists and reads the notifications of
+// the user.
+func InboxCommand() *cobra.Command {
+	var unread bool
+
+	inboxCmd := &cobra.Command{
+		Use:   "inbox",
+		Short: "List your notifications.",
+		Long: `List your notifications, newest first: pushes mentioning you in their
+commit messages, e.g. @frankie, the outcome of your access requests, and the
+access requests and failed mirror syncs of the repositories you're an admin
+of. Unread notifications are marked with a *.
+
+Notifications are kept in the data path for as long as the inbox retention
+policy allows.`,
+		Example: `  inbox --unread
+  inbox read 3f2a9c1d0b7e
+  inbox read --all`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			ns, err := ac.Notifications(s.PublicKey())
+			if err != nil {
+				return err
+			}
+			if unread {
+				kept := ns[:0]
+				for _, n := range ns {
+					if !n.Read {
+						kept = append(kept, n)
+					}
+				}
+				ns = kept
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(ns)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, n := range ns {
+				mark := " "
+				if !n.Read {
+					mark = "*"
+				}
+				fmt.Fprintf(tw, "%s %s\t%s\t%s\n", mark, n.ID, n.Time.Format(time.RFC3339), n.Summary())
+			}
+			return tw.Flush()
+		},
+	}
+	inboxCmd.Flags().BoolVar(&unread, "unread", false, "Only list unread notifications")
+
+	var all bool
+	readCmd := &cobra.Command{
+		Use:   "read [ID...]",
+		Short: "Mark notifications as read.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if all == (len(args) > 0) {
+				return invalidArgument(cmd, errors.New("give the IDs of notifications, or --all"))
+			}
+			ac, s := fromContext(cmd)
+			err := ac.MarkNotificationsRead(s.PublicKey(), args...)
+			if errors.Is(err, config.ErrUnknownNotification) {
+				return &Error{
+					Code:    "notification_not_found",
+					Message: err.Error(),
+					Hint:    "run inbox to list your notifications",
+					Status:  StatusNotFound,
+				}
+			}
+			return err
+		},
+	}
+	readCmd.Flags().BoolVar(&all, "all", false, "Mark all notifications as read")
+
+	inboxCmd.AddCommand(readCmd)
+	return inboxCmd
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// repoInfo is the information about a repository printed by the info command.
+type repoInfo struct {
+	Repo          string    `json:"repo"`
+	Name          string    `json:"name"`
+	Description   string    `json:"description"`
+	DefaultBranch string    `json:"default-branch"`
+	Head          string    `json:"head"`
+	Size          int64     `json:"size"`
+	Private       bool      `json:"private"`
+	Collabs       []string  `json:"collabs,omitempty"`
+	UpdatedAt     time.Time `json:"updated-at"`
+	Features      []string  `json:"features"`
+	// Downloads are the download counts of the release archives and
+	// bundles served over HTTP since the server started.
+	Downloads map[string]int64 `json:"downloads,omitempty"`
+	// Mirror is the sync status of the repository, if it's a mirror.
+	Mirror *config.MirrorStatus `json:"mirror,omitempty"`
+}
+
+// InfoCommand returns a command that prints information about a repository.
+func InfoCommand() *cobra.Command {
+	infoCmd := &cobra.Command{
+		Use:   "info REPO",
+		Short: "Print information about a repository.",
+		Long: `Print information about a repository.
+
+Collaborators are only shown to users with read-write access to the
+repository. Use --json to get the information as a JSON object.`,
+		Example: `  info soft-serve
+  info soft-serve --json`,
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeRepo,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn := args[0]
+			auth := ac.AuthRepoCtx(s.Context(), rn, s.PublicKey())
+			if auth < gitwish.ReadOnlyAccess {
+				return ErrUnauthorized
+			}
+			r, err := ac.Source.GetRepo(rn)
+			if err != nil {
+				return err
+			}
+			head, err := r.HEAD()
+			if err != nil {
+				return err
+			}
+			size, err := r.Size()
+			if err != nil {
+				return err
+			}
+			info := repoInfo{
+				Repo:          r.Repo(),
+				Name:          r.Name(),
+				Description:   r.Description(),
+				DefaultBranch: head.Name().Short(),
+				Head:          head.Hash.String(),
+				Size:          size,
+				Private:       r.IsPrivate(),
+				UpdatedAt:     r.UpdatedAt(),
+				Features:      make([]string, 0),
+				Downloads:     ac.Downloads(rn),
+			}
+			if auth >= gitwish.ReadWriteAccess {
+				info.Collabs = ac.Collabs(rn)
+			}
+			if m, ok := ac.MirrorStatus(rn); ok {
+				info.Mirror = &m
+			}
+			if rm, _ := r.Readme(); rm != "" {
+				info.Features = append(info.Features, "readme")
+			}
+			if _, err := os.Stat(filepath.Join(r.Path(), "lfs")); err == nil {
+				info.Features = append(info.Features, "lfs")
+			}
+
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(info)
+			}
+			vis := "public"
+			if info.Private {
+				vis = "private"
+			}
+			updated := "never"
+			if !info.UpdatedAt.IsZero() {
+				updated = humanize.Time(info.UpdatedAt)
+			}
+			fmt.Fprintf(s, "Repo:           %s\n", info.Repo)
+			fmt.Fprintf(s, "Name:           %s\n", info.Name)
+			if info.Description != "" {
+				fmt.Fprintf(s, "Description:    %s\n", info.Description)
+			}
+			fmt.Fprintf(s, "Default branch: %s\n", info.DefaultBranch)
+			fmt.Fprintf(s, "Head:           %s\n", info.Head)
+			fmt.Fprintf(s, "Size:           %s\n", humanize.Bytes(uint64(info.Size)))
+			fmt.Fprintf(s, "Visibility:     %s\n", vis)
+			if auth >= gitwish.ReadWriteAccess {
+				fmt.Fprintf(s, "Collaborators:  %s\n", strings.Join(info.Collabs, ", "))
+			}
+			fmt.Fprintf(s, "Last push:      %s\n", updated)
+			fmt.Fprintf(s, "Features:       %s\n", strings.Join(info.Features, ", "))
+			if m := info.Mirror; m != nil {
+				synced := "never"
+				if !m.LastSync.IsZero() {
+					synced = humanize.Time(m.LastSync)
+				}
+				fmt.Fprintf(s, "Mirror of:      %s, every %s\n", m.URL, m.Interval)
+				fmt.Fprintf(s, "Last sync:      %s\n", synced)
+				if m.Error != "" {
+					fmt.Fprintf(s, "Sync error:     %s\n", strings.ReplaceAll(m.Error, "\n", " "))
+				}
+			}
+			if len(info.Downloads) > 0 {
+				assets := make([]string, 0, len(info.Downloads))
+				for a := range info.Downloads {
+					assets = append(assets, a)
+				}
+				sort.Strings(assets)
+				for i, a := range assets {
+					assets[i] = fmt.Sprintf("%s (%d)", a, info.Downloads[a])
+				}
+				fmt.Fprintf(s, "Downloads:      %s\n", strings.Join(assets, ", "))
+			}
+			return nil
+		},
+	}
+	return infoCmd
+}
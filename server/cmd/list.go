@@ -3,6 +3,7 @@ package cmd
 import (
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/soft-serve/git"
@@ -12,11 +13,28 @@ import (
 
 // ListCommand returns a command that list file or directory at path.
 func ListCommand() *cobra.Command {
+	var porcelain bool
+
 	lsCmd := &cobra.Command{
 		Use:     "ls PATH",
 		Aliases: []string{"list"},
 		Short:   "List file or directory at path.",
-		Args:    cobra.RangeArgs(0, 1),
+		Long: `List file or directory at path.
+
+Without a path, lists the repositories you have access to.
+
+With --porcelain, the output is stable and tab-separated, suitable for
+scripts. Repositories are printed sorted as "REPO VISIBILITY", and entries as
+"MODE TYPE SIZE NAME".`,
+		Example: `  ls
+  ls soft-serve
+  ls soft-serve/cmd
+  ls --porcelain soft-serve`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		Args:              cobra.RangeArgs(0, 1),
+		ValidArgsFunction: completeRepoPath,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ac, s := fromContext(cmd)
 			rn := ""
@@ -26,14 +44,29 @@ func ListCommand() *cobra.Command {
 				path = filepath.Clean(args[0])
 				ps = strings.Split(path, "/")
 				rn = ps[0]
-				auth := ac.AuthRepo(rn, s.PublicKey())
+				auth := ac.AuthRepoCtx(s.Context(), rn, s.PublicKey())
 				if auth < gitwish.ReadOnlyAccess {
 					return ErrUnauthorized
 				}
 			}
 			if path == "" || path == "." || path == "/" {
-				for _, r := range ac.Source.AllRepos() {
-					if ac.AuthRepo(r.Repo(), s.PublicKey()) >= gitwish.ReadOnlyAccess {
+				repos := ac.Source.AllRepos()
+				if porcelain {
+					sort.Slice(repos, func(i, j int) bool {
+						return repos[i].Repo() < repos[j].Repo()
+					})
+				}
+				for _, r := range repos {
+					if ac.AuthRepoCtx(s.Context(), r.Repo(), s.PublicKey()) < gitwish.ReadOnlyAccess {
+						continue
+					}
+					if porcelain {
+						vis := "public"
+						if r.IsPrivate() {
+							vis = "private"
+						}
+						fmt.Fprintf(s, "%s\t%s\n", r.Repo(), vis)
+					} else {
 						fmt.Fprintln(s, r.Repo())
 					}
 				}
@@ -74,10 +107,15 @@ func ListCommand() *cobra.Command {
 			}
 			ents.Sort()
 			for _, ent := range ents {
-				fmt.Fprintf(s, "%s\t%d\t %s\n", ent.Mode(), ent.Size(), ent.Name())
+				if porcelain {
+					fmt.Fprintf(s, "%s\t%s\t%d\t%s\n", ent.Mode(), ent.Type(), ent.Size(), ent.Name())
+				} else {
+					fmt.Fprintf(s, "%s\t%d\t %s\n", ent.Mode(), ent.Size(), ent.Name())
+				}
 			}
 			return nil
 		},
 	}
+	lsCmd.Flags().BoolVarP(&porcelain, "porcelain", "p", false, "Print stable, tab-separated output for scripts")
 	return lsCmd
 }
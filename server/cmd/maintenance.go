@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// MaintenanceCommand returns a command that schedules maintenance windows,
+// during which the server or a repository is read-only.
+func MaintenanceCommand() *cobra.Command {
+	maintenanceCmd := &cobra.Command{
+		Use:   "maintenance",
+		Short: "Make the server or a repository read-only for maintenance.",
+		Long: `Make the server or a repository read-only for maintenance, now or at a
+scheduled time. Pushes are rejected during the window with its message, and the
+TUI shows a banner from the time the window is scheduled until it ends.
+
+Server-wide windows don't apply to the config repository, so that the
+configuration can still be changed. Windows are saved in the config file.`,
+		Example: `  maintenance start --for 30m --message "Upgrading the server"
+  maintenance start my-repo --at 2023-06-01T22:00:00Z --until 2023-06-01T23:00:00Z
+  maintenance stop my-repo`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleSettingsAdmin)
+		},
+	}
+
+	var at, until, message string
+	var length time.Duration
+	startCmd := dangerous(&cobra.Command{
+		Use:   "start [REPO]",
+		Short: "Start or schedule a maintenance window.",
+		Long: `Start or schedule a maintenance window of the server or of a repository.
+--at and --until take an RFC 3339 time, or a duration from now, e.g. 2h.
+Windows without an end last until they're stopped.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			now := time.Now()
+			m := config.Maintenance{Message: message, Start: now}
+			if len(args) > 0 {
+				if _, err := ac.Source.GetRepo(args[0]); err != nil {
+					return ErrRepoNotFound
+				}
+				m.Repo = args[0]
+			}
+			var err error
+			if at != "" {
+				if m.Start, err = parseWhen("at", at, now); err != nil {
+					return invalidArgument(cmd, err)
+				}
+			}
+			switch {
+			case until != "" && length != 0:
+				return invalidArgument(cmd, errors.New("--until and --for can't be used together"))
+			case until != "":
+				if m.End, err = parseWhen("until", until, now); err != nil {
+					return invalidArgument(cmd, err)
+				}
+			case length < 0:
+				return invalidArgument(cmd, fmt.Errorf("invalid --for %s", length))
+			case length > 0:
+				m.End = m.Start.Add(length)
+			}
+			if !m.End.IsZero() && !m.End.After(m.Start) {
+				return invalidArgument(cmd, errors.New("maintenance must end after it starts"))
+			}
+			m.Start, m.End = m.Start.UTC().Truncate(time.Second), m.End.UTC().Truncate(time.Second)
+			if err := ac.StartMaintenance(m); err != nil {
+				return err
+			}
+			fmt.Fprintln(s, m.Banner(now))
+			return nil
+		},
+	})
+	startCmd.Flags().StringVar(&at, "at", "", "When the window starts, now by default")
+	startCmd.Flags().StringVar(&until, "until", "", "When the window ends")
+	startCmd.Flags().DurationVar(&length, "for", 0, "How long the window lasts")
+	startCmd.Flags().StringVarP(&message, "message", "m", "", "Message shown to users")
+
+	stopCmd := &cobra.Command{
+		Use:   "stop [REPO]",
+		Short: "End the maintenance of the server or a repository.",
+		Long: `End the open maintenance window of the server or of a repository, and
+cancel its scheduled ones.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			var repo string
+			if len(args) > 0 {
+				repo = args[0]
+			}
+			if err := ac.StopMaintenance(repo); errors.Is(err, config.ErrNoMaintenance) {
+				return invalidArgument(cmd, err)
+			} else if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the open and scheduled maintenance windows.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			now := time.Now()
+			ms := ac.MaintenanceWindows(now)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(ms)
+			}
+			for _, m := range ms {
+				target, state, end := "server", "scheduled", "-"
+				if m.Repo != "" {
+					target = m.Repo
+				}
+				if m.Active(now) {
+					state = "active"
+				}
+				if !m.End.IsZero() {
+					end = m.End.UTC().Format(time.RFC3339)
+				}
+				fmt.Fprintf(s, "%s\t%s\t%s\t%s\t%s\n", target, state, m.Start.UTC().Format(time.RFC3339), end, m.Message)
+			}
+			return nil
+		},
+	}
+
+	maintenanceCmd.AddCommand(startCmd, stopCmd, listCmd)
+	return maintenanceCmd
+}
+
+// parseWhen parses an RFC 3339 time, or a duration after now, of the named
+// flag.
+func parseWhen(flag, s string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s %q, e.g. 2023-01-01T15:04:05Z or 2h", flag, s)
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// MigrateCommand returns a command that reports the compatibility of the
+// configuration and saves its migration.
+func MigrateCommand() *cobra.Command {
+	var dryRun bool
+	migrateCmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the configuration to the current version.",
+		Long: `Report how the configuration was made readable by this server and save its
+migration to the config repo.
+
+The server migrates older configurations when reading them, and ignores
+settings it doesn't know, such as those of a newer server. Use --dry-run to
+only print the report.`,
+		Example: `  migrate --dry-run
+  migrate`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			c := ac.Compatibility()
+			if !dryRun {
+				if err := ac.Migrate(); err != nil {
+					return err
+				}
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(c)
+			}
+			printCompatibility(s, c)
+			switch {
+			case len(c.Migrations) == 0:
+			case dryRun:
+				fmt.Fprintln(s, "Run migrate without --dry-run to save the migration.")
+			default:
+				fmt.Fprintf(s, "Saved the config as version %d.\n", c.ServerVersion)
+			}
+			return nil
+		},
+	}
+	migrateCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Only report the compatibility of the configuration")
+	return migrateCmd
+}
+
+func printCompatibility(w io.Writer, c config.Compatibility) {
+	fmt.Fprintf(w, "Config version: %d\n", c.Version)
+	fmt.Fprintf(w, "Server version: %d\n", c.ServerVersion)
+	if c.Newer() {
+		fmt.Fprintln(w, "The config was written for a newer server.")
+	}
+	if len(c.Migrations) > 0 {
+		fmt.Fprintln(w, "Migrations:")
+		for _, m := range c.Migrations {
+			fmt.Fprintf(w, "  %s\n", m)
+		}
+	}
+	if len(c.Unknown) > 0 {
+		fmt.Fprintln(w, "Ignored settings:")
+		for _, u := range c.Unknown {
+			fmt.Fprintf(w, "  %s\n", u)
+		}
+	}
+	if c.OK() {
+		fmt.Fprintln(w, "The config is up to date.")
+	}
+}
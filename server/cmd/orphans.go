@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// OrphansCommand returns a command that finds repositories on disk that
+// aren't loaded by the server, and loaded repositories that no longer exist
+// on disk.
+func OrphansCommand() *cobra.Command {
+	var adopt bool
+	var remove bool
+
+	orphansCmd := dangerousWhen(&cobra.Command{
+		Use:   "orphans",
+		Short: "Find repositories out of sync with the disk.",
+		Long: `Find repositories out of sync with the disk, for example after moving
+repositories around on the server by hand.
+
+Each line of the output is a status followed by a repository name:
+
+  unloaded  the directory exists on disk but isn't served
+  missing   the repository is served but no longer exists on disk
+  unknown   the repository is in the configuration but doesn't exist
+
+Use --adopt to start serving unloaded repositories, or --remove to delete
+unloaded directories from disk and stop serving missing repositories. Removing
+deletes repositories for good, so it's limited to config admins. Unknown repositories
+must be removed from the configuration by hand.`,
+		Example: `  orphans
+  orphans --adopt`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleRepoAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleRepoAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			// Removing deletes data for good, so it needs more than the
+			// repo admin role.
+			if remove && ac.AuthRepoCtx(s.Context(), "config", s.PublicKey()) < gitwish.AdminAccess {
+				return ErrUnauthorized
+			}
+			unloaded, missing, err := ac.Source.Orphans()
+			if err != nil {
+				return err
+			}
+			for _, rn := range unloaded {
+				switch {
+				case adopt:
+					if err := ac.Source.LoadRepo(rn); err != nil {
+						fmt.Fprintf(s, "unloaded\t%s\tcannot adopt: %s\n", rn, err)
+						continue
+					}
+					fmt.Fprintf(s, "adopted\t%s\n", rn)
+				case remove && rn != "config":
+					if err := ac.Source.RemoveRepo(rn); err != nil {
+						return err
+					}
+					fmt.Fprintf(s, "removed\t%s\n", rn)
+				default:
+					fmt.Fprintf(s, "unloaded\t%s\n", rn)
+				}
+			}
+			for _, rn := range missing {
+				if remove {
+					ac.Source.UnloadRepo(rn)
+					fmt.Fprintf(s, "removed\t%s\n", rn)
+					continue
+				}
+				fmt.Fprintf(s, "missing\t%s\n", rn)
+			}
+			if adopt || remove {
+				// Pick up the configuration of adopted repos.
+				if err := ac.Reload(); err != nil {
+					return err
+				}
+			}
+			for _, rn := range ac.UnknownRepos() {
+				fmt.Fprintf(s, "unknown\t%s\n", rn)
+			}
+			return nil
+		},
+	}, func() bool { return remove })
+	orphansCmd.Flags().BoolVarP(&adopt, "adopt", "a", false, "Serve repositories found on disk")
+	orphansCmd.Flags().BoolVarP(&remove, "remove", "r", false, "Delete unloaded repositories and forget missing ones")
+	orphansCmd.MarkFlagsMutuallyExclusive("adopt", "remove")
+
+	return orphansCmd
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// rangeDiffTimeout is how long range-diff can run before it's canceled.
+const rangeDiffTimeout = 30 * time.Second
+
+// RangeDiffCommand returns a command that compares two versions of a series
+// of commits, e.g. before and after a force-push.
+func RangeDiffCommand() *cobra.Command {
+	var color bool
+
+	rangeDiffCmd := &cobra.Command{
+		Use:   "range-diff REPO RANGE1 [RANGE2] [RANGE3]",
+		Short: "Compare two versions of a series of commits.",
+		Long: `Compare two versions of a series of commits like git range-diff.
+
+Ranges can be given as "OLD-RANGE NEW-RANGE", "REV1...REV2", or
+"BASE REV1 REV2". Each version of the series can have up to 250 commits.`,
+		Example: `  range-diff soft-serve main~3..feature@{1} main..feature
+  range-diff soft-serve old-feature...feature
+  range-diff soft-serve main old-feature feature`,
+		Args: cobra.RangeArgs(2, 4),
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		ValidArgsFunction: completeRepo,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn := args[0]
+			auth := ac.AuthRepoCtx(s.Context(), rn, s.PublicKey())
+			if auth < gitwish.ReadOnlyAccess {
+				return ErrUnauthorized
+			}
+			r, err := ac.Source.GetRepo(rn)
+			if errors.Is(err, config.ErrMissingRepo) {
+				return ErrRepoNotFound
+			}
+			if err != nil {
+				return err
+			}
+			ctx, cancel := context.WithTimeout(s.Context(), rangeDiffTimeout)
+			defer cancel()
+			out, err := r.RangeDiff(ctx, color, args[1:]...)
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("range-diff took longer than %s", rangeDiffTimeout)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(s, out)
+			return nil
+		},
+	}
+	rangeDiffCmd.Flags().BoolVarP(&color, "color", "c", false, "Colorize output")
+
+	return rangeDiffCmd
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+// ReleasesCommand returns a command that lists the most recent tags of the
+// repositories the user can read.
+func ReleasesCommand() *cobra.Command {
+	var sortBy string
+	var limit int
+
+	releasesCmd := &cobra.Command{
+		Use:   "releases",
+		Short: "List the latest releases of all repositories.",
+		Long: `List the tags of the repositories you have access to, newest first, or
+sorted by repository and tag with --sort name. Repositories with releases
+turned off aren't listed.`,
+		Example: `  releases
+  releases --limit 10
+  releases --sort name --json`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rs := ac.Releases(s.PublicKey())
+			if err := config.SortReleases(rs, sortBy); err != nil {
+				return invalidArgument(cmd, err)
+			}
+			if limit > 0 && len(rs) > limit {
+				rs = rs[:limit]
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(rs)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, r := range rs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Repo, r.Tag, r.Commit[:7], r.Date.Format(time.RFC3339))
+			}
+			return tw.Flush()
+		},
+	}
+	releasesCmd.Flags().StringVar(&sortBy, "sort", "date", "Sort by date or name")
+	releasesCmd.Flags().IntVarP(&limit, "limit", "n", 0, "List at most this many releases")
+
+	return releasesCmd
+}
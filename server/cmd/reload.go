@@ -1,7 +1,7 @@
 package cmd
 
 import (
-	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/charmbracelet/soft-serve/config"
 	"github.com/spf13/cobra"
 )
 
@@ -10,12 +10,14 @@ func ReloadCommand() *cobra.Command {
 	reloadCmd := &cobra.Command{
 		Use:   "reload",
 		Short: "Reloads the configuration",
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			ac, s := fromContext(cmd)
-			auth := ac.AuthRepo("config", s.PublicKey())
-			if auth < gitwish.AdminAccess {
-				return ErrUnauthorized
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
 			}
+			ac, _ := fromContext(cmd)
 			return ac.Reload()
 		},
 	}
@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// repoRecord is a repository exported by the repo export command.
+type repoRecord struct {
+	Repo       string `json:"repo"`
+	Visibility string `json:"visibility"`
+	// Collaborators is only exported to users with admin access to the
+	// repository.
+	Collaborators []string `json:"collaborators,omitempty"`
+	// LastPush is the last time the references of the repository were
+	// updated, if ever.
+	LastPush *time.Time `json:"last-push,omitempty"`
+	// Size is the on-disk size of the repository in bytes.
+	Size int64 `json:"size"`
+}
+
+// RepoCommand returns a command that works with repositories as a whole.
+func RepoCommand() *cobra.Command {
+	repoCmd := &cobra.Command{
+		Use:   "repo",
+		Short: "Work with repositories.",
+	}
+	repoCmd.AddCommand(
+		repoExportCommand(),
+		repoFeaturesCommand(),
+		repoFeatureCommand(true),
+		repoFeatureCommand(false),
+	)
+	return repoCmd
+}
+
+func repoExportCommand() *cobra.Command {
+	var format string
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export the list of repositories.",
+		Long: `Export the repositories you have access to with their visibility, last push
+and size, for compliance reporting. Collaborators are included for the
+repositories you have admin access to, so admins get the complete picture.
+
+Repositories are sorted by name. The CSV output has a header row, lists
+collaborators separated by spaces, and prints times in RFC 3339 format.`,
+		Example: `  repo export > repos.csv
+  repo export --format json`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				format = "json"
+			}
+			if format != "csv" && format != "json" {
+				return invalidArgument(cmd, fmt.Errorf("invalid format %q, must be csv or json", format))
+			}
+			records := make([]repoRecord, 0)
+			for _, r := range ac.Source.AllRepos() {
+				acc := ac.AuthRepoCtx(s.Context(), r.Repo(), s.PublicKey())
+				if acc < gitwish.ReadOnlyAccess {
+					continue
+				}
+				du, err := r.DiskUsage()
+				if err != nil {
+					return err
+				}
+				rec := repoRecord{
+					Repo:       r.Repo(),
+					Visibility: "public",
+					Size:       du.Total,
+				}
+				if r.IsPrivate() {
+					rec.Visibility = "private"
+				}
+				if acc >= gitwish.AdminAccess {
+					rec.Collaborators = ac.Collabs(r.Repo())
+				}
+				if t := r.UpdatedAt(); !t.IsZero() {
+					t = t.UTC()
+					rec.LastPush = &t
+				}
+				records = append(records, rec)
+			}
+			sort.Slice(records, func(i, j int) bool {
+				return records[i].Repo < records[j].Repo
+			})
+
+			if format == "json" {
+				return json.NewEncoder(s).Encode(records)
+			}
+			w := csv.NewWriter(s)
+			_ = w.Write([]string{"repo", "visibility", "collaborators", "last_push", "size"})
+			for _, r := range records {
+				lastPush := ""
+				if r.LastPush != nil {
+					lastPush = r.LastPush.Format(time.RFC3339)
+				}
+				_ = w.Write([]string{
+					r.Repo,
+					r.Visibility,
+					strings.Join(r.Collaborators, " "),
+					lastPush,
+					strconv.FormatInt(r.Size, 10),
+				})
+			}
+			w.Flush()
+			return w.Error()
+		},
+	}
+	exportCmd.Flags().StringVar(&format, "format", "csv", "Output format, csv or json")
+	return exportCmd
+}
+
+func repoFeaturesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "features REPO",
+		Short: "List the features of a repository.",
+		Long: `List whether each feature of a repository is on or off. Features are on
+unless turned off with repo disable:
+
+  releases      source archives of tags, and the tags tab of the TUI
+  webhooks      the hooks of the repository, those of the server always run
+  housekeeping  scheduled garbage collection and repacking`,
+		Example: `  repo features soft-serve
+  repo features soft-serve --json`,
+		Args: cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			silenceIfJSON(cmd)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn := args[0]
+			if err := checkArtifactAccess(cmd, rn, gitwish.ReadOnlyAccess); err != nil {
+				return ErrRepoNotFound
+			}
+			fs := ac.RepoFeatures(rn)
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(fs)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, f := range config.Features {
+				state := "on"
+				if !fs[f] {
+					state = "off"
+				}
+				fmt.Fprintf(tw, "%s\t%s\n", f, state)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+// repoFeatureCommand returns the command turning features of a repository on
+// or off.
+func repoFeatureCommand(on bool) *cobra.Command {
+	use, short, example := "enable", "Turn features of a repository on.", "  repo enable soft-serve releases"
+	if !on {
+		use, short, example = "disable", "Turn features of a repository off.", "  repo disable soft-serve releases webhooks"
+	}
+	return &cobra.Command{
+		Use:     use + " REPO FEATURE...",
+		Short:   short,
+		Long:    short + " Run repo features to list them.\n\nChanges are committed to the config repo.",
+		Example: example,
+		Args:    cobra.MinimumNArgs(2),
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			rn := args[0]
+			if err := checkArtifactAccess(cmd, rn, gitwish.AdminAccess); err != nil {
+				return err
+			}
+			fs := make(map[config.Feature]bool, len(args)-1)
+			for _, name := range args[1:] {
+				f, err := config.ParseFeature(name)
+				if err != nil {
+					return invalidArgument(cmd, err)
+				}
+				fs[f] = on
+			}
+			if err := ac.SetRepoSettings(rn, config.RepoSettings{Features: fs}); err != nil {
+				return err
+			}
+			fmt.Fprintf(s, "%s\n", rn)
+			return nil
+		},
+	}
+}
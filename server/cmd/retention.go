@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/retention"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// RetentionCommand returns a command that enforces retention policies.
+func RetentionCommand() *cobra.Command {
+	var dryRun bool
+	retentionCmd := dangerousWhen(&cobra.Command{
+		Use:   "retention",
+		Short: "Purge data past its retention policy.",
+		Long: `Purge audit logs, session recordings, trashed repositories, and stored
+events that are older or larger than the retention policies of the
+configuration allow.
+
+The server enforces retention policies periodically. Use --dry-run to
+report what would be purged without deleting anything.`,
+		Example: `  retention --dry-run
+  retention`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			report, err := retention.Enforce(ac, ac.Cfg.DataPath, dryRun)
+			if err != nil {
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(report)
+			}
+			w := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "CLASS\tPATH\tSIZE\tMODIFIED\tREASON")
+			var total int64
+			for _, p := range report {
+				total += p.Size
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+					p.Class,
+					p.Path,
+					humanize.Bytes(uint64(p.Size)),
+					humanize.Time(p.ModTime),
+					p.Reason,
+				)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			verb := "Purged"
+			if dryRun {
+				verb = "Would purge"
+			}
+			fmt.Fprintf(s, "%s %d entries, %s\n", verb, len(report), humanize.Bytes(uint64(total)))
+			return nil
+		},
+	}, func() bool { return !dryRun })
+	retentionCmd.Flags().BoolVarP(&dryRun, "dry-run", "n", false, "Report what would be purged without deleting anything")
+	return retentionCmd
+}
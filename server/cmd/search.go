@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrSearchNeedsUser is returned when a user without an entry in the
+	// configuration manages saved searches.
+	ErrSearchNeedsUser = &Error{
+		Code:    "user_not_found",
+		Message: "Saved searches need a user in the server config",
+		Hint:    "ask an admin to add your key to a user of the config repo",
+		Status:  StatusUnauthorized,
+	}
+	// ErrSearchNotFound is returned when the saved search is not found.
+	ErrSearchNotFound = &Error{
+		Code:    "search_not_found",
+		Message: "Search not found",
+		Hint:    "run search list to list saved searches",
+		Status:  StatusNotFound,
+	}
+)
+
+// SearchCommand returns a command that manages the saved searches of the
+// user, listed as tabs of the repo list in the TUI.
+func SearchCommand() *cobra.Command {
+	searchCmd := &cobra.Command{
+		Use:   "search",
+		Short: "Manage your saved searches.",
+		Long: `Manage your saved searches. Saved searches are listed as tabs of the repo
+list in the TUI.
+
+Queries are made of words, matching repos by name or description, and
+qualifiers: file:NAME matches repos with a file at their root, and
+updated:AGE repos updated in the last AGE, e.g. 12h, 30d, or 2w.
+
+Changes are committed to the config repo.`,
+		Example: `  search save "Go repos" file:go.mod updated:30d --sort updated
+  search list
+  search remove "Go repos"`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+	}
+
+	var sort, visibility string
+	saveCmd := &cobra.Command{
+		Use:   "save NAME [QUERY...]",
+		Short: "Save a search, replacing the search with the same name.",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			search := config.Search{
+				Name:       args[0],
+				Query:      strings.Join(args[1:], " "),
+				Sort:       sort,
+				Visibility: visibility,
+			}
+			if err := search.Validate(); err != nil {
+				return invalidArgument(cmd, err)
+			}
+			err := ac.SaveSearch(s.PublicKey(), search)
+			if errors.Is(err, config.ErrUnknownUser) {
+				return ErrSearchNeedsUser
+			}
+			return err
+		},
+	}
+	saveCmd.Flags().StringVar(&sort, "sort", "", "Sort repos by name or updated")
+	saveCmd.Flags().StringVar(&visibility, "visibility", "", "Only list public or private repos")
+
+	removeCmd := &cobra.Command{
+		Use:     "remove NAME",
+		Aliases: []string{"rm"},
+		Short:   "Remove a saved search.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			err := ac.DeleteSearch(s.PublicKey(), args[0])
+			switch {
+			case errors.Is(err, config.ErrUnknownUser):
+				return ErrSearchNeedsUser
+			case errors.Is(err, config.ErrUnknownSearch):
+				return ErrSearchNotFound
+			}
+			return err
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List your saved searches.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			searches := ac.Searches(s.PublicKey())
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				if searches == nil {
+					searches = []config.Search{}
+				}
+				return json.NewEncoder(s).Encode(searches)
+			}
+			for _, search := range searches {
+				fmt.Fprintf(s, "%s\t%s\t%s\t%s\n", search.Name, search.Query, search.Sort, search.Visibility)
+			}
+			return nil
+		},
+	}
+
+	searchCmd.AddCommand(saveCmd, removeCmd, listCmd)
+
+	return searchCmd
+}
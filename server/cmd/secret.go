@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/secrets"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrSecretsDisabled is returned when the server has no secrets store.
+	ErrSecretsDisabled = &Error{
+		Code:    "secrets_disabled",
+		Message: "Secrets are not configured",
+		Hint:    "set SOFT_SERVE_SECRETS_PATH on the server",
+		Status:  StatusError,
+	}
+	// ErrSecretNotFound is returned when the secret is not found.
+	ErrSecretNotFound = &Error{
+		Code:    "secret_not_found",
+		Message: "Secret not found",
+		Hint:    "run secret list to list secrets",
+		Status:  StatusNotFound,
+	}
+)
+
+// errSecretArg is returned when a secret value is given as an argument.
+var errSecretArg = errors.New("secret values are read from stdin, not arguments")
+
+// SecretCommand returns a command that manages the encrypted secrets used by
+// integrations.
+func SecretCommand() *cobra.Command {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage secrets used by integrations.",
+		Long: `Manage secrets used by integrations, such as webhook secrets and mirror
+credentials. Secrets are encrypted at rest and redacted from the server logs.`,
+		Example: `  secret set webhook/ci < token.txt
+  secret list
+  secret remove webhook/ci`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, _ := fromContext(cmd)
+			if ac.Secrets == nil {
+				return ErrSecretsDisabled
+			}
+			return nil
+		},
+	}
+
+	setCmd := &cobra.Command{
+		Use:   "set NAME",
+		Short: "Set a secret to the value read from stdin.",
+		Long: `Set a secret to the value read from stdin. Values can't be given as
+arguments, which end up in shell histories and server logs.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 1 {
+				return errSecretArg
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			bts, err := io.ReadAll(s)
+			if err != nil {
+				return err
+			}
+			value := strings.TrimRight(string(bts), "\r\n")
+			if err := ac.Secrets.Set(args[0], value); errors.Is(err, secrets.ErrInvalidName) {
+				return invalidArgument(cmd, err)
+			} else if err != nil {
+				return err
+			}
+			return nil
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get NAME",
+		Short: "Print a secret.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			v, err := ac.Secrets.Get(args[0])
+			if errors.Is(err, secrets.ErrSecretNotFound) {
+				return ErrSecretNotFound
+			} else if err != nil {
+				return err
+			}
+			fmt.Fprintln(s, v)
+			return nil
+		},
+	}
+
+	removeCmd := dangerous(&cobra.Command{
+		Use:     "remove NAME",
+		Aliases: []string{"rm"},
+		Short:   "Remove a secret.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			err := ac.Secrets.Remove(args[0])
+			if errors.Is(err, secrets.ErrSecretNotFound) {
+				return ErrSecretNotFound
+			}
+			return err
+		},
+	})
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the names of secrets.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			for _, n := range ac.Secrets.Names() {
+				fmt.Fprintln(s, n)
+			}
+			return nil
+		},
+	}
+
+	secretCmd.AddCommand(setCmd, getCmd, removeCmd, listCmd)
+
+	return secretCmd
+}
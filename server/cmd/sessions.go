@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+)
+
+// ErrSessionNotFound is returned when the session is not connected.
+var ErrSessionNotFound = &Error{
+	Code:    "session_not_found",
+	Message: "Session not found",
+	Hint:    "run sessions list to list connected sessions",
+	Status:  StatusNotFound,
+}
+
+// SessionsCommand returns a command that manages the connected sessions.
+func SessionsCommand() *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Manage connected sessions.",
+		Long: `List the connected SSH sessions, with their user, address, operation, and
+the bytes they transferred, show a message to their users, or terminate
+them, e.g. when a runaway clone saturates the uplink. Sessions are also
+listed in the Sessions tab of the TUI.`,
+		Example: `  sessions list
+  sessions message 3f2a9c1d0b7e4a65 please stop cloning in a loop
+  sessions terminate 3f2a9c1d0b7e4a65`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin, config.RoleAuditor),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleSettingsAdmin, config.RoleAuditor)
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the connected sessions, oldest first.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			ss := ac.Sessions()
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(ss)
+			}
+			now := time.Now()
+			for _, ses := range ss {
+				op := ses.Operation
+				if ses.Repo != "" {
+					op += " " + ses.Repo
+				}
+				fmt.Fprintf(s, "%s\t%s\t%s\t%s\t%s\tin %s\tout %s\n", ses.ID, ses.User, ses.RemoteAddr, op,
+					ses.Duration(now).Round(time.Second), humanize.Bytes(uint64(ses.In)), humanize.Bytes(uint64(ses.Out)))
+			}
+			return nil
+		},
+	}
+
+	messageCmd := &cobra.Command{
+		Use:   "message ID MESSAGE...",
+		Short: "Show a message to the user of a session.",
+		Args:  cobra.MinimumNArgs(2),
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			err := ac.MessageSession(args[0], s.PublicKey(), strings.Join(args[1:], " "))
+			if errors.Is(err, config.ErrUnknownSession) {
+				return ErrSessionNotFound
+			}
+			return err
+		},
+	}
+
+	terminateCmd := &cobra.Command{
+		Use:     "terminate ID",
+		Aliases: []string{"kill"},
+		Short:   "Disconnect a session.",
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleSettingsAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkRole(cmd, config.RoleSettingsAdmin); err != nil {
+				return err
+			}
+			ac, _ := fromContext(cmd)
+			err := ac.TerminateSession(args[0])
+			if errors.Is(err, config.ErrUnknownSession) {
+				return ErrSessionNotFound
+			}
+			return err
+		},
+	}
+
+	sessionsCmd.AddCommand(listCmd, messageCmd, terminateCmd)
+
+	return sessionsCmd
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrTokenNeedsUser is returned when a user without an entry in the
+	// configuration manages personal access tokens.
+	ErrTokenNeedsUser = &Error{
+		Code:    "user_not_found",
+		Message: "Personal access tokens need a user in the server config",
+		Hint:    "ask an admin to add your key to a user of the config repo",
+		Status:  StatusUnauthorized,
+	}
+	// ErrTokenNotFound is returned when the token is not found.
+	ErrTokenNotFound = &Error{
+		Code:    "token_not_found",
+		Message: "Token not found",
+		Hint:    "run token list to list your tokens",
+		Status:  StatusNotFound,
+	}
+)
+
+// TokenCommand returns a command that manages the personal access tokens of
+// the user.
+func TokenCommand() *cobra.Command {
+	tokenCmd := &cobra.Command{
+		Use:   "token",
+		Short: "Manage your personal access tokens.",
+		Long: `Manage your personal access tokens. Tokens authenticate HTTP clones and
+pushes, as the password of basic auth or as a bearer token, and admin API
+calls, with your access, capped to the scope of the token: read-only,
+read-write, or admin-access.
+
+Tokens are shown once, when they're created; only their SHA-256 digest is
+committed to the config repo.`,
+		Example: `  token create ci --scope read-only --expires 2160h
+  token list
+  token revoke ci`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+	}
+
+	var scope, expires string
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Create a token and print it.",
+		Args:  cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			auditAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if err := config.ValidTokenScope(scope); err != nil {
+				return invalidArgument(cmd, err)
+			}
+			var exp time.Time
+			if expires != "" {
+				var err error
+				if exp, err = parseWhen("expires", expires, time.Now()); err != nil {
+					return invalidArgument(cmd, err)
+				}
+			}
+			token, err := ac.CreateToken(s.PublicKey(), args[0], scope, exp)
+			switch {
+			case errors.Is(err, config.ErrUnknownUser):
+				return ErrTokenNeedsUser
+			case errors.Is(err, config.ErrTokenExists):
+				return invalidArgument(cmd, err)
+			case err != nil:
+				return err
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(map[string]string{"name": args[0], "token": token})
+			}
+			fmt.Fprintln(s, token)
+			return nil
+		},
+	}
+	createCmd.Flags().StringVar(&scope, "scope", "read-only", "Scope of the token: read-only, read-write, or admin-access")
+	createCmd.Flags().StringVar(&expires, "expires", "", "Expiry of the token, as an RFC 3339 time or a duration, e.g. 720h")
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List your tokens.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			tokens := ac.Tokens(s.PublicKey())
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				if tokens == nil {
+					tokens = []config.Token{}
+				}
+				return json.NewEncoder(s).Encode(tokens)
+			}
+			now := time.Now()
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, t := range tokens {
+				exp := "never"
+				if !t.Expires.IsZero() {
+					exp = t.Expires.Format(time.RFC3339)
+					if t.Expired(now) {
+						exp += " (expired)"
+					}
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Name, t.Scope, t.Created.Format(time.RFC3339), exp)
+			}
+			return tw.Flush()
+		},
+	}
+
+	revokeCmd := dangerous(&cobra.Command{
+		Use:     "revoke NAME",
+		Aliases: []string{"rm"},
+		Short:   "Revoke a token.",
+		Args:    cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			auditAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			err := ac.RevokeToken(s.PublicKey(), args[0])
+			switch {
+			case errors.Is(err, config.ErrUnknownUser):
+				return ErrTokenNeedsUser
+			case errors.Is(err, config.ErrUnknownToken):
+				return ErrTokenNotFound
+			}
+			return err
+		},
+	})
+
+	tokenCmd.AddCommand(createCmd, listCmd, revokeCmd)
+
+	return tokenCmd
+}
@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ErrTOTPRequired is returned when a user without two-factor
+	// authentication runs a dangerous command on a server requiring it.
+	ErrTOTPRequired = &Error{
+		Code:    "totp_required",
+		Message: "This command requires two-factor authentication",
+		Hint:    "run totp enroll to enable it",
+		Status:  StatusUnauthorized,
+	}
+	// ErrOTPRequired is returned when a user with two-factor authentication
+	// runs a dangerous command without a one-time code.
+	ErrOTPRequired = &Error{
+		Code:    "otp_required",
+		Message: "This command needs a one-time code",
+		Hint:    "append --otp CODE, from your authenticator app or a recovery code",
+		Status:  StatusUnauthorized,
+	}
+	// ErrOTPInvalid is returned for wrong, expired, or reused one-time codes.
+	ErrOTPInvalid = &Error{
+		Code:    "otp_invalid",
+		Message: "Invalid one-time code",
+		Hint:    "codes can only be used once; ask an admin for recovery codes if you lost your authenticator",
+		Status:  StatusUnauthorized,
+	}
+	// ErrTOTPNeedsUser is returned when a user without an entry in the
+	// configuration or the directory manages two-factor authentication.
+	ErrTOTPNeedsUser = &Error{
+		Code:    "user_not_found",
+		Message: "Two-factor authentication needs a user in the server config",
+		Hint:    "ask an admin to add your key to a user of the config repo",
+		Status:  StatusUnauthorized,
+	}
+	// ErrReasonRequired is returned when a dangerous command is run without
+	// a reason on a server requiring one.
+	ErrReasonRequired = &Error{
+		Code:    "reason_required",
+		Message: "This command needs a reason",
+		Hint:    `append --reason, e.g. --reason "ticket-123"`,
+		Status:  StatusInvalidArgument,
+	}
+	// ErrTOTPNotEnrolled is returned when confirming or managing the
+	// two-factor authentication of a user who didn't enroll.
+	ErrTOTPNotEnrolled = &Error{
+		Code:    "totp_not_enrolled",
+		Message: "Two-factor authentication isn't enrolled",
+		Hint:    "run totp enroll to enable it",
+		Status:  StatusNotFound,
+	}
+)
+
+// dangerous marks a command as dangerous: users with two-factor
+// authentication must confirm running it with a one-time code, given with
+// --otp. Dangerous commands are recorded to the audit log, along with the
+// reason given with --reason, which servers can require.
+func dangerous(c *cobra.Command) *cobra.Command {
+	return dangerousWhen(c, nil)
+}
+
+// dangerousWhen is dangerous for commands that are only dangerous with some
+// flags, such as --remove: the one-time code and reason are only checked
+// when when returns true.
+func dangerousWhen(c *cobra.Command, when func() bool) *cobra.Command {
+	var code, reason string
+	c.Flags().StringVar(&code, "otp", "", "One-time code from your authenticator app, or a recovery code")
+	c.Flags().StringVar(&reason, "reason", "", "Why the command is run, e.g. a ticket, recorded to the audit log")
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+	c.Annotations[dangerousAnnotation] = "true"
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		if when != nil && !when() {
+			return run(cmd, args)
+		}
+		ac, s := fromContext(cmd)
+		// Checked first, so that one-time codes aren't used up by commands
+		// that are rejected anyway.
+		if ac.RequireReason && strings.TrimSpace(reason) == "" {
+			return ErrReasonRequired
+		}
+		if err := totpError(ac.VerifyTOTP(s.PublicKey(), code)); err != nil {
+			return err
+		}
+		return run(cmd, args)
+	}
+	return c
+}
+
+// TOTPCommand returns a command that manages two-factor authentication.
+func TOTPCommand() *cobra.Command {
+	totpCmd := &cobra.Command{
+		Use:   "totp",
+		Short: "Manage your two-factor authentication.",
+		Long: `Manage your two-factor authentication. Once enabled, dangerous commands,
+such as user delete, need a one-time code from your authenticator app,
+appended with --otp, so that a compromised SSH agent isn't enough to run
+them. Servers can require all users to enable it.
+
+Enroll to get a key for your authenticator app, then confirm a code to
+enable it. If you lose your authenticator, ask an admin for recovery codes,
+each usable once in place of a one-time code, or to reset your enrollment.`,
+		Example: `  totp enroll
+  totp confirm 123456
+  user delete Frankie --otp 654321
+  totp recovery-codes Frankie`,
+		Annotations: map[string]string{
+			accessAnnotation: "read-only",
+		},
+	}
+
+	statusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether two-factor authentication is enabled.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			st, err := ac.TOTP(s.PublicKey())
+			if err != nil {
+				return totpError(err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(st)
+			}
+			switch {
+			case st.Enabled:
+				fmt.Fprintf(s, "enabled, %d recovery codes left\n", st.RecoveryCodes)
+			case st.Pending:
+				fmt.Fprintln(s, "pending, run totp confirm CODE to enable it")
+			default:
+				fmt.Fprintln(s, "disabled")
+			}
+			return nil
+		},
+	}
+
+	enrollCmd := &cobra.Command{
+		Use:   "enroll",
+		Short: "Generate a key for your authenticator app.",
+		Long: `Generate a key for your authenticator app, and print it along with its
+otpauth:// URI. Two-factor authentication is enabled once you confirm a code
+with totp confirm. Enrolling again before confirming replaces the key.`,
+		Args: cobra.NoArgs,
+		Annotations: map[string]string{
+			auditAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			secret, uri, err := ac.EnrollTOTP(s.PublicKey())
+			if errors.Is(err, config.ErrTOTPEnrolled) {
+				return invalidArgument(cmd, err)
+			}
+			if err != nil {
+				return totpError(err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(map[string]string{"secret": secret, "uri": uri})
+			}
+			fmt.Fprintf(s, "%s\n%s\n", secret, uri)
+			return nil
+		},
+	}
+
+	confirmCmd := &cobra.Command{
+		Use:   "confirm CODE",
+		Short: "Enable two-factor authentication with a code of your new key.",
+		Args:  cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			auditAnnotation: "true",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			err := ac.ConfirmTOTP(s.PublicKey(), args[0])
+			if errors.Is(err, config.ErrTOTPEnrolled) {
+				return invalidArgument(cmd, err)
+			}
+			return totpError(err)
+		},
+	}
+
+	recoveryCmd := dangerous(&cobra.Command{
+		Use:   "recovery-codes USER",
+		Short: "Replace the recovery codes of a user and print them.",
+		Long: fmt.Sprintf(`Replace the recovery codes of a user with %d new ones, and print them. Each
+code can be used once in place of a one-time code. Codes are shown once;
+only their digests are kept.`, config.RecoveryCodes),
+		Args: cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleUserAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkManageTOTP(cmd, args[0]); err != nil {
+				return err
+			}
+			ac, s := fromContext(cmd)
+			codes, err := ac.GenerateRecoveryCodes(args[0])
+			if err != nil {
+				return totpError(err)
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(codes)
+			}
+			fmt.Fprintln(s, strings.Join(codes, "\n"))
+			return nil
+		},
+	})
+
+	resetCmd := dangerous(&cobra.Command{
+		Use:   "reset USER",
+		Short: "Remove the two-factor authentication of a user.",
+		Long: `Remove the two-factor authentication of a user, e.g. when they lost their
+authenticator and recovery codes, so that they can enroll again.`,
+		Args: cobra.ExactArgs(1),
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleUserAdmin),
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkManageTOTP(cmd, args[0]); err != nil {
+				return err
+			}
+			ac, _ := fromContext(cmd)
+			return totpError(ac.ResetTOTP(args[0]))
+		},
+	})
+
+	totpCmd.AddCommand(statusCmd, enrollCmd, confirmCmd, recoveryCmd, resetCmd)
+	return totpCmd
+}
+
+// checkManageTOTP returns an error unless the user of the session can manage
+// the two-factor authentication of the named user: user admins can manage
+// that of users, like their keys, and admins that of everyone, including
+// directory users.
+func checkManageTOTP(cmd *cobra.Command, name string) error {
+	ac, s := fromContext(cmd)
+	if err := checkRole(cmd, config.RoleUserAdmin); err != nil {
+		return err
+	}
+	if _, ok := ac.GetUser(name); ok {
+		return checkManageUser(cmd, name)
+	}
+	if ac.AuthRepoCtx(s.Context(), "config", s.PublicKey()) < gitwish.AdminAccess {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// totpError returns the command error of a two-factor authentication error.
+func totpError(err error) error {
+	switch {
+	case errors.Is(err, config.ErrTOTPRequired):
+		return ErrTOTPRequired
+	case errors.Is(err, config.ErrCodeRequired):
+		return ErrOTPRequired
+	case errors.Is(err, config.ErrInvalidCode):
+		return ErrOTPInvalid
+	case errors.Is(err, config.ErrTOTPNotEnrolled):
+		return ErrTOTPNotEnrolled
+	case errors.Is(err, config.ErrUnknownUser):
+		return ErrTOTPNeedsUser
+	}
+	return err
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/charmbracelet/soft-serve/config"
+	gitwish "github.com/charmbracelet/wish/git"
+	"github.com/spf13/cobra"
+)
+
+// ErrUserProtected is returned when a user admin changes an admin or a user
+// with roles.
+var ErrUserProtected = &Error{
+	Code:    "user_protected",
+	Message: "Only admins can change admins and users with roles",
+	Hint:    "ask an admin to make the change",
+	Status:  StatusUnauthorized,
+}
+
+// userRecord is a user as listed by the user command.
+type userRecord struct {
+	Name       string   `json:"name"`
+	Admin      bool     `json:"admin"`
+	Roles      []string `json:"roles"`
+	Teams      []string `json:"teams"`
+	PublicKeys []string `json:"public-keys"`
+}
+
+// UserCommand returns a command that manages the users of the server.
+func UserCommand() *cobra.Command {
+	userCmd := &cobra.Command{
+		Use:   "user",
+		Short: "Manage users and their keys.",
+		Long: `Manage the users of the server and their public keys. User admins can manage
+users who aren't admins and don't have roles; only admins can change admins,
+and grant and revoke roles:
+
+  user-admin      manage users, their keys, and the collaborators of repos
+  repo-admin      admin access to all repos but the config repo
+  settings-admin  manage secrets, maintenance, retention, bandwidth, event
+                  deliveries, and connected sessions
+  auditor         read the audit log, access control, and connected sessions
+
+Changes are committed to the config repo.`,
+		Example: `  user create Frankie --key "ssh-ed25519 AAAA..."
+  user add-key Frankie ssh-ed25519 AAAA...
+  user grant Frankie user-admin
+  user list`,
+		Annotations: map[string]string{
+			accessAnnotation: roleAccess(config.RoleUserAdmin),
+		},
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			silenceIfJSON(cmd)
+			return checkRole(cmd, config.RoleUserAdmin)
+		},
+	}
+
+	listCmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List the users.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			records := make([]userRecord, 0)
+			for _, u := range ac.ListUsers() {
+				records = append(records, userRecord{
+					Name:       u.Name,
+					Admin:      u.Admin,
+					Roles:      append([]string{}, u.Roles...),
+					Teams:      ac.UserTeams(u.Name),
+					PublicKeys: append([]string{}, u.PublicKeys...),
+				})
+			}
+			if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+				return json.NewEncoder(s).Encode(records)
+			}
+			tw := tabwriter.NewWriter(s, 0, 4, 2, ' ', 0)
+			for _, r := range records {
+				roles := strings.Join(r.Roles, ",")
+				if r.Admin {
+					roles = "admin"
+				}
+				if roles == "" {
+					roles = "-"
+				}
+				teams := strings.Join(r.Teams, ",")
+				if teams == "" {
+					teams = "-"
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%d keys\n", r.Name, roles, teams, len(r.PublicKeys))
+			}
+			return tw.Flush()
+		},
+	}
+
+	var keys []string
+	createCmd := &cobra.Command{
+		Use:   "create NAME",
+		Short: "Add a user.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ac, _ := fromContext(cmd)
+			return userError(cmd, ac.CreateUser(args[0], keys))
+		},
+	}
+	createCmd.Flags().StringArrayVar(&keys, "key", nil, "Public key of the user, in authorized_keys format; repeat for several keys")
+
+	deleteCmd := dangerous(&cobra.Command{
+		Use:     "delete NAME",
+		Aliases: []string{"rm"},
+		Short:   "Remove a user and their collaborations.",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := checkManageUser(cmd, args[0]); err != nil {
+				return err
+			}
+			ac, _ := fromContext(cmd)
+			return userError(cmd, ac.DeleteUser(args[0]))
+		},
+	})
+
+	setKey := func(present bool) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			if err := checkManageUser(cmd, args[0]); err != nil {
+				return err
+			}
+			ac, _ := fromContext(cmd)
+			return userError(cmd, ac.SetUserKey(args[0], strings.Join(args[1:], " "), present))
+		}
+	}
+
+	addKeyCmd := &cobra.Command{
+		Use:   "add-key NAME KEY",
+		Short: "Add a public key to a user.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  setKey(true),
+	}
+
+	removeKeyCmd := &cobra.Command{
+		Use:   "remove-key NAME KEY",
+		Short: "Remove a public key of a user.",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  setKey(false),
+	}
+
+	setRole := func(present bool) func(cmd *cobra.Command, args []string) error {
+		return func(cmd *cobra.Command, args []string) error {
+			ac, s := fromContext(cmd)
+			if ac.AuthRepoCtx(s.Context(), "config", s.PublicKey()) < gitwish.AdminAccess {
+				return ErrUnauthorized
+			}
+			if err := config.ValidRole(args[1]); err != nil {
+				return invalidArgument(cmd, err)
+			}
+			return userError(cmd, ac.SetUserRole(args[0], config.Role(args[1]), present))
+		}
+	}
+
+	grantCmd := &cobra.Command{
+		Use:   "grant NAME ROLE",
+		Short: "Grant a role to a user.",
+		Args:  cobra.ExactArgs(2),
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: setRole(true),
+	}
+
+	revokeCmd := &cobra.Command{
+		Use:   "revoke NAME ROLE",
+		Short: "Revoke a role of a user.",
+		Args:  cobra.ExactArgs(2),
+		Annotations: map[string]string{
+			accessAnnotation: "admin-access",
+		},
+		RunE: setRole(false),
+	}
+
+	userCmd.AddCommand(listCmd, createCmd, deleteCmd, addKeyCmd, removeKeyCmd, grantCmd, revokeCmd)
+
+	return userCmd
+}
+
+// checkManageUser returns an error unless the user of the session can change
+// the named user: admins can change anyone, user admins only users who
+// aren't admins and don't have roles, so they can't take over their access.
+func checkManageUser(cmd *cobra.Command, name string) error {
+	ac, s := fromContext(cmd)
+	u, ok := ac.GetUser(name)
+	if !ok {
+		return ErrUserNotFound
+	}
+	if (u.Admin || len(u.Roles) > 0) && ac.AuthRepoCtx(s.Context(), "config", s.PublicKey()) < gitwish.AdminAccess {
+		return ErrUserProtected
+	}
+	return nil
+}
+
+// userError returns the command error of an error changing a user.
+func userError(cmd *cobra.Command, err error) error {
+	switch {
+	case errors.Is(err, config.ErrUnknownUser):
+		return ErrUserNotFound
+	case errors.Is(err, config.ErrUserExists), errors.Is(err, config.ErrInvalidUserName),
+		errors.Is(err, config.ErrKeyInUse), errors.Is(err, config.ErrInvalidKey):
+		return invalidArgument(cmd, err)
+	}
+	return err
+}
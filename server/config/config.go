@@ -3,6 +3,7 @@ package config
 import (
 	glog "log"
 	"path/filepath"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 	"github.com/charmbracelet/log"
@@ -16,33 +17,90 @@ type Callbacks interface {
 }
 
 // Config is the configuration for Soft Serve.
+//
+// Each field with an env tag is a setting, see Settings. The help tag
+// describes it for the flags of soft serve.
 type Config struct {
-	BindAddr         string   `env:"SOFT_SERVE_BIND_ADDRESS" envDefault:""`
-	Host             string   `env:"SOFT_SERVE_HOST" envDefault:"localhost"`
-	Port             int      `env:"SOFT_SERVE_PORT" envDefault:"23231"`
-	KeyPath          string   `env:"SOFT_SERVE_KEY_PATH"`
-	RepoPath         string   `env:"SOFT_SERVE_REPO_PATH" envDefault:".repos"`
-	Debug            bool     `env:"SOFT_SERVE_DEBUG" envDefault:"false"`
-	InitialAdminKeys []string `env:"SOFT_SERVE_INITIAL_ADMIN_KEY" envSeparator:"\n"`
-	Callbacks        Callbacks
-	ErrorLog         *glog.Logger
+	BindAddr         string        `env:"SOFT_SERVE_BIND_ADDRESS" envDefault:"" help:"Network interface to listen on"`
+	Host             string        `env:"SOFT_SERVE_HOST" envDefault:"localhost" help:"Address to use in public clone URLs"`
+	Port             int           `env:"SOFT_SERVE_PORT" envDefault:"23231" help:"SSH listen port"`
+	HTTPPort         int           `env:"SOFT_SERVE_HTTP_PORT" envDefault:"23232" help:"HTTP listen port serving public repos, 0 to disable"`
+	GitPort          int           `env:"SOFT_SERVE_GIT_PORT" envDefault:"0" help:"Git daemon listen port for anonymous read-only fetches, usually 9418, 0 to disable"`
+	MetricsPort      int           `env:"SOFT_SERVE_METRICS_PORT" envDefault:"0" help:"Listen port serving Prometheus metrics at /metrics, e.g. 23233, 0 to disable"`
+	ACMEDomains      []string      `env:"SOFT_SERVE_ACME_DOMAINS" envSeparator:"," help:"Hostnames to get Let's Encrypt certificates for, serving HTTPS on the HTTP port"`
+	ACMEEmail        string        `env:"SOFT_SERVE_ACME_EMAIL" help:"Contact email of the Let's Encrypt account, for expiry notices"`
+	ACMEDirectory    string        `env:"SOFT_SERVE_ACME_DIRECTORY" help:"ACME directory URL to get certificates from (default Let's Encrypt)"`
+	KeyPath          string        `env:"SOFT_SERVE_KEY_PATH" help:"SSH host key-pair path (default .ssh/soft_serve_server_ed25519)"`
+	RepoPath         string        `env:"SOFT_SERVE_REPO_PATH" envDefault:".repos" help:"Path where repos are stored"`
+	Debug            bool          `env:"SOFT_SERVE_DEBUG" envDefault:"false" help:"Log debug messages, same as a debug log level"`
+	LogLevel         string        `env:"SOFT_SERVE_LOG_LEVEL" envDefault:"info" help:"Level of logged messages: debug, info, warn, or error"`
+	LogFormat        string        `env:"SOFT_SERVE_LOG_FORMAT" envDefault:"text" help:"Format of logged messages: text, json, or logfmt"`
+	LogPath          string        `env:"SOFT_SERVE_LOG_PATH" help:"File logs are appended to (default stderr)"`
+	InitialAdminKeys []string      `env:"SOFT_SERVE_INITIAL_ADMIN_KEY" envSeparator:"\n" help:"Public key, or path of a public key, with admin access when the config repo is created"`
+	Hyperlinks       bool          `env:"SOFT_SERVE_HYPERLINKS" envDefault:"true" help:"Make URLs in the TUI clickable"`
+	EventsAddress    string        `env:"SOFT_SERVE_EVENTS_ADDRESS" envDefault:"" help:"Syslog or SIEM address to forward events to, e.g. udp://localhost:514"`
+	EventsFormat     string        `env:"SOFT_SERVE_EVENTS_FORMAT" envDefault:"syslog" help:"Format of forwarded events: syslog, cef, or json"`
+	SecretsPath      string        `env:"SOFT_SERVE_SECRETS_PATH" help:"Path of the encrypted secrets store (default soft_serve_secrets.json next to the SSH key)"`
+	SecretsKeyPath   string        `env:"SOFT_SERVE_SECRETS_KEY_PATH" help:"Path of the key sealing the secrets store (default soft_serve_secrets_key next to the SSH key)"`
+	BackupTarget     string        `env:"SOFT_SERVE_BACKUP_TARGET" envDefault:"" help:"Directory or rsync destination to back up repos to"`
+	BackupInterval   time.Duration `env:"SOFT_SERVE_BACKUP_INTERVAL" envDefault:"24h" help:"How often changed repos are backed up"`
+	BackupVerify     time.Duration `env:"SOFT_SERVE_BACKUP_VERIFY_INTERVAL" envDefault:"168h" help:"How often a random backup is test-restored"`
+	CommitterName    string        `env:"SOFT_SERVE_COMMITTER_NAME" envDefault:"Soft Serve Server" help:"Name of the committer of commits made by the server"`
+	CommitterEmail   string        `env:"SOFT_SERVE_COMMITTER_EMAIL" envDefault:"vt100@charm.sh" help:"Email of the committer of commits made by the server"`
+	SigningKeyPath   string        `env:"SOFT_SERVE_SIGNING_KEY_PATH" help:"Path of an OpenPGP private key signing commits made by the server"`
+	MailmapPath      string        `env:"SOFT_SERVE_MAILMAP_PATH" help:"Path of a mailmap applied to the authors of all repos"`
+	DataPath         string        `env:"SOFT_SERVE_DATA_PATH" envDefault:".data" help:"Path where audit logs, session recordings, trashed repos, stored events, artifacts, and certificates are stored"`
+	HooksPath        string        `env:"SOFT_SERVE_HOOKS_PATH" help:"Directory of the git hooks run on pushes to all repos (default hooks in the data path)"`
+	RetentionEvery   time.Duration `env:"SOFT_SERVE_RETENTION_INTERVAL" envDefault:"24h" help:"How often retention policies are enforced and repo disk usage is measured"`
+	Chaos            string        `env:"SOFT_SERVE_CHAOS" help:"Faults to inject into storage and git operations, in chaos builds"`
+	// Name, AnonAccess, and AllowKeyless override the settings of the
+	// config repo when set.
+	Name         string `env:"SOFT_SERVE_NAME" help:"Server name shown in the TUI, overriding the config repo"`
+	AnonAccess   string `env:"SOFT_SERVE_ANON_ACCESS" help:"Access level of anonymous users, overriding the config repo"`
+	AllowKeyless *bool  `env:"SOFT_SERVE_ALLOW_KEYLESS" help:"Allow users without keys, overriding the config repo"`
+	Callbacks    Callbacks
+	ErrorLog     *glog.Logger
 }
 
 // DefaultConfig returns a Config with the values populated with the defaults
 // or specified environment variables.
 func DefaultConfig() *Config {
+	cfg, err := Environ()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return cfg.WithDefaults()
+}
+
+// Environ returns a Config with the values of the environment variables and
+// the defaults of the settings. Unlike DefaultConfig, it leaves the settings
+// whose defaults derive from others empty, so that they can be changed first,
+// e.g. by flags; call WithDefaults afterwards.
+func Environ() (*Config, error) {
 	cfg := &Config{ErrorLog: log.StandardLog(log.StandardLogOptions{ForceLevel: log.ErrorLevel})}
 	if err := env.Parse(cfg); err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	if cfg.Debug {
+	return cfg.WithCallbacks(nil), nil
+}
+
+// WithDefaults sets the settings that default to values derived from other
+// settings, and applies the debug setting.
+func (c *Config) WithDefaults() *Config {
+	if c.Debug {
 		log.SetLevel(log.DebugLevel)
 	}
-	if cfg.KeyPath == "" {
+	if c.KeyPath == "" {
 		// NB: cross-platform-compatible path
-		cfg.KeyPath = filepath.Join(".ssh", "soft_serve_server_ed25519")
+		c.KeyPath = filepath.Join(".ssh", "soft_serve_server_ed25519")
+	}
+	if c.SecretsPath == "" {
+		c.SecretsPath = filepath.Join(filepath.Dir(c.KeyPath), "soft_serve_secrets.json")
 	}
-	return cfg.WithCallbacks(nil)
+	if c.SecretsKeyPath == "" {
+		c.SecretsKeyPath = filepath.Join(filepath.Dir(c.KeyPath), "soft_serve_secrets_key")
+	}
+	return c
 }
 
 // WithCallbacks applies the given Callbacks to the configuration.
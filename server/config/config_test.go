@@ -2,8 +2,12 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/charmbracelet/log"
 	"github.com/matryer/is"
 )
 
@@ -17,3 +21,81 @@ func TestParseMultipleKeys(t *testing.T) {
 		"testdata/k2.pub",
 	})
 }
+
+func TestSettings(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(os.Setenv("SOFT_SERVE_PORT", "2222"))
+	t.Cleanup(func() { is.NoErr(os.Unsetenv("SOFT_SERVE_PORT")) })
+	cfg, err := Environ()
+	is.NoErr(err)
+	settings := make(map[string]*Setting)
+	for _, s := range cfg.Settings() {
+		is.True(s.Usage != "") // every setting should be documented
+		settings[s.Flag] = s
+	}
+
+	// The environment overrides defaults, and flags override both.
+	is.Equal(settings["port"].Env, "SOFT_SERVE_PORT")
+	is.Equal(settings["port"].Default, "23231")
+	is.Equal(settings["port"].String(), "2222")
+	is.NoErr(settings["port"].Set("3333"))
+	is.Equal(cfg.Port, 3333)
+	is.True(settings["port"].Set("port") != nil)
+
+	is.NoErr(settings["backup-interval"].Set("1h"))
+	is.Equal(cfg.BackupInterval, time.Hour)
+	is.Equal(settings["hyperlinks"].Type(), "bool")
+	is.NoErr(settings["hyperlinks"].Set("false"))
+	is.Equal(cfg.Hyperlinks, false)
+
+	is.Equal(cfg.AllowKeyless, nil)
+	is.Equal(settings["allow-keyless"].String(), "")
+	is.NoErr(settings["allow-keyless"].Set("true"))
+	is.Equal(*cfg.AllowKeyless, true)
+
+	// Lists are replaced, then appended to.
+	cfg.InitialAdminKeys = []string{"from-env"}
+	is.NoErr(settings["initial-admin-key"].Set("k1"))
+	is.NoErr(settings["initial-admin-key"].Set("k2"))
+	is.Equal(cfg.InitialAdminKeys, []string{"k1", "k2"})
+
+	// Derived defaults follow the settings they derive from.
+	is.NoErr(settings["key-path"].Set(filepath.Join("keys", "host")))
+	cfg.WithDefaults()
+	is.Equal(cfg.SecretsPath, filepath.Join("keys", "soft_serve_secrets.json"))
+}
+
+func TestSettingsDocumented(t *testing.T) {
+	is := is.New(t)
+	readme, err := os.ReadFile(filepath.Join("..", "..", "README.md"))
+	is.NoErr(err)
+	for _, s := range (&Config{}).Settings() {
+		if !strings.Contains(string(readme), "`"+s.Env+"`") {
+			t.Errorf("%s is missing from the README", s.Env)
+		}
+	}
+}
+
+func TestLogOptions(t *testing.T) {
+	is := is.New(t)
+	cfg := &Config{}
+	f, l, err := cfg.LogOptions()
+	is.NoErr(err)
+	is.Equal(f, log.TextFormatter)
+	is.Equal(l, log.InfoLevel)
+
+	cfg = &Config{LogFormat: "JSON", LogLevel: "warn"}
+	f, l, err = cfg.LogOptions()
+	is.NoErr(err)
+	is.Equal(f, log.JSONFormatter)
+	is.Equal(l, log.WarnLevel)
+	cfg.Debug = true
+	_, l, err = cfg.LogOptions()
+	is.NoErr(err)
+	is.Equal(l, log.DebugLevel)
+
+	_, _, err = (&Config{LogFormat: "xml"}).LogOptions()
+	is.True(err != nil)
+	_, _, err = (&Config{LogLevel: "trace"}).LogOptions()
+	is.True(err != nil)
+}
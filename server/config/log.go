@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// logFormats maps the values of the LogFormat setting to formatters.
+var logFormats = map[string]log.Formatter{
+	"text":   log.TextFormatter,
+	"json":   log.JSONFormatter,
+	"logfmt": log.LogfmtFormatter,
+}
+
+// logLevels maps the values of the LogLevel setting to levels.
+var logLevels = map[string]log.Level{
+	"debug": log.DebugLevel,
+	"info":  log.InfoLevel,
+	"warn":  log.WarnLevel,
+	"error": log.ErrorLevel,
+}
+
+// LogOptions returns the formatter and level of the LogFormat and LogLevel
+// settings. Empty settings default to text and info; Debug lowers the level
+// to debug.
+func (c *Config) LogOptions() (log.Formatter, log.Level, error) {
+	format := strings.ToLower(c.LogFormat)
+	if format == "" {
+		format = "text"
+	}
+	f, ok := logFormats[format]
+	if !ok {
+		return f, 0, fmt.Errorf("invalid log format %q, must be text, json, or logfmt", c.LogFormat)
+	}
+	level := strings.ToLower(c.LogLevel)
+	if level == "" {
+		level = "info"
+	}
+	l, ok := logLevels[level]
+	if !ok {
+		return f, l, fmt.Errorf("invalid log level %q, must be debug, info, warn, or error", c.LogLevel)
+	}
+	if c.Debug {
+		l = log.DebugLevel
+	}
+	return f, l, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting is a setting of Config. It implements the flag.Value interface of
+// the flag and pflag packages, setting the field of the Config it was
+// returned for.
+//
+// Settings take their value from, in increasing order of precedence, their
+// default, their environment variable, and their flag. Those overriding the
+// config repo take precedence over it.
+type Setting struct {
+	// Env is the environment variable of the setting, e.g. SOFT_SERVE_PORT.
+	Env string
+	// Flag is the name of the flag of the setting, e.g. port.
+	Flag string
+	// Default is the default value of the setting, if any.
+	Default string
+	// Usage describes the setting.
+	Usage string
+
+	v   reflect.Value
+	set bool
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Settings returns the settings of c, in the order they're declared.
+func (c *Config) Settings() []*Setting {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+	settings := make([]*Setting, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		e, ok := f.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		settings = append(settings, &Setting{
+			Env:     e,
+			Flag:    strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(e, "SOFT_SERVE_")), "_", "-"),
+			Default: f.Tag.Get("envDefault"),
+			Usage:   f.Tag.Get("help"),
+			v:       v.Field(i),
+		})
+	}
+	return settings
+}
+
+// String returns the value of the setting.
+func (s *Setting) String() string {
+	if s == nil || !s.v.IsValid() {
+		return ""
+	}
+	v := s.v
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		return strings.Join(v.Interface().([]string), ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// Set sets the setting. Settings holding lists replace their value the first
+// time they're set and append to it afterwards, so that their flag can be
+// repeated.
+func (s *Setting) Set(value string) error {
+	v := s.v
+	if v.Kind() == reflect.Ptr {
+		p := reflect.New(v.Type().Elem())
+		v.Set(p)
+		v = p.Elem()
+	}
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice:
+		l := []string{value}
+		if s.set {
+			l = append(v.Interface().([]string), value)
+		}
+		v.Set(reflect.ValueOf(l))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	s.set = true
+	return nil
+}
+
+// Type returns the type of the setting, for flag usage.
+func (s *Setting) Type() string {
+	t := s.v.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return "duration"
+	case t.Kind() == reflect.Slice:
+		return "strings"
+	default:
+		return t.Kind().String()
+	}
+}
@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	gm "github.com/charmbracelet/wish/git"
+)
+
+const (
+	// daemonRequestTimeout is how long clients have to send their request.
+	daemonRequestTimeout = 10 * time.Second
+	// daemonIdleTimeout is how long upload-pack waits for clients.
+	daemonIdleTimeout = 60
+)
+
+// errDaemonClosed is returned by the Serve method of a closed git daemon.
+var errDaemonClosed = errors.New("git daemon closed")
+
+// gitDaemon serves anonymous, read-only fetches of repos over the git
+// protocol, like git daemon. Repos are served if anonymous users can read
+// them.
+type gitDaemon struct {
+	ac   *appCfg.Config
+	addr string
+	// ctx is canceled to kill the running fetches.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mtx       sync.Mutex
+	closed    bool
+	listeners map[net.Listener]struct{}
+	conns     sync.WaitGroup
+}
+
+func newGitDaemon(addr string, ac *appCfg.Config) *gitDaemon {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &gitDaemon{
+		ac:        ac,
+		addr:      addr,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+	}
+}
+
+// ListenAndServe listens on the address of the daemon and serves it.
+func (d *gitDaemon) ListenAndServe() error {
+	l, err := net.Listen("tcp", d.addr)
+	if err != nil {
+		return err
+	}
+	return d.Serve(l)
+}
+
+// Serve serves connections accepted on l until the daemon is closed, when it
+// returns errDaemonClosed.
+func (d *gitDaemon) Serve(l net.Listener) error {
+	d.mtx.Lock()
+	if d.closed {
+		d.mtx.Unlock()
+		l.Close()
+		return errDaemonClosed
+	}
+	d.listeners[l] = struct{}{}
+	d.mtx.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			d.mtx.Lock()
+			closed := d.closed
+			d.mtx.Unlock()
+			if closed {
+				return errDaemonClosed
+			}
+			var ne net.Error
+			if errors.As(err, &ne) && ne.Temporary() {
+				time.Sleep(100 * time.Millisecond)
+				continue
+			}
+			return err
+		}
+		d.conns.Add(1)
+		go func() {
+			defer d.conns.Done()
+			defer conn.Close()
+			d.handle(conn)
+		}()
+	}
+}
+
+// Shutdown stops accepting connections and waits for the open ones to be
+// done, or for ctx to be done.
+func (d *gitDaemon) Shutdown(ctx context.Context) error {
+	d.closeListeners()
+	done := make(chan struct{})
+	go func() {
+		d.conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		d.cancel()
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting connections and kills the running fetches.
+func (d *gitDaemon) Close() error {
+	d.closeListeners()
+	d.cancel()
+	return nil
+}
+
+func (d *gitDaemon) closeListeners() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.closed = true
+	for l := range d.listeners {
+		l.Close()
+		delete(d.listeners, l)
+	}
+}
+
+// handle serves a single request.
+func (d *gitDaemon) handle(conn net.Conn) {
+	l := log.With("request", newRequestID())
+	_ = conn.SetReadDeadline(time.Now().Add(daemonRequestTimeout))
+	// The request is read unbuffered, so that what follows is left for
+	// upload-pack.
+	service, path, extra, err := readDaemonRequest(conn)
+	if err != nil {
+		l.Debug("invalid git daemon request", "remote", conn.RemoteAddr(), "err", err)
+		return
+	}
+	if service != "git-upload-pack" {
+		writeDaemonError(conn, "service not enabled")
+		return
+	}
+	repo := strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	// Private repos are reported missing rather than forbidden, so that
+	// their names aren't disclosed.
+	if repo == "" || strings.Contains(repo, "/") || !d.readable(repo) {
+		writeDaemonError(conn, "repository not found")
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	d.ac.Fetch(repo, nil)
+	l.Debug("git daemon request", "repo", repo, "remote", conn.RemoteAddr())
+	cmd := exec.CommandContext(d.ctx, "git", "upload-pack", "--strict",
+		"--timeout="+strconv.Itoa(daemonIdleTimeout),
+		filepath.Join(d.ac.Source.Dir(), repo))
+	cmd.Env = os.Environ()
+	for _, e := range extra {
+		if strings.HasPrefix(e, "version=") {
+			cmd.Env = append(cmd.Env, "GIT_PROTOCOL="+e)
+		}
+	}
+	cmd.Stdout = conn
+	// Clients keep their end open until the response is done, so stdin is
+	// copied by hand rather than by exec, which would wait for the copy to
+	// end. Closing the conn once upload-pack exits stops the copy.
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		l.Error("git daemon upload-pack", "repo", repo, "err", err)
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		l.Error("git daemon upload-pack", "repo", repo, "err", err)
+		return
+	}
+	go func() {
+		_, _ = io.Copy(stdin, conn)
+		stdin.Close()
+	}()
+	if err := cmd.Wait(); err != nil {
+		l.Debug("git daemon upload-pack", "repo", repo, "err", err)
+	}
+}
+
+// readable returns whether the repo exists and can be read anonymously.
+func (d *gitDaemon) readable(repo string) bool {
+	if _, err := d.ac.Source.GetRepo(repo); err != nil {
+		return false
+	}
+	return d.ac.AuthRepo(repo, nil) >= gm.ReadOnlyAccess
+}
+
+// readDaemonRequest reads the request a client sends to git daemon, a
+// pkt-line of the form "service path\0host=host\0\0extra\0...".
+func readDaemonRequest(r io.Reader) (service, path string, extra []string, err error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", "", nil, err
+	}
+	n, err := strconv.ParseUint(string(size[:]), 16, 16)
+	if err != nil || n <= 4 {
+		return "", "", nil, fmt.Errorf("invalid pkt-line length %q", size)
+	}
+	payload := make([]byte, n-4)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return "", "", nil, err
+	}
+	fields := strings.Split(string(payload), "\x00")
+	i := strings.Index(fields[0], " ")
+	if i < 0 {
+		return "", "", nil, fmt.Errorf("invalid request %q", fields[0])
+	}
+	service, path = fields[0][:i], strings.TrimSuffix(fields[0][i+1:], "\n")
+	// Extra parameters follow the host, after an empty field.
+	for j := 1; j+1 < len(fields); j++ {
+		if fields[j] == "" {
+			for _, e := range fields[j+1:] {
+				if e != "" {
+					extra = append(extra, e)
+				}
+			}
+			break
+		}
+	}
+	return service, path, extra, nil
+}
+
+// writeDaemonError sends an error to the client as an ERR pkt-line.
+func writeDaemonError(w io.Writer, msg string) {
+	line := "ERR " + msg + "\n"
+	fmt.Fprintf(w, "%04x%s", len(line)+4, line)
+}
@@ -0,0 +1,55 @@
+package server_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/matryer/is"
+)
+
+func TestGitDaemon(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	url := func(repo string) string {
+		return fmt.Sprintf("git://%s/%s", s.GitAddr, repo)
+	}
+
+	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: url("repo.git")})
+	is.NoErr(err)
+
+	// Pushing isn't enabled.
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	sig := &object.Signature{Name: "test", Email: "test@example.com"}
+	_, err = wt.Commit("empty commit", &git.CommitOptions{
+		Author:            sig,
+		Committer:         sig,
+		AllowEmptyCommits: true,
+	})
+	is.NoErr(err)
+	err = r.Push(&git.PushOptions{})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "service not enabled"))
+
+	// Private repos look like they don't exist.
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: url("config")})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "repository not found"))
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: url("missing")})
+	is.True(strings.Contains(err.Error(), "repository not found"))
+
+	// Public repos too, when anonymous users have no access.
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf("anon-access: no-access\nusers:\n  - name: admin\n    admin: true\n    public-keys:\n      - %s\n", s.Admin.AuthorizedKey()),
+	}))
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: url("repo")})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "repository not found"))
+}
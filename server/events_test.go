@@ -0,0 +1,302 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/hooks"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	ghttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestEventsReplay(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+`, s.Admin.AuthorizedKey(), hash),
+	}))
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	s.CreateRepo("other", map[string]string{"README.md": "# Other\n"})
+	// Push over HTTP, which reports pushes once they're done.
+	auth := &ghttp.BasicAuth{Username: "admin", Password: "secret"}
+	heads := make(map[string]string)
+	for _, repo := range []string{"repo", "other"} {
+		r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+			URL:  fmt.Sprintf("http://%s/%s.git", s.HTTPAddr, repo),
+			Auth: auth,
+		})
+		is.NoErr(err)
+		wt, err := r.Worktree()
+		is.NoErr(err)
+		sig := &object.Signature{Name: "test", Email: "test@example.com"}
+		_, err = wt.Commit("empty commit", &git.CommitOptions{
+			Author:            sig,
+			Committer:         sig,
+			AllowEmptyCommits: true,
+		})
+		is.NoErr(err)
+		is.NoErr(r.Push(&git.PushOptions{Auth: auth}))
+		head, err := r.Head()
+		is.NoErr(err)
+		heads[repo] = head.Hash().String()
+	}
+
+	var mtx sync.Mutex
+	var got []events.Event
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bts, _ := io.ReadAll(r.Body)
+		var e events.Event
+		if err := json.Unmarshal(bts, &e); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mtx.Lock()
+		got = append(got, e)
+		mtx.Unlock()
+	}))
+	defer ts.Close()
+
+	// Events are stored asynchronously.
+	cmd := "events replay --repo repo --since 1h --target " + ts.URL
+	var replayed []events.Event
+	pushed := func() bool {
+		for _, e := range replayed {
+			if e.Commit == heads["repo"] {
+				return true
+			}
+		}
+		return false
+	}
+	for deadline := time.Now().Add(5 * time.Second); !pushed() && time.Now().Before(deadline); {
+		out, err := s.Run(s.Admin, cmd+" --dry-run --json")
+		is.NoErr(err)
+		is.NoErr(json.Unmarshal([]byte(out), &replayed))
+		time.Sleep(10 * time.Millisecond)
+	}
+	is.True(pushed())
+	mtx.Lock()
+	is.Equal(len(got), 0) // dry runs don't post events
+	mtx.Unlock()
+
+	out, err := s.Run(s.Admin, cmd)
+	is.NoErr(err)
+	is.True(strings.Contains(out, fmt.Sprintf("Replayed %d events", len(replayed))))
+	mtx.Lock()
+	is.Equal(len(got), len(replayed))
+	for _, e := range got {
+		is.Equal(e.Type, events.Push)
+		is.Equal(e.Repo, "repo")
+		is.True(e.Replayed)
+	}
+	is.Equal(got[len(got)-1].Commit, heads["repo"])
+	mtx.Unlock()
+
+	// Only admins can replay events.
+	_, err = s.Run(servertest.NewKey(t), cmd)
+	is.True(err != nil)
+	_, err = s.Run(s.Admin, "events replay --since yesterday --target "+ts.URL)
+	is.True(err != nil)
+}
+
+func TestPushHooks(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	got := make(chan events.Event, 4)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bts, _ := io.ReadAll(r.Body)
+		var e events.Event
+		if r.Header.Get(hooks.SignatureHeader) != hooks.Sign("hook-key", bts) || json.Unmarshal(bts, &e) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got <- e
+	}))
+	defer ts.Close()
+
+	// Repo hooks only call URLs, so the command hook never runs.
+	ran := filepath.Join(t.TempDir(), "ran")
+	sess := s.Session(s.Admin)
+	sess.Stdin = strings.NewReader("hook-key")
+	is.NoErr(sess.Run("secret set webhook/repo"))
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+repos:
+  - repo: repo
+    hooks:
+      - events: [push]
+        url: %s
+        signing-secret: webhook/repo
+      - events: [push]
+        command: touch %s
+`, s.Admin.AuthorizedKey(), hash, ts.URL, ran),
+	}))
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+
+	auth := &ghttp.BasicAuth{Username: "admin", Password: "secret"}
+	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:  fmt.Sprintf("http://%s/repo.git", s.HTTPAddr),
+		Auth: auth,
+	})
+	is.NoErr(err)
+	old, err := r.Head()
+	is.NoErr(err)
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	for _, msg := range []string{"first", "second"} {
+		_, err = wt.Commit(msg, &git.CommitOptions{
+			Author:            sig,
+			Committer:         sig,
+			AllowEmptyCommits: true,
+		})
+		is.NoErr(err)
+	}
+	is.NoErr(r.Push(&git.PushOptions{Auth: auth}))
+	head, err := r.Head()
+	is.NoErr(err)
+
+	// Skip the push creating the repo.
+	var e events.Event
+	for e.Commit != head.Hash().String() {
+		select {
+		case e = <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("hook not called")
+		}
+	}
+	is.Equal(e.Type, events.Push)
+	is.Equal(e.Repo, "repo")
+	is.Equal(e.User, "admin")
+	is.Equal(e.Before, old.Hash().String())
+	is.Equal(len(e.Commits), 2)
+	is.Equal(e.Commits[0].ID, head.Hash().String())
+	is.Equal(e.Commits[0].Message, "second")
+	is.Equal(e.Commits[1].Author, "test")
+
+	_, err = os.Stat(ran)
+	is.True(os.IsNotExist(err))
+}
+
+func TestEventsTest(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	var mtx sync.Mutex
+	posted := make([]string, 0)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The pushes setting up the repo are delivered too.
+		var e events.Event
+		if json.NewDecoder(r.Body).Decode(&e) == nil && e.Test {
+			mtx.Lock()
+			posted = append(posted, r.URL.Path)
+			mtx.Unlock()
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer ts.Close()
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+repos:
+  - repo: repo
+    hooks:
+      - events: [push]
+        url: %[2]s/hook
+        branches: [master]
+      - events: [push]
+        url: %[2]s/code
+        paths: [cmd/]
+    ci:
+      - provider: drone
+        url: %[2]s
+        pipeline: org/repo
+        paths: ["!docs/"]
+`, s.Admin.AuthorizedKey(), ts.URL),
+	}))
+	s.CreateRepo("repo", map[string]string{"cmd/main.go": "package main\n"})
+	is.NoErr(s.Push(s.Admin, "repo", map[string]string{"docs/index.md": "# Docs\n"}))
+
+	// Only the hook without path filters gets the docs-only push.
+	out, err := s.Run(s.Admin, "events test --repo repo --json")
+	is.NoErr(err)
+	var ds []struct {
+		Kind      string `json:"kind"`
+		Target    string `json:"target"`
+		Matched   bool   `json:"matched"`
+		Delivered bool   `json:"delivered"`
+		Checks    []struct {
+			Filter string `json:"filter"`
+			Match  bool   `json:"match"`
+		} `json:"checks"`
+	}
+	is.NoErr(json.Unmarshal([]byte(out), &ds))
+	is.Equal(len(ds), 3)
+	is.Equal(ds[0].Target, ts.URL+"/hook")
+	is.True(ds[0].Delivered)
+	is.Equal(ds[1].Target, ts.URL+"/code")
+	is.True(!ds[1].Matched)
+	is.Equal(ds[1].Checks[2].Filter, "paths")
+	is.True(!ds[1].Checks[2].Match)
+	is.Equal(ds[2].Kind, "ci")
+	is.Equal(ds[2].Target, "drone org/repo")
+	is.True(!ds[2].Delivered)
+	mtx.Lock()
+	is.Equal(posted, []string{"/hook"})
+	mtx.Unlock()
+
+	out, err = s.Run(s.Admin, `events test --repo repo --target "drone org/repo" --dry-run`)
+	is.NoErr(err)
+	is.True(strings.Contains(out, "ci drone org/repo: skipped"))
+	is.True(strings.Contains(out, "✗ paths: none of the 1 changed files match !docs/"))
+
+	// Pushes of tags don't match branch filters.
+	r, err := s.Source.GetRepo("repo")
+	is.NoErr(err)
+	is.NoErr(exec.Command("git", "-C", r.Path(), "tag", "v1.0.0", "HEAD~1").Run())
+	is.NoErr(s.Reload())
+	out, err = s.Run(s.Admin, "events test --repo repo --ref v1.0.0 --dry-run")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "hook "+ts.URL+"/hook: skipped"))
+	is.True(strings.Contains(out, "✗ branches: refs/tags/v1.0.0 is not a branch"))
+	is.True(strings.Contains(out, "✓ paths: cmd/main.go changed"))
+
+	_, err = s.Run(servertest.NewKey(t), "events test --repo repo")
+	is.True(err != nil)
+	mtx.Lock()
+	is.Equal(len(posted), 1)
+	mtx.Unlock()
+}
@@ -0,0 +1,78 @@
+package server_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestRepoExport(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	s.CreateRepo("empty", nil)
+
+	out, err := s.Run(s.Admin, "repo export --format json")
+	is.NoErr(err)
+	var records []struct {
+		Repo       string  `json:"repo"`
+		Visibility string  `json:"visibility"`
+		LastPush   *string `json:"last-push"`
+		Size       int64   `json:"size"`
+	}
+	is.NoErr(json.Unmarshal([]byte(out), &records))
+	is.Equal(len(records), 3)
+	is.Equal(records[0].Repo, "config")
+	is.Equal(records[0].Visibility, "private")
+	is.Equal(records[1].Repo, "empty")
+	is.Equal(records[2].Repo, "repo")
+	is.Equal(records[2].Visibility, "public")
+	is.True(records[2].LastPush != nil)
+	is.True(records[2].Size > 0)
+
+	// Other users only get the repos they can read.
+	out, err = s.Run(servertest.NewKey(t), "repo export")
+	is.NoErr(err)
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	is.NoErr(err)
+	is.Equal(len(rows), 3)
+	is.Equal(rows[0], []string{"repo", "visibility", "collaborators", "last_push", "size"})
+	is.Equal(rows[1][0], "empty")
+	is.Equal(rows[2][0], "repo")
+
+	_, err = s.Run(s.Admin, "repo export --format xml")
+	var ee *cssh.ExitError
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusInvalidArgument)
+}
+
+func TestRepoFeatures(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+
+	out, err := s.Run(s.Admin, "repo features repo")
+	is.NoErr(err)
+	is.Equal(out, "releases      on\nwebhooks      on\nhousekeeping  on\n")
+	_, err = s.Run(s.Admin, "repo disable repo releases")
+	is.NoErr(err)
+	out, err = s.Run(s.Admin, "repo features repo --json")
+	is.NoErr(err)
+	is.Equal(strings.TrimSpace(out), `{"housekeeping":true,"releases":false,"webhooks":true}`)
+
+	// Only admins of the repo can turn features on and off.
+	var ee *cssh.ExitError
+	_, err = s.Run(servertest.NewKey(t), "repo enable repo releases")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusUnauthorized)
+	_, err = s.Run(s.Admin, "repo disable repo wiki")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusInvalidArgument)
+}
@@ -0,0 +1,61 @@
+package server_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestGitHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("git hooks are shell scripts")
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	s := servertest.New(t)
+
+	// Hooks of the hooks directory of the data path, for one repo.
+	locked := filepath.Join(s.Config.DataPath, "hooks", "locked", "pre-receive")
+	is.NoErr(os.MkdirAll(filepath.Dir(locked), 0o755))
+	is.NoErr(os.WriteFile(locked, []byte("#!/bin/sh\necho locked by policy >&2\nexit 1\n"), 0o755))
+	// Hooks of the config repo, for all repos.
+	out := filepath.Join(t.TempDir(), "received")
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"hooks/post-receive": fmt.Sprintf("#!/bin/sh\necho \"$SOFT_SERVE_REPO $(cat)\" >> %s\n", out),
+	}))
+	// The hooks of the config repo are copied once the push is processed.
+	synced := filepath.Join(s.Config.DataPath, "config-hooks", "post-receive")
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, err := os.Stat(synced); err == nil {
+			break
+		}
+	}
+
+	s.CreateRepo("open", nil)
+	is.NoErr(s.Push(s.Admin, "open", map[string]string{"README.md": "# Open\n"}))
+	// Clients don't wait for post-receive hooks to finish.
+	var fs []string
+	for deadline := time.Now().Add(5 * time.Second); len(fs) < 4 && time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		bts, _ := os.ReadFile(out)
+		fs = strings.Fields(string(bts))
+	}
+	is.Equal(len(fs), 4)
+	is.Equal(fs[0], "open")
+	is.Equal(fs[3], "refs/heads/master")
+
+	// Repos created by pushing to them get their hooks first.
+	is.True(s.Push(s.Admin, "locked", map[string]string{"README.md": "# Locked\n"}) != nil)
+	r, err := s.Source.GetRepo("locked")
+	is.NoErr(err)
+	is.True(r.IsEmpty())
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+)
+
+// gitService returns the git service a smart HTTP request is for, if any.
+// Those are GET requests to info/refs with a service parameter, and POST
+// requests to the service itself.
+func gitService(r *http.Request, rest string) string {
+	var service string
+	switch {
+	case r.Method == http.MethodGet && rest == "info/refs":
+		service = r.URL.Query().Get("service")
+	case r.Method == http.MethodPost:
+		service = rest
+	}
+	switch service {
+	case "git-upload-pack", "git-receive-pack":
+		return service
+	}
+	return ""
+}
+
+// serveGit serves the smart HTTP git protocol by running git http-backend.
+// Users authenticate with basic auth using their name and HTTP password, and
+// get the same access to the repo as they do over SSH. A personal access
+// token can be used instead of the password, or as a bearer token, capping
+// the access to its scope.
+func (h *httpHandler) serveGit(w http.ResponseWriter, r *http.Request, repo, rest, service string) {
+	var pk ssh.PublicKey
+	scope := gm.AdminAccess
+	user, password, hasAuth := r.BasicAuth()
+	bearer := strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if bearer {
+		hasAuth, password = true, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+	if hasAuth {
+		var ok bool
+		if bearer || strings.HasPrefix(password, appCfg.TokenPrefix) {
+			pk, scope, ok = h.cfg.TokenAuth(r.RemoteAddr, password)
+		} else {
+			pk, ok = h.cfg.BasicAuth(r.RemoteAddr, user, password)
+		}
+		if !ok {
+			h.unauthorized(w)
+			return
+		}
+	}
+	need := gm.ReadOnlyAccess
+	if service == "git-receive-pack" {
+		need = gm.ReadWriteAccess
+	}
+	if h.cfg.AuthRepo(repo, pk) < need || scope < need {
+		if !hasAuth {
+			h.unauthorized(w)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+	if service == "git-receive-pack" {
+		if m := h.cfg.ActiveMaintenance(repo, time.Now()); m != nil {
+			if !m.End.IsZero() {
+				w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(m.End).Seconds())+1))
+			}
+			http.Error(w, m.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err := quotaExceeded(r.Context(), h.cfg, repo); err != nil {
+			http.Error(w, err.Error(), http.StatusInsufficientStorage)
+			return
+		}
+	}
+	if _, err := h.cfg.Source.GetRepo(repo); err != nil {
+		// Pushing creates the repo, like it does over SSH.
+		if service != "git-receive-pack" {
+			http.NotFound(w, r)
+			return
+		}
+		if _, err := h.cfg.Source.InitRepo(repo, true); err != nil {
+			ctxLogger(r.Context()).Error("error creating repo", "repo", repo, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if err := h.cfg.InstallGitHooks(repo); err != nil {
+			ctxLogger(r.Context()).Error("error installing git hooks", "repo", repo, "err", err)
+		}
+	}
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		ctxLogger(r.Context()).Error("git not found", "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	env := []string{
+		"GIT_PROJECT_ROOT=" + h.cfg.Source.Dir(),
+		"GIT_HTTP_EXPORT_ALL=1",
+	}
+	if hasAuth {
+		env = append(env, "REMOTE_USER="+user)
+	}
+	cgih := &cgi.Handler{
+		Path:       gitPath,
+		Args:       []string{"-c", "http.receivepack=true", "http-backend"},
+		Env:        env,
+		InheritEnv: []string{"PATH"},
+	}
+	// http-backend finds the repo from the path, which may have had a .git
+	// suffix the repo directory doesn't have.
+	r2 := r.Clone(r.Context())
+	r2.URL.Path = "/" + repo + "/" + rest
+	cr := &countingReader{ReadCloser: r.Body}
+	r2.Body = cr
+	cw := &countingWriter{ResponseWriter: w}
+	w = cw
+	defer observeGit(r.Context(), h.cfg, repo, service, "http", time.Now(), &cr.n, &cw.n)
+	defer func() {
+		h.cfg.RecordBandwidth(pk, repo, atomic.LoadInt64(&cr.n), atomic.LoadInt64(&cw.n))
+	}()
+	if r.Method == http.MethodPost && service == "git-receive-pack" {
+		// Hold off repo maintenance, such as gc, while the push is
+		// writing objects and updating refs.
+		unlock := h.cfg.Source.LockPush(repo)
+		before := h.cfg.RefHashes(repo)
+		// Check the commands of the push against the branch protection
+		// rules before receive-pack gets them. http-backend reads the
+		// body to its end, which may be shorter, and no longer
+		// compressed.
+		body := io.Reader(cr)
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(cr)
+			if err != nil {
+				unlock()
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			body = zr
+			r2.Header.Del("Content-Encoding")
+		}
+		pc := newPushCommands(h.cfg, repo, pk, body)
+		r2.Body = io.NopCloser(pc)
+		r2.ContentLength = -1
+		r2.Header.Del("Content-Length")
+		sb := &sidebandWriter{w: w}
+		cgih.ServeHTTP(sidebandResponse{w, sb}, r2)
+		if pc.rejected() {
+			ctxLogger(r.Context()).Info("push rejected by branch protection", "repo", repo, "violations", pc.violations)
+			if _, err := pc.reject(w); err != nil {
+				ctxLogger(r.Context()).Error("error rejecting push", "repo", repo, "err", err)
+			}
+			unlock()
+			return
+		}
+		if _, err := sb.finish(quotaWarning(r.Context(), h.cfg, repo)); err != nil {
+			ctxLogger(r.Context()).Error("error writing quota warning", "repo", repo, "err", err)
+		}
+		unlock()
+		h.cfg.PushFrom(repo, pk, before)
+		return
+	}
+	if r.Method == http.MethodGet && service == "git-upload-pack" {
+		h.cfg.Fetch(repo, pk)
+	}
+	cgih.ServeHTTP(w, r2)
+}
+
+func (h *httpHandler) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Basic realm="Soft Serve"`)
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
@@ -0,0 +1,77 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	ghttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestGitHTTP(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`anon-access: read-only
+users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+`, s.Admin.AuthorizedKey(), hash),
+	}))
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	url := fmt.Sprintf("http://%s/repo.git", s.HTTPAddr)
+
+	// Anonymous users can clone public repos.
+	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{URL: url})
+	is.NoErr(err)
+
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	sig := &object.Signature{Name: "test", Email: "test@example.com"}
+	_, err = wt.Commit("empty commit", &git.CommitOptions{
+		Author:            sig,
+		Committer:         sig,
+		AllowEmptyCommits: true,
+	})
+	is.NoErr(err)
+
+	// But not push to them.
+	err = r.Push(&git.PushOptions{})
+	is.Equal(err, transport.ErrAuthenticationRequired)
+
+	// Wrong passwords are rejected.
+	err = r.Push(&git.PushOptions{Auth: &ghttp.BasicAuth{Username: "admin", Password: "wrong"}})
+	is.Equal(err, transport.ErrAuthenticationRequired)
+
+	is.NoErr(r.Push(&git.PushOptions{Auth: &ghttp.BasicAuth{Username: "admin", Password: "secret"}}))
+	head, err := r.Head()
+	is.NoErr(err)
+	cloned, err := s.Clone(s.Admin, "repo")
+	is.NoErr(err)
+	clonedHead, err := cloned.Head()
+	is.NoErr(err)
+	is.Equal(clonedHead.Hash(), head.Hash())
+
+	// Private repos need credentials.
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL: fmt.Sprintf("http://%s/config", s.HTTPAddr),
+	})
+	is.Equal(err, transport.ErrAuthenticationRequired)
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:  fmt.Sprintf("http://%s/config", s.HTTPAddr),
+		Auth: &ghttp.BasicAuth{Username: "admin", Password: "secret"},
+	})
+	is.NoErr(err)
+}
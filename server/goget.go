@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+)
+
+var goImportTmpl = template.Must(template.New("go-import").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta name="go-import" content="{{ .ImportRoot }} git {{ .RepoURL }}">
+</head>
+<body>
+go get {{ .ImportRoot }}
+</body>
+</html>
+`))
+
+// serveGoImport serves the go-import meta tag that lets the go tool resolve
+// host/repo module paths to the repo.
+func (h *httpHandler) serveGoImport(w http.ResponseWriter, r *http.Request, repo string) {
+	if h.cfg.RepoNoGoImport(repo) {
+		http.NotFound(w, r)
+		return
+	}
+	host := h.cfg.Host
+	if host == "" {
+		host = "localhost"
+	}
+	repoURL := fmt.Sprintf("ssh://%s/%s", host, repo)
+	if h.cfg.Port != 22 {
+		repoURL = fmt.Sprintf("ssh://%s:%d/%s", host, h.cfg.Port, repo)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_ = goImportTmpl.Execute(w, struct {
+		ImportRoot string
+		RepoURL    string
+	}{
+		ImportRoot: r.Host + "/" + repo,
+		RepoURL:    repoURL,
+	})
+}
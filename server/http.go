@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// httpHandler serves repositories over HTTP. Only repos readable without a
+// key are served, except over the git protocol which supports basic auth, and
+// to users signed in with OIDC.
+type httpHandler struct {
+	cfg   *appCfg.Config
+	pages *pages
+	oidc  oidcProviders
+}
+
+func newHTTPServer(cfg *config.Config, ac *appCfg.Config) *http.Server {
+	s := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.BindAddr, cfg.HTTPPort),
+		Handler:           newHTTPHandler(ac),
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          cfg.ErrorLog,
+	}
+	if len(cfg.ACMEDomains) > 0 {
+		s.TLSConfig = newACMEManager(cfg).TLSConfig()
+	}
+	return s
+}
+
+// newACMEManager returns the manager getting and renewing the certificates
+// of the configured domains. Certificates are validated with the TLS-ALPN-01
+// challenge, so the HTTP server must be reachable on port 443 of the
+// domains.
+func newACMEManager(cfg *config.Config) *autocert.Manager {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+		Cache:      autocert.DirCache(filepath.Join(cfg.DataPath, "acme")),
+		Email:      cfg.ACMEEmail,
+	}
+	if cfg.ACMEDirectory != "" {
+		m.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectory}
+	}
+	return m
+}
+
+func newHTTPHandler(ac *appCfg.Config) *httpHandler {
+	return &httpHandler{
+		cfg:   ac,
+		pages: newPages(ac),
+	}
+}
+
+// Run keeps the handler caches up to date until ctx is done.
+func (h *httpHandler) Run(ctx context.Context) {
+	h.pages.Run(ctx)
+}
+
+// ServeHTTP implements http.Handler.
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r = withRequestLogger(w, r)
+	p := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	repo, rest := p, ""
+	if i := strings.Index(p, "/"); i >= 0 {
+		repo, rest = p[:i], p[i+1:]
+	}
+	if p == apiPrefix || strings.HasPrefix(p, apiPrefix+"/") {
+		h.serveAPI(w, r, p)
+		return
+	}
+	if repo == "-" {
+		h.serveOIDC(w, r, rest)
+		return
+	}
+	repo = strings.TrimSuffix(repo, ".git")
+	// Git requests do their own access checks, as they may be authenticated
+	// and may create repos.
+	if service := gitService(r, rest); repo != "" && service != "" {
+		h.serveGit(w, r, repo, rest, service)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if repo == "" {
+		h.serveRepoList(w, r)
+		return
+	}
+	if !h.readable(r, repo) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Query().Get("go-get") == "1" {
+		h.serveGoImport(w, r, repo)
+		return
+	}
+	// Redirect to the trailing slash so relative links in pages resolve.
+	if rest == "" && !strings.HasSuffix(r.URL.Path, "/") {
+		http.Redirect(w, r, "/"+repo+"/", http.StatusMovedPermanently)
+		return
+	}
+	// The web UI is under /<repo>/-/, out of the way of pages sites. The
+	// repo root shows the site if there's one, and the web UI otherwise.
+	if rest == "-" || strings.HasPrefix(rest, "-/") {
+		h.serveWeb(w, r, repo, strings.TrimPrefix(strings.TrimPrefix(rest, "-"), "/"))
+		return
+	}
+	if rest == "" && !h.cfg.RepoPages(repo).Enabled {
+		h.serveWeb(w, r, repo, "")
+		return
+	}
+	if strings.HasPrefix(rest, "raw/") {
+		h.serveRaw(w, r, repo, strings.TrimPrefix(rest, "raw/"))
+		return
+	}
+	if strings.HasPrefix(rest, "artifacts/") {
+		h.serveArtifact(w, r, repo, strings.TrimPrefix(rest, "artifacts/"))
+		return
+	}
+	if strings.HasPrefix(rest, "archive/") {
+		h.serveArchive(w, r, repo, strings.TrimPrefix(rest, "archive/"))
+		return
+	}
+	h.pages.ServeHTTP(w, r, repo, rest)
+}
+
+// readable returns whether the repo exists and can be read anonymously, or
+// by the user signed in to the web UI.
+func (h *httpHandler) readable(r *http.Request, repo string) bool {
+	if _, err := h.cfg.Source.GetRepo(repo); err != nil {
+		return false
+	}
+	_, pk := h.webUser(r)
+	return h.cfg.AuthRepo(repo, pk) >= gm.ReadOnlyAccess
+}
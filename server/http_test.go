@@ -0,0 +1,341 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/matryer/is"
+)
+
+// newTestRepo creates a repo named name in the repo source with a single
+// commit containing files, and points each of the given branches at it.
+func newTestRepo(t *testing.T, rs *appCfg.RepoSource, name string, files map[string]string, branches ...string) {
+	t.Helper()
+	is := is.New(t)
+	dir := filepath.Join(rs.Path, name)
+	r, err := git.PlainInit(dir, false)
+	is.NoErr(err)
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	for fp, content := range files {
+		is.NoErr(os.MkdirAll(filepath.Join(dir, filepath.Dir(fp)), 0o755))
+		is.NoErr(os.WriteFile(filepath.Join(dir, fp), []byte(content), 0o644))
+		_, err = wt.Add(fp)
+		is.NoErr(err)
+	}
+	sig := &object.Signature{Name: "test", When: time.Now()}
+	h, err := wt.Commit("test commit", &git.CommitOptions{Author: sig, Committer: sig})
+	is.NoErr(err)
+	for _, b := range branches {
+		is.NoErr(r.Storer.SetReference(plumbing.NewHashReference(plumbing.NewBranchReferenceName(b), h)))
+	}
+	is.NoErr(rs.LoadRepo(name))
+}
+
+func newTestHandler(t *testing.T, repos ...appCfg.RepoConfig) (*httpHandler, *appCfg.RepoSource) {
+	rs := appCfg.NewRepoSource(t.TempDir())
+	ac := &appCfg.Config{
+		AnonAccess: "read-only",
+		// Private repos are only private once there are users.
+		Users:  []appCfg.User{{Name: "admin", Admin: true}},
+		Repos:  repos,
+		Source: rs,
+		Events: events.NewBus(),
+	}
+	return newHTTPHandler(ac), rs
+}
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestPages(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t,
+		appCfg.RepoConfig{Repo: "site", Pages: appCfg.Pages{Enabled: true}},
+		appCfg.RepoConfig{Repo: "big", Pages: appCfg.Pages{Enabled: true, MaxSize: "4B"}},
+		appCfg.RepoConfig{Repo: "secret", Private: true, Pages: appCfg.Pages{Enabled: true}},
+	)
+	files := map[string]string{
+		"index.html":      "<h1>home</h1>",
+		"docs/index.html": "<h1>docs</h1>",
+		"style.css":       "body {}",
+	}
+	newTestRepo(t, rs, "site", files, "pages")
+	newTestRepo(t, rs, "big", files, "pages")
+	newTestRepo(t, rs, "secret", files, "pages")
+	newTestRepo(t, rs, "plain", files, "pages")
+
+	w := get(h, "/site/")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), "<h1>home</h1>")
+
+	w = get(h, "/site/style.css")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Header().Get("Content-Type"), "text/css; charset=utf-8")
+
+	is.Equal(get(h, "/site").Code, http.StatusMovedPermanently)
+	is.Equal(get(h, "/site/docs").Code, http.StatusMovedPermanently)
+	is.Equal(get(h, "/site/docs/").Body.String(), "<h1>docs</h1>")
+	is.Equal(get(h, "/site/missing.html").Code, http.StatusNotFound)
+	is.Equal(get(h, "/big/").Code, http.StatusInternalServerError)
+	is.Equal(get(h, "/secret/").Code, http.StatusNotFound)
+	// Repos without a site show the web UI.
+	is.True(strings.Contains(get(h, "/plain/").Body.String(), "git clone"))
+	is.Equal(get(h, "/nope/").Code, http.StatusNotFound)
+}
+
+func TestWeb(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t, appCfg.RepoConfig{Repo: "secret", Private: true})
+	files := map[string]string{
+		"README.md":    "# Hello <script>",
+		"dir/data.txt": "first\n<second>",
+	}
+	newTestRepo(t, rs, "repo", files, "release/v1")
+	newTestRepo(t, rs, "secret", files)
+	// Readmes are loaded with the config.
+	rr, err := rs.GetRepo("repo")
+	is.NoErr(err)
+	rr.SetReadme(files["README.md"], "README.md")
+
+	w := get(h, "/")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.Contains(w.Body.String(), `href="/repo/-/"`))
+	is.True(!strings.Contains(w.Body.String(), "secret"))
+
+	w = get(h, "/repo/-/")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Header().Get("Content-Type"), "text/html; charset=utf-8")
+	is.True(strings.Contains(w.Body.String(), "<h1>Hello"))
+	is.True(!strings.Contains(w.Body.String(), "<script>"))
+	is.Equal(get(h, "/repo/").Body.String(), w.Body.String())
+
+	w = get(h, "/repo/-/tree/release/v1/dir")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.Contains(w.Body.String(), `href="/repo/-/blob/release/v1/dir/data.txt"`))
+	w = get(h, "/repo/-/blob/master/dir/data.txt")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.Contains(w.Body.String(), "&lt;second&gt;"))
+	w = get(h, "/repo/-/commits/master")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.Contains(w.Body.String(), "test commit"))
+
+	is.Equal(get(h, "/repo/-/blob/master/missing").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/-/tree/nope/").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/-/other").Code, http.StatusNotFound)
+	is.Equal(get(h, "/secret/-/").Code, http.StatusNotFound)
+}
+
+func TestRaw(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t, appCfg.RepoConfig{Repo: "secret", Private: true})
+	files := map[string]string{
+		"README.md":    "# hello",
+		"dir/data.txt": "0123456789",
+		"app.json":     "{}",
+		"page.html":    "<script>alert(1)</script>",
+		"logo.svg":     "<svg xmlns=\"http://www.w3.org/2000/svg\"/>",
+		"LICENSE":      strings.Repeat("0123456789", 100),
+	}
+	newTestRepo(t, rs, "repo", files, "release/v1")
+	newTestRepo(t, rs, "secret", files)
+
+	w := get(h, "/repo/raw/master/README.md")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Body.String(), "# hello")
+	etag := w.Header().Get("ETag")
+	is.True(etag != "")
+	is.Equal(w.Header().Get("Content-Security-Policy"), "sandbox")
+	is.Equal(w.Header().Get("Content-Disposition"), "")
+
+	// Active content is sandboxed and downloaded.
+	for name, ct := range map[string]string{"page.html": "text/html", "logo.svg": "image/svg+xml"} {
+		w = get(h, "/repo/raw/master/"+name)
+		is.Equal(w.Code, http.StatusOK)
+		is.True(strings.HasPrefix(w.Header().Get("Content-Type"), ct))
+		is.Equal(w.Header().Get("Content-Security-Policy"), "sandbox")
+		is.Equal(w.Header().Get("Content-Disposition"), "attachment; filename="+name)
+	}
+
+	is.Equal(get(h, "/repo/raw/master/app.json").Header().Get("Content-Type"), "application/json")
+	is.Equal(get(h, "/repo/raw/release/v1/dir/data.txt").Body.String(), "0123456789")
+	is.Equal(get(h, "/repo/raw/master/missing").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/raw/master/dir").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/raw/nope/README.md").Code, http.StatusNotFound)
+	is.Equal(get(h, "/secret/raw/master/README.md").Code, http.StatusNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/repo/raw/master/dir/data.txt", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	is.Equal(w.Code, http.StatusPartialContent)
+	is.Equal(w.Body.String(), "234")
+
+	// Blobs are sniffed, and read again from the start or from a range.
+	w = get(h, "/repo/raw/master/LICENSE")
+	is.True(strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"))
+	is.Equal(w.Header().Get("Content-Length"), "1000")
+	is.Equal(w.Body.String(), files["LICENSE"])
+	req = httptest.NewRequest(http.MethodGet, "/repo/raw/master/LICENSE", nil)
+	req.Header.Set("Range", "bytes=995-")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	is.Equal(w.Code, http.StatusPartialContent)
+	is.Equal(w.Body.String(), "56789")
+
+	req = httptest.NewRequest(http.MethodGet, "/repo/raw/master/README.md", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	is.Equal(w.Code, http.StatusNotModified)
+}
+
+func TestGoImport(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t,
+		appCfg.RepoConfig{Repo: "secret", Private: true},
+		appCfg.RepoConfig{Repo: "optout", NoGoImport: true},
+	)
+	h.cfg.Host = "git.example.com"
+	h.cfg.Port = 23231
+	files := map[string]string{"go.mod": "module example.com/repo"}
+	newTestRepo(t, rs, "repo", files)
+	newTestRepo(t, rs, "secret", files)
+	newTestRepo(t, rs, "optout", files)
+
+	w := get(h, "/repo/sub/pkg?go-get=1")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.Contains(w.Body.String(),
+		`<meta name="go-import" content="example.com/repo git ssh://git.example.com:23231/repo">`))
+	is.Equal(get(h, "/secret?go-get=1").Code, http.StatusNotFound)
+	is.Equal(get(h, "/optout?go-get=1").Code, http.StatusNotFound)
+}
+
+func TestArchive(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t)
+	newTestRepo(t, rs, "repo", map[string]string{"README.md": "# hello"})
+	r, err := git.PlainOpen(filepath.Join(rs.Path, "repo"))
+	is.NoErr(err)
+	head, err := r.Head()
+	is.NoErr(err)
+	_, err = r.CreateTag("v1.0", head.Hash(), nil)
+	is.NoErr(err)
+	is.NoErr(rs.LoadRepo("repo"))
+
+	w := get(h, "/repo/archive/v1.0.tar.gz")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Header().Get("Content-Disposition"), `attachment; filename="repo-v1.0.tar.gz"`)
+	is.True(strings.HasPrefix(w.Body.String(), "\x1f\x8b"))
+	w = get(h, "/repo/archive/v1.0.zip")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.HasPrefix(w.Body.String(), "PK"))
+	w = get(h, "/repo/archive/v1.0.bundle")
+	is.Equal(w.Code, http.StatusOK)
+	is.True(strings.HasPrefix(w.Body.String(), "# v2 git bundle"))
+	get(h, "/repo/archive/v1.0.zip")
+
+	is.Equal(get(h, "/repo/archive/v2.0.zip").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/archive/master.zip").Code, http.StatusNotFound)
+	is.Equal(get(h, "/repo/archive/v1.0.rar").Code, http.StatusNotFound)
+	is.Equal(h.cfg.Downloads("repo"), map[string]int64{
+		"v1.0.tar.gz": 1,
+		"v1.0.zip":    2,
+		"v1.0.bundle": 1,
+	})
+}
+
+func TestArchiveProfile(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t, appCfg.RepoConfig{
+		Repo:     "mono",
+		Profiles: map[string]string{"frontend": "web/"},
+	})
+	newTestRepo(t, rs, "mono", map[string]string{
+		"web/index.js":   "hello",
+		"server/main.go": "package main",
+	})
+	r, err := git.PlainOpen(filepath.Join(rs.Path, "mono"))
+	is.NoErr(err)
+	head, err := r.Head()
+	is.NoErr(err)
+	_, err = r.CreateTag("v1.0", head.Hash(), nil)
+	is.NoErr(err)
+	is.NoErr(rs.LoadRepo("mono"))
+
+	w := get(h, "/mono/archive/v1.0.zip?profile=frontend")
+	is.Equal(w.Code, http.StatusOK)
+	is.Equal(w.Header().Get("Content-Disposition"), `attachment; filename="mono-v1.0-frontend.zip"`)
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	is.NoErr(err)
+	names := make([]string, 0)
+	for _, f := range zr.File {
+		if !f.FileInfo().IsDir() {
+			names = append(names, f.Name)
+		}
+	}
+	is.Equal(names, []string{"mono-v1.0-frontend/web/index.js"})
+
+	is.Equal(get(h, "/mono/archive/v1.0.zip?profile=backend").Code, http.StatusNotFound)
+	is.Equal(get(h, "/mono/archive/v1.0.bundle?profile=frontend").Code, http.StatusBadRequest)
+	is.Equal(h.cfg.Downloads("mono"), map[string]int64{"v1.0-frontend.zip": 1})
+}
+
+func TestACME(t *testing.T) {
+	is := is.New(t)
+	_, rs := newTestHandler(t)
+	ac := &appCfg.Config{Source: rs, Events: events.NewBus()}
+
+	s := newHTTPServer(&config.Config{HTTPPort: 443}, ac)
+	is.True(s.TLSConfig == nil) // plain HTTP without domains
+
+	s = newHTTPServer(&config.Config{
+		HTTPPort:    443,
+		DataPath:    t.TempDir(),
+		ACMEDomains: []string{"git.example.com"},
+	}, ac)
+	is.True(s.TLSConfig != nil)
+	is.True(s.TLSConfig.GetCertificate != nil)
+	// The TLS-ALPN-01 challenge is answered on the HTTPS port.
+	is.True(strings.Contains(strings.Join(s.TLSConfig.NextProtos, " "), "acme-tls/1"))
+
+	// Certificates are only requested for the configured domains.
+	_, err := s.TLSConfig.GetCertificate(&tls.ClientHelloInfo{ServerName: "other.example.com"})
+	is.True(err != nil)
+}
+
+func TestArchiveReleasesOff(t *testing.T) {
+	is := is.New(t)
+	h, rs := newTestHandler(t, appCfg.RepoConfig{
+		Repo:     "repo",
+		Features: map[string]bool{"releases": false},
+	})
+	newTestRepo(t, rs, "repo", map[string]string{"README.md": "# hello"})
+	r, err := git.PlainOpen(filepath.Join(rs.Path, "repo"))
+	is.NoErr(err)
+	head, err := r.Head()
+	is.NoErr(err)
+	_, err = r.CreateTag("v1.0", head.Hash(), nil)
+	is.NoErr(err)
+	is.NoErr(rs.LoadRepo("repo"))
+
+	is.Equal(get(h, "/repo/archive/v1.0.tar.gz").Code, http.StatusNotFound)
+	is.Equal(len(h.cfg.Downloads("repo")), 0)
+}
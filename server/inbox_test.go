@@ -0,0 +1,80 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestInbox(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	frankie := servertest.NewKey(t)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+  - name: Frankie
+    public-keys:
+      - %s
+repos:
+  - repo: repo
+    private: true
+    requestable: true
+`, strings.TrimSpace(s.Admin.AuthorizedKey()), strings.TrimSpace(frankie.AuthorizedKey())),
+	}))
+	_, err := s.Run(frankie, "access request repo")
+	is.NoErr(err)
+
+	// The inbox records events in the background.
+	out := ""
+	for deadline := time.Now().Add(5 * time.Second); out == "" && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		out, err = s.Run(s.Admin, "inbox --unread")
+		is.NoErr(err)
+	}
+	is.True(strings.HasPrefix(out, "* "))
+	is.True(strings.Contains(out, "Frankie requested access to repo"))
+	out, err = s.Run(frankie, "inbox")
+	is.NoErr(err)
+	is.Equal(out, "")
+
+	out, err = s.Run(s.Admin, "inbox --json")
+	is.NoErr(err)
+	var ns []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+		Read bool   `json:"read"`
+	}
+	is.NoErr(json.Unmarshal([]byte(out), &ns))
+	is.Equal(len(ns), 1)
+	is.Equal(ns[0].Type, "access-requested")
+	is.True(!ns[0].Read)
+	_, err = s.Run(s.Admin, "inbox read "+ns[0].ID)
+	is.NoErr(err)
+	out, err = s.Run(s.Admin, "inbox")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(out, "  "+ns[0].ID))
+	out, err = s.Run(s.Admin, "inbox --unread")
+	is.NoErr(err)
+	is.Equal(out, "")
+
+	var ee *cssh.ExitError
+	_, err = s.Run(s.Admin, "inbox read")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusInvalidArgument)
+	_, err = s.Run(s.Admin, "inbox read 0123456789ab")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusNotFound)
+}
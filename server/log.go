@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/wish"
+	"github.com/gliderlabs/ssh"
+)
+
+// sessionIDLen is the length of the session IDs logged, a prefix of the
+// SSH session hash.
+const sessionIDLen = 16
+
+// requestIDHeader is the header of HTTP responses holding the ID of the
+// request, to find its log entries.
+const requestIDHeader = "X-Request-Id"
+
+// loggerCtxKey is the key of the logger of a session in its context.
+type loggerCtxKey struct{}
+
+// ctxLogger returns the logger of the session or HTTP request of ctx, which
+// tags entries with its ID, or the default logger.
+func ctxLogger(ctx context.Context) *log.Logger {
+	if l, ok := ctx.Value(loggerCtxKey{}).(*log.Logger); ok {
+		return l
+	}
+	return log.FromContext(ctx)
+}
+
+// sessionID returns the ID of the session of ctx.
+func sessionID(ctx ssh.Context) string {
+	id := ctx.SessionID()
+	if len(id) > sessionIDLen {
+		id = id[:sessionIDLen]
+	}
+	return id
+}
+
+// logMiddleware gives each session a logger tagging entries with the session
+// ID, so that the operations of a session can be traced, and logs when
+// sessions start and end.
+func logMiddleware() wish.Middleware {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			l := log.With("session", sessionID(s.Context()))
+			s.Context().SetValue(loggerCtxKey{}, l)
+			_, _, pty := s.Pty()
+			l.Debug("session started",
+				"user", s.User(),
+				"remote", s.RemoteAddr().String(),
+				"command", strings.Join(s.Command(), " "),
+				"pty", pty,
+			)
+			start := time.Now()
+			sh(s)
+			l.Debug("session ended", "duration", time.Since(start))
+		}
+	}
+}
+
+// newRequestID returns a random ID for a request.
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// withRequestLogger gives a request a logger tagging entries with a new
+// request ID, returned to the client in the X-Request-Id header.
+func withRequestLogger(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := newRequestID()
+	w.Header().Set(requestIDHeader, id)
+	l := log.With("request", id)
+	l.Debug("http request", "method", r.Method, "path", r.URL.Path, "remote", r.RemoteAddr)
+	return r.WithContext(log.WithContext(r.Context(), l))
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/log"
+	"github.com/charmbracelet/wish/testsession"
+	"github.com/gliderlabs/ssh"
+	"github.com/matryer/is"
+)
+
+// captureLog makes the default logger write JSON at the debug level to the
+// returned buffer until the test finishes.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	level := log.GetLevel()
+	log.SetOutput(&buf)
+	log.SetFormatter(log.JSONFormatter)
+	log.SetLevel(log.DebugLevel)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormatter(log.TextFormatter)
+		log.SetLevel(level)
+	})
+	return &buf
+}
+
+// logEntries parses the JSON log entries of buf.
+func logEntries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var e map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("invalid log entry %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestLogMiddleware(t *testing.T) {
+	is := is.New(t)
+	buf := captureLog(t)
+	sess := testsession.New(t, &ssh.Server{
+		Handler: logMiddleware()(func(s ssh.Session) {
+			ctxLogger(s.Context()).Info("hello", "user", s.User())
+		}),
+	}, nil)
+	is.NoErr(sess.Run("ls repo"))
+
+	entries := logEntries(t, buf)
+	is.Equal(len(entries), 3)
+	is.Equal(entries[0]["msg"], "session started")
+	is.Equal(entries[0]["command"], "ls repo")
+	is.Equal(entries[1]["msg"], "hello")
+	is.Equal(entries[2]["msg"], "session ended")
+	// All entries of the session are tagged with its ID.
+	id, ok := entries[0]["session"].(string)
+	is.True(ok)
+	is.Equal(len(id), sessionIDLen)
+	for _, e := range entries {
+		is.Equal(e["session"], id)
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	is := is.New(t)
+	buf := captureLog(t)
+	w := httptest.NewRecorder()
+	r := withRequestLogger(w, httptest.NewRequest(http.MethodGet, "/repo", nil))
+	ctxLogger(r.Context()).Error("oops")
+
+	id := w.Header().Get(requestIDHeader)
+	is.True(id != "")
+	entries := logEntries(t, buf)
+	is.Equal(len(entries), 2)
+	is.Equal(entries[0]["msg"], "http request")
+	is.Equal(entries[0]["path"], "/repo")
+	is.Equal(entries[1]["msg"], "oops")
+	for _, e := range entries {
+		is.Equal(e["request"], id)
+	}
+	// Requests get different IDs.
+	w2 := httptest.NewRecorder()
+	withRequestLogger(w2, httptest.NewRequest(http.MethodGet, "/repo", nil))
+	is.True(w2.Header().Get(requestIDHeader) != id)
+}
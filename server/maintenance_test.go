@@ -0,0 +1,119 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMaintenance(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+`, s.Admin.AuthorizedKey(), hash),
+	}))
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	s.CreateRepo("other", map[string]string{"README.md": "# Other\n"})
+
+	out, err := s.Run(s.Admin, `maintenance start repo --for 1h -m "Moving to new storage"`)
+	is.NoErr(err)
+	is.True(strings.HasPrefix(out, "Read-only: repo is read-only for maintenance until "))
+	out, err = s.Run(s.Admin, "maintenance start --at 2h --for 30m")
+	is.NoErr(err)
+	is.True(strings.HasPrefix(out, "Maintenance scheduled at "))
+	out, err = s.Run(s.Admin, "maintenance list --json")
+	is.NoErr(err)
+	var ms []config.Maintenance
+	is.NoErr(json.Unmarshal([]byte(out), &ms))
+	is.Equal(len(ms), 2)
+	is.Equal(ms[0].Repo, "repo")
+	is.Equal(ms[0].Message, "Moving to new storage")
+
+	// Pushes to the repo are rejected over SSH and HTTP, with the message.
+	err = s.Push(s.Admin, "repo", map[string]string{"a.txt": "a"})
+	is.True(err != nil)
+	is.True(strings.Contains(err.Error(), "Moving to new storage"))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/repo.git/info/refs?service=git-receive-pack", s.HTTPAddr), nil)
+	is.NoErr(err)
+	req.SetBasicAuth("admin", "secret")
+	res, err := http.DefaultClient.Do(req)
+	is.NoErr(err)
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	is.NoErr(err)
+	is.Equal(res.StatusCode, http.StatusServiceUnavailable)
+	is.True(res.Header.Get("Retry-After") != "")
+	is.True(strings.Contains(string(body), "Moving to new storage"))
+	// Other repos aren't in maintenance yet.
+	is.NoErr(s.Push(s.Admin, "other", map[string]string{"a.txt": "a"}))
+
+	_, err = s.Run(s.Admin, "maintenance stop repo")
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "repo", map[string]string{"a.txt": "a"}))
+	_, err = s.Run(s.Admin, "maintenance stop repo")
+	is.True(err != nil)
+
+	// Server-wide maintenance spares the config repo.
+	_, err = s.Run(s.Admin, "maintenance start")
+	is.NoErr(err)
+	is.True(s.Push(s.Admin, "other", map[string]string{"b.txt": "b"}) != nil)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{"b.txt": "b"}))
+	_, err = s.Run(s.Admin, "maintenance stop")
+	is.NoErr(err)
+	is.NoErr(s.Push(s.Admin, "other", map[string]string{"b.txt": "b"}))
+
+	_, err = s.Run(servertest.NewKey(t), "maintenance start")
+	is.True(err != nil)
+	_, err = s.Run(s.Admin, "maintenance start --at 2h --until 1h")
+	is.True(err != nil)
+}
+
+func TestHousekeeping(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	s.CreateRepo("other", map[string]string{"README.md": "# Other\n"})
+	_, err := s.Run(s.Admin, "repo disable other housekeeping")
+	is.NoErr(err)
+
+	// Repos that opted out are only maintained when named.
+	out, err := s.Run(s.Admin, "housekeeping run")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "repo\tgc\n"))
+	is.True(!strings.Contains(out, "other"))
+	out, err = s.Run(s.Admin, "housekeeping run other --repack")
+	is.NoErr(err)
+	is.Equal(out, "other\trepack\n")
+
+	out, err = s.Run(s.Admin, "housekeeping status repo other --json")
+	is.NoErr(err)
+	var sts []config.HousekeepingStatus
+	is.NoErr(json.Unmarshal([]byte(out), &sts))
+	is.Equal(len(sts), 2)
+	is.True(sts[0].Enabled)
+	is.True(!sts[0].LastGC.IsZero())
+	is.True(!sts[1].Enabled)
+	is.True(sts[1].LastGC.IsZero())
+	is.True(!sts[1].LastRun.IsZero())
+
+	_, err = s.Run(s.Admin, "housekeeping run missing")
+	is.True(err != nil)
+	_, err = s.Run(servertest.NewKey(t), "housekeeping status")
+	is.True(err != nil)
+}
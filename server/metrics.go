@@ -0,0 +1,148 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/metrics"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+)
+
+var (
+	sshSessions = metrics.NewGauge("soft_serve_ssh_sessions",
+		"Open SSH sessions.")
+	sshSessionsTotal = metrics.NewCounter("soft_serve_ssh_sessions_total",
+		"SSH sessions opened.")
+	tuiSessions = metrics.NewGauge("soft_serve_tui_sessions",
+		"Open TUI sessions.")
+	tuiSessionsTotal = metrics.NewCounter("soft_serve_tui_sessions_total",
+		"TUI sessions opened.")
+	gitTransferBytes = metrics.NewCounter("soft_serve_git_transfer_bytes_total",
+		"Bytes transferred by git commands, by repo and direction, in to or out of the server.", "repo", "direction")
+	gitDuration = metrics.NewHistogram("soft_serve_git_duration_seconds",
+		"Duration of git commands, by service and protocol.", metrics.DefaultBuckets, "service", "protocol")
+)
+
+// newMetricsServer returns the server of the /metrics endpoint.
+func newMetricsServer(cfg *config.Config) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	return &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", cfg.BindAddr, cfg.MetricsPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ErrorLog:          cfg.ErrorLog,
+	}
+}
+
+// metricsMiddleware counts SSH and TUI sessions, and the bytes and duration
+// of git commands.
+func metricsMiddleware(ac *appCfg.Config) func(ssh.Handler) ssh.Handler {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			sshSessions.Inc()
+			sshSessionsTotal.Inc()
+			defer sshSessions.Dec()
+			cmds := s.Command()
+			if _, _, pty := s.Pty(); pty && len(cmds) == 0 {
+				tuiSessions.Inc()
+				tuiSessionsTotal.Inc()
+				defer tuiSessions.Dec()
+			}
+			if len(cmds) != 2 || !strings.HasPrefix(cmds[0], "git-") {
+				sh(s)
+				return
+			}
+			repo := strings.TrimSuffix(strings.TrimPrefix(cmds[1], "/"), "/")
+			repo = strings.TrimSuffix(filepath.Clean(repo), ".git")
+			cs := &countingSession{Session: s}
+			start := time.Now()
+			sh(cs)
+			observeGit(s.Context(), ac, repo, cmds[0], "ssh", start, &cs.in, &cs.out)
+		}
+	}
+}
+
+// observeGit records and logs the bytes transferred by a git command, and
+// how long it took. Bytes are only recorded for repos that exist, so that
+// requests for random names don't make up new series.
+func observeGit(ctx context.Context, ac *appCfg.Config, repo, service, protocol string, start time.Time, in, out *int64) {
+	d := time.Since(start)
+	ctxLogger(ctx).Debug("git command",
+		"service", service,
+		"protocol", protocol,
+		"repo", repo,
+		"duration", d,
+		"in", atomic.LoadInt64(in),
+		"out", atomic.LoadInt64(out),
+	)
+	gitDuration.Observe(d.Seconds(), service, protocol)
+	if _, err := ac.Source.GetRepo(repo); err != nil {
+		return
+	}
+	gitTransferBytes.Add(float64(atomic.LoadInt64(in)), repo, "in")
+	gitTransferBytes.Add(float64(atomic.LoadInt64(out)), repo, "out")
+}
+
+// countingSession is a session counting the bytes read from and written to
+// it.
+type countingSession struct {
+	ssh.Session
+	in, out int64
+}
+
+// Read implements io.Reader.
+func (s *countingSession) Read(p []byte) (int, error) {
+	n, err := s.Session.Read(p)
+	atomic.AddInt64(&s.in, int64(n))
+	return n, err
+}
+
+// Write implements io.Writer.
+func (s *countingSession) Write(p []byte) (int, error) {
+	n, err := s.Session.Write(p)
+	atomic.AddInt64(&s.out, int64(n))
+	return n, err
+}
+
+// countingReader is a request body counting the bytes read from it.
+type countingReader struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read implements io.Reader.
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.n, int64(n))
+	return n, err
+}
+
+// countingWriter is a response writer counting the bytes of the body written
+// to it.
+type countingWriter struct {
+	http.ResponseWriter
+	n int64
+}
+
+// Write implements io.Writer.
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	atomic.AddInt64(&w.n, int64(n))
+	return n, err
+}
+
+// Flush implements http.Flusher.
+func (w *countingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
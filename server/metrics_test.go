@@ -0,0 +1,55 @@
+package server_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestMetrics(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("metered", nil)
+	is.NoErr(s.Push(s.Admin, "metered", map[string]string{"README.md": "# Metered\n"}))
+	_, err := s.Clone(s.Admin, "metered")
+	is.NoErr(err)
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/metered.git/info/refs?service=git-upload-pack", s.HTTPAddr), nil)
+	is.NoErr(err)
+	req.SetBasicAuth("nobody", "wrong")
+	res, err := http.DefaultClient.Do(req)
+	is.NoErr(err)
+	res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusUnauthorized)
+
+	res, err = http.Get(fmt.Sprintf("http://%s/metrics", s.MetricsAddr))
+	is.NoErr(err)
+	defer res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusOK)
+	bts, err := io.ReadAll(res.Body)
+	is.NoErr(err)
+	out := string(bts)
+	value := func(series string) float64 {
+		t.Helper()
+		m := regexp.MustCompile("(?m)^" + regexp.QuoteMeta(series) + ` (\S+)$`).FindStringSubmatch(out)
+		if m == nil {
+			t.Fatalf("metrics are missing %s:\n%s", series, out)
+		}
+		v, err := strconv.ParseFloat(m[1], 64)
+		is.NoErr(err)
+		return v
+	}
+	is.True(value(`soft_serve_git_pushes_total{repo="metered"}`) >= 1)
+	is.True(value(`soft_serve_git_fetches_total{repo="metered"}`) >= 1)
+	is.True(value(`soft_serve_git_transfer_bytes_total{repo="metered",direction="in"}`) > 0)
+	is.True(value(`soft_serve_git_transfer_bytes_total{repo="metered",direction="out"}`) > 0)
+	is.True(value(`soft_serve_git_duration_seconds_count{service="git-receive-pack",protocol="ssh"}`) >= 1)
+	is.True(value(`soft_serve_git_duration_seconds_count{service="git-upload-pack",protocol="ssh"}`) >= 1)
+	is.True(value(`soft_serve_ssh_sessions_total`) >= 2)
+	is.True(value(`soft_serve_auth_failures_total{method="http"}`) >= 1)
+}
@@ -3,13 +3,93 @@ package server
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	appCfg "github.com/charmbracelet/soft-serve/config"
 	"github.com/charmbracelet/soft-serve/server/cmd"
 	"github.com/charmbracelet/wish"
+	gm "github.com/charmbracelet/wish/git"
 	"github.com/gliderlabs/ssh"
 )
 
+// connHooks checks repo access for the git middleware with the connection's
+// cached access levels.
+type connHooks struct {
+	*appCfg.Config
+	ctx ssh.Context
+}
+
+// AuthRepo implements git.Hooks.
+func (h connHooks) AuthRepo(repo string, pk ssh.PublicKey) gm.AccessLevel {
+	return h.AuthRepoCtx(h.ctx, repo, pk)
+}
+
+// refHashesCtxKey is the session context key of the hashes of the references
+// of the repo pushed to, before the push.
+type refHashesCtxKey struct{}
+
+// pushCommandsCtxKey is the session context key of the commands of the push,
+// checked against the branch protection rules.
+type pushCommandsCtxKey struct{}
+
+// Push implements git.Hooks. The push is registered with the references of
+// the repo as they were before the git middleware updated them, unless it
+// was rejected.
+func (h connHooks) Push(repo string, pk ssh.PublicKey) {
+	if pc, ok := h.ctx.Value(pushCommandsCtxKey{}).(*pushCommands); ok && pc.rejected() {
+		return
+	}
+	before, ok := h.ctx.Value(refHashesCtxKey{}).(map[string]string)
+	if !ok {
+		h.Config.Push(repo, pk)
+		return
+	}
+	h.PushFrom(repo, pk, before)
+}
+
+// pushSession is a session of a push. Its context isn't canceled when the
+// client hangs up, which clients do once the status of the push is reported,
+// so that receive-pack runs the post-receive hooks to completion and the
+// push is registered.
+type pushSession struct {
+	ssh.Session
+}
+
+// Context implements ssh.Session.
+func (s pushSession) Context() ssh.Context {
+	return pushContext{s.Session.Context()}
+}
+
+// pushContext is a session context that is never done.
+type pushContext struct {
+	ssh.Context
+}
+
+// Done implements context.Context.
+func (pushContext) Done() <-chan struct{} { return nil }
+
+// Err implements context.Context.
+func (pushContext) Err() error { return nil }
+
+// createPushedRepo creates the repo a session pushes to when it doesn't exist
+// and the user can push to it, so that its git hooks are installed before the
+// first push. The git middleware would create it without them otherwise.
+func createPushedRepo(ac *appCfg.Config, s ssh.Session, repo string) {
+	if _, err := ac.Source.GetRepo(repo); err == nil || strings.Contains(repo, "..") || strings.Count(repo, "/") > 1 {
+		return
+	}
+	if ac.AuthRepoCtx(s.Context(), repo, s.PublicKey()) < gm.ReadWriteAccess {
+		return
+	}
+	if _, err := ac.Source.InitRepo(repo, true); err != nil {
+		ctxLogger(s.Context()).Error("error creating repo", "repo", repo, "err", err)
+		return
+	}
+	if err := ac.InstallGitHooks(repo); err != nil {
+		ctxLogger(s.Context()).Error("error installing git hooks", "repo", repo, "err", err)
+	}
+}
+
 // softMiddleware is the Soft Serve middleware that handles SSH commands.
 func softMiddleware(ac *appCfg.Config) wish.Middleware {
 	return func(sh ssh.Handler) ssh.Handler {
@@ -28,17 +108,21 @@ func softMiddleware(ac *appCfg.Config) wish.Middleware {
 					use += fmt.Sprintf(" -p%d", port)
 				}
 				use += fmt.Sprintf(" %s", ac.Host)
-				cmd := cmd.RootCommand()
-				cmd.Use = use
-				cmd.CompletionOptions.DisableDefaultCmd = true
-				cmd.SetIn(s)
-				cmd.SetOut(s)
-				cmd.SetErr(s.Stderr())
-				cmd.SetArgs(s.Command())
-				err := cmd.ExecuteContext(ctx)
+				rootCmd := cmd.RootCommand()
+				rootCmd.Use = use
+				rootCmd.CompletionOptions.DisableDefaultCmd = true
+				rootCmd.SetIn(s)
+				rootCmd.SetOut(s)
+				rootCmd.SetErr(s.Stderr())
+				rootCmd.SetArgs(ac.ExpandAlias(s.PublicKey(), s.Command()))
+				c, err := rootCmd.ExecuteContextC(ctx)
+				if c != nil && cmd.Audited(c) {
+					reason, _ := c.Flags().GetString("reason")
+					ac.AuditCommand(s.PublicKey(), s.RemoteAddr().String(), cmd.AuditLine(s.Command()), reason, err)
+				}
 				if err != nil {
-					_, _ = s.Write([]byte(err.Error()))
-					_ = s.Exit(1)
+					asJSON, _ := rootCmd.PersistentFlags().GetBool("json")
+					_ = s.Exit(cmd.WriteError(s, err, asJSON))
 					return
 				}
 			}()
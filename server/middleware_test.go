@@ -1,14 +1,19 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
 	"github.com/charmbracelet/soft-serve/config"
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
 	sconfig "github.com/charmbracelet/soft-serve/server/config"
 	"github.com/charmbracelet/wish/testsession"
 	"github.com/gliderlabs/ssh"
 	"github.com/matryer/is"
+	gossh "golang.org/x/crypto/ssh"
 )
 
 var ()
@@ -36,3 +41,84 @@ func TestMiddleware(t *testing.T) {
 		}),
 	}, nil)
 }
+
+func TestMiddlewareErrors(t *testing.T) {
+	is := is.New(t)
+	appCfg, err := config.NewConfig(&sconfig.Config{
+		Host:     "localhost",
+		Port:     22224,
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+		InitialAdminKeys: []string{
+			"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMJlb/qf2B2kMNdBxfpCQqI2ctPcsOkdZGVh5zTRhKtH",
+		},
+	})
+	is.NoErr(err)
+	cases := []struct {
+		command string
+		status  int
+		code    string
+	}{
+		{"cat missing/README.md --json", cm.StatusNotFound, "repo_not_found"},
+		{"reload --json", cm.StatusUnauthorized, "unauthorized"},
+		{"cat --json", cm.StatusInvalidArgument, "invalid_argument"},
+		{"ls --json --foo", cm.StatusInvalidArgument, "invalid_argument"},
+		{"create ../escape --json", cm.StatusInvalidArgument, "invalid_argument"},
+		{"create new-repo --json", cm.StatusUnauthorized, "unauthorized"},
+		{"collab add config Admin --json", cm.StatusUnauthorized, "unauthorized"},
+		{"migrate --json", cm.StatusUnauthorized, "unauthorized"},
+		{"find code TODO --json", cm.StatusUnauthorized, "unauthorized"},
+	}
+	for _, c := range cases {
+		t.Run(c.command, func(t *testing.T) {
+			is := is.New(t)
+			var out bytes.Buffer
+			s := testsession.New(t, &ssh.Server{
+				Handler: softMiddleware(appCfg)(func(s ssh.Session) {}),
+			}, nil)
+			defer s.Close()
+			s.Stdout = &out
+			err := s.Run(c.command)
+			var ee *gossh.ExitError
+			is.True(errors.As(err, &ee))
+			is.Equal(ee.ExitStatus(), c.status)
+			var e cm.Error
+			is.NoErr(json.Unmarshal(out.Bytes(), &e))
+			is.Equal(e.Code, c.code)
+		})
+	}
+}
+
+func TestSidebandWriter(t *testing.T) {
+	is := is.New(t)
+	var buf bytes.Buffer
+	w := &sidebandWriter{w: &buf}
+	// The flush packet ending the ref advertisement isn't held back...
+	adv := "003e0000000000000000000000000000000000000000 capabilities^{}\x00\n0000"
+	_, err := w.Write([]byte(adv))
+	is.NoErr(err)
+	is.Equal(buf.String(), adv)
+	// ...but the one ending the side-band stream is, even when packets are
+	// split across writes.
+	buf.Reset()
+	status := "0013\x01000eunpack ok\n0000"
+	for i := range status {
+		_, err := w.Write([]byte{status[i]})
+		is.NoErr(err)
+	}
+	is.Equal(buf.String(), "0013\x01000eunpack ok\n")
+	sent, err := w.finish("warning: almost full")
+	is.NoErr(err)
+	is.True(sent)
+	is.Equal(buf.String(), "0013\x01000eunpack ok\n001a\x02warning: almost full\n0000")
+
+	// Without a side-band, messages aren't sent.
+	buf.Reset()
+	w = &sidebandWriter{w: &buf}
+	_, err = w.Write([]byte("000eunpack ok\n0000"))
+	is.NoErr(err)
+	sent, err = w.finish("warning: almost full")
+	is.NoErr(err)
+	is.True(!sent)
+	is.Equal(buf.String(), "000eunpack ok\n0000")
+}
@@ -0,0 +1,265 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/oidc"
+	"github.com/gliderlabs/ssh"
+)
+
+const (
+	// webSessionCookie holds the user signed in to the web UI.
+	webSessionCookie = "soft_serve_session"
+	// oidcLoginCookie holds the state of a sign in with the OIDC provider.
+	oidcLoginCookie = "soft_serve_oidc"
+	// webSessionTTL is how long users stay signed in.
+	webSessionTTL = 12 * time.Hour
+	// oidcLoginTTL is how long users have to sign in with the provider.
+	oidcLoginTTL = 10 * time.Minute
+)
+
+// webSession is the sealed content of the session cookie.
+type webSession struct {
+	User    string    `json:"user"`
+	Expires time.Time `json:"expires"`
+}
+
+// oidcLogin is the sealed content of the login cookie, tying the callback to
+// the browser that started signing in.
+type oidcLogin struct {
+	State    string    `json:"state"`
+	Nonce    string    `json:"nonce"`
+	Verifier string    `json:"verifier"`
+	Next     string    `json:"next"`
+	Expires  time.Time `json:"expires"`
+}
+
+// oidcProviders keeps the provider of the OIDC settings, so that its
+// discovery document and keys are fetched once.
+type oidcProviders struct {
+	mtx      sync.Mutex
+	settings string
+	provider *oidc.Provider
+}
+
+// get returns the provider of the OIDC settings.
+func (ps *oidcProviders) get(ac *appCfg.Config, o appCfg.OIDC) (*oidc.Provider, error) {
+	var secret string
+	if o.ClientSecret != "" {
+		s, err := ac.Secrets.Get(o.ClientSecret)
+		if err != nil {
+			return nil, fmt.Errorf("secret %q: %w", o.ClientSecret, err)
+		}
+		secret = s
+	}
+	key := fmt.Sprint(o.Issuer, o.ClientID, secret, o.URL, o.Scopes)
+	ps.mtx.Lock()
+	defer ps.mtx.Unlock()
+	if ps.provider == nil || ps.settings != key {
+		ps.provider = oidc.NewProvider(o.Issuer, o.ClientID, secret, o.RedirectURL(), o.Scopes)
+		ps.settings = key
+	}
+	return ps.provider, nil
+}
+
+// oidcEnabled returns the OIDC settings, and whether signing in to the web
+// UI is enabled. Sessions are sealed with the key of the secrets store, so it
+// must be configured.
+func (h *httpHandler) oidcEnabled() (appCfg.OIDC, bool) {
+	o := h.cfg.OIDCSettings()
+	return o, o.Enabled() && h.cfg.Secrets != nil
+}
+
+// serveOIDC serves signing in to and out of the web UI, under /-/.
+//
+//	GET /-/login?next=PATH
+//	GET /-/oidc/callback
+//	GET /-/logout
+func (h *httpHandler) serveOIDC(w http.ResponseWriter, r *http.Request, rest string) {
+	o, ok := h.oidcEnabled()
+	if !ok || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		http.NotFound(w, r)
+		return
+	}
+	switch rest {
+	case "login":
+		h.oidcLogin(w, r, o)
+	case "oidc/callback":
+		h.oidcCallback(w, r, o)
+	case "logout":
+		h.clearCookie(w, r, webSessionCookie)
+		http.Redirect(w, r, "/", http.StatusFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// oidcLogin sends the user to the provider to sign in.
+func (h *httpHandler) oidcLogin(w http.ResponseWriter, r *http.Request, o appCfg.OIDC) {
+	p, err := h.oidc.get(h.cfg, o)
+	if err != nil {
+		h.oidcError(w, r, err)
+		return
+	}
+	l := oidcLogin{
+		State:    oidc.RandomString(),
+		Nonce:    oidc.RandomString(),
+		Verifier: oidc.RandomString(),
+		Next:     safeNext(r.URL.Query().Get("next")),
+		Expires:  time.Now().Add(oidcLoginTTL),
+	}
+	u, err := p.AuthCodeURL(r.Context(), l.State, l.Nonce, l.Verifier)
+	if err != nil {
+		h.oidcError(w, r, err)
+		return
+	}
+	if err := h.setCookie(w, r, oidcLoginCookie, l, l.Expires); err != nil {
+		h.oidcError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// oidcCallback signs the user in with the code the provider sent them back
+// with.
+func (h *httpHandler) oidcCallback(w http.ResponseWriter, r *http.Request, o appCfg.OIDC) {
+	var l oidcLogin
+	if !h.readCookie(r, oidcLoginCookie, &l) || time.Now().After(l.Expires) ||
+		r.URL.Query().Get("state") != l.State {
+		http.Error(w, "Sign in expired, try again", http.StatusBadRequest)
+		return
+	}
+	h.clearCookie(w, r, oidcLoginCookie)
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, "Sign in failed: "+e, http.StatusForbidden)
+		return
+	}
+	p, err := h.oidc.get(h.cfg, o)
+	if err != nil {
+		h.oidcError(w, r, err)
+		return
+	}
+	claims, err := p.Exchange(r.Context(), r.URL.Query().Get("code"), l.Nonce, l.Verifier)
+	if err != nil {
+		ctxLogger(r.Context()).Warn("error signing in with oidc", "err", err)
+		http.Error(w, "Sign in failed", http.StatusForbidden)
+		return
+	}
+	identity := claims.String(o.Claim)
+	if o.Claim == "email" {
+		if verified, ok := claims["email_verified"].(bool); ok && !verified {
+			identity = ""
+		}
+	}
+	name, _, ok := h.cfg.OIDCAuth(r.RemoteAddr, identity)
+	if !ok {
+		http.Error(w, "No user matches your identity", http.StatusForbidden)
+		return
+	}
+	s := webSession{User: name, Expires: time.Now().Add(webSessionTTL)}
+	if err := h.setCookie(w, r, webSessionCookie, s, s.Expires); err != nil {
+		h.oidcError(w, r, err)
+		return
+	}
+	http.Redirect(w, r, l.Next, http.StatusFound)
+}
+
+// webUser returns the name and key of the user signed in to the web UI, or
+// a nil key for anonymous users.
+func (h *httpHandler) webUser(r *http.Request) (string, ssh.PublicKey) {
+	if _, ok := h.oidcEnabled(); !ok {
+		return "", nil
+	}
+	var s webSession
+	if !h.readCookie(r, webSessionCookie, &s) || time.Now().After(s.Expires) {
+		return "", nil
+	}
+	pk, ok := h.cfg.UserKey(s.User)
+	if !ok {
+		return "", nil
+	}
+	return s.User, pk
+}
+
+// setCookie seals v into a cookie.
+func (h *httpHandler) setCookie(w http.ResponseWriter, r *http.Request, name string, v interface{}, expires time.Time) error {
+	bts, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	box, err := h.cfg.Secrets.Seal(bts)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    base64.RawURLEncoding.EncodeToString(box),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   h.secureCookies(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie unseals a cookie into v. It returns false if the cookie is
+// missing or was tampered with.
+func (h *httpHandler) readCookie(r *http.Request, name string, v interface{}) bool {
+	c, err := r.Cookie(name)
+	if err != nil {
+		return false
+	}
+	box, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return false
+	}
+	bts, err := h.cfg.Secrets.Unseal(box)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(bts, v) == nil
+}
+
+// clearCookie removes a cookie.
+func (h *httpHandler) clearCookie(w http.ResponseWriter, r *http.Request, name string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   h.secureCookies(r),
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// secureCookies returns whether cookies are only sent over HTTPS: when the
+// request came over TLS, or the public URL of the server is HTTPS.
+func (h *httpHandler) secureCookies(r *http.Request) bool {
+	o := h.cfg.OIDCSettings()
+	return r.TLS != nil || strings.HasPrefix(o.URL, "https://")
+}
+
+func (h *httpHandler) oidcError(w http.ResponseWriter, r *http.Request, err error) {
+	ctxLogger(r.Context()).Error("error signing in with oidc", "err", err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// safeNext returns the path to go to after signing in, which must be on this
+// server.
+func safeNext(next string) string {
+	u, err := url.Parse(next)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") ||
+		strings.HasPrefix(next, "//") || strings.Contains(next, "\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,179 @@
+package server_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+// newOIDCProvider starts an OpenID Connect provider signing everyone in with
+// the email returned by identity.
+func newOIDCProvider(t *testing.T, identity func() string) *httptest.Server {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var srv *httptest.Server
+	// logins maps codes to the nonce and PKCE challenge of their sign in.
+	logins := map[string][2]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 srv.URL,
+			"authorization_endpoint": srv.URL + "/authorize",
+			"token_endpoint":         srv.URL + "/token",
+			"jwks_uri":               srv.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		code := fmt.Sprint(len(logins))
+		logins[code] = [2]string{q.Get("nonce"), q.Get("code_challenge")}
+		http.Redirect(w, r, q.Get("redirect_uri")+"?"+url.Values{
+			"code":  {code},
+			"state": {q.Get("state")},
+		}.Encode(), http.StatusFound)
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		login, ok := logins[r.FormValue("code")]
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if id != "soft-serve" || secret != "hunter2" || !ok ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != login[1] {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		seg := func(v interface{}) string {
+			bts, _ := json.Marshal(v)
+			return base64.RawURLEncoding.EncodeToString(bts)
+		}
+		signed := seg(map[string]string{"alg": "RS256", "kid": "k1"}) + "." + seg(map[string]interface{}{
+			"iss":            srv.URL,
+			"aud":            "soft-serve",
+			"sub":            "1234",
+			"email":          identity(),
+			"email_verified": true,
+			"nonce":          login[0],
+			"exp":            time.Now().Add(time.Hour).Unix(),
+		})
+		digest := sha256.Sum256([]byte(signed))
+		sig, _ := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"id_token": signed + "." + base64.RawURLEncoding.EncodeToString(sig),
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	srv = httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOIDC(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	email := "frankie@example.com"
+	provider := newOIDCProvider(t, func() string { return email })
+	s.CreateRepo("secret", map[string]string{"README.md": "# Secret\n"})
+	sess := s.Session(s.Admin)
+	sess.Stdin = strings.NewReader("hunter2")
+	is.NoErr(sess.Run("secret set oidc/client"))
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+  - name: Frankie
+    oidc: frankie@example.com
+    public-keys:
+      - %s
+repos:
+  - name: secret
+    repo: secret
+    private: true
+    collabs:
+      - Frankie
+oidc:
+  issuer: %s
+  client-id: soft-serve
+  client-secret: oidc/client
+  url: http://%s
+`, s.Admin.AuthorizedKey(), servertest.NewKey(t).AuthorizedKey(), provider.URL, s.HTTPAddr),
+	}))
+
+	jar, err := cookiejar.New(nil)
+	is.NoErr(err)
+	client := &http.Client{Jar: jar}
+	get := func(path string) (int, string) {
+		res, err := client.Get(fmt.Sprintf("http://%s%s", s.HTTPAddr, path))
+		is.NoErr(err)
+		defer res.Body.Close()
+		bts, err := io.ReadAll(res.Body)
+		is.NoErr(err)
+		return res.StatusCode, string(bts)
+	}
+
+	code, body := get("/")
+	is.Equal(code, http.StatusOK)
+	is.True(strings.Contains(body, "Sign in"))
+	is.True(!strings.Contains(body, "secret"))
+	code, _ = get("/secret/-/")
+	is.Equal(code, http.StatusNotFound)
+
+	// Signing in gives the access of the user with the identity.
+	code, body = get("/-/login?next=" + url.QueryEscape("/secret/-/"))
+	is.Equal(code, http.StatusOK)
+	is.True(strings.Contains(body, "# Secret") || strings.Contains(body, "Secret</h1>"))
+	is.True(strings.Contains(body, "Frankie"))
+	code, body = get("/")
+	is.Equal(code, http.StatusOK)
+	is.True(strings.Contains(body, "/secret/-/"))
+
+	code, _ = get("/-/logout")
+	is.Equal(code, http.StatusOK)
+	code, _ = get("/secret/-/")
+	is.Equal(code, http.StatusNotFound)
+
+	// Identities without a user are refused, and sign ins only redirect
+	// to this server.
+	email = "stranger@example.com"
+	code, _ = get("/-/login")
+	is.Equal(code, http.StatusForbidden)
+	email = "frankie@example.com"
+	res, err := client.Get(fmt.Sprintf("http://%s/-/login?next=//evil.example.com/", s.HTTPAddr))
+	is.NoErr(err)
+	res.Body.Close()
+	is.Equal(res.Request.URL.Host, s.HTTPAddr)
+	is.Equal(res.Request.URL.Path, "/")
+
+	// The callback only completes sign ins started by the browser.
+	code, _ = get("/-/oidc/callback?code=0&state=forged")
+	is.Equal(code, http.StatusBadRequest)
+}
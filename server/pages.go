@@ -0,0 +1,206 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/log"
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	defaultPagesBranch  = "pages"
+	defaultPagesMaxSize = 50 << 20
+)
+
+var (
+	errPagesDisabled = errors.New("pages are not enabled")
+	errPagesTooLarge = errors.New("site exceeds the pages size limit")
+)
+
+// site is a static site built from the pages branch of a repo.
+type site struct {
+	commit string
+	built  time.Time
+	files  map[string][]byte
+}
+
+// pages serves the static sites of the repos with pages enabled. Sites are
+// built on first request and rebuilt when their branch is pushed to.
+type pages struct {
+	cfg   *appCfg.Config
+	mtx   sync.Mutex
+	sites map[string]*site
+}
+
+func newPages(cfg *appCfg.Config) *pages {
+	return &pages{
+		cfg:   cfg,
+		sites: make(map[string]*site),
+	}
+}
+
+// Run rebuilds sites as their branches are pushed to until ctx is done.
+func (p *pages) Run(ctx context.Context) {
+	for e := range p.cfg.Events.Subscribe(ctx) {
+		if e.Type != events.Push {
+			continue
+		}
+		pc := p.cfg.RepoPages(e.Repo)
+		if !pc.Enabled {
+			p.forget(e.Repo)
+			continue
+		}
+		if e.Ref != "" && e.Ref != git.RefsHeads+pagesBranch(pc) {
+			continue
+		}
+		p.forget(e.Repo)
+		if _, err := p.site(e.Repo); err != nil {
+			log.Error("error building pages", "repo", e.Repo, "err", err)
+		}
+	}
+}
+
+// ServeHTTP serves the file at the given path of the repo site.
+func (p *pages) ServeHTTP(w http.ResponseWriter, r *http.Request, repo, fp string) {
+	s, err := p.site(repo)
+	switch {
+	case errors.Is(err, errPagesDisabled), errors.Is(err, git.ErrReferenceNotFound):
+		http.NotFound(w, r)
+		return
+	case err != nil:
+		ctxLogger(r.Context()).Error("error building pages", "repo", repo, "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	name := fp
+	data, ok := s.files[name]
+	if !ok {
+		name = path.Join(fp, "index.html")
+		data, ok = s.files[name]
+		// Redirect directories to the trailing slash so relative links
+		// resolve.
+		if ok && fp != "" && !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+	}
+	if !ok {
+		if nf, ok := s.files["404.html"]; ok {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write(nf)
+			return
+		}
+		http.NotFound(w, r)
+		return
+	}
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Set("ETag", fmt.Sprintf("%q", s.commit+":"+name))
+	http.ServeContent(w, r, name, s.built, bytes.NewReader(data))
+}
+
+// site returns the built site of the repo, building it if needed.
+func (p *pages) site(repo string) (*site, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if s, ok := p.sites[repo]; ok {
+		return s, nil
+	}
+	pc := p.cfg.RepoPages(repo)
+	if !pc.Enabled {
+		return nil, errPagesDisabled
+	}
+	maxSize := uint64(defaultPagesMaxSize)
+	if pc.MaxSize != "" {
+		n, err := humanize.ParseBytes(pc.MaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pages max-size %q: %w", pc.MaxSize, err)
+		}
+		maxSize = n
+	}
+	r, err := p.cfg.Source.GetRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := r.Reference(git.RefsHeads + pagesBranch(pc))
+	if err != nil {
+		return nil, err
+	}
+	t, err := r.Tree(ref, "")
+	if err != nil {
+		return nil, err
+	}
+	s := &site{
+		commit: ref.Hash.String(),
+		built:  time.Now(),
+		files:  make(map[string][]byte),
+	}
+	var size uint64
+	if err := addSiteFiles(s, t, "", &size, maxSize); err != nil {
+		return nil, err
+	}
+	p.sites[repo] = s
+	log.Debug("built pages", "repo", repo, "commit", s.commit, "files", len(s.files), "size", humanize.Bytes(size))
+	return s, nil
+}
+
+// forget drops the built site of the repo.
+func (p *pages) forget(repo string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	delete(p.sites, repo)
+}
+
+// addSiteFiles adds the files of the tree to the site, failing once their
+// total size exceeds maxSize.
+func addSiteFiles(s *site, t *git.Tree, dir string, size *uint64, maxSize uint64) error {
+	ents, err := t.Entries()
+	if err != nil {
+		return err
+	}
+	for _, e := range ents {
+		name := path.Join(dir, e.Name())
+		switch {
+		case e.IsTree():
+			st, err := t.SubTree(e.Name())
+			if err != nil {
+				return err
+			}
+			if err := addSiteFiles(s, st, name, size, maxSize); err != nil {
+				return err
+			}
+		case e.IsBlob() || e.IsExec():
+			*size += uint64(e.Size())
+			if *size > maxSize {
+				return errPagesTooLarge
+			}
+			data, err := e.Contents()
+			if err != nil {
+				return err
+			}
+			s.files[name] = data
+		}
+	}
+	return nil
+}
+
+func pagesBranch(pc appCfg.Pages) string {
+	if pc.Branch != "" {
+		return pc.Branch
+	}
+	return defaultPagesBranch
+}
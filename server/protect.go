@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/gliderlabs/ssh"
+)
+
+// refUpdateLine matches the lines of push commands updating refs.
+var refUpdateLine = regexp.MustCompile(`^([0-9a-f]{40,64}) ([0-9a-f]{40,64}) (\S+)$`)
+
+// pushCommands reads the commands of a push, the ref updates the client
+// sends before the pack, from the input of receive-pack, and checks them
+// against the branch protection rules of the repo. Pushes breaking the
+// rules are kept from receive-pack, which gets no commands and stops, and
+// are rejected with reject.
+type pushCommands struct {
+	r     io.Reader
+	check func([]appCfg.RefUpdate) []appCfg.PushViolation
+	// buf holds the input read until the end of the commands, parsed up
+	// to off.
+	buf []byte
+	off int
+	// out holds the input to pass on before reading more.
+	out  []byte
+	done bool
+	// updates and caps are the ref updates of the push, and the
+	// capabilities the client asked for.
+	updates    []appCfg.RefUpdate
+	caps       []string
+	violations []appCfg.PushViolation
+}
+
+// newPushCommands returns the push commands of a push by the user of a key
+// to a repo, read from r.
+func newPushCommands(ac *appCfg.Config, repo string, pk ssh.PublicKey, r io.Reader) *pushCommands {
+	return &pushCommands{
+		r: r,
+		check: func(updates []appCfg.RefUpdate) []appCfg.PushViolation {
+			return ac.CheckPush(repo, pk, updates)
+		},
+	}
+}
+
+// Read implements io.Reader.
+func (c *pushCommands) Read(p []byte) (int, error) {
+	for !c.done {
+		if err := c.readCommands(); err != nil {
+			return 0, err
+		}
+	}
+	if len(c.out) > 0 {
+		n := copy(p, c.out)
+		c.out = c.out[n:]
+		return n, nil
+	}
+	if c.rejected() {
+		return 0, io.EOF
+	}
+	return c.r.Read(p)
+}
+
+// readCommands reads more input, and parses the commands once they're all
+// read. Input that isn't made of packets is passed on as is.
+func (c *pushCommands) readCommands() error {
+	chunk := make([]byte, 32*1024)
+	n, err := c.r.Read(chunk)
+	c.buf = append(c.buf, chunk[:n]...)
+	for len(c.buf)-c.off >= 4 {
+		size, perr := strconv.ParseUint(string(c.buf[c.off:c.off+4]), 16, 16)
+		if perr != nil || (size > 0 && size < 4) {
+			c.finish(false)
+			return nil
+		}
+		if size == 0 {
+			c.finish(true)
+			return nil
+		}
+		if len(c.buf)-c.off < int(size) {
+			break
+		}
+		c.parseCommand(string(c.buf[c.off+4 : c.off+int(size)]))
+		c.off += int(size)
+	}
+	if err == io.EOF {
+		c.finish(false)
+		return nil
+	}
+	return err
+}
+
+// parseCommand parses a command packet. The first one lists the
+// capabilities of the client after a NUL.
+func (c *pushCommands) parseCommand(line string) {
+	line = strings.TrimSuffix(line, "\n")
+	if i := strings.IndexByte(line, 0); i >= 0 {
+		if c.caps == nil {
+			c.caps = strings.Fields(line[i+1:])
+		}
+		line = line[:i]
+	}
+	if m := refUpdateLine.FindStringSubmatch(line); m != nil {
+		c.updates = append(c.updates, appCfg.RefUpdate{Old: m[1], New: m[2], Ref: m[3]})
+	}
+}
+
+// finish ends reading the commands, checking them if they were all read.
+func (c *pushCommands) finish(complete bool) {
+	c.done = true
+	if complete && len(c.updates) > 0 {
+		c.violations = c.check(c.updates)
+	}
+	if c.rejected() {
+		c.out = []byte("0000")
+	} else {
+		c.out = c.buf
+	}
+	c.buf = nil
+}
+
+// rejected returns whether the push breaks the branch protection rules.
+func (c *pushCommands) rejected() bool {
+	return len(c.violations) > 0
+}
+
+// hasCap returns whether the client asked for a capability.
+func (c *pushCommands) hasCap(caps ...string) bool {
+	for _, cc := range c.caps {
+		for _, want := range caps {
+			if cc == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// reject reports a rejected push to the client: the violations on the
+// progress band, if the client asked for a side-band, and the status of
+// each ref, which is rejected. It returns false when the violations
+// couldn't be sent for lack of a side-band.
+func (c *pushCommands) reject(w io.Writer) (bool, error) {
+	reasons := make(map[string]string, len(c.violations))
+	for _, v := range c.violations {
+		reasons[v.Ref] = v.Reason
+	}
+	var status bytes.Buffer
+	if c.hasCap("report-status", "report-status-v2") {
+		status.WriteString(pktLine("unpack ok\n"))
+		for _, u := range c.updates {
+			reason, ok := reasons[u.Ref]
+			if !ok {
+				reason = "push rejected by branch protection"
+			}
+			status.WriteString(pktLine(fmt.Sprintf("ng %s %s\n", u.Ref, reason)))
+		}
+		status.WriteString("0000")
+	}
+	max := 0
+	switch {
+	case c.hasCap("side-band-64k"):
+		max = 65520 - 5
+	case c.hasCap("side-band"):
+		max = 1000 - 5
+	default:
+		_, err := w.Write(status.Bytes())
+		return false, err
+	}
+	var out bytes.Buffer
+	for _, v := range c.violations {
+		out.WriteString(pktLine(fmt.Sprintf("\x02error: %s\n", v)))
+	}
+	for b := status.Bytes(); len(b) > 0; {
+		n := len(b)
+		if n > max {
+			n = max
+		}
+		out.WriteString(pktLine("\x01" + string(b[:n])))
+		b = b[n:]
+	}
+	out.WriteString("0000")
+	_, err := w.Write(out.Bytes())
+	return true, err
+}
+
+// pushInputSession is a session of a push whose input goes through
+// pushCommands.
+type pushInputSession struct {
+	ssh.Session
+	pc *pushCommands
+}
+
+// Read implements io.Reader.
+func (s pushInputSession) Read(p []byte) (int, error) {
+	return s.pc.Read(p)
+}
+
+// pktLine returns a packet of the git protocol with payload s.
+func pktLine(s string) string {
+	return fmt.Sprintf("%04x%s", len(s)+4, s)
+}
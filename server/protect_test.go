@@ -0,0 +1,95 @@
+package server_test
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBranchProtection(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	s := servertest.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+repos:
+  - name: Repo
+    repo: repo
+    protect:
+      - branches: [master]
+        no-force-push: true
+        no-deletion: true
+      - branches: ["release/*"]
+        pushers: [Frankie]
+`, s.Admin.AuthorizedKey(), hash),
+	}))
+	wd := t.TempDir()
+	gitCmd := func(args ...string) (string, error) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = wd
+		cmd.Env = append(cmd.Environ(), fmt.Sprintf("GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no -o IdentitiesOnly=yes -i %s -F /dev/null", s.Admin.Path))
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	sshURL := s.CloneURL("repo")
+	httpURL := fmt.Sprintf("http://admin:secret@%s/repo.git", s.HTTPAddr)
+	_, err = gitCmd("clone", "-q", sshURL, ".")
+	is.NoErr(err)
+
+	// Fast-forwards of protected branches go through.
+	_, err = gitCmd("commit", "-q", "--allow-empty", "-m", "empty commit")
+	is.NoErr(err)
+	out, err := gitCmd("push", sshURL, "HEAD:refs/heads/master")
+	is.NoErr(err)
+	is.True(!strings.Contains(out, "protected"))
+
+	// Force pushes don't, over SSH and HTTP.
+	_, err = gitCmd("commit", "-q", "--amend", "--allow-empty", "-m", "amended")
+	is.NoErr(err)
+	for _, url := range []string{sshURL, httpURL} {
+		out, err = gitCmd("push", "-f", url, "HEAD:refs/heads/master")
+		is.True(err != nil)
+		is.True(strings.Contains(out, "remote: error: refs/heads/master is a protected branch, it can't be force pushed"))
+	}
+
+	// Neither do deletions, nor pushes by users other than the pushers,
+	// which are rejected before the pack is sent.
+	for _, url := range []string{sshURL, httpURL} {
+		out, err = gitCmd("push", url, ":refs/heads/master")
+		is.True(err != nil)
+		is.True(strings.Contains(out, "remote: error: refs/heads/master: protected branch, it can't be deleted"))
+		out, err = gitCmd("push", url, "HEAD:refs/heads/release/1.0", "HEAD:refs/heads/feature")
+		is.True(err != nil)
+		is.True(strings.Contains(out, "remote: error: refs/heads/release/1.0: protected branch, you can't push to it"))
+		is.True(strings.Contains(out, "[remote rejected] HEAD -> feature (push rejected by branch protection)"))
+	}
+	out, err = gitCmd("ls-remote", sshURL)
+	is.NoErr(err)
+	is.True(strings.Contains(out, "refs/heads/master"))
+	is.True(!strings.Contains(out, "refs/heads/feature"))
+
+	// Other branches can be pushed to, and force pushed.
+	_, err = gitCmd("push", httpURL, "HEAD:refs/heads/feature")
+	is.NoErr(err)
+	_, err = gitCmd("commit", "-q", "--amend", "--allow-empty", "-m", "amended again")
+	is.NoErr(err)
+	_, err = gitCmd("push", "-f", sshURL, "HEAD:refs/heads/feature")
+	is.NoErr(err)
+}
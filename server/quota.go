@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/gliderlabs/ssh"
+)
+
+// quotaExceeded returns the error pushes to a repo at or over its quota are
+// rejected with, if any.
+func quotaExceeded(ctx context.Context, ac *appCfg.Config, repo string) error {
+	u, ok, err := ac.QuotaUsage(repo)
+	if err != nil {
+		ctxLogger(ctx).Error("error measuring quota usage", "repo", repo, "err", err)
+		return nil
+	}
+	if !ok || !u.Exceeded() {
+		return nil
+	}
+	return fmt.Errorf("push rejected: %s", u)
+}
+
+// quotaWarning returns the advisory message of pushes that left a repo past
+// the warning threshold of its quota, or "".
+func quotaWarning(ctx context.Context, ac *appCfg.Config, repo string) string {
+	u, ok, err := ac.QuotaUsage(repo)
+	if err != nil {
+		ctxLogger(ctx).Error("error measuring quota usage", "repo", repo, "err", err)
+		return ""
+	}
+	if !ok || !u.Warning() {
+		return ""
+	}
+	return "warning: " + u.String()
+}
+
+// sidebandWriter passes the output of receive-pack through, holding back
+// the flush packet ending its side-band stream, so that messages can be sent
+// on the progress band once receive-pack is done. Clients print them like
+// the output of git hooks.
+type sidebandWriter struct {
+	w io.Writer
+	// hdr holds the length of the next packet while it's being written.
+	hdr []byte
+	// left is the number of payload bytes of the current packet left.
+	left int
+	// start is whether the payload of the current packet is starting.
+	start bool
+	// sideband is whether the output is multiplexed into bands.
+	sideband bool
+	// held is whether a flush packet is held back.
+	held bool
+	// raw is whether the output isn't made of packets, and passed as is.
+	raw bool
+}
+
+// Write implements io.Writer.
+func (w *sidebandWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		if w.raw {
+			_, err := w.w.Write(p)
+			return n, err
+		}
+		if w.left > 0 {
+			if w.start {
+				w.sideband = w.sideband || (p[0] >= 1 && p[0] <= 3)
+				w.start = false
+			}
+			k := w.left
+			if k > len(p) {
+				k = len(p)
+			}
+			if _, err := w.w.Write(p[:k]); err != nil {
+				return 0, err
+			}
+			w.left -= k
+			p = p[k:]
+			continue
+		}
+		k := 4 - len(w.hdr)
+		if k > len(p) {
+			k = len(p)
+		}
+		w.hdr = append(w.hdr, p[:k]...)
+		p = p[k:]
+		if len(w.hdr) < 4 {
+			break
+		}
+		hdr := w.hdr
+		w.hdr = nil
+		size, err := strconv.ParseUint(string(hdr), 16, 16)
+		switch {
+		case err != nil:
+			w.raw = true
+		case size == 0 && w.sideband:
+			w.held = true
+			continue
+		case size > 4:
+			w.left = int(size) - 4
+			w.start = true
+		}
+		if _, err := w.w.Write(hdr); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// release writes the flush packet held back, if any.
+func (w *sidebandWriter) release() error {
+	if !w.held {
+		return nil
+	}
+	w.held = false
+	_, err := io.WriteString(w.w, "0000")
+	return err
+}
+
+// finish sends msg on the progress band, if it isn't empty, and ends the
+// side-band stream. It returns false when msg couldn't be sent because the
+// client didn't ask for a side-band.
+func (w *sidebandWriter) finish(msg string) (bool, error) {
+	sent := false
+	if msg != "" && w.held {
+		payload := "\x02" + msg + "\n"
+		if _, err := fmt.Fprintf(w.w, "%04x%s", len(payload)+4, payload); err != nil {
+			return false, err
+		}
+		sent = true
+	}
+	return sent, w.release()
+}
+
+// sidebandSession is a session whose output goes through a sidebandWriter.
+type sidebandSession struct {
+	ssh.Session
+	sb *sidebandWriter
+}
+
+// Write implements io.Writer.
+func (s sidebandSession) Write(p []byte) (int, error) {
+	return s.sb.Write(p)
+}
+
+// sidebandResponse is a response writer whose body goes through a
+// sidebandWriter.
+type sidebandResponse struct {
+	http.ResponseWriter
+	sb *sidebandWriter
+}
+
+// Write implements io.Writer.
+func (w sidebandResponse) Write(p []byte) (int, error) {
+	return w.sb.Write(p)
+}
+
+// Flush implements http.Flusher.
+func (w sidebandResponse) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
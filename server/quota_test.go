@@ -0,0 +1,96 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestQuota(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	s := servertest.New(t)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	is.NoErr(err)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	r, err := s.Source.GetRepo("repo")
+	is.NoErr(err)
+	du, err := r.DiskUsage()
+	is.NoErr(err)
+	setQuota := func(max int64, warn int) {
+		t.Helper()
+		is.NoErr(s.Push(s.Admin, "config", map[string]string{
+			"config.yaml": fmt.Sprintf(`users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+    http-password: %s
+repos:
+  - name: Repo
+    repo: repo
+    quota:
+      max-size: %dB
+      warn: %d
+`, s.Admin.AuthorizedKey(), hash, max, warn),
+		}))
+	}
+	// The git command line is used as it prints the side-band messages
+	// sent once the status of the push is reported.
+	wd := t.TempDir()
+	gitCmd := func(args ...string) (string, error) {
+		t.Helper()
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		cmd := exec.Command("git", args...)
+		cmd.Dir = wd
+		cmd.Env = append(cmd.Environ(), fmt.Sprintf("GIT_SSH_COMMAND=ssh -o UserKnownHostsFile=/dev/null -o StrictHostKeyChecking=no -o IdentitiesOnly=yes -i %s -F /dev/null", s.Admin.Path))
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	httpURL := fmt.Sprintf("http://admin:secret@%s/repo.git", s.HTTPAddr)
+	push := func(url string) (string, error) {
+		t.Helper()
+		_, err := gitCmd("commit", "-q", "--allow-empty", "-m", "empty commit")
+		is.NoErr(err)
+		return gitCmd("push", url, "HEAD:refs/heads/master")
+	}
+	_, err = gitCmd("clone", "-q", s.CloneURL("repo"), ".")
+	is.NoErr(err)
+
+	// Pushes under the warning threshold go through quietly.
+	setQuota(du.Total*10, 50)
+	out, err := push(s.CloneURL("repo"))
+	is.NoErr(err)
+	is.True(!strings.Contains(out, "quota"))
+
+	// Pushes past it are warned on the side-band, over SSH and HTTP.
+	setQuota(du.Total*10, 5)
+	out, err = push(s.CloneURL("repo"))
+	is.NoErr(err)
+	is.True(strings.Contains(out, "remote: warning: repo at "))
+	is.True(strings.Contains(out, " quota ("))
+	out, err = push(httpURL)
+	is.NoErr(err)
+	is.True(strings.Contains(out, "remote: warning: repo at "))
+
+	// Pushes to repos at their quota are rejected.
+	setQuota(du.Total/2, 0)
+	out, err = push(s.CloneURL("repo"))
+	is.True(err != nil)
+	is.True(strings.Contains(out, "push rejected: repo at "))
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/repo.git/info/refs?service=git-receive-pack", s.HTTPAddr), nil)
+	is.NoErr(err)
+	req.SetBasicAuth("admin", "secret")
+	res, err := http.DefaultClient.Do(req)
+	is.NoErr(err)
+	res.Body.Close()
+	is.Equal(res.StatusCode, http.StatusInsufficientStorage)
+}
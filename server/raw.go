@@ -0,0 +1,111 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/git"
+)
+
+// shaRe matches abbreviated and full commit hashes.
+var shaRe = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// serveRaw serves the blob at <ref>/<path> of the repo. Branch and tag names
+// may contain slashes, so the longest matching reference name wins.
+func (h *httpHandler) serveRaw(w http.ResponseWriter, r *http.Request, repo, refPath string) {
+	rr, err := h.cfg.Source.GetRepo(repo)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ref, fp := resolveRefPath(rr, refPath)
+	if ref == nil || fp == "" {
+		http.NotFound(w, r)
+		return
+	}
+	t, err := rr.Tree(ref, "")
+	if err != nil {
+		ctxLogger(r.Context()).Error("error reading tree", "repo", repo, "ref", ref.Name(), "err", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	e, err := t.TreeEntry(fp)
+	if err != nil || e.IsTree() || e.IsCommit() {
+		http.NotFound(w, r)
+		return
+	}
+	// Blobs are streamed rather than read in memory, as they can be large.
+	// ServeContent takes the Content-Length from the size of the blob.
+	blob := t.Repository.OpenBlob(r.Context(), e)
+	defer blob.Close() // nolint: errcheck
+	ct := mime.TypeByExtension(path.Ext(fp))
+	if ct == "" {
+		var head [512]byte
+		n, err := io.ReadFull(blob, head[:])
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			ctxLogger(r.Context()).Error("error reading blob", "repo", repo, "path", fp, "err", err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		ct = http.DetectContentType(head[:n])
+	}
+	w.Header().Set("Content-Type", ct)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	// Files are untrusted: browsers mustn't run their scripts with the
+	// origin of the server, and active content is downloaded rather than
+	// rendered.
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	if activeContent(ct) {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(fp)}))
+	}
+	// Blobs are content addressed, so their hash is a strong validator.
+	w.Header().Set("ETag", fmt.Sprintf("%q", e.ID().String()))
+	http.ServeContent(w, r, path.Base(fp), time.Time{}, blob)
+}
+
+// activeContent returns whether browsers run scripts of content of type ct,
+// as they do for HTML, SVG, and XML documents.
+func activeContent(ct string) bool {
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil {
+		return true
+	}
+	switch {
+	case mt == "text/html", mt == "application/xhtml+xml", mt == "image/svg+xml",
+		mt == "text/xml", mt == "application/xml", strings.HasSuffix(mt, "+xml"),
+		strings.Contains(mt, "javascript"), mt == "application/pdf":
+		return true
+	}
+	return false
+}
+
+// resolveRefPath splits <ref>/<path> into the reference and the path. The ref
+// can be a branch, tag, or commit hash.
+func resolveRefPath(r *appCfg.Repo, refPath string) (*git.Reference, string) {
+	parts := strings.Split(refPath, "/")
+	for i := len(parts) - 1; i > 0; i-- {
+		ref, err := r.Reference(strings.Join(parts[:i], "/"))
+		if err == nil {
+			return ref, strings.Join(parts[i:], "/")
+		}
+		if !errors.Is(err, git.ErrReferenceNotFound) {
+			return nil, ""
+		}
+	}
+	if len(parts) > 1 && shaRe.MatchString(parts[0]) {
+		c, err := r.Commit(parts[0])
+		if err != nil {
+			return nil, ""
+		}
+		return git.NewCommitReference(r.Path(), c.Hash), strings.Join(parts[1:], "/")
+	}
+	return nil, ""
+}
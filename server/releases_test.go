@@ -0,0 +1,56 @@
+package server_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestReleases(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	s.CreateRepo("other", map[string]string{"README.md": "# Other\n"})
+	for _, tag := range []struct{ repo, name string }{
+		{"repo", "v1.0.0"},
+		{"other", "v0.1.0"},
+	} {
+		r, err := s.Source.GetRepo(tag.repo)
+		is.NoErr(err)
+		is.NoErr(exec.Command("git", "-C", r.Path(), "tag", tag.name).Run())
+	}
+	is.NoErr(s.Reload())
+
+	out, err := s.Run(s.Admin, "releases --sort name")
+	is.NoErr(err)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	is.Equal(len(lines), 2)
+	is.True(strings.HasPrefix(lines[0], "other  v0.1.0  "))
+	is.True(strings.HasPrefix(lines[1], "repo   v1.0.0  "))
+
+	// Repos with releases off aren't listed.
+	_, err = s.Run(s.Admin, "repo disable other releases")
+	is.NoErr(err)
+	out, err = s.Run(servertest.NewKey(t), "releases --json")
+	is.NoErr(err)
+	var rs []struct {
+		Repo string `json:"repo"`
+		Tag  string `json:"tag"`
+	}
+	is.NoErr(json.Unmarshal([]byte(out), &rs))
+	is.Equal(len(rs), 1)
+	is.Equal(rs[0].Repo, "repo")
+	is.Equal(rs[0].Tag, "v1.0.0")
+
+	_, err = s.Run(s.Admin, "releases --sort size")
+	var ee *cssh.ExitError
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusInvalidArgument)
+}
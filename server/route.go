@@ -0,0 +1,105 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// routeDialTimeout is how long connecting to the backend of a route can
+// take.
+const routeDialTimeout = 10 * time.Second
+
+// routeMiddleware passes the git commands of routed repos through to their
+// backend, connecting to it with the host key at keyPath. Access is checked
+// like for local repos first.
+func routeMiddleware(ac *appCfg.Config, keyPath string) func(ssh.Handler) ssh.Handler {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cmds := s.Command()
+			if len(cmds) != 2 || !strings.HasPrefix(cmds[0], "git-") {
+				sh(s)
+				return
+			}
+			repo := strings.TrimSuffix(strings.TrimPrefix(cmds[1], "/"), "/")
+			repo = strings.TrimSuffix(filepath.Clean(repo), ".git")
+			r, ok := ac.RouteFor(repo)
+			if !ok {
+				sh(s)
+				return
+			}
+			need := gm.ReadOnlyAccess
+			switch cmds[0] {
+			case "git-upload-pack", "git-upload-archive":
+			case "git-receive-pack":
+				need = gm.ReadWriteAccess
+			default:
+				gm.Fatal(s, fmt.Errorf("unknown git command: %s", cmds[0]))
+				return
+			}
+			if ac.AuthRepoCtx(s.Context(), repo, s.PublicKey()) < need {
+				gm.Fatal(s, gm.ErrNotAuthed)
+				return
+			}
+			l := ctxLogger(s.Context())
+			l.Debug("routing git command", "repo", repo, "upstream", r.Upstream)
+			if err := proxyGit(s, r, keyPath, cmds[0], repo); err != nil {
+				var ee *gossh.ExitError
+				if errors.As(err, &ee) {
+					_ = s.Exit(ee.ExitStatus())
+					return
+				}
+				l.Error("error routing git command", "repo", repo, "upstream", r.Upstream, "err", err)
+				gm.Fatal(s, gm.ErrSystemMalfunction)
+			}
+		}
+	}
+}
+
+// proxyGit runs a git command for repo on the backend of a route, with the
+// streams of the session.
+func proxyGit(s ssh.Session, r appCfg.Route, keyPath, service, repo string) error {
+	addr, user, repoPath, err := r.Target(repo)
+	if err != nil {
+		return err
+	}
+	hk, err := r.PublicHostKey()
+	if err != nil {
+		return err
+	}
+	pem, err := os.ReadFile(keyPath)
+	if err != nil {
+		return err
+	}
+	signer, err := gossh.ParsePrivateKey(pem)
+	if err != nil {
+		return err
+	}
+	c, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            user,
+		Auth:            []gossh.AuthMethod{gossh.PublicKeys(signer)},
+		HostKeyCallback: gossh.FixedHostKey(hk),
+		Timeout:         routeDialTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	sess, err := c.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+	sess.Stdin = s
+	sess.Stdout = s
+	sess.Stderr = s.Stderr()
+	return sess.Run(fmt.Sprintf("%s '%s'", service, strings.ReplaceAll(repoPath, "'", `'\''`)))
+}
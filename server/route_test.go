@@ -0,0 +1,71 @@
+package server_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestRoutes(t *testing.T) {
+	is := is.New(t)
+	backend := servertest.New(t)
+	front := servertest.New(t)
+	backend.CreateRepo("old-repo", map[string]string{"README.md": "# Old\n"})
+	is.NoErr(backend.Push(backend.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`anon-access: no-access
+users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+  - name: soft-serve
+    admin: true
+    public-keys:
+      - %s
+`, backend.Admin.AuthorizedKey(), front.HostKey()),
+	}))
+	route := func(hostKey string) error {
+		return front.Push(front.Admin, "config", map[string]string{
+			"config.yaml": fmt.Sprintf(`anon-access: no-access
+users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+routes:
+  - repos: [old-*]
+    upstream: ssh://git@%s/
+    host-key: %s
+`, front.Admin.AuthorizedKey(), backend.SSHAddr, hostKey),
+		})
+	}
+	is.NoErr(route(backend.HostKey()))
+
+	// Routed repos are cloned from and pushed to the backend.
+	r, err := front.Clone(front.Admin, "old-repo")
+	is.NoErr(err)
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	_, err = wt.Filesystem.Stat("README.md")
+	is.NoErr(err)
+	is.NoErr(front.Push(front.Admin, "old-repo", map[string]string{"NEW.md": "new\n"}))
+	r, err = backend.Clone(backend.Admin, "old-repo")
+	is.NoErr(err)
+	wt, err = r.Worktree()
+	is.NoErr(err)
+	_, err = wt.Filesystem.Stat("NEW.md")
+	is.NoErr(err)
+	_, err = front.Source.GetRepo("old-repo")
+	is.True(err != nil) // the repo isn't created on the front server
+
+	// Access is checked by the front server.
+	_, err = front.Clone(servertest.NewKey(t), "old-repo")
+	is.True(err != nil)
+
+	// Backends with another host key are refused.
+	is.NoErr(route(front.HostKey()))
+	_, err = front.Clone(front.Admin, "old-repo")
+	is.True(err != nil)
+}
@@ -0,0 +1,29 @@
+package server_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestSecretSet(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+
+	sess := s.Session(s.Admin)
+	sess.Stdin = strings.NewReader("hunter2\n")
+	is.NoErr(sess.Run("secret set ci/token"))
+	out, err := s.Run(s.Admin, "secret get ci/token")
+	is.NoErr(err)
+	is.Equal(out, "hunter2\n")
+
+	// Values given as arguments are refused, and the secret is kept.
+	out, err = s.Run(s.Admin, "secret set ci/token hunter3")
+	is.True(err != nil)
+	is.True(strings.Contains(out, "secret values are read from stdin, not arguments"))
+	out, err = s.Run(s.Admin, "secret get ci/token")
+	is.NoErr(err)
+	is.Equal(out, "hunter2\n")
+}
@@ -3,28 +3,126 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
 	"net"
+	"net/http"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/log"
 
+	"github.com/charmbracelet/soft-serve/audit"
+	"github.com/charmbracelet/soft-serve/backup"
+	"github.com/charmbracelet/soft-serve/chaos"
+	"github.com/charmbracelet/soft-serve/ci"
 	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/expiry"
+	"github.com/charmbracelet/soft-serve/hooks"
+	"github.com/charmbracelet/soft-serve/housekeeping"
+	"github.com/charmbracelet/soft-serve/mirror"
+	"github.com/charmbracelet/soft-serve/retention"
 	"github.com/charmbracelet/soft-serve/server/config"
 	"github.com/charmbracelet/wish"
 	bm "github.com/charmbracelet/wish/bubbletea"
 	gm "github.com/charmbracelet/wish/git"
-	lm "github.com/charmbracelet/wish/logging"
 	rm "github.com/charmbracelet/wish/recover"
 	"github.com/gliderlabs/ssh"
 	"github.com/muesli/termenv"
+	"golang.org/x/sync/errgroup"
 )
 
 // Server is the Soft Serve server.
 type Server struct {
-	SSHServer *ssh.Server
-	Config    *config.Config
-	config    *appCfg.Config
+	SSHServer  *ssh.Server
+	HTTPServer *http.Server
+	// MetricsServer serves the /metrics endpoint, if enabled.
+	MetricsServer *http.Server
+	Config        *config.Config
+	config        *appCfg.Config
+	cancel        context.CancelFunc
+	// The listeners are served instead of listening on the configured ports
+	// when set.
+	sshListener     net.Listener
+	httpListener    net.Listener
+	gitListener     net.Listener
+	metricsListener net.Listener
+	gitDaemon       *gitDaemon
+}
+
+// options are the options of New.
+type options struct {
+	cfg             *config.Config
+	sshListener     net.Listener
+	httpListener    net.Listener
+	gitListener     net.Listener
+	metricsListener net.Listener
+	source          appCfg.Source
+	authProviders   []appCfg.AuthProvider
+}
+
+// Option configures a Server created by New.
+type Option func(*options)
+
+// WithConfig sets the server configuration. Defaults to
+// config.DefaultConfig().
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) {
+		o.cfg = cfg
+	}
+}
+
+// WithSSHListener makes the server serve SSH on l instead of listening on the
+// configured address.
+func WithSSHListener(l net.Listener) Option {
+	return func(o *options) {
+		o.sshListener = l
+	}
+}
+
+// WithHTTPListener makes the server serve HTTP on l instead of listening on
+// the configured address. HTTP is served even if the configured HTTP port is
+// 0.
+func WithHTTPListener(l net.Listener) Option {
+	return func(o *options) {
+		o.httpListener = l
+	}
+}
+
+// WithGitListener makes the server serve the git protocol on l instead of
+// listening on the configured address. It's served even if the configured
+// git port is 0.
+func WithGitListener(l net.Listener) Option {
+	return func(o *options) {
+		o.gitListener = l
+	}
+}
+
+// WithMetricsListener makes the server serve metrics on l instead of
+// listening on the configured address. Metrics are served even if the
+// configured metrics port is 0.
+func WithMetricsListener(l net.Listener) Option {
+	return func(o *options) {
+		o.metricsListener = l
+	}
+}
+
+// WithStorage makes the server serve the repos of rs, usually a wrapped
+// config.RepoSource, instead of those in the configured repo path.
+func WithStorage(rs appCfg.Source) Option {
+	return func(o *options) {
+		o.source = rs
+	}
+}
+
+// WithAuthProvider adds a provider granting access to repos on top of the
+// users of the config repo.
+func WithAuthProvider(p appCfg.AuthProvider) Option {
+	return func(o *options) {
+		o.authProviders = append(o.authProviders, p)
+	}
 }
 
 // NewServer returns a new *ssh.Server configured to serve Soft Serve. The SSH
@@ -32,17 +130,135 @@ type Server struct {
 // key can be provided with authKey. If authKey is provided, access will be
 // restricted to that key. If authKey is not provided, the server will be
 // publicly writable until configured otherwise by cloning the `config` repo.
+//
+// It exits on errors. Use New to handle them, or to embed the server.
 func NewServer(cfg *config.Config) *Server {
-	ac, err := appCfg.NewConfig(cfg)
+	s, err := New(WithConfig(cfg))
 	if err != nil {
 		log.Fatal(err)
 	}
+	return s
+}
+
+// New returns a new Soft Serve server configured with opts, to be run with
+// Start.
+func New(opts ...Option) (*Server, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	cfg := o.cfg
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	logFormatter, logLevel, err := cfg.LogOptions()
+	if err != nil {
+		return nil, err
+	}
+	var logOutput io.Writer = os.Stderr
+	if cfg.LogPath != "" {
+		f, err := os.OpenFile(cfg.LogPath, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		// The file stays open for the life of the process, as the default
+		// logger writes to it.
+		logOutput = f
+	}
+	log.SetFormatter(logFormatter)
+	log.SetLevel(logLevel)
+	log.SetOutput(logOutput)
+	var ac *appCfg.Config
+	source := o.source
+	if cfg.Chaos != "" {
+		if !chaos.Enabled {
+			log.Warn("ignoring SOFT_SERVE_CHAOS, build with the chaos tag to inject faults")
+		} else {
+			i, err := chaos.Parse(cfg.Chaos)
+			if err != nil {
+				return nil, err
+			}
+			if source == nil {
+				rs := appCfg.NewRepoSource(cfg.RepoPath)
+				rs.Mailmap = cfg.MailmapPath
+				source = rs
+			}
+			log.Warn("injecting faults into storage and git operations", "chaos", cfg.Chaos)
+			source = chaos.Source(source, i)
+		}
+	}
+	if source != nil {
+		ac, err = appCfg.NewConfigWithSource(cfg, source)
+	} else {
+		ac, err = appCfg.NewConfig(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ac.AuthProviders = o.authProviders
+	if ac.Secrets != nil {
+		log.SetOutput(ac.Secrets.Redactor(logOutput))
+	}
 	mw := []wish.Middleware{
 		rm.MiddlewareWithLogger(
 			cfg.ErrorLog,
 			softMiddleware(ac),
 			bm.MiddlewareWithProgramHandler(SessionHandler(ac), termenv.ANSI256),
-			gm.Middleware(cfg.RepoPath, ac),
+			func(sh ssh.Handler) ssh.Handler {
+				return func(s ssh.Session) {
+					gm.Middleware(ac.Source.Dir(), connHooks{ac, s.Context()})(sh)(s)
+				}
+			},
+			// Hold off repo maintenance, such as gc, while a push is
+			// writing objects and updating refs.
+			func(sh ssh.Handler) ssh.Handler {
+				return func(s ssh.Session) {
+					cmds := s.Command()
+					if len(cmds) == 2 && cmds[0] == "git-receive-pack" {
+						repo := strings.TrimSuffix(strings.TrimPrefix(cmds[1], "/"), "/")
+						repo = strings.TrimSuffix(filepath.Clean(repo), ".git")
+						if m := ac.ActiveMaintenance(repo, time.Now()); m != nil {
+							wish.Fatalln(s, m.Error())
+							return
+						}
+						if err := quotaExceeded(s.Context(), ac, repo); err != nil {
+							wish.Fatalln(s, err)
+							return
+						}
+						defer ac.Source.LockPush(repo)()
+						s.Context().SetValue(refHashesCtxKey{}, ac.RefHashes(repo))
+						createPushedRepo(ac, s, repo)
+						// Check the commands of the push against the
+						// branch protection rules before receive-pack
+						// gets them.
+						pc := newPushCommands(ac, repo, s.PublicKey(), s)
+						s.Context().SetValue(pushCommandsCtxKey{}, pc)
+						// Warn about the quota of the repo once the
+						// push is done, on the side-band if the client
+						// asked for one, or on stderr otherwise.
+						sb := &sidebandWriter{w: s}
+						sh(sidebandSession{pushSession{pushInputSession{s, pc}}, sb})
+						if pc.rejected() {
+							ctxLogger(s.Context()).Info("push rejected by branch protection", "repo", repo, "violations", pc.violations)
+							if sent, err := pc.reject(s); err == nil && !sent {
+								for _, v := range pc.violations {
+									fmt.Fprintf(s.Stderr(), "error: %s\n", v)
+								}
+							}
+							// Clients read the status of the push once
+							// they're done sending the pack.
+							_, _ = io.Copy(io.Discard, s)
+							return
+						}
+						msg := quotaWarning(s.Context(), ac, repo)
+						if sent, err := sb.finish(msg); err == nil && !sent && msg != "" {
+							fmt.Fprintln(s.Stderr(), msg)
+						}
+						return
+					}
+					sh(s)
+				}
+			},
 			// Note: disable pushing to subdirectories as it can create
 			// conflicts with existing repos. This only affects the git
 			// middleware.
@@ -64,7 +280,12 @@ func NewServer(cfg *config.Config) *Server {
 					sh(s)
 				}
 			},
-			lm.MiddlewareWithLogger(log.StandardLog(log.StandardLogOptions{ForceLevel: log.DebugLevel})),
+			// Routed repos are passed through to their backend before
+			// the checks of local repos, so they can have subdirectories.
+			routeMiddleware(ac, cfg.KeyPath),
+			metricsMiddleware(ac),
+			sessionsMiddleware(ac),
+			logMiddleware(),
 		),
 	}
 	s, err := wish.NewServer(
@@ -75,13 +296,74 @@ func NewServer(cfg *config.Config) *Server {
 		wish.WithMiddleware(mw...),
 	)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
+	}
+	var fwd *events.Forwarder
+	if cfg.EventsAddress != "" {
+		fwd, err = events.NewForwarder(cfg.EventsAddress, events.Format(cfg.EventsFormat))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var bs *backup.Scheduler
+	if cfg.BackupTarget != "" {
+		t, err := backup.NewTarget(cfg.BackupTarget)
+		if err != nil {
+			return nil, err
+		}
+		bs, err = backup.NewScheduler(ac, t)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.BackupInterval > 0 {
+			bs.Interval = cfg.BackupInterval
+		}
+		if cfg.BackupVerify > 0 {
+			bs.VerifyInterval = cfg.BackupVerify
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if fwd != nil {
+		go fwd.Run(ctx, ac.Events)
+	}
+	if bs != nil {
+		go bs.Run(ctx)
 	}
-	return &Server{
-		SSHServer: s,
-		Config:    cfg,
-		config:    ac,
+	go events.NewStore(retention.Dir(cfg.DataPath, retention.Events)).Run(ctx, ac.Events)
+	go audit.NewLog(cfg.DataPath).Run(ctx, ac.Events)
+	go ac.RecordNotifications(ctx)
+	go ci.NewClient().Run(ctx, ac)
+	go hooks.NewRunner().Run(ctx, ac)
+	rs := retention.NewScheduler(ac, cfg.DataPath)
+	if cfg.RetentionEvery > 0 {
+		rs.Interval = cfg.RetentionEvery
 	}
+	go rs.Run(ctx)
+	go expiry.NewScheduler(ac).Run(ctx)
+	go mirror.NewScheduler(ac).Run(ctx)
+	go mirror.NewPusher().Run(ctx, ac)
+	go housekeeping.NewScheduler(ac).Run(ctx)
+	srv := &Server{
+		SSHServer:    s,
+		Config:       cfg,
+		config:       ac,
+		cancel:       cancel,
+		sshListener:  o.sshListener,
+		httpListener: o.httpListener,
+		gitListener:  o.gitListener,
+	}
+	if cfg.HTTPPort != 0 || o.httpListener != nil {
+		srv.HTTPServer = newHTTPServer(cfg, ac)
+		go srv.HTTPServer.Handler.(*httpHandler).Run(ctx)
+	}
+	if cfg.GitPort != 0 || o.gitListener != nil {
+		srv.gitDaemon = newGitDaemon(fmt.Sprintf("%s:%d", cfg.BindAddr, cfg.GitPort), ac)
+	}
+	if cfg.MetricsPort != 0 || o.metricsListener != nil {
+		srv.MetricsServer = newMetricsServer(cfg)
+		srv.metricsListener = o.metricsListener
+	}
+	return srv, nil
 }
 
 // Reload reloads the server configuration.
@@ -89,12 +371,66 @@ func (srv *Server) Reload() error {
 	return srv.config.Reload()
 }
 
-// Start starts the SSH server.
+// Start starts the SSH, HTTP, metrics, and git daemon servers.
 func (srv *Server) Start() error {
-	if err := srv.SSHServer.ListenAndServe(); err != ssh.ErrServerClosed {
-		return err
+	var g errgroup.Group
+	g.Go(func() error {
+		if srv.sshListener != nil {
+			return srv.Serve(srv.sshListener)
+		}
+		if err := srv.SSHServer.ListenAndServe(); err != ssh.ErrServerClosed {
+			return err
+		}
+		return nil
+	})
+	if srv.HTTPServer != nil {
+		g.Go(func() error {
+			var err error
+			switch tls := srv.HTTPServer.TLSConfig != nil; {
+			case srv.httpListener != nil && tls:
+				err = srv.HTTPServer.ServeTLS(srv.httpListener, "", "")
+			case srv.httpListener != nil:
+				err = srv.HTTPServer.Serve(srv.httpListener)
+			case tls:
+				err = srv.HTTPServer.ListenAndServeTLS("", "")
+			default:
+				err = srv.HTTPServer.ListenAndServe()
+			}
+			if err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
 	}
-	return nil
+	if srv.MetricsServer != nil {
+		g.Go(func() error {
+			var err error
+			if srv.metricsListener != nil {
+				err = srv.MetricsServer.Serve(srv.metricsListener)
+			} else {
+				err = srv.MetricsServer.ListenAndServe()
+			}
+			if err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+	}
+	if srv.gitDaemon != nil {
+		g.Go(func() error {
+			var err error
+			if srv.gitListener != nil {
+				err = srv.gitDaemon.Serve(srv.gitListener)
+			} else {
+				err = srv.gitDaemon.ListenAndServe()
+			}
+			if err != errDaemonClosed {
+				return err
+			}
+			return nil
+		})
+	}
+	return g.Wait()
 }
 
 // Serve serves the SSH server using the provided listener.
@@ -107,10 +443,50 @@ func (srv *Server) Serve(l net.Listener) error {
 
 // Shutdown lets the server gracefully shutdown.
 func (srv *Server) Shutdown(ctx context.Context) error {
-	return srv.SSHServer.Shutdown(ctx)
+	srv.cancel()
+	if srv.HTTPServer != nil {
+		if err := srv.HTTPServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if srv.MetricsServer != nil {
+		if err := srv.MetricsServer.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if srv.gitDaemon != nil {
+		if err := srv.gitDaemon.Shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	if err := srv.SSHServer.Shutdown(ctx); err != nil {
+		return err
+	}
+	srv.config.WaitPushes()
+	return nil
 }
 
-// Close closes the SSH server.
+// Close closes the SSH server, and waits for the pushes being processed.
 func (srv *Server) Close() error {
-	return srv.SSHServer.Close()
+	srv.cancel()
+	if srv.HTTPServer != nil {
+		if err := srv.HTTPServer.Close(); err != nil {
+			return err
+		}
+	}
+	if srv.MetricsServer != nil {
+		if err := srv.MetricsServer.Close(); err != nil {
+			return err
+		}
+	}
+	if srv.gitDaemon != nil {
+		if err := srv.gitDaemon.Close(); err != nil {
+			return err
+		}
+	}
+	if err := srv.SSHServer.Close(); err != nil {
+		return err
+	}
+	srv.config.WaitPushes()
+	return nil
 }
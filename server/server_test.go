@@ -1,13 +1,18 @@
 package server
 
 import (
+	"bytes"
 	"fmt"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/charmbracelet/keygen"
+	appCfg "github.com/charmbracelet/soft-serve/config"
 	"github.com/charmbracelet/soft-serve/server/config"
+	gm "github.com/charmbracelet/wish/git"
 	"github.com/gliderlabs/ssh"
 	"github.com/go-git/go-git/v5"
 	gconfig "github.com/go-git/go-git/v5/config"
@@ -34,6 +39,8 @@ func TestServer(t *testing.T) {
 		os.RemoveAll(testdata)
 	})
 	is := is.New(t)
+	// Audit logs and events are written to the data path.
+	cfg.DataPath = t.TempDir()
 	_, pkPath = createKeyPair(t)
 	s := setupServer(t)
 	err := s.Reload()
@@ -115,3 +122,73 @@ func createKeyPair(t *testing.T) (ssh.PublicKey, string) {
 	is.NoErr(err)
 	return pubkey, filepath.Join(keyDir, "id_ed25519")
 }
+
+// adminProvider grants admin access to a key.
+type adminProvider struct {
+	pk ssh.PublicKey
+}
+
+func (p adminProvider) AccessLevel(repo string, pk ssh.PublicKey) gm.AccessLevel {
+	if pk != nil && ssh.KeysEqual(pk, p.pk) {
+		return gm.AdminAccess
+	}
+	return gm.NoAccess
+}
+
+func TestNewWithOptions(t *testing.T) {
+	is := is.New(t)
+	pk, kp := createKeyPair(t)
+	sshl, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	httpl, err := net.Listen("tcp", "127.0.0.1:0")
+	is.NoErr(err)
+	rs := appCfg.NewRepoSource(t.TempDir())
+	s, err := New(
+		WithConfig(&config.Config{
+			Host:    "localhost",
+			KeyPath: filepath.Join(t.TempDir(), "key"),
+			// The initial admin isn't the key the provider grants admin
+			// access to.
+			InitialAdminKeys: []string{
+				"ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMJlb/qf2B2kMNdBxfpCQqI2ctPcsOkdZGVh5zTRhKtH",
+			},
+			DataPath: t.TempDir(),
+		}),
+		WithSSHListener(sshl),
+		WithHTTPListener(httpl),
+		WithStorage(rs),
+		WithAuthProvider(adminProvider{pk}),
+	)
+	is.NoErr(err)
+	go s.Start()
+	t.Cleanup(func() {
+		s.Close()
+	})
+
+	// The config repo is created in the given storage.
+	_, err = os.Stat(filepath.Join(rs.Path, "config"))
+	is.NoErr(err)
+
+	bts, err := os.ReadFile(kp)
+	is.NoErr(err)
+	signer, err := cssh.ParsePrivateKey(bts)
+	is.NoErr(err)
+	c, err := cssh.Dial("tcp", sshl.Addr().String(), &cssh.ClientConfig{
+		User:            "test",
+		Auth:            []cssh.AuthMethod{cssh.PublicKeys(signer)},
+		HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+	})
+	is.NoErr(err)
+	defer c.Close()
+	sess, err := c.NewSession()
+	is.NoErr(err)
+	defer sess.Close()
+	// Reloading needs admin access.
+	out, err := sess.CombinedOutput("reload")
+	is.NoErr(err)
+	is.True(!bytes.Contains(out, []byte("Unauthorized")))
+
+	resp, err := http.Get("http://" + httpl.Addr().String() + "/")
+	is.NoErr(err)
+	resp.Body.Close()
+}
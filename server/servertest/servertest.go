@@ -0,0 +1,307 @@
+// Package servertest provides a Soft Serve server for end-to-end tests.
+//
+// A test server listens on random local ports and keeps its repos, keys and
+// data in temporary directories that are removed when the test finishes:
+//
+//	s := servertest.New(t)
+//	s.CreateRepo("repo", map[string]string{"README.md": "# Repo"})
+//	out, err := s.Run(s.Admin, "ls repo")
+package servertest
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charmbracelet/keygen"
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/server"
+	"github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/go-git/go-git/v5"
+	gconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	gssh "github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+// Server is a running test server.
+type Server struct {
+	*server.Server
+	// SSHAddr, HTTPAddr, GitAddr, and MetricsAddr are the addresses the
+	// server listens on.
+	SSHAddr     string
+	HTTPAddr    string
+	GitAddr     string
+	MetricsAddr string
+	// Source stores the repos of the server.
+	Source *appCfg.RepoSource
+	// Admin is a key with admin access.
+	Admin *Key
+
+	t testing.TB
+}
+
+// Key is an SSH key pair used to connect to a test server.
+type Key struct {
+	// Path is the path of the private key.
+	Path      string
+	PublicKey ssh.PublicKey
+	Signer    cssh.Signer
+}
+
+// AuthorizedKey returns the public key in authorized_keys format, as used
+// in the server configuration.
+func (k *Key) AuthorizedKey() string {
+	return string(bytes.TrimSpace(cssh.MarshalAuthorizedKey(k.PublicKey)))
+}
+
+// New starts a test server and stops it when the test finishes. The options
+// are applied after the ones servertest sets, so they can override them;
+// note that WithConfig replaces the configuration that grants Admin access.
+func New(t testing.TB, opts ...server.Option) *Server {
+	t.Helper()
+	sshl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gitl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	metricsl, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		SSHAddr:     sshl.Addr().String(),
+		HTTPAddr:    httpl.Addr().String(),
+		GitAddr:     gitl.Addr().String(),
+		MetricsAddr: metricsl.Addr().String(),
+		Source:      appCfg.NewRepoSource(t.TempDir()),
+		t:           t,
+	}
+	s.Admin = NewKey(t)
+	dir := t.TempDir()
+	cfg := &config.Config{
+		Host:             "127.0.0.1",
+		Port:             sshl.Addr().(*net.TCPAddr).Port,
+		HTTPPort:         httpl.Addr().(*net.TCPAddr).Port,
+		GitPort:          gitl.Addr().(*net.TCPAddr).Port,
+		KeyPath:          filepath.Join(dir, "ssh", "soft_serve_server_ed25519"),
+		RepoPath:         s.Source.Path,
+		InitialAdminKeys: []string{s.Admin.AuthorizedKey()},
+		SecretsPath:      filepath.Join(dir, "secrets.json"),
+		SecretsKeyPath:   filepath.Join(dir, "secrets_key"),
+		DataPath:         filepath.Join(dir, "data"),
+		CommitterName:    "Soft Serve Server",
+		CommitterEmail:   "vt100@charm.sh",
+	}
+	opts = append([]server.Option{
+		server.WithConfig(cfg),
+		server.WithSSHListener(sshl),
+		server.WithHTTPListener(httpl),
+		server.WithGitListener(gitl),
+		server.WithMetricsListener(metricsl),
+		server.WithStorage(s.Source),
+	}, opts...)
+	srv, err := server.New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Server = srv
+	go srv.Start()
+	t.Cleanup(func() {
+		srv.Close()
+	})
+	return s
+}
+
+// NewKey generates a key pair whose private key is removed when the test
+// finishes.
+func NewKey(t testing.TB) *Key {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "id")
+	kp, err := keygen.NewWithWrite(path, nil, keygen.Ed25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, _, _, err := ssh.ParseAuthorizedKey(kp.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path += "_ed25519"
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := cssh.ParsePrivateKey(bts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Key{Path: path, PublicKey: pk, Signer: signer}
+}
+
+// CloneURL returns the SSH URL of a repo.
+func (s *Server) CloneURL(repo string) string {
+	return fmt.Sprintf("ssh://%s/%s", s.SSHAddr, repo)
+}
+
+// Dial opens an SSH connection as the given key. The connection is closed
+// when the test finishes.
+func (s *Server) Dial(k *Key) *cssh.Client {
+	s.t.Helper()
+	c, err := cssh.Dial("tcp", s.SSHAddr, &cssh.ClientConfig{
+		User:            "test",
+		Auth:            []cssh.AuthMethod{cssh.PublicKeys(k.Signer)},
+		HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.t.Cleanup(func() {
+		c.Close()
+	})
+	return c
+}
+
+// Session opens an SSH session as the given key. The session is closed when
+// the test finishes.
+func (s *Server) Session(k *Key) *cssh.Session {
+	s.t.Helper()
+	sess, err := s.Dial(k).NewSession()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	s.t.Cleanup(func() {
+		sess.Close()
+	})
+	return sess
+}
+
+// Run runs a command as the given key and returns its combined output. The
+// error is a *ssh.ExitError if the command fails.
+func (s *Server) Run(k *Key, cmd string) (string, error) {
+	s.t.Helper()
+	out, err := s.Session(k).CombinedOutput(cmd)
+	return string(out), err
+}
+
+// CreateRepo creates a repo. If files is not empty, they are pushed to the
+// repo's default branch as the admin in a single commit.
+func (s *Server) CreateRepo(name string, files map[string]string) *appCfg.Repo {
+	s.t.Helper()
+	if _, err := s.Source.InitRepo(name, true); err != nil {
+		s.t.Fatal(err)
+	}
+	if len(files) > 0 {
+		if err := s.Push(s.Admin, name, files); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	if err := s.Reload(); err != nil {
+		s.t.Fatal(err)
+	}
+	r, err := s.Source.GetRepo(name)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return r
+}
+
+// Push pushes a commit that writes files to the master branch of a repo as
+// the given key. The repo is created if it doesn't exist.
+func (s *Server) Push(k *Key, repo string, files map[string]string) error {
+	s.t.Helper()
+	dir := s.t.TempDir()
+	auth := s.auth(k)
+	r, err := git.PlainClone(dir, false, &git.CloneOptions{
+		URL:  s.CloneURL(repo),
+		Auth: auth,
+	})
+	if err != nil {
+		// The repo is empty or doesn't exist yet.
+		r, err = git.PlainInit(dir, false)
+		if err != nil {
+			s.t.Fatal(err)
+		}
+		if _, err := r.CreateRemote(&gconfig.RemoteConfig{
+			Name: "origin",
+			URLs: []string{s.CloneURL(repo)},
+		}); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			s.t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			s.t.Fatal(err)
+		}
+		if _, err := wt.Add(name); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com"}
+	if _, err := wt.Commit("test commit", &git.CommitOptions{
+		Author:    sig,
+		Committer: sig,
+	}); err != nil {
+		s.t.Fatal(err)
+	}
+	if err := r.Push(&git.PushOptions{
+		RemoteName: "origin",
+		Auth:       auth,
+	}); err != nil {
+		return err
+	}
+	// The server reloads in the background after a push; reload now so the
+	// push is visible as soon as Push returns.
+	return s.Reload()
+}
+
+// HostKey returns the SSH host key of the server in authorized_keys format.
+func (s *Server) HostKey() string {
+	s.t.Helper()
+	bts, err := os.ReadFile(s.Config.KeyPath)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	signer, err := cssh.ParsePrivateKey(bts)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return string(bytes.TrimSpace(cssh.MarshalAuthorizedKey(signer.PublicKey())))
+}
+
+// Clone clones a repo as the given key into a temporary directory.
+func (s *Server) Clone(k *Key, repo string) (*git.Repository, error) {
+	s.t.Helper()
+	return git.PlainClone(s.t.TempDir(), false, &git.CloneOptions{
+		URL:  s.CloneURL(repo),
+		Auth: s.auth(k),
+	})
+}
+
+func (s *Server) auth(k *Key) *gssh.PublicKeys {
+	return &gssh.PublicKeys{
+		User:   "git",
+		Signer: k.Signer,
+		HostKeyCallbackHelper: gssh.HostKeyCallbackHelper{
+			HostKeyCallback: cssh.InsecureIgnoreHostKey(),
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package servertest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+func TestServer(t *testing.T) {
+	is := is.New(t)
+	s := New(t)
+
+	s.CreateRepo("repo", map[string]string{
+		"README.md":   "# Repo\n",
+		"docs/foo.md": "foo\n",
+	})
+	out, err := s.Run(s.Admin, "ls repo/docs")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "foo.md"))
+
+	r, err := s.Clone(s.Admin, "repo")
+	is.NoErr(err)
+	head, err := r.Head()
+	is.NoErr(err)
+	is.Equal(head.Name().Short(), "master")
+
+	// Pushing to a missing repo creates it.
+	is.NoErr(s.Push(s.Admin, "pushed", map[string]string{"a": "a"}))
+	out, err = s.Run(s.Admin, "ls")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "pushed"))
+
+	// Other keys get the anonymous access level, read-only.
+	k := NewKey(t)
+	_, err = s.Clone(k, "repo")
+	is.NoErr(err)
+	is.True(s.Push(k, "repo", map[string]string{"b": "b"}) != nil)
+	_, err = s.Run(k, "reload")
+	var ee *cssh.ExitError
+	is.True(errors.As(err, &ee))
+}
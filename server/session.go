@@ -2,6 +2,7 @@ package server
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/aymanbagabas/go-osc52"
 	tea "github.com/charmbracelet/bubbletea"
@@ -27,9 +28,16 @@ func SessionHandler(ac *appCfg.Config) bm.ProgramHandler {
 		}
 		cmd := s.Command()
 		initialRepo := ""
+		initialPath := ""
 		if len(cmd) == 1 {
-			initialRepo = cmd[0]
-			auth := ac.AuthRepo(initialRepo, s.PublicKey())
+			// The argument can point to a directory in the repository, as in
+			// repo/path/to/dir.
+			initialRepo = strings.Trim(cmd[0], "/")
+			if i := strings.Index(initialRepo, "/"); i >= 0 {
+				initialPath = strings.Trim(initialRepo[i+1:], "/")
+				initialRepo = initialRepo[:i]
+			}
+			auth := ac.AuthRepoCtx(s.Context(), initialRepo, s.PublicKey())
 			if auth < gm.ReadOnlyAccess {
 				wish.Fatalln(s, cm.ErrUnauthorized)
 				return nil
@@ -54,6 +62,7 @@ func SessionHandler(ac *appCfg.Config) bm.ProgramHandler {
 			s,
 			c,
 			initialRepo,
+			initialPath,
 		)
 		p := tea.NewProgram(m,
 			tea.WithInput(s),
@@ -62,6 +71,11 @@ func SessionHandler(ac *appCfg.Config) bm.ProgramHandler {
 			tea.WithoutCatchPanics(),
 			tea.WithMouseCellMotion(),
 		)
+		// Show messages from admins in the TUI rather than over it.
+		ac.SetSessionNotifier(sessionID(s.Context()), func(msg string) error {
+			go p.Send(ui.MessageMsg(msg))
+			return nil
+		})
 		return p
 	}
 }
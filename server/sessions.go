@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// sessionsMiddleware tracks the connected sessions, with the bytes they
+// transferred, so that admins can list them, message their users, and
+// terminate them. The bytes are added to the bandwidth usage of the user
+// once the session ends.
+func sessionsMiddleware(ac *appCfg.Config) func(ssh.Handler) ssh.Handler {
+	return func(sh ssh.Handler) ssh.Handler {
+		return func(s ssh.Session) {
+			cs := &countingSession{Session: s}
+			info := appCfg.Session{
+				ID:         sessionID(s.Context()),
+				RemoteAddr: s.RemoteAddr().String(),
+				Start:      time.Now(),
+			}
+			info.Operation, info.Repo = sessionOperation(s)
+			untrack := ac.TrackSession(info, s.PublicKey(), &cs.in, &cs.out,
+				func(msg string) error {
+					_, err := fmt.Fprintf(s.Stderr(), "\r\n%s\r\n", msg)
+					return err
+				},
+				func() error {
+					ctxLogger(s.Context()).Info("terminating session")
+					conn, ok := s.Context().Value(ssh.ContextKeyConn).(gossh.Conn)
+					if !ok {
+						return errors.New("session has no connection")
+					}
+					// The connection can close before the session ends.
+					if err := conn.Close(); !errors.Is(err, net.ErrClosed) {
+						return err
+					}
+					return appCfg.ErrUnknownSession
+				},
+			)
+			defer untrack()
+			sh(cs)
+			ac.RecordBandwidth(s.PublicKey(), info.Repo, atomic.LoadInt64(&cs.in), atomic.LoadInt64(&cs.out))
+		}
+	}
+}
+
+// sessionOperation returns what a session runs, as listed to admins, and the
+// repository it runs on, if any.
+func sessionOperation(s ssh.Session) (string, string) {
+	cmds := s.Command()
+	if _, _, pty := s.Pty(); pty && len(cmds) <= 1 {
+		if len(cmds) == 1 {
+			return "tui", strings.Split(strings.Trim(cmds[0], "/"), "/")[0]
+		}
+		return "tui", ""
+	}
+	if len(cmds) == 2 && strings.HasPrefix(cmds[0], "git-") {
+		repo := strings.TrimSuffix(strings.TrimPrefix(cmds[1], "/"), "/")
+		return cmds[0], strings.TrimSuffix(filepath.Clean(repo), ".git")
+	}
+	return strings.Join(cmds, " "), ""
+}
@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+// syncBuffer is a buffer safe for concurrent use.
+type syncBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+func TestSessions(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+
+	// upload-pack waits for the wants of the client after advertising the
+	// refs, keeping the session open.
+	clone := s.Session(s.Admin)
+	var stdout, stderr syncBuffer
+	clone.Stdout = &stdout
+	clone.Stderr = &stderr
+	stdin, err := clone.StdinPipe()
+	is.NoErr(err)
+	defer stdin.Close()
+	is.NoErr(clone.Start("git-upload-pack repo"))
+	done := make(chan error, 1)
+	go func() {
+		done <- clone.Wait()
+	}()
+
+	var ses appCfg.Session
+	for i := 0; i < 100 && ses.ID == ""; i++ {
+		out, err := s.Run(s.Admin, "sessions list --json")
+		is.NoErr(err)
+		var ss []appCfg.Session
+		is.NoErr(json.Unmarshal([]byte(out), &ss))
+		for _, x := range ss {
+			if x.Operation == "git-upload-pack" && x.Out > 0 {
+				ses = x
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	is.True(ses.ID != "")
+	is.Equal(ses.Repo, "repo")
+	is.True(strings.HasPrefix(ses.Key, "SHA256:"))
+
+	_, err = s.Run(s.Admin, "sessions message "+ses.ID+" please stop")
+	is.NoErr(err)
+	for i := 0; i < 100 && !strings.Contains(stderr.String(), "please stop"); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	is.True(strings.Contains(stderr.String(), "Message from "))
+	is.True(strings.Contains(stderr.String(), ": please stop"))
+
+	_, err = s.Run(s.Admin, "sessions terminate "+ses.ID)
+	is.NoErr(err)
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("session wasn't terminated")
+	}
+	out, err := s.Run(s.Admin, "sessions terminate "+ses.ID)
+	is.True(err != nil) // the session is gone
+	is.True(strings.Contains(out, "Session not found"))
+}
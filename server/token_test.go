@@ -0,0 +1,89 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	ghttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/matryer/is"
+)
+
+func TestTokens(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	url := fmt.Sprintf("http://%s/repo.git", s.HTTPAddr)
+
+	out, err := s.Run(s.Admin, "token create ci")
+	is.NoErr(err)
+	ro := strings.TrimSpace(out)
+	out, err = s.Run(s.Admin, "token create admin --scope admin-access")
+	is.NoErr(err)
+	admin := strings.TrimSpace(out)
+	_, err = s.Run(s.Admin, "token create bad --scope owner")
+	is.True(err != nil) // invalid scope
+	_, err = s.Run(servertest.NewKey(t), "token create ci")
+	is.True(err != nil) // unknown user
+
+	out, err = s.Run(s.Admin, "token list")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "ci"))
+	is.True(strings.Contains(out, "admin-access"))
+	is.True(!strings.Contains(out, ro)) // tokens are only shown once
+
+	// Read-only tokens can clone private repos, but not push.
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:  fmt.Sprintf("http://%s/config", s.HTTPAddr),
+		Auth: &ghttp.BasicAuth{Username: "ci", Password: ro},
+	})
+	is.NoErr(err)
+	r, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:  url,
+		Auth: &ghttp.BasicAuth{Username: "ci", Password: ro},
+	})
+	is.NoErr(err)
+	wt, err := r.Worktree()
+	is.NoErr(err)
+	sig := &object.Signature{Name: "test", Email: "test@example.com"}
+	_, err = wt.Commit("empty commit", &git.CommitOptions{
+		Author:            sig,
+		Committer:         sig,
+		AllowEmptyCommits: true,
+	})
+	is.NoErr(err)
+	err = r.Push(&git.PushOptions{Auth: &ghttp.BasicAuth{Username: "ci", Password: ro}})
+	is.Equal(err, transport.ErrAuthorizationFailed)
+	is.NoErr(r.Push(&git.PushOptions{Auth: &ghttp.TokenAuth{Token: admin}}))
+
+	// Only admin-access tokens can use the API.
+	api := func(token string) int {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s/api/v1/repos", s.HTTPAddr), nil)
+		is.NoErr(err)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res, err := http.DefaultClient.Do(req)
+		is.NoErr(err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	is.Equal(api(ro), http.StatusForbidden)
+	is.Equal(api(admin), http.StatusOK)
+
+	// Revoked tokens stop authenticating.
+	_, err = s.Run(s.Admin, "token revoke ci")
+	is.NoErr(err)
+	_, err = git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:  fmt.Sprintf("http://%s/config", s.HTTPAddr),
+		Auth: &ghttp.BasicAuth{Username: "ci", Password: ro},
+	})
+	is.Equal(err, transport.ErrAuthenticationRequired)
+	_, err = s.Run(s.Admin, "token revoke ci")
+	is.True(err != nil)
+}
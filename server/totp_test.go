@@ -0,0 +1,139 @@
+package server_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha1" // nolint: gosec
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	cm "github.com/charmbracelet/soft-serve/server/cmd"
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+	cssh "golang.org/x/crypto/ssh"
+)
+
+// totpCode returns the code of a base32-encoded TOTP key, steps time steps
+// from now.
+func totpCode(t *testing.T, secret string, steps int64) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(time.Now().Unix()/30+steps))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0xf
+	return fmt.Sprintf("%06d", (binary.BigEndian.Uint32(sum[off:off+4])&0x7fffffff)%1000000)
+}
+
+func TestTOTP(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	frankie := servertest.NewKey(t)
+	_, err := s.Run(s.Admin, "user create Frankie --key \""+strings.TrimSpace(frankie.AuthorizedKey())+"\"")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "user grant Frankie user-admin")
+	is.NoErr(err)
+	for _, u := range []string{"Bea", "Sam", "Kim"} {
+		_, err = s.Run(s.Admin, "user create "+u)
+		is.NoErr(err)
+	}
+
+	out, err := s.Run(frankie, "totp enroll")
+	is.NoErr(err)
+	secret := strings.SplitN(out, "\n", 2)[0]
+	_, err = s.Run(frankie, "totp confirm "+totpCode(t, secret, 0))
+	is.NoErr(err)
+	out, err = s.Run(frankie, "totp status")
+	is.NoErr(err)
+	is.Equal(out, "enabled, 0 recovery codes left\n")
+
+	// Dangerous commands need a code once enrolled.
+	var ee *cssh.ExitError
+	out, err = s.Run(frankie, "user delete Bea --json")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusUnauthorized)
+	is.True(strings.Contains(out, `"code":"otp_required"`))
+	_, err = s.Run(frankie, "user delete Bea --otp 123")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusUnauthorized)
+	_, err = s.Run(frankie, "user delete Bea --otp "+totpCode(t, secret, 1))
+	is.NoErr(err)
+
+	// Admins hand out recovery codes, each usable once.
+	out, err = s.Run(s.Admin, "totp recovery-codes Frankie")
+	is.NoErr(err)
+	codes := strings.Fields(out)
+	is.Equal(len(codes), 10)
+	_, err = s.Run(frankie, "user delete Sam --otp "+codes[0])
+	is.NoErr(err)
+	_, err = s.Run(frankie, "user delete Kim --otp "+codes[0])
+	is.True(err != nil)
+	out, err = s.Run(s.Admin, "audit")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "user delete Sam --otp ***"))
+	is.True(!strings.Contains(out, codes[0]))
+
+	// Resetting lets the user run dangerous commands without codes again.
+	_, err = s.Run(s.Admin, "totp reset Frankie")
+	is.NoErr(err)
+	_, err = s.Run(frankie, "user delete Kim")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "totp reset Frankie")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusNotFound)
+}
+
+func TestReason(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	is.NoErr(s.Push(s.Admin, "config", map[string]string{
+		"config.yaml": fmt.Sprintf(`require-reason: true
+users:
+  - name: admin
+    admin: true
+    public-keys:
+      - %s
+`, s.Admin.AuthorizedKey()),
+	}))
+	_, err := s.Run(s.Admin, "user create Frankie")
+	is.NoErr(err)
+
+	var ee *cssh.ExitError
+	out, err := s.Run(s.Admin, "user delete Frankie --json")
+	is.True(errors.As(err, &ee))
+	is.Equal(ee.ExitStatus(), cm.StatusInvalidArgument)
+	is.True(strings.Contains(out, `"code":"reason_required"`))
+	_, err = s.Run(s.Admin, `user delete Frankie --reason " "`)
+	is.True(errors.As(err, &ee))
+	_, err = s.Run(s.Admin, `user delete Frankie --reason ticket-123`)
+	is.NoErr(err)
+
+	out, err = s.Run(s.Admin, "audit")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "user delete Frankie --reason ticket-123\treason: ticket-123\n"))
+	is.True(strings.Contains(out, "\terror: "+cm.ErrReasonRequired.Error()))
+
+	// Commands only dangerous with some flags need a reason with them.
+	for cmd, dangerous := range map[string]bool{
+		"orphans":             false,
+		"orphans --remove":    true,
+		"retention --dry-run": false,
+		"retention":           true,
+		"token revoke api":    true,
+		"maintenance start":   true,
+		"maintenance list":    false,
+		"acl import":          true,
+	} {
+		out, _ := s.Run(s.Admin, cmd+" --json")
+		is.Equal(strings.Contains(out, `"code":"reason_required"`), dangerous) // reason required
+	}
+}
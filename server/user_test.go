@@ -0,0 +1,118 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/server/servertest"
+	"github.com/matryer/is"
+)
+
+func TestRoles(t *testing.T) {
+	is := is.New(t)
+	s := servertest.New(t)
+	s.CreateRepo("repo", map[string]string{"README.md": "# Repo\n"})
+	frankie, bea := servertest.NewKey(t), servertest.NewKey(t)
+	key := func(k *servertest.Key) string { return strings.TrimSpace(k.AuthorizedKey()) }
+
+	// Admins grant roles.
+	_, err := s.Run(s.Admin, "user create Frankie")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "user add-key Frankie "+key(frankie))
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "user grant Frankie owner")
+	is.True(err != nil) // invalid role
+	_, err = s.Run(s.Admin, "user grant Frankie user-admin")
+	is.NoErr(err)
+
+	// User admins manage users, but can't grant roles or change admins and
+	// users with roles.
+	_, err = s.Run(frankie, "user create Bea")
+	is.NoErr(err)
+	_, err = s.Run(frankie, "user add-key Bea "+key(bea))
+	is.NoErr(err)
+	_, err = s.Run(frankie, "user add-key Bea "+key(s.Admin))
+	is.True(err != nil) // key of another user
+	_, err = s.Run(frankie, "user grant Bea auditor")
+	is.True(err != nil)
+	_, err = s.Run(frankie, "user add-key admin "+key(servertest.NewKey(t)))
+	is.True(err != nil)
+	_, err = s.Run(frankie, "collab add repo Bea")
+	is.NoErr(err)
+	// Collaborators of the config repo could make themselves admins.
+	_, err = s.Run(frankie, "collab add config Bea")
+	is.True(err != nil)
+	_, err = s.Run(frankie, "secret list")
+	is.True(err != nil)
+	_, err = s.Run(frankie, "gc repo")
+	is.True(err != nil)
+	out, err := s.Run(frankie, "user list")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "Bea"))
+	is.True(strings.Contains(out, "user-admin"))
+	_, err = s.Run(bea, "user list")
+	is.True(err != nil)
+
+	// Auditors read the audit log, but can't change anything.
+	_, err = s.Run(s.Admin, "user grant Bea auditor")
+	is.NoErr(err)
+	out, err = s.Run(bea, "audit")
+	is.NoErr(err)
+	is.True(strings.Contains(out, "user grant Bea auditor"))
+	_, err = s.Run(bea, "sessions list")
+	is.NoErr(err)
+	_, err = s.Run(bea, "sessions terminate 0")
+	is.True(err != nil)
+	_, err = s.Run(bea, "acl import")
+	is.True(err != nil)
+	_, err = s.Run(frankie, "user delete Bea")
+	is.True(err != nil) // Bea has a role now
+
+	// Repo admins have admin access to repos, but not to the config repo.
+	_, err = s.Run(s.Admin, "user revoke Bea auditor")
+	is.NoErr(err)
+	_, err = s.Run(s.Admin, "user grant Bea repo-admin")
+	is.NoErr(err)
+	_, err = s.Run(bea, "gc repo")
+	is.NoErr(err)
+	// Raw git commands can reach the config repo, whatever the repo.
+	_, err = s.Run(bea, "git repo log --oneline")
+	is.True(err != nil)
+	_, err = s.Run(bea, "git repo -c core.hooksPath=/tmp log --oneline")
+	is.True(err != nil)
+	// Removing orphans deletes data for good, so it's left to config admins.
+	_, err = s.Run(bea, "orphans")
+	is.NoErr(err)
+	out, err = s.Run(bea, "orphans --remove --json")
+	is.True(err != nil)
+	is.True(strings.Contains(out, `"code":"unauthorized"`))
+	is.True(s.Push(bea, "repo", map[string]string{"NEW.md": "new"}) == nil)
+	is.True(s.Push(bea, "config", map[string]string{"config.yaml": "anon-access: admin-access\n"}) != nil)
+
+	// Roles apply to the API too.
+	token, err := s.Run(bea, "token create api --scope admin-access")
+	is.NoErr(err)
+	api := func(method, path string) int {
+		req, err := http.NewRequest(method, fmt.Sprintf("http://%s/api/v1/%s", s.HTTPAddr, path), nil)
+		is.NoErr(err)
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+		res, err := http.DefaultClient.Do(req)
+		is.NoErr(err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+	is.Equal(api(http.MethodGet, "repos"), http.StatusOK)
+	is.Equal(api(http.MethodPut, "repos/repo/collaborators/Frankie"), http.StatusForbidden)
+	is.Equal(api(http.MethodDelete, "repos/config"), http.StatusForbidden)
+	is.Equal(api(http.MethodDelete, "repos/repo"), http.StatusNoContent)
+
+	// User admins can't add collaborators to the config repo over the API
+	// either.
+	token, err = s.Run(frankie, "token create api --scope admin-access")
+	is.NoErr(err)
+	s.CreateRepo("other", nil)
+	is.Equal(api(http.MethodPut, "repos/config/collaborators/Bea"), http.StatusForbidden)
+	is.Equal(api(http.MethodPut, "repos/other/collaborators/Bea"), http.StatusNoContent)
+}
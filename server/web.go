@@ -0,0 +1,424 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/log"
+	appCfg "github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/git"
+	uigit "github.com/charmbracelet/soft-serve/ui/git"
+	gm "github.com/charmbracelet/wish/git"
+	"github.com/dustin/go-humanize"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// webCommitsPerPage is the number of commits per page of the commit log.
+const webCommitsPerPage = 30
+
+// webMarkdown renders readmes. Raw HTML is left out.
+var webMarkdown = goldmark.New(goldmark.WithExtensions(extension.GFM))
+
+// webLayout is the layout of the web UI and its pages, each rendered inside
+// the layout.
+var webLayout = template.Must(template.New("layout").Funcs(template.FuncMap{
+	"pathEscape": escapePath,
+	"humanTime":  humanize.Time,
+	"humanBytes": func(n int64) string { return humanize.Bytes(uint64(n)) },
+	"short":      func(h string) string { return h[:7] },
+	"add":        func(a, b int) int { return a + b },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ if .Repo }}{{ .Repo }} · {{ end }}Soft Serve</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 0 auto; padding: 1rem; color: #222; }
+a { color: #6c50ff; text-decoration: none; }
+a:hover { text-decoration: underline; }
+header { display: flex; gap: 1rem; align-items: baseline; border-bottom: 1px solid #ddd; margin-bottom: 1rem; }
+header h1 { font-size: 1.25rem; }
+header .account { margin-left: auto; }
+nav a { margin-right: 1rem; }
+table { border-collapse: collapse; width: 100%; }
+td { padding: .25rem .5rem; border-bottom: 1px solid #eee; vertical-align: top; }
+td.meta { color: #777; white-space: nowrap; text-align: right; }
+pre, code { font-family: ui-monospace, monospace; font-size: .9rem; }
+pre { background: #f6f6f6; padding: .75rem; overflow-x: auto; }
+.lines td { border: 0; padding: 0 .5rem; white-space: pre; }
+.lines td.num { color: #aaa; text-align: right; user-select: none; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<header>
+<h1><a href="/">Soft Serve</a>{{ if .Repo }} / <a href="/{{ pathEscape .Repo }}/-/">{{ .Repo }}</a>{{ end }}</h1>
+{{ if .Ref }}<nav>
+<a href="/{{ pathEscape .Repo }}/-/tree/{{ pathEscape .Ref }}/">Files</a>
+<a href="/{{ pathEscape .Repo }}/-/commits/{{ pathEscape .Ref }}">Commits</a>
+</nav>{{ end }}
+{{ if .User }}<span class="account">{{ .User }} · <a href="/-/logout">Sign out</a></span>{{ else if .Login }}<span class="account"><a href="{{ .Login }}">Sign in</a></span>{{ end }}
+</header>
+<main>
+{{ template "page" . }}
+</main>
+</body>
+</html>
+{{ define "repos" }}
+{{ if .Repos }}<table>
+{{ range .Repos }}<tr>
+<td><a href="/{{ pathEscape .Name }}/-/">{{ .Name }}</a>{{ with .Description }}<br><span class="muted">{{ . }}</span>{{ end }}</td>
+<td class="meta">{{ if not .Updated.IsZero }}Updated {{ humanTime .Updated }}{{ end }}</td>
+</tr>
+{{ end }}</table>
+{{ else }}<p class="muted">No repositories.</p>{{ end }}
+{{ end }}
+{{ define "repo" }}
+{{ with .Description }}<p>{{ . }}</p>{{ end }}
+<pre>{{ range .CloneURLs }}git clone {{ . }}
+{{ end }}</pre>
+{{ if .Empty }}<p class="muted">This repository is empty.</p>{{ end }}
+{{ with .Readme }}<article>{{ . }}</article>{{ end }}
+{{ end }}
+{{ define "tree" }}
+<p>{{ template "breadcrumbs" . }}</p>
+<table>
+{{ range .Entries }}<tr>
+<td><a href="/{{ pathEscape $.Repo }}/-/{{ if .Dir }}tree{{ else }}blob{{ end }}/{{ pathEscape $.Ref }}/{{ pathEscape .Path }}">{{ .Name }}{{ if .Dir }}/{{ end }}</a></td>
+<td class="meta">{{ if not .Dir }}{{ humanBytes .Size }}{{ end }}</td>
+</tr>
+{{ end }}</table>
+{{ end }}
+{{ define "blob" }}
+<p>{{ template "breadcrumbs" . }} · <a href="/{{ pathEscape .Repo }}/raw/{{ pathEscape .Ref }}/{{ pathEscape .Path }}">Raw</a></p>
+{{ if .Binary }}<p class="muted">Binary file not shown.</p>{{ else }}<pre><table class="lines">
+{{ range $i, $l := .Lines }}<tr><td class="num">{{ add $i 1 }}</td><td>{{ $l }}</td></tr>
+{{ end }}</table></pre>{{ end }}
+{{ end }}
+{{ define "commits" }}
+<table>
+{{ range .Commits }}<tr>
+<td>{{ .Title }}<br><span class="muted">{{ .Author }} committed {{ humanTime .When }}</span></td>
+<td class="meta"><code>{{ short .Hash }}</code></td>
+</tr>
+{{ end }}</table>
+<p>{{ if gt .Page 1 }}<a href="?page={{ add .Page -1 }}">Newer</a> {{ end }}{{ if .More }}<a href="?page={{ add .Page 1 }}">Older</a>{{ end }}</p>
+{{ end }}
+{{ define "breadcrumbs" }}<a href="/{{ pathEscape .Repo }}/-/tree/{{ pathEscape .Ref }}/">{{ .Repo }}</a>{{ range .Crumbs }} / <a href="/{{ pathEscape $.Repo }}/-/tree/{{ pathEscape $.Ref }}/{{ pathEscape .Path }}">{{ .Name }}</a>{{ end }}{{ end }}
+`))
+
+// webTemplates maps the names of the pages of the web UI to their templates.
+var webTemplates = func() map[string]*template.Template {
+	ts := make(map[string]*template.Template)
+	for _, page := range []string{"repos", "repo", "tree", "blob", "commits"} {
+		t := template.Must(webLayout.Clone())
+		ts[page] = template.Must(t.AddParseTree("page", webLayout.Lookup(page).Tree))
+	}
+	return ts
+}()
+
+// webPage is the data of a web UI page.
+type webPage struct {
+	// User is the user signed in, if any.
+	User string
+	// Login is the URL to sign in at, if signing in is enabled.
+	Login string
+
+	Repo string
+	// Ref is the short name of the browsed branch or tag, or a commit hash.
+	Ref string
+
+	// The repo list.
+	Repos []webRepo
+
+	// The repo home.
+	Description string
+	CloneURLs   []string
+	Empty       bool
+	Readme      template.HTML
+
+	// The file tree and blobs.
+	Path    string
+	Crumbs  []webEntry
+	Entries []webEntry
+	Binary  bool
+	Lines   []string
+
+	// The commit log.
+	Commits []webCommit
+	Page    int
+	More    bool
+}
+
+type webRepo struct {
+	Name        string
+	Description string
+	Updated     time.Time
+}
+
+type webEntry struct {
+	Name string
+	Path string
+	Dir  bool
+	Size int64
+}
+
+type webCommit struct {
+	Hash   string
+	Title  string
+	Author string
+	When   time.Time
+}
+
+// serveRepoList serves the list of the repos readable anonymously, or by the
+// signed in user.
+func (h *httpHandler) serveRepoList(w http.ResponseWriter, r *http.Request) {
+	repos := make([]webRepo, 0)
+	_, pk := h.webUser(r)
+	for _, rr := range h.cfg.Source.AllRepos() {
+		if h.cfg.AuthRepo(rr.Repo(), pk) < gm.ReadOnlyAccess || h.cfg.ListingHides(h.cfg.RepoKind(rr.Repo())) {
+			continue
+		}
+		repos = append(repos, webRepo{
+			Name:        rr.Repo(),
+			Description: rr.Description(),
+			Updated:     rr.UpdatedAt(),
+		})
+	}
+	sort.Slice(repos, func(i, j int) bool {
+		return repos[i].Name < repos[j].Name
+	})
+	h.renderWeb(w, r, "repos", webPage{Repos: repos})
+}
+
+// serveWeb serves the web UI page of a repo at rest, one of "" for the repo
+// home, tree/<ref>/<path>, blob/<ref>/<path>, and commits/<ref>.
+func (h *httpHandler) serveWeb(w http.ResponseWriter, r *http.Request, repo, rest string) {
+	rr, err := h.cfg.Source.GetRepo(repo)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	p := webPage{
+		Repo:        repo,
+		Description: rr.Description(),
+	}
+	if head, err := rr.HEAD(); err == nil {
+		p.Ref = webRefName(head)
+	}
+	if rest == "" {
+		h.serveWebHome(w, r, rr, p)
+		return
+	}
+	view, refPath := rest, ""
+	if i := strings.Index(rest, "/"); i >= 0 {
+		view, refPath = rest[:i], rest[i+1:]
+	}
+	ref, fp := resolveWebRef(rr, refPath)
+	if ref == nil {
+		http.NotFound(w, r)
+		return
+	}
+	p.Ref = webRefName(ref)
+	p.Path = fp
+	switch view {
+	case "tree":
+		h.serveWebTree(w, r, rr, ref, p)
+	case "blob":
+		h.serveWebBlob(w, r, rr, ref, p)
+	case "commits":
+		h.serveWebCommits(w, r, rr, ref, p)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *httpHandler) serveWebHome(w http.ResponseWriter, r *http.Request, rr *appCfg.Repo, p webPage) {
+	p.CloneURLs = uigit.CloneURLs(h.cfg, rr.Repo())
+	p.Empty = rr.IsEmpty()
+	if rm, rp := rr.Readme(); rm != "" {
+		switch strings.ToLower(path.Ext(rp)) {
+		case ".md", ".markdown":
+			var b bytes.Buffer
+			if err := webMarkdown.Convert([]byte(rm), &b); err != nil {
+				log.Error("error rendering readme", "repo", rr.Repo(), "err", err)
+			}
+			p.Readme = template.HTML(b.String())
+		default:
+			p.Readme = template.HTML("<pre>" + template.HTMLEscapeString(rm) + "</pre>")
+		}
+	}
+	h.renderWeb(w, r, "repo", p)
+}
+
+func (h *httpHandler) serveWebTree(w http.ResponseWriter, r *http.Request, rr *appCfg.Repo, ref *git.Reference, p webPage) {
+	t, err := rr.Tree(ref, p.Path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	ents, err := t.Entries()
+	if err != nil {
+		h.webError(w, rr.Repo(), err)
+		return
+	}
+	ents.Sort()
+	dirs := make([]webEntry, 0)
+	files := make([]webEntry, 0)
+	for _, e := range ents {
+		we := webEntry{
+			Name: e.Name(),
+			Path: path.Join(p.Path, e.Name()),
+			Dir:  e.IsTree(),
+		}
+		if we.Dir {
+			dirs = append(dirs, we)
+		} else {
+			we.Size = e.Size()
+			files = append(files, we)
+		}
+	}
+	p.Entries = append(dirs, files...)
+	p.Crumbs = webCrumbs(p.Path)
+	h.renderWeb(w, r, "tree", p)
+}
+
+func (h *httpHandler) serveWebBlob(w http.ResponseWriter, r *http.Request, rr *appCfg.Repo, ref *git.Reference, p webPage) {
+	t, err := rr.Tree(ref, "")
+	if err != nil {
+		h.webError(w, rr.Repo(), err)
+		return
+	}
+	e, err := t.TreeEntry(p.Path)
+	if err != nil || e.IsTree() || e.IsCommit() {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := e.Contents()
+	if err != nil {
+		h.webError(w, rr.Repo(), err)
+		return
+	}
+	p.Binary, _ = git.IsBinary(bytes.NewReader(data))
+	if !p.Binary {
+		p.Lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	p.Crumbs = webCrumbs(p.Path)
+	h.renderWeb(w, r, "blob", p)
+}
+
+func (h *httpHandler) serveWebCommits(w http.ResponseWriter, r *http.Request, rr *appCfg.Repo, ref *git.Reference, p webPage) {
+	p.Page = 1
+	if n, err := strconv.Atoi(r.URL.Query().Get("page")); err == nil && n > 0 {
+		p.Page = n
+	}
+	// Ask for one more commit than shown to know if there are older ones.
+	cs, err := rr.CommitsByPage(ref, p.Path, p.Page, webCommitsPerPage+1)
+	if err != nil {
+		h.webError(w, rr.Repo(), err)
+		return
+	}
+	if len(cs) > webCommitsPerPage {
+		cs, p.More = cs[:webCommitsPerPage], true
+	}
+	for _, c := range cs {
+		p.Commits = append(p.Commits, webCommit{
+			Hash:   c.ID.String(),
+			Title:  strings.Split(c.Message, "\n")[0],
+			Author: c.Author.Name,
+			When:   c.Committer.When,
+		})
+	}
+	h.renderWeb(w, r, "commits", p)
+}
+
+// renderWeb renders the named page of the web UI.
+func (h *httpHandler) renderWeb(w http.ResponseWriter, r *http.Request, page string, p webPage) {
+	if _, ok := h.oidcEnabled(); ok {
+		p.User, _ = h.webUser(r)
+		p.Login = "/-/login?next=" + url.QueryEscape(r.URL.RequestURI())
+	}
+	var b bytes.Buffer
+	if err := webTemplates[page].ExecuteTemplate(&b, "layout", p); err != nil {
+		h.webError(w, p.Repo, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = w.Write(b.Bytes())
+}
+
+func (h *httpHandler) webError(w http.ResponseWriter, repo string, err error) {
+	log.Error("error rendering web page", "repo", repo, "err", err)
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+// resolveWebRef splits <ref>/<path> into the reference and the path, like
+// resolveRefPath, but the path may be empty.
+func resolveWebRef(r *appCfg.Repo, refPath string) (*git.Reference, string) {
+	refPath = strings.Trim(refPath, "/")
+	if refPath == "" {
+		ref, err := r.HEAD()
+		if err != nil {
+			return nil, ""
+		}
+		return ref, ""
+	}
+	if ref, err := r.Reference(refPath); err == nil {
+		return ref, ""
+	} else if !errors.Is(err, git.ErrReferenceNotFound) {
+		return nil, ""
+	}
+	if shaRe.MatchString(refPath) {
+		if c, err := r.Commit(refPath); err == nil {
+			return git.NewCommitReference(r.Path(), c.Hash), ""
+		}
+	}
+	return resolveRefPath(r, refPath)
+}
+
+// webRefName returns the name of a reference used in web UI links.
+func webRefName(ref *git.Reference) string {
+	switch {
+	case ref.IsBranch():
+		return strings.TrimPrefix(ref.Name().String(), git.RefsHeads)
+	case ref.IsTag():
+		return strings.TrimPrefix(ref.Name().String(), git.RefsTags)
+	}
+	return ref.TargetHash().String()
+}
+
+// webCrumbs returns the breadcrumbs of a path, one per directory.
+func webCrumbs(fp string) []webEntry {
+	crumbs := make([]webEntry, 0)
+	if fp == "" {
+		return crumbs
+	}
+	parts := strings.Split(fp, "/")
+	for i, part := range parts {
+		crumbs = append(crumbs, webEntry{
+			Name: part,
+			Path: strings.Join(parts[:i+1], "/"),
+		})
+	}
+	return crumbs
+}
+
+// escapePath escapes each segment of a slash-separated path.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
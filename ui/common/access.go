@@ -0,0 +1,18 @@
+package common
+
+import "github.com/charmbracelet/wish/git"
+
+// AccessBadge returns the short name of an access level shown to users, or
+// an empty string for no access.
+func AccessBadge(l git.AccessLevel) string {
+	switch {
+	case l >= git.AdminAccess:
+		return "admin"
+	case l >= git.ReadWriteAccess:
+		return "write"
+	case l >= git.ReadOnlyAccess:
+		return "read"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,110 @@
+package common
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/muesli/reflow/ansi"
+)
+
+var (
+	urlRegexp = regexp.MustCompile(`(https?|ssh|git)://[^\s"'<>]+`)
+	// linkMarker matches the markers of MarkLinks. The link target is
+	// encoded as decimal bytes so that the markers are escape sequences our
+	// width calculations skip, unlike OSC 8 sequences. An empty target ends
+	// the link.
+	linkMarker = regexp.MustCompile("\x1b\\[8;([0-9:]*)y")
+)
+
+// MarkLinks marks the URLs found in s, which can be styled already, as
+// links. Marks don't take any room, so that s can still be measured,
+// wrapped and truncated. RenderLinks turns them into hyperlinks.
+func MarkLinks(s string) string {
+	// Find URLs in the printable text, and keep track of where each of its
+	// bytes is in s.
+	var b strings.Builder
+	pos := make([]int, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == ansi.Marker {
+			for i++; i < len(s) && !ansi.IsTerminator(rune(s[i])); i++ {
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+		pos = append(pos, i)
+	}
+	text := b.String()
+	matches := urlRegexp.FindAllStringIndex(text, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	b.Reset()
+	last := 0
+	for _, m := range matches {
+		link := strings.TrimRight(text[m[0]:m[1]], ".,:;!?)]}")
+		start, end := pos[m[0]], pos[m[0]+len(link)-1]+1
+		b.WriteString(s[last:start])
+		b.WriteString(linkMark(link))
+		b.WriteString(s[start:end])
+		b.WriteString(linkMark(""))
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func linkMark(link string) string {
+	codes := make([]string, len(link))
+	for i := 0; i < len(link); i++ {
+		codes[i] = strconv.Itoa(int(link[i]))
+	}
+	return "\x1b[8;" + strings.Join(codes, ":") + "y"
+}
+
+// RenderLinks turns the links marked by MarkLinks into OSC 8 hyperlinks, or
+// removes the marks if hyperlinks are disabled. Terminals that don't support
+// OSC 8 will simply ignore the escape sequences.
+//
+// Links end with their line, which also ends links whose end was truncated,
+// so the rest of a link wrapped over several lines isn't clickable.
+func RenderLinks(s string, hyperlinks bool) string {
+	if !strings.Contains(s, "\x1b[8;") {
+		return s
+	}
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		open := false
+		l = linkMarker.ReplaceAllStringFunc(l, func(m string) string {
+			if !hyperlinks {
+				return ""
+			}
+			var link []byte
+			if codes := linkMarker.FindStringSubmatch(m)[1]; codes != "" {
+				for _, c := range strings.Split(codes, ":") {
+					n, err := strconv.Atoi(c)
+					if err != nil || n > 255 {
+						return ""
+					}
+					link = append(link, byte(n))
+				}
+			}
+			if len(link) == 0 && !open {
+				return ""
+			}
+			open = len(link) > 0
+			return hyperlink(string(link))
+		})
+		if open {
+			l += hyperlink("")
+		}
+		lines[i] = l
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hyperlink returns the OSC 8 escape sequence starting a hyperlink to link,
+// or ending the current one if link is empty.
+func hyperlink(link string) string {
+	return "\x1b]8;;" + link + "\x1b\\"
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/matryer/is"
+)
+
+func TestLinks(t *testing.T) {
+	is := is.New(t)
+	link := func(url, text string) string { return hyperlink(url) + text + hyperlink("") }
+
+	s := MarkLinks("see https://example.com/docs.")
+	is.Equal(RenderLinks(s, true), "see "+link("https://example.com/docs", "https://example.com/docs")+".")
+	is.Equal(RenderLinks(s, false), "see https://example.com/docs.")
+	is.Equal(MarkLinks("no links"), "no links")
+
+	// Marks don't take any room, unlike hyperlinks.
+	is.Equal(lipgloss.Width(s), len("see https://example.com/docs."))
+	s = MarkLinks("git clone ssh://example.com/repo")
+	is.Equal(lipgloss.Width(TruncateString(s, 20)), 20)
+	// Truncated links still point to the whole URL, and end with the line.
+	is.Equal(RenderLinks(TruncateString(s, 20)+"\nnext", true),
+		"git clone "+hyperlink("ssh://example.com/repo")+"ssh://exa…"+hyperlink("")+"\nnext")
+
+	// URLs can be styled already, even partly.
+	s = MarkLinks("\x1b[1mhttp://\x1b[0mexample.com\x1b[0m")
+	is.Equal(RenderLinks(s, true), "\x1b[1m"+link("http://example.com", "http://\x1b[0mexample.com")+"\x1b[0m")
+	s = lipgloss.NewStyle().Width(30).Render(MarkLinks("a http://example.com b"))
+	is.Equal(lipgloss.Width(s), 30)
+	is.True(strings.Contains(RenderLinks(s, true), link("http://example.com", "http://example.com")))
+}
@@ -0,0 +1,48 @@
+package code
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"github.com/golang/groupcache/lru"
+)
+
+// renderCacheSize is the number of rendered markdown documents kept in memory.
+const renderCacheSize = 256
+
+// renderCache holds rendered markdown shared by all sessions. Documents are
+// keyed by their git blob hash, so a push that changes a readme never hits
+// the old rendering, which is eventually evicted.
+var renderCache = struct {
+	sync.Mutex
+	*lru.Cache
+}{Cache: lru.New(renderCacheSize)}
+
+// blobHash returns the git blob hash of content.
+func blobHash(content string) string {
+	h := sha1.Sum([]byte(fmt.Sprintf("blob %d\x00%s", len(content), content)))
+	return hex.EncodeToString(h[:])
+}
+
+// renderKey returns the cache key of content rendered at width with theme.
+func renderKey(content string, width int, theme string) string {
+	return fmt.Sprintf("%s:%d:%s", blobHash(content), width, theme)
+}
+
+func cachedRender(key string) (string, bool) {
+	renderCache.Lock()
+	defer renderCache.Unlock()
+	v, ok := renderCache.Get(key)
+	if !ok {
+		return "", false
+	}
+	return v.(string), true
+}
+
+func cacheRender(key, rendered string) {
+	renderCache.Lock()
+	defer renderCache.Unlock()
+	renderCache.Add(key, rendered)
+}
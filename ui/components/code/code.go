@@ -27,13 +27,19 @@ var (
 // Code is a code snippet.
 type Code struct {
 	*vp.Viewport
-	common         common.Common
-	content        string
-	extension      string
-	renderContext  gansi.RenderContext
-	renderMutex    sync.Mutex
-	styleConfig    gansi.StyleConfig
+	common        common.Common
+	content       string
+	extension     string
+	renderContext gansi.RenderContext
+	renderMutex   sync.Mutex
+	styleConfig   gansi.StyleConfig
+	// theme names styleConfig in rendering cache keys.
+	theme          string
 	showLineNumber bool
+	// highlighted caches the highlighted content, which doesn't depend on
+	// the width, so that resizing only wraps it again.
+	highlighted string
+	numberWidth int
 
 	NoContentStyle lipgloss.Style
 	LineDigitStyle lipgloss.Style
@@ -53,6 +59,7 @@ func New(c common.Common, content, extension string) *Code {
 	}
 	st := common.StyleConfig()
 	r.styleConfig = st
+	r.theme = "dark"
 	r.renderContext = gansi.NewRenderContext(gansi.Options{
 		ColorProfile: termenv.TrueColor,
 		Styles:       st,
@@ -64,6 +71,7 @@ func New(c common.Common, content, extension string) *Code {
 // SetShowLineNumber sets whether to show line numbers.
 func (r *Code) SetShowLineNumber(show bool) {
 	r.showLineNumber = show
+	r.highlighted = ""
 }
 
 // SetSize implements common.Component.
@@ -76,6 +84,7 @@ func (r *Code) SetSize(width, height int) {
 func (r *Code) SetContent(c, ext string) tea.Cmd {
 	r.content = c
 	r.extension = ext
+	r.highlighted = ""
 	return r.Init()
 }
 
@@ -167,6 +176,10 @@ func (r *Code) glamourize(w int, md string) (string, error) {
 	if w > 120 {
 		w = 120
 	}
+	key := renderKey(md, w, r.theme)
+	if mdt, ok := cachedRender(key); ok {
+		return mdt, nil
+	}
 	tr, err := glamour.NewTermRenderer(
 		glamour.WithStyles(r.styleConfig),
 		glamour.WithWordWrap(w),
@@ -179,6 +192,7 @@ func (r *Code) glamourize(w int, md string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	cacheRender(key, mdt)
 	return mdt, nil
 }
 
@@ -203,33 +217,17 @@ func (r *Code) renderFile(path, content string, width int) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		c = md
+		c = common.MarkLinks(md)
 	} else {
-		formatter := &gansi.CodeBlockElement{
-			Code:     content,
-			Language: lang,
-		}
-		s := strings.Builder{}
-		rc := r.renderContext
-		if r.showLineNumber {
-			st := common.StyleConfig()
-			var m uint
-			st.CodeBlock.Margin = &m
-			rc = gansi.NewRenderContext(gansi.Options{
-				ColorProfile: termenv.TrueColor,
-				Styles:       st,
-			})
-		}
-		err := formatter.Render(&s, rc)
-		if err != nil {
-			return "", err
-		}
-		c = s.String()
-		if r.showLineNumber {
-			var ml int
-			c, ml = withLineNumber(c)
-			width -= ml
+		if r.highlighted == "" {
+			h, err := r.highlight(lang, content)
+			if err != nil {
+				return "", err
+			}
+			r.highlighted = h
 		}
+		c = r.highlighted
+		width -= r.numberWidth
 	}
 	// Fix styling when after line breaks.
 	// https://github.com/muesli/reflow/issues/43
@@ -238,6 +236,35 @@ func (r *Code) renderFile(path, content string, width int) (string, error) {
 	return lipgloss.NewStyle().Width(width).Render(c), nil
 }
 
+// highlight returns content highlighted as lang, with line numbers if they're
+// shown.
+func (r *Code) highlight(lang, content string) (string, error) {
+	formatter := &gansi.CodeBlockElement{
+		Code:     content,
+		Language: lang,
+	}
+	s := strings.Builder{}
+	rc := r.renderContext
+	if r.showLineNumber {
+		st := common.StyleConfig()
+		var m uint
+		st.CodeBlock.Margin = &m
+		rc = gansi.NewRenderContext(gansi.Options{
+			ColorProfile: termenv.TrueColor,
+			Styles:       st,
+		})
+	}
+	if err := formatter.Render(&s, rc); err != nil {
+		return "", err
+	}
+	c := s.String()
+	r.numberWidth = 0
+	if r.showLineNumber {
+		c, r.numberWidth = withLineNumber(c)
+	}
+	return c, nil
+}
+
 func withLineNumber(s string) (string, int) {
 	lines := strings.Split(s, "\n")
 	// NB: len() is not a particularly safe way to count string width (because
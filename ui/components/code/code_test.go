@@ -0,0 +1,43 @@
+package code
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/keymap"
+	"github.com/charmbracelet/soft-serve/ui/styles"
+	"github.com/matryer/is"
+)
+
+func TestResizeRewraps(t *testing.T) {
+	is := is.New(t)
+	c := New(common.Common{
+		Styles: styles.DefaultStyles(),
+		KeyMap: keymap.DefaultKeyMap(),
+		Width:  80,
+		Height: 20,
+	}, "", "")
+	c.SetShowLineNumber(true)
+	src := "package main\n\n// " + strings.Repeat("word ", 30) + "\nfunc main() {}\n"
+	is.Equal(c.SetContent(src, ".go"), nil)
+	is.True(c.highlighted != "")
+	is.True(c.numberWidth > 0)
+
+	// Resizing wraps the highlighted content again instead of highlighting
+	// it anew.
+	c.highlighted = strings.Replace(c.highlighted, "main", "cached", 1)
+	c.SetSize(40, 20)
+	c.Update(tea.WindowSizeMsg{Width: 40, Height: 20})
+	v := c.View()
+	is.True(strings.Contains(v, "cached"))
+	for _, l := range strings.Split(v, "\n") {
+		is.True(lipgloss.Width(l) <= 40)
+	}
+
+	// New content is highlighted.
+	c.SetContent(src, ".go")
+	is.True(!strings.Contains(c.View(), "cached"))
+}
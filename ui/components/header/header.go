@@ -26,6 +26,11 @@ func (h *Header) SetSize(width, height int) {
 	h.common.SetSize(width, height)
 }
 
+// SetText sets the text of the header.
+func (h *Header) SetText(text string) {
+	h.text = text
+}
+
 // Init implements tea.Model.
 func (h *Header) Init() tea.Cmd {
 	return nil
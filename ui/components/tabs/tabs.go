@@ -39,6 +39,15 @@ func New(c common.Common, tabs []string) *Tabs {
 	return r
 }
 
+// SetTabs replaces the tabs, keeping the active tab if it still exists and
+// activating the first tab otherwise.
+func (t *Tabs) SetTabs(tabs []string) {
+	t.tabs = tabs
+	if t.activeTab >= len(tabs) {
+		t.activeTab = 0
+	}
+}
+
 // SetSize implements common.Component.
 func (t *Tabs) SetSize(width, height int) {
 	t.common.SetSize(width, height)
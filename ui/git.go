@@ -5,21 +5,32 @@ import (
 	"github.com/charmbracelet/soft-serve/ui/git"
 )
 
-// source is a wrapper around config.RepoSource that implements git.GitRepoSource.
+// source is a wrapper around config.Source that implements git.GitRepoSource.
 type source struct {
-	*config.RepoSource
+	config.Source
 }
 
 // GetRepo implements git.GitRepoSource.
 func (s *source) GetRepo(name string) (git.GitRepo, error) {
-	return s.RepoSource.GetRepo(name)
+	r, err := s.Source.GetRepo(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.wrap(r), nil
 }
 
 // AllRepos implements git.GitRepoSource.
 func (s *source) AllRepos() []git.GitRepo {
 	rs := make([]git.GitRepo, 0)
-	for _, r := range s.RepoSource.AllRepos() {
-		rs = append(rs, r)
+	for _, r := range s.Source.AllRepos() {
+		rs = append(rs, s.wrap(r))
 	}
 	return rs
 }
+
+func (s *source) wrap(r git.GitRepo) git.GitRepo {
+	if w, ok := s.Source.(git.RepoWrapper); ok {
+		return w.WrapRepo(r)
+	}
+	return r
+}
@@ -3,8 +3,15 @@ package git
 import (
 	"errors"
 	"fmt"
+	"net"
+	gopath "path"
+	"path/filepath"
+	"strconv"
+	"strings"
 
+	"github.com/charmbracelet/soft-serve/config"
 	"github.com/charmbracelet/soft-serve/git"
+	wgit "github.com/charmbracelet/wish/git"
 )
 
 // ErrMissingRepo indicates that the requested repository could not be found.
@@ -18,12 +25,13 @@ type GitRepo interface {
 	Readme() (string, string)
 	HEAD() (*git.Reference, error)
 	Commit(string) (*git.Commit, error)
-	CommitsByPage(*git.Reference, int, int) (git.Commits, error)
-	CountCommits(*git.Reference) (int64, error)
+	CommitsByPage(ref *git.Reference, path string, page, size int) (git.Commits, error)
+	CountCommits(ref *git.Reference, path string) (int64, error)
 	Diff(*git.Commit) (*git.Diff, error)
 	References() ([]*git.Reference, error)
 	Tree(*git.Reference, string) (*git.Tree, error)
 	IsPrivate() bool
+	IsEmpty() bool
 }
 
 // GitRepoSource is an interface for Git repository factory.
@@ -32,11 +40,108 @@ type GitRepoSource interface {
 	AllRepos() []GitRepo
 }
 
-// RepoURL returns the URL of the repository.
+// RepoWrapper is implemented by repo sources that wrap the repos browsed in
+// the UI, such as the fault-injecting sources of the chaos package.
+type RepoWrapper interface {
+	WrapRepo(GitRepo) GitRepo
+}
+
+// RepoURL returns the clone command of the repository.
 func RepoURL(host string, port int, name string) string {
-	p := ""
-	if port != 22 {
-		p += fmt.Sprintf(":%d", port)
+	return CloneCommand(SSHURL(host, port, name))
+}
+
+// CloneCommand returns the command cloning the repository at url.
+func CloneCommand(url string) string {
+	return fmt.Sprintf("git clone %s", url)
+}
+
+// SSHURL returns the SSH URL of the repository.
+func SSHURL(host string, port int, name string) string {
+	return fmt.Sprintf("ssh://%s/%s", hostPort(host, port, 22), name)
+}
+
+// HTTPURL returns the HTTP URL of the repository served on the given port.
+// Port 443 is assumed to be served over HTTPS, by a proxy terminating TLS.
+func HTTPURL(host string, port int, name string) string {
+	if port == 443 {
+		return HTTPSURL(host, port, name)
+	}
+	return fmt.Sprintf("http://%s/%s", hostPort(host, port, 80), name)
+}
+
+// HTTPSURL returns the HTTPS URL of the repository served on the given port.
+func HTTPSURL(host string, port int, name string) string {
+	return fmt.Sprintf("https://%s/%s", hostPort(host, port, 443), name)
+}
+
+// hostPort joins host and port, leaving out the port if it's the default
+// port of the protocol. IPv6 hosts are bracketed, whether or not they
+// already were.
+func hostPort(host string, port, defaultPort int) string {
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if port == defaultPort {
+		if strings.Contains(host, ":") {
+			return "[" + host + "]"
+		}
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// CloneURLs returns the URLs the repository can be cloned from, over SSH
+// and, when the HTTP server is enabled and the repository is readable
+// anonymously, over HTTP, or HTTPS when it gets certificates with ACME.
+func CloneURLs(cfg *config.Config, name string) []string {
+	urls := []string{SSHURL(cfg.Host, cfg.Port, name)}
+	if u := WebURL(cfg, name); u != "" {
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// WebURL returns the URL of the repository on the HTTP server, or an empty
+// string when the HTTP server is disabled or the repository isn't readable
+// anonymously.
+func WebURL(cfg *config.Config, name string) string {
+	if cfg.Cfg == nil || cfg.Cfg.HTTPPort == 0 || cfg.AuthRepo(name, nil) < wgit.ReadOnlyAccess {
+		return ""
+	}
+	if len(cfg.Cfg.ACMEDomains) > 0 {
+		return HTTPSURL(cfg.Host, cfg.Cfg.HTTPPort, name)
+	}
+	return HTTPURL(cfg.Host, cfg.Cfg.HTTPPort, name)
+}
+
+// PushCommands returns the commands that push an existing local repository
+// to the repository.
+func PushCommands(host string, port int, name string) string {
+	return fmt.Sprintf("git remote add origin %s\ngit push -u origin HEAD",
+		SSHURL(host, port, name))
+}
+
+// EditCommand returns a shell snippet that clones the repository and opens
+// the file at path in the user's $EDITOR at the given line.
+func EditCommand(host string, port int, name, path string, line int) string {
+	if line < 1 {
+		line = 1
+	}
+	dir := gopath.Base(name)
+	return fmt.Sprintf("%s %s && cd %s && ${EDITOR:-vi} +%d %s",
+		CloneCommand(shellQuote(SSHURL(host, port, name))),
+		shellQuote(dir),
+		shellQuote(dir),
+		line,
+		shellQuote(filepath.ToSlash(path)),
+	)
+}
+
+// shellQuote quotes s as a single word for POSIX shells. Words starting
+// with a dash are made relative, so that commands don't take them for
+// flags.
+func shellQuote(s string) string {
+	if strings.HasPrefix(s, "-") {
+		s = "./" + s
 	}
-	return fmt.Sprintf("git clone ssh://%s/%s", host+p, name)
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
@@ -0,0 +1,51 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestURLs(t *testing.T) {
+	cases := []struct {
+		host      string
+		port      int
+		ssh, http string
+	}{
+		{"example.com", 22, "ssh://example.com/repo", "http://example.com:22/repo"},
+		{"example.com", 23231, "ssh://example.com:23231/repo", "http://example.com:23231/repo"},
+		{"example.com", 80, "ssh://example.com:80/repo", "http://example.com/repo"},
+		{"example.com", 443, "ssh://example.com:443/repo", "https://example.com/repo"},
+		{"::1", 22, "ssh://[::1]/repo", "http://[::1]:22/repo"},
+		{"::1", 23231, "ssh://[::1]:23231/repo", "http://[::1]:23231/repo"},
+		{"[::1]", 23231, "ssh://[::1]:23231/repo", "http://[::1]:23231/repo"},
+		{"[2001:db8::1]", 443, "ssh://[2001:db8::1]:443/repo", "https://[2001:db8::1]/repo"},
+	}
+	for _, c := range cases {
+		is := is.New(t)
+		is.Equal(SSHURL(c.host, c.port, "repo"), c.ssh)   // ssh url
+		is.Equal(HTTPURL(c.host, c.port, "repo"), c.http) // http url
+	}
+}
+
+func TestHTTPSURL(t *testing.T) {
+	is := is.New(t)
+	is.Equal(HTTPSURL("example.com", 443, "repo"), "https://example.com/repo")
+	is.Equal(HTTPSURL("example.com", 23232, "repo"), "https://example.com:23232/repo")
+}
+
+func TestEditCommand(t *testing.T) {
+	cases := []struct {
+		name, path string
+		want       string
+	}{
+		{"repo", "README.md", `git clone 'ssh://example.com/repo' 'repo' && cd 'repo' && ${EDITOR:-vi} +3 'README.md'`},
+		{"group/repo", "docs/a b.md", `git clone 'ssh://example.com/group/repo' 'repo' && cd 'repo' && ${EDITOR:-vi} +3 'docs/a b.md'`},
+		{"it's", "x;rm -rf ~", `git clone 'ssh://example.com/it'\''s' 'it'\''s' && cd 'it'\''s' && ${EDITOR:-vi} +3 'x;rm -rf ~'`},
+		{"repo", "-c:!id", `git clone 'ssh://example.com/repo' 'repo' && cd 'repo' && ${EDITOR:-vi} +3 './-c:!id'`},
+	}
+	for _, c := range cases {
+		is := is.New(t)
+		is.Equal(EditCommand("example.com", 22, c.name, c.path, 3), c.want) // edit command
+	}
+}
@@ -20,7 +20,8 @@ type KeyMap struct {
 	SelectItem key.Binding
 	BackItem   key.Binding
 
-	Copy key.Binding
+	Copy      key.Binding
+	SwitchURL key.Binding
 }
 
 // DefaultKeyMap returns the default key map.
@@ -202,5 +203,15 @@ func DefaultKeyMap() *KeyMap {
 		),
 	)
 
+	km.SwitchURL = key.NewBinding(
+		key.WithKeys(
+			"s",
+		),
+		key.WithHelp(
+			"s",
+			"switch ssh/http",
+		),
+	)
+
 	return km
 }
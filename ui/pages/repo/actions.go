@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var openActions = key.NewBinding(
+	key.WithKeys("x"),
+	key.WithHelp("x", "actions"),
+)
+
+// ActionResultMsg is a message that contains the result of running an
+// action.
+type ActionResultMsg struct {
+	name string
+	out  string
+	err  error
+}
+
+// actionMenu is the menu of the custom actions the user can run on the
+// selected repository.
+type actionMenu struct {
+	open    bool
+	cursor  int
+	running bool
+	// status is the result of the last action run.
+	status string
+}
+
+// updateActions handles key presses while the action menu is open.
+func (r *Repo) updateActions(msg tea.KeyMsg) tea.Cmd {
+	m := &r.actionMenu
+	if m.running {
+		return nil
+	}
+	switch {
+	case msg.Type == tea.KeyEsc || key.Matches(msg, openActions):
+		m.open = false
+	case key.Matches(msg, r.common.KeyMap.UpDown) && (msg.String() == "up" || msg.String() == "k"):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case key.Matches(msg, r.common.KeyMap.UpDown):
+		if m.cursor < len(r.actions)-1 {
+			m.cursor++
+		}
+	case msg.Type == tea.KeyEnter && m.cursor < len(r.actions):
+		m.running = true
+		name := r.actions[m.cursor].Name
+		m.status = fmt.Sprintf("Running %s…", name)
+		return r.runActionCmd(name)
+	}
+	return nil
+}
+
+// runActionCmd runs the named action on the selected repository.
+func (r *Repo) runActionCmd(name string) tea.Cmd {
+	repo := r.selectedRepo.Repo()
+	return func() tea.Msg {
+		out, err := r.cfg.RunAction(context.Background(), repo, r.pk, name)
+		return ActionResultMsg{name: name, out: out, err: err}
+	}
+}
+
+// actionsView renders the action menu in place of the active tab.
+func (r *Repo) actionsView() string {
+	m := r.actionMenu
+	lines := []string{r.common.Styles.Repo.HeaderName.Render("Actions"), ""}
+	for i, a := range r.actions {
+		if i == m.cursor {
+			lines = append(lines, r.common.Styles.LogItem.Active.Title.Render("> "+a.Name))
+		} else {
+			lines = append(lines, r.common.Styles.LogItem.Normal.Title.Render("  "+a.Name))
+		}
+	}
+	if m.status != "" {
+		lines = append(lines, "", m.status)
+	}
+	return lipgloss.NewStyle().
+		Width(r.common.Width).
+		Render(strings.Join(lines, "\n"))
+}
+
+// actionStatus returns the status line of an action result.
+func actionStatus(msg ActionResultMsg) string {
+	status := fmt.Sprintf("%s: done", msg.name)
+	if msg.err != nil {
+		status = fmt.Sprintf("%s: failed: %v", msg.name, msg.err)
+	}
+	if msg.out != "" {
+		status += "\n" + msg.out
+	}
+	return status
+}
@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/deps"
+	ggit "github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/code"
+	"github.com/charmbracelet/soft-serve/ui/git"
+)
+
+// ecosystemNames are the display names of the ecosystems of dependencies.
+var ecosystemNames = map[string]string{
+	deps.Go:   "Go",
+	deps.NPM:  "npm",
+	deps.PyPI: "PyPI",
+}
+
+// DependenciesMsg is a message that contains the rendered dependencies of a
+// repo.
+type DependenciesMsg string
+
+// Dependencies is a page that lists the dependencies declared in the
+// manifests of a repository, such as go.mod and package.json files.
+type Dependencies struct {
+	common common.Common
+	cfg    *config.Config
+	code   *code.Code
+	repo   git.GitRepo
+	ref    *ggit.Reference
+}
+
+// NewDependencies creates a new dependencies model.
+func NewDependencies(cfg *config.Config, common common.Common) *Dependencies {
+	c := code.New(common, "", "")
+	c.NoContentStyle = c.NoContentStyle.SetString("No dependencies found.")
+	return &Dependencies{
+		common: common,
+		cfg:    cfg,
+		code:   c,
+	}
+}
+
+// SetSize implements common.Component.
+func (d *Dependencies) SetSize(width, height int) {
+	d.common.SetSize(width, height)
+	d.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (d *Dependencies) ShortHelp() []key.Binding {
+	return []key.Binding{
+		d.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (d *Dependencies) FullHelp() [][]key.Binding {
+	k := d.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+		{
+			k.Down,
+			k.Up,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (d *Dependencies) Init() tea.Cmd {
+	if d.repo == nil {
+		return common.ErrorCmd(git.ErrMissingRepo)
+	}
+	d.code.GotoTop()
+	return d.updateDependenciesCmd
+}
+
+// Update implements tea.Model.
+func (d *Dependencies) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		d.repo = git.GitRepo(msg)
+	case RefMsg:
+		d.ref = msg
+		cmds = append(cmds, d.Init())
+	case DependenciesMsg:
+		cmds = append(cmds, d.code.SetContent(string(msg), ".md"))
+	}
+	c, cmd := d.code.Update(msg)
+	d.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	return d, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (d *Dependencies) View() string {
+	return d.code.View()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (d *Dependencies) StatusBarValue() string {
+	return ""
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (d *Dependencies) StatusBarInfo() string {
+	return fmt.Sprintf("☰ %.f%%", d.code.ScrollPercent()*100)
+}
+
+// updateDependenciesCmd renders the dependencies at the selected reference,
+// grouped by manifest.
+func (d *Dependencies) updateDependenciesCmd() tea.Msg {
+	if d.ref == nil {
+		return common.ErrorMsg(errNoRef)
+	}
+	ds, err := d.cfg.Dependencies(d.repo.Repo(), d.ref)
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	s := strings.Builder{}
+	manifest := ""
+	for _, dep := range ds {
+		if dep.Manifest != manifest {
+			if manifest != "" {
+				s.WriteString("\n")
+			}
+			manifest = dep.Manifest
+			fmt.Fprintf(&s, "## %s (%s)\n\n", manifest, ecosystemNames[dep.Ecosystem])
+		}
+		fmt.Fprintf(&s, "* `%s`", dep.Name)
+		if dep.Version != "" {
+			fmt.Fprintf(&s, " %s", dep.Version)
+		}
+		switch {
+		case dep.Indirect:
+			s.WriteString(" (indirect)")
+		case dep.Dev:
+			s.WriteString(" (dev)")
+		}
+		s.WriteString("\n")
+	}
+	return DependenciesMsg(s.String())
+}
@@ -0,0 +1,106 @@
+package repo
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/common"
+)
+
+// DiffFileItem is a changed file in a commit diff.
+type DiffFileItem struct {
+	*git.DiffFile
+	// index is the position of the file in the diff.
+	index int
+}
+
+// ID implements selector.IdentifiableItem.
+func (i DiffFileItem) ID() string {
+	return fmt.Sprintf("diff-file-%d", i.index)
+}
+
+// Title implements list.DefaultItem.
+func (i DiffFileItem) Title() string {
+	from, to := i.Files()
+	if from != nil && to != nil && from.Name() != to.Name() {
+		return fmt.Sprintf("%s → %s", from.Name(), to.Name())
+	}
+	return i.Name
+}
+
+// Description implements list.DefaultItem.
+func (i DiffFileItem) Description() string {
+	return ""
+}
+
+// FilterValue implements list.Item.
+func (i DiffFileItem) FilterValue() string { return i.Title() }
+
+// Status returns a one letter status of the change, similar to git
+// diff --name-status.
+func (i DiffFileItem) Status() string {
+	from, to := i.Files()
+	switch {
+	case from == nil:
+		return "A"
+	case to == nil:
+		return "D"
+	case from.Name() != to.Name():
+		return "R"
+	default:
+		return "M"
+	}
+}
+
+// DiffFileItemDelegate is the delegate for DiffFileItem.
+type DiffFileItemDelegate struct {
+	common *common.Common
+}
+
+// Height implements list.ItemDelegate.
+func (d DiffFileItemDelegate) Height() int { return 1 }
+
+// Spacing implements list.ItemDelegate.
+func (d DiffFileItemDelegate) Spacing() int { return 0 }
+
+// Update implements list.ItemDelegate.
+func (d DiffFileItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d DiffFileItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	s := d.common.Styles.Ref
+	i, ok := listItem.(DiffFileItem)
+	if !ok {
+		return
+	}
+
+	st := s.Normal.Item
+	selector := "  "
+	if index == m.Index() {
+		st = s.Active.Item
+		selector = s.ItemSelector.String()
+	}
+
+	stats := fmt.Sprintf(" %s %s",
+		d.common.Styles.Log.CommitStatsAdd.Render(fmt.Sprintf("+%d", i.NumAdditions())),
+		d.common.Styles.Log.CommitStatsDel.Render(fmt.Sprintf("-%d", i.NumDeletions())),
+	)
+	status := fmt.Sprintf("%s ", i.Status())
+	nameMaxWidth := m.Width() -
+		lipgloss.Width(selector) -
+		lipgloss.Width(status) -
+		lipgloss.Width(stats)
+	name := st.Render(common.TruncateString(i.Title(), nameMaxWidth))
+	fmt.Fprint(w,
+		d.common.Zone.Mark(
+			i.ID(),
+			fmt.Sprint(selector, status, name, stats),
+		),
+	)
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+	"github.com/matryer/is"
+)
+
+func TestDiffFiles(t *testing.T) {
+	is := is.New(t)
+	_, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Initial commit", map[string]string{
+			"a.txt": "one\ntwo\nthree\n",
+			"b.txt": "gone soon\n",
+		})
+		commitFiles(t, wd, "Change files", map[string]string{
+			"a.txt": "one\n2\nthree\nfour\n",
+			"b.txt": "",
+			"c.txt": "new\n",
+		})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+	c, err := r.Commit(head.Hash.String())
+	is.NoErr(err)
+	diff, err := r.Diff(c)
+	is.NoErr(err)
+	is.Equal(len(diff.Files), 3)
+
+	want := map[string]struct {
+		status             string
+		additions, deletes int
+	}{
+		"a.txt": {"M", 2, 1},
+		"b.txt": {"D", 0, 1},
+		"c.txt": {"A", 1, 0},
+	}
+	var patches strings.Builder
+	for i, f := range diff.Files {
+		item := DiffFileItem{DiffFile: f, index: i}
+		w, ok := want[item.Title()]
+		is.True(ok)
+		is.Equal(item.Status(), w.status)
+		is.Equal(item.NumAdditions(), w.additions)
+		is.Equal(item.NumDeletions(), w.deletes)
+		patches.WriteString(f.Patch())
+	}
+	// The patches of the files make up the patch of the commit.
+	is.Equal(patches.String(), diff.Patch())
+
+	// Selecting a changed file scrolls the diff to it.
+	l := NewLog(testCommon())
+	l.SetSize(80, 5)
+	l.selectedCommit = c
+	l.Update(LogDiffMsg(diff))
+	is.Equal(l.activeView, logViewDiff)
+	is.Equal(len(l.fileOffsets), 3)
+	is.True(l.fileOffsets[0] < l.fileOffsets[1] && l.fileOffsets[1] < l.fileOffsets[2])
+	l.Update(selector.SelectMsg{IdentifiableItem: DiffFileItem{DiffFile: diff.Files[1], index: 1}})
+	is.Equal(l.vp.YOffset, l.fileOffsets[1])
+}
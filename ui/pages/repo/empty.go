@@ -0,0 +1,33 @@
+package repo
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/ui/git"
+)
+
+// emptyView is shown instead of the tabs for repositories without commits.
+func (r *Repo) emptyView() string {
+	if m, ok := r.cfg.MirrorStatus(r.selectedRepo.Repo()); ok {
+		return fmt.Sprintf("This mirror of %s is empty. %s", m.URL, mirrorSummary(m))
+	}
+	if !r.canPush() {
+		return "This repository is empty."
+	}
+	cmds := git.PushCommands(r.cfg.Host, r.cfg.Port, r.selectedRepo.Repo())
+	hint := "Press c or click the commands to copy them."
+	if !r.copyURL.IsZero() {
+		hint = "Copied!"
+	}
+	return lipgloss.JoinVertical(lipgloss.Left,
+		"This repository is empty. Push an existing repository to it:",
+		"",
+		r.common.Zone.Mark(
+			fmt.Sprintf("%s-push", r.selectedRepo.Repo()),
+			r.common.Styles.Repo.Command.Render(cmds),
+		),
+		"",
+		r.common.Styles.HelpValue.Render(hint),
+	)
+}
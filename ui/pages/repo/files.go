@@ -34,6 +34,10 @@ var (
 		key.WithKeys("l"),
 		key.WithHelp("l", "toggle line numbers"),
 	)
+	editFile = key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "copy edit command"),
+	)
 )
 
 // FileItemsMsg is a message that contains a list of files.
@@ -45,15 +49,25 @@ type FileContentMsg struct {
 	ext     string
 }
 
+// EditFileMsg is a message to copy a command that opens the current file in
+// a local editor.
+type EditFileMsg struct {
+	path string
+	line int
+}
+
 // Files is the model for the files view.
 type Files struct {
-	common         common.Common
-	selector       *selector.Selector
-	ref            *ggit.Reference
-	activeView     filesView
-	repo           git.GitRepo
-	code           *code.Code
-	path           string
+	common     common.Common
+	selector   *selector.Selector
+	ref        *ggit.Reference
+	activeView filesView
+	repo       git.GitRepo
+	code       *code.Code
+	path       string
+	// root is the directory the files are scoped to. Browsing never goes
+	// above it.
+	root           string
 	currentItem    *FileItem
 	currentContent FileContentMsg
 	lastSelected   []int
@@ -112,6 +126,7 @@ func (f *Files) ShortHelp() []key.Binding {
 			f.common.KeyMap.UpDown,
 			f.common.KeyMap.BackItem,
 			copyKey,
+			editFile,
 		}
 		lexer := lexers.Match(f.currentContent.ext)
 		lang := ""
@@ -171,6 +186,7 @@ func (f *Files) FullHelp() [][]key.Binding {
 			k.Down,
 			k.Up,
 			copyKey,
+			editFile,
 		}
 		lexer := lexers.Match(f.currentContent.ext)
 		lang := ""
@@ -187,7 +203,7 @@ func (f *Files) FullHelp() [][]key.Binding {
 
 // Init implements tea.Model.
 func (f *Files) Init() tea.Cmd {
-	f.path = ""
+	f.path = f.root
 	f.currentItem = nil
 	f.activeView = filesViewFiles
 	f.lastSelected = make([]int, 0)
@@ -201,10 +217,16 @@ func (f *Files) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case RepoMsg:
 		f.repo = git.GitRepo(msg)
+		f.root = ""
 		cmds = append(cmds, f.Init())
 	case RefMsg:
 		f.ref = msg
 		cmds = append(cmds, f.Init())
+	case ScopeMsg:
+		f.root = string(msg)
+		if f.ref != nil {
+			cmds = append(cmds, f.Init())
+		}
 	case FileItemsMsg:
 		cmds = append(cmds,
 			f.selector.SetItems(msg),
@@ -244,6 +266,8 @@ func (f *Files) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, backCmd)
 			case key.Matches(msg, f.common.KeyMap.Copy):
 				f.common.Copy.Copy(f.currentContent.content)
+			case key.Matches(msg, editFile):
+				cmds = append(cmds, f.editFileCmd)
 			case key.Matches(msg, lineNo):
 				f.lineNumber = !f.lineNumber
 				f.code.SetShowLineNumber(f.lineNumber)
@@ -251,11 +275,8 @@ func (f *Files) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 	case tea.WindowSizeMsg:
+		// The file list lays itself out again, only content needs wrapping.
 		switch f.activeView {
-		case filesViewFiles:
-			if f.repo != nil {
-				cmds = append(cmds, f.updateFilesCmd)
-			}
 		case filesViewContent:
 			if f.currentContent.content != "" {
 				m, cmd := f.code.Update(msg)
@@ -378,6 +399,9 @@ func (f *Files) selectFileCmd() tea.Msg {
 }
 
 func (f *Files) deselectItemCmd() tea.Msg {
+	if f.activeView == filesViewFiles && f.path == f.root {
+		return nil
+	}
 	f.path = filepath.Dir(f.path)
 	f.activeView = filesViewFiles
 	msg := f.updateFilesCmd()
@@ -389,3 +413,10 @@ func (f *Files) deselectItemCmd() tea.Msg {
 	f.selector.Select(index)
 	return msg
 }
+
+func (f *Files) editFileCmd() tea.Msg {
+	return EditFileMsg{
+		path: f.path,
+		line: f.code.YOffset + 1,
+	}
+}
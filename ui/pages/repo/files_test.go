@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/matryer/is"
+)
+
+func TestFilesScope(t *testing.T) {
+	is := is.New(t)
+	_, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Add files", map[string]string{
+			"README.md":        "# Repo\n",
+			"docs/index.md":    "# Docs\n",
+			"docs/api/spec.md": "# API\n",
+		})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+	n, err := r.CountCommits(head, "docs/api")
+	is.NoErr(err)
+	is.Equal(n, int64(1))
+
+	f := NewFiles(testCommon())
+	f.Update(RepoMsg(r))
+	f.Update(RefMsg(head))
+	f.Update(ScopeMsg("docs"))
+	is.Equal(f.path, "docs")
+	items, ok := f.updateFilesCmd().(FileItemsMsg)
+	is.True(ok)
+	names := []string{}
+	for _, i := range items {
+		names = append(names, i.ID())
+	}
+	is.Equal(names, []string{"api", "index.md"})
+
+	// Browsing doesn't go above the scope.
+	is.Equal(f.deselectItemCmd(), nil)
+	is.Equal(f.path, "docs")
+	f.path = "docs/api"
+	_, ok = f.deselectItemCmd().(FileItemsMsg)
+	is.True(ok)
+	is.Equal(f.path, "docs")
+}
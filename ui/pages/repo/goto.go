@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	ggit "github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/common"
+)
+
+var gotoRef = key.NewBinding(
+	key.WithKeys("@"),
+	key.WithHelp("@", "go to ref or commit"),
+)
+
+func newRefPrompt() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "@ "
+	ti.Placeholder = "branch, tag, or commit"
+	ti.CharLimit = 256
+	return ti
+}
+
+// IsPrompting returns true if the page is taking text input or the action
+// menu is open, so that keys like q shouldn't be handled as shortcuts.
+func (r *Repo) IsPrompting() bool {
+	return r.prompting || r.actionMenu.open
+}
+
+// updatePrompt handles key presses while the ref prompt is open.
+func (r *Repo) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		r.prompting = false
+		r.prompt.Blur()
+		return nil
+	case tea.KeyEnter:
+		r.prompting = false
+		r.prompt.Blur()
+		return r.gotoRefCmd(strings.TrimSpace(r.prompt.Value()))
+	}
+	p, cmd := r.prompt.Update(msg)
+	r.prompt = p
+	return cmd
+}
+
+// gotoRefCmd switches to the branch, tag, or commit named by rev.
+func (r *Repo) gotoRefCmd(rev string) tea.Cmd {
+	if rev == "" || r.selectedRepo == nil {
+		return nil
+	}
+	rr := r.selectedRepo
+	return func() tea.Msg {
+		refs, err := rr.References()
+		if err != nil {
+			return common.ErrorMsg(err)
+		}
+		for _, prefix := range []string{"", ggit.RefsHeads, ggit.RefsTags} {
+			for _, ref := range refs {
+				if ref.Name().String() == prefix+rev {
+					return RefMsg(ref)
+				}
+			}
+		}
+		c, err := rr.Commit(rev)
+		if err != nil {
+			return common.ErrorMsg(fmt.Errorf("%q: %w", rev, ggit.ErrReferenceNotFound))
+		}
+		return RefMsg(ggit.NewCommitReference("", c.Hash))
+	}
+}
+
+// refName returns the name of the reference shown in the status bar.
+func refName(ref *ggit.Reference) string {
+	if ref.IsDetached() {
+		return fmt.Sprintf("detached at %s", ref.Hash.String()[:7])
+	}
+	return ref.Name().Short()
+}
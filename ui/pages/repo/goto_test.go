@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	ggit "github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/matryer/is"
+)
+
+func TestGotoRef(t *testing.T) {
+	is := is.New(t)
+	var wd string
+	cfg, r := testRepo(t, func(dir string) {
+		wd = dir
+		commitFiles(t, wd, "Initial commit", map[string]string{"a.txt": "a\n"})
+		runGit(t, wd, "tag", "v1")
+		runGit(t, wd, "branch", "dev")
+		commitFiles(t, wd, "Second commit", map[string]string{"a.txt": "b\n"})
+	})
+	runGit(t, wd, "push", "-q", filepath.Join(cfg.Source.Dir(), "repo"), "dev")
+	is.NoErr(cfg.Reload())
+	r, err := cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+	first, err := r.Commit("v1")
+	is.NoErr(err)
+
+	rp := &Repo{selectedRepo: r}
+	is.Equal(rp.gotoRefCmd(""), nil)
+	for rev, want := range map[string]string{
+		"dev":                   "dev",
+		"refs/heads/master":     "master",
+		"v1":                    "v1",
+		first.Hash.String()[:7]: "detached at " + first.Hash.String()[:7],
+		first.Hash.String():     "detached at " + first.Hash.String()[:7],
+	} {
+		ref, ok := rp.gotoRefCmd(rev)().(RefMsg)
+		is.True(ok)
+		is.Equal(refName(ref), want)
+	}
+	// Commits are browsed as detached refs.
+	ref := rp.gotoRefCmd(first.Hash.String()[:7])().(RefMsg)
+	is.True((*ggit.Reference)(ref).IsDetached())
+	is.Equal(ref.Hash, first.Hash)
+
+	err, ok := rp.gotoRefCmd("nope")().(common.ErrorMsg)
+	is.True(ok)
+	is.True(errors.Is(err, ggit.ErrReferenceNotFound))
+}
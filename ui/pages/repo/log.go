@@ -27,6 +27,14 @@ type logView int
 const (
 	logViewCommits logView = iota
 	logViewDiff
+	logViewFiles
+)
+
+var (
+	diffFiles = key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "changed files"),
+	)
 )
 
 // LogCountMsg is a message that contains the number of commits in a repo.
@@ -43,20 +51,29 @@ type LogDiffMsg *ggit.Diff
 
 // Log is a model that displays a list of commits and their diffs.
 type Log struct {
-	common         common.Common
-	selector       *selector.Selector
-	vp             *viewport.Viewport
-	activeView     logView
-	repo           git.GitRepo
-	ref            *ggit.Reference
+	common       common.Common
+	selector     *selector.Selector
+	fileSelector *selector.Selector
+	fileOffsets  []int
+	vp           *viewport.Viewport
+	activeView   logView
+	repo         git.GitRepo
+	ref          *ggit.Reference
+	// path limits the log to commits touching it.
+	path           string
 	count          int64
 	nextPage       int
 	activeCommit   *ggit.Commit
 	selectedCommit *ggit.Commit
 	currentDiff    *ggit.Diff
-	loadingTime    time.Time
-	loading        bool
-	spinner        spinner.Model
+	// diffPatches holds the highlighted patches of currentDiff, so that
+	// resizing only has to wrap them again.
+	diffPatches []string
+	// perPage is the page size commits were last loaded with.
+	perPage     int
+	loadingTime time.Time
+	loading     bool
+	spinner     spinner.Model
 }
 
 // NewLog creates a new Log model.
@@ -66,17 +83,28 @@ func NewLog(common common.Common) *Log {
 		vp:         viewport.New(common),
 		activeView: logViewCommits,
 	}
-	selector := selector.New(common, []selector.IdentifiableItem{}, LogItemDelegate{&common})
-	selector.SetShowFilter(false)
-	selector.SetShowHelp(false)
-	selector.SetShowPagination(false)
-	selector.SetShowStatusBar(false)
-	selector.SetShowTitle(false)
-	selector.SetFilteringEnabled(false)
-	selector.DisableQuitKeybindings()
-	selector.KeyMap.NextPage = common.KeyMap.NextPage
-	selector.KeyMap.PrevPage = common.KeyMap.PrevPage
-	l.selector = selector
+	commitSelector := selector.New(common, []selector.IdentifiableItem{}, LogItemDelegate{&common})
+	commitSelector.SetShowFilter(false)
+	commitSelector.SetShowHelp(false)
+	commitSelector.SetShowPagination(false)
+	commitSelector.SetShowStatusBar(false)
+	commitSelector.SetShowTitle(false)
+	commitSelector.SetFilteringEnabled(false)
+	commitSelector.DisableQuitKeybindings()
+	commitSelector.KeyMap.NextPage = common.KeyMap.NextPage
+	commitSelector.KeyMap.PrevPage = common.KeyMap.PrevPage
+	l.selector = commitSelector
+	fileSelector := selector.New(common, []selector.IdentifiableItem{}, DiffFileItemDelegate{&common})
+	fileSelector.SetShowFilter(false)
+	fileSelector.SetShowHelp(false)
+	fileSelector.SetShowPagination(false)
+	fileSelector.SetShowStatusBar(false)
+	fileSelector.SetShowTitle(false)
+	fileSelector.SetFilteringEnabled(false)
+	fileSelector.DisableQuitKeybindings()
+	fileSelector.KeyMap.NextPage = common.KeyMap.NextPage
+	fileSelector.KeyMap.PrevPage = common.KeyMap.PrevPage
+	l.fileSelector = fileSelector
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = common.Styles.Spinner
@@ -88,6 +116,7 @@ func NewLog(common common.Common) *Log {
 func (l *Log) SetSize(width, height int) {
 	l.common.SetSize(width, height)
 	l.selector.SetSize(width, height)
+	l.fileSelector.SetSize(width, height)
 	l.vp.SetSize(width, height)
 }
 
@@ -106,6 +135,13 @@ func (l *Log) ShortHelp() []key.Binding {
 		return []key.Binding{
 			l.common.KeyMap.UpDown,
 			l.common.KeyMap.BackItem,
+			diffFiles,
+		}
+	case logViewFiles:
+		return []key.Binding{
+			l.common.KeyMap.UpDown,
+			l.common.KeyMap.SelectItem,
+			l.common.KeyMap.BackItem,
 		}
 	default:
 		return []key.Binding{}
@@ -141,6 +177,7 @@ func (l *Log) FullHelp() [][]key.Binding {
 		k := l.vp.KeyMap
 		b = append(b, []key.Binding{
 			l.common.KeyMap.BackItem,
+			diffFiles,
 		})
 		b = append(b, [][]key.Binding{
 			{
@@ -154,6 +191,24 @@ func (l *Log) FullHelp() [][]key.Binding {
 				k.Up,
 			},
 		}...)
+	case logViewFiles:
+		k := l.fileSelector.KeyMap
+		b = append(b, []key.Binding{
+			l.common.KeyMap.SelectItem,
+			l.common.KeyMap.BackItem,
+		})
+		b = append(b, [][]key.Binding{
+			{
+				k.CursorUp,
+				k.CursorDown,
+			},
+			{
+				k.NextPage,
+				k.PrevPage,
+				k.GoToStart,
+				k.GoToEnd,
+			},
+		}...)
 	}
 	return b
 }
@@ -176,6 +231,7 @@ func (l *Log) Init() tea.Cmd {
 	l.count = 0
 	l.activeCommit = nil
 	l.selectedCommit = nil
+	l.perPage = l.selector.PerPage()
 	l.selector.Select(0)
 	return tea.Batch(
 		l.updateCommitsCmd,
@@ -190,10 +246,16 @@ func (l *Log) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case RepoMsg:
 		l.repo = git.GitRepo(msg)
+		l.path = ""
 		cmds = append(cmds, l.Init())
 	case RefMsg:
 		l.ref = msg
 		cmds = append(cmds, l.Init())
+	case ScopeMsg:
+		l.path = string(msg)
+		if l.ref != nil {
+			cmds = append(cmds, l.Init())
+		}
 	case LogCountMsg:
 		l.count = int64(msg)
 	case LogItemsMsg:
@@ -238,13 +300,32 @@ func (l *Log) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				switch {
 				case key.Matches(kmsg, l.common.KeyMap.BackItem):
 					cmds = append(cmds, backCmd)
+				case key.Matches(kmsg, diffFiles):
+					l.activeView = logViewFiles
+					cmds = append(cmds, updateStatusBarCmd)
 				}
 			}
+		case logViewFiles:
+			switch kmsg := msg.(type) {
+			case tea.KeyMsg:
+				switch {
+				case key.Matches(kmsg, l.common.KeyMap.SelectItem):
+					cmds = append(cmds, l.fileSelector.SelectItem)
+				case key.Matches(kmsg, l.common.KeyMap.BackItem, diffFiles):
+					cmds = append(cmds, backCmd)
+				}
+			}
+			s, cmd := l.fileSelector.Update(msg)
+			l.fileSelector = s.(*selector.Selector)
+			cmds = append(cmds, cmd)
 		}
 	case BackMsg:
-		if l.activeView == logViewDiff {
+		switch l.activeView {
+		case logViewDiff:
 			l.activeView = logViewCommits
 			l.selectedCommit = nil
+		case logViewFiles:
+			l.activeView = logViewDiff
 		}
 	case selector.ActiveMsg:
 		switch sel := msg.IdentifiableItem.(type) {
@@ -259,19 +340,26 @@ func (l *Log) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				l.selectCommitCmd(sel.Commit),
 				l.startLoading(),
 			)
+		case DiffFileItem:
+			if sel.index < len(l.fileOffsets) {
+				l.vp.SetYOffset(l.fileOffsets[sel.index])
+			}
+			l.activeView = logViewDiff
+			cmds = append(cmds, updateStatusBarCmd)
 		}
 	case LogCommitMsg:
 		l.selectedCommit = msg
 		cmds = append(cmds, l.loadDiffCmd)
 	case LogDiffMsg:
 		l.currentDiff = msg
-		l.vp.SetContent(
-			lipgloss.JoinVertical(lipgloss.Top,
-				l.renderCommit(l.selectedCommit),
-				l.renderSummary(msg),
-				l.renderDiff(msg),
-			),
-		)
+		l.diffPatches = nil
+		l.setDiffContent(msg)
+		items := make([]selector.IdentifiableItem, len(msg.Files))
+		for i, f := range msg.Files {
+			items[i] = DiffFileItem{DiffFile: f, index: i}
+		}
+		cmds = append(cmds, l.fileSelector.SetItems(items))
+		l.fileSelector.Select(0)
 		l.vp.GotoTop()
 		l.activeView = logViewDiff
 		cmds = append(cmds,
@@ -283,19 +371,13 @@ func (l *Log) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, l.updateCommitsCmd)
 	case tea.WindowSizeMsg:
 		if l.selectedCommit != nil && l.currentDiff != nil {
-			l.vp.SetContent(
-				lipgloss.JoinVertical(lipgloss.Top,
-					l.renderCommit(l.selectedCommit),
-					l.renderSummary(l.currentDiff),
-					l.renderDiff(l.currentDiff),
-				),
-			)
+			l.setDiffContent(l.currentDiff)
 		}
-		if l.repo != nil {
+		// Only reload commits when the number of commits per page changed.
+		if l.repo != nil && l.selector.PerPage() != l.perPage {
+			l.perPage = l.selector.PerPage()
 			cmds = append(cmds,
 				l.updateCommitsCmd,
-				// start loading on resize since the number of commits per page
-				// might change and we'd need to load more commits.
 				l.startLoading(),
 			)
 		}
@@ -333,6 +415,8 @@ func (l *Log) View() string {
 		return l.selector.View()
 	case logViewDiff:
 		return l.vp.View()
+	case logViewFiles:
+		return l.fileSelector.View()
 	default:
 		return ""
 	}
@@ -343,6 +427,11 @@ func (l *Log) StatusBarValue() string {
 	if l.loading {
 		return ""
 	}
+	if l.activeView == logViewFiles {
+		if i, ok := l.fileSelector.SelectedItem().(DiffFileItem); ok {
+			return i.Title()
+		}
+	}
 	c := l.activeCommit
 	if c == nil {
 		return ""
@@ -367,6 +456,8 @@ func (l *Log) StatusBarInfo() string {
 		return fmt.Sprintf("p. %d/%d", l.nextPage+1, l.selector.TotalPages())
 	case logViewDiff:
 		return fmt.Sprintf("☰ %.f%%", l.vp.ScrollPercent()*100)
+	case logViewFiles:
+		return fmt.Sprintf("# %d/%d", l.fileSelector.Index()+1, len(l.fileSelector.VisibleItems()))
 	default:
 		return ""
 	}
@@ -376,7 +467,7 @@ func (l *Log) countCommitsCmd() tea.Msg {
 	if l.ref == nil {
 		return common.ErrorMsg(errNoRef)
 	}
-	count, err := l.repo.CountCommits(l.ref)
+	count, err := l.repo.CountCommits(l.ref, l.path)
 	if err != nil {
 		return common.ErrorMsg(err)
 	}
@@ -401,7 +492,7 @@ func (l *Log) updateCommitsCmd() tea.Msg {
 	limit := l.selector.PerPage()
 	skip := page * limit
 	// CommitsByPage pages start at 1
-	cc, err := l.repo.CommitsByPage(l.ref, page+1, limit)
+	cc, err := l.repo.CommitsByPage(l.ref, l.path, page+1, limit)
 	if err != nil {
 		return common.ErrorMsg(err)
 	}
@@ -445,7 +536,7 @@ func (l *Log) renderCommit(c *ggit.Commit) string {
 		l.common.Styles.Log.CommitHash.Render("commit "+c.ID.String()),
 		l.common.Styles.Log.CommitAuthor.Render(fmt.Sprintf("Author: %s <%s>", c.Author.Name, c.Author.Email)),
 		l.common.Styles.Log.CommitDate.Render("Date:   "+c.Committer.When.Format(time.UnixDate)),
-		l.common.Styles.Log.CommitBody.Render(msg),
+		l.common.Styles.Log.CommitBody.Render(common.MarkLinks(msg)),
 	))
 	return wrap.String(s.String(), l.common.Width-2)
 }
@@ -464,18 +555,43 @@ func (l *Log) renderSummary(diff *ggit.Diff) string {
 	return wrap.String(strings.Join(stats, "\n"), l.common.Width-2)
 }
 
-func (l *Log) renderDiff(diff *ggit.Diff) string {
-	var s strings.Builder
+// setDiffContent renders the selected commit and its diff into the viewport
+// and records the line offset of each changed file.
+func (l *Log) setDiffContent(diff *ggit.Diff) {
+	parts := []string{
+		l.renderCommit(l.selectedCommit),
+		l.renderSummary(diff),
+		"",
+	}
+	offset := 0
+	for _, p := range parts {
+		offset += lipgloss.Height(p)
+	}
+	if len(l.diffPatches) != len(diff.Files) {
+		l.diffPatches = make([]string, len(diff.Files))
+		for i, f := range diff.Files {
+			l.diffPatches[i] = l.highlightPatch(f)
+		}
+	}
+	l.fileOffsets = make([]int, len(diff.Files))
+	for i := range diff.Files {
+		p := wrap.String(l.diffPatches[i], l.common.Width)
+		l.fileOffsets[i] = offset
+		offset += lipgloss.Height(p)
+		parts = append(parts, p)
+	}
+	l.vp.SetContent(lipgloss.JoinVertical(lipgloss.Top, parts...))
+}
+
+// highlightPatch returns the syntax highlighted patch of a file.
+func (l *Log) highlightPatch(file *ggit.DiffFile) string {
 	var pr strings.Builder
 	diffChroma := &gansi.CodeBlockElement{
-		Code:     diff.Patch(),
+		Code:     strings.TrimSuffix(file.Patch(), "\n"),
 		Language: "diff",
 	}
-	err := diffChroma.Render(&pr, renderCtx())
-	if err != nil {
-		s.WriteString(fmt.Sprintf("\n%s", err.Error()))
-	} else {
-		s.WriteString(fmt.Sprintf("\n%s", pr.String()))
+	if err := diffChroma.Render(&pr, renderCtx()); err != nil {
+		return err.Error()
 	}
-	return wrap.String(s.String(), l.common.Width)
+	return pr.String()
 }
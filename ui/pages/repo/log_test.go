@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/matryer/is"
+)
+
+func TestLogResize(t *testing.T) {
+	is := is.New(t)
+	_, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Add a file", map[string]string{
+			"a.txt": strings.Repeat("a long line ", 20) + "\n",
+		})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+	c, err := r.Commit(head.Hash.String())
+	is.NoErr(err)
+	diff, err := r.Diff(c)
+	is.NoErr(err)
+
+	l := NewLog(testCommon())
+	l.SetSize(80, 40)
+	l.selectedCommit = c
+	l.Update(LogDiffMsg(diff))
+	is.Equal(len(l.diffPatches), 1)
+
+	// Resizing wraps the highlighted patches again instead of highlighting
+	// them anew.
+	l.diffPatches[0] = "cached " + strings.Repeat("x", 50)
+	l.SetSize(40, 40)
+	l.Update(tea.WindowSizeMsg{Width: 40, Height: 40})
+	is.True(strings.Contains(l.vp.View(), "cached"))
+}
@@ -0,0 +1,337 @@
+package repo
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/alecthomas/chroma/lexers"
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/soft-serve/config"
+	ggit "github.com/charmbracelet/soft-serve/git"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/code"
+	"github.com/charmbracelet/soft-serve/ui/git"
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// overviewCommits is the number of recent commits shown in the overview.
+	overviewCommits = 5
+	// overviewReadmeLines is the number of readme lines shown in the overview.
+	overviewReadmeLines = 30
+	// overviewArtifacts is the number of recent artifacts shown in the
+	// overview.
+	overviewArtifacts = 5
+	// quotaBarWidth is the width of the quota usage bar.
+	quotaBarWidth = 30
+)
+
+// OverviewMsg is a message that contains the rendered overview of a repo.
+type OverviewMsg string
+
+// LatestTagMsg is a message that contains the latest tag of a repo, and the
+// commit it points to.
+type LatestTagMsg struct {
+	repo   string
+	tag    *ggit.Reference
+	commit *ggit.Commit
+}
+
+// Overview is a page that summarizes a repository.
+type Overview struct {
+	common common.Common
+	cfg    *config.Config
+	code   *code.Code
+	repo   git.GitRepo
+	ref    *ggit.Reference
+	path   string
+	// tag is the latest tag of the repo, found once when it's selected, as
+	// finding it reads the commit of every tag.
+	tag       *ggit.Reference
+	tagCommit *ggit.Commit
+}
+
+// NewOverview creates a new overview model.
+func NewOverview(cfg *config.Config, common common.Common) *Overview {
+	c := code.New(common, "", "")
+	c.NoContentStyle = c.NoContentStyle.SetString("Nothing to see here.")
+	return &Overview{
+		common: common,
+		cfg:    cfg,
+		code:   c,
+	}
+}
+
+// SetSize implements common.Component.
+func (o *Overview) SetSize(width, height int) {
+	o.common.SetSize(width, height)
+	o.code.SetSize(width, height)
+}
+
+// ShortHelp implements help.KeyMap.
+func (o *Overview) ShortHelp() []key.Binding {
+	return []key.Binding{
+		o.common.KeyMap.UpDown,
+	}
+}
+
+// FullHelp implements help.KeyMap.
+func (o *Overview) FullHelp() [][]key.Binding {
+	k := o.code.KeyMap
+	return [][]key.Binding{
+		{
+			k.PageDown,
+			k.PageUp,
+			k.HalfPageDown,
+			k.HalfPageUp,
+		},
+		{
+			k.Down,
+			k.Up,
+		},
+	}
+}
+
+// Init implements tea.Model.
+func (o *Overview) Init() tea.Cmd {
+	if o.repo == nil {
+		return common.ErrorCmd(git.ErrMissingRepo)
+	}
+	o.code.GotoTop()
+	return o.updateOverviewCmd
+}
+
+// Update implements tea.Model.
+func (o *Overview) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	cmds := make([]tea.Cmd, 0)
+	switch msg := msg.(type) {
+	case RepoMsg:
+		o.repo = git.GitRepo(msg)
+		o.path = ""
+		o.tag, o.tagCommit = nil, nil
+		cmds = append(cmds, o.latestTagCmd(o.repo))
+	case LatestTagMsg:
+		if o.repo != nil && msg.repo == o.repo.Repo() && msg.tag != nil {
+			o.tag, o.tagCommit = msg.tag, msg.commit
+			if o.ref != nil {
+				cmds = append(cmds, o.Init())
+			}
+		}
+	case RefMsg:
+		o.ref = msg
+		cmds = append(cmds, o.Init())
+	case ScopeMsg:
+		o.path = string(msg)
+		if o.ref != nil {
+			cmds = append(cmds, o.Init())
+		}
+	case OverviewMsg:
+		cmds = append(cmds, o.code.SetContent(string(msg), ".md"))
+	}
+	c, cmd := o.code.Update(msg)
+	o.code = c.(*code.Code)
+	if cmd != nil {
+		cmds = append(cmds, cmd)
+	}
+	return o, tea.Batch(cmds...)
+}
+
+// View implements tea.Model.
+func (o *Overview) View() string {
+	return o.code.View()
+}
+
+// StatusBarValue implements statusbar.StatusBar.
+func (o *Overview) StatusBarValue() string {
+	return ""
+}
+
+// StatusBarInfo implements statusbar.StatusBar.
+func (o *Overview) StatusBarInfo() string {
+	return fmt.Sprintf("☰ %.f%%", o.code.ScrollPercent()*100)
+}
+
+func (o *Overview) updateOverviewCmd() tea.Msg {
+	if o.ref == nil {
+		return common.ErrorMsg(errNoRef)
+	}
+	r := o.repo
+	s := strings.Builder{}
+	if desc := r.Description(); desc != "" {
+		fmt.Fprintf(&s, "%s\n\n", desc)
+	}
+
+	s.WriteString("## Clone\n\n```sh\n")
+	for _, u := range git.CloneURLs(o.cfg, r.Repo()) {
+		fmt.Fprintf(&s, "%s\n", git.CloneCommand(u))
+	}
+	s.WriteString("```\n\n")
+
+	head, err := r.HEAD()
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	hc, err := r.Commit(head.Hash.String())
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	fmt.Fprintf(&s, "## Default branch\n\n`%s` at `%s` %s (%s)\n\n",
+		head.Name().Short(),
+		hc.ID.String()[:7],
+		commitTitle(hc),
+		humanize.Time(hc.Committer.When),
+	)
+
+	if tag, tc := o.tag, o.tagCommit; tag != nil && o.cfg.FeatureEnabled(r.Repo(), config.FeatureReleases) {
+		fmt.Fprintf(&s, "## Latest release\n\n`%s` at `%s` (%s)\n\n",
+			tag.Name().Short(),
+			tc.ID.String()[:7],
+			humanize.Time(tc.Committer.When),
+		)
+	}
+
+	if m, ok := o.cfg.MirrorStatus(r.Repo()); ok {
+		fmt.Fprintf(&s, "## Mirror\n\nMirror of `%s`, synced every %s. %s\n\n", m.URL, m.Interval, mirrorSummary(m))
+	}
+
+	if u, ok, err := o.cfg.StoredQuotaUsage(r.Repo()); err == nil && ok {
+		fmt.Fprintf(&s, "## Quota\n\n`%s` %d%% of %s (%s used)\n\n",
+			usageBar(u.Percent(), quotaBarWidth),
+			u.Percent(),
+			humanize.Bytes(uint64(u.Max)),
+			humanize.Bytes(uint64(u.Used)),
+		)
+		switch {
+		case u.Exceeded():
+			s.WriteString("The repo is over its quota, pushes are rejected.\n\n")
+		case u.Warning():
+			s.WriteString("The repo is nearing its quota, pushes will be rejected once it's reached.\n\n")
+		}
+	}
+
+	if as, err := o.cfg.RepoArtifacts(r.Repo(), ""); err == nil && len(as) > 0 {
+		s.WriteString("## Artifacts\n\n")
+		web := git.WebURL(o.cfg, r.Repo())
+		for i, a := range as {
+			if i == overviewArtifacts {
+				fmt.Fprintf(&s, "* … and %d more, see `artifact list %s`\n", len(as)-i, r.Repo())
+				break
+			}
+			target := a.Commit[:7]
+			if a.Tag != "" {
+				target = a.Tag
+			}
+			name := fmt.Sprintf("`%s`", a.Name)
+			if web != "" {
+				name = fmt.Sprintf("[%s](%s/artifacts/%s/%s)", a.Name, web, a.Digest, url.PathEscape(a.Name))
+			}
+			fmt.Fprintf(&s, "* %s on `%s` — %s, %s\n",
+				name,
+				target,
+				humanize.IBytes(uint64(a.Size)),
+				humanize.Time(a.Created),
+			)
+		}
+		s.WriteString("\n")
+	}
+
+	cc, err := r.CommitsByPage(o.ref, o.path, 1, overviewCommits)
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	if len(cc) > 0 {
+		fmt.Fprintf(&s, "## Recent commits on `%s`", refName(o.ref))
+		if o.path != "" {
+			fmt.Fprintf(&s, " in `%s`", o.path)
+		}
+		s.WriteString("\n\n")
+		for _, c := range cc {
+			fmt.Fprintf(&s, "* `%s` %s — %s, %s\n",
+				c.ID.String()[:7],
+				commitTitle(c),
+				c.Author.Name,
+				humanize.Time(c.Committer.When),
+			)
+		}
+		s.WriteString("\n")
+	}
+
+	if rm, rp := r.Readme(); rm != "" {
+		lines := strings.Split(rm, "\n")
+		if len(lines) > overviewReadmeLines {
+			lines = append(lines[:overviewReadmeLines], "…")
+		}
+		preview := strings.Join(lines, "\n")
+		fmt.Fprintf(&s, "## Readme\n\n")
+		lexer := lexers.Match(rp)
+		if lexer != nil && lexer.Config() != nil && lexer.Config().Name == "markdown" {
+			s.WriteString(preview)
+		} else {
+			fmt.Fprintf(&s, "```\n%s\n```", preview)
+		}
+		s.WriteString("\n")
+	}
+	return OverviewMsg(s.String())
+}
+
+// usageBar returns a bar of the given width filled to percent.
+func usageBar(percent, width int) string {
+	n := percent * width / 100
+	if n > width {
+		n = width
+	}
+	return strings.Repeat("█", n) + strings.Repeat("░", width-n)
+}
+
+// commitTitle returns the first line of the commit message.
+func commitTitle(c *ggit.Commit) string {
+	return strings.Split(c.Message, "\n")[0]
+}
+
+func (o *Overview) latestTagCmd(r git.GitRepo) tea.Cmd {
+	return func() tea.Msg {
+		msg := LatestTagMsg{repo: r.Repo()}
+		if o.cfg.FeatureEnabled(r.Repo(), config.FeatureReleases) {
+			msg.tag, msg.commit = latestTag(r)
+		}
+		return msg
+	}
+}
+
+// latestTag returns the most recently committed tag of the repository and the
+// commit it points to.
+func latestTag(r git.GitRepo) (*ggit.Reference, *ggit.Commit) {
+	refs, err := r.References()
+	if err != nil {
+		return nil, nil
+	}
+	var tag *ggit.Reference
+	var commit *ggit.Commit
+	for _, ref := range refs {
+		if !ref.IsTag() {
+			continue
+		}
+		c, err := r.Commit(ref.TargetHash().String())
+		if err != nil {
+			continue
+		}
+		if commit == nil || c.Committer.When.After(commit.Committer.When) {
+			tag = ref
+			commit = c
+		}
+	}
+	return tag, commit
+}
+
+// mirrorSummary describes the last sync of a mirror.
+func mirrorSummary(m config.MirrorStatus) string {
+	switch {
+	case m.Error != "":
+		return fmt.Sprintf("The last sync failed %s: %s", humanize.Time(m.LastAttempt), m.Error)
+	case m.LastSync.IsZero():
+		return "It hasn't synced yet."
+	}
+	return fmt.Sprintf("Last synced %s.", humanize.Time(m.LastSync))
+}
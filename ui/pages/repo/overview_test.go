@@ -0,0 +1,200 @@
+package repo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/keymap"
+	"github.com/charmbracelet/soft-serve/ui/styles"
+	"github.com/matryer/is"
+)
+
+// testCommon returns the common.Common of a 80x24 terminal.
+func testCommon() common.Common {
+	return common.Common{
+		Styles: styles.DefaultStyles(),
+		KeyMap: keymap.DefaultKeyMap(),
+		Width:  80,
+		Height: 24,
+	}
+}
+
+// testRepo returns a config serving a repo named repo, and the repo. Its
+// content is pushed from a working directory set up by setup.
+func testRepo(t *testing.T, setup func(wd string)) (*config.Config, *config.Repo) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	is := is.New(t)
+	cfg, err := config.NewConfig(&sconfig.Config{
+		Host:     "localhost",
+		Port:     23231,
+		RepoPath: t.TempDir(),
+		KeyPath:  t.TempDir(),
+	})
+	is.NoErr(err)
+	_, err = cfg.Source.InitRepo("repo", true)
+	is.NoErr(err)
+	wd := t.TempDir()
+	runGit(t, wd, "init", "-q")
+	setup(wd)
+	runGit(t, wd, "push", "-q", "--tags", filepath.Join(cfg.Source.Dir(), "repo"), "HEAD:refs/heads/master")
+	is.NoErr(cfg.Reload())
+	r, err := cfg.Source.GetRepo("repo")
+	is.NoErr(err)
+	return cfg, r
+}
+
+// commitFiles writes files to the working directory wd and commits them.
+// Files with an empty content are deleted.
+func commitFiles(t *testing.T, wd, msg string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(wd, name)
+		if content == "" {
+			if err := os.Remove(p); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGit(t, wd, "add", "-A")
+	runGit(t, wd, "commit", "-q", "-m", msg)
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+}
+
+func TestOverview(t *testing.T) {
+	is := is.New(t)
+	cfg, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Initial commit", map[string]string{
+			"README.md": "# Repo\n\nA repo to summarize.\n",
+		})
+		runGit(t, wd, "tag", "v1.0.0")
+		commitFiles(t, wd, "Add main\n\nWith a body.", map[string]string{
+			"main.go": "package main\n",
+		})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+
+	o := NewOverview(cfg, testCommon())
+	o.Update(RepoMsg(r))
+	o.Update(o.latestTagCmd(r)())
+	// The overview needs a ref.
+	_, ok := o.updateOverviewCmd().(common.ErrorMsg)
+	is.True(ok)
+
+	o.ref = head
+	msg, ok := o.updateOverviewCmd().(OverviewMsg)
+	is.True(ok)
+	s := string(msg)
+	for _, want := range []string{
+		"## Clone\n\n```sh\ngit clone ssh://localhost:23231/repo\n```",
+		"## Default branch\n\n`master` at `" + head.Hash.String()[:7] + "` Add main",
+		"## Latest release\n\n`v1.0.0`",
+		"## Recent commits on `master`",
+		"` Add main — test, ",
+		"` Initial commit — test, ",
+		"## Readme\n\n# Repo\n\nA repo to summarize.",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("overview is missing %q:\n%s", want, s)
+		}
+	}
+	// Only the title of commit messages is shown.
+	is.True(!strings.Contains(s, "With a body."))
+}
+
+func TestOverviewScope(t *testing.T) {
+	is := is.New(t)
+	cfg, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Add docs", map[string]string{"docs/index.md": "# Docs\n"})
+		commitFiles(t, wd, "Add main", map[string]string{"main.go": "package main\n"})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+
+	o := NewOverview(cfg, testCommon())
+	o.Update(RepoMsg(r))
+	o.ref = head
+	o.Update(ScopeMsg("docs"))
+	msg, ok := o.updateOverviewCmd().(OverviewMsg)
+	is.True(ok)
+	s := string(msg)
+	is.True(strings.Contains(s, "## Recent commits on `master` in `docs`"))
+	is.True(strings.Contains(s, "` Add docs — "))
+	// Commits that don't touch the path are left out.
+	is.True(!strings.Contains(s, "` Add main — "))
+
+	// Opening a repo clears the scope.
+	o.Update(RepoMsg(r))
+	msg, ok = o.updateOverviewCmd().(OverviewMsg)
+	is.True(ok)
+	is.True(strings.Contains(string(msg), "` Add main — "))
+}
+
+func TestOverviewQuota(t *testing.T) {
+	is := is.New(t)
+	cfg, r := testRepo(t, func(wd string) {
+		commitFiles(t, wd, "Initial commit", map[string]string{"README.md": "# Repo\n"})
+	})
+	head, err := r.HEAD()
+	is.NoErr(err)
+	cfg.Cfg.DataPath = t.TempDir()
+	cfg.Quota = config.Quota{MaxSize: "1B"}
+	o := NewOverview(cfg, testCommon())
+	o.repo = r
+	o.ref = head
+	// Usage is shown once it's measured.
+	msg, ok := o.updateOverviewCmd().(OverviewMsg)
+	is.True(ok)
+	is.True(!strings.Contains(string(msg), "## Quota"))
+
+	is.NoErr(cfg.MeasureDiskUsage())
+	msg, ok = o.updateOverviewCmd().(OverviewMsg)
+	is.True(ok)
+	is.True(strings.Contains(string(msg), "## Quota\n\n`"+usageBar(100, quotaBarWidth)+"` "))
+	is.True(strings.Contains(string(msg), "The repo is over its quota, pushes are rejected."))
+}
+
+func TestUsageBar(t *testing.T) {
+	is := is.New(t)
+	is.Equal(usageBar(0, 4), "░░░░")
+	is.Equal(usageBar(50, 4), "██░░")
+	is.Equal(usageBar(250, 4), "████")
+}
+
+func TestMirrorSummary(t *testing.T) {
+	is := is.New(t)
+	now := time.Now()
+	is.Equal(mirrorSummary(config.MirrorStatus{}), "It hasn't synced yet.")
+	is.Equal(mirrorSummary(config.MirrorStatus{LastSync: now.Add(-time.Hour)}), "Last synced 1 hour ago.")
+	is.Equal(mirrorSummary(config.MirrorStatus{
+		LastSync:    now.Add(-time.Hour),
+		LastAttempt: now.Add(-time.Minute),
+		Error:       "repository not found",
+	}), "The last sync failed 1 minute ago: repository not found")
+}
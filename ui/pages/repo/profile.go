@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"sort"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var switchProfile = key.NewBinding(
+	key.WithKeys("p"),
+	key.WithHelp("p", "switch profile"),
+)
+
+// ProfileMsg is a message that scopes the repository to a named profile.
+type ProfileMsg struct {
+	name string
+	path string
+}
+
+// profiles returns the sorted profile names of the selected repository.
+func (r *Repo) profiles() []string {
+	if r.selectedRepo == nil {
+		return nil
+	}
+	ps := r.cfg.RepoProfiles(r.selectedRepo.Repo())
+	names := make([]string, 0, len(ps))
+	for n := range ps {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nextProfileCmd switches to the profile after the current one, going back
+// to the whole repository after the last one.
+func (r *Repo) nextProfileCmd() tea.Msg {
+	names := r.profiles()
+	if len(names) == 0 {
+		return nil
+	}
+	next := ""
+	if r.profile == "" {
+		next = names[0]
+	} else {
+		for i, n := range names {
+			if n == r.profile && i+1 < len(names) {
+				next = names[i+1]
+			}
+		}
+	}
+	return ProfileMsg{
+		name: next,
+		path: r.cfg.RepoProfiles(r.selectedRepo.Repo())[next],
+	}
+}
@@ -2,22 +2,56 @@ package repo
 
 import (
 	"fmt"
+	"path"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/soft-serve/ui/common"
 	"github.com/charmbracelet/soft-serve/ui/components/code"
+	"github.com/charmbracelet/soft-serve/ui/components/tabs"
 	"github.com/charmbracelet/soft-serve/ui/git"
 )
 
 type ReadmeMsg struct{}
 
-// Readme is the readme component page.
+// DocsMsg is a message that contains the documents shown next to the readme.
+type DocsMsg []doc
+
+// doc is a document shown in the readme tab.
+type doc struct {
+	path    string
+	content string
+}
+
+// docNames are the prefixes of the names of the documents shown next to the
+// readme, in order.
+var docNames = []string{"CONTRIBUTING", "SECURITY"}
+
+// docDirs are the directories documents are looked up in, in order.
+var docDirs = []string{"", ".github", "docs"}
+
+var (
+	nextDoc = key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "next document"),
+	)
+	prevDoc = key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "previous document"),
+	)
+)
+
+// Readme is the readme component page. It also shows the contribution
+// guidelines and security policy of the repository, if any, as sub-tabs.
 type Readme struct {
 	common common.Common
 	code   *code.Code
 	ref    RefMsg
 	repo   git.GitRepo
+	docs   []doc
+	active int
 }
 
 // NewReadme creates a new readme model.
@@ -33,6 +67,10 @@ func NewReadme(common common.Common) *Readme {
 // SetSize implements common.Component.
 func (r *Readme) SetSize(width, height int) {
 	r.common.SetSize(width, height)
+	if len(r.docs) > 1 {
+		// -1 for the document tabs
+		height--
+	}
 	r.code.SetSize(width, height)
 }
 
@@ -41,6 +79,9 @@ func (r *Readme) ShortHelp() []key.Binding {
 	b := []key.Binding{
 		r.common.KeyMap.UpDown,
 	}
+	if len(r.docs) > 1 {
+		b = append(b, nextDoc)
+	}
 	return b
 }
 
@@ -59,6 +100,12 @@ func (r *Readme) FullHelp() [][]key.Binding {
 			k.Up,
 		},
 	}
+	if len(r.docs) > 1 {
+		b = append(b, []key.Binding{
+			nextDoc,
+			prevDoc,
+		})
+	}
 	return b
 }
 
@@ -68,10 +115,14 @@ func (r *Readme) Init() tea.Cmd {
 		return common.ErrorCmd(git.ErrMissingRepo)
 	}
 	rm, rp := r.repo.Readme()
+	r.docs = []doc{{rp, rm}}
+	r.active = 0
+	r.SetSize(r.common.Width, r.common.Height)
 	r.code.GotoTop()
 	return tea.Batch(
 		r.code.SetContent(rm, rp),
 		r.updateReadmeCmd,
+		r.updateDocsCmd,
 	)
 }
 
@@ -85,6 +136,24 @@ func (r *Readme) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case RefMsg:
 		r.ref = msg
 		cmds = append(cmds, r.Init())
+	case DocsMsg:
+		r.docs = append(r.docs[:1], msg...)
+		r.SetSize(r.common.Width, r.common.Height)
+	case tea.KeyMsg:
+		switch {
+		case key.Matches(msg, nextDoc) && len(r.docs) > 1:
+			cmds = append(cmds, r.selectDoc((r.active+1)%len(r.docs)))
+		case key.Matches(msg, prevDoc) && len(r.docs) > 1:
+			cmds = append(cmds, r.selectDoc((r.active-1+len(r.docs))%len(r.docs)))
+		}
+	case tea.MouseMsg:
+		if msg.Type == tea.MouseLeft && len(r.docs) > 1 {
+			for i, d := range r.docs {
+				if r.common.Zone.Get(docZone(d)).InBounds(msg) {
+					cmds = append(cmds, r.selectDoc(i))
+				}
+			}
+		}
 	}
 	c, cmd := r.code.Update(msg)
 	r.code = c.(*code.Code)
@@ -96,7 +165,77 @@ func (r *Readme) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 // View implements tea.Model.
 func (r *Readme) View() string {
-	return r.code.View()
+	if len(r.docs) < 2 {
+		return r.code.View()
+	}
+	names := make([]string, len(r.docs))
+	for i, d := range r.docs {
+		names[i] = docZone(d)
+	}
+	// The tabs component only renders the document names, switching
+	// documents is handled here so it doesn't take over the tab key.
+	t := tabs.New(r.common, names)
+	t.Update(tabs.SelectTabMsg(r.active))
+	return lipgloss.JoinVertical(lipgloss.Left, t.View(), r.code.View())
+}
+
+// selectDoc shows the document at index i.
+func (r *Readme) selectDoc(i int) tea.Cmd {
+	r.active = i
+	r.code.GotoTop()
+	d := r.docs[i]
+	return r.code.SetContent(d.content, d.path)
+}
+
+// docZone returns the name of the tab of d, also used as its mouse zone.
+func docZone(d doc) string {
+	if d.path == "" {
+		return "README"
+	}
+	return path.Base(d.path)
+}
+
+// updateDocsCmd finds the documents shown next to the readme at the
+// selected reference.
+func (r *Readme) updateDocsCmd() tea.Msg {
+	if r.repo == nil || r.ref == nil || r.repo.IsEmpty() {
+		return nil
+	}
+	docs := make(DocsMsg, 0)
+	for _, name := range docNames {
+		if d, ok := r.findDoc(name); ok {
+			docs = append(docs, d)
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	return docs
+}
+
+// findDoc returns the first file whose name starts with name in docDirs.
+func (r *Readme) findDoc(name string) (doc, bool) {
+	for _, dir := range docDirs {
+		t, err := r.repo.Tree(r.ref, dir)
+		if err != nil {
+			continue
+		}
+		ents, err := t.Entries()
+		if err != nil {
+			continue
+		}
+		for _, e := range ents {
+			if e.IsTree() || !strings.HasPrefix(strings.ToUpper(e.Name()), name) {
+				continue
+			}
+			bts, err := e.Contents()
+			if err != nil {
+				return doc{}, false
+			}
+			return doc{path.Join(dir, e.Name()), string(bts)}, true
+		}
+	}
+	return doc{}, false
 }
 
 // StatusBarValue implements statusbar.StatusBar.
@@ -7,15 +7,19 @@ import (
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
 	ggit "github.com/charmbracelet/soft-serve/git"
 	"github.com/charmbracelet/soft-serve/ui/common"
 	"github.com/charmbracelet/soft-serve/ui/components/footer"
 	"github.com/charmbracelet/soft-serve/ui/components/statusbar"
 	"github.com/charmbracelet/soft-serve/ui/components/tabs"
 	"github.com/charmbracelet/soft-serve/ui/git"
+	wgit "github.com/charmbracelet/wish/git"
+	"github.com/gliderlabs/ssh"
 )
 
 type state int
@@ -28,24 +32,37 @@ const (
 type tab int
 
 const (
-	readmeTab tab = iota
+	overviewTab tab = iota
+	readmeTab
 	filesTab
 	commitsTab
 	branchesTab
 	tagsTab
+	dependenciesTab
 	lastTab
 )
 
 func (t tab) String() string {
 	return []string{
+		"Overview",
 		"Readme",
 		"Files",
 		"Commits",
 		"Branches",
 		"Tags",
+		"Dependencies",
 	}[t]
 }
 
+// tabNames returns the names of tabs.
+func tabNames(ts []tab) []string {
+	names := make([]string, len(ts))
+	for i, t := range ts {
+		names[i] = t.String()
+	}
+	return names
+}
+
 // CopyURLMsg is a message to copy the URL of the current repository.
 type CopyURLMsg struct{}
 
@@ -61,54 +78,128 @@ type RepoMsg git.GitRepo
 // RefMsg is a message that contains a git.Reference.
 type RefMsg *ggit.Reference
 
+// ScopeMsg is a message that scopes the files and commits of the repository
+// to a path.
+type ScopeMsg string
+
 // BackMsg is a message to go back to the previous view.
 type BackMsg struct{}
 
+// RefreshMsg is a message that contains a freshly loaded repository.
+type RefreshMsg struct {
+	repo git.GitRepo
+}
+
+var (
+	refresh = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "refresh"),
+	)
+)
+
 // Repo is a view for a git repository.
 type Repo struct {
 	common       common.Common
 	cfg          *config.Config
+	pk           ssh.PublicKey
 	selectedRepo git.GitRepo
-	activeTab    tab
-	tabs         *tabs.Tabs
-	statusbar    *statusbar.StatusBar
-	panes        []common.Component
-	ref          *ggit.Reference
-	copyURL      time.Time
+	// access is the access level of the user to the selected repository.
+	access wgit.AccessLevel
+	// url is the index of the clone URL shown in the header, cycled through
+	// with the SwitchURL key.
+	url       int
+	activeTab tab
+	tabs      *tabs.Tabs
+	// shown are the tabs shown for the selected repository, in order. Tabs of
+	// features the repository turned off are hidden.
+	shown     []tab
+	statusbar *statusbar.StatusBar
+	panes     []common.Component
+	ref       *ggit.Reference
+	copyURL   time.Time
+	// stale is true when the repository has been pushed to since it was
+	// loaded.
+	stale bool
+	// empty is true when the repository has no commits yet.
+	empty bool
+	// prompt reads the branch, tag, or commit to browse.
+	prompt    textinput.Model
+	prompting bool
+	// scope is the path the repository is browsed at, if any.
+	scope string
+	// profile is the name of the profile scope comes from, if any.
+	profile string
+	// actions are the custom actions the user can run on the repository.
+	actions    []config.Action
+	actionMenu actionMenu
 }
 
-// New returns a new Repo.
-func New(cfg *config.Config, c common.Common) *Repo {
+// New returns a new Repo for the user with the given public key.
+func New(cfg *config.Config, pk ssh.PublicKey, c common.Common) *Repo {
 	sb := statusbar.New(c)
-	ts := make([]string, lastTab)
 	// Tabs must match the order of tab constants above.
-	for i, t := range []tab{readmeTab, filesTab, commitsTab, branchesTab, tagsTab} {
-		ts[i] = t.String()
-	}
-	tb := tabs.New(c, ts)
+	shown := []tab{overviewTab, readmeTab, filesTab, commitsTab, branchesTab, tagsTab, dependenciesTab}
+	tb := tabs.New(c, tabNames(shown))
+	overview := NewOverview(cfg, c)
 	readme := NewReadme(c)
 	log := NewLog(c)
 	files := NewFiles(c)
 	branches := NewRefs(c, ggit.RefsHeads)
 	tags := NewRefs(c, ggit.RefsTags)
+	dependencies := NewDependencies(cfg, c)
 	// Make sure the order matches the order of tab constants above.
 	panes := []common.Component{
+		overview,
 		readme,
 		files,
 		log,
 		branches,
 		tags,
+		dependencies,
 	}
 	r := &Repo{
 		cfg:       cfg,
+		pk:        pk,
 		common:    c,
 		tabs:      tb,
+		shown:     shown,
 		statusbar: sb,
 		panes:     panes,
+		prompt:    newRefPrompt(),
 	}
 	return r
 }
 
+// setTabs shows the tabs of the features the selected repository has turned
+// on. The overview is activated if the active tab gets hidden.
+func (r *Repo) setTabs() {
+	shown := make([]tab, 0, lastTab)
+	for t := overviewTab; t < lastTab; t++ {
+		if t == tagsTab && !r.cfg.FeatureEnabled(r.selectedRepo.Repo(), config.FeatureReleases) {
+			continue
+		}
+		shown = append(shown, t)
+	}
+	r.shown = shown
+	r.tabs.SetTabs(tabNames(shown))
+	i := r.tabIndex(r.activeTab)
+	if i < 0 {
+		r.activeTab, i = overviewTab, 0
+	}
+	r.tabs.Update(tabs.SelectTabMsg(i))
+}
+
+// tabIndex returns the index of a tab among the tabs shown, or -1 if it's
+// hidden.
+func (r *Repo) tabIndex(t tab) int {
+	for i, s := range r.shown {
+		if s == t {
+			return i
+		}
+	}
+	return -1
+}
+
 // SetSize implements common.Component.
 func (r *Repo) SetSize(width, height int) {
 	r.common.SetSize(width, height)
@@ -131,6 +222,26 @@ func (r *Repo) commonHelp() []key.Binding {
 	tab.SetHelp("tab", "switch tab")
 	b = append(b, back)
 	b = append(b, tab)
+	if r.selectedRepo != nil && r.canClone() && len(git.CloneURLs(r.cfg, r.selectedRepo.Repo())) > 1 {
+		b = append(b, r.common.KeyMap.SwitchURL)
+	}
+	if !r.empty {
+		b = append(b, gotoRef)
+		if len(r.profiles()) > 0 {
+			b = append(b, switchProfile)
+		}
+	}
+	if r.stale {
+		b = append(b, refresh)
+	}
+	if len(r.actions) > 0 {
+		b = append(b, openActions)
+	}
+	if r.empty && r.canPush() {
+		cp := r.common.KeyMap.Copy
+		cp.SetHelp("c", "copy push commands")
+		b = append(b, cp)
+	}
 	return b
 }
 
@@ -163,7 +274,15 @@ func (r *Repo) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case RepoMsg:
 		r.activeTab = 0
+		r.stale = false
 		r.selectedRepo = git.GitRepo(msg)
+		r.access = r.cfg.AuthRepo(r.selectedRepo.Repo(), r.pk)
+		r.empty = r.selectedRepo.IsEmpty()
+		r.scope = ""
+		r.profile = ""
+		r.actions = r.cfg.RepoActions(r.selectedRepo.Repo(), r.pk)
+		r.actionMenu = actionMenu{}
+		r.setTabs()
 		cmds = append(cmds,
 			r.tabs.Init(),
 			r.updateRefCmd,
@@ -178,21 +297,95 @@ func (r *Repo) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			r.updateStatusBarCmd,
 			r.updateModels(msg),
 		)
+	case ScopeMsg:
+		r.scope = string(msg)
+		r.profile = ""
+		cmds = append(cmds,
+			r.updateStatusBarCmd,
+			r.updateModels(msg),
+		)
+	case ProfileMsg:
+		r.scope = msg.path
+		r.profile = msg.name
+		cmds = append(cmds,
+			r.updateStatusBarCmd,
+			r.updateModels(ScopeMsg(msg.path)),
+		)
 	case tabs.SelectTabMsg:
-		r.activeTab = tab(msg)
-		t, cmd := r.tabs.Update(msg)
-		r.tabs = t.(*tabs.Tabs)
-		if cmd != nil {
-			cmds = append(cmds, cmd)
+		// Tabs are selected by constant, and the tabs component by index.
+		if i := r.tabIndex(tab(msg)); i >= 0 {
+			r.activeTab = tab(msg)
+			t, cmd := r.tabs.Update(tabs.SelectTabMsg(i))
+			r.tabs = t.(*tabs.Tabs)
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
 		}
 	case tabs.ActiveTabMsg:
-		r.activeTab = tab(msg)
+		if int(msg) < len(r.shown) {
+			r.activeTab = r.shown[msg]
+		}
 		if r.selectedRepo != nil {
 			cmds = append(cmds,
 				r.updateStatusBarCmd,
 			)
 		}
+	case RefreshMsg:
+		r.stale = false
+		r.selectedRepo = msg.repo
+		r.empty = msg.repo.IsEmpty()
+		cmds = append(cmds,
+			r.updateModels(RepoMsg(msg.repo)),
+			r.refreshRefCmd,
+		)
+	case events.Event:
+		if msg.Type == events.Push && r.selectedRepo != nil && msg.Repo == r.selectedRepo.Repo() {
+			r.stale = true
+		}
+		if msg.Type == events.ConfigUpdated && r.selectedRepo != nil {
+			r.setTabs()
+		}
+		if msg.Type == events.ConfigUpdated && r.selectedRepo != nil && !r.actionMenu.open {
+			r.actions = r.cfg.RepoActions(r.selectedRepo.Repo(), r.pk)
+		}
+	case ActionResultMsg:
+		r.actionMenu.running = false
+		r.actionMenu.status = actionStatus(msg)
 	case tea.KeyMsg, tea.MouseMsg:
+		if kmsg, ok := msg.(tea.KeyMsg); ok && r.prompting {
+			return r, r.updatePrompt(kmsg)
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && r.actionMenu.open {
+			return r, r.updateActions(kmsg)
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && len(r.actions) > 0 && key.Matches(kmsg, openActions) {
+			r.actionMenu = actionMenu{open: true}
+			return r, nil
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && !r.empty && r.selectedRepo != nil && key.Matches(kmsg, gotoRef) {
+			r.prompting = true
+			r.prompt.Reset()
+			return r, r.prompt.Focus()
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && !r.empty && key.Matches(kmsg, switchProfile) {
+			cmds = append(cmds, r.nextProfileCmd)
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && r.selectedRepo != nil && key.Matches(kmsg, r.common.KeyMap.SwitchURL) {
+			r.url++
+		}
+		if kmsg, ok := msg.(tea.KeyMsg); ok && r.stale && key.Matches(kmsg, refresh) {
+			cmds = append(cmds, r.refreshCmd)
+		}
+		if r.empty && r.canPush() {
+			kmsg, isKey := msg.(tea.KeyMsg)
+			mmsg, isMouse := msg.(tea.MouseMsg)
+			if (isKey && key.Matches(kmsg, r.common.KeyMap.Copy)) ||
+				(isMouse && mmsg.Type == tea.MouseLeft &&
+					r.common.Zone.Get(fmt.Sprintf("%s-push", r.selectedRepo.Repo())).InBounds(mmsg)) {
+				r.common.Copy.Copy(git.PushCommands(r.cfg.Host, r.cfg.Port, r.selectedRepo.Repo()))
+				cmds = append(cmds, r.resetURLCmd())
+			}
+		}
 		t, cmd := r.tabs.Update(msg)
 		r.tabs = t.(*tabs.Tabs)
 		if cmd != nil {
@@ -201,7 +394,7 @@ func (r *Repo) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if r.selectedRepo != nil {
 			cmds = append(cmds, r.updateStatusBarCmd)
 			urlID := fmt.Sprintf("%s-url", r.selectedRepo.Repo())
-			if msg, ok := msg.(tea.MouseMsg); ok && r.common.Zone.Get(urlID).InBounds(msg) {
+			if msg, ok := msg.(tea.MouseMsg); ok && r.canClone() && r.common.Zone.Get(urlID).InBounds(msg) {
 				cmds = append(cmds, r.copyURLCmd())
 			}
 		}
@@ -221,12 +414,35 @@ func (r *Repo) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 	case CopyURLMsg:
-		r.common.Copy.Copy(
-			git.RepoURL(r.cfg.Host, r.cfg.Port, r.selectedRepo.Repo()),
-		)
+		r.common.Copy.Copy(r.cloneCommand())
 	case ResetURLMsg:
 		r.copyURL = time.Time{}
+	case EditFileMsg:
+		if r.selectedRepo != nil {
+			r.common.Copy.Copy(
+				git.EditCommand(r.cfg.Host, r.cfg.Port, r.selectedRepo.Repo(), msg.path, msg.line),
+			)
+			cmds = append(cmds, r.resetURLCmd())
+		}
 	case ReadmeMsg:
+	case OverviewMsg, LatestTagMsg:
+		o, cmd := r.panes[overviewTab].Update(msg)
+		r.panes[overviewTab] = o.(*Overview)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case DependenciesMsg:
+		d, cmd := r.panes[dependenciesTab].Update(msg)
+		r.panes[dependenciesTab] = d.(*Dependencies)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case DocsMsg:
+		m, cmd := r.panes[readmeTab].Update(msg)
+		r.panes[readmeTab] = m.(*Readme)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	case FileItemsMsg:
 		f, cmd := r.panes[filesTab].Update(msg)
 		r.panes[filesTab] = f.(*Files)
@@ -272,6 +488,14 @@ func (r *Repo) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	if cmd != nil {
 		cmds = append(cmds, cmd)
 	}
+	if r.prompting {
+		// Keep the cursor blinking.
+		p, cmd := r.prompt.Update(msg)
+		r.prompt = p
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
 	return r, tea.Batch(cmds...)
 }
 
@@ -289,15 +513,29 @@ func (r *Repo) View() string {
 		r.common.Styles.Tabs.GetVerticalFrameSize()
 	mainStyle := repoBodyStyle.
 		Height(r.common.Height - hm)
+	body := r.panes[r.activeTab].View()
+	if r.empty && r.selectedRepo != nil {
+		body = r.emptyView()
+	}
+	if r.actionMenu.open {
+		body = r.actionsView()
+	}
 	main := r.common.Zone.Mark(
 		"repo-main",
-		mainStyle.Render(r.panes[r.activeTab].View()),
+		mainStyle.Render(body),
 	)
+	bottom := r.statusbar.View()
+	if r.prompting {
+		bottom = lipgloss.NewStyle().
+			Width(r.common.Width).
+			MaxHeight(1).
+			Render(r.prompt.View())
+	}
 	view := lipgloss.JoinVertical(lipgloss.Top,
 		r.headerView(),
 		r.tabs.View(),
 		main,
-		r.statusbar.View(),
+		bottom,
 	)
 	return s.Render(view)
 }
@@ -306,7 +544,6 @@ func (r *Repo) headerView() string {
 	if r.selectedRepo == nil {
 		return ""
 	}
-	cfg := r.cfg
 	truncate := lipgloss.NewStyle().MaxWidth(r.common.Width)
 	name := r.common.Styles.Repo.HeaderName.Render(r.selectedRepo.Name())
 	desc := r.selectedRepo.Description()
@@ -316,14 +553,19 @@ func (r *Repo) headerView() string {
 	} else {
 		desc = r.common.Styles.Repo.HeaderDesc.Render(desc)
 	}
+	if a := common.AccessBadge(r.access); a != "" {
+		desc += " " + r.common.Styles.AccessBadge.Render(a)
+	}
 	urlStyle := r.common.Styles.URLStyle.Copy().
 		Width(r.common.Width - lipgloss.Width(desc) - 1).
 		Align(lipgloss.Right)
-	url := git.RepoURL(cfg.Host, cfg.Port, r.selectedRepo.Repo())
+	url := r.cloneCommand()
 	if !r.copyURL.IsZero() && r.copyURL.Add(time.Second).After(time.Now()) {
 		url = "copied!"
+	} else if r.stale {
+		url = "new changes pushed, press r to refresh"
 	}
-	url = common.TruncateString(url, r.common.Width-lipgloss.Width(desc)-1)
+	url = common.TruncateString(common.MarkLinks(url), r.common.Width-lipgloss.Width(desc)-1)
 	url = r.common.Zone.Mark(
 		fmt.Sprintf("%s-url", r.selectedRepo.Repo()),
 		urlStyle.Render(url),
@@ -348,18 +590,26 @@ func (r *Repo) updateStatusBarCmd() tea.Msg {
 	info := r.panes[r.activeTab].(statusbar.Model).StatusBarInfo()
 	ref := ""
 	if r.ref != nil {
-		ref = r.ref.Name().Short()
+		ref = refName(r.ref)
+	}
+	branch := fmt.Sprintf("* %s", ref)
+	switch {
+	case r.profile != "":
+		branch += fmt.Sprintf(" [%s]", r.profile)
+	case r.scope != "":
+		branch += fmt.Sprintf(" /%s", r.scope)
 	}
 	return statusbar.StatusBarMsg{
 		Key:    r.selectedRepo.Repo(),
 		Value:  value,
 		Info:   info,
-		Branch: fmt.Sprintf("* %s", ref),
+		Branch: branch,
 	}
 }
 
 func (r *Repo) updateRefCmd() tea.Msg {
-	if r.selectedRepo == nil {
+	// Empty repositories have no HEAD to show.
+	if r.selectedRepo == nil || r.selectedRepo.IsEmpty() {
 		return nil
 	}
 	head, err := r.selectedRepo.HEAD()
@@ -369,6 +619,42 @@ func (r *Repo) updateRefCmd() tea.Msg {
 	return RefMsg(head)
 }
 
+// refreshCmd reloads the selected repository from the source.
+func (r *Repo) refreshCmd() tea.Msg {
+	if r.selectedRepo == nil {
+		return nil
+	}
+	rr, err := r.cfg.Source.GetRepo(r.selectedRepo.Repo())
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	return RefreshMsg{repo: rr}
+}
+
+// refreshRefCmd reloads the currently selected reference, falling back to
+// HEAD if it no longer exists.
+func (r *Repo) refreshRefCmd() tea.Msg {
+	if r.selectedRepo == nil {
+		return nil
+	}
+	// Commits don't move.
+	if r.ref != nil && r.ref.IsDetached() {
+		return RefMsg(r.ref)
+	}
+	if r.ref != nil {
+		refs, err := r.selectedRepo.References()
+		if err != nil {
+			return common.ErrorMsg(err)
+		}
+		for _, ref := range refs {
+			if ref.Name() == r.ref.Name() {
+				return RefMsg(ref)
+			}
+		}
+	}
+	return r.updateRefCmd()
+}
+
 func (r *Repo) updateModels(msg tea.Msg) tea.Cmd {
 	cmds := make([]tea.Cmd, 0)
 	for i, b := range r.panes {
@@ -381,18 +667,44 @@ func (r *Repo) updateModels(msg tea.Msg) tea.Cmd {
 	return tea.Batch(cmds...)
 }
 
+// canClone reports whether the user can clone the selected repository.
+func (r *Repo) canClone() bool {
+	return r.access >= wgit.ReadOnlyAccess
+}
+
+// cloneCommand returns the clone command shown in the header, or an empty
+// string if the user can't clone the selected repository.
+func (r *Repo) cloneCommand() string {
+	if !r.canClone() {
+		return ""
+	}
+	urls := git.CloneURLs(r.cfg, r.selectedRepo.Repo())
+	return git.CloneCommand(urls[r.url%len(urls)])
+}
+
+// canPush reports whether the user can push to the selected repository.
+func (r *Repo) canPush() bool {
+	return r.access >= wgit.ReadWriteAccess
+}
+
 func (r *Repo) copyURLCmd() tea.Cmd {
-	r.copyURL = time.Now()
 	return tea.Batch(
 		func() tea.Msg {
 			return CopyURLMsg{}
 		},
-		tea.Tick(time.Second, func(time.Time) tea.Msg {
-			return ResetURLMsg{}
-		}),
+		r.resetURLCmd(),
 	)
 }
 
+// resetURLCmd shows the "copied!" notice in the header and resets it after a
+// second.
+func (r *Repo) resetURLCmd() tea.Cmd {
+	r.copyURL = time.Now()
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return ResetURLMsg{}
+	})
+}
+
 func updateStatusBarCmd() tea.Msg {
 	return UpdateStatusBarMsg{}
 }
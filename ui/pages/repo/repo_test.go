@@ -0,0 +1,116 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/uitest"
+)
+
+var sizes = []struct{ width, height int }{
+	{60, 20},
+	{80, 24},
+	{120, 40},
+}
+
+func TestRepoGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%dx%d", size.width, size.height), func(t *testing.T) {
+			r, err := cfg.Source.GetRepo("soft-serve")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := uitest.Common(t, size.width, size.height)
+			m := uitest.New(t, New(cfg, nil, c), c, spinner.TickMsg{})
+			m.Send(RepoMsg(r))
+			m.RequireGolden("overview")
+			m.Type("tab", "tab")
+			m.RequireGolden("files")
+			m.Type("tab")
+			m.RequireGolden("log")
+			m.Type("down", "enter")
+			m.RequireGolden("diff")
+		})
+	}
+}
+
+func TestEmptyRepoGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%dx%d", size.width, size.height), func(t *testing.T) {
+			r, err := cfg.Source.GetRepo("empty")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := uitest.Common(t, size.width, size.height)
+			m := uitest.New(t, New(cfg, uitest.AdminKey(t), c), c, spinner.TickMsg{})
+			m.Send(RepoMsg(r))
+			m.RequireGolden("empty")
+			// Users who can't push aren't shown the push commands.
+			c = uitest.Common(t, size.width, size.height)
+			m = uitest.New(t, New(cfg, nil, c), c, spinner.TickMsg{})
+			m.Send(RepoMsg(r))
+			m.RequireGolden("empty-read-only")
+		})
+	}
+}
+
+func TestDocsGolden(t *testing.T) {
+	cfg := uitest.Config(t, map[string][]uitest.Commit{
+		"docs": {
+			{
+				Message: "Add docs",
+				Files: map[string]string{
+					"README.md":           "# Docs\n\nA repo with guidelines.\n",
+					"CONTRIBUTING.md":     "# Contributing\n\nSend patches.\n",
+					".github/SECURITY.md": "# Security\n\nReport issues privately.\n",
+				},
+			},
+		},
+	})
+	r, err := cfg.Source.GetRepo("docs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, nil, c), c, spinner.TickMsg{})
+	m.Send(RepoMsg(r))
+	m.Type("tab")
+	m.RequireGolden("readme")
+	m.Type("]")
+	m.RequireGolden("contributing")
+	m.Type("]")
+	m.RequireGolden("security")
+	m.Type("[", "[")
+	m.RequireGolden("readme")
+}
+
+func TestActionsGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	cfg.Actions = []config.Action{
+		{Name: "Trigger deploy", Command: "echo deploying {{ .Repo }}", Access: "read-only"},
+		{Name: "Rebuild docs", Command: "echo rebuilt", Access: "read-only"},
+		{Name: "Rotate secrets", Command: "true", Access: "admin-access"},
+	}
+	r, err := cfg.Source.GetRepo("soft-serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, nil, c), c, spinner.TickMsg{})
+	m.Send(RepoMsg(r))
+	// Only the actions the user has access to are listed.
+	m.Type("x")
+	m.RequireGolden("menu")
+	m.Type("enter")
+	m.RequireGolden("done")
+	// Closing the menu shows the tab again, instead of going back.
+	m.Type("esc")
+	if v := m.View(); strings.Contains(v, "Trigger deploy") || !strings.Contains(v, "Recent commits") {
+		t.Fatalf("action menu still open:\n%s", v)
+	}
+}
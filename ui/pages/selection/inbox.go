@@ -0,0 +1,155 @@
+package selection
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	markRead = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "mark read"),
+	)
+	markAllRead = key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "mark all read"),
+	)
+)
+
+// NotificationsMsg is a message that contains the notifications of the user.
+type NotificationsMsg []selector.IdentifiableItem
+
+// NotificationItem is a notification item. Selecting it opens its repo and
+// marks it read.
+type NotificationItem struct {
+	config.Notification
+}
+
+// ID implements selector.IdentifiableItem.
+func (i NotificationItem) ID() string {
+	return i.Notification.ID
+}
+
+// Title implements list.DefaultItem.
+func (i NotificationItem) Title() string {
+	return i.Summary()
+}
+
+// Description implements list.DefaultItem.
+func (i NotificationItem) Description() string {
+	switch i.Type {
+	case events.Push:
+		// The first line of the first commit mentioning someone.
+		for _, c := range i.Commits {
+			if len(config.Mentions(c.Message)) > 0 {
+				return strings.SplitN(c.Message, "\n", 2)[0]
+			}
+		}
+	case events.AccessRequested:
+		return i.Message
+	}
+	return ""
+}
+
+// FilterValue implements list.Item.
+func (i NotificationItem) FilterValue() string { return i.Summary() }
+
+// NotificationItemDelegate is the delegate for the notification item.
+type NotificationItemDelegate struct {
+	common *common.Common
+}
+
+// Height implements list.ItemDelegate.
+func (d NotificationItemDelegate) Height() int { return 2 }
+
+// Spacing implements list.ItemDelegate.
+func (d NotificationItemDelegate) Spacing() int { return 1 }
+
+// Update implements list.ItemDelegate.
+func (d NotificationItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d NotificationItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	s := d.common.Styles.Ref
+	i, ok := listItem.(NotificationItem)
+	if !ok {
+		return
+	}
+
+	st := s.Normal.Item
+	selector := "  "
+	if index == m.Index() {
+		st = s.Active.Item
+		selector = s.ItemSelector.String()
+	}
+
+	var badge string
+	if !i.Read {
+		badge = " " + d.common.Styles.RepoSelector.BadgeKind.Render("new")
+	}
+	info := " " + humanize.Time(i.Time)
+	maxWidth := m.Width() -
+		s.ItemSelector.GetMarginLeft() -
+		s.ItemSelector.GetWidth() -
+		s.Normal.Item.GetMarginLeft()
+	title := common.TruncateString(i.Title(), maxWidth-lipgloss.Width(badge)-lipgloss.Width(info))
+	line := st.Render(title) + badge +
+		d.common.Styles.RepoSelector.Normal.Updated.Render(info)
+
+	desc := common.TruncateString(i.Description(), maxWidth)
+	desc = d.common.Styles.RepoSelector.Normal.Desc.Render(desc)
+
+	s2 := strings.Builder{}
+	s2.WriteString(fmt.Sprint(selector, line))
+	s2.WriteRune('\n')
+	s2.WriteString(fmt.Sprint("  ", desc))
+	fmt.Fprint(w,
+		d.common.Zone.Mark(
+			i.ID(),
+			s2.String(),
+		),
+	)
+}
+
+// updateNotificationsCmd lists the notifications of the user.
+func (s *Selection) updateNotificationsCmd() tea.Msg {
+	ns, err := s.cfg.Notifications(s.pk)
+	if err != nil {
+		return common.ErrorMsg(err)
+	}
+	items := make([]selector.IdentifiableItem, len(ns))
+	for i, n := range ns {
+		items[i] = NotificationItem{n}
+	}
+	return NotificationsMsg(items)
+}
+
+// selectedNotification returns the selected notification, if any.
+func (s *Selection) selectedNotification() (NotificationItem, bool) {
+	i, ok := s.inbox.SelectedItem().(NotificationItem)
+	return i, ok
+}
+
+// markReadCmd marks notifications as read, or all of them when no IDs are
+// given, and refreshes the inbox.
+func (s *Selection) markReadCmd(ids ...string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.cfg.MarkNotificationsRead(s.pk, ids...); err != nil {
+			return common.ErrorMsg(err)
+		}
+		return s.updateNotificationsCmd()
+	}
+}
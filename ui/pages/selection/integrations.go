@@ -0,0 +1,155 @@
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+	"github.com/dustin/go-humanize"
+)
+
+var (
+	retryDelivery = key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "retry"),
+	)
+	toggleDelivery = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "disable/enable"),
+	)
+)
+
+// DeliveriesMsg is a message that contains the integration deliveries.
+type DeliveriesMsg []selector.IdentifiableItem
+
+// DeliveryItem is an integration delivery item.
+type DeliveryItem struct {
+	config.Delivery
+}
+
+// ID implements selector.IdentifiableItem.
+func (i DeliveryItem) ID() string {
+	return i.Target
+}
+
+// Title implements list.DefaultItem.
+func (i DeliveryItem) Title() string {
+	return i.Target
+}
+
+// Description implements list.DefaultItem.
+func (i DeliveryItem) Description() string {
+	return i.Err
+}
+
+// FilterValue implements list.Item.
+func (i DeliveryItem) FilterValue() string { return i.Target }
+
+// DeliveryItemDelegate is the delegate for the delivery item.
+type DeliveryItemDelegate struct {
+	common *common.Common
+}
+
+// Height implements list.ItemDelegate.
+func (d DeliveryItemDelegate) Height() int { return 2 }
+
+// Spacing implements list.ItemDelegate.
+func (d DeliveryItemDelegate) Spacing() int { return 1 }
+
+// Update implements list.ItemDelegate.
+func (d DeliveryItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d DeliveryItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	s := d.common.Styles.Ref
+	i, ok := listItem.(DeliveryItem)
+	if !ok {
+		return
+	}
+
+	st := s.Normal.Item
+	selector := "  "
+	if index == m.Index() {
+		st = s.Active.Item
+		selector = s.ItemSelector.String()
+	}
+
+	var status string
+	switch {
+	case i.Disabled:
+		status = d.common.Styles.RepoSelector.BadgeEmpty.Render("disabled")
+	case i.Failed():
+		status = d.common.Styles.RepoSelector.BadgeError.Render("failed")
+	default:
+		status = d.common.Styles.RepoSelector.BadgeKind.Render("ok")
+	}
+	info := fmt.Sprintf(" %s %s %s", i.Kind, i.Latency.Round(1e6), humanize.Time(i.Time))
+	maxWidth := m.Width() -
+		s.ItemSelector.GetMarginLeft() -
+		s.ItemSelector.GetWidth() -
+		s.Normal.Item.GetMarginLeft()
+	target := common.TruncateString(i.Target, maxWidth-lipgloss.Width(status)-lipgloss.Width(info)-1)
+	line := st.Render(target) + " " + status +
+		d.common.Styles.RepoSelector.Normal.Updated.Render(info)
+
+	errStr := common.TruncateString(i.Err, maxWidth)
+	errStr = d.common.Styles.RepoSelector.Normal.Desc.Render(errStr)
+
+	s2 := strings.Builder{}
+	s2.WriteString(fmt.Sprint(selector, line))
+	s2.WriteRune('\n')
+	s2.WriteString(fmt.Sprint("  ", errStr))
+	fmt.Fprint(w,
+		d.common.Zone.Mark(
+			i.ID(),
+			s2.String(),
+		),
+	)
+}
+
+// updateDeliveriesCmd lists the integration deliveries.
+func (s *Selection) updateDeliveriesCmd() tea.Msg {
+	ds := s.cfg.Deliveries()
+	items := make([]selector.IdentifiableItem, len(ds))
+	for i, d := range ds {
+		items[i] = DeliveryItem{d}
+	}
+	return DeliveriesMsg(items)
+}
+
+// selectedDelivery returns the selected delivery, if any.
+func (s *Selection) selectedDelivery() (DeliveryItem, bool) {
+	i, ok := s.integrations.SelectedItem().(DeliveryItem)
+	return i, ok
+}
+
+// retryDeliveryCmd retries the delivery to target and refreshes the list.
+func (s *Selection) retryDeliveryCmd(target string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.cfg.RetryDelivery(target); errors.Is(err, config.ErrUnknownTarget) {
+			return common.ErrorMsg(err)
+		}
+		// Delivery errors are shown in the list.
+		return s.updateDeliveriesCmd()
+	}
+}
+
+// toggleDeliveryCmd disables or enables delivering to target.
+func (s *Selection) toggleDeliveryCmd(target string, disabled bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.cfg.SetDeliveryDisabled(target, disabled); err != nil {
+			return common.ErrorMsg(err)
+		}
+		return s.updateDeliveriesCmd()
+	}
+}
@@ -10,17 +10,32 @@ import (
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/config"
 	"github.com/charmbracelet/soft-serve/ui/common"
 	"github.com/charmbracelet/soft-serve/ui/git"
+	wgit "github.com/charmbracelet/wish/git"
 	"github.com/dustin/go-humanize"
 )
 
+const (
+	// badgeEmpty marks repos without commits.
+	badgeEmpty = "empty"
+	// badgeError marks repos that couldn't be read.
+	badgeError = "error"
+)
+
 // Item represents a single item in the selector.
 type Item struct {
 	repo       git.GitRepo
 	lastUpdate time.Time
-	cmd        string
+	badge      string
+	kind       config.RepoKind
+	access     wgit.AccessLevel
+	urls       []string
 	copied     time.Time
+	// requested is whether the user requested access to the repo, which
+	// is listed without read access when it's requestable.
+	requested bool
 }
 
 // ID implements selector.IdentifiableItem.
@@ -37,15 +52,25 @@ func (i Item) Description() string { return i.repo.Description() }
 // FilterValue implements list.Item.
 func (i Item) FilterValue() string { return i.Title() }
 
-// Command returns the item Command view.
-func (i Item) Command() string {
-	return i.cmd
+// Readable returns whether the user can read the repo, and open it.
+func (i Item) Readable() bool {
+	return i.access >= wgit.ReadOnlyAccess
+}
+
+// Command returns the clone command of the item using the URL at index url,
+// wrapping around. It's empty if the repo can't be cloned.
+func (i Item) Command(url int) string {
+	if len(i.urls) == 0 {
+		return ""
+	}
+	return git.CloneCommand(i.urls[url%len(i.urls)])
 }
 
 // ItemDelegate is the delegate for the item.
 type ItemDelegate struct {
 	common     *common.Common
 	activePane *pane
+	url        *int
 }
 
 // Width returns the item width.
@@ -73,9 +98,9 @@ func (d ItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
-		case key.Matches(msg, d.common.KeyMap.Copy):
+		case key.Matches(msg, d.common.KeyMap.Copy) && item.Command(*d.url) != "":
 			item.copied = time.Now()
-			d.common.Copy.Copy(item.Command())
+			d.common.Copy.Copy(item.Command(*d.url))
 			return m.SetItem(idx, item)
 		}
 	}
@@ -104,16 +129,32 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	if i.repo.IsPrivate() {
 		title += " 🔒"
 	}
+	var badge string
+	switch i.badge {
+	case badgeEmpty:
+		badge = " " + d.common.Styles.RepoSelector.BadgeEmpty.Render(i.badge)
+	case badgeError:
+		badge = " " + d.common.Styles.RepoSelector.BadgeError.Render(i.badge)
+	}
+	if i.kind == config.KindFork || i.kind == config.KindMirror {
+		badge += " " + d.common.Styles.RepoSelector.BadgeKind.Render(string(i.kind))
+	}
+	if a := common.AccessBadge(i.access); a != "" {
+		badge += " " + d.common.Styles.AccessBadge.Render(a)
+	}
 	if isSelected {
 		title += " "
 	}
-	updatedStr := fmt.Sprintf(" Updated %s", humanize.Time(i.lastUpdate))
-	if m.Width()-styles.Base.GetHorizontalFrameSize()-lipgloss.Width(updatedStr)-lipgloss.Width(title) <= 0 {
+	updatedStr := ""
+	if !i.lastUpdate.IsZero() {
+		updatedStr = fmt.Sprintf(" Updated %s", humanize.Time(i.lastUpdate))
+	}
+	if m.Width()-styles.Base.GetHorizontalFrameSize()-lipgloss.Width(updatedStr)-lipgloss.Width(title)-lipgloss.Width(badge) <= 0 {
 		updatedStr = ""
 	}
 	updatedStyle := styles.Updated.Copy().
 		Align(lipgloss.Right).
-		Width(m.Width() - styles.Base.GetHorizontalFrameSize() - lipgloss.Width(title))
+		Width(m.Width() - styles.Base.GetHorizontalFrameSize() - lipgloss.Width(title) - lipgloss.Width(badge))
 	updated := updatedStyle.Render(updatedStr)
 
 	if isFiltered && index < len(m.VisibleItems()) {
@@ -126,7 +167,7 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 		matched := unmatched.Copy().Underline(true)
 		title = lipgloss.StyleRunes(title, matchedRunes, matched, unmatched)
 	}
-	title = styles.Title.Render(title)
+	title = styles.Title.Render(title) + badge
 	desc := i.Description()
 	desc = common.TruncateString(desc, m.Width()-styles.Base.GetHorizontalFrameSize())
 	desc = styles.Desc.Render(desc)
@@ -135,11 +176,18 @@ func (d ItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list
 	s.WriteRune('\n')
 	s.WriteString(desc)
 	s.WriteRune('\n')
-	cmd := common.TruncateString(i.Command(), m.Width()-styles.Base.GetHorizontalFrameSize())
+	cmd := common.TruncateString(common.MarkLinks(i.Command(*d.url)), m.Width()-styles.Base.GetHorizontalFrameSize())
 	cmd = styles.Command.Render(cmd)
 	if !i.copied.IsZero() && i.copied.Add(time.Second).After(time.Now()) {
 		cmd = styles.Command.Render("Copied!")
 	}
+	switch {
+	case i.Readable():
+	case i.requested:
+		cmd = styles.Command.Render("Access requested")
+	default:
+		cmd = styles.Command.Render(fmt.Sprintf("Press %s to request access", requestAccess.Help().Key))
+	}
 	s.WriteString(cmd)
 	fmt.Fprint(w,
 		d.common.Zone.Mark(i.ID(),
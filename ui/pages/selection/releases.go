@@ -0,0 +1,131 @@
+package selection
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+	"github.com/dustin/go-humanize"
+)
+
+// releaseSorts are the orders releases are listed in, cycled through with
+// the sortReleases key.
+var releaseSorts = []string{"date", "name"}
+
+var sortReleases = key.NewBinding(
+	key.WithKeys("s"),
+	key.WithHelp("s", "sort"),
+)
+
+// ReleasesMsg is a message that contains the releases of the repos.
+type ReleasesMsg []selector.IdentifiableItem
+
+// ReleaseItem is a release item. Selecting it opens its repo.
+type ReleaseItem struct {
+	config.Release
+}
+
+// ID implements selector.IdentifiableItem.
+func (i ReleaseItem) ID() string {
+	return i.Repo + "@" + i.Tag
+}
+
+// Title implements list.DefaultItem.
+func (i ReleaseItem) Title() string {
+	return i.Repo
+}
+
+// Description implements list.DefaultItem.
+func (i ReleaseItem) Description() string {
+	return i.Tag
+}
+
+// FilterValue implements list.Item.
+func (i ReleaseItem) FilterValue() string { return i.Repo + " " + i.Tag }
+
+// ReleaseItemDelegate is the delegate for the release item.
+type ReleaseItemDelegate struct {
+	common *common.Common
+}
+
+// Height implements list.ItemDelegate.
+func (d ReleaseItemDelegate) Height() int { return 2 }
+
+// Spacing implements list.ItemDelegate.
+func (d ReleaseItemDelegate) Spacing() int { return 1 }
+
+// Update implements list.ItemDelegate.
+func (d ReleaseItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d ReleaseItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	s := d.common.Styles.Ref
+	i, ok := listItem.(ReleaseItem)
+	if !ok {
+		return
+	}
+
+	st := s.Normal.Item
+	selector := "  "
+	if index == m.Index() {
+		st = s.Active.Item
+		selector = s.ItemSelector.String()
+	}
+
+	tag := d.common.Styles.RepoSelector.BadgeKind.Render(i.Tag)
+	info := " " + humanize.Time(i.Date)
+	maxWidth := m.Width() -
+		s.ItemSelector.GetMarginLeft() -
+		s.ItemSelector.GetWidth() -
+		s.Normal.Item.GetMarginLeft()
+	repo := common.TruncateString(i.Repo, maxWidth-lipgloss.Width(tag)-lipgloss.Width(info)-1)
+	line := st.Render(repo) + " " + tag +
+		d.common.Styles.RepoSelector.Normal.Updated.Render(info)
+
+	desc := fmt.Sprintf("%s %s", i.Commit[:7], i.Date.Format("2006-01-02 15:04"))
+	desc = common.TruncateString(desc, maxWidth)
+	desc = d.common.Styles.RepoSelector.Normal.Desc.Render(desc)
+
+	s2 := strings.Builder{}
+	s2.WriteString(fmt.Sprint(selector, line))
+	s2.WriteRune('\n')
+	s2.WriteString(fmt.Sprint("  ", desc))
+	fmt.Fprint(w,
+		d.common.Zone.Mark(
+			i.ID(),
+			s2.String(),
+		),
+	)
+}
+
+// sortReleasesKey returns the key binding cycling through the orders of
+// releases, with the current order as its help.
+func (s *Selection) sortReleasesKey() key.Binding {
+	k := sortReleases
+	k.SetHelp("s", fmt.Sprintf("sort (%s)", releaseSorts[s.releaseSort]))
+	return k
+}
+
+// updateReleasesCmd lists the releases of the repos the user can read, in
+// the current order.
+func (s *Selection) updateReleasesCmd() tea.Cmd {
+	by := releaseSorts[s.releaseSort]
+	return func() tea.Msg {
+		rs := s.cfg.Releases(s.pk)
+		config.SortReleases(rs, by) // nolint: errcheck
+		items := make([]selector.IdentifiableItem, len(rs))
+		for i, r := range rs {
+			items[i] = ReleaseItem{r}
+		}
+		return ReleasesMsg(items)
+	}
+}
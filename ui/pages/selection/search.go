@@ -0,0 +1,69 @@
+package selection
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+)
+
+// matchSearch returns whether the item is listed by a saved search with the
+// parsed query q.
+func matchSearch(i Item, s config.Search, q config.Query, now time.Time) bool {
+	switch s.Visibility {
+	case "public":
+		if i.repo.IsPrivate() {
+			return false
+		}
+	case "private":
+		if !i.repo.IsPrivate() {
+			return false
+		}
+	}
+	text := strings.ToLower(strings.Join([]string{
+		i.repo.Repo(),
+		i.repo.Name(),
+		i.repo.Description(),
+	}, " "))
+	for _, t := range q.Terms {
+		if !strings.Contains(text, t) {
+			return false
+		}
+	}
+	if q.Updated > 0 && (i.lastUpdate.IsZero() || now.Sub(i.lastUpdate) > q.Updated) {
+		return false
+	}
+	if len(q.Files) > 0 {
+		head, err := i.repo.HEAD()
+		if err != nil {
+			return false
+		}
+		tree, err := i.repo.Tree(head, "")
+		if err != nil {
+			return false
+		}
+		for _, f := range q.Files {
+			if _, err := tree.TreeEntry(f); err != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortItems sorts items by name, or by last update, most recent first. Items
+// keep their order for other sorts.
+func sortItems(items []selector.IdentifiableItem, by string) {
+	switch by {
+	case "name":
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].(Item).Title() < items[j].(Item).Title()
+		})
+	case "updated":
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].(Item).lastUpdate.After(items[j].(Item).lastUpdate)
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package selection
 
 import (
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
 	"github.com/charmbracelet/soft-serve/ui/common"
 	"github.com/charmbracelet/soft-serve/ui/components/code"
 	"github.com/charmbracelet/soft-serve/ui/components/selector"
@@ -23,6 +27,10 @@ type pane int
 const (
 	selectorPane pane = iota
 	readmePane
+	integrationsPane
+	sessionsPane
+	releasesPane
+	inboxPane
 	lastPane
 )
 
@@ -30,9 +38,46 @@ func (p pane) String() string {
 	return []string{
 		"Repositories",
 		"About",
+		"Integrations",
+		"Sessions",
+		"Releases",
+		"Inbox",
 	}[p]
 }
 
+// kindFilter selects the kinds of repos that are listed.
+type kindFilter int
+
+const (
+	// kindDefault lists the kinds that aren't hidden by the config.
+	kindDefault kindFilter = iota
+	kindAll
+	kindSources
+	kindForks
+	kindMirrors
+	lastKindFilter
+)
+
+func (k kindFilter) String() string {
+	return []string{
+		"default",
+		"all",
+		"sources",
+		"forks",
+		"mirrors",
+	}[k]
+}
+
+var filterKind = key.NewBinding(
+	key.WithKeys("t"),
+	key.WithHelp("t", "repo types"),
+)
+
+var requestAccess = key.NewBinding(
+	key.WithKeys("r"),
+	key.WithHelp("r", "request access"),
+)
+
 // Selection is the model for the selection screen/page.
 type Selection struct {
 	cfg          *config.Config
@@ -43,15 +88,54 @@ type Selection struct {
 	selector     *selector.Selector
 	activePane   pane
 	tabs         *tabs.Tabs
+	// panes are the panes of the first tabs, followed by a tab for each of
+	// the saved searches of the user.
+	panes    []pane
+	searches []config.Search
+	// search is the index of the saved search listing the repos, or -1
+	// when all repos are listed.
+	search int
+	// releases lists the tags of the repos the user can read.
+	releases    *selector.Selector
+	releaseSort int
+	// inbox lists the notifications of the user, only set for users of
+	// the server.
+	inbox *selector.Selector
+	// integrations and sessions are only set for admins, settings admins,
+	// and auditors.
+	integrations *selector.Selector
+	sessions     *selector.Selector
+	// manage is whether deliveries can be retried and toggled, and sessions
+	// messaged and terminated.
+	manage bool
+	// ticking is whether the sessions are being refreshed periodically.
+	ticking bool
+	// prompt reads the message to the user of the selected session.
+	prompt    textinput.Model
+	prompting bool
+	// items holds all listed repos, before they're filtered by kind.
+	items []selector.IdentifiableItem
+	kind  kindFilter
+	// url is the index of the clone URL shown for repos, cycled through with
+	// the SwitchURL key.
+	url int
 }
 
 // New creates a new selection model.
 func New(cfg *config.Config, pk ssh.PublicKey, common common.Common) *Selection {
-	ts := make([]string, lastPane)
-	for i, b := range []pane{selectorPane, readmePane} {
-		ts[i] = b.String()
+	panes := []pane{selectorPane, readmePane}
+	// Auditors see the deliveries and sessions, which settings admins
+	// manage.
+	admin := cfg.HasRole(pk, config.RoleSettingsAdmin, config.RoleAuditor)
+	if admin {
+		panes = append(panes, integrationsPane, sessionsPane)
+	}
+	panes = append(panes, releasesPane)
+	hasInbox := cfg.HasInbox(pk)
+	if hasInbox {
+		panes = append(panes, inboxPane)
 	}
-	t := tabs.New(common, ts)
+	t := tabs.New(common, tabNames(panes, nil))
 	t.TabSeparator = lipgloss.NewStyle()
 	t.TabInactive = common.Styles.TopLevelNormalTab.Copy()
 	t.TabActive = common.Styles.TopLevelActiveTab.Copy()
@@ -63,12 +147,60 @@ func New(cfg *config.Config, pk ssh.PublicKey, common common.Common) *Selection
 		common:     common,
 		activePane: selectorPane, // start with the selector focused
 		tabs:       t,
+		panes:      panes,
+		search:     -1,
+		prompt:     newMessagePrompt(),
+		manage:     cfg.HasRole(pk, config.RoleSettingsAdmin),
+	}
+	releases := selector.New(common,
+		[]selector.IdentifiableItem{},
+		ReleaseItemDelegate{&common})
+	releases.SetShowTitle(false)
+	releases.SetShowHelp(false)
+	releases.SetShowStatusBar(false)
+	releases.SetFilteringEnabled(false)
+	releases.DisableQuitKeybindings()
+	releases.Styles.NoItems = releases.Styles.NoItems.SetString("No releases yet.")
+	sel.releases = releases
+	if hasInbox {
+		inbox := selector.New(common,
+			[]selector.IdentifiableItem{},
+			NotificationItemDelegate{&common})
+		inbox.SetShowTitle(false)
+		inbox.SetShowHelp(false)
+		inbox.SetShowStatusBar(false)
+		inbox.SetFilteringEnabled(false)
+		inbox.DisableQuitKeybindings()
+		inbox.Styles.NoItems = inbox.Styles.NoItems.SetString("No notifications.")
+		sel.inbox = inbox
+	}
+	if admin {
+		integrations := selector.New(common,
+			[]selector.IdentifiableItem{},
+			DeliveryItemDelegate{&common})
+		integrations.SetShowTitle(false)
+		integrations.SetShowHelp(false)
+		integrations.SetShowStatusBar(false)
+		integrations.SetFilteringEnabled(false)
+		integrations.DisableQuitKeybindings()
+		integrations.Styles.NoItems = integrations.Styles.NoItems.SetString("No deliveries yet.")
+		sel.integrations = integrations
+		sessions := selector.New(common,
+			[]selector.IdentifiableItem{},
+			SessionItemDelegate{&common})
+		sessions.SetShowTitle(false)
+		sessions.SetShowHelp(false)
+		sessions.SetShowStatusBar(false)
+		sessions.SetFilteringEnabled(false)
+		sessions.DisableQuitKeybindings()
+		sessions.Styles.NoItems = sessions.Styles.NoItems.SetString("No sessions.")
+		sel.sessions = sessions
 	}
 	readme := code.New(common, "", "")
 	readme.NoContentStyle = readme.NoContentStyle.SetString("No readme found.")
 	selector := selector.New(common,
 		[]selector.IdentifiableItem{},
-		ItemDelegate{&common, &sel.activePane})
+		ItemDelegate{&common, &sel.activePane, &sel.url})
 	selector.SetShowTitle(false)
 	selector.SetShowHelp(false)
 	selector.SetShowStatusBar(false)
@@ -78,6 +210,18 @@ func New(cfg *config.Config, pk ssh.PublicKey, common common.Common) *Selection
 	return sel
 }
 
+// tabNames returns the names of the tabs of the panes and saved searches.
+func tabNames(panes []pane, searches []config.Search) []string {
+	ts := make([]string, 0, len(panes)+len(searches))
+	for _, p := range panes {
+		ts = append(ts, p.String())
+	}
+	for _, s := range searches {
+		ts = append(ts, s.Name)
+	}
+	return ts
+}
+
 func (s *Selection) getMargins() (wm, hm int) {
 	wm = 0
 	hm = s.common.Styles.Tabs.GetVerticalFrameSize() +
@@ -101,6 +245,17 @@ func (s *Selection) SetSize(width, height int) {
 	s.tabs.SetSize(width, height-hm)
 	s.selector.SetSize(width-wm, height-hm)
 	s.readme.SetSize(width-wm, height-hm-1) // -1 for readme status line
+	s.releases.SetSize(width-wm, height-hm)
+	if s.inbox != nil {
+		s.inbox.SetSize(width-wm, height-hm)
+	}
+	if s.integrations != nil {
+		s.integrations.SetSize(width-wm, height-hm)
+	}
+	if s.sessions != nil {
+		// -1 for the message prompt
+		s.sessions.SetSize(width-wm, height-hm-1)
+	}
 }
 
 // IsFiltering returns true if the selector is currently filtering.
@@ -124,6 +279,35 @@ func (s *Selection) ShortHelp() []key.Binding {
 			k.Filter,
 			k.ClearFilter,
 			copyKey,
+			s.filterKindKey(),
+		)
+		if s.switchURL() {
+			kb = append(kb, s.common.KeyMap.SwitchURL)
+		}
+		if i, ok := s.selector.SelectedItem().(Item); ok && !i.Readable() {
+			kb = append(kb, requestAccess)
+		}
+	}
+	if s.activePane == releasesPane {
+		openKey := s.common.KeyMap.Select
+		openKey.SetHelp("enter", "open repo")
+		kb = append(kb, openKey, s.sortReleasesKey())
+	}
+	if s.activePane == inboxPane {
+		openKey := s.common.KeyMap.Select
+		openKey.SetHelp("enter", "open repo")
+		kb = append(kb, openKey, markRead, markAllRead)
+	}
+	if s.activePane == integrationsPane && s.manage {
+		kb = append(kb,
+			retryDelivery,
+			toggleDelivery,
+		)
+	}
+	if s.activePane == sessionsPane && s.manage {
+		kb = append(kb,
+			messageSession,
+			terminateSession,
 		)
 	}
 	return kb
@@ -151,6 +335,72 @@ func (s *Selection) FullHelp() [][]key.Binding {
 			k.Down,
 			k.Up,
 		})
+	case releasesPane:
+		k := s.releases.KeyMap
+		openKey := s.common.KeyMap.Select
+		openKey.SetHelp("enter", "open repo")
+		b[0] = append(b[0], openKey, s.sortReleasesKey())
+		b = append(b, []key.Binding{
+			k.CursorUp,
+			k.CursorDown,
+		})
+		b = append(b, []key.Binding{
+			k.NextPage,
+			k.PrevPage,
+			k.GoToStart,
+			k.GoToEnd,
+		})
+	case inboxPane:
+		k := s.inbox.KeyMap
+		openKey := s.common.KeyMap.Select
+		openKey.SetHelp("enter", "open repo")
+		b[0] = append(b[0], openKey, markRead, markAllRead)
+		b = append(b, []key.Binding{
+			k.CursorUp,
+			k.CursorDown,
+		})
+		b = append(b, []key.Binding{
+			k.NextPage,
+			k.PrevPage,
+			k.GoToStart,
+			k.GoToEnd,
+		})
+	case integrationsPane:
+		k := s.integrations.KeyMap
+		if s.manage {
+			b[0] = append(b[0],
+				retryDelivery,
+				toggleDelivery,
+			)
+		}
+		b = append(b, []key.Binding{
+			k.CursorUp,
+			k.CursorDown,
+		})
+		b = append(b, []key.Binding{
+			k.NextPage,
+			k.PrevPage,
+			k.GoToStart,
+			k.GoToEnd,
+		})
+	case sessionsPane:
+		k := s.sessions.KeyMap
+		if s.manage {
+			b[0] = append(b[0],
+				messageSession,
+				terminateSession,
+			)
+		}
+		b = append(b, []key.Binding{
+			k.CursorUp,
+			k.CursorDown,
+		})
+		b = append(b, []key.Binding{
+			k.NextPage,
+			k.PrevPage,
+			k.GoToStart,
+			k.GoToEnd,
+		})
 	case selectorPane:
 		copyKey := s.common.KeyMap.Copy
 		copyKey.SetHelp("c", "copy command")
@@ -159,7 +409,14 @@ func (s *Selection) FullHelp() [][]key.Binding {
 			b[0] = append(b[0],
 				s.common.KeyMap.Select,
 				copyKey,
+				s.filterKindKey(),
 			)
+			if s.switchURL() {
+				b[0] = append(b[0], s.common.KeyMap.SwitchURL)
+			}
+			if i, ok := s.selector.SelectedItem().(Item); ok && !i.Readable() {
+				b[0] = append(b[0], requestAccess)
+			}
 		}
 		b = append(b, []key.Binding{
 			k.CursorUp,
@@ -183,6 +440,14 @@ func (s *Selection) FullHelp() [][]key.Binding {
 
 // Init implements tea.Model.
 func (s *Selection) Init() tea.Cmd {
+	return tea.Batch(
+		s.selector.Init(),
+		s.refresh(),
+	)
+}
+
+// refresh reloads the repo items and the About readme from the config.
+func (s *Selection) refresh() tea.Cmd {
 	var readmeCmd tea.Cmd
 	items := make([]selector.IdentifiableItem, 0)
 	cfg := s.cfg
@@ -190,7 +455,9 @@ func (s *Selection) Init() tea.Cmd {
 	// Put configured repos first
 	for _, r := range cfg.Repos {
 		acc := cfg.AuthRepo(r.Repo, pk)
-		if r.Private && acc < wgit.ReadOnlyAccess {
+		// Requestable repos are listed to users without access, so that
+		// they can request it.
+		if acc < wgit.ReadOnlyAccess && !cfg.Requestable(r.Repo, pk) {
 			continue
 		}
 		repo, err := cfg.Source.GetRepo(r.Repo)
@@ -198,8 +465,11 @@ func (s *Selection) Init() tea.Cmd {
 			continue
 		}
 		items = append(items, Item{
-			repo: repo,
-			cmd:  git.RepoURL(cfg.Host, cfg.Port, r.Repo),
+			repo:      repo,
+			kind:      cfg.RepoKind(r.Repo),
+			access:    acc,
+			urls:      cloneURLs(cfg, r.Repo, acc),
+			requested: acc < wgit.ReadOnlyAccess && cfg.AccessRequested(r.Repo, pk),
 		})
 	}
 	for _, r := range cfg.Source.AllRepos() {
@@ -209,23 +479,32 @@ func (s *Selection) Init() tea.Cmd {
 			readmeCmd = s.readme.SetContent(rm, rp)
 		}
 		acc := cfg.AuthRepo(r.Repo(), pk)
-		if r.IsPrivate() && acc < wgit.ReadOnlyAccess {
+		// Public repos can be closed to anonymous users too.
+		if acc < wgit.ReadOnlyAccess {
 			continue
 		}
 		exists := false
+		// Don't let one broken repo keep the others from being listed.
+		var lastUpdate time.Time
+		var badge string
 		lc, err := r.Commit("HEAD")
-		if err != nil {
-			return common.ErrorCmd(err)
-		}
-		lastUpdate := lc.Committer.When
-		if lastUpdate.IsZero() {
-			lastUpdate = lc.Author.When
+		switch {
+		case err == nil:
+			lastUpdate = lc.Committer.When
+			if lastUpdate.IsZero() {
+				lastUpdate = lc.Author.When
+			}
+		case r.IsEmpty():
+			badge = badgeEmpty
+		default:
+			badge = badgeError
 		}
 		for i, item := range items {
 			item := item.(Item)
 			if item.repo.Repo() == r.Repo() {
 				exists = true
 				item.lastUpdate = lastUpdate
+				item.badge = badge
 				items[i] = item
 				break
 			}
@@ -234,17 +513,112 @@ func (s *Selection) Init() tea.Cmd {
 			items = append(items, Item{
 				repo:       r,
 				lastUpdate: lastUpdate,
-				cmd:        git.RepoURL(cfg.Host, cfg.Port, r.Name()),
+				badge:      badge,
+				kind:       cfg.RepoKind(r.Repo()),
+				access:     acc,
+				urls:       cloneURLs(cfg, r.Repo(), acc),
 			})
 		}
 	}
+	s.items = items
+	s.searches = cfg.Searches(pk)
+	s.tabs.SetTabs(tabNames(s.panes, s.searches))
+	if s.search >= len(s.searches) {
+		// The saved search was deleted, and so was its tab.
+		s.search = -1
+		s.activePane = selectorPane
+	}
 	return tea.Batch(
-		s.selector.Init(),
-		s.selector.SetItems(items),
+		s.selector.SetItems(s.filterItems()),
 		readmeCmd,
 	)
 }
 
+// cloneURLs returns the clone URLs of a repo, or none if the user can't
+// clone it.
+func cloneURLs(cfg *config.Config, repo string, acc wgit.AccessLevel) []string {
+	if acc < wgit.ReadOnlyAccess {
+		return nil
+	}
+	return git.CloneURLs(cfg, repo)
+}
+
+// accessRequestedMsg is sent when the user requested access to a repo.
+type accessRequestedMsg string
+
+// requestAccessCmd requests access to a repo for the user.
+func (s *Selection) requestAccessCmd(repo string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := s.cfg.RequestAccess(repo, s.pk, "")
+		if err != nil && !errors.Is(err, config.ErrRequestPending) {
+			return common.ErrorMsg(err)
+		}
+		return accessRequestedMsg(repo)
+	}
+}
+
+// switchURL reports whether repos can be cloned over HTTP as well as SSH,
+// so that there's a clone URL to switch to.
+func (s *Selection) switchURL() bool {
+	return s.cfg.Cfg != nil && s.cfg.Cfg.HTTPPort != 0
+}
+
+// filterKindKey returns the key binding cycling through repo kinds, with the
+// current kind as its help.
+func (s *Selection) filterKindKey() key.Binding {
+	k := filterKind
+	k.SetHelp("t", fmt.Sprintf("repo types (%s)", s.kind))
+	return k
+}
+
+// filterItems returns the items of the kind being listed that match the
+// saved search, if any, in the order of the search.
+func (s *Selection) filterItems() []selector.IdentifiableItem {
+	items := make([]selector.IdentifiableItem, 0, len(s.items))
+	var search *config.Search
+	var query config.Query
+	if s.search >= 0 {
+		search = &s.searches[s.search]
+		// Searches are validated when saved, but may have been edited in
+		// the config repo since; an invalid query matches nothing.
+		q, err := config.ParseQuery(search.Query)
+		if err != nil {
+			return items
+		}
+		query = q
+	}
+	now := time.Now()
+	for _, i := range s.items {
+		if search != nil && !matchSearch(i.(Item), *search, query, now) {
+			continue
+		}
+		k := i.(Item).kind
+		switch s.kind {
+		case kindDefault:
+			if s.cfg.ListingHides(k) {
+				continue
+			}
+		case kindSources:
+			if k != config.KindSource {
+				continue
+			}
+		case kindForks:
+			if k != config.KindFork {
+				continue
+			}
+		case kindMirrors:
+			if k != config.KindMirror {
+				continue
+			}
+		}
+		items = append(items, i)
+	}
+	if search != nil {
+		sortItems(items, search.Sort)
+	}
+	return items
+}
+
 // Update implements tea.Model.
 func (s *Selection) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds := make([]tea.Cmd, 0)
@@ -261,11 +635,52 @@ func (s *Selection) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 	case tea.KeyMsg, tea.MouseMsg:
+		if kmsg, ok := msg.(tea.KeyMsg); ok && s.prompting {
+			return s, s.updatePrompt(kmsg)
+		}
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
 			switch {
+			case key.Matches(msg, messageSession) && s.activePane == sessionsPane && s.manage:
+				if _, ok := s.selectedSession(); ok {
+					s.prompting = true
+					s.prompt.Reset()
+					return s, s.prompt.Focus()
+				}
+			case key.Matches(msg, terminateSession) && s.activePane == sessionsPane && s.manage:
+				if i, ok := s.selectedSession(); ok {
+					cmds = append(cmds, s.terminateSessionCmd(i.Session.ID))
+				}
 			case key.Matches(msg, s.common.KeyMap.Back):
 				cmds = append(cmds, s.selector.Init())
+			case key.Matches(msg, s.common.KeyMap.SwitchURL) && s.switchURL() && s.activePane == selectorPane && !s.IsFiltering():
+				s.url++
+			case key.Matches(msg, requestAccess) && s.activePane == selectorPane && !s.IsFiltering():
+				if i, ok := s.selector.SelectedItem().(Item); ok && !i.Readable() && !i.requested {
+					cmds = append(cmds, s.requestAccessCmd(i.ID()))
+				}
+			case key.Matches(msg, filterKind) && s.activePane == selectorPane && !s.IsFiltering():
+				s.kind = (s.kind + 1) % lastKindFilter
+				s.selector.Select(0)
+				cmds = append(cmds, s.selector.SetItems(s.filterItems()))
+			case key.Matches(msg, sortReleases) && s.activePane == releasesPane:
+				s.releaseSort = (s.releaseSort + 1) % len(releaseSorts)
+				s.releases.Select(0)
+				cmds = append(cmds, s.updateReleasesCmd())
+			case key.Matches(msg, markRead) && s.activePane == inboxPane:
+				if n, ok := s.selectedNotification(); ok && !n.Read {
+					cmds = append(cmds, s.markReadCmd(n.ID()))
+				}
+			case key.Matches(msg, markAllRead) && s.activePane == inboxPane:
+				cmds = append(cmds, s.markReadCmd())
+			case key.Matches(msg, retryDelivery) && s.activePane == integrationsPane && s.manage:
+				if d, ok := s.selectedDelivery(); ok {
+					cmds = append(cmds, s.retryDeliveryCmd(d.Target))
+				}
+			case key.Matches(msg, toggleDelivery) && s.activePane == integrationsPane && s.manage:
+				if d, ok := s.selectedDelivery(); ok {
+					cmds = append(cmds, s.toggleDeliveryCmd(d.Target, !d.Disabled))
+				}
 			}
 		}
 		t, cmd := s.tabs.Update(msg)
@@ -274,7 +689,77 @@ func (s *Selection) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 	case tabs.ActiveTabMsg:
-		s.activePane = pane(msg)
+		search := -1
+		if int(msg) < len(s.panes) {
+			s.activePane = s.panes[msg]
+		} else {
+			s.activePane = selectorPane
+			search = int(msg) - len(s.panes)
+		}
+		if search != s.search {
+			s.search = search
+			s.selector.Select(0)
+			cmds = append(cmds, s.selector.SetItems(s.filterItems()))
+		}
+		if s.activePane == releasesPane {
+			cmds = append(cmds, s.updateReleasesCmd())
+		}
+		if s.activePane == inboxPane {
+			cmds = append(cmds, s.updateNotificationsCmd)
+		}
+		if s.activePane == integrationsPane {
+			cmds = append(cmds, s.updateDeliveriesCmd)
+		}
+		if s.activePane == sessionsPane {
+			cmds = append(cmds, s.updateSessionsCmd)
+			if !s.ticking {
+				s.ticking = true
+				cmds = append(cmds, sessionsTickCmd())
+			}
+		}
+	case ReleasesMsg:
+		cmds = append(cmds, s.releases.SetItems(msg))
+	case NotificationsMsg:
+		if s.inbox != nil {
+			cmds = append(cmds, s.inbox.SetItems(msg))
+		}
+	case selector.SelectMsg:
+		// Notifications opened are read.
+		if n, ok := msg.IdentifiableItem.(NotificationItem); ok && !n.Read {
+			cmds = append(cmds, s.markReadCmd(n.ID()))
+		}
+	case DeliveriesMsg:
+		if s.integrations != nil {
+			cmds = append(cmds, s.integrations.SetItems(msg))
+		}
+	case SessionsMsg:
+		if s.sessions != nil {
+			cmds = append(cmds, s.sessions.SetItems(msg))
+		}
+	case sessionsTickMsg:
+		// Keep refreshing durations and bandwidth while the sessions are
+		// listed.
+		s.ticking = s.activePane == sessionsPane
+		if s.ticking {
+			cmds = append(cmds, s.updateSessionsCmd, sessionsTickCmd())
+		}
+	case accessRequestedMsg:
+		cmds = append(cmds, s.refresh())
+	case events.Event:
+		switch msg.Type {
+		case events.ConfigUpdated, events.RepoCreated, events.RepoDeleted:
+			cmds = append(cmds, s.refresh())
+		}
+		// Keep the listed releases current while they're shown.
+		switch msg.Type {
+		case events.Push, events.ConfigUpdated, events.RepoDeleted:
+			if s.activePane == releasesPane {
+				cmds = append(cmds, s.updateReleasesCmd())
+			}
+		}
+		if s.activePane == inboxPane && config.InboxType(msg.Type) {
+			cmds = append(cmds, s.updateNotificationsCmd)
+		}
 	}
 	switch s.activePane {
 	case readmePane:
@@ -289,6 +774,30 @@ func (s *Selection) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if cmd != nil {
 			cmds = append(cmds, cmd)
 		}
+	case releasesPane:
+		m, cmd := s.releases.Update(msg)
+		s.releases = m.(*selector.Selector)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case inboxPane:
+		m, cmd := s.inbox.Update(msg)
+		s.inbox = m.(*selector.Selector)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case integrationsPane:
+		m, cmd := s.integrations.Update(msg)
+		s.integrations = m.(*selector.Selector)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case sessionsPane:
+		m, cmd := s.sessions.Update(msg)
+		s.sessions = m.(*selector.Selector)
+		if cmd != nil {
+			cmds = append(cmds, cmd)
+		}
 	}
 	return s, tea.Batch(cmds...)
 }
@@ -316,6 +825,33 @@ func (s *Selection) View() string {
 			s.readme.View(),
 			readmeStatus,
 		))
+	case releasesPane:
+		ss := lipgloss.NewStyle().
+			Width(s.common.Width - wm).
+			Height(s.common.Height - hm)
+		view = ss.Render(s.releases.View())
+	case inboxPane:
+		ss := lipgloss.NewStyle().
+			Width(s.common.Width - wm).
+			Height(s.common.Height - hm)
+		view = ss.Render(s.inbox.View())
+	case integrationsPane:
+		ss := lipgloss.NewStyle().
+			Width(s.common.Width - wm).
+			Height(s.common.Height - hm)
+		view = ss.Render(s.integrations.View())
+	case sessionsPane:
+		ss := lipgloss.NewStyle().
+			Width(s.common.Width - wm).
+			Height(s.common.Height - hm - 1)
+		prompt := ""
+		if s.prompting {
+			prompt = s.prompt.View()
+		}
+		view = lipgloss.JoinVertical(lipgloss.Left,
+			ss.Render(s.sessions.View()),
+			prompt,
+		)
 	}
 	if s.activePane != selectorPane || s.FilterState() != list.Filtering {
 		tabs := s.common.Styles.Tabs.Render(s.tabs.View())
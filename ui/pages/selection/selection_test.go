@@ -0,0 +1,187 @@
+package selection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
+	"github.com/charmbracelet/soft-serve/ui/uitest"
+)
+
+var sizes = []struct{ width, height int }{
+	{60, 20},
+	{80, 24},
+	{120, 40},
+}
+
+func TestSelectionGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	for _, size := range sizes {
+		t.Run(fmt.Sprintf("%dx%d", size.width, size.height), func(t *testing.T) {
+			c := uitest.Common(t, size.width, size.height)
+			m := uitest.New(t, New(cfg, nil, c), c)
+			m.RequireGolden("repos")
+			m.Type("tab")
+			m.RequireGolden("readme")
+		})
+	}
+}
+
+func TestRefreshGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, nil, c), c)
+	uitest.Push(t, cfg, "config", uitest.Commit{
+		Message: "Update readme",
+		Files:   map[string]string{"README.md": "# Welcome back\n\nThere's a new repo.\n"},
+	})
+	uitest.Push(t, cfg, "new-repo", uitest.Commit{
+		Message: "Initial commit",
+		Files:   map[string]string{"README.md": "# New repo\n"},
+	})
+	m.Send(events.Event{Type: events.ConfigUpdated})
+	m.RequireGolden("repos")
+	m.Type("tab")
+	m.RequireGolden("readme")
+}
+
+func TestAdminGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	// Admins see the config repo, commit to it at a fixed time.
+	uitest.Push(t, cfg, "config", uitest.Commit{
+		Message: "Update readme",
+		Files:   map[string]string{"README.md": "# Home\n"},
+	})
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, uitest.AdminKey(t), c), c)
+	m.RequireGolden("repos")
+}
+
+func TestSwitchURLGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	cfg.Cfg.HTTPPort = 23232
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, nil, c), c)
+	m.Type("s")
+	m.RequireGolden("http")
+	m.Type("s")
+	m.RequireGolden("ssh")
+}
+
+func TestSearchGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	pk := uitest.AdminKey(t)
+	if err := cfg.SaveSearch(pk, config.Search{
+		Name:  "Go repos",
+		Query: "file:go.mod",
+		Sort:  "updated",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, pk, c), c)
+	// Repositories, About, Integrations, Sessions, Releases, Inbox, then
+	// the saved searches.
+	m.Type("tab", "tab", "tab", "tab", "tab", "tab")
+	m.RequireGolden("go-repos")
+}
+
+func TestReleasesGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	uitest.Push(t, cfg, "glow", uitest.Commit{
+		Message: "Initial commit",
+		Files:   map[string]string{"README.md": "# Glow\n"},
+		Tag:     "v1.0.0",
+	}, uitest.Commit{
+		Message: "Fix rendering",
+		Files:   map[string]string{"main.go": "package main\n"},
+		Tag:     "v1.0.1",
+	})
+	uitest.Push(t, cfg, "gum", uitest.Commit{
+		Message: "Initial commit",
+		Files:   map[string]string{"README.md": "# Gum\n"},
+		Tag:     "v0.1.0",
+	})
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, nil, c), c)
+	// Repositories, About, then Releases.
+	m.Type("tab", "tab")
+	m.RequireGolden("date")
+	m.Type("s")
+	m.RequireGolden("name")
+}
+
+func TestInbox(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	cfg.Cfg.DataPath = t.TempDir()
+	pk := uitest.AdminKey(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.RecordNotifications(ctx)
+	// Events published before the inbox subscribes aren't recorded, so
+	// publish until the first one is.
+	for cfg.UnreadNotifications(pk) == 0 {
+		cfg.Events.Publish(events.Event{Type: events.MirrorFailed, Repo: "soft-serve", Error: "exit status 128"})
+		time.Sleep(10 * time.Millisecond)
+	}
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, pk, c), c)
+	// Repositories, About, Integrations, Sessions, Releases, then Inbox.
+	m.Type("tab", "tab", "tab", "tab", "tab")
+	v := m.View()
+	for _, want := range []string{"Mirror soft-serve failed to sync: exit status 128", "new"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("inbox doesn't contain %q:\n%s", want, v)
+		}
+	}
+	m.Type("a")
+	if n := cfg.UnreadNotifications(pk); n != 0 {
+		t.Errorf("got %d unread notifications, want none", n)
+	}
+	if v := m.View(); strings.Contains(v, "new") {
+		t.Errorf("inbox still shows unread notifications:\n%s", v)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	pk := uitest.AdminKey(t)
+	var in, out int64 = 2048, 5 << 20
+	var msgs []string
+	terminated := false
+	cfg.TrackSession(config.Session{
+		ID:         "3f2a9c1d0b7e4a65",
+		RemoteAddr: "192.0.2.1:52000",
+		Operation:  "git-upload-pack",
+		Repo:       "soft-serve",
+		Start:      time.Now().Add(-time.Hour),
+	}, nil, &in, &out, func(msg string) error {
+		msgs = append(msgs, msg)
+		return nil
+	}, func() error {
+		terminated = true
+		return nil
+	})
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, pk, c), c)
+	// Repositories, About, Integrations, then Sessions.
+	m.Type("tab", "tab", "tab")
+	v := m.View()
+	for _, want := range []string{"anonymous", "git-upload-pack", "192.0.2.1:52000 soft-serve", "1h0m", "↓2.0 kB", "↑5.2 MB"} {
+		if !strings.Contains(v, want) {
+			t.Errorf("sessions view doesn't contain %q:\n%s", want, v)
+		}
+	}
+	m.Type("m", "s", "t", "o", "p", "enter")
+	if len(msgs) != 1 || !strings.HasSuffix(msgs[0], ": stop") {
+		t.Errorf("got messages %q, want one ending with \": stop\"", msgs)
+	}
+	m.Type("x")
+	if !terminated {
+		t.Error("session wasn't terminated")
+	}
+}
@@ -0,0 +1,208 @@
+package selection
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/components/selector"
+	"github.com/dustin/go-humanize"
+)
+
+// sessionsInterval is how often the sessions are refreshed while listed.
+const sessionsInterval = time.Second
+
+var (
+	messageSession = key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "message"),
+	)
+	terminateSession = key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "terminate"),
+	)
+)
+
+// SessionsMsg is a message that contains the connected sessions.
+type SessionsMsg []selector.IdentifiableItem
+
+// sessionsTickMsg refreshes the sessions.
+type sessionsTickMsg struct{}
+
+// SessionItem is a connected session item.
+type SessionItem struct {
+	config.Session
+	now time.Time
+}
+
+// ID implements selector.IdentifiableItem.
+func (i SessionItem) ID() string {
+	return i.Session.ID
+}
+
+// Title implements list.DefaultItem.
+func (i SessionItem) Title() string {
+	return i.User
+}
+
+// Description implements list.DefaultItem.
+func (i SessionItem) Description() string {
+	if i.Repo == "" {
+		return i.Operation
+	}
+	return i.Operation + " " + i.Repo
+}
+
+// FilterValue implements list.Item.
+func (i SessionItem) FilterValue() string { return i.User }
+
+// SessionItemDelegate is the delegate for the session item.
+type SessionItemDelegate struct {
+	common *common.Common
+}
+
+// Height implements list.ItemDelegate.
+func (d SessionItemDelegate) Height() int { return 2 }
+
+// Spacing implements list.ItemDelegate.
+func (d SessionItemDelegate) Spacing() int { return 1 }
+
+// Update implements list.ItemDelegate.
+func (d SessionItemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd {
+	return nil
+}
+
+// Render implements list.ItemDelegate.
+func (d SessionItemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
+	s := d.common.Styles.Ref
+	i, ok := listItem.(SessionItem)
+	if !ok {
+		return
+	}
+
+	st := s.Normal.Item
+	selector := "  "
+	if index == m.Index() {
+		st = s.Active.Item
+		selector = s.ItemSelector.String()
+	}
+
+	op := d.common.Styles.RepoSelector.BadgeKind.Render(i.Operation)
+	info := fmt.Sprintf(" %s ↓%s ↑%s", i.Duration(i.now).Round(time.Second),
+		humanize.Bytes(uint64(i.In)), humanize.Bytes(uint64(i.Out)))
+	maxWidth := m.Width() -
+		s.ItemSelector.GetMarginLeft() -
+		s.ItemSelector.GetWidth() -
+		s.Normal.Item.GetMarginLeft()
+	user := common.TruncateString(i.User, maxWidth-lipgloss.Width(op)-lipgloss.Width(info)-1)
+	line := st.Render(user) + " " + op +
+		d.common.Styles.RepoSelector.Normal.Updated.Render(info)
+
+	desc := i.RemoteAddr
+	if i.Repo != "" {
+		desc += " " + i.Repo
+	}
+	desc = common.TruncateString(desc, maxWidth)
+	desc = d.common.Styles.RepoSelector.Normal.Desc.Render(desc)
+
+	s2 := strings.Builder{}
+	s2.WriteString(fmt.Sprint(selector, line))
+	s2.WriteRune('\n')
+	s2.WriteString(fmt.Sprint("  ", desc))
+	fmt.Fprint(w,
+		d.common.Zone.Mark(
+			i.ID(),
+			s2.String(),
+		),
+	)
+}
+
+func newMessagePrompt() textinput.Model {
+	ti := textinput.New()
+	ti.Prompt = "> "
+	ti.Placeholder = "message to the user of the session"
+	ti.CharLimit = 256
+	return ti
+}
+
+// IsPrompting returns true if the page is taking text input, so that keys
+// like q shouldn't be handled as shortcuts.
+func (s *Selection) IsPrompting() bool {
+	return s.prompting
+}
+
+// updatePrompt handles key presses while the message prompt is open.
+func (s *Selection) updatePrompt(msg tea.KeyMsg) tea.Cmd {
+	switch msg.Type {
+	case tea.KeyEsc:
+		s.prompting = false
+		s.prompt.Blur()
+		return nil
+	case tea.KeyEnter:
+		s.prompting = false
+		s.prompt.Blur()
+		text := strings.TrimSpace(s.prompt.Value())
+		i, ok := s.selectedSession()
+		if text == "" || !ok {
+			return nil
+		}
+		return s.messageSessionCmd(i.Session.ID, text)
+	}
+	p, cmd := s.prompt.Update(msg)
+	s.prompt = p
+	return cmd
+}
+
+// updateSessionsCmd lists the connected sessions.
+func (s *Selection) updateSessionsCmd() tea.Msg {
+	now := time.Now()
+	ss := s.cfg.Sessions()
+	items := make([]selector.IdentifiableItem, len(ss))
+	for i, ses := range ss {
+		items[i] = SessionItem{ses, now}
+	}
+	return SessionsMsg(items)
+}
+
+// sessionsTickCmd refreshes the sessions after sessionsInterval.
+func sessionsTickCmd() tea.Cmd {
+	return tea.Tick(sessionsInterval, func(time.Time) tea.Msg {
+		return sessionsTickMsg{}
+	})
+}
+
+// selectedSession returns the selected session, if any.
+func (s *Selection) selectedSession() (SessionItem, bool) {
+	i, ok := s.sessions.SelectedItem().(SessionItem)
+	return i, ok
+}
+
+// messageSessionCmd shows a message to the user of the session id.
+func (s *Selection) messageSessionCmd(id, text string) tea.Cmd {
+	return func() tea.Msg {
+		if err := s.cfg.MessageSession(id, s.pk, text); err != nil {
+			return common.ErrorMsg(err)
+		}
+		return nil
+	}
+}
+
+// terminateSessionCmd disconnects the session id and refreshes the list.
+func (s *Selection) terminateSessionCmd(id string) tea.Cmd {
+	return func() tea.Msg {
+		// Sessions that already ended are just gone from the list.
+		if err := s.cfg.TerminateSession(id); err != nil && !errors.Is(err, config.ErrUnknownSession) {
+			return common.ErrorMsg(err)
+		}
+		return s.updateSessionsCmd()
+	}
+}
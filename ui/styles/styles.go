@@ -14,6 +14,7 @@ type Styles struct {
 
 	App                  lipgloss.Style
 	ServerName           lipgloss.Style
+	Banner               lipgloss.Style
 	TopLevelNormalTab    lipgloss.Style
 	TopLevelActiveTab    lipgloss.Style
 	TopLevelActiveTabDot lipgloss.Style
@@ -36,8 +37,13 @@ type Styles struct {
 			Command lipgloss.Style
 			Updated lipgloss.Style
 		}
+		BadgeEmpty lipgloss.Style
+		BadgeError lipgloss.Style
+		BadgeKind  lipgloss.Style
 	}
 
+	AccessBadge lipgloss.Style
+
 	Repo struct {
 		Base       lipgloss.Style
 		Title      lipgloss.Style
@@ -163,6 +169,15 @@ func DefaultStyles() *Styles {
 		Foreground(lipgloss.Color("229")).
 		Bold(true)
 
+	s.Banner = lipgloss.NewStyle().
+		Height(1).
+		MarginLeft(1).
+		MarginBottom(1).
+		Padding(0, 1).
+		Background(lipgloss.Color("214")).
+		Foreground(lipgloss.Color("235")).
+		Bold(true)
+
 	s.TopLevelNormalTab = lipgloss.NewStyle().
 		MarginRight(2)
 
@@ -204,6 +219,18 @@ func DefaultStyles() *Styles {
 	s.RepoSelector.Active.Command = s.RepoSelector.Normal.Command.Copy().
 		Foreground(lipgloss.Color("204"))
 
+	s.RepoSelector.BadgeEmpty = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("243")).
+		Italic(true)
+
+	s.RepoSelector.BadgeError = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("203")).
+		Italic(true)
+
+	s.RepoSelector.BadgeKind = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("111")).
+		Italic(true)
+
 	s.MenuItem = lipgloss.NewStyle().
 		PaddingLeft(1).
 		Border(lipgloss.Border{
@@ -226,6 +253,10 @@ func DefaultStyles() *Styles {
 	s.Repo.Body = lipgloss.NewStyle().
 		Margin(1, 0)
 
+	s.AccessBadge = lipgloss.NewStyle().
+		Foreground(lipgloss.Color("150")).
+		Italic(true)
+
 	s.Repo.Header = lipgloss.NewStyle().
 		Height(2).
 		Border(lipgloss.NormalBorder(), false, false, true, false).
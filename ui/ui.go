@@ -1,11 +1,15 @@
 package ui
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/events"
 	"github.com/charmbracelet/soft-serve/ui/common"
 	"github.com/charmbracelet/soft-serve/ui/components/footer"
 	"github.com/charmbracelet/soft-serve/ui/components/header"
@@ -13,6 +17,7 @@ import (
 	"github.com/charmbracelet/soft-serve/ui/git"
 	"github.com/charmbracelet/soft-serve/ui/pages/repo"
 	"github.com/charmbracelet/soft-serve/ui/pages/selection"
+	wgit "github.com/charmbracelet/wish/git"
 	"github.com/gliderlabs/ssh"
 )
 
@@ -37,6 +42,7 @@ type UI struct {
 	session     ssh.Session
 	rs          git.GitRepoSource
 	initialRepo string
+	initialPath string
 	common      common.Common
 	pages       []common.Component
 	activePage  page
@@ -45,10 +51,23 @@ type UI struct {
 	footer      *footer.Footer
 	showFooter  bool
 	error       error
+	events      <-chan events.Event
+	// repo is the name of the repository open in the repo page.
+	repo string
+	// banner is the admin message or maintenance notice shown above the
+	// pages.
+	banner string
+	// message is the last message sent by an admin to the session, or
+	// warning about expiring access, shown until dismissed with the Back key.
+	message string
 }
 
-// New returns a new UI model.
-func New(cfg *config.Config, s ssh.Session, c common.Common, initialRepo string) *UI {
+// MessageMsg is a message sent by an admin to the session.
+type MessageMsg string
+
+// New returns a new UI model. If initialRepo is set, the UI opens that
+// repository, scoped to initialPath when it's not empty.
+func New(cfg *config.Config, s ssh.Session, c common.Common, initialRepo, initialPath string) *UI {
 	src := &source{cfg.Source}
 	h := header.New(c, cfg.Name)
 	ui := &UI{
@@ -61,8 +80,12 @@ func New(cfg *config.Config, s ssh.Session, c common.Common, initialRepo string)
 		state:       startState,
 		header:      h,
 		initialRepo: initialRepo,
+		initialPath: initialPath,
 		showFooter:  true,
 	}
+	if cfg.Events != nil {
+		ui.events = cfg.Events.Subscribe(s.Context())
+	}
 	ui.footer = footer.New(c, ui)
 	return ui
 }
@@ -75,6 +98,10 @@ func (ui *UI) getMargins() (wm, hm int) {
 			ui.common.Styles.ServerName.GetVerticalFrameSize()
 	case repoPage:
 	}
+	if ui.banner != "" {
+		hm += ui.common.Styles.Banner.GetHeight() +
+			ui.common.Styles.Banner.GetVerticalFrameSize()
+	}
 	wm += style.GetHorizontalFrameSize()
 	hm += style.GetVerticalFrameSize()
 	if ui.showFooter {
@@ -143,6 +170,7 @@ func (ui *UI) Init() tea.Cmd {
 	)
 	ui.pages[repoPage] = repo.New(
 		ui.cfg,
+		ui.session.PublicKey(),
 		ui.common,
 	)
 	ui.SetSize(ui.common.Width, ui.common.Height)
@@ -154,15 +182,25 @@ func (ui *UI) Init() tea.Cmd {
 	if ui.initialRepo != "" {
 		cmds = append(cmds, ui.initialRepoCmd(ui.initialRepo))
 	}
+	if ui.events != nil {
+		cmds = append(cmds, ui.waitForEventCmd)
+	}
 	ui.state = loadedState
+	ui.updateBanner()
 	ui.SetSize(ui.common.Width, ui.common.Height)
 	return tea.Batch(cmds...)
 }
 
-// IsFiltering returns true if the selection page is filtering.
+// IsFiltering returns true if the selection page is filtering, or the
+// selection or repo page is taking text input.
 func (ui *UI) IsFiltering() bool {
-	if ui.activePage == selectionPage {
-		if s, ok := ui.pages[selectionPage].(*selection.Selection); ok && s.FilterState() == list.Filtering {
+	switch ui.activePage {
+	case selectionPage:
+		if s, ok := ui.pages[selectionPage].(*selection.Selection); ok && (s.FilterState() == list.Filtering || s.IsPrompting()) {
+			return true
+		}
+	case repoPage:
+		if r, ok := ui.pages[repoPage].(*repo.Repo); ok && r.IsPrompting() {
 			return true
 		}
 	}
@@ -185,13 +223,17 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg, tea.MouseMsg:
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
+			if key.Matches(msg, ui.common.KeyMap.Back) && ui.message != "" {
+				ui.message = ""
+				return ui, nil
+			}
 			switch {
 			case key.Matches(msg, ui.common.KeyMap.Back) && ui.error != nil:
 				ui.error = nil
 				ui.state = loadedState
 				// Always show the footer on error.
 				ui.showFooter = ui.footer.ShowAll()
-			case key.Matches(msg, ui.common.KeyMap.Help):
+			case key.Matches(msg, ui.common.KeyMap.Help) && !ui.IsFiltering():
 				cmds = append(cmds, footer.ToggleFooterCmd)
 			case key.Matches(msg, ui.common.KeyMap.Quit):
 				if !ui.IsFiltering() {
@@ -199,8 +241,9 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					ui.common.Zone.Close()
 					return ui, tea.Quit
 				}
-			case ui.activePage == repoPage && key.Matches(msg, ui.common.KeyMap.Back):
+			case ui.activePage == repoPage && key.Matches(msg, ui.common.KeyMap.Back) && !ui.IsFiltering():
 				ui.activePage = selectionPage
+				ui.repo = ""
 				// Always show the footer on selection page.
 				ui.showFooter = true
 			}
@@ -213,27 +256,78 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 		}
+	case MessageMsg:
+		ui.message = string(msg)
 	case footer.ToggleFooterMsg:
 		ui.footer.SetShowAll(!ui.footer.ShowAll())
 		// Show the footer when on repo page and shot all help.
 		if ui.error == nil && ui.activePage == repoPage {
 			ui.showFooter = !ui.showFooter
 		}
+	case events.Event:
+		// Pages need to know about pushes and config changes even when
+		// they're not active.
+		for _, p := range []page{selectionPage, repoPage} {
+			if p == ui.activePage && ui.state == loadedState {
+				continue
+			}
+			m, cmd := ui.pages[p].Update(msg)
+			ui.pages[p] = m.(common.Component)
+			if cmd != nil {
+				cmds = append(cmds, cmd)
+			}
+		}
+		if msg.Type == events.ConfigUpdated {
+			ui.header.SetText(ui.cfg.Name)
+		}
+		// Warn those who can extend the access of collaborators before it
+		// expires.
+		if msg.Type == events.CollabExpiring && msg.Expires != nil &&
+			ui.cfg.HasRole(ui.session.PublicKey(), config.RoleUserAdmin) {
+			ui.message = fmt.Sprintf("Collaborator access of %s to %s expires %s",
+				msg.User, msg.Repo, msg.Expires.UTC().Format("2006-01-02 15:04 MST"))
+		}
+		// Let the admins of the repo know there's a request to approve.
+		if msg.Type == events.AccessRequested &&
+			ui.cfg.AuthRepo(msg.Repo, ui.session.PublicKey()) >= wgit.AdminAccess {
+			ui.message = fmt.Sprintf("%s requested access to %s", msg.User, msg.Repo)
+		}
+		cmds = append(cmds, ui.waitForEventCmd)
 	case repo.RepoMsg:
 		ui.activePage = repoPage
+		ui.repo = git.GitRepo(msg).Repo()
 		// Show the footer on repo page if show all is set.
 		ui.showFooter = ui.footer.ShowAll()
+		// The initial path only applies to the first repository opened.
+		if p := ui.initialPath; p != "" {
+			ui.initialPath = ""
+			cmds = append(cmds, func() tea.Msg {
+				return repo.ScopeMsg(p)
+			})
+		}
 	case common.ErrorMsg:
-		ui.error = msg
+		// Keep the first error, later ones are usually caused by it.
+		if ui.error == nil {
+			ui.error = msg
+		}
 		ui.state = errorState
 		ui.showFooter = true
 		return ui, nil
 	case selector.SelectMsg:
-		switch msg.IdentifiableItem.(type) {
+		switch item := msg.IdentifiableItem.(type) {
 		case selection.Item:
-			if ui.activePage == selectionPage {
+			// Repos listed for access requests can't be opened.
+			if ui.activePage == selectionPage && item.Readable() {
 				cmds = append(cmds, ui.setRepoCmd(msg.ID()))
 			}
+		case selection.ReleaseItem:
+			if ui.activePage == selectionPage {
+				cmds = append(cmds, ui.setRepoCmd(item.Repo))
+			}
+		case selection.NotificationItem:
+			if ui.activePage == selectionPage {
+				cmds = append(cmds, ui.setRepoCmd(item.Repo))
+			}
 		}
 	}
 	h, cmd := ui.header.Update(msg)
@@ -253,6 +347,7 @@ func (ui *UI) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			cmds = append(cmds, cmd)
 		}
 	}
+	ui.updateBanner()
 	// This fixes determining the height margin of the footer.
 	ui.SetSize(ui.common.Width, ui.common.Height)
 	return ui, tea.Batch(cmds...)
@@ -284,12 +379,29 @@ func (ui *UI) View() string {
 	if ui.activePage == selectionPage {
 		view = lipgloss.JoinVertical(lipgloss.Left, ui.header.View(), view)
 	}
+	if ui.banner != "" {
+		banner := ui.common.Styles.Banner.Copy().
+			MaxWidth(ui.common.Width - wm).
+			Render(ui.banner)
+		view = lipgloss.JoinVertical(lipgloss.Left, banner, view)
+	}
 	if ui.showFooter {
 		view = lipgloss.JoinVertical(lipgloss.Left, view, ui.footer.View())
 	}
-	return ui.common.Zone.Scan(
+	view = ui.common.Zone.Scan(
 		ui.common.Styles.App.Render(view),
 	)
+	return common.RenderLinks(view, ui.cfg.Cfg != nil && ui.cfg.Cfg.Hyperlinks)
+}
+
+// updateBanner shows the message of an admin, if any, or the notice of the
+// maintenance window of the repo, as windows open and close as time goes by.
+func (ui *UI) updateBanner() {
+	if ui.message != "" {
+		ui.banner = fmt.Sprintf("%s (%s to dismiss)", ui.message, ui.common.KeyMap.Back.Help().Key)
+		return
+	}
+	ui.banner = ui.cfg.MaintenanceBanner(ui.repo, time.Now())
 }
 
 func (ui *UI) setRepoCmd(rn string) tea.Cmd {
@@ -303,6 +415,15 @@ func (ui *UI) setRepoCmd(rn string) tea.Cmd {
 	}
 }
 
+// waitForEventCmd waits for the next server event.
+func (ui *UI) waitForEventCmd() tea.Msg {
+	e, ok := <-ui.events
+	if !ok {
+		return nil
+	}
+	return e
+}
+
 func (ui *UI) initialRepoCmd(rn string) tea.Cmd {
 	return func() tea.Msg {
 		for _, r := range ui.rs.AllRepos() {
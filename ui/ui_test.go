@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/soft-serve/chaos"
+	"github.com/charmbracelet/soft-serve/config"
+	"github.com/charmbracelet/soft-serve/ui/uitest"
+	"github.com/gliderlabs/ssh"
+)
+
+// session is an anonymous SSH session.
+type session struct {
+	ssh.Session
+}
+
+func (session) PublicKey() ssh.PublicKey { return nil }
+
+func TestErrorGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	cfg.Events = nil
+	cfg.Source = chaos.Source(cfg.Source, &chaos.Injector{
+		Every: 1,
+		Ops:   []string{"HEAD"},
+	})
+	for _, size := range []struct{ width, height int }{{60, 20}, {80, 24}} {
+		t.Run(fmt.Sprintf("%dx%d", size.width, size.height), func(t *testing.T) {
+			c := uitest.Common(t, size.width, size.height)
+			m := uitest.New(t, New(cfg, session{}, c, "soft-serve", ""), c, spinner.TickMsg{})
+			m.RequireGolden("error")
+		})
+	}
+}
+
+func TestMaintenanceGolden(t *testing.T) {
+	cfg := uitest.Config(t, uitest.Repos)
+	cfg.Events = nil
+	cfg.Maintenance = []config.Maintenance{{
+		Start:   time.Now().Add(-time.Hour),
+		Message: "Moving to new storage",
+	}}
+	c := uitest.Common(t, 80, 24)
+	m := uitest.New(t, New(cfg, session{}, c, "", ""), c)
+	m.RequireGolden("banner")
+}
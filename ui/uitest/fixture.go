@@ -0,0 +1,182 @@
+package uitest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/soft-serve/config"
+	sconfig "github.com/charmbracelet/soft-serve/server/config"
+	"github.com/gliderlabs/ssh"
+	"github.com/go-git/go-billy/v5/memfs"
+	ggit "github.com/go-git/go-git/v5"
+	gconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// Commit is a commit of a fixture repo.
+type Commit struct {
+	Message string
+	// Files maps paths to contents. An empty content deletes the file.
+	Files map[string]string
+	// Tag, if set, tags the commit.
+	Tag string
+}
+
+// Epoch is the time of the first fixture commit. Later commits are a day
+// apart. It's old enough for relative times to read "a long while ago",
+// which keeps golden files from changing as time passes.
+var Epoch = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+const adminKey = "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIMJlb/qf2B2kMNdBxfpCQqI2ctPcsOkdZGVh5zTRhKtH"
+
+// AdminKey returns the public key of the admin of the configurations
+// returned by Config.
+func AdminKey(t testing.TB) ssh.PublicKey {
+	t.Helper()
+	pk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(adminKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pk
+}
+
+// Config returns a configuration with a repo for each entry of repos, made
+// of the given commits. The config repo is private and anonymous users have
+// read-only access, so it's only listed for admins.
+func Config(t testing.TB, repos map[string][]Commit) *config.Config {
+	t.Helper()
+	cfg, err := config.NewConfig(&sconfig.Config{
+		Host:             "localhost",
+		Port:             23231,
+		RepoPath:         t.TempDir(),
+		KeyPath:          filepath.Join(t.TempDir(), "key"),
+		InitialAdminKeys: []string{adminKey},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, commits := range repos {
+		if _, err := cfg.Source.InitRepo(name, true); err != nil {
+			t.Fatal(err)
+		}
+		if err := commit(filepath.Join(cfg.Source.Dir(), name), commits); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := cfg.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+// Push pushes commits to a repo of cfg, creating the repo if it doesn't
+// exist, and reloads cfg.
+func Push(t testing.TB, cfg *config.Config, repo string, commits ...Commit) {
+	t.Helper()
+	if _, err := cfg.Source.GetRepo(repo); err != nil {
+		if _, err := cfg.Source.InitRepo(repo, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := commit(filepath.Join(cfg.Source.Dir(), repo), commits); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Reload(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commit pushes commits to the repo at path.
+func commit(path string, commits []Commit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	r, err := ggit.Clone(memory.NewStorage(), memfs.New(), &ggit.CloneOptions{
+		URL: path,
+	})
+	if err != nil && err != transport.ErrEmptyRemoteRepository {
+		return err
+	}
+	wt, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+	for i, c := range commits {
+		for name, content := range c.Files {
+			if content == "" {
+				if _, err := wt.Remove(name); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := wt.Filesystem.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+				return err
+			}
+			f, err := wt.Filesystem.Create(name)
+			if err != nil {
+				return err
+			}
+			if _, err := f.Write([]byte(content)); err != nil {
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			if _, err := wt.Add(name); err != nil {
+				return err
+			}
+		}
+		sig := &object.Signature{
+			Name:  "Soft Serve",
+			Email: "vt100@charm.sh",
+			When:  Epoch.AddDate(0, 0, i),
+		}
+		h, err := wt.Commit(c.Message, &ggit.CommitOptions{
+			Author:    sig,
+			Committer: sig,
+		})
+		if err != nil {
+			return err
+		}
+		if c.Tag != "" {
+			if _, err := r.CreateTag(c.Tag, h, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return r.Push(&ggit.PushOptions{
+		RefSpecs: []gconfig.RefSpec{
+			"refs/heads/*:refs/heads/*",
+			"refs/tags/*:refs/tags/*",
+		},
+	})
+}
+
+// Repos are the repos used by the UI golden tests.
+var Repos = map[string][]Commit{
+	"soft-serve": {
+		{
+			Message: "Initial commit",
+			Files: map[string]string{
+				"README.md": "# Soft Serve\n\nA tasty, self-hostable Git server for the command line.\n",
+				"go.mod":    "module github.com/charmbracelet/soft-serve\n\ngo 1.17\n",
+			},
+		},
+		{
+			Message: "Add main package\n\nThis adds the soft binary.",
+			Files: map[string]string{
+				"cmd/soft/main.go": "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"Soft Serve\")\n}\n",
+			},
+		},
+		{
+			Message: "Update README",
+			Files: map[string]string{
+				"README.md": "# Soft Serve\n\nA tasty, self-hostable Git server for the command line. 🍦\n\n## Installation\n\n    go install github.com/charmbracelet/soft-serve/cmd/soft@latest\n",
+			},
+		},
+	},
+	"empty": nil,
+}
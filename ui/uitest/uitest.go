@@ -0,0 +1,261 @@
+// Package uitest runs UI models in tests and compares their views to golden
+// files.
+//
+// Views are rendered without colors and compared to
+// testdata/<TestName>/<name>.golden. Run the tests with -update to rewrite
+// the golden files after an intended change, and review the diff.
+package uitest
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/soft-serve/ui/common"
+	"github.com/charmbracelet/soft-serve/ui/keymap"
+	"github.com/charmbracelet/soft-serve/ui/styles"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/muesli/termenv"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var (
+	// settle is how long the model must go without messages for it to be
+	// considered idle.
+	settle = 200 * time.Millisecond
+	// timeout is how long a model may take to become idle.
+	timeout = 10 * time.Second
+)
+
+func init() {
+	lipgloss.SetColorProfile(termenv.Ascii)
+	lipgloss.SetHasDarkBackground(true)
+}
+
+// Common returns the common.Common of a terminal of the given size. Its zone
+// manager is closed when the test finishes.
+func Common(t testing.TB, width, height int) common.Common {
+	z := zone.New()
+	t.Cleanup(z.Close)
+	return common.Common{
+		Styles: styles.DefaultStyles(),
+		KeyMap: keymap.DefaultKeyMap(),
+		Width:  width,
+		Height: height,
+		Zone:   z,
+	}
+}
+
+// Model drives a tea.Model the way a tea.Program would, running the
+// commands it returns and feeding their messages back until it's idle.
+type Model struct {
+	t      testing.TB
+	m      tea.Model
+	zone   *zone.Manager
+	ignore map[reflect.Type]bool
+	msgs   chan tea.Msg
+}
+
+// New initializes m, sizes it, and waits for it to be idle. c must be the
+// common.Common m was created with. The Model must only be used by the test
+// t, not by its subtests. Messages of the same types as ignore,
+// such as spinner ticks, are dropped to keep the model from never being
+// idle.
+func New(t testing.TB, m tea.Model, c common.Common, ignore ...tea.Msg) *Model {
+	t.Helper()
+	tm := &Model{
+		t:      t,
+		m:      m,
+		zone:   c.Zone,
+		ignore: make(map[reflect.Type]bool),
+		msgs:   make(chan tea.Msg, 64),
+	}
+	for _, msg := range ignore {
+		tm.ignore[reflect.TypeOf(msg)] = true
+	}
+	tm.exec(m.Init())
+	tm.Resize(c.Width, c.Height)
+	return tm
+}
+
+// Resize resizes the model the way the UI does when the terminal is resized.
+func (m *Model) Resize(width, height int) {
+	m.t.Helper()
+	if s, ok := m.m.(interface{ SetSize(int, int) }); ok {
+		s.SetSize(width, height)
+	}
+	m.Send(tea.WindowSizeMsg{Width: width, Height: height})
+}
+
+// Send sends messages to the model and waits for it to be idle.
+func (m *Model) Send(msgs ...tea.Msg) {
+	m.t.Helper()
+	for _, msg := range msgs {
+		m.update(msg)
+	}
+	m.wait()
+}
+
+// Type sends a key press for each key. Keys are either names, such as
+// "enter" or "tab", or single characters.
+func (m *Model) Type(keys ...string) {
+	m.t.Helper()
+	for _, k := range keys {
+		m.update(Key(k))
+		m.wait()
+	}
+}
+
+// View returns the view of the model without zone markers and links, as
+// shown when hyperlinks are disabled.
+func (m *Model) View() string {
+	v := m.m.View()
+	if m.zone != nil {
+		v = m.zone.Scan(v)
+	}
+	return common.RenderLinks(v, false)
+}
+
+// RequireGolden fails the test if the view of the model doesn't match the
+// golden file name.
+func (m *Model) RequireGolden(name string) {
+	m.t.Helper()
+	RequireGolden(m.t, name, m.View())
+}
+
+func (m *Model) update(msg tea.Msg) {
+	nm, cmd := m.m.Update(msg)
+	m.m = nm
+	m.exec(cmd)
+}
+
+// exec runs cmd in the background and sends its message to m.msgs.
+func (m *Model) exec(cmd tea.Cmd) {
+	if cmd == nil {
+		return
+	}
+	go func() {
+		m.msgs <- cmd()
+	}()
+}
+
+// wait delivers messages to the model until it's idle.
+func (m *Model) wait() {
+	m.t.Helper()
+	deadline := time.After(timeout)
+	for {
+		select {
+		case msg := <-m.msgs:
+			m.handle(msg)
+		case <-time.After(settle):
+			return
+		case <-deadline:
+			m.t.Fatalf("model didn't become idle within %s", timeout)
+		}
+	}
+}
+
+func (m *Model) handle(msg tea.Msg) {
+	if msg == nil || m.ignore[reflect.TypeOf(msg)] {
+		return
+	}
+	if msg, ok := msg.(tea.BatchMsg); ok {
+		for _, cmd := range msg {
+			m.exec(cmd)
+		}
+		return
+	}
+	// tea.Sequence returns an unexported message type.
+	if v := reflect.ValueOf(msg); v.Kind() == reflect.Slice && v.Type().Elem() == reflect.TypeOf(tea.Cmd(nil)) {
+		cmds := make([]tea.Cmd, v.Len())
+		for i := range cmds {
+			cmds[i] = v.Index(i).Interface().(tea.Cmd)
+		}
+		go func() {
+			for _, cmd := range cmds {
+				if cmd != nil {
+					m.msgs <- cmd()
+				}
+			}
+		}()
+		return
+	}
+	m.update(msg)
+}
+
+// Key returns the key press of a key name, such as "enter", or a single
+// character.
+func Key(k string) tea.KeyMsg {
+	for t, name := range keyNames {
+		if name == k {
+			return tea.KeyMsg{Type: t}
+		}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+var keyNames = map[tea.KeyType]string{
+	tea.KeyEnter:     "enter",
+	tea.KeyTab:       "tab",
+	tea.KeyShiftTab:  "shift+tab",
+	tea.KeyEsc:       "esc",
+	tea.KeyUp:        "up",
+	tea.KeyDown:      "down",
+	tea.KeyLeft:      "left",
+	tea.KeyRight:     "right",
+	tea.KeyBackspace: "backspace",
+	tea.KeySpace:     " ",
+}
+
+// RequireGolden fails the test if got doesn't match the golden file name of
+// the test, testdata/<TestName>/<name>.golden. With -update, it writes got
+// to the golden file instead.
+func RequireGolden(t testing.TB, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name()), name+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the tests with -update to create it)", err)
+	}
+	if !bytes.Equal(want, []byte(got)) {
+		t.Fatalf("view doesn't match %s (run the tests with -update to update it):\n%s", path, diff(string(want), got))
+	}
+}
+
+// diff returns the lines of want and got that differ.
+func diff(want, got string) string {
+	wl := strings.Split(want, "\n")
+	gl := strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g string
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if w != g {
+			b.WriteString("-" + w + "\n")
+			b.WriteString("+" + g + "\n")
+		}
+	}
+	return b.String()
+}